@@ -0,0 +1,137 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gosom/scrapemate"
+	parser "github.com/gosom/scrapemate/adapters/parsers/goqueryparser"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
+	"github.com/gosom/scrapemate/scrapemateapp"
+	"golang.org/x/sync/errgroup"
+)
+
+// App runs a JS crawl like scrapemateapp.ScrapemateApp does, fetching the
+// pages through a supervised Pool.
+type App struct {
+	cfg  *scrapemateapp.Config
+	opts []Option
+
+	pool *Pool
+}
+
+// NewApp creates an App for cfg, which must enable JS. The browser count,
+// pages per browser, headless mode, images, user agent and proxies are taken
+// from cfg; opts tune the supervision.
+func NewApp(cfg *scrapemateapp.Config, opts ...Option) (*App, error) {
+	if !cfg.UseJS {
+		return nil, errors.New("browserpool: the app needs JS enabled")
+	}
+
+	pagesPerBrowser := max(cfg.MaxPagesPerBrowser, 1)
+
+	browsers := cfg.BrowserPoolSize
+	if browsers <= 0 {
+		browsers = (cfg.Concurrency + pagesPerBrowser - 1) / pagesPerBrowser
+	}
+
+	base := []Option{
+		WithBrowsers(browsers),
+		WithPagesPerBrowser(pagesPerBrowser),
+		WithUserAgent(cfg.JSOpts.UA),
+		WithProxies(cfg.Proxies),
+	}
+
+	if cfg.JSOpts.Headfull {
+		base = append(base, WithHeadfull())
+	}
+
+	if cfg.JSOpts.DisableImages {
+		base = append(base, WithDisableImages())
+	}
+
+	return &App{cfg: cfg, opts: append(base, opts...)}, nil
+}
+
+// Start launches the browsers and runs the crawl from seedJobs until it is
+// done, ctx ends or the inactivity timeout of the config fires.
+func (a *App) Start(ctx context.Context, seedJobs ...scrapemate.IJob) error {
+	g, ctx := errgroup.WithContext(ctx)
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	defer cancel(errors.New("closing app"))
+
+	pool, err := New(a.opts...)
+	if err != nil {
+		return err
+	}
+
+	a.pool = pool
+
+	provider := a.cfg.Provider
+	if provider == nil {
+		provider = memprovider.New()
+	}
+
+	mate, err := scrapemate.New(
+		scrapemate.WithContext(ctx, nil),
+		scrapemate.WithJobProvider(provider),
+		scrapemate.WithHTTPFetcher(pool),
+		scrapemate.WithHTMLParser(parser.New()),
+		scrapemate.WithConcurrency(a.cfg.Concurrency),
+		scrapemate.WithExitBecauseOfInactivity(a.cfg.ExitOnInactivityDuration),
+	)
+	if err != nil {
+		_ = pool.Close()
+
+		return err
+	}
+
+	// closes the pool too
+	defer mate.Close()
+
+	for i := range a.cfg.Writers {
+		writer := a.cfg.Writers[i]
+
+		g.Go(func() error {
+			if err := writer.Run(ctx, mate.Results()); err != nil {
+				cancel(err)
+
+				return err
+			}
+
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		return mate.Start()
+	})
+
+	g.Go(func() error {
+		for i := range seedJobs {
+			if err := provider.Push(ctx, seedJobs[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// Stats returns the browser failures of the crawl.
+func (a *App) Stats() Stats {
+	if a.pool == nil {
+		return Stats{}
+	}
+
+	return a.pool.Stats()
+}
+
+// Close is a no-op kept for parity with scrapemateapp: the browsers are
+// closed when Start returns.
+func (a *App) Close() error {
+	return nil
+}
@@ -0,0 +1,556 @@
+// Package browserpool runs the JS crawl on several independent browser
+// processes, each driven by its own Playwright driver, and supervises them:
+// a browser that crashes or stops responding is replaced and the jobs it was
+// running are retried, so that one renderer crash no longer takes down a
+// whole run.
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+)
+
+var (
+	// ErrBrowserCrashed is returned for the jobs running on a browser that
+	// exited or could not open a page.
+	ErrBrowserCrashed = errors.New("browser crashed")
+	// ErrBrowserHung is returned for the jobs running on a browser that
+	// stopped answering.
+	ErrBrowserHung = errors.New("browser stopped responding")
+	// ErrPageCrashed is returned for a job whose page renderer crashed.
+	ErrPageCrashed = errors.New("page crashed")
+)
+
+const (
+	// DefaultHangTimeout is how long a job without its own timeout may run
+	// before its browser is considered hung.
+	DefaultHangTimeout = 10 * time.Minute
+	// DefaultMaxRequeues is how many times a job interrupted by a browser
+	// failure is retried.
+	DefaultMaxRequeues = 2
+
+	pingInterval = 30 * time.Second
+	pingTimeout  = 20 * time.Second
+	closeTimeout = 10 * time.Second
+	// hangGrace is added to the timeout of a job before its browser is
+	// considered hung, so that Playwright's own timeouts fire first.
+	hangGrace = 30 * time.Second
+)
+
+// Stats counts the browser failures met by a Pool and the browsers it
+// recycled, with their resource usage.
+type Stats struct {
+	Browsers int `json:"browsers"`
+	// Launches includes the first launch of every browser.
+	Launches    int `json:"launches"`
+	Crashes     int `json:"crashes"`
+	Hangs       int `json:"hangs"`
+	PageCrashes int `json:"page_crashes"`
+	// Requeues is the number of jobs retried after a failure.
+	Requeues int `json:"requeues"`
+	// Recycles counts the browsers replaced by the recycling policy, by
+	// reason: RecyclePages, RecycleAge or RecycleMemory.
+	Recycles map[string]int `json:"recycles,omitempty"`
+	// MemoryBytes is the resident memory of all the browsers at the last
+	// check, PeakMemoryBytes the highest seen. Both stay 0 where the memory
+	// of a process cannot be read (only Linux is supported).
+	MemoryBytes     int64 `json:"memory_bytes"`
+	PeakMemoryBytes int64 `json:"peak_memory_bytes"`
+	// CPUPercent is the CPU used by all the browsers between the last two
+	// checks; 100 is one core.
+	CPUPercent float64 `json:"cpu_percent"`
+}
+
+// launcher starts browser processes.
+type launcher interface {
+	launch() (instance, error)
+}
+
+// instance is a running browser process.
+type instance interface {
+	newPage(timeout time.Duration) (page, error)
+	// ping makes a round trip to the browser.
+	ping() error
+	// exited is closed when the browser process goes away.
+	exited() <-chan struct{}
+	// usage measures the processes of the browser.
+	usage() (usage, error)
+	close()
+}
+
+// usage is the resident memory and the CPU time used so far by the
+// processes of a browser.
+type usage struct {
+	rssBytes   int64
+	cpuSeconds float64
+}
+
+// page is a tab of an instance.
+type page interface {
+	browserPage() scrapemate.BrowserPage
+	crashed() bool
+	close()
+}
+
+// Pool is a scrapemate.HTTPFetcher spreading the jobs over browsers
+// processes, each hosting up to pagesPerBrowser jobs at a time.
+type Pool struct {
+	launcher        launcher
+	browsers        int
+	pagesPerBrowser int
+	hangTimeout     time.Duration
+	maxRequeues     int
+	pingInterval    time.Duration
+	recycle         recyclePolicy
+
+	workers []*worker
+	slots   chan *worker
+
+	mu    sync.Mutex
+	stats Stats
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+var _ scrapemate.HTTPFetcher = (*Pool)(nil)
+
+// worker is the seat of one browser process, relaunched after a failure.
+type worker struct {
+	id int
+
+	mu      sync.Mutex
+	current *browserInstance
+}
+
+// browserInstance is the instance a worker currently runs. retired is closed
+// when the pool gives up on it, so that the jobs still running on it stop
+// waiting even when its driver is wedged.
+type browserInstance struct {
+	instance
+
+	retired chan struct{}
+	// reason is why it was retired, set before retired is closed.
+	reason error
+
+	launchedAt time.Time
+
+	// guarded by the worker mutex
+	pages    int
+	active   int
+	draining bool
+
+	// last usage sample, only touched by the supervisor
+	sample     usage
+	sampleAt   time.Time
+	cpuPercent float64
+}
+
+// New launches the browsers and starts supervising them.
+func New(opts ...Option) (*Pool, error) {
+	cfg := defaultConfig()
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	l, err := newPlaywrightLauncher(cfg.launch)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPool(l, cfg)
+}
+
+func newPool(l launcher, cfg config) (*Pool, error) {
+	p := Pool{
+		launcher:        l,
+		browsers:        cfg.browsers,
+		pagesPerBrowser: cfg.pagesPerBrowser,
+		hangTimeout:     cfg.hangTimeout,
+		maxRequeues:     cfg.maxRequeues,
+		pingInterval:    cfg.pingInterval,
+		recycle:         cfg.recycle,
+		slots:           make(chan *worker, cfg.browsers*cfg.pagesPerBrowser),
+		stop:            make(chan struct{}),
+	}
+
+	p.stats.Browsers = cfg.browsers
+
+	for i := range cfg.browsers {
+		w := &worker{id: i + 1}
+
+		if _, err := p.instance(w); err != nil {
+			_ = p.Close()
+
+			return nil, err
+		}
+
+		p.workers = append(p.workers, w)
+
+		for range cfg.pagesPerBrowser {
+			p.slots <- w
+		}
+	}
+
+	p.wg.Add(1)
+
+	go p.supervise()
+
+	return &p, nil
+}
+
+// Fetch implements scrapemate.HTTPFetcher. A job interrupted by a browser
+// or page crash, or by a hung browser, is retried up to maxRequeues times.
+func (p *Pool) Fetch(ctx context.Context, job scrapemate.IJob) scrapemate.Response {
+	if t := job.GetTimeout(); t > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		var w *worker
+
+		select {
+		case <-ctx.Done():
+			return scrapemate.Response{Error: ctx.Err()}
+		case w = <-p.slots:
+		}
+
+		resp, failure := p.run(ctx, w, job)
+
+		p.slots <- w
+
+		if failure == nil || ctx.Err() != nil || attempt >= p.maxRequeues {
+			return resp
+		}
+
+		p.count(func(s *Stats) { s.Requeues++ })
+
+		log.Printf("browser %d: %v, requeueing %s", w.id, failure, job.GetURL())
+	}
+}
+
+// run executes the browser actions of job on a page of w. The returned error
+// is set when the job was interrupted by a failure of the browser or of the
+// page and should be retried.
+func (p *Pool) run(ctx context.Context, w *worker, job scrapemate.IJob) (scrapemate.Response, error) {
+	inst, err := p.checkout(w)
+	if err != nil {
+		return scrapemate.Response{Error: err}, err
+	}
+
+	defer p.checkin(w, inst)
+
+	pg, err := inst.newPage(job.GetTimeout())
+	if err != nil {
+		p.retire(w, inst, ErrBrowserCrashed)
+
+		return scrapemate.Response{Error: fmt.Errorf("%w: %v", ErrBrowserCrashed, err)}, ErrBrowserCrashed
+	}
+
+	done := make(chan scrapemate.Response, 1)
+
+	// the actions run on their own goroutine so that a wedged Playwright
+	// call never pins the scrapemate worker
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- scrapemate.Response{Error: fmt.Errorf("browser actions panic: %v", r)}
+			}
+		}()
+
+		done <- runBrowserActions(ctx, job, pg.browserPage())
+	}()
+
+	limit := p.hangTimeout
+	if t := job.GetTimeout(); t > 0 {
+		limit = t + hangGrace
+	}
+
+	watchdog := time.NewTimer(limit)
+	defer watchdog.Stop()
+
+	select {
+	case resp := <-done:
+		closeWithTimeout(pg.close)
+
+		select {
+		case <-inst.exited():
+			p.retire(w, inst, ErrBrowserCrashed)
+
+			return failed(resp, ErrBrowserCrashed), ErrBrowserCrashed
+		default:
+		}
+
+		if pg.crashed() {
+			p.count(func(s *Stats) { s.PageCrashes++ })
+
+			return failed(resp, ErrPageCrashed), ErrPageCrashed
+		}
+
+		return resp, nil
+	case <-inst.exited():
+		p.retire(w, inst, ErrBrowserCrashed)
+
+		return p.abandon(done, ErrBrowserCrashed)
+	case <-inst.retired:
+		return p.abandon(done, inst.reason)
+	case <-watchdog.C:
+		p.retire(w, inst, ErrBrowserHung)
+
+		return p.abandon(done, ErrBrowserHung)
+	case <-ctx.Done():
+		go pg.close()
+
+		return scrapemate.Response{Error: ctx.Err()}, nil
+	}
+}
+
+// abandon waits for the actions of a job whose browser was retired. They
+// are retried only if they returned: actions still running on a wedged
+// driver would race with their retry.
+func (p *Pool) abandon(done <-chan scrapemate.Response, reason error) (scrapemate.Response, error) {
+	timer := time.NewTimer(closeTimeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-done:
+		return failed(resp, reason), reason
+	case <-timer.C:
+		return scrapemate.Response{Error: reason}, nil
+	}
+}
+
+func failed(resp scrapemate.Response, reason error) scrapemate.Response {
+	if resp.Error == nil {
+		resp.Error = reason
+	} else {
+		resp.Error = fmt.Errorf("%w: %v", reason, resp.Error)
+	}
+
+	return resp
+}
+
+func runBrowserActions(ctx context.Context, job scrapemate.IJob, pg scrapemate.BrowserPage) scrapemate.Response {
+	if hooks, ok := pg.(interface{ ClearNetworkHooks() }); ok {
+		defer hooks.ClearNetworkHooks()
+	}
+
+	return job.BrowserActions(ctx, pg)
+}
+
+// instance returns the browser of w, launching a new one after a failure.
+func (p *Pool) instance(w *worker) (*browserInstance, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return p.instanceLocked(w)
+}
+
+func (p *Pool) instanceLocked(w *worker) (*browserInstance, error) {
+	if w.current != nil {
+		return w.current, nil
+	}
+
+	inst, err := p.launcher.launch()
+	if err != nil {
+		return nil, fmt.Errorf("launching browser %d: %w", w.id, err)
+	}
+
+	w.current = &browserInstance{
+		instance:   inst,
+		retired:    make(chan struct{}),
+		launchedAt: time.Now(),
+	}
+
+	p.count(func(s *Stats) { s.Launches++ })
+
+	return w.current, nil
+}
+
+// retire drops inst from w, which gets a new browser on its next job, and
+// closes it in the background.
+func (p *Pool) retire(w *worker, inst *browserInstance, reason error) {
+	w.mu.Lock()
+
+	if w.current != inst {
+		w.mu.Unlock()
+
+		return
+	}
+
+	w.current = nil
+	inst.reason = reason
+
+	w.mu.Unlock()
+
+	close(inst.retired)
+
+	p.count(func(s *Stats) {
+		if errors.Is(reason, ErrBrowserHung) {
+			s.Hangs++
+		} else {
+			s.Crashes++
+		}
+	})
+
+	log.Printf("browser %d: %v, replacing it", w.id, reason)
+
+	go closeWithTimeout(inst.close)
+}
+
+// supervise pings the browsers and retires those that do not answer, which
+// catches a hung driver even while no job notices it, then applies the
+// recycling policy to the others.
+func (p *Pool) supervise() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		var (
+			wg    sync.WaitGroup
+			mu    sync.Mutex
+			total usage
+			cpu   float64
+		)
+
+		for _, w := range p.workers {
+			w.mu.Lock()
+			inst := w.current
+			w.mu.Unlock()
+
+			if inst == nil {
+				continue
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				select {
+				case <-inst.exited():
+					p.retire(w, inst, ErrBrowserCrashed)
+				default:
+					if err := callWithTimeout(inst.ping, pingTimeout); err != nil {
+						p.retire(w, inst, ErrBrowserHung)
+
+						return
+					}
+
+					u, percent := p.watch(w, inst)
+
+					mu.Lock()
+					total.rssBytes += u.rssBytes
+					cpu += percent
+					mu.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		p.count(func(s *Stats) {
+			s.MemoryBytes = total.rssBytes
+			s.PeakMemoryBytes = max(s.PeakMemoryBytes, total.rssBytes)
+			s.CPUPercent = cpu
+		})
+	}
+}
+
+// Stats returns the failures and the resource usage measured so far.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.stats
+	s.Recycles = maps.Clone(p.stats.Recycles)
+
+	return s
+}
+
+func (p *Pool) count(fn func(*Stats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fn(&p.stats)
+}
+
+// Close stops the supervisor and the browsers.
+func (p *Pool) Close() error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+
+	p.wg.Wait()
+
+	var wg sync.WaitGroup
+
+	for _, w := range p.workers {
+		w.mu.Lock()
+		inst := w.current
+		w.current = nil
+		w.mu.Unlock()
+
+		if inst != nil {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				closeWithTimeout(inst.close)
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// closeWithTimeout runs closer and gives up on it after closeTimeout: a
+// wedged driver can block a close forever.
+func closeWithTimeout(closer func()) {
+	_ = callWithTimeout(func() error {
+		closer()
+
+		return nil
+	}, closeTimeout)
+}
+
+func callWithTimeout(fn func() error, d time.Duration) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- fn()
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("no answer after %s", d)
+	}
+}
@@ -0,0 +1,256 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLauncher struct {
+	mu        sync.Mutex
+	instances []*fakeInstance
+	pingErr   error
+	rss       int64
+}
+
+func (l *fakeLauncher) launch() (instance, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inst := &fakeInstance{
+		gone:    make(chan struct{}),
+		closed:  make(chan struct{}),
+		pingErr: l.pingErr,
+		rss:     l.rss,
+	}
+
+	l.instances = append(l.instances, inst)
+
+	return inst, nil
+}
+
+type fakeInstance struct {
+	gone    chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+	pingErr error
+	rss     int64
+}
+
+func (i *fakeInstance) newPage(time.Duration) (page, error) { return &fakePage{inst: i}, nil }
+func (i *fakeInstance) ping() error                         { return i.pingErr }
+func (i *fakeInstance) exited() <-chan struct{}             { return i.gone }
+func (i *fakeInstance) usage() (usage, error)               { return usage{rssBytes: i.rss}, nil }
+func (i *fakeInstance) close()                              { i.once.Do(func() { close(i.closed) }) }
+
+type fakePage struct {
+	scrapemate.BrowserPage
+
+	inst *fakeInstance
+}
+
+func (p *fakePage) browserPage() scrapemate.BrowserPage { return p }
+func (p *fakePage) crashed() bool                       { return false }
+func (p *fakePage) close()                              {}
+
+// fakeJob runs actions with the instance its page belongs to.
+type fakeJob struct {
+	scrapemate.Job
+
+	calls   atomic.Int32
+	actions func(call int, inst *fakeInstance) scrapemate.Response
+}
+
+func (j *fakeJob) BrowserActions(_ context.Context, pg scrapemate.BrowserPage) scrapemate.Response {
+	return j.actions(int(j.calls.Add(1)), pg.(*fakePage).inst)
+}
+
+func testPool(t *testing.T, l *fakeLauncher, cfg config) *Pool {
+	t.Helper()
+
+	pool, err := newPool(l, cfg)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = pool.Close() })
+
+	return pool
+}
+
+func TestPoolRequeuesJobsOfCrashedBrowser(t *testing.T) {
+	pool := testPool(t, &fakeLauncher{}, defaultConfig())
+
+	job := &fakeJob{actions: func(call int, inst *fakeInstance) scrapemate.Response {
+		if call == 1 {
+			close(inst.gone)
+
+			return scrapemate.Response{Error: errors.New("Target page, context or browser has been closed")}
+		}
+
+		return scrapemate.Response{StatusCode: 200}
+	}}
+
+	resp := pool.Fetch(context.Background(), job)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 200, resp.StatusCode)
+
+	require.Equal(t, Stats{Browsers: 1, Launches: 2, Crashes: 1, Requeues: 1}, pool.Stats())
+}
+
+func TestPoolReplacesHungBrowser(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.hangTimeout = 50 * time.Millisecond
+
+	l := &fakeLauncher{}
+	pool := testPool(t, l, cfg)
+
+	job := &fakeJob{actions: func(call int, inst *fakeInstance) scrapemate.Response {
+		if call == 1 {
+			// wedged until the pool closes the browser
+			<-inst.closed
+
+			return scrapemate.Response{Error: errors.New("driver gone")}
+		}
+
+		return scrapemate.Response{StatusCode: 200}
+	}}
+
+	resp := pool.Fetch(context.Background(), job)
+	require.NoError(t, resp.Error)
+
+	require.Equal(t, 1, pool.Stats().Hangs)
+	require.Equal(t, 1, pool.Stats().Requeues)
+	require.Len(t, l.instances, 2)
+}
+
+func TestPoolGivesUpAfterMaxRequeues(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.maxRequeues = 1
+
+	pool := testPool(t, &fakeLauncher{}, cfg)
+
+	job := &fakeJob{actions: func(_ int, inst *fakeInstance) scrapemate.Response {
+		close(inst.gone)
+
+		return scrapemate.Response{}
+	}}
+
+	resp := pool.Fetch(context.Background(), job)
+	require.ErrorIs(t, resp.Error, ErrBrowserCrashed)
+	require.Equal(t, int32(2), job.calls.Load())
+}
+
+func TestSupervisorRetiresUnresponsiveBrowser(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.pingInterval = 10 * time.Millisecond
+
+	l := &fakeLauncher{pingErr: errors.New("no answer")}
+	pool := testPool(t, l, cfg)
+
+	require.Eventually(t, func() bool {
+		return pool.Stats().Hangs > 0
+	}, time.Second, 10*time.Millisecond)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-l.instances[0].closed:
+	case <-time.After(time.Second):
+		t.Fatal("hung browser was not closed")
+	}
+}
+
+func TestPoolRecyclesBrowserAfterPages(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.recycle.pages = 2
+
+	l := &fakeLauncher{}
+	pool := testPool(t, l, cfg)
+
+	var used []*fakeInstance
+
+	job := &fakeJob{actions: func(_ int, inst *fakeInstance) scrapemate.Response {
+		used = append(used, inst)
+
+		return scrapemate.Response{StatusCode: 200}
+	}}
+
+	for range 3 {
+		require.NoError(t, pool.Fetch(context.Background(), job).Error)
+	}
+
+	require.Same(t, used[0], used[1])
+	require.NotSame(t, used[1], used[2])
+
+	select {
+	case <-used[0].closed:
+	case <-time.After(time.Second):
+		t.Fatal("recycled browser was not closed")
+	}
+
+	stats := pool.Stats()
+	require.Equal(t, map[string]int{RecyclePages: 1}, stats.Recycles)
+	require.Zero(t, stats.Crashes)
+	require.Zero(t, stats.Requeues)
+}
+
+func TestPoolDrainsRecycledBrowserBeforeClosingIt(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.pagesPerBrowser = 2
+
+	pool := testPool(t, &fakeLauncher{}, cfg)
+
+	w := pool.workers[0]
+
+	inst, err := pool.checkout(w)
+	require.NoError(t, err)
+
+	pool.drain(w, inst, RecycleAge)
+
+	fake := inst.instance.(*fakeInstance)
+
+	select {
+	case <-fake.closed:
+		t.Fatal("browser closed while a page was still running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	pool.checkin(w, inst)
+
+	select {
+	case <-fake.closed:
+	case <-time.After(time.Second):
+		t.Fatal("drained browser was not closed")
+	}
+}
+
+func TestSupervisorRecyclesBrowserOverMemoryLimit(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.pingInterval = 10 * time.Millisecond
+	cfg.recycle.memoryBytes = 1 << 20
+
+	l := &fakeLauncher{rss: 2 << 20}
+	pool := testPool(t, l, cfg)
+
+	require.Eventually(t, func() bool {
+		return pool.Stats().Recycles[RecycleMemory] > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.Positive(t, pool.Stats().PeakMemoryBytes)
+	require.Zero(t, pool.Stats().Hangs)
+}
+
+func TestStatmResident(t *testing.T) {
+	pages, err := statmResident("1195 316 261 11 0 112 0\n")
+	require.NoError(t, err)
+	require.Equal(t, int64(316), pages)
+
+	_, err = statmResident("")
+	require.Error(t, err)
+}
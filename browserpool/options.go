@@ -0,0 +1,137 @@
+package browserpool
+
+import "time"
+
+type config struct {
+	browsers        int
+	pagesPerBrowser int
+	hangTimeout     time.Duration
+	maxRequeues     int
+	pingInterval    time.Duration
+	recycle         recyclePolicy
+	launch          launchConfig
+}
+
+type launchConfig struct {
+	headless      bool
+	disableImages bool
+	userAgent     string
+	proxies       []string
+}
+
+func defaultConfig() config {
+	return config{
+		browsers:        1,
+		pagesPerBrowser: 1,
+		hangTimeout:     DefaultHangTimeout,
+		maxRequeues:     DefaultMaxRequeues,
+		pingInterval:    pingInterval,
+		recycle: recyclePolicy{
+			pages:       DefaultRecyclePages,
+			age:         DefaultRecycleAfter,
+			memoryBytes: DefaultMemoryLimit,
+		},
+		launch: launchConfig{headless: true},
+	}
+}
+
+// Option configures a Pool.
+type Option func(*config)
+
+// WithBrowsers sets the number of browser processes.
+func WithBrowsers(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.browsers = n
+		}
+	}
+}
+
+// WithPagesPerBrowser sets how many jobs a browser runs at a time, each in
+// its own tab.
+func WithPagesPerBrowser(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.pagesPerBrowser = n
+		}
+	}
+}
+
+// WithHangTimeout sets how long a job without its own timeout may run before
+// its browser is considered hung and replaced.
+func WithHangTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.hangTimeout = d
+		}
+	}
+}
+
+// WithMaxRequeues sets how many times a job interrupted by a browser failure
+// is retried. 0 disables the retries.
+func WithMaxRequeues(n int) Option {
+	return func(c *config) {
+		if n >= 0 {
+			c.maxRequeues = n
+		}
+	}
+}
+
+// WithRecycleAfterPages replaces a browser once it opened n pages. 0 disables
+// the limit.
+func WithRecycleAfterPages(n int) Option {
+	return func(c *config) {
+		if n >= 0 {
+			c.recycle.pages = n
+		}
+	}
+}
+
+// WithRecycleAfter replaces a browser once it has been running for d. 0
+// disables the limit.
+func WithRecycleAfter(d time.Duration) Option {
+	return func(c *config) {
+		if d >= 0 {
+			c.recycle.age = d
+		}
+	}
+}
+
+// WithMemoryLimit replaces a browser once its resident memory goes over
+// bytes. 0 disables the limit.
+func WithMemoryLimit(bytes int64) Option {
+	return func(c *config) {
+		if bytes >= 0 {
+			c.recycle.memoryBytes = bytes
+		}
+	}
+}
+
+// WithHeadfull shows the browser windows.
+func WithHeadfull() Option {
+	return func(c *config) {
+		c.launch.headless = false
+	}
+}
+
+// WithDisableImages stops the browsers from loading images.
+func WithDisableImages() Option {
+	return func(c *config) {
+		c.launch.disableImages = true
+	}
+}
+
+// WithUserAgent sets the user agent of the browsers.
+func WithUserAgent(ua string) Option {
+	return func(c *config) {
+		c.launch.userAgent = ua
+	}
+}
+
+// WithProxies makes each browser go through one of proxies, taken in turn at
+// every launch.
+func WithProxies(proxies []string) Option {
+	return func(c *config) {
+		c.launch.proxies = proxies
+	}
+}
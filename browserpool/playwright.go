@@ -0,0 +1,261 @@
+package browserpool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	playwrightadapter "github.com/gosom/scrapemate/adapters/browsers/playwright"
+	"github.com/gosom/scrapemate/adapters/fetchers/jshttp"
+	"github.com/playwright-community/playwright-go"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// chromiumArgs are the flags scrapemate launches chromium with.
+var chromiumArgs = []string{
+	`--start-maximized`,
+	`--no-default-browser-check`,
+	`--disable-dev-shm-usage`,
+	`--no-sandbox`,
+	`--disable-setuid-sandbox`,
+	`--no-zygote`,
+	`--disable-gpu`,
+	`--mute-audio`,
+	`--disable-extensions`,
+	`--single-process`,
+	`--disable-breakpad`,
+	`--disable-features=TranslateUI,BlinkGenPropertyTrees`,
+	`--disable-ipc-flooding-protection`,
+	`--enable-features=NetworkService,NetworkServiceInProcess`,
+	`--disable-default-apps`,
+	`--disable-notifications`,
+	`--disable-webgl`,
+	`--disable-blink-features=AutomationControlled`,
+	`--ignore-certificate-errors`,
+	`--ignore-certificate-errors-spki-list`,
+	`--disable-web-security`,
+}
+
+// playwrightLauncher starts every browser with its own Playwright driver, so
+// that a wedged driver only takes its browser down.
+type playwrightLauncher struct {
+	cfg     launchConfig
+	proxies *jshttp.ProxyPool
+}
+
+func newPlaywrightLauncher(cfg launchConfig) (*playwrightLauncher, error) {
+	err := playwright.Install(&playwright.RunOptions{Browsers: []string{"chromium"}, Verbose: true})
+	if err != nil {
+		return nil, err
+	}
+
+	l := playwrightLauncher{cfg: cfg}
+
+	if len(cfg.proxies) > 0 {
+		// local forwarders that add the proxy credentials chromium cannot
+		// send to SOCKS5 proxies
+		l.proxies, err = jshttp.NewProxyPool(cfg.proxies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &l, nil
+}
+
+func (l *playwrightLauncher) launch() (instance, error) {
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, fmt.Errorf("starting playwright: %w", err)
+	}
+
+	args := chromiumArgs
+	if l.cfg.disableImages {
+		args = append(args[:len(args):len(args)], `--blink-settings=imagesEnabled=false`)
+	}
+
+	br, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(l.cfg.headless),
+		Args:     args,
+	})
+	if err != nil {
+		_ = pw.Stop()
+
+		return nil, err
+	}
+
+	ua := l.cfg.userAgent
+	if ua == "" {
+		ua = defaultUserAgent
+	}
+
+	opts := playwright.BrowserNewContextOptions{
+		UserAgent: playwright.String(ua),
+		Viewport:  &playwright.Size{Width: 1920, Height: 1080},
+	}
+
+	if l.proxies != nil {
+		opts.Proxy = &playwright.Proxy{Server: l.proxies.Next().Address()}
+	}
+
+	bctx, err := br.NewContext(opts)
+	if err != nil {
+		_ = br.Close()
+		_ = pw.Stop()
+
+		return nil, err
+	}
+
+	b := playwrightBrowser{
+		pw:      pw,
+		browser: br,
+		ctx:     bctx,
+		gone:    make(chan struct{}),
+	}
+
+	br.OnDisconnected(func(playwright.Browser) {
+		b.goneOnce.Do(func() { close(b.gone) })
+	})
+
+	return &b, nil
+}
+
+type playwrightBrowser struct {
+	pw      *playwright.Playwright
+	browser playwright.Browser
+	ctx     playwright.BrowserContext
+
+	gone     chan struct{}
+	goneOnce sync.Once
+
+	// cdp is only used by the supervisor
+	cdp playwright.CDPSession
+}
+
+func (b *playwrightBrowser) newPage(timeout time.Duration) (page, error) {
+	p, err := b.ctx.NewPage()
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout > 0 {
+		p.SetDefaultTimeout(float64(timeout.Milliseconds()))
+	}
+
+	pg := playwrightPage{page: p}
+
+	p.OnCrash(func(playwright.Page) {
+		pg.crashedFlag.Store(true)
+	})
+
+	return &pg, nil
+}
+
+func (b *playwrightBrowser) ping() error {
+	_, err := b.ctx.Cookies()
+
+	return err
+}
+
+func (b *playwrightBrowser) exited() <-chan struct{} {
+	return b.gone
+}
+
+// usage asks Chromium for the CPU time of its processes and reads the
+// resident memory of the browser process, which hosts the renderers since
+// chromium runs with --single-process.
+func (b *playwrightBrowser) usage() (usage, error) {
+	if b.cdp == nil {
+		cdp, err := b.browser.NewBrowserCDPSession()
+		if err != nil {
+			return usage{}, err
+		}
+
+		b.cdp = cdp
+	}
+
+	res, err := b.cdp.Send("SystemInfo.getProcessInfo", nil)
+	if err != nil {
+		return usage{}, err
+	}
+
+	info, _ := res.(map[string]any)
+	procs, _ := info["processInfo"].([]any)
+
+	var u usage
+
+	for _, p := range procs {
+		proc, _ := p.(map[string]any)
+
+		cpu, _ := proc["cpuTime"].(float64)
+		u.cpuSeconds += cpu
+
+		if kind, _ := proc["type"].(string); kind == "browser" {
+			id, _ := proc["id"].(float64)
+			u.rssBytes = residentMemory(int(id))
+		}
+	}
+
+	return u, nil
+}
+
+// residentMemory returns the resident memory of the process pid, or 0 where
+// /proc is not available.
+func residentMemory(pid int) int64 {
+	if pid <= 0 {
+		return 0
+	}
+
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0
+	}
+
+	pages, err := statmResident(string(raw))
+	if err != nil {
+		return 0
+	}
+
+	return pages * int64(os.Getpagesize())
+}
+
+// statmResident parses the resident pages, the second field of
+// /proc/<pid>/statm.
+func statmResident(statm string) (int64, error) {
+	fields := strings.Fields(statm)
+	if len(fields) < 2 {
+		return 0, errors.New("short statm")
+	}
+
+	return strconv.ParseInt(fields[1], 10, 64)
+}
+
+func (b *playwrightBrowser) close() {
+	_ = b.ctx.Close()
+	_ = b.browser.Close()
+	_ = b.pw.Stop()
+}
+
+type playwrightPage struct {
+	page        playwright.Page
+	crashedFlag atomic.Bool
+}
+
+func (p *playwrightPage) browserPage() scrapemate.BrowserPage {
+	return playwrightadapter.NewPage(p.page)
+}
+
+func (p *playwrightPage) crashed() bool {
+	return p.crashedFlag.Load()
+}
+
+func (p *playwrightPage) close() {
+	_ = p.page.Close()
+}
@@ -0,0 +1,133 @@
+package browserpool
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// DefaultRecyclePages is how many pages a browser opens before it is
+	// replaced.
+	DefaultRecyclePages = 300
+	// DefaultRecycleAfter is how long a browser lives before it is replaced.
+	DefaultRecycleAfter = 30 * time.Minute
+	// DefaultMemoryLimit is the resident memory above which a browser is
+	// replaced.
+	DefaultMemoryLimit = 2 << 30
+)
+
+// Recycle reasons, as counted in Stats.Recycles.
+const (
+	RecyclePages  = "pages"
+	RecycleAge    = "age"
+	RecycleMemory = "memory"
+)
+
+// recyclePolicy bounds the life of a browser: Chromium keeps growing over a
+// long crawl, so browsers are replaced before the container runs out of
+// memory. A zero field disables its limit.
+type recyclePolicy struct {
+	pages       int
+	age         time.Duration
+	memoryBytes int64
+}
+
+// checkout returns the browser of w for a new page. A browser that reached
+// its page budget is drained first, so the page opens on a fresh one.
+func (p *Pool) checkout(w *worker) (*browserInstance, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if inst := w.current; inst != nil && p.recycle.pages > 0 && inst.pages >= p.recycle.pages {
+		p.drainLocked(w, inst, RecyclePages)
+	}
+
+	inst, err := p.instanceLocked(w)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.pages++
+	inst.active++
+
+	return inst, nil
+}
+
+// checkin releases a page of inst and closes inst if it was drained and this
+// was its last page.
+func (p *Pool) checkin(w *worker, inst *browserInstance) {
+	w.mu.Lock()
+
+	inst.active--
+	closing := inst.draining && inst.active == 0
+
+	w.mu.Unlock()
+
+	if closing {
+		go closeWithTimeout(inst.close)
+	}
+}
+
+// drain replaces inst on w with a fresh browser for the next jobs. The jobs
+// running on inst are left to finish: a recycled browser is not a failure.
+func (p *Pool) drain(w *worker, inst *browserInstance, reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current != inst {
+		return
+	}
+
+	p.drainLocked(w, inst, reason)
+}
+
+func (p *Pool) drainLocked(w *worker, inst *browserInstance, reason string) {
+	w.current = nil
+	inst.draining = true
+
+	if inst.active == 0 {
+		go closeWithTimeout(inst.close)
+	}
+
+	p.count(func(s *Stats) {
+		if s.Recycles == nil {
+			s.Recycles = make(map[string]int)
+		}
+
+		s.Recycles[reason]++
+	})
+
+	log.Printf("browser %d: recycling after %d pages and %s (%s)",
+		w.id, inst.pages, time.Since(inst.launchedAt).Round(time.Second), reason)
+}
+
+// watch samples the usage of inst and drains it once it is too old or too
+// big. It returns the sample and the CPU percentage since the previous one.
+func (p *Pool) watch(w *worker, inst *browserInstance) (usage, float64) {
+	now := time.Now()
+
+	var u usage
+
+	err := callWithTimeout(func() (err error) {
+		u, err = inst.usage()
+
+		return err
+	}, pingTimeout)
+	if err == nil {
+		if !inst.sampleAt.IsZero() && u.cpuSeconds >= inst.sample.cpuSeconds {
+			inst.cpuPercent = 100 * (u.cpuSeconds - inst.sample.cpuSeconds) / now.Sub(inst.sampleAt).Seconds()
+		}
+
+		inst.sample = u
+		inst.sampleAt = now
+	}
+
+	switch {
+	case p.recycle.memoryBytes > 0 && inst.sample.rssBytes > p.recycle.memoryBytes:
+		p.drain(w, inst, RecycleMemory)
+	case p.recycle.age > 0 && now.Sub(inst.launchedAt) > p.recycle.age:
+		p.drain(w, inst, RecycleAge)
+	}
+
+	return inst.sample, inst.cpuPercent
+}
@@ -0,0 +1,48 @@
+// Package tsv is an example exporter, writing the name, phone and website of
+// the places as tab-separated values. Build it in with a blank import from
+// the main package:
+//
+//	import _ "github.com/gosom/google-maps-scraper/examples/exporters/tsv"
+//
+// and run with -exporter tsv.
+package tsv
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/exporter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func init() {
+	exporter.Register("tsv", func(w io.Writer, _ string) (exporter.Exporter, error) {
+		return &tsvExporter{w: bufio.NewWriter(w)}, nil
+	})
+}
+
+type tsvExporter struct {
+	w *bufio.Writer
+}
+
+func (e *tsvExporter) Export(_ context.Context, entries []*gmaps.Entry) error {
+	for _, entry := range entries {
+		_, err := fmt.Fprintf(e.w, "%s\t%s\t%s\n", clean(entry.Title), clean(entry.Phone), clean(entry.WebSite))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *tsvExporter) Close() error {
+	return e.w.Flush()
+}
+
+func clean(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+}
@@ -8,9 +8,18 @@ import (
 type Exiter interface {
 	SetSeedCount(int)
 	SetCancelFunc(context.CancelFunc)
+	// SetMaxPlaces ends the run once that many places are completed, even
+	// when seeds are left. 0 means no limit.
+	SetMaxPlaces(int)
 	IncrSeedCompleted(int)
 	IncrPlacesFound(int)
+	// ReservePlaces records up to val found places, as many as the limit
+	// of SetMaxPlaces still allows, and returns how many it recorded.
+	ReservePlaces(int) int
 	IncrPlacesCompleted(int)
+	// Progress returns the places found and completed so far, and whether
+	// more may still be found.
+	Progress() (found, completed int, more bool)
 	Run(context.Context)
 }
 
@@ -19,6 +28,7 @@ type exiter struct {
 	seedCompleted   int
 	placesFound     int
 	placesCompleted int
+	maxPlaces       int
 
 	mu         *sync.Mutex
 	cancelFunc context.CancelFunc
@@ -46,10 +56,17 @@ func (e *exiter) SetCancelFunc(fn context.CancelFunc) {
 	e.cancelFunc = fn
 }
 
+func (e *exiter) SetMaxPlaces(val int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.maxPlaces = val
+}
+
 func (e *exiter) IncrSeedCompleted(val int) {
 	e.mu.Lock()
 	e.seedCompleted += val
-	done := e.seedCompleted >= e.seedCount && e.placesCompleted >= e.placesFound
+	done := e.isDone()
 	e.mu.Unlock()
 
 	if done {
@@ -67,10 +84,23 @@ func (e *exiter) IncrPlacesFound(val int) {
 	e.placesFound += val
 }
 
+func (e *exiter) ReservePlaces(val int) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.maxPlaces > 0 {
+		val = max(0, min(val, e.maxPlaces-e.placesFound))
+	}
+
+	e.placesFound += val
+
+	return val
+}
+
 func (e *exiter) IncrPlacesCompleted(val int) {
 	e.mu.Lock()
 	e.placesCompleted += val
-	done := e.seedCompleted >= e.seedCount && e.placesCompleted >= e.placesFound
+	done := e.isDone()
 	e.mu.Unlock()
 
 	if done {
@@ -81,6 +111,28 @@ func (e *exiter) IncrPlacesCompleted(val int) {
 	}
 }
 
+func (e *exiter) Progress() (found, completed int, more bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.placesFound, e.placesCompleted, !e.seedsOver()
+}
+
+// seedsOver reports whether no more places are coming, because the seeds
+// are over or the places limit is reached. e.mu must be held.
+func (e *exiter) seedsOver() bool {
+	limitReached := e.maxPlaces > 0 && e.placesFound >= e.maxPlaces
+
+	return e.seedCompleted >= e.seedCount || limitReached
+}
+
+// isDone reports whether every place found is completed and no more are
+// coming, because the seeds are over or the places limit is reached. e.mu
+// must be held.
+func (e *exiter) isDone() bool {
+	return e.seedsOver() && e.placesCompleted >= e.placesFound
+}
+
 func (e *exiter) Run(ctx context.Context) {
 	select {
 	case <-ctx.Done():
@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func init() {
+	Register("exec", NewExec)
+}
+
+// Exec runs a shell command for the whole run and writes the places to its
+// stdin, one JSON object per line; the command writes the results on its
+// stdout. It is the way to export from any language, like
+// "exec:python3 to_crm.py".
+type Exec struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	buf   *bufio.Writer
+	enc   *json.Encoder
+}
+
+// NewExec starts command, the options of the "exec" exporter, with w as its
+// stdout. Its stderr is the one of the scraper.
+func NewExec(w io.Writer, command string) (Exporter, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, errors.New("missing command, use exec:<command>")
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %q: %w", command, err)
+	}
+
+	buf := bufio.NewWriter(stdin)
+
+	return &Exec{cmd: cmd, stdin: stdin, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+// Export implements Exporter.
+func (e *Exec) Export(_ context.Context, entries []*gmaps.Entry) error {
+	for _, entry := range entries {
+		if err := e.enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	return e.buf.Flush()
+}
+
+// Close implements Exporter: it closes the stdin of the command and waits
+// for it to exit.
+func (e *Exec) Close() error {
+	flushErr := e.buf.Flush()
+
+	if err := e.stdin.Close(); err != nil && flushErr == nil {
+		flushErr = err
+	}
+
+	if err := e.cmd.Wait(); err != nil {
+		return fmt.Errorf("export command: %w", err)
+	}
+
+	return flushErr
+}
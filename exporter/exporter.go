@@ -0,0 +1,92 @@
+// Package exporter writes the results in a format of the user's own, such as
+// the schema of a proprietary API, without forking the writers. Exporters
+// are registered under a name at compile time, by a package importing this
+// one and calling Register from its init, or run as an external command
+// reading the places as JSON lines (the built-in "exec" exporter).
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Exporter writes the places of a run in its own format.
+type Exporter interface {
+	// Export writes a batch of places.
+	Export(ctx context.Context, entries []*gmaps.Entry) error
+	// Close writes what is left once all the places were exported.
+	Close() error
+}
+
+// Factory creates the exporter writing the results to w, given the options
+// of its spec, see New.
+type Factory func(w io.Writer, options string) (Exporter, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes the exporter created by f available under name. Like
+// database/sql.Register, it is meant to be called from init and panics when
+// name is empty or already taken.
+func Register(name string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if name == "" || strings.Contains(name, ":") {
+		panic(fmt.Sprintf("exporter: invalid name %q", name))
+	}
+
+	if f == nil {
+		panic("exporter: Register factory is nil")
+	}
+
+	if _, ok := factories[name]; ok {
+		panic("exporter: Register called twice for " + name)
+	}
+
+	factories[name] = f
+}
+
+// Names returns the names of the registered exporters, sorted.
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	ans := make([]string, 0, len(factories))
+	for name := range factories {
+		ans = append(ans, name)
+	}
+
+	slices.Sort(ans)
+
+	return ans
+}
+
+// New creates the exporter of spec, "name" or "name:options", writing the
+// results to w. The options are up to the exporter.
+func New(spec string, w io.Writer) (Exporter, error) {
+	name, options, _ := strings.Cut(strings.TrimSpace(spec), ":")
+
+	factoriesMu.RLock()
+	f, ok := factories[name]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown exporter %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	e, err := f(w, options)
+	if err != nil {
+		return nil, fmt.Errorf("exporter %s: %w", name, err)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+type recorder struct {
+	batches [][]*gmaps.Entry
+	closed  bool
+}
+
+func (r *recorder) Export(_ context.Context, entries []*gmaps.Entry) error {
+	r.batches = append(r.batches, entries)
+
+	return nil
+}
+
+func (r *recorder) Close() error {
+	r.closed = true
+
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	rec := &recorder{}
+
+	Register("test-recorder", func(_ io.Writer, options string) (Exporter, error) {
+		require.Equal(t, "a:b", options)
+
+		return rec, nil
+	})
+
+	require.Contains(t, Names(), "exec")
+	require.Contains(t, Names(), "test-recorder")
+
+	e, err := New("test-recorder:a:b", io.Discard)
+	require.NoError(t, err)
+	require.Same(t, rec, e)
+
+	_, err = New("nope", io.Discard)
+	require.ErrorContains(t, err, `unknown exporter "nope"`)
+
+	require.Panics(t, func() {
+		Register("test-recorder", func(io.Writer, string) (Exporter, error) { return nil, nil })
+	})
+}
+
+func TestWriterBatches(t *testing.T) {
+	rec := &recorder{}
+	in := make(chan scrapemate.Result, 4)
+
+	in <- scrapemate.Result{Data: &gmaps.Entry{Title: "a"}}
+	in <- scrapemate.Result{Data: []*gmaps.Entry{{Title: "b"}, {Title: "c"}}}
+	in <- scrapemate.Result{Data: "not a place"}
+	in <- scrapemate.Result{Data: &gmaps.Entry{Title: "d"}}
+	close(in)
+
+	require.NoError(t, NewWriter(rec, 2).Run(context.Background(), in))
+	require.True(t, rec.closed)
+	require.Len(t, rec.batches, 2)
+	require.Len(t, rec.batches[0], 3)
+	require.Len(t, rec.batches[1], 1)
+}
+
+func TestExec(t *testing.T) {
+	var out bytes.Buffer
+
+	e, err := New("exec:cat", &out)
+	require.NoError(t, err)
+
+	require.NoError(t, e.Export(context.Background(), []*gmaps.Entry{{Title: "a"}, {Title: "b"}}))
+	require.NoError(t, e.Close())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var entry gmaps.Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	require.Equal(t, "b", entry.Title)
+
+	_, err = New("exec:", &out)
+	require.Error(t, err)
+
+	e, err = New("exec:exit 3", io.Discard)
+	require.NoError(t, err)
+	require.Error(t, e.Close())
+}
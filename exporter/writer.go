@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// DefaultBatchSize is the number of places handed to an exporter at once.
+const DefaultBatchSize = 50
+
+// Writer hands the places of the results to an Exporter in batches, and
+// closes it once the results are over.
+type Writer struct {
+	exporter  Exporter
+	batchSize int
+}
+
+// NewWriter returns the writer exporting with e. A batchSize below 1 uses
+// DefaultBatchSize.
+func NewWriter(e Exporter, batchSize int) *Writer {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Writer{exporter: e, batchSize: batchSize}
+}
+
+// Run implements scrapemate.ResultWriter. A failed batch is logged and the
+// run goes on, so that the results keep flowing; the first failure is
+// returned at the end.
+func (w *Writer) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	var (
+		batch    []*gmaps.Entry
+		firstErr error
+	)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := w.exporter.Export(ctx, batch); err != nil {
+			log.Printf("exporting %d places failed: %v", len(batch), err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		batch = nil
+	}
+
+	for result := range in {
+		switch data := result.Data.(type) {
+		case *gmaps.Entry:
+			batch = append(batch, data)
+		case []*gmaps.Entry:
+			batch = append(batch, data...)
+		default:
+			continue
+		}
+
+		if len(batch) >= w.batchSize {
+			flush()
+		}
+	}
+
+	flush()
+
+	return errors.Join(firstErr, w.exporter.Close())
+}
@@ -0,0 +1,123 @@
+package gmaps
+
+import (
+	"path"
+	"strings"
+)
+
+// The kinds of the attributes of the About tab besides the yes/no ones,
+// like "No delivery".
+const (
+	// aboutKindValue is an attribute with one value, like "Free Wi-Fi".
+	aboutKindValue = 2
+	// aboutKindList is an attribute listing values, like the credit cards
+	// accepted.
+	aboutKindList = 3
+)
+
+// Values of Entry.Attributes for the yes/no attributes.
+const (
+	AttributeYes = "yes"
+	AttributeNo  = "no"
+)
+
+// parseAbout returns the groups of attributes of the About tab of darray.
+//
+//nolint:gomnd // it's ok, I need the indexes
+func parseAbout(darray []any) []About {
+	var ans []About
+
+	groupsI := getNthElementAndCast[[]any](darray, 100, 1)
+
+	for i := range groupsI {
+		el := getNthElementAndCast[[]any](groupsI, i)
+		about := About{
+			ID:   getNthElementAndCast[string](el, 0),
+			Name: getNthElementAndCast[string](el, 1),
+		}
+
+		optsI := getNthElementAndCast[[]any](el, 2)
+
+		for j := range optsI {
+			optI := getNthElementAndCast[[]any](optsI, j)
+
+			opt := Option{
+				ID:   getNthElementAndCast[string](optI, 0),
+				Name: getNthElementAndCast[string](optI, 1),
+			}
+
+			switch int(getNthElementAndCast[float64](optI, 2, 0)) {
+			case aboutKindValue:
+				opt.Enabled = true
+				opt.Value = getNthElementAndCast[string](optI, 2, 3, 2)
+			case aboutKindList:
+				opt.Enabled = true
+
+				listsI := getNthElementAndCast[[]any](optI, 2, 4, 1)
+				for k := range listsI {
+					itemsI := getNthElementAndCast[[]any](listsI, k, 0)
+					for m := range itemsI {
+						if v := getNthElementAndCast[string](itemsI, m, 2); v != "" {
+							opt.Values = append(opt.Values, v)
+						}
+					}
+				}
+			default:
+				opt.Enabled = getNthElementAndCast[float64](optI, 2, 1, 0, 0) == 1
+			}
+
+			if opt.Name != "" {
+				about.Options = append(about.Options, opt)
+			}
+		}
+
+		ans = append(ans, about)
+	}
+
+	return ans
+}
+
+// attributesOf returns the attributes of about keyed by group ID and
+// attribute ID, like "payments" and "pay_credit_card". IDs do not depend on
+// the language of the page. Yes/no attributes are AttributeYes or
+// AttributeNo, the others their value, the lists joined by ", ".
+func attributesOf(about []About) map[string]map[string]string {
+	var ans map[string]map[string]string
+
+	for i := range about {
+		if about[i].ID == "" {
+			continue
+		}
+
+		for _, opt := range about[i].Options {
+			if opt.ID == "" {
+				continue
+			}
+
+			var value string
+
+			switch {
+			case len(opt.Values) > 0:
+				value = strings.Join(opt.Values, ", ")
+			case opt.Value != "":
+				value = opt.Value
+			case opt.Enabled:
+				value = AttributeYes
+			default:
+				value = AttributeNo
+			}
+
+			if ans == nil {
+				ans = map[string]map[string]string{}
+			}
+
+			if ans[about[i].ID] == nil {
+				ans[about[i].ID] = map[string]string{}
+			}
+
+			ans[about[i].ID][path.Base(opt.ID)] = value
+		}
+	}
+
+	return ans
+}
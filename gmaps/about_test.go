@@ -0,0 +1,59 @@
+package gmaps
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryFromJSONAttributes(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/panic2.json")
+	require.NoError(t, err)
+
+	entry, err := EntryFromJSON(raw)
+	require.NoError(t, err)
+
+	var wifi, cards Option
+
+	for _, about := range entry.About {
+		for _, opt := range about.Options {
+			switch opt.ID {
+			case "/geo/type/establishment_poi/wi_fi":
+				wifi = opt
+			case "/geo/type/establishment_poi/pay_credit_card_types_accepted":
+				cards = opt
+			}
+		}
+	}
+
+	require.True(t, wifi.Enabled)
+	require.Equal(t, "Free Wi-Fi", wifi.Value)
+	require.True(t, cards.Enabled)
+	require.Contains(t, cards.Values, "Mastercard")
+	require.Contains(t, cards.Values, "American Express")
+
+	require.Equal(t, AttributeNo, entry.Attributes["service_options"]["has_delivery"])
+	require.Equal(t, AttributeYes, entry.Attributes["service_options"]["has_takeout"])
+	require.Equal(t, "Free Wi-Fi", entry.Attributes["amenities"]["wi_fi"])
+	require.Contains(t, entry.Attributes["payments"]["pay_credit_card_types_accepted"], "Mastercard, ")
+	require.Equal(t, AttributeYes, entry.Attributes["crowd"]["welcomes_families"])
+	require.Len(t, entry.Attributes, len(entry.About))
+}
+
+func TestAttributesOfSameKeysAcrossLanguages(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/raw2.json")
+	require.NoError(t, err)
+
+	entry, err := EntryFromJSON(raw)
+	require.NoError(t, err)
+
+	// raw2.json is in Greek, the keys are still the attribute IDs
+	require.Equal(t, AttributeYes, entry.Attributes["payments"]["pay_credit_card"])
+	require.Equal(t, "MasterCard", entry.Attributes["payments"]["pay_credit_card_types_accepted"])
+}
+
+func TestAttributesOfNone(t *testing.T) {
+	require.Nil(t, attributesOf(nil))
+	require.Nil(t, attributesOf([]About{{ID: "amenities", Name: "Amenities"}}))
+}
@@ -0,0 +1,24 @@
+package gmaps
+
+import "strconv"
+
+// AIInsight is what a language model made of a place, see the LLM hook of
+// the postprocess package.
+type AIInsight struct {
+	// Summary is a one-line description of the business.
+	Summary string `json:"summary"`
+	// Score rates the place as a lead from 0 to 100, against the prompt of
+	// the user.
+	Score int `json:"score"`
+	// Model is the model that answered.
+	Model string `json:"model,omitempty"`
+}
+
+// aiCsvValues returns the AI fields of e for its CSV row.
+func (e *Entry) aiCsvValues() []string {
+	if e.AI == nil {
+		return []string{"", ""}
+	}
+
+	return []string{e.AI.Summary, strconv.Itoa(e.AI.Score)}
+}
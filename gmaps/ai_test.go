@@ -0,0 +1,17 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryAICsv(t *testing.T) {
+	e := Entry{}
+	require.Equal(t, "", csvValue(t, &e, "ai_summary"))
+	require.Equal(t, "", csvValue(t, &e, "ai_score"))
+
+	e.AI = &AIInsight{Summary: "Family-run pizzeria with a wood oven", Score: 0}
+	require.Equal(t, "Family-run pizzeria with a wood oven", csvValue(t, &e, "ai_summary"))
+	require.Equal(t, "0", csvValue(t, &e, "ai_score"))
+}
@@ -0,0 +1,116 @@
+package gmaps
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Reviewer anonymization modes, see NewReviewerAnonymizer.
+const (
+	// AnonymizeReviewersHash replaces the reviewer names, profile links and
+	// review IDs with keyed hashes: the reviews of a same reviewer can still
+	// be grouped, but not traced back to them without the key.
+	AnonymizeReviewersHash = "hash"
+	// AnonymizeReviewersDrop clears them.
+	AnonymizeReviewersDrop = "drop"
+)
+
+// ReviewerAnonymizer strips the personal data of the reviewers from the
+// reviews of the entries, keeping their rating, text and date. Profile
+// pictures and review photos are always dropped. Its methods are safe on a
+// nil anonymizer, which keeps the reviews as they are.
+type ReviewerAnonymizer struct {
+	mode string
+	key  []byte
+}
+
+// ValidateReviewerAnonymization checks that mode is empty or one of the
+// anonymization modes.
+func ValidateReviewerAnonymization(mode string) error {
+	switch mode {
+	case "", AnonymizeReviewersHash, AnonymizeReviewersDrop:
+		return nil
+	default:
+		return fmt.Errorf("invalid reviewer anonymization %q: use %q or %q", mode, AnonymizeReviewersHash, AnonymizeReviewersDrop)
+	}
+}
+
+// NewReviewerAnonymizer returns the anonymizer of mode, or nil when mode is
+// empty. The hashes of AnonymizeReviewersHash are keyed with key, so that a
+// reviewer gets the same hash in every dataset anonymized with the same key;
+// an empty key picks a random one, only valid for this anonymizer.
+func NewReviewerAnonymizer(mode, key string) (*ReviewerAnonymizer, error) {
+	if err := ValidateReviewerAnonymization(mode); err != nil {
+		return nil, err
+	}
+
+	if mode == "" {
+		return nil, nil
+	}
+
+	a := ReviewerAnonymizer{mode: mode, key: []byte(key)}
+
+	if key == "" {
+		a.key = make([]byte, 32)
+
+		if _, err := rand.Read(a.key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// Anonymize strips the reviewer data of the reviews of e.
+func (a *ReviewerAnonymizer) Anonymize(e *Entry) {
+	if a == nil {
+		return
+	}
+
+	for i := range e.UserReviews {
+		a.review(&e.UserReviews[i])
+	}
+
+	for i := range e.UserReviewsExtended {
+		a.review(&e.UserReviewsExtended[i])
+	}
+}
+
+func (a *ReviewerAnonymizer) review(r *Review) {
+	r.ProfilePicture = ""
+	r.Images = nil
+
+	if a.mode == AnonymizeReviewersDrop {
+		r.Name = ""
+		r.AuthorURL = ""
+		r.ReviewID = ""
+
+		return
+	}
+
+	// the profile link identifies the reviewer better than the name
+	author := r.AuthorURL
+	if author == "" {
+		author = r.Name
+	}
+
+	r.Name = a.hash("reviewer", author)
+	r.AuthorURL = ""
+	r.ReviewID = a.hash("review", r.ReviewID)
+}
+
+// hash returns the keyed hash of value in namespace, or an empty string for
+// an empty value.
+func (a *ReviewerAnonymizer) hash(namespace, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(namespace + "\x00" + value))
+
+	return namespace + "_" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
@@ -0,0 +1,92 @@
+package gmaps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func anonymizeTestEntry() *Entry {
+	return &Entry{
+		UserReviews: []Review{{
+			Name:           "Mario Rossi",
+			ProfilePicture: "https://lh3.googleusercontent.com/a/mario",
+			Rating:         5,
+			Description:    "Great pizza",
+			Images:         []string{"https://lh5.googleusercontent.com/p/1"},
+			When:           "2 weeks ago",
+			ReviewID:       "ChZDSUhNMG9nS0VJQ0FnSUR",
+			AuthorURL:      "https://www.google.com/maps/contrib/1234",
+		}},
+		UserReviewsExtended: []Review{{
+			Name:        "Mario Rossi",
+			Rating:      4,
+			Description: "Good",
+			AuthorURL:   "https://www.google.com/maps/contrib/1234",
+		}},
+	}
+}
+
+func TestReviewerAnonymizerDrop(t *testing.T) {
+	a, err := NewReviewerAnonymizer(AnonymizeReviewersDrop, "")
+	require.NoError(t, err)
+
+	e := anonymizeTestEntry()
+	a.Anonymize(e)
+
+	r := e.UserReviews[0]
+	require.Empty(t, r.Name)
+	require.Empty(t, r.AuthorURL)
+	require.Empty(t, r.ProfilePicture)
+	require.Empty(t, r.ReviewID)
+	require.Empty(t, r.Images)
+	require.Equal(t, 5, r.Rating)
+	require.Equal(t, "Great pizza", r.Description)
+	require.Equal(t, "2 weeks ago", r.When)
+}
+
+func TestReviewerAnonymizerHash(t *testing.T) {
+	a, err := NewReviewerAnonymizer(AnonymizeReviewersHash, "secret")
+	require.NoError(t, err)
+
+	e := anonymizeTestEntry()
+	a.Anonymize(e)
+
+	r := e.UserReviews[0]
+	require.True(t, strings.HasPrefix(r.Name, "reviewer_"))
+	require.True(t, strings.HasPrefix(r.ReviewID, "review_"))
+	require.Empty(t, r.AuthorURL)
+	require.Empty(t, r.ProfilePicture)
+	require.Equal(t, "Great pizza", r.Description)
+
+	// the reviews of a reviewer keep grouping together
+	require.Equal(t, r.Name, e.UserReviewsExtended[0].Name)
+	require.Empty(t, e.UserReviewsExtended[0].ReviewID)
+
+	// the same key gives the same hashes, another key others
+	again := anonymizeTestEntry()
+	a2, err := NewReviewerAnonymizer(AnonymizeReviewersHash, "secret")
+	require.NoError(t, err)
+	a2.Anonymize(again)
+	require.Equal(t, r.Name, again.UserReviews[0].Name)
+
+	other := anonymizeTestEntry()
+	a3, err := NewReviewerAnonymizer(AnonymizeReviewersHash, "")
+	require.NoError(t, err)
+	a3.Anonymize(other)
+	require.NotEqual(t, r.Name, other.UserReviews[0].Name)
+}
+
+func TestReviewerAnonymizerNone(t *testing.T) {
+	a, err := NewReviewerAnonymizer("", "")
+	require.NoError(t, err)
+	require.Nil(t, a)
+
+	e := anonymizeTestEntry()
+	a.Anonymize(e)
+	require.Equal(t, "Mario Rossi", e.UserReviews[0].Name)
+
+	_, err = NewReviewerAnonymizer("mask", "")
+	require.Error(t, err)
+}
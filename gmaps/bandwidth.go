@@ -0,0 +1,156 @@
+package gmaps
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gosom/scrapemate"
+)
+
+type bandwidthKey struct {
+	source string
+	proxy  string
+}
+
+// RecordBytes counts n bytes downloaded for source through proxy (a
+// proxyLabel, empty when scrapemate picked the proxy).
+func (s *FetchStats) RecordBytes(source, proxy string, n int64) {
+	if s == nil || n <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bytes[bandwidthKey{source: source, proxy: proxy}] += n
+}
+
+// BandwidthReport is the traffic downloaded by a job. Proxies are billed per
+// GB, so the report tells which part of a job (and which proxy) cost what.
+type BandwidthReport struct {
+	TotalBytes int64            `json:"total_bytes"`
+	BySource   map[string]int64 `json:"by_source,omitempty"`
+	// ByProxy is keyed by proxy ("direct" for no proxy). Only fetches whose
+	// proxy is known are included: the Maps crawl proxies are rotated by
+	// scrapemate and only show up in BySource.
+	ByProxy map[string]int64 `json:"by_proxy,omitempty"`
+}
+
+// BandwidthReport summarizes the recorded traffic.
+func (s *FetchStats) BandwidthReport() BandwidthReport {
+	var report BandwidthReport
+
+	if s == nil {
+		return report
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.bytes) == 0 {
+		return report
+	}
+
+	report.BySource = make(map[string]int64)
+
+	for k, n := range s.bytes {
+		report.TotalBytes += n
+		report.BySource[k.source] += n
+
+		if k.proxy == "" {
+			continue
+		}
+
+		if report.ByProxy == nil {
+			report.ByProxy = make(map[string]int64)
+		}
+
+		report.ByProxy[k.proxy] += n
+	}
+
+	return report
+}
+
+// Total renders TotalBytes in binary units, e.g. "12.4 MiB".
+func (r BandwidthReport) Total() string {
+	return formatBytes(r.TotalBytes)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// meteredTransport counts the response bodies read through base, as they
+// come from the network (before decompression), per proxy.
+type meteredTransport struct {
+	base   *http.Transport
+	stats  *FetchStats
+	source string
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	label := proxyLabel(nil)
+
+	if t.base.Proxy != nil {
+		if p, err := t.base.Proxy(req); err == nil && p != nil {
+			label = proxyLabel(p)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &meteredBody{ReadCloser: resp.Body, record: func(n int64) {
+		t.stats.RecordBytes(t.source, label, n)
+	}}
+
+	return resp, nil
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	record func(int64)
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.record(int64(n))
+
+	return n, err
+}
+
+// meterBrowserPage counts the responses received by page, from their
+// Content-Length, until scrapemate clears the page hooks. Responses without
+// a Content-Length (chunked) are not counted, so this is a lower bound.
+func (s *FetchStats) meterBrowserPage(page scrapemate.BrowserPage, source string) {
+	if s == nil {
+		return
+	}
+
+	hooks, ok := page.(scrapemate.RequestHookProvider)
+	if !ok {
+		return
+	}
+
+	hooks.OnResponse(func(_ string, _ int, headers map[string]string) {
+		if n, err := strconv.ParseInt(headers["content-length"], 10, 64); err == nil {
+			s.RecordBytes(source, "", n)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package gmaps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailPipelineMetersBandwidth(t *testing.T) {
+	page := `<html><body>` + strings.Repeat("<p>menu</p>", 100) + `<a href="mailto:info@shop.it">mail</a></body></html>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	stats := NewFetchStats()
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil, WithEmailPipelineFetchStats(stats)).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "found", entry.EmailStatus)
+
+	report := stats.BandwidthReport()
+
+	require.Equal(t, int64(len(page)), report.TotalBytes)
+	require.Equal(t, map[string]int64{FetchSourceEmail: int64(len(page))}, report.BySource)
+	require.Equal(t, map[string]int64{"direct": int64(len(page))}, report.ByProxy)
+}
+
+// hookPage is a browser page that only supports the response hook.
+type hookPage struct {
+	scrapemate.BrowserPage
+
+	onResponse func(url string, statusCode int, headers map[string]string)
+}
+
+func (p *hookPage) OnRequest(func(string, map[string]string)) {}
+
+func (p *hookPage) OnResponse(h func(string, int, map[string]string)) {
+	p.onResponse = h
+}
+
+func TestMeterBrowserPage(t *testing.T) {
+	stats := NewFetchStats()
+	page := &hookPage{}
+
+	stats.meterBrowserPage(page, FetchSourceMaps)
+
+	page.onResponse("https://www.google.com/maps", 200, map[string]string{"content-length": "2048"})
+	page.onResponse("https://www.gstatic.com/app.js", 200, map[string]string{"content-length": "1024"})
+	page.onResponse("https://www.google.com/search", 200, map[string]string{"transfer-encoding": "chunked"})
+
+	report := stats.BandwidthReport()
+
+	require.Equal(t, int64(3072), report.TotalBytes)
+	require.Equal(t, "3.0 KiB", report.Total())
+	require.Nil(t, report.ByProxy)
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "512 B", formatBytes(512))
+	require.Equal(t, "1.5 KiB", formatBytes(1536))
+	require.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
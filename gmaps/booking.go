@@ -0,0 +1,168 @@
+package gmaps
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// orderOnlineAction is the type of the food ordering actions of a place,
+// read as Entry.OrderOnline. The other actions book a table, a room or an
+// appointment.
+const orderOnlineAction = 4
+
+// bookingPlatforms names the booking tools by domain, for the links whose
+// provider Google does not name.
+var bookingPlatforms = map[string]string{
+	"booksy.com":            "Booksy",
+	"fresha.com":            "Fresha",
+	"treatwell.com":         "Treatwell",
+	"vagaro.com":            "Vagaro",
+	"mindbodyonline.com":    "Mindbody",
+	"squareup.com":          "Square Appointments",
+	"square.site":           "Square Appointments",
+	"calendly.com":          "Calendly",
+	"acuityscheduling.com":  "Acuity Scheduling",
+	"setmore.com":           "Setmore",
+	"simplybook.me":         "SimplyBook.me",
+	"schedulicity.com":      "Schedulicity",
+	"styleseat.com":         "StyleSeat",
+	"planity.com":           "Planity",
+	"doctolib.fr":           "Doctolib",
+	"doctolib.de":           "Doctolib",
+	"zocdoc.com":            "Zocdoc",
+	"opentable.com":         "OpenTable",
+	"resy.com":              "Resy",
+	"sevenrooms.com":        "SevenRooms",
+	"thefork.com":           "TheFork",
+	"quandoo.com":           "Quandoo",
+	"tablein.com":           "Tablein",
+	"yelp.com":              "Yelp",
+	"booking.com":           "Booking.com",
+	"expedia.com":           "Expedia",
+	"hotels.com":            "Hotels.com",
+	"agoda.com":             "Agoda",
+	"classpass.com":         "ClassPass",
+	"phorest.com":           "Phorest",
+	"timely.is":             "Timely",
+	"salonized.com":         "Salonized",
+	"gettimely.com":         "Timely",
+	"appointy.com":          "Appointy",
+	"youcanbook.me":         "YouCanBook.me",
+	"bookingkoala.com":      "BookingKoala",
+	"housecallpro.com":      "Housecall Pro",
+	"getjobber.com":         "Jobber",
+	"servicetitan.com":      "ServiceTitan",
+	"reservewithgoogle.com": "Reserve with Google",
+}
+
+// BookingLink is a link to book a table, a room or an appointment at a
+// place, like the "Book online" and Reserve with Google buttons.
+type BookingLink struct {
+	Link string `json:"link"`
+	// Source is the domain of the link.
+	Source string `json:"source"`
+	// Platform is the booking tool behind the link, like "Booksy", when
+	// known.
+	Platform string `json:"platform,omitempty"`
+}
+
+// parseBookingLinks returns the booking links of darray: the reservation
+// links and the providers of the actions other than ordering food.
+//
+//nolint:gomnd // it's ok, I need the indexes
+func parseBookingLinks(darray []any) []BookingLink {
+	var ans []BookingLink
+
+	seen := map[string]bool{}
+
+	add := func(link, source, platform string) {
+		if link == "" || seen[link] {
+			return
+		}
+
+		seen[link] = true
+
+		if source == "" {
+			source = linkDomain(link)
+		}
+
+		if platform == "" {
+			platform = bookingPlatform(link)
+		}
+
+		ans = append(ans, BookingLink{Link: link, Source: source, Platform: platform})
+	}
+
+	reservationsI := getNthElementAndCast[[]any](darray, 46)
+	for i := range reservationsI {
+		add(
+			getNthElementAndCast[string](reservationsI, i, 0),
+			getNthElementAndCast[string](reservationsI, i, 1),
+			"",
+		)
+	}
+
+	actionsI := getNthElementAndCast[[]any](darray, 75, 0)
+	for i := range actionsI {
+		if int(getNthElementAndCast[float64](actionsI, i, 0)) == orderOnlineAction {
+			continue
+		}
+
+		providersI := getNthElementAndCast[[]any](actionsI, i, 2)
+		for j := range providersI {
+			add(
+				getNthElementAndCast[string](providersI, j, 1, 2, 0),
+				getNthElementAndCast[string](providersI, j, 0, 0),
+				getNthElementAndCast[string](providersI, j, 0, 2, 1),
+			)
+		}
+	}
+
+	return ans
+}
+
+// bookingPlatform returns the booking tool of link from its domain, "" when
+// unknown.
+func bookingPlatform(link string) string {
+	host := linkDomain(link)
+
+	for host != "" {
+		if name, ok := bookingPlatforms[host]; ok {
+			return name
+		}
+
+		// book.fresha.com is fresha.com
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return ""
+		}
+
+		host = parent
+	}
+
+	return ""
+}
+
+// linkDomain returns the host of link without www., "" when it is not a URL.
+func linkDomain(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// bookingPlatformNames returns the distinct platforms of links, in order.
+func bookingPlatformNames(links []BookingLink) []string {
+	var ans []string
+
+	for _, l := range links {
+		if l.Platform != "" && !slices.Contains(ans, l.Platform) {
+			ans = append(ans, l.Platform)
+		}
+	}
+
+	return ans
+}
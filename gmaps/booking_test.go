@@ -0,0 +1,68 @@
+package gmaps
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBookingLinks(t *testing.T) {
+	darray := make([]any, 76)
+	darray[46] = []any{
+		[]any{"https://www.opentable.com/r/kipriakon", "opentable.com"},
+	}
+	darray[75] = []any{[]any{
+		// ordering food, read as OrderOnline
+		[]any{4.0, nil, []any{
+			[]any{
+				[]any{"wolt.com", nil, []any{"logo", "Wolt"}},
+				[]any{nil, nil, []any{"https://wolt.com/kipriakon"}},
+			},
+		}},
+		[]any{1.0, nil, []any{
+			[]any{
+				[]any{"booksy.com", nil, []any{"logo", "Booksy"}},
+				[]any{nil, nil, []any{"https://booksy.com/en-us/123_barber"}},
+			},
+			[]any{
+				[]any{"", nil, nil},
+				[]any{nil, nil, []any{"https://book.fresha.com/a/barber-xyz"}},
+			},
+			// repeated
+			[]any{
+				[]any{"opentable.com", nil, nil},
+				[]any{nil, nil, []any{"https://www.opentable.com/r/kipriakon"}},
+			},
+		}},
+	}}
+
+	links := parseBookingLinks(darray)
+
+	require.Equal(t, []BookingLink{
+		{Link: "https://www.opentable.com/r/kipriakon", Source: "opentable.com", Platform: "OpenTable"},
+		{Link: "https://booksy.com/en-us/123_barber", Source: "booksy.com", Platform: "Booksy"},
+		{Link: "https://book.fresha.com/a/barber-xyz", Source: "book.fresha.com", Platform: "Fresha"},
+	}, links)
+
+	e := Entry{BookingLinks: links}
+	require.Equal(t, "OpenTable, Booksy, Fresha", csvValue(t, &e, "booking_platforms"))
+}
+
+func TestBookingPlatform(t *testing.T) {
+	require.Equal(t, "Calendly", bookingPlatform("https://calendly.com/dr-smith"))
+	require.Equal(t, "Square Appointments", bookingPlatform("https://app.squareup.com/appointments/book/x"))
+	require.Empty(t, bookingPlatform("https://example.com/book"))
+	require.Empty(t, bookingPlatform("not a url"))
+}
+
+func TestEntryFromJSONOrderOnlineIsNotBooking(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/raw.json")
+	require.NoError(t, err)
+
+	entry, err := EntryFromJSON(raw)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, entry.OrderOnline)
+	require.Empty(t, entry.BookingLinks)
+}
@@ -0,0 +1,88 @@
+package gmaps
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// FetchErrCircuitOpen is the email_error of the places whose website was not
+// fetched because its domain kept failing, see DomainBreaker.
+const FetchErrCircuitOpen = "circuit_open"
+
+var errCircuitOpen = errors.New("domain failing, not fetched")
+
+const (
+	// breakerFailures is how many fetches of a domain in a row time out or
+	// answer 5xx before its breaker opens.
+	breakerFailures = 3
+	// breakerCooldown is how long a breaker stays open; the next failure
+	// opens it again at once.
+	breakerCooldown = 5 * time.Minute
+)
+
+// DomainBreaker stops the email pipelines of a job from fetching a domain
+// that keeps timing out or answering 5xx: the many places of a dead
+// franchise website then fail at once, instead of each spending the
+// retries and the timeouts of the pipeline. It is safe for concurrent use;
+// a nil *DomainBreaker never opens.
+type DomainBreaker struct {
+	mu      sync.Mutex
+	domains map[string]*domainCircuit
+}
+
+type domainCircuit struct {
+	// failures counts the failures in a row
+	failures  int
+	openUntil time.Time
+}
+
+// NewDomainBreaker creates the breaker of a job.
+func NewDomainBreaker() *DomainBreaker {
+	return &DomainBreaker{domains: make(map[string]*domainCircuit)}
+}
+
+// Allow reports whether the website of domain may be fetched.
+func (b *DomainBreaker) Allow(domain string) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.domains[domain]
+
+	return !ok || !time.Now().Before(c.openUntil)
+}
+
+// Record records a fetch of domain that failed with class, empty when it
+// succeeded. Only the timeouts and the 5xx count, the other failures say
+// nothing about the server.
+func (b *DomainBreaker) Record(domain, class string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch class {
+	case "":
+		delete(b.domains, domain)
+	case FetchErrTimeout, FetchErrHTTP5xx:
+		c, ok := b.domains[domain]
+		if !ok {
+			c = &domainCircuit{}
+			b.domains[domain] = c
+		}
+
+		c.failures++
+
+		if c.failures >= breakerFailures {
+			c.openUntil = time.Now().Add(breakerCooldown)
+			// one more failure after the cooldown opens it again
+			c.failures = breakerFailures - 1
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gmaps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainBreaker(t *testing.T) {
+	b := NewDomainBreaker()
+
+	for range breakerFailures - 1 {
+		b.Record("dead.com", FetchErrHTTP5xx)
+	}
+
+	require.True(t, b.Allow("dead.com"))
+
+	b.Record("dead.com", FetchErrTimeout)
+	require.False(t, b.Allow("dead.com"))
+	require.True(t, b.Allow("alive.com"))
+
+	// a success closes it
+	b.Record("dead.com", "")
+	require.True(t, b.Allow("dead.com"))
+
+	// the other failures say nothing about the server
+	for range breakerFailures {
+		b.Record("gone.com", FetchErrHTTP4xx)
+		b.Record("gone.com", FetchErrDNS)
+	}
+
+	require.True(t, b.Allow("gone.com"))
+
+	var none *DomainBreaker
+
+	none.Record("dead.com", FetchErrTimeout)
+	require.True(t, none.Allow("dead.com"))
+}
+
+func TestEmailPipelineBreakerOpen(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	b := NewDomainBreaker()
+	for range breakerFailures {
+		b.Record(websiteDomain(srv.URL), FetchErrHTTP5xx)
+	}
+
+	fetcher := &mockBrowserFetcher{html: `<a href="mailto:info@biz.com">Email</a>`}
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, fetcher, WithEmailPipelineBreaker(b)).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "website_error", entry.EmailStatus)
+	require.Equal(t, FetchErrCircuitOpen, entry.EmailError)
+	require.Equal(t, []string{}, entry.Emails)
+	require.Zero(t, hits.Load())
+}
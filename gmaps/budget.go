@@ -0,0 +1,95 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// EmailStatusBudgetExceeded marks the entries whose website was not (fully)
+// searched for emails because the email fetch budget of the job ran out.
+const EmailStatusBudgetExceeded = "budget_exceeded"
+
+// EmailStatusAborted marks the entries whose website was not (fully)
+// searched for emails because the email phase of the job was aborted, see
+// FetchBudget.Abort.
+const EmailStatusAborted = "aborted"
+
+var errEmailBudgetExhausted = errors.New("email fetch budget exhausted")
+
+// FetchBudget caps the website fetches of the email pipeline across all the
+// places of a job. It is safe for concurrent use. Its methods are safe on a
+// nil budget, which never runs out.
+type FetchBudget struct {
+	max     int64
+	used    atomic.Int64
+	aborted atomic.Bool
+}
+
+// NewFetchBudget returns a budget of max fetches. A max of 0 means no limit,
+// the budget only counts the fetches.
+func NewFetchBudget(max int) *FetchBudget {
+	return &FetchBudget{max: int64(max)}
+}
+
+// Take consumes one fetch. It returns false, consuming nothing, when the
+// budget is exhausted.
+func (b *FetchBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+
+	if b.aborted.Load() {
+		return false
+	}
+
+	if n := b.used.Add(1); b.max > 0 && n > b.max {
+		b.used.Add(-1)
+
+		return false
+	}
+
+	return true
+}
+
+// Used returns the number of fetches consumed so far.
+func (b *FetchBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+
+	return int(b.used.Load())
+}
+
+// Exhausted reports whether no fetch is left.
+func (b *FetchBudget) Exhausted() bool {
+	return b != nil && (b.aborted.Load() || b.max > 0 && b.used.Load() >= b.max)
+}
+
+// Abort leaves no fetch: the email searches stop at their next fetch and
+// the places still to come get no email job, the Maps data of all of them
+// is kept. A nil budget cannot be aborted.
+func (b *FetchBudget) Abort() {
+	if b != nil {
+		b.aborted.Store(true)
+	}
+}
+
+// Aborted reports whether Abort was called.
+func (b *FetchBudget) Aborted() bool {
+	return b != nil && b.aborted.Load()
+}
+
+// budgetedBrowserFetcher makes the Level 3 fetches consume the budget too.
+type budgetedBrowserFetcher struct {
+	base   BrowserFetcher
+	budget *FetchBudget
+}
+
+func (f *budgetedBrowserFetcher) FetchWithBrowser(ctx context.Context, url string) (string, error) {
+	if !f.budget.Take() {
+		return "", errEmailBudgetExhausted
+	}
+
+	return f.base.FetchWithBrowser(ctx, url)
+}
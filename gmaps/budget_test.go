@@ -0,0 +1,123 @@
+package gmaps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchBudget(t *testing.T) {
+	b := NewFetchBudget(2)
+
+	require.True(t, b.Take())
+	require.False(t, b.Exhausted())
+	require.True(t, b.Take())
+	require.True(t, b.Exhausted())
+	require.False(t, b.Take())
+	require.Equal(t, 2, b.Used())
+
+	// no limit, only counting
+	b = NewFetchBudget(0)
+
+	for range 5 {
+		require.True(t, b.Take())
+	}
+
+	require.False(t, b.Exhausted())
+	require.Equal(t, 5, b.Used())
+
+	var none *FetchBudget
+
+	require.True(t, none.Take())
+	require.False(t, none.Exhausted())
+	require.Zero(t, none.Used())
+}
+
+func TestFetchBudgetAbort(t *testing.T) {
+	b := NewFetchBudget(0)
+
+	require.True(t, b.Take())
+	require.False(t, b.Aborted())
+
+	b.Abort()
+
+	require.True(t, b.Aborted())
+	require.True(t, b.Exhausted())
+	require.False(t, b.Take())
+	require.Equal(t, 1, b.Used())
+
+	var none *FetchBudget
+
+	none.Abort()
+	require.False(t, none.Aborted())
+	require.True(t, none.Take())
+}
+
+func TestEmailPipelineFetchBudget(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/contact">Contact</a></body></html>`)
+		case "/contact":
+			fmt.Fprint(w, `<html><body><a href="mailto:info@testbiz.com">Write to us</a></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// the homepage takes the only fetch, the contact page is out of budget
+	budget := NewFetchBudget(1)
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil, WithEmailPipelineFetchBudget(budget)).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, EmailStatusBudgetExceeded, entry.EmailStatus)
+	require.Empty(t, entry.Emails)
+	require.Equal(t, int32(1), hits.Load())
+
+	// once exhausted nothing is fetched at all
+	entry = &Entry{WebSite: srv.URL}
+
+	err = NewEmailPipeline(entry, nil, WithEmailPipelineFetchBudget(budget)).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, EmailStatusBudgetExceeded, entry.EmailStatus)
+	require.Equal(t, int32(1), hits.Load())
+
+	entry = &Entry{WebSite: srv.URL}
+
+	err = NewEmailPipeline(entry, nil, WithEmailPipelineFetchBudget(NewFetchBudget(2))).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "found", entry.EmailStatus)
+}
+
+func TestEmailPipelineAborted(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	budget := NewFetchBudget(0)
+	budget.Abort()
+
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil, WithEmailPipelineFetchBudget(budget)).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, EmailStatusAborted, entry.EmailStatus)
+	require.Empty(t, entry.Emails)
+	require.Zero(t, hits.Load())
+}
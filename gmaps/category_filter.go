@@ -0,0 +1,136 @@
+package gmaps
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CategoryFilter keeps the places of some categories only, or drops those
+// of others, so that a search does not spend place visits on what its
+// keywords happen to match, like the hotels and gas stations of a
+// "restaurants" search. It matches the main category of a place, the one
+// the result list shows: a term matches a category holding its words, so
+// "bar" matches "Wine bar" but not "Barber shop". Its methods are safe on a
+// nil filter, which keeps everything.
+type CategoryFilter struct {
+	include [][]string
+	exclude [][]string
+}
+
+// NewCategoryFilter returns the filter keeping the places whose category
+// matches one of include, all of them when include is empty, and dropping
+// those matching one of exclude. It returns nil when both are empty.
+func NewCategoryFilter(include, exclude []string) *CategoryFilter {
+	f := CategoryFilter{
+		include: categoryTerms(include),
+		exclude: categoryTerms(exclude),
+	}
+
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return nil
+	}
+
+	return &f
+}
+
+func categoryTerms(categories []string) [][]string {
+	var ans [][]string
+
+	for _, c := range categories {
+		if words := categoryWords(c); len(words) > 0 {
+			ans = append(ans, words)
+		}
+	}
+
+	return ans
+}
+
+// categoryWords returns the lowercased words of s.
+func categoryWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matchesTerm reports whether the words of term follow each other in
+// words.
+func matchesTerm(words, term []string) bool {
+	for i := 0; i+len(term) <= len(words); i++ {
+		match := true
+
+		for k := range term {
+			if words[i+k] != term[k] {
+				match = false
+
+				break
+			}
+		}
+
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(words []string, terms [][]string) bool {
+	for _, term := range terms {
+		if matchesTerm(words, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Keeps reports whether f keeps a place of the main category category. A
+// place without category is kept.
+func (f *CategoryFilter) Keeps(category string) bool {
+	words := categoryWords(category)
+	if f == nil || len(words) == 0 {
+		return true
+	}
+
+	if len(f.include) > 0 && !matchesAny(words, f.include) {
+		return false
+	}
+
+	return !matchesAny(words, f.exclude)
+}
+
+// keepsCard reports whether f keeps the place of the result card of the
+// link a, told from the texts of the card. Its category is the first of
+// them, the one it is dropped on; the others, a description or an opening
+// status, may hold an excluded term by chance, but they are enough to keep
+// it, the place page tells. A card whose texts cannot be read is kept.
+func (f *CategoryFilter) keepsCard(a *goquery.Selection) bool {
+	if f == nil {
+		return true
+	}
+
+	var category []string
+
+	included := len(f.include) == 0
+
+	for _, text := range cardTexts(a) {
+		words := categoryWords(text)
+		if len(words) == 0 {
+			continue
+		}
+
+		if category == nil {
+			category = words
+		}
+
+		included = included || matchesAny(words, f.include)
+	}
+
+	if category == nil {
+		return true
+	}
+
+	return included && !matchesAny(category, f.exclude)
+}
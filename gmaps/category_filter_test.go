@@ -0,0 +1,61 @@
+package gmaps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryFilterKeeps(t *testing.T) {
+	require.Nil(t, NewCategoryFilter(nil, []string{" ", ""}))
+
+	var none *CategoryFilter
+	require.True(t, none.Keeps("Hotel"))
+
+	f := NewCategoryFilter([]string{"restaurant", "Pizzeria"}, []string{"fast food restaurant"})
+	require.True(t, f.Keeps("Italian restaurant"))
+	require.True(t, f.Keeps("pizzeria"))
+	require.False(t, f.Keeps("Fast food restaurant"))
+	require.False(t, f.Keeps("Gas station"))
+	require.True(t, f.Keeps(""))
+
+	f = NewCategoryFilter(nil, []string{"bar"})
+	require.False(t, f.Keeps("Wine bar"))
+	require.True(t, f.Keeps("Barber shop"))
+}
+
+const resultsFeed = `<div role="feed">
+<div jsaction="a"><a href="https://www.google.com/maps/place/Trattoria+Roma/data=x" aria-label="Trattoria Roma"></a>
+<div><div>Trattoria Roma</div><div><span>4.5</span><span>(120)</span></div>
+<div><span>Italian restaurant</span><span> · </span><span>Via Roma 1</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/Hotel+Bar/data=x" aria-label="Hotel Bar"></a>
+<div><div>Hotel Bar</div><div><span>Hotel · Via Milano 2</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/Osteria/data=x" aria-label="Osteria"></a>
+<div><div>Osteria</div><div><span>€€</span><span> · </span><span>Restaurant · Via Napoli 3</span></div>
+<div><span>Cozy hotel bar with a terrace</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/Unknown/data=x" aria-label="Unknown"></a></div>
+</div>`
+
+func TestExclusionsSkipResult(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(resultsFeed))
+	require.NoError(t, err)
+
+	x := (*Exclusions)(nil).FilterCategories(NewCategoryFilter([]string{"restaurant"}, []string{"hotel"}))
+
+	var skipped []string
+
+	doc.Find(`div[role=feed] div[jsaction]>a`).Each(func(_ int, s *goquery.Selection) {
+		if x.SkipResult(s) {
+			skipped = append(skipped, s.AttrOr("aria-label", ""))
+		}
+	})
+
+	require.Equal(t, []string{"Hotel Bar"}, skipped)
+
+	require.True(t, x.Excludes(&Entry{Title: "Grand Hotel", Category: "Hotel"}))
+	require.True(t, x.Excludes(&Entry{Title: "Eni", Category: "Gas station"}))
+	require.False(t, x.Excludes(&Entry{Title: "Trattoria Roma", Category: "Italian restaurant"}))
+	require.Zero(t, x.Len())
+}
@@ -0,0 +1,289 @@
+package gmaps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Chain is a group of entries that belong to the same brand.
+type Chain struct {
+	ID     string
+	Name   string
+	Domain string
+	// Members holds the indexes of the grouped entries in the slice passed
+	// to AssignChains.
+	Members []int
+}
+
+// sharedHosts are platforms hosting pages of unrelated businesses, so a
+// shared domain on them says nothing about the brand.
+var sharedHosts = map[string]bool{
+	"facebook.com":     true,
+	"instagram.com":    true,
+	"google.com":       true,
+	"business.site":    true,
+	"linktr.ee":        true,
+	"wixsite.com":      true,
+	"wordpress.com":    true,
+	"blogspot.com":     true,
+	"tripadvisor.com":  true,
+	"yelp.com":         true,
+	"booking.com":      true,
+	"twitter.com":      true,
+	"x.com":            true,
+	"tiktok.com":       true,
+	"linkedin.com":     true,
+	"youtube.com":      true,
+	"square.site":      true,
+	"godaddysites.com": true,
+}
+
+// nameSeparators split a brand name from the location suffix Google shows
+// for franchise branches ("Brand - Via Roma", "Brand | Centro").
+var nameSeparators = []string{" - ", " – ", " — ", " | ", " @ ", "("}
+
+// AssignChains groups entries sharing a website domain or a brand name
+// backed by a second signal, sets ChainID on every grouped entry and clears
+// it on the others. A name alone is too weak, many unrelated places being
+// called "Pizzeria" or "Bar": it groups places when the brand has several
+// words or when their phone numbers share a prefix, and never places with
+// different domains. Groups are returned largest first.
+func AssignChains(entries []*Entry) []Chain {
+	parent := make([]int, len(entries))
+	domains := make([]string, len(entries))
+
+	for i, e := range entries {
+		parent[i] = i
+		domains[i] = ChainDomain(e.WebSite)
+	}
+
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+
+		return i
+	}
+
+	// union joins the groups of a and b, unless they have different
+	// domains; the domain of a group is kept on its root
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+
+		if domains[ra] != "" && domains[rb] != "" && domains[ra] != domains[rb] {
+			return
+		}
+
+		parent[rb] = ra
+
+		if domains[ra] == "" {
+			domains[ra] = domains[rb]
+		}
+	}
+
+	byDomain := make(map[string]int)
+	byName := make(map[string][]int)
+
+	var names []string
+
+	for i, e := range entries {
+		if d := domains[i]; d != "" {
+			if j, ok := byDomain[d]; ok {
+				union(j, i)
+			} else {
+				byDomain[d] = i
+			}
+		}
+
+		if n := chainNameKey(e.Title); n != "" {
+			if _, ok := byName[n]; !ok {
+				names = append(names, n)
+			}
+
+			byName[n] = append(byName[n], i)
+		}
+	}
+
+	// in the order of the entries, for the same groups on every run
+	for _, name := range names {
+		members := byName[name]
+		multiWord := strings.Contains(name, " ")
+
+		for k, i := range members {
+			for _, j := range members[:k] {
+				if multiWord || sharePhonePrefix(entries[i].Phone, entries[j].Phone) {
+					union(j, i)
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+
+	for i := range entries {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var ans []Chain
+
+	for i, e := range entries {
+		members := groups[find(i)]
+		if len(members) < 2 {
+			e.ChainID = ""
+
+			continue
+		}
+
+		if members[0] != i {
+			continue
+		}
+
+		chain := newChain(entries, members)
+
+		for _, m := range members {
+			entries[m].ChainID = chain.ID
+		}
+
+		ans = append(ans, chain)
+	}
+
+	sort.SliceStable(ans, func(a, b int) bool {
+		return len(ans[a].Members) > len(ans[b].Members)
+	})
+
+	return ans
+}
+
+// minPhonePrefix is the digits two phone numbers must share, all but the
+// last two, for the lines of one business rather than of one area code.
+const minPhonePrefix = 8
+
+// sharePhonePrefix reports whether phones a and b differ in their last two
+// digits at most.
+func sharePhonePrefix(a, b string) bool {
+	da, db := phoneDigits(a), phoneDigits(b)
+	if len(da) != len(db) || len(da)-2 < minPhonePrefix {
+		return false
+	}
+
+	return da[:len(da)-2] == db[:len(db)-2]
+}
+
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, phone)
+}
+
+func newChain(entries []*Entry, members []int) Chain {
+	chain := Chain{Members: members}
+
+	for _, m := range members {
+		if chain.Domain == "" {
+			chain.Domain = ChainDomain(entries[m].WebSite)
+		}
+
+		if chain.Name == "" {
+			chain.Name = chainBrandName(entries[m].Title)
+		}
+	}
+
+	// the key only depends on the brand so the ID is stable across runs
+	key := "domain:" + chain.Domain
+	if chain.Domain == "" {
+		key = "name:" + chainNameKey(chain.Name)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	chain.ID = "chain-" + hex.EncodeToString(sum[:6])
+
+	return chain
+}
+
+// ChainDomain returns the registrable domain of a website ("shop.brand.co.uk"
+// becomes "brand.co.uk"), or an empty string for missing websites and for
+// platforms shared by unrelated businesses.
+func ChainDomain(website string) string {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return ""
+	}
+
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return ""
+	}
+
+	for candidate := host; ; {
+		if sharedHosts[candidate] {
+			return ""
+		}
+
+		_, rest, ok := strings.Cut(candidate, ".")
+		if !ok {
+			break
+		}
+
+		candidate = rest
+	}
+
+	return domain
+}
+
+// chainBrandName strips the branch suffix from a place title.
+func chainBrandName(title string) string {
+	name := title
+
+	for _, sep := range nameSeparators {
+		if before, _, ok := strings.Cut(name, sep); ok && strings.TrimSpace(before) != "" {
+			name = before
+		}
+	}
+
+	return strings.TrimSpace(name)
+}
+
+// chainNameKey normalizes a brand name for comparison. Names shorter than
+// three characters are too generic to group on.
+func chainNameKey(title string) string {
+	fields := strings.FieldsFunc(strings.ToLower(chainBrandName(title)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	key := strings.Join(fields, " ")
+	if len([]rune(key)) < 3 {
+		return ""
+	}
+
+	return key
+}
@@ -0,0 +1,94 @@
+package gmaps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestChainDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.brand.com/stores/rome": "brand.com",
+		"http://shop.brand.co.uk":           "brand.co.uk",
+		"https://roma.abc.it/menu":          "abc.it",
+		"https://milano.abc.it":             "abc.it",
+		"http://192.0.2.1/shop":             "",
+		"brand.it":                          "brand.it",
+		"https://www.facebook.com/mybiz":    "",
+		"https://mybiz.business.site":       "",
+		"":                                  "",
+	}
+
+	for in, want := range tests {
+		require.Equal(t, want, gmaps.ChainDomain(in), in)
+	}
+}
+
+func TestAssignChains(t *testing.T) {
+	entries := []*gmaps.Entry{
+		{Title: "Burger Co - Via Roma", WebSite: "https://www.burgerco.com/rome"},
+		{Title: "Burger Co (Centro)", WebSite: ""},
+		{Title: "BurgerCo Express", WebSite: "https://burgerco.com"},
+		{Title: "Trattoria Da Mario", WebSite: "https://www.facebook.com/damario"},
+		{Title: "Pizzeria Luigi", WebSite: "https://www.facebook.com/luigi"},
+		{Title: "Pizzeria Luigi - Ostia"},
+		{Title: "Bar", ChainID: "stale"},
+	}
+
+	chains := gmaps.AssignChains(entries)
+	require.Len(t, chains, 2)
+
+	require.Equal(t, []int{0, 1, 2}, chains[0].Members)
+	require.Equal(t, "burgerco.com", chains[0].Domain)
+	require.Equal(t, "Burger Co", chains[0].Name)
+
+	require.Equal(t, []int{4, 5}, chains[1].Members)
+	require.Empty(t, chains[1].Domain)
+
+	require.NotEmpty(t, entries[0].ChainID)
+	require.Equal(t, entries[0].ChainID, entries[1].ChainID)
+	require.Equal(t, entries[0].ChainID, entries[2].ChainID)
+	require.Equal(t, entries[4].ChainID, entries[5].ChainID)
+	require.NotEqual(t, entries[0].ChainID, entries[4].ChainID)
+	require.Empty(t, entries[3].ChainID)
+	require.Empty(t, entries[6].ChainID)
+
+	// the ID depends on the brand only
+	again := gmaps.AssignChains([]*gmaps.Entry{
+		{Title: "Other branch", WebSite: "https://burgerco.com/milan"},
+		{Title: "Another branch", WebSite: "https://burgerco.com/turin"},
+	})
+	require.Len(t, again, 1)
+	require.Equal(t, chains[0].ID, again[0].ID)
+}
+
+func TestAssignChainsNeedsASecondSignal(t *testing.T) {
+	entries := []*gmaps.Entry{
+		{Title: "Pizzeria", WebSite: "https://pizzeria-da-mario.it"},
+		{Title: "Pizzeria", WebSite: "https://pizzeriabella.com"},
+		{Title: "Bar"},
+		{Title: "Bar"},
+		{Title: "Bar Centrale", Phone: "+39 06 1234 5610"},
+		{Title: "Bar Centrale - Prati", WebSite: "https://barcentrale.it"},
+		{Title: "Bar Centrale", WebSite: "https://altrobar.it"},
+		{Title: "Kebab", Phone: "+39 06 9876 5401"},
+		{Title: "Kebab", Phone: "+39 06 9876 5402"},
+		{Title: "Osteria", WebSite: "https://roma.abc.it"},
+		{Title: "Trattoria", WebSite: "https://milano.abc.it"},
+	}
+
+	chains := gmaps.AssignChains(entries)
+
+	var groups [][]int
+	for _, c := range chains {
+		groups = append(groups, c.Members)
+	}
+
+	require.ElementsMatch(t, [][]int{{4, 5}, {7, 8}, {9, 10}}, groups)
+	require.Empty(t, entries[0].ChainID)
+	require.Empty(t, entries[2].ChainID)
+	// a name never joins a place of another domain
+	require.Empty(t, entries[6].ChainID)
+}
@@ -0,0 +1,155 @@
+package gmaps
+
+import (
+	"sort"
+	"strings"
+)
+
+// The fields two places may conflict on, see FindConflicts.
+const (
+	ConflictWebsite = "website"
+	ConflictPhone   = "phone"
+)
+
+// The likely causes of a conflict.
+const (
+	// ConflictFranchise: the places share a brand name, so they are likely
+	// branches of a franchise sharing its website or call center.
+	ConflictFranchise = "franchise"
+	// ConflictDataEntry: the places have unrelated names, so one of them
+	// likely got the contact of another by mistake.
+	ConflictDataEntry = "data_entry"
+)
+
+// Conflict is a website or phone number shared by different places, which
+// breaks the deduplication on it downstream, in a CRM for instance.
+type Conflict struct {
+	Field string `json:"field"`
+	// Value is the registrable domain of the website, or the digits of the
+	// phone number.
+	Value  string          `json:"value"`
+	Cause  string          `json:"cause"`
+	Places []ConflictPlace `json:"places"`
+}
+
+// ConflictPlace is a place of a conflict.
+type ConflictPlace struct {
+	Cid     string `json:"cid"`
+	Title   string `json:"title"`
+	Address string `json:"address"`
+	Link    string `json:"link"`
+}
+
+// FindConflicts returns the websites and phone numbers shared by different
+// places of entries, those with the most places first. The websites are
+// compared on their registrable domain and the platforms hosting pages of
+// unrelated businesses are left out, as for AssignChains. The same place
+// listed twice is no conflict.
+func FindConflicts(entries []*Entry) []Conflict {
+	type group struct {
+		conflict Conflict
+		seen     map[string]bool
+	}
+
+	groups := make(map[string]*group)
+
+	var keys []string
+
+	add := func(field, value string, e *Entry) {
+		if value == "" {
+			return
+		}
+
+		key := field + ":" + value
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				conflict: Conflict{Field: field, Value: value},
+				seen:     make(map[string]bool),
+			}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+
+		place := conflictPlaceKey(e)
+		if g.seen[place] {
+			return
+		}
+
+		g.seen[place] = true
+		g.conflict.Places = append(g.conflict.Places, ConflictPlace{
+			Cid:     e.Cid,
+			Title:   e.Title,
+			Address: e.Address,
+			Link:    e.Link,
+		})
+	}
+
+	for _, e := range entries {
+		add(ConflictWebsite, ChainDomain(e.WebSite), e)
+		add(ConflictPhone, phoneKey(e.Phone), e)
+	}
+
+	var ans []Conflict
+
+	for _, key := range keys {
+		c := groups[key].conflict
+		if len(c.Places) < 2 {
+			continue
+		}
+
+		c.Cause = conflictCause(c.Places)
+		ans = append(ans, c)
+	}
+
+	sort.SliceStable(ans, func(a, b int) bool {
+		return len(ans[a].Places) > len(ans[b].Places)
+	})
+
+	return ans
+}
+
+// conflictPlaceKey identifies the place of e, by CID when known.
+func conflictPlaceKey(e *Entry) string {
+	if e.Cid != "" {
+		return "cid:" + e.Cid
+	}
+
+	return "place:" + strings.ToLower(e.Title) + "|" + strings.ToLower(e.Address)
+}
+
+// phoneKey returns the digits of phone, without the 00 of the international
+// prefix, or "" when they are too few to be a number.
+func phoneKey(phone string) string {
+	var digits strings.Builder
+
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+
+	ans := strings.TrimPrefix(digits.String(), "00")
+	if len(ans) < phoneMinDigits {
+		return ""
+	}
+
+	return ans
+}
+
+// conflictCause returns ConflictFranchise when places share a brand name.
+func conflictCause(places []ConflictPlace) string {
+	brand := chainNameKey(places[0].Title)
+	if brand == "" {
+		return ConflictDataEntry
+	}
+
+	for _, p := range places[1:] {
+		if chainNameKey(p.Title) != brand {
+			return ConflictDataEntry
+		}
+	}
+
+	return ConflictFranchise
+}
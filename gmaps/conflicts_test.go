@@ -0,0 +1,44 @@
+package gmaps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestFindConflicts(t *testing.T) {
+	entries := []*gmaps.Entry{
+		{Cid: "1", Title: "Burger Co - Via Roma", WebSite: "https://www.burgerco.com/rome", Phone: "+39 06 1234567"},
+		{Cid: "2", Title: "Burger Co (Centro)", WebSite: "https://burgerco.com", Phone: "+39 06 7654321"},
+		{Cid: "3", Title: "Trattoria Da Mario", WebSite: "https://www.facebook.com/damario", Phone: "06 555 1234"},
+		{Cid: "4", Title: "Pizzeria Luigi", WebSite: "https://www.facebook.com/luigi", Phone: "06-555-1234"},
+		// the same place listed twice
+		{Cid: "1", Title: "Burger Co - Via Roma", Phone: "0039 06 1234567"},
+		{Cid: "5", Title: "Bar", Phone: "123"},
+		{Cid: "6", Title: "Pub", Phone: "123"},
+	}
+
+	conflicts := gmaps.FindConflicts(entries)
+	require.Len(t, conflicts, 2)
+
+	require.Equal(t, gmaps.ConflictWebsite, conflicts[0].Field)
+	require.Equal(t, "burgerco.com", conflicts[0].Value)
+	require.Equal(t, gmaps.ConflictFranchise, conflicts[0].Cause)
+	require.Len(t, conflicts[0].Places, 2)
+
+	require.Equal(t, gmaps.ConflictPhone, conflicts[1].Field)
+	require.Equal(t, "065551234", conflicts[1].Value)
+	require.Equal(t, gmaps.ConflictDataEntry, conflicts[1].Cause)
+	require.Equal(t, "3", conflicts[1].Places[0].Cid)
+	require.Equal(t, "4", conflicts[1].Places[1].Cid)
+}
+
+func TestFindConflictsNone(t *testing.T) {
+	require.Empty(t, gmaps.FindConflicts(nil))
+	require.Empty(t, gmaps.FindConflicts([]*gmaps.Entry{
+		{Cid: "1", Title: "A", WebSite: "https://a.com", Phone: "+39 06 1234567"},
+		{Cid: "2", Title: "B", WebSite: "https://b.com", Phone: "+39 06 7654321"},
+	}))
+}
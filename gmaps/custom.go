@@ -0,0 +1,184 @@
+package gmaps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gosom/scrapemate"
+)
+
+// CustomExtractor reads a user defined field from the place page, to
+// collect a Google field the scraper does not know about yet.
+type CustomExtractor struct {
+	Field    string `json:"field_name"`
+	Selector string `json:"css_selector"`
+	// Attribute is read from the first element matching Selector. When it
+	// is empty the text of the element is read instead.
+	Attribute string `json:"attribute,omitempty"`
+}
+
+var (
+	customFieldRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	attributeRe   = regexp.MustCompile(`^[a-zA-Z_:][-a-zA-Z0-9_:.]*$`)
+)
+
+// Validate checks that the field has a usable CSV column name and a selector.
+func (c *CustomExtractor) Validate() error {
+	if !customFieldRe.MatchString(c.Field) {
+		return fmt.Errorf("invalid custom field name %q: use lowercase letters, digits and underscores", c.Field)
+	}
+
+	if slices.Contains((&Entry{}).CsvHeaders(), c.Field) || slices.Contains(ProvenanceCsvHeaders(), c.Field) {
+		return fmt.Errorf("custom field %q clashes with a built-in column", c.Field)
+	}
+
+	if strings.TrimSpace(c.Selector) == "" {
+		return fmt.Errorf("custom field %q has no css selector", c.Field)
+	}
+
+	if c.Attribute != "" && !attributeRe.MatchString(c.Attribute) {
+		return fmt.Errorf("custom field %q: invalid attribute %q", c.Field, c.Attribute)
+	}
+
+	return nil
+}
+
+// ValidateCustomExtractors validates every extractor and checks that their
+// field names are unique.
+func ValidateCustomExtractors(extractors []CustomExtractor) error {
+	seen := make(map[string]bool, len(extractors))
+
+	for i := range extractors {
+		if err := extractors[i].Validate(); err != nil {
+			return err
+		}
+
+		if seen[extractors[i].Field] {
+			return fmt.Errorf("duplicate custom field %q", extractors[i].Field)
+		}
+
+		seen[extractors[i].Field] = true
+	}
+
+	return nil
+}
+
+// ParseCustomExtractors parses extractors written as field=selector or
+// field=selector@attribute, separated by new lines or semicolons.
+func ParseCustomExtractors(s string) ([]CustomExtractor, error) {
+	var ans []CustomExtractor
+
+	items := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\n' || r == ';'
+	})
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		field, selector, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid custom field %q: expected field=selector[@attribute]", item)
+		}
+
+		c := CustomExtractor{
+			Field:    strings.TrimSpace(field),
+			Selector: strings.TrimSpace(selector),
+		}
+
+		// selectors seldom contain an @, attributes never do
+		if i := strings.LastIndex(c.Selector, "@"); i >= 0 && attributeRe.MatchString(c.Selector[i+1:]) {
+			c.Attribute = c.Selector[i+1:]
+			c.Selector = strings.TrimSpace(c.Selector[:i])
+		}
+
+		ans = append(ans, c)
+	}
+
+	if err := ValidateCustomExtractors(ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+// FormatCustomExtractors writes extractors one per line, in the syntax of
+// ParseCustomExtractors.
+func FormatCustomExtractors(extractors []CustomExtractor) string {
+	lines := make([]string, 0, len(extractors))
+
+	for _, c := range extractors {
+		line := c.Field + "=" + c.Selector
+		if c.Attribute != "" {
+			line += "@" + c.Attribute
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+const customFieldsJS = `(spec) => {
+	const out = {};
+	for (const [field, selector, attribute] of JSON.parse(spec)) {
+		let el = null;
+		try {
+			el = document.querySelector(selector);
+		} catch (e) {
+			continue;
+		}
+		if (!el) {
+			continue;
+		}
+		const value = attribute ? el.getAttribute(attribute) : el.textContent;
+		if (value !== null) {
+			out[field] = value.trim();
+		}
+	}
+	return out;
+}`
+
+// extractCustomFields reads the custom fields from the place page. Every
+// field is set, to an empty string when nothing matched, so that all the
+// entries of a job share the same CSV columns.
+func extractCustomFields(page scrapemate.BrowserPage, extractors []CustomExtractor) (map[string]string, error) {
+	ans := make(map[string]string, len(extractors))
+	spec := make([][3]string, 0, len(extractors))
+
+	for _, c := range extractors {
+		ans[c.Field] = ""
+		spec = append(spec, [3]string{c.Field, c.Selector, c.Attribute})
+	}
+
+	arg, err := json.Marshal(spec)
+	if err != nil {
+		return ans, err
+	}
+
+	raw, err := page.Eval(customFieldsJS, string(arg))
+	if err != nil {
+		return ans, err
+	}
+
+	values, ok := raw.(map[string]any)
+	if !ok {
+		return ans, errors.New("unexpected custom fields result")
+	}
+
+	for field, v := range values {
+		if s, ok := v.(string); ok {
+			if _, known := ans[field]; known {
+				ans[field] = s
+			}
+		}
+	}
+
+	return ans, nil
+}
@@ -0,0 +1,70 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCustomExtractors(t *testing.T) {
+	extractors, err := ParseCustomExtractors(`booking_url=a[data-item-id='action:4']@href;
+floor_info = button[data-item-id='oloc'] .fontBodyMedium
+mail=a[href^='mailto:a@b.c']`)
+	require.NoError(t, err)
+
+	require.Equal(t, []CustomExtractor{
+		{Field: "booking_url", Selector: "a[data-item-id='action:4']", Attribute: "href"},
+		{Field: "floor_info", Selector: "button[data-item-id='oloc'] .fontBodyMedium"},
+		// the @ belongs to the selector
+		{Field: "mail", Selector: "a[href^='mailto:a@b.c']"},
+	}, extractors)
+
+	back, err := ParseCustomExtractors(FormatCustomExtractors(extractors))
+	require.NoError(t, err)
+	require.Equal(t, extractors, back)
+
+	extractors, err = ParseCustomExtractors(" \n;")
+	require.NoError(t, err)
+	require.Empty(t, extractors)
+}
+
+func TestParseCustomExtractorsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing selector", input: "booking_url"},
+		{name: "empty selector", input: "booking_url="},
+		{name: "uppercase field", input: "BookingURL=a"},
+		{name: "built-in column", input: "title=h1"},
+		{name: "provenance column", input: "source_url=a@href"},
+		{name: "duplicate field", input: "a=h1;a=h2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCustomExtractors(tc.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEntryCustomFieldsCSV(t *testing.T) {
+	plain := &Entry{Title: "Kipriakon"}
+
+	e := &Entry{
+		Title: "Kipriakon",
+		CustomFields: map[string]string{
+			"floor_info":  "2nd floor",
+			"booking_url": "",
+		},
+	}
+
+	headers := e.CsvHeaders()
+	row := e.CsvRow()
+
+	require.Len(t, row, len(headers))
+	require.Equal(t, plain.CsvHeaders(), headers[:len(plain.CsvHeaders())])
+	require.Equal(t, []string{"booking_url", "floor_info"}, headers[len(plain.CsvHeaders()):])
+	require.Equal(t, []string{"", "2nd floor"}, row[len(plain.CsvRow()):])
+}
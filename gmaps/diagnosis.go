@@ -0,0 +1,147 @@
+package gmaps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosom/scrapemate"
+)
+
+// Causes of a ZeroResultDiagnosis.
+const (
+	ZeroResultConsentWall = "consent_wall"
+	ZeroResultCaptcha     = "captcha"
+	ZeroResultEmpty       = "no_results"
+	ZeroResultSinglePlace = "single_place"
+	ZeroResultUnknown     = "unknown"
+)
+
+// maxDiagnoses caps the pages a Diagnoser captures.
+const maxDiagnoses = 50
+
+var (
+	consentFormRe = regexp.MustCompile(`<form[^>]+action="https://consent\.google\.`)
+	captchaRe     = regexp.MustCompile(`(?i)g-recaptcha|recaptcha/api|unusual traffic`)
+	noResultsRe   = regexp.MustCompile(`(?i)role="feed"|google maps can(?:'|&#39;|’)t find`)
+)
+
+// ZeroResultDiagnosis tells why a search listed no place, with the page it
+// ended on.
+type ZeroResultDiagnosis struct {
+	// Cause is one of the ZeroResult causes.
+	Cause string `json:"cause"`
+	URL   string `json:"url"`
+	// Screenshot and HTML are the names of the files of the page in the
+	// folder of the Diagnoser, empty when they were not saved.
+	Screenshot string    `json:"screenshot,omitempty"`
+	HTML       string    `json:"html,omitempty"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// DiagnoseZeroResults returns the cause of a search ending on pageURL with
+// html without listing any place.
+func DiagnoseZeroResults(pageURL, html string) string {
+	switch {
+	case strings.Contains(pageURL, "consent.google.") || consentFormRe.MatchString(html):
+		return ZeroResultConsentWall
+	case isBlockedPage(pageURL) || captchaRe.MatchString(html):
+		return ZeroResultCaptcha
+	case strings.Contains(pageURL, "/maps/place/"):
+		return ZeroResultSinglePlace
+	case noResultsRe.MatchString(html):
+		return ZeroResultEmpty
+	default:
+		return ZeroResultUnknown
+	}
+}
+
+// blocked reports whether d explains the missing places by Google refusing
+// the search.
+func (d *ZeroResultDiagnosis) blocked() bool {
+	return d != nil && (d.Cause == ZeroResultConsentWall || d.Cause == ZeroResultCaptcha)
+}
+
+// Diagnoser captures the page of the searches that list no place, once per
+// keyword, so that "0 places found" can be explained without reproducing
+// the search. It is safe for concurrent use; a nil *Diagnoser captures
+// nothing.
+type Diagnoser struct {
+	dir string
+
+	mu    sync.Mutex
+	taken map[string]bool
+}
+
+// NewDiagnoser returns a Diagnoser saving the screenshot and the HTML of the
+// pages into dir, or only classifying them when dir is empty.
+func NewDiagnoser(dir string) *Diagnoser {
+	return &Diagnoser{dir: dir, taken: make(map[string]bool)}
+}
+
+// claim reports whether the page of a search of keyword is to be captured.
+func (d *Diagnoser) claim(keyword string) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.taken[keyword] || len(d.taken) >= maxDiagnoses {
+		return false
+	}
+
+	d.taken[keyword] = true
+
+	return true
+}
+
+// capture diagnoses the page a search of keyword ended on, or returns nil
+// when it was already done for keyword.
+func (d *Diagnoser) capture(page scrapemate.BrowserPage, keyword string) *ZeroResultDiagnosis {
+	if !d.claim(keyword) {
+		return nil
+	}
+
+	html, _ := page.Content()
+
+	ans := ZeroResultDiagnosis{
+		Cause:      DiagnoseZeroResults(page.URL(), html),
+		URL:        page.URL(),
+		CapturedAt: time.Now().UTC(),
+	}
+
+	if d.dir == "" {
+		return &ans
+	}
+
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return &ans
+	}
+
+	name := uuid.New().String()
+
+	if shot, err := page.Screenshot(false); err == nil && d.save(name+".png", shot) == nil {
+		ans.Screenshot = name + ".png"
+	}
+
+	if html != "" && d.save(name+".html", []byte(html)) == nil {
+		ans.HTML = name + ".html"
+	}
+
+	return &ans
+}
+
+func (d *Diagnoser) save(name string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(d.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("saving diagnosis: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package gmaps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+// diagnosisPage is a fakeBrowserPage stuck on url with html.
+type diagnosisPage struct {
+	fakeBrowserPage
+	url  string
+	html string
+}
+
+func (p *diagnosisPage) URL() string                     { return p.url }
+func (p *diagnosisPage) Content() (string, error)        { return p.html, nil }
+func (p *diagnosisPage) Screenshot(bool) ([]byte, error) { return []byte("png"), nil }
+
+func TestDiagnoseZeroResults(t *testing.T) {
+	tests := []struct {
+		url  string
+		html string
+		want string
+	}{
+		{"https://consent.google.com/ml?continue=https://www.google.com/maps", "", ZeroResultConsentWall},
+		{"https://www.google.com/maps/search/pizza", `<form action="https://consent.google.com/save" method="POST">`, ZeroResultConsentWall},
+		{"https://www.google.com/sorry/index?continue=x", "", ZeroResultCaptcha},
+		{"https://www.google.com/maps/search/pizza", `<div class="g-recaptcha"></div>`, ZeroResultCaptcha},
+		{"https://www.google.com/maps/place/Pizzeria/@1,2,17z", "", ZeroResultSinglePlace},
+		{"https://www.google.com/maps/search/xyzzy", `<div role="feed"></div>`, ZeroResultEmpty},
+		{"https://www.google.com/maps/search/xyzzy", `<div>Google Maps can&#39;t find xyzzy</div>`, ZeroResultEmpty},
+		{"https://www.google.com/maps/search/xyzzy", `<div></div>`, ZeroResultUnknown},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, DiagnoseZeroResults(tt.url, tt.html), tt.url+" "+tt.html)
+	}
+}
+
+func TestDiagnoserCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diagnosis")
+	d := NewDiagnoser(dir)
+
+	page := &diagnosisPage{url: "https://www.google.com/maps/search/xyzzy", html: `<div role="feed"></div>`}
+
+	diag := d.capture(page, "xyzzy")
+	require.NotNil(t, diag)
+	require.Equal(t, ZeroResultEmpty, diag.Cause)
+	require.Equal(t, page.url, diag.URL)
+
+	shot, err := os.ReadFile(filepath.Join(dir, diag.Screenshot))
+	require.NoError(t, err)
+	require.Equal(t, "png", string(shot))
+
+	html, err := os.ReadFile(filepath.Join(dir, diag.HTML))
+	require.NoError(t, err)
+	require.Equal(t, page.html, string(html))
+
+	// once per keyword
+	require.Nil(t, d.capture(page, "xyzzy"))
+
+	// without a folder the page is only classified
+	diag = NewDiagnoser("").capture(page, "xyzzy")
+	require.NotNil(t, diag)
+	require.Empty(t, diag.Screenshot)
+
+	var nilDiagnoser *Diagnoser
+
+	require.Nil(t, nilDiagnoser.capture(page, "xyzzy"))
+}
+
+func TestGmapJobZeroResultDiagnosis(t *testing.T) {
+	stats := NewKeywordStats()
+	stats.AddSeed("pizza")
+
+	job := NewGmapJob("", "en", "pizza", 1, false, "", 0, WithKeywordStats(stats))
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html></html>"))
+	require.NoError(t, err)
+
+	resp := scrapemate.Response{
+		URL:      "https://www.google.com/maps/search/pizza",
+		Document: doc,
+		Meta:     map[string]any{"diagnosis": &ZeroResultDiagnosis{Cause: ZeroResultConsentWall}},
+	}
+
+	_, next, err := job.Process(context.Background(), &resp)
+	require.NoError(t, err)
+	require.Empty(t, next)
+
+	report := stats.Report()
+	require.Equal(t, KeywordBlocked, report[0].Status)
+	require.Equal(t, "blocked by google: consent_wall", report[0].Error)
+	require.Equal(t, ZeroResultConsentWall, report[0].Diagnosis.Cause)
+}
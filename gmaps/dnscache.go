@@ -0,0 +1,219 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDNSCacheTTL         = 10 * time.Minute
+	defaultDNSCacheNegativeTTL = time.Minute
+	defaultDNSMaxConcurrent    = 16
+	// maxDNSCacheEntries bounds the names a DNSCache holds.
+	maxDNSCacheEntries = 10000
+	// dnsLookupTimeout bounds a lookup, waiting for its turn included.
+	dnsLookupTimeout = 30 * time.Second
+)
+
+// DNSCache resolves MX and host records with a shared cache so that the
+// many places of a franchise, which share a website domain, hit the resolver
+// once. Concurrent lookups of the same name wait for a single query, and the
+// number of queries in flight is bounded to avoid resolver throttling.
+//
+// Answers are kept for the TTL, "no such host" answers for the negative TTL;
+// other errors (timeouts, SERVFAIL) are not cached. The expired answers are
+// dropped once the cache is full. A lookup runs on its own, so that a caller
+// giving up does not fail the others waiting for the same name.
+type DNSCache struct {
+	lookupMX    func(ctx context.Context, domain string) ([]*net.MX, error)
+	lookupHost  func(ctx context.Context, host string) ([]string, error)
+	ttl         time.Duration
+	negativeTTL time.Duration
+	sem         chan struct{}
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	ready   chan struct{}
+	expires time.Time
+
+	mx    []*net.MX
+	hosts []string
+	err   error
+}
+
+// DNSCacheOption configures a DNSCache.
+type DNSCacheOption func(*DNSCache)
+
+// WithDNSCacheTTL sets how long successful and "no such host" answers are
+// kept.
+func WithDNSCacheTTL(ttl, negativeTTL time.Duration) DNSCacheOption {
+	return func(c *DNSCache) {
+		c.ttl = ttl
+		c.negativeTTL = negativeTTL
+	}
+}
+
+// WithDNSCacheMaxConcurrent bounds the lookups sent to the resolver at the
+// same time.
+func WithDNSCacheMaxConcurrent(n int) DNSCacheOption {
+	return func(c *DNSCache) {
+		if n > 0 {
+			c.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithDNSCacheResolver uses r instead of net.DefaultResolver.
+func WithDNSCacheResolver(r *net.Resolver) DNSCacheOption {
+	return func(c *DNSCache) {
+		c.lookupMX = r.LookupMX
+		c.lookupHost = r.LookupHost
+	}
+}
+
+// NewDNSCache creates an empty DNSCache.
+func NewDNSCache(opts ...DNSCacheOption) *DNSCache {
+	c := DNSCache{
+		lookupMX:    net.DefaultResolver.LookupMX,
+		lookupHost:  net.DefaultResolver.LookupHost,
+		ttl:         defaultDNSCacheTTL,
+		negativeTTL: defaultDNSCacheNegativeTTL,
+		sem:         make(chan struct{}, defaultDNSMaxConcurrent),
+		now:         time.Now,
+		entries:     make(map[string]*dnsCacheEntry),
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
+
+// LookupMX returns the MX records of domain. The returned slice is a copy
+// the caller may reorder.
+func (c *DNSCache) LookupMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	e, err := c.lookup(ctx, "mx:"+domain, func(ctx context.Context, e *dnsCacheEntry) {
+		e.mx, e.err = c.lookupMX(ctx, domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(e.mx), e.err
+}
+
+// LookupHost returns the addresses of host.
+func (c *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	e, err := c.lookup(ctx, "host:"+host, func(ctx context.Context, e *dnsCacheEntry) {
+		e.hosts, e.err = c.lookupHost(ctx, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(e.hosts), e.err
+}
+
+// lookup returns the cached entry of key, running resolve when it is missing
+// or expired. The returned error is set only when ctx ended while waiting;
+// resolver errors are carried by the entry.
+func (c *DNSCache) lookup(ctx context.Context, key string, resolve func(context.Context, *dnsCacheEntry)) (*dnsCacheEntry, error) {
+	c.mu.Lock()
+
+	e, ok := c.entries[key]
+	if !ok || e.resolved() && !c.now().Before(e.expires) {
+		e = &dnsCacheEntry{ready: make(chan struct{})}
+		c.store(key, e)
+
+		// on behalf of all the callers of key, whichever gives up
+		go c.resolve(context.WithoutCancel(ctx), key, e, resolve)
+	}
+
+	c.mu.Unlock()
+
+	select {
+	case <-e.ready:
+		return e, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve runs resolve for the entry e of key and sets how long e is kept.
+func (c *DNSCache) resolve(ctx context.Context, key string, e *dnsCacheEntry, resolve func(context.Context, *dnsCacheEntry)) {
+	defer close(e.ready)
+
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		c.forget(key, e)
+		e.err = ctx.Err()
+
+		return
+	}
+
+	resolve(ctx, e)
+
+	<-c.sem
+
+	var dnsErr *net.DNSError
+
+	switch {
+	case e.err == nil:
+		e.expires = c.now().Add(c.ttl)
+	case errors.As(e.err, &dnsErr) && dnsErr.IsNotFound:
+		e.expires = c.now().Add(c.negativeTTL)
+	default:
+		c.forget(key, e)
+	}
+}
+
+// store adds e as the entry of key, dropping the expired entries when the
+// cache is full. e is not kept when they were all fresh. c.mu must be held.
+func (c *DNSCache) store(key string, e *dnsCacheEntry) {
+	if len(c.entries) >= maxDNSCacheEntries {
+		now := c.now()
+
+		for k, old := range c.entries {
+			if old.resolved() && !now.Before(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	if _, ok := c.entries[key]; ok || len(c.entries) < maxDNSCacheEntries {
+		c.entries[key] = e
+	}
+}
+
+// resolved reports whether the lookup of e is over.
+func (e *dnsCacheEntry) resolved() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// forget drops e unless it was already replaced.
+func (c *DNSCache) forget(key string, e *dnsCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[key] == e {
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,204 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCacheSharesLookups(t *testing.T) {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+
+	c := NewDNSCache()
+	c.lookupMX = func(_ context.Context, domain string) ([]*net.MX, error) {
+		calls.Add(1)
+		<-release
+
+		return []*net.MX{{Host: "mx." + domain + ".", Pref: 10}}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			mxs, err := c.LookupMX(context.Background(), "burgerco.com")
+			require.NoError(t, err)
+			require.Equal(t, "mx.burgerco.com.", mxs[0].Host)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestDNSCacheTTL(t *testing.T) {
+	var calls int
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewDNSCache(WithDNSCacheTTL(time.Minute, 10*time.Second))
+	c.now = func() time.Time { return now }
+	c.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		calls++
+
+		switch host {
+		case "missing.example":
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		case "flaky.example":
+			return nil, errors.New("i/o timeout")
+		default:
+			return []string{"192.0.2.1"}, nil
+		}
+	}
+
+	ctx := context.Background()
+
+	for range 3 {
+		_, _ = c.LookupHost(ctx, "shop.example")
+		_, _ = c.LookupHost(ctx, "missing.example")
+	}
+
+	require.Equal(t, 2, calls)
+
+	// transient errors are retried
+	_, err := c.LookupHost(ctx, "flaky.example")
+	require.Error(t, err)
+	_, _ = c.LookupHost(ctx, "flaky.example")
+	require.Equal(t, 4, calls)
+
+	// the negative answer expires first
+	now = now.Add(30 * time.Second)
+	_, _ = c.LookupHost(ctx, "shop.example")
+	_, err = c.LookupHost(ctx, "missing.example")
+	require.True(t, isNotFound(err))
+	require.Equal(t, 5, calls)
+
+	now = now.Add(time.Minute)
+	addrs, err := c.LookupHost(ctx, "shop.example")
+	require.NoError(t, err)
+	require.Equal(t, []string{"192.0.2.1"}, addrs)
+	require.Equal(t, 6, calls)
+}
+
+func TestDNSCacheLimitsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+
+	c := NewDNSCache(WithDNSCacheMaxConcurrent(2))
+	c.lookupMX = func(_ context.Context, _ string) ([]*net.MX, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		return nil, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for _, domain := range []string{"a.com", "b.com", "c.com", "d.com", "e.com", "f.com"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _ = c.LookupMX(context.Background(), domain)
+		}()
+	}
+
+	wg.Wait()
+
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestDNSCacheCallerGivingUp(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var calls atomic.Int32
+
+	c := NewDNSCache()
+	c.lookupHost = func(ctx context.Context, _ string) ([]string, error) {
+		calls.Add(1)
+		close(started)
+
+		select {
+		case <-release:
+			return []string{"192.0.2.1"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error)
+
+	go func() {
+		_, err := c.LookupHost(first, "shop.example")
+		errs <- err
+	}()
+
+	<-started
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	go func() {
+		_, err := c.LookupHost(context.Background(), "shop.example")
+		errs <- err
+	}()
+
+	// the lookup goes on for the next caller: a canceled one would fail it or
+	// be run again
+	close(release)
+	require.NoError(t, <-errs)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestDNSCacheBounded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewDNSCache(WithDNSCacheTTL(time.Minute, time.Minute))
+	c.now = func() time.Time { return now }
+	c.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+
+	ctx := context.Background()
+
+	for i := range maxDNSCacheEntries + 10 {
+		_, err := c.LookupHost(ctx, fmt.Sprintf("shop%d.example", i))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, c.entries, maxDNSCacheEntries)
+
+	// the expired answers make room
+	now = now.Add(2 * time.Minute)
+
+	_, err := c.LookupHost(ctx, "new.example")
+	require.NoError(t, err)
+	require.Len(t, c.entries, 1)
+}
@@ -0,0 +1,174 @@
+package gmaps
+
+import (
+	"slices"
+	"strings"
+)
+
+// MaxEmailConfidence is the score of an email found as a mailto link on the
+// contact page, on the website's own domain, with a contact-type prefix.
+const MaxEmailConfidence = 100
+
+// contactPrefixes are local parts businesses publish for customers to reach
+// them. They score higher than personal addresses.
+var contactPrefixes = map[string]bool{
+	"info":            true,
+	"contact":         true,
+	"contacts":        true,
+	"hello":           true,
+	"office":          true,
+	"mail":            true,
+	"email":           true,
+	"enquiries":       true,
+	"inquiries":       true,
+	"sales":           true,
+	"booking":         true,
+	"bookings":        true,
+	"reservations":    true,
+	"orders":          true,
+	"shop":            true,
+	"kontakt":         true,
+	"contatti":        true,
+	"contacto":        true,
+	"segreteria":      true,
+	"amministrazione": true,
+}
+
+// roleOnlyPrefixes are technical or back-office mailboxes that rarely answer
+// customers: they get no prefix bonus.
+var roleOnlyPrefixes = map[string]bool{
+	"webmaster":     true,
+	"admin":         true,
+	"administrator": true,
+	"postmaster":    true,
+	"hostmaster":    true,
+	"abuse":         true,
+	"privacy":       true,
+	"gdpr":          true,
+	"dpo":           true,
+	"legal":         true,
+	"jobs":          true,
+	"careers":       true,
+	"hr":            true,
+	"billing":       true,
+	"press":         true,
+	"security":      true,
+	"dev":           true,
+	"it":            true,
+}
+
+// freeMailDomains are webmail providers: a small business on gmail is still
+// a plausible contact, just less certain than one on its own domain.
+var freeMailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"yahoo.com":      true,
+	"yahoo.it":       true,
+	"yahoo.fr":       true,
+	"yahoo.co.uk":    true,
+	"hotmail.com":    true,
+	"hotmail.it":     true,
+	"hotmail.fr":     true,
+	"outlook.com":    true,
+	"outlook.it":     true,
+	"live.com":       true,
+	"live.it":        true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"aol.com":        true,
+	"gmx.de":         true,
+	"gmx.net":        true,
+	"web.de":         true,
+	"t-online.de":    true,
+	"libero.it":      true,
+	"virgilio.it":    true,
+	"alice.it":       true,
+	"tiscali.it":     true,
+	"orange.fr":      true,
+	"free.fr":        true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"yandex.ru":      true,
+	"mail.ru":        true,
+}
+
+// scoreEmail rates from 0 to MaxEmailConfidence how likely email is the
+// business's real contact address:
+//
+//	source page:  contact page 25, homepage 20, deep-crawl page 15
+//	found as:     mailto link 25, plain text 10
+//	domain:       website domain 35, free webmail 15, anything else 0
+//	local part:   contact-type 15, personal 10, technical/back-office 0
+func scoreEmail(email, website, source string, mailto bool) int {
+	local, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return 0
+	}
+
+	score := 0
+
+	switch strings.TrimPrefix(source, "browser_") {
+	case "contact_page":
+		score += 25
+	case "homepage":
+		score += 20
+	case "deep_crawl_page":
+		score += 15
+	}
+
+	if mailto {
+		score += 25
+	} else {
+		score += 10
+	}
+
+	switch siteDomain := ChainDomain(website); {
+	case siteDomain != "" && ChainDomain(domain) == siteDomain:
+		score += 35
+	case freeMailDomains[domain]:
+		score += 15
+	}
+
+	// info+web@, sales.rome@ and similar variants count as their base prefix
+	if idx := strings.IndexAny(local, "+.-_"); idx > 0 && !contactPrefixes[local] {
+		if base := local[:idx]; contactPrefixes[base] || roleOnlyPrefixes[base] {
+			local = base
+		}
+	}
+
+	switch {
+	case contactPrefixes[local]:
+		score += 15
+	case roleOnlyPrefixes[local]:
+		// no bonus
+	default:
+		score += 10
+	}
+
+	return score
+}
+
+// setFoundEmails stores the emails found on a page ordered by confidence,
+// best first, and sets Entry.EmailConfidence to the best score. mailto holds
+// the addresses that appeared as mailto links.
+func (e *Entry) setFoundEmails(emails []string, source string, mailto map[string]bool) {
+	scores := make(map[string]int, len(emails))
+	for _, email := range emails {
+		scores[email] = scoreEmail(email, e.WebSite, source, mailto[email])
+	}
+
+	slices.SortStableFunc(emails, func(a, b string) int {
+		return scores[b] - scores[a]
+	})
+
+	e.Emails = emails
+	e.EmailStatus = "found"
+	e.EmailSource = source
+	e.EmailConfidence = scores[emails[0]]
+}
+
+// HasEmailConfidence reports whether the entry has an email scored at least
+// minConfidence. A non-positive threshold accepts every entry.
+func (e *Entry) HasEmailConfidence(minConfidence int) bool {
+	return minConfidence <= 0 || (len(e.Emails) > 0 && e.EmailConfidence >= minConfidence)
+}
@@ -0,0 +1,91 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		website string
+		source  string
+		mailto  bool
+		want    int
+	}{
+		{
+			name:    "contact page mailto on own domain",
+			email:   "info@bakery.it",
+			website: "https://www.bakery.it/",
+			source:  "contact_page",
+			mailto:  true,
+			want:    MaxEmailConfidence,
+		},
+		{
+			name:    "subdomain website matches registrable domain",
+			email:   "sales@bakery.co.uk",
+			website: "https://shop.bakery.co.uk",
+			source:  "homepage",
+			mailto:  false,
+			want:    20 + 10 + 35 + 15,
+		},
+		{
+			name:    "personal gmail in page text",
+			email:   "mario.rossi@gmail.com",
+			website: "https://bakery.it",
+			source:  "browser_homepage",
+			mailto:  false,
+			want:    20 + 10 + 15 + 10,
+		},
+		{
+			name:    "webmaster of the web agency",
+			email:   "webmaster@agency.com",
+			website: "https://bakery.it",
+			source:  "deep_crawl_page",
+			mailto:  true,
+			want:    15 + 25,
+		},
+		{
+			name:    "prefix variant counts as its base",
+			email:   "info.rome@bakery.it",
+			website: "bakery.it",
+			source:  "homepage",
+			mailto:  true,
+			want:    20 + 25 + 35 + 15,
+		},
+		{
+			name:  "not an email",
+			email: "bakery.it",
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, scoreEmail(tt.email, tt.website, tt.source, tt.mailto))
+		})
+	}
+}
+
+func TestEntrySetFoundEmailsSortsByConfidence(t *testing.T) {
+	entry := &Entry{WebSite: "https://bakery.it"}
+
+	entry.setFoundEmails(
+		[]string{"privacy@agency.com", "owner@gmail.com", "info@bakery.it"},
+		"homepage",
+		map[string]bool{"info@bakery.it": true},
+	)
+
+	require.Equal(t, []string{"info@bakery.it", "owner@gmail.com", "privacy@agency.com"}, entry.Emails)
+	require.Equal(t, "found", entry.EmailStatus)
+	require.Equal(t, "homepage", entry.EmailSource)
+	require.Equal(t, 20+25+35+15, entry.EmailConfidence)
+
+	require.True(t, entry.HasEmailConfidence(0))
+	require.True(t, entry.HasEmailConfidence(95))
+	require.False(t, entry.HasEmailConfidence(96))
+	require.False(t, (&Entry{}).HasEmailConfidence(1))
+	require.True(t, (&Entry{}).HasEmailConfidence(0))
+}
@@ -0,0 +1,86 @@
+package gmaps
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+// acceptEncoding is sent by fetchPage. Setting it by hand disables the
+// transparent gzip handling of net/http, so decompressBody handles every
+// listed encoding.
+const acceptEncoding = "gzip, deflate, br"
+
+// errNonHTMLContent is returned for responses that cannot contain an HTML
+// page (PDFs, images, archives...), before their body is read.
+var errNonHTMLContent = errors.New("non-HTML content")
+
+// isHTMLContentType reports whether a Content-Type header may carry a page
+// worth scanning for emails. A missing or unparsable header is accepted
+// since many small-business servers omit it.
+func isHTMLContentType(contentType string) bool {
+	if strings.TrimSpace(contentType) == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/xhtml+xml", mediaType == "application/xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// decompressBody wraps body according to the Content-Encoding header.
+func decompressBody(body io.Reader, contentEncoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		return zlib.NewReader(body)
+	case "br":
+		return brotli.NewReader(body), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+	}
+}
+
+// toUTF8 decodes a page to UTF-8 using the charset of the Content-Type
+// header, a BOM or the <meta charset> tag, so emails on ISO-8859 or
+// Windows-1252 pages are not mangled before the regex runs.
+func toUTF8(body []byte, contentType string) []byte {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" {
+		return body
+	}
+
+	// the detection only sniffs the first 1KB and falls back to
+	// windows-1252: keep bodies that are valid UTF-8 as they are
+	if !certain && utf8.Valid(body) {
+		return body
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+
+	return decoded
+}
@@ -0,0 +1,142 @@
+package gmaps
+
+import (
+	"encoding/hex"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mcnijman/go-emailaddress"
+)
+
+var (
+	// obfuscatedAt matches "[at]", "(at)", "{at}" and their spaced or
+	// uppercase variants. A bare " at " is left alone: it is far too common
+	// in running text.
+	obfuscatedAt = regexp.MustCompile(`(?i)\s*(?:\[\s*at\s*\]|\(\s*at\s*\)|\{\s*at\s*\})\s*`)
+	// obfuscatedDot matches "[dot]", "(dot)" and "{dot}".
+	obfuscatedDot = regexp.MustCompile(`(?i)\s*(?:\[\s*dot\s*\]|\(\s*dot\s*\)|\{\s*dot\s*\})\s*`)
+
+	// cfEmailPattern finds Cloudflare email-protection payloads in raw HTML,
+	// both in data-cfemail attributes and in /cdn-cgi/l/email-protection#
+	// links.
+	cfEmailPattern = regexp.MustCompile(`(?i)(?:data-cfemail=["']|/cdn-cgi/l/email-protection#)([0-9a-f]+)`)
+
+	// jsConcatPattern matches two or more quoted JavaScript strings joined
+	// with "+", e.g. 'info' + '@' + 'shop.com'.
+	jsConcatPattern = regexp.MustCompile(`(?:'[^'\n]*'|"[^"\n]*")(?:\s*\+\s*(?:'[^'\n]*'|"[^"\n]*"))+`)
+	jsStringPattern = regexp.MustCompile(`'([^'\n]*)'|"([^"\n]*)"`)
+)
+
+// deobfuscateText rewrites "info [at] shop [dot] com" style addresses into
+// their plain form.
+func deobfuscateText(s string) string {
+	s = obfuscatedAt.ReplaceAllString(s, "@")
+
+	return obfuscatedDot.ReplaceAllString(s, ".")
+}
+
+// decodeCFEmail decodes the hex payload of a Cloudflare email-protection
+// span: the first byte is the XOR key for the remaining ones.
+func decodeCFEmail(encoded string) (string, bool) {
+	raw, err := hex.DecodeString(encoded)
+	if err != nil || len(raw) < 2 {
+		return "", false
+	}
+
+	key := raw[0]
+	decoded := make([]byte, len(raw)-1)
+
+	for i, b := range raw[1:] {
+		decoded[i] = b ^ key
+	}
+
+	return string(decoded), true
+}
+
+// joinJSConcatenations returns the strings built by the quoted
+// concatenations found in a JavaScript snippet.
+func joinJSConcatenations(script string) []string {
+	matches := jsConcatPattern.FindAllString(script, -1)
+	joined := make([]string, 0, len(matches))
+
+	for _, m := range matches {
+		var sb strings.Builder
+
+		for _, part := range jsStringPattern.FindAllStringSubmatch(m, -1) {
+			sb.WriteString(part[1])
+			sb.WriteString(part[2])
+		}
+
+		joined = append(joined, sb.String())
+	}
+
+	return joined
+}
+
+// findEmails runs the address regex on s after undoing the text
+// obfuscations.
+func findEmails(s string) []string {
+	found := emailaddress.Find([]byte(deobfuscateText(s)), false)
+	emails := make([]string, 0, len(found))
+
+	for _, addr := range found {
+		emails = append(emails, addr.String())
+	}
+
+	return emails
+}
+
+// extractObfuscatedEmailsFromDoc collects the addresses hidden behind
+// Cloudflare email protection and JavaScript string concatenation in
+// onclick handlers or javascript: links.
+func extractObfuscatedEmailsFromDoc(doc *goquery.Document) []string {
+	var emails []string
+
+	doc.Find("[data-cfemail]").Each(func(_ int, s *goquery.Selection) {
+		if decoded, ok := decodeCFEmail(s.AttrOr("data-cfemail", "")); ok {
+			emails = append(emails, decoded)
+		}
+	})
+
+	doc.Find("a[href*='/cdn-cgi/l/email-protection#']").Each(func(_ int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		if idx := strings.LastIndex(href, "#"); idx >= 0 {
+			if decoded, ok := decodeCFEmail(href[idx+1:]); ok {
+				emails = append(emails, strings.TrimPrefix(decoded, "mailto:"))
+			}
+		}
+	})
+
+	doc.Find("[onclick], a[href^='javascript:']").Each(func(_ int, s *goquery.Selection) {
+		for _, attr := range []string{"onclick", "href"} {
+			for _, joined := range joinJSConcatenations(s.AttrOr(attr, "")) {
+				emails = append(emails, findEmails(joined)...)
+			}
+		}
+	})
+
+	return emails
+}
+
+// extractObfuscatedEmailsFromHTML is the raw-bytes counterpart of
+// extractObfuscatedEmailsFromDoc; it returns the plain addresses as well.
+// Entities are decoded first so that "info&#64;shop.com" is matched too.
+func extractObfuscatedEmailsFromHTML(body []byte) []string {
+	text := html.UnescapeString(string(body))
+
+	emails := findEmails(text)
+
+	for _, m := range cfEmailPattern.FindAllStringSubmatch(text, -1) {
+		if decoded, ok := decodeCFEmail(m[1]); ok {
+			emails = append(emails, strings.TrimPrefix(decoded, "mailto:"))
+		}
+	}
+
+	for _, joined := range joinJSConcatenations(text) {
+		emails = append(emails, findEmails(joined)...)
+	}
+
+	return emails
+}
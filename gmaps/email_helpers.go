@@ -1,6 +1,7 @@
 package gmaps
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -80,15 +81,47 @@ func deduplicateEmails(emails []string) []string {
 	return result
 }
 
-// extractEmailsFromDoc extracts emails from a parsed HTML document using two
+// extractEmailsFromDoc extracts emails from a parsed HTML document using three
 // strategies: first it looks for mailto: links, then it scans visible text
-// (with script and style elements removed). All results are validated and
-// deduplicated.
+// (with script and style elements removed, "[at]"/"[dot]" undone) and finally
+// it decodes Cloudflare-protected and JavaScript-concatenated addresses. All
+// results are validated and deduplicated.
 func extractEmailsFromDoc(doc *goquery.Document) []string {
+	// Strategy 1: mailto links.
+	emails := extractMailtoEmails(doc)
+
+	seen := make(map[string]bool, len(emails))
+	for _, e := range emails {
+		seen[e] = true
+	}
+
+	// Strategy 2: scan visible text after removing script/style elements.
+	docCopy := doc.Clone()
+	docCopy.Find("script, style, noscript").Remove()
+	text := docCopy.Text()
+	found := findEmails(text)
+
+	// Strategy 3: Cloudflare email protection and onclick concatenations.
+	found = append(found, extractObfuscatedEmailsFromDoc(doc)...)
+
+	for _, addr := range found {
+		lower := strings.ToLower(addr)
+		if isValidEmail(lower) && !seen[lower] {
+			seen[lower] = true
+			emails = append(emails, lower)
+		}
+	}
+
+	return emails
+}
+
+// extractMailtoEmails returns the validated, lowercased addresses of the
+// mailto: links in doc.
+func extractMailtoEmails(doc *goquery.Document) []string {
 	var emails []string
 	seen := make(map[string]bool)
 
-	// Strategy 1: mailto links (case-insensitive selector via goquery).
+	// Case-insensitive selector via goquery.
 	doc.Find("a[href^='mailto:'], a[href^='Mailto:'], a[href^='MAILTO:']").Each(func(_ int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
 		if !exists {
@@ -107,6 +140,11 @@ func extractEmailsFromDoc(doc *goquery.Document) []string {
 			value = value[:idx]
 		}
 
+		// Percent-encoded addresses (info%40shop.com).
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+
 		value = strings.TrimSpace(value)
 		if value == "" {
 			return
@@ -119,33 +157,20 @@ func extractEmailsFromDoc(doc *goquery.Document) []string {
 		}
 	})
 
-	// Strategy 2: scan visible text after removing script/style elements.
-	docCopy := doc.Clone()
-	docCopy.Find("script, style, noscript").Remove()
-	text := docCopy.Text()
-
-	found := emailaddress.Find([]byte(text), false)
-	for _, addr := range found {
-		lower := strings.ToLower(addr.String())
-		if isValidEmail(lower) && !seen[lower] {
-			seen[lower] = true
-			emails = append(emails, lower)
-		}
-	}
-
 	return emails
 }
 
 // extractEmailsFromHTML extracts emails from raw HTML bytes using a regex
-// approach via go-emailaddress. Results are filtered through isValidEmail.
+// approach via go-emailaddress, after decoding entities and the common
+// obfuscations. Results are filtered through isValidEmail.
 func extractEmailsFromHTML(body []byte) []string {
-	addresses := emailaddress.Find(body, false)
+	addresses := extractObfuscatedEmailsFromHTML(body)
 
 	seen := make(map[string]bool, len(addresses))
 	var emails []string
 
 	for _, addr := range addresses {
-		lower := strings.ToLower(addr.String())
+		lower := strings.ToLower(addr)
 		if isValidEmail(lower) && !seen[lower] {
 			seen[lower] = true
 			emails = append(emails, lower)
@@ -145,6 +145,29 @@ func TestExtractEmailsFromDoc(t *testing.T) {
 			html:   `<html><body><p>No emails here</p></body></html>`,
 			expect: nil,
 		},
+		{
+			name:   "decodes bracketed at and dot",
+			html:   `<html><body><p>Write to info [at] bakery [dot] com or sales(AT)bakery(DOT)com</p></body></html>`,
+			expect: []string{"info@bakery.com", "sales@bakery.com"},
+		},
+		{
+			name: "decodes html entities and percent-encoded mailto",
+			html: `<html><body><a href="mailto:shop%40bakery.com">Mail</a>
+				<p>&#105;nfo&#64;bakery&#46;com</p></body></html>`,
+			expect: []string{"shop@bakery.com", "info@bakery.com"},
+		},
+		{
+			name: "decodes cloudflare email protection",
+			html: `<html><body>
+				<a href="/cdn-cgi/l/email-protection" class="__cf_email__" data-cfemail="422b2c242d02312a2d326c2b36">[email&#160;protected]</a>
+			</body></html>`,
+			expect: []string{"info@shop.it"},
+		},
+		{
+			name:   "joins javascript concatenations in onclick",
+			html:   `<html><body><a href="#" onclick="location.href='mai'+'lto:'+'info'+'@'+'bakery.com'">Mail</a></body></html>`,
+			expect: []string{"info@bakery.com"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -178,6 +201,11 @@ func TestExtractEmailsFromHTML(t *testing.T) {
 			body:   []byte(`<html><body><p>No emails here at all.</p></body></html>`),
 			expect: nil,
 		},
+		{
+			name:   "decodes obfuscations in raw HTML",
+			body:   []byte(`<p>info&#64;shop.com</p><a href="/cdn-cgi/l/email-protection#422b2c242d02312a2d326c2b36">x</a><script>var m = "sales" + "@" + "shop.com";</script>`),
+			expect: []string{"info@shop.com", "info@shop.it", "sales@shop.com"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -252,3 +280,15 @@ func TestExtractEmailsFromText(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeCFEmail(t *testing.T) {
+	got, ok := decodeCFEmail("422b2c242d02312a2d326c2b36")
+	require.True(t, ok)
+	require.Equal(t, "info@shop.it", got)
+
+	_, ok = decodeCFEmail("zz")
+	require.False(t, ok)
+
+	_, ok = decodeCFEmail("42")
+	require.False(t, ok)
+}
@@ -0,0 +1,106 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEmailHostInterval is the default time between two fetches of
+	// the same website by the email pipelines.
+	DefaultEmailHostInterval = 250 * time.Millisecond
+	// maxPacedHosts bounds the websites a WebsitePacer remembers; the ones
+	// free to fetch again are forgotten first.
+	maxPacedHosts = 10000
+)
+
+// WebsitePacer spaces the HTTP fetches of the email pipelines of a job to
+// one website, so that the contact pages fetched at once and the places
+// sharing a website do not hammer the server of a small business. The
+// other websites are not slowed down. It is safe for concurrent use; a nil
+// *WebsitePacer never waits.
+type WebsitePacer struct {
+	interval time.Duration
+
+	mu    sync.Mutex
+	next  map[string]time.Time
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewWebsitePacer returns the pacer keeping the fetches of a website
+// interval apart, nil when interval is not positive.
+func NewWebsitePacer(interval time.Duration) *WebsitePacer {
+	if interval <= 0 {
+		return nil
+	}
+
+	return &WebsitePacer{
+		interval: interval,
+		next:     make(map[string]time.Time),
+		now:      time.Now,
+		after:    time.After,
+	}
+}
+
+// ParseEmailHostInterval parses the time between two fetches of a website,
+// a Go duration: DefaultEmailHostInterval when s is empty, 0 to fetch
+// without waiting.
+func ParseEmailHostInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultEmailHostInterval, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errors.New("invalid email host interval (use a Go duration like 250ms, 0 to disable)")
+	}
+
+	return d, nil
+}
+
+// Wait blocks until the website of u may be fetched, or until ctx ends.
+func (p *WebsitePacer) Wait(ctx context.Context, u *url.URL) error {
+	if p == nil || u == nil {
+		return nil
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	p.mu.Lock()
+
+	now := p.now()
+
+	if len(p.next) >= maxPacedHosts {
+		for h, at := range p.next {
+			if !at.After(now) {
+				delete(p.next, h)
+			}
+		}
+	}
+
+	at := p.next[host]
+	if at.Before(now) {
+		at = now
+	}
+
+	p.next[host] = at.Add(p.interval)
+
+	p.mu.Unlock()
+
+	if !at.After(now) {
+		return nil
+	}
+
+	select {
+	case <-p.after(at.Sub(now)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package gmaps
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebsitePacerSpacesFetchesOfAWebsite(t *testing.T) {
+	require.Nil(t, NewWebsitePacer(0))
+
+	var none *WebsitePacer
+	require.NoError(t, none.Wait(context.Background(), &url.URL{Host: "shop.example"}))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var waits []time.Duration
+
+	p := NewWebsitePacer(time.Second)
+	p.now = func() time.Time { return now }
+	p.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+
+		ch := make(chan time.Time, 1)
+		ch <- now.Add(d)
+
+		return ch
+	}
+
+	ctx := context.Background()
+
+	for _, site := range []string{"https://baeckerei.de/", "https://www.baeckerei.de/kontakt", "https://baeckerei.de/impressum", "https://metzgerei.de/"} {
+		u, err := url.Parse(site)
+		require.NoError(t, err)
+		require.NoError(t, p.Wait(ctx, u))
+	}
+
+	// the first page of each website goes at once
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	now = now.Add(5 * time.Second)
+
+	u, _ := url.Parse("https://baeckerei.de/")
+	require.NoError(t, p.Wait(ctx, u))
+	require.Len(t, waits, 2)
+
+	// a caller giving up does not wait
+	p.after = func(time.Duration) <-chan time.Time { return nil }
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	require.ErrorIs(t, p.Wait(cancelled, u), context.Canceled)
+}
+
+func TestParseEmailHostInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      DefaultEmailHostInterval,
+		"1s":    time.Second,
+		" 50ms": 50 * time.Millisecond,
+		"0":     0,
+	}
+
+	for in, want := range tests {
+		got, err := ParseEmailHostInterval(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"-1s", "fast", "10"} {
+		_, err := ParseEmailHostInterval(in)
+		require.Error(t, err, in)
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -24,6 +26,10 @@ const (
 	retryBackoff2    = 3 * time.Second
 	maxResponseBytes = 5 * 1024 * 1024 // 5MB
 
+	// contactPagesParallel is how many contact pages Level 2 fetches at a
+	// time.
+	contactPagesParallel = 3
+
 	userAgent    = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 	acceptHeader = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
 )
@@ -37,7 +43,7 @@ type BrowserFetcher interface {
 // EmailPipeline orchestrates a multi-level email extraction process:
 //
 //	Level 1:   HTTP fetch of the homepage
-//	Level 2:   HTTP fetch of discovered contact/about pages
+//	Level 2:   HTTP fetch of discovered contact/about pages, a few at a time
 //	Level 2.5: HTTP fetch of deep-crawl pages (sitemap + footer/nav links)
 //	Level 3:   Browser-rendered fetch of homepage, contact pages, and deep-crawl pages
 type EmailPipeline struct {
@@ -46,16 +52,107 @@ type EmailPipeline struct {
 	httpClient     *http.Client
 	contactPages   []string // discovered at Level 2, reused at Level 3
 	deepCrawlPages []string // discovered at Level 2.5 via sitemap + footer/nav
+	proxyRouter    *EmailProxyRouter
+	fetchStats     *FetchStats
+	budget         *FetchBudget
+	timings        *StepTimings
+	breaker        *DomainBreaker
+	// domain is the domain of the website, the breaker counts by
+	domain string
+
+	// step is the level running since stepStart, see enterStep
+	step      string
+	stepStart time.Time
+	pacer     *WebsitePacer
+}
+
+// EmailPipelineOption configures an EmailPipeline.
+type EmailPipelineOption func(*emailPipelineConfig)
+
+type emailPipelineConfig struct {
+	proxyRouter *EmailProxyRouter
+	fetchStats  *FetchStats
+	budget      *FetchBudget
+	timings     *StepTimings
+	breaker     *DomainBreaker
+	pacer       *WebsitePacer
+}
+
+// WithEmailPipelineProxyRouter routes the HTTP fetches (Levels 1 to 2.5)
+// through the proxy matching the website country.
+func WithEmailPipelineProxyRouter(r *EmailProxyRouter) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.proxyRouter = r
+	}
+}
+
+// WithEmailPipelineFetchStats records the failed HTTP fetches, and the bytes
+// downloaded per proxy, into s.
+func WithEmailPipelineFetchStats(s *FetchStats) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.fetchStats = s
+	}
+}
+
+// WithEmailPipelineFetchBudget makes every fetch, HTTP or browser, consume
+// one unit of b. Once b is exhausted the pipeline stops with the
+// budget_exceeded status.
+func WithEmailPipelineFetchBudget(b *FetchBudget) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.budget = b
+	}
+}
+
+// WithEmailPipelineStepTimings records how long each level takes into t.
+func WithEmailPipelineStepTimings(t *StepTimings) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.timings = t
+	}
+}
+
+// WithEmailPipelineBreaker skips the website while b is open for its
+// domain, settling the entry with website_error, and records the HTTP
+// fetches into b.
+func WithEmailPipelineBreaker(b *DomainBreaker) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.breaker = b
+	}
+}
+
+// WithEmailPipelinePacer spaces the HTTP fetches of a website with p, see
+// WebsitePacer.
+func WithEmailPipelinePacer(p *WebsitePacer) EmailPipelineOption {
+	return func(c *emailPipelineConfig) {
+		c.pacer = p
+	}
 }
 
 // NewEmailPipeline creates an EmailPipeline for the given entry.
 // If browserFetcher is nil, Level 3 (browser rendering) is skipped.
-func NewEmailPipeline(entry *Entry, browserFetcher BrowserFetcher) *EmailPipeline {
+func NewEmailPipeline(entry *Entry, browserFetcher BrowserFetcher, opts ...EmailPipelineOption) *EmailPipeline {
+	var cfg emailPipelineConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.TLSClientConfig = &tls.Config{
 		InsecureSkipVerify: true, //nolint:gosec // scraper must handle sites with bad certs
 	}
 
+	// a custom TLS config disables HTTP/2 unless explicitly requested
+	transport.ForceAttemptHTTP2 = true
+
+	if cfg.proxyRouter != nil {
+		transport.Proxy = cfg.proxyRouter.transportProxy(entry.CompleteAddress.Country)
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if cfg.fetchStats != nil {
+		roundTripper = &meteredTransport{base: transport, stats: cfg.fetchStats, source: FetchSourceEmail}
+	}
+
 	client := &http.Client{
 		Timeout: httpTimeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -65,7 +162,11 @@ func NewEmailPipeline(entry *Entry, browserFetcher BrowserFetcher) *EmailPipelin
 
 			return nil
 		},
-		Transport: transport,
+		Transport: roundTripper,
+	}
+
+	if cfg.budget != nil && browserFetcher != nil {
+		browserFetcher = &budgetedBrowserFetcher{base: browserFetcher, budget: cfg.budget}
 	}
 
 	// Sanitize entry URL before pipeline starts.
@@ -75,29 +176,94 @@ func NewEmailPipeline(entry *Entry, browserFetcher BrowserFetcher) *EmailPipelin
 		entry:          entry,
 		browserFetcher: browserFetcher,
 		httpClient:     client,
+		proxyRouter:    cfg.proxyRouter,
+		fetchStats:     cfg.fetchStats,
+		budget:         cfg.budget,
+		timings:        cfg.timings,
+		breaker:        cfg.breaker,
+		domain:         websiteDomain(entry.WebSite),
+		pacer:          cfg.pacer,
 	}
 }
 
 // Run executes the 3-level pipeline. It modifies entry.Emails,
-// entry.EmailStatus, and entry.EmailSource in place.
+// entry.EmailStatus, entry.EmailSource, entry.EmailConfidence,
+// entry.EmailError and entry.WebsiteStatus in place.
 func (p *EmailPipeline) Run(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, globalTimeout)
 	defer cancel()
 
+	defer p.enterStep("")
+
+	if p.httpLevels(ctx) {
+		return nil
+	}
+
+	p.browserLevels(ctx)
+
+	return nil
+}
+
+// RunHTTP executes the HTTP levels of the pipeline only, up to Level 2.5.
+// It reports whether they settled the entry; when not, RunBrowser goes on
+// with Level 3.
+func (p *EmailPipeline) RunHTTP(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, globalTimeout)
+	defer cancel()
+
+	defer p.enterStep("")
+
+	return p.httpLevels(ctx)
+}
+
+// RunBrowser executes Level 3 with browserFetcher, on the pages found by
+// RunHTTP, and settles the entry. A nil browserFetcher only settles it.
+func (p *EmailPipeline) RunBrowser(ctx context.Context, browserFetcher BrowserFetcher) {
+	ctx, cancel := context.WithTimeout(ctx, globalTimeout)
+	defer cancel()
+
+	defer p.enterStep("")
+
+	if p.budget != nil && browserFetcher != nil {
+		browserFetcher = &budgetedBrowserFetcher{base: browserFetcher, budget: p.budget}
+	}
+
+	p.browserFetcher = browserFetcher
+
+	p.browserLevels(ctx)
+}
+
+// httpLevels runs Levels 1 to 2.5 and reports whether they settled the
+// entry.
+func (p *EmailPipeline) httpLevels(ctx context.Context) bool {
 	// --- Level 1: fetch homepage via HTTP ---
+	p.enterStep(StepEmailLevel1)
+
 	var doc *goquery.Document
 
 	body, err := p.fetchWithRetry(ctx, p.entry.WebSite, maxRetryLevel1)
-	if err == nil {
+	if errors.Is(err, errEmailBudgetExhausted) {
+		p.setBudgetExceeded()
+
+		return true
+	}
+
+	if fetchErrorClass(err) == FetchErrCircuitOpen {
+		p.setWebsiteError()
+
+		return true
+	}
+
+	if err != nil {
+		p.entry.EmailError = fetchErrorClass(err)
+	} else {
 		var emails []string
 		emails, doc = p.extractEmails(body)
 
 		if len(emails) > 0 {
-			p.entry.Emails = emails
-			p.entry.EmailStatus = "found"
-			p.entry.EmailSource = "homepage"
+			p.setFound(emails, "homepage", doc)
 
-			return nil
+			return true
 		}
 	}
 	// If Level 1 failed (403, TLS error, timeout, etc.), doc is nil.
@@ -108,35 +274,34 @@ func (p *EmailPipeline) Run(ctx context.Context) error {
 		p.contactPages = discoverContactPages(doc, p.entry.WebSite)
 	}
 
-	// --- Level 2: fetch each contact page via HTTP ---
-	for _, pageURL := range p.contactPages {
-		select {
-		case <-ctx.Done():
-			p.entry.Emails = []string{}
-			p.entry.EmailStatus = "not_found"
+	// --- Level 2: fetch the contact pages via HTTP, a few at a time ---
+	if len(p.contactPages) > 0 {
+		p.enterStep(StepEmailLevel2)
 
-			return nil
-		default:
-		}
+		pageEmails, pageDoc, fetchErr := p.fetchContactPages(ctx)
 
-		pageBody, fetchErr := p.fetchWithRetry(ctx, pageURL, maxRetryLevel2)
-		if fetchErr != nil {
-			continue
-		}
+		switch {
+		case len(pageEmails) > 0:
+			p.setFound(pageEmails, "contact_page", pageDoc)
 
-		pageEmails, _ := p.extractEmails(pageBody)
-		if len(pageEmails) > 0 {
-			p.entry.Emails = pageEmails
-			p.entry.EmailStatus = "found"
-			p.entry.EmailSource = "contact_page"
+			return true
+		case errors.Is(fetchErr, errEmailBudgetExhausted):
+			p.setBudgetExceeded()
 
-			return nil
+			return true
+		case ctx.Err() != nil:
+			p.entry.Emails = []string{}
+			p.entry.EmailStatus = "not_found"
+
+			return true
 		}
 	}
 
 	// Discover deep-crawl pages from footer/nav links and sitemap.
 	// Done after Level 2 so the sitemap fetch doesn't delay contact page checks.
-	if doc != nil {
+	if doc != nil && !p.budget.Exhausted() {
+		p.enterStep(StepEmailLevel25)
+
 		p.deepCrawlPages = discoverDeepCrawlPages(
 			ctx,
 			doc,
@@ -153,37 +318,136 @@ func (p *EmailPipeline) Run(ctx context.Context) error {
 			p.entry.Emails = []string{}
 			p.entry.EmailStatus = "not_found"
 
-			return nil
+			return true
 		default:
 		}
 
 		pageBody, fetchErr := p.fetchWithRetry(ctx, pageURL, maxRetryLevel2)
+		if errors.Is(fetchErr, errEmailBudgetExhausted) {
+			p.setBudgetExceeded()
+
+			return true
+		}
+
 		if fetchErr != nil {
 			continue
 		}
 
-		pageEmails, _ := p.extractEmails(pageBody)
+		pageEmails, pageDoc := p.extractEmails(pageBody)
 		if len(pageEmails) > 0 {
-			p.entry.Emails = pageEmails
-			p.entry.EmailStatus = "found"
-			p.entry.EmailSource = "deep_crawl_page"
+			p.setFound(pageEmails, "deep_crawl_page", pageDoc)
 
-			return nil
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchContactPages fetches the contact pages of Level 2,
+// contactPagesParallel at a time, and returns the emails of the first one
+// listing some, cancelling the fetches still running. It returns
+// errEmailBudgetExhausted when the budget ran out before any was found.
+func (p *EmailPipeline) fetchContactPages(ctx context.Context) ([]string, *goquery.Document, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, contactPagesParallel)
+
+		emails    []string
+		doc       *goquery.Document
+		budgetOut bool
+	)
+
+	for _, pageURL := range p.contactPages {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+
+		if ctx.Err() != nil {
+			break
 		}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			body, err := p.fetchWithRetry(ctx, pageURL, maxRetryLevel2)
+			if errors.Is(err, errEmailBudgetExhausted) {
+				mu.Lock()
+				budgetOut = true
+				mu.Unlock()
+
+				cancel()
+
+				return
+			}
+
+			if err != nil {
+				return
+			}
+
+			pageEmails, pageDoc := p.extractEmails(body)
+			if len(pageEmails) == 0 {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if emails == nil {
+				emails, doc = pageEmails, pageDoc
+
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if emails == nil && budgetOut {
+		return nil, nil, errEmailBudgetExhausted
+	}
+
+	return emails, doc, nil
+}
+
+// browserLevels runs Level 3, when there is a browser, and settles the
+// entry.
+func (p *EmailPipeline) browserLevels(ctx context.Context) {
+	// the browser would wait on the same dead server
+	if !p.breaker.Allow(p.domain) {
+		p.setWebsiteError()
+
+		return
 	}
 
 	// --- Level 3: browser rendering (only if browserFetcher is available) ---
 	if p.browserFetcher != nil {
+		p.enterStep(StepEmailLevel3)
+
 		// Try homepage with browser.
 		html, browserErr := p.browserFetcher.FetchWithBrowser(ctx, p.entry.WebSite)
+		if errors.Is(browserErr, errEmailBudgetExhausted) {
+			p.setBudgetExceeded()
+
+			return
+		}
+
 		if browserErr == nil && html != "" {
-			browserEmails, _ := p.extractEmails([]byte(html))
+			browserEmails, browserDoc := p.extractEmails([]byte(html))
 			if len(browserEmails) > 0 {
-				p.entry.Emails = browserEmails
-				p.entry.EmailStatus = "found"
-				p.entry.EmailSource = "browser_homepage"
+				p.setFound(browserEmails, "browser_homepage", browserDoc)
 
-				return nil
+				return
 			}
 		}
 
@@ -199,22 +463,26 @@ func (p *EmailPipeline) Run(ctx context.Context) error {
 				p.entry.Emails = []string{}
 				p.entry.EmailStatus = "not_found"
 
-				return nil
+				return
 			default:
 			}
 
 			pageHTML, browserErr := p.browserFetcher.FetchWithBrowser(ctx, p.contactPages[i])
+			if errors.Is(browserErr, errEmailBudgetExhausted) {
+				p.setBudgetExceeded()
+
+				return
+			}
+
 			if browserErr != nil || pageHTML == "" {
 				continue
 			}
 
-			browserEmails, _ := p.extractEmails([]byte(pageHTML))
+			browserEmails, browserDoc := p.extractEmails([]byte(pageHTML))
 			if len(browserEmails) > 0 {
-				p.entry.Emails = browserEmails
-				p.entry.EmailStatus = "found"
-				p.entry.EmailSource = "browser_contact_page"
+				p.setFound(browserEmails, "browser_contact_page", browserDoc)
 
-				return nil
+				return
 			}
 		}
 
@@ -230,22 +498,26 @@ func (p *EmailPipeline) Run(ctx context.Context) error {
 				p.entry.Emails = []string{}
 				p.entry.EmailStatus = "not_found"
 
-				return nil
+				return
 			default:
 			}
 
 			pageHTML, browserErr := p.browserFetcher.FetchWithBrowser(ctx, p.deepCrawlPages[i])
+			if errors.Is(browserErr, errEmailBudgetExhausted) {
+				p.setBudgetExceeded()
+
+				return
+			}
+
 			if browserErr != nil || pageHTML == "" {
 				continue
 			}
 
-			browserEmails, _ := p.extractEmails([]byte(pageHTML))
+			browserEmails, browserDoc := p.extractEmails([]byte(pageHTML))
 			if len(browserEmails) > 0 {
-				p.entry.Emails = browserEmails
-				p.entry.EmailStatus = "found"
-				p.entry.EmailSource = "browser_deep_crawl_page"
+				p.setFound(browserEmails, "browser_deep_crawl_page", browserDoc)
 
-				return nil
+				return
 			}
 		}
 	}
@@ -253,11 +525,58 @@ func (p *EmailPipeline) Run(ctx context.Context) error {
 	// Nothing found at any level.
 	p.entry.Emails = []string{}
 	p.entry.EmailStatus = "not_found"
+}
 
-	return nil
+// enterStep records the time of the level running, if any, and starts
+// step. An empty step ends the last level.
+func (p *EmailPipeline) enterStep(step string) {
+	if p.step != "" {
+		p.timings.Since(p.step, p.stepStart)
+	}
+
+	p.step, p.stepStart = step, time.Now()
+}
+
+// setBudgetExceeded ends a search cut short by the fetch budget, or by the
+// abort of the email phase.
+func (p *EmailPipeline) setBudgetExceeded() {
+	p.entry.Emails = []string{}
+	p.entry.EmailStatus = EmailStatusBudgetExceeded
+
+	if p.budget.Aborted() {
+		p.entry.EmailStatus = EmailStatusAborted
+	}
+}
+
+// setWebsiteError settles the entry of a website whose domain the breaker
+// cut off, keeping the failure met before, if any.
+func (p *EmailPipeline) setWebsiteError() {
+	p.entry.Emails = []string{}
+	p.entry.EmailStatus = "website_error"
+
+	if p.entry.EmailError == "" {
+		p.entry.EmailError = FetchErrCircuitOpen
+	}
+}
+
+// setFound records the emails found on a page, scoring them against the
+// mailto links of its parsed document.
+func (p *EmailPipeline) setFound(emails []string, source string, doc *goquery.Document) {
+	mailto := make(map[string]bool)
+
+	if doc != nil {
+		for _, e := range extractMailtoEmails(doc) {
+			mailto[e] = true
+		}
+	}
+
+	p.entry.setFoundEmails(emails, source, mailto)
+	p.entry.EmailError = ""
 }
 
 // fetchWithRetry fetches the given URL with exponential backoff retries.
+// Failures that another attempt cannot fix (see isRetryableFetchError) are
+// returned at once.
 func (p *EmailPipeline) fetchWithRetry(ctx context.Context, url string, maxRetries int) ([]byte, error) {
 	var lastErr error
 
@@ -280,41 +599,153 @@ func (p *EmailPipeline) fetchWithRetry(ctx context.Context, url string, maxRetri
 			return body, nil
 		}
 
+		// the failures of this fetch opened the breaker: they tell more
+		if lastErr != nil && fetchErrorClass(err) == FetchErrCircuitOpen {
+			break
+		}
+
 		lastErr = err
+
+		if errors.Is(err, errEmailBudgetExhausted) || !isRetryableFetchError(err) {
+			break
+		}
 	}
 
 	return nil, lastErr
 }
 
 // fetchPage performs a single HTTP GET and returns the response body.
+// Failures are returned as *FetchError and reported to the fetch stats and
+// to the proxy router.
 func (p *EmailPipeline) fetchPage(ctx context.Context, rawURL string) ([]byte, error) {
 	cleanURL := sanitizeURL(rawURL)
 
+	// the pages of the website, or the ones it links to elsewhere
+	domain := websiteDomain(cleanURL)
+
+	if !p.breaker.Allow(domain) {
+		p.recordHomepage(&WebsiteStatus{Error: FetchErrCircuitOpen})
+
+		return nil, &FetchError{URL: cleanURL, Class: FetchErrCircuitOpen, Err: errCircuitOpen}
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cleanURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request for %s: %w", cleanURL, err)
 	}
 
+	// a fetch given up while waiting its turn uses no budget
+	if err := p.pacer.Wait(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	if !p.budget.Take() {
+		return nil, errEmailBudgetExhausted
+	}
+
+	var proxy *url.URL
+
+	if p.proxyRouter != nil {
+		proxy = p.proxyRouter.ProxyFor(req.URL, p.entry.CompleteAddress.Country)
+		req = req.WithContext(withFetchProxy(ctx, proxy))
+	}
+
+	fail := func(statusCode int, err error) error {
+		fe := &FetchError{
+			URL:        cleanURL,
+			Proxy:      proxy,
+			StatusCode: statusCode,
+			Class:      ClassifyFetchError(err, statusCode),
+			Err:        err,
+		}
+
+		// a cancelled pipeline says nothing about the proxy or the site
+		if ctx.Err() == nil {
+			p.fetchStats.RecordError(FetchSourceEmail, proxyLabel(proxy), req.URL.Hostname(), fe.Class)
+			p.proxyRouter.ReportFetchError(req.URL, proxy, fe.Class)
+			p.breaker.Record(domain, fe.Class)
+		}
+
+		return fe
+	}
+
 	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept", acceptHeader)
 	req.Header.Set("Accept-Language", "it-IT,it;q=0.9,en-US;q=0.8,en;q=0.7")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("fetching %s: %w", cleanURL, err)
+		err = fail(0, err)
+		if ctx.Err() == nil {
+			p.recordHomepage(&WebsiteStatus{Error: fetchErrorClass(err)})
+		}
+
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	p.recordHomepage(websiteStatusOf(resp))
+
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d for %s", resp.StatusCode, cleanURL)
+		return nil, fail(resp.StatusCode, nil)
+	}
+
+	p.proxyRouter.ReportFetchSuccess(proxy)
+	p.breaker.Record(domain, "")
+
+	contentType := resp.Header.Get("Content-Type")
+
+	// Skip PDFs, images, etc. before reading up to maxResponseBytes of them.
+	if !isHTMLContentType(contentType) {
+		return nil, fail(0, fmt.Errorf("%w (%s)", errNonHTMLContent, contentType))
+	}
+
+	reader, err := decompressBody(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, fail(0, fmt.Errorf("decoding body: %w", err))
 	}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	body, err := io.ReadAll(io.LimitReader(reader, maxResponseBytes))
 	if err != nil {
-		return nil, fmt.Errorf("reading body of %s: %w", cleanURL, err)
+		return nil, fail(0, fmt.Errorf("reading body: %w", err))
 	}
 
-	return body, nil
+	if s := p.entry.WebsiteStatus; s != nil && p.step == StepEmailLevel1 && isParkedPage(body) {
+		s.Parked = true
+	}
+
+	return toUTF8(body, contentType), nil
+}
+
+// recordHomepage sets the website status of the entry from a fetch of its
+// homepage, the one of Level 1; the last attempt wins.
+func (p *EmailPipeline) recordHomepage(s *WebsiteStatus) {
+	if p.step == StepEmailLevel1 {
+		p.entry.WebsiteStatus = s
+	}
+}
+
+// fetchErrorClass returns the failure class of an error from fetchPage.
+func fetchErrorClass(err error) string {
+	var fe *FetchError
+	if errors.As(err, &fe) {
+		return fe.Class
+	}
+
+	return ClassifyFetchError(err, 0)
+}
+
+// isRetryableFetchError reports whether another attempt at the same URL may
+// succeed. A 403/429 is retried since the router moves the site to another
+// proxy.
+func isRetryableFetchError(err error) bool {
+	switch fetchErrorClass(err) {
+	case FetchErrDNS, FetchErrNonHTML, FetchErrHTTP4xx, FetchErrCircuitOpen:
+		return false
+	default:
+		return true
+	}
 }
 
 // extractEmails tries goquery-based extraction first; only if it finds
@@ -1,11 +1,13 @@
 package gmaps
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -90,6 +92,7 @@ func TestEmailPipelineWebsiteError(t *testing.T) {
 	err := pipeline.Run(context.Background())
 	require.NoError(t, err)
 	require.Equal(t, "not_found", entry.EmailStatus)
+	require.Equal(t, FetchErrConnRefused, entry.EmailError)
 }
 
 type mockBrowserFetcher struct {
@@ -123,6 +126,48 @@ func TestEmailPipelineBrowserFallback(t *testing.T) {
 	require.Equal(t, "browser_homepage", entry.EmailSource)
 }
 
+func TestEmailPipelineContactPagesInParallel(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body>
+				<a href="/contatti">Contatti</a>
+				<a href="/about">About us</a>
+			</body></html>`)
+		case "/contatti":
+			// until the hit on the about page cancels it
+			select {
+			case <-r.Context().Done():
+				close(cancelled)
+			case <-time.After(5 * time.Second):
+			}
+		case "/about":
+			fmt.Fprint(w, `<html><body><a href="mailto:about@testbiz.com">Write to us</a></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	entry := &Entry{WebSite: srv.URL}
+	start := time.Now()
+
+	err := NewEmailPipeline(entry, nil).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"about@testbiz.com"}, entry.Emails)
+	require.Equal(t, "contact_page", entry.EmailSource)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow contact page was not cancelled")
+	}
+}
+
 func TestEmailPipelineRegexFallback(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
@@ -143,3 +188,44 @@ func TestEmailPipelineRegexFallback(t *testing.T) {
 	require.Equal(t, "found", entry.EmailStatus)
 	require.Equal(t, "homepage", entry.EmailSource)
 }
+
+func TestEmailPipelineDecodesCompressedLatin1Page(t *testing.T) {
+	// gzip-compressed ISO-8859-1 page: accented text must be decoded, not
+	// turned into mojibake
+	page := "<html><head><meta charset=\"iso-8859-1\"></head><body><p>Caf\xe9 M\xfcller</p>" +
+		"<a href=\"mailto:info@cafemuller.de\">Schreiben Sie uns</a></body></html>"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Contains(t, r.Header.Get("Accept-Encoding"), "gzip")
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Encoding", "gzip")
+
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(page))
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	pipeline := NewEmailPipeline(&Entry{WebSite: srv.URL}, nil)
+
+	body, err := pipeline.fetchPage(context.Background(), srv.URL)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "Café Müller")
+
+	emails, _ := pipeline.extractEmails(body)
+	require.Equal(t, []string{"info@cafemuller.de"}, emails)
+}
+
+func TestEmailPipelineSkipsNonHTMLContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.4 info@testbiz.com")
+	}))
+	defer srv.Close()
+
+	pipeline := NewEmailPipeline(&Entry{WebSite: srv.URL}, nil)
+
+	_, err := pipeline.fetchPage(context.Background(), srv.URL)
+	require.ErrorIs(t, err, errNonHTMLContent)
+}
@@ -0,0 +1,268 @@
+package gmaps
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWebsiteTime and defaultWebsiteFetches are what the email
+	// search of a website is expected to take while none was timed yet.
+	defaultWebsiteTime    = 10 * time.Second
+	defaultWebsiteFetches = 3
+	// maxTimedDomains bounds the domains DomainTimings remembers; the
+	// others still count in its mean.
+	maxTimedDomains = 50000
+)
+
+// DomainTimings remembers how long the email search took on the websites
+// of each domain, across the jobs, to estimate the email phase of the jobs
+// to come: a chain or a website seen before takes about as long again. It
+// is safe for concurrent use; a nil *DomainTimings remembers nothing.
+type DomainTimings struct {
+	mu      sync.Mutex
+	domains map[string]time.Duration
+	total   time.Duration
+	count   int
+}
+
+// NewDomainTimings creates an empty DomainTimings.
+func NewDomainTimings() *DomainTimings {
+	return &DomainTimings{domains: make(map[string]time.Duration)}
+}
+
+// Record adds the time d of an email search on the website of domain.
+func (t *DomainTimings) Record(domain string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total += d
+	t.count++
+
+	if prev, ok := t.domains[domain]; ok {
+		// the last searches weigh as much as all the earlier ones
+		t.domains[domain] = (prev + d) / 2
+	} else if len(t.domains) < maxTimedDomains {
+		t.domains[domain] = d
+	}
+}
+
+// Expected returns how long an email search on the website of domain is
+// expected to take: its own time when it was searched before, else the mean
+// of all the searches.
+func (t *DomainTimings) Expected(domain string) time.Duration {
+	if t == nil {
+		return defaultWebsiteTime
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if d, ok := t.domains[domain]; ok {
+		return d
+	}
+
+	if t.count == 0 {
+		return defaultWebsiteTime
+	}
+
+	return t.total / time.Duration(t.count)
+}
+
+// EmailProgress follows the email phase of a job: the places queued for an
+// email search of their website and those searched already, to estimate
+// how long and how many fetches the rest takes. Until MapsEntries it also
+// keeps the Maps data of the places queued, as they were before their
+// search. It is safe for concurrent use; a nil *EmailProgress follows
+// nothing.
+type EmailProgress struct {
+	timings *DomainTimings
+
+	mu sync.Mutex
+	// pending counts the websites queued and not searched yet, by domain
+	pending map[string]int
+	queued  int
+	done    int
+	elapsed time.Duration
+	// maps holds copies of the places queued, in order, until MapsEntries
+	// takes them
+	maps     []*Entry
+	mapsDone bool
+}
+
+// NewEmailProgress creates the progress of a job, recording the time of its
+// searches into timings, shared by the jobs. A nil timings is fine.
+func NewEmailProgress(timings *DomainTimings) *EmailProgress {
+	return &EmailProgress{timings: timings, pending: make(map[string]int)}
+}
+
+// Queue records a place queued for the email search of its website, before
+// the search changes it.
+func (p *EmailProgress) Queue(entry *Entry) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending[websiteDomain(entry.WebSite)]++
+	p.queued++
+
+	if !p.mapsDone {
+		// the search replaces the email fields, it does not change them in
+		// place
+		maps := *entry
+		p.maps = append(p.maps, &maps)
+	}
+}
+
+// Done records the email search of a queued place, which took d.
+func (p *EmailProgress) Done(entry *Entry, d time.Duration) {
+	if p == nil {
+		return
+	}
+
+	domain := websiteDomain(entry.WebSite)
+
+	p.mu.Lock()
+
+	if p.pending[domain] > 1 {
+		p.pending[domain]--
+	} else {
+		delete(p.pending, domain)
+	}
+
+	p.done++
+	p.elapsed += d
+
+	p.mu.Unlock()
+
+	p.timings.Record(domain, d)
+}
+
+// Pending returns how many places queued are not searched yet.
+func (p *EmailProgress) Pending() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.queued - p.done
+}
+
+// MapsEntries returns the Maps data of the places of a job whose Maps
+// scraping is over: the places written already, with or without their
+// emails, and the places queued for an email search but not written yet, as
+// they were before it. It drops the copies of the places queued, later
+// calls return written.
+func (p *EmailProgress) MapsEntries(written []*Entry) []*Entry {
+	if p == nil {
+		return written
+	}
+
+	p.mu.Lock()
+	maps := p.maps
+	p.maps, p.mapsDone = nil, true
+	p.mu.Unlock()
+
+	seen := make(map[string]bool, len(written))
+	for _, e := range written {
+		seen[e.IdentityKey()] = true
+	}
+
+	ans := slices.Clip(written)
+
+	for _, e := range maps {
+		if !seen[e.IdentityKey()] {
+			ans = append(ans, e)
+		}
+	}
+
+	return ans
+}
+
+// EmailEstimate is the progress of the email phase of a job, with what the
+// websites still queued are expected to take.
+type EmailEstimate struct {
+	// Queued counts the websites queued for an email search so far, Done
+	// those searched. More are queued as long as places are found.
+	Queued int `json:"queued"`
+	Done   int `json:"done"`
+	// ElapsedSeconds is the time spent on the searches done, summed over
+	// the parallel ones.
+	ElapsedSeconds int64 `json:"elapsed_seconds"`
+	// RemainingSeconds is the time the searches still queued are expected
+	// to take, from the times of their domains, run Workers at a time.
+	RemainingSeconds int64 `json:"remaining_seconds"`
+	Workers          int   `json:"workers,omitempty"`
+	// FetchesUsed counts the website fetches so far, FetchesExpected those
+	// the searches still queued are expected to make, at the mean of the
+	// searches done. Both count against the email fetch budgets.
+	FetchesUsed     int `json:"fetches_used"`
+	FetchesExpected int `json:"fetches_expected"`
+	// Aborted is set once the email phase was aborted.
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// Estimate returns the progress of the email phase, its searches running
+// workers at a time and their fetches consuming budget.
+func (p *EmailProgress) Estimate(workers int, budget *FetchBudget) EmailEstimate {
+	if p == nil {
+		return EmailEstimate{}
+	}
+
+	workers = max(workers, 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ans := EmailEstimate{
+		Queued:         p.queued,
+		Done:           p.done,
+		ElapsedSeconds: int64(p.elapsed.Seconds()),
+		Workers:        workers,
+		FetchesUsed:    budget.Used(),
+		Aborted:        budget.Aborted(),
+	}
+
+	if ans.Aborted {
+		return ans
+	}
+
+	var remaining time.Duration
+
+	for domain, n := range p.pending {
+		remaining += time.Duration(n) * p.timings.Expected(domain)
+	}
+
+	ans.RemainingSeconds = int64((remaining / time.Duration(workers)).Seconds())
+
+	pending := p.queued - p.done
+	if p.done > 0 {
+		ans.FetchesExpected = pending * ans.FetchesUsed / p.done
+	} else {
+		ans.FetchesExpected = pending * defaultWebsiteFetches
+	}
+
+	return ans
+}
+
+// websiteDomain returns the host of website without its www., which the
+// searches are timed by.
+func websiteDomain(website string) string {
+	return strings.TrimPrefix(websiteHost(website), "www.")
+}
+
+// RemainingMinutes is RemainingSeconds in minutes, rounded up.
+func (e EmailEstimate) RemainingMinutes() int64 {
+	return (e.RemainingSeconds + 59) / 60
+}
@@ -0,0 +1,109 @@
+package gmaps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainTimings(t *testing.T) {
+	timings := NewDomainTimings()
+
+	require.Equal(t, defaultWebsiteTime, timings.Expected("a.com"))
+
+	timings.Record("a.com", 4*time.Second)
+	timings.Record("b.com", 20*time.Second)
+
+	require.Equal(t, 4*time.Second, timings.Expected("a.com"))
+	// an unknown domain takes the mean
+	require.Equal(t, 12*time.Second, timings.Expected("c.com"))
+
+	timings.Record("a.com", 2*time.Second)
+	require.Equal(t, 3*time.Second, timings.Expected("a.com"))
+
+	var none *DomainTimings
+
+	none.Record("a.com", time.Second)
+	require.Equal(t, defaultWebsiteTime, none.Expected("a.com"))
+}
+
+func TestEmailProgress(t *testing.T) {
+	timings := NewDomainTimings()
+	timings.Record("slow.com", 40*time.Second)
+	timings.Record("fast.com", 2*time.Second)
+
+	progress := NewEmailProgress(timings)
+	budget := NewFetchBudget(0)
+
+	progress.Queue(&Entry{WebSite: "https://www.slow.com/"})
+	progress.Queue(&Entry{WebSite: "http://fast.com"})
+	progress.Queue(&Entry{WebSite: "http://fast.com/shop"})
+	progress.Queue(&Entry{WebSite: "http://other.com"})
+
+	estimate := progress.Estimate(2, budget)
+	require.Equal(t, 4, estimate.Queued)
+	require.Zero(t, estimate.Done)
+	// 40 + 2 + 2 + the mean 21, two at a time
+	require.Equal(t, int64(32), estimate.RemainingSeconds)
+	require.Equal(t, int64(1), estimate.RemainingMinutes())
+	require.Equal(t, 4*defaultWebsiteFetches, estimate.FetchesExpected)
+
+	for range 4 {
+		budget.Take()
+	}
+
+	progress.Done(&Entry{WebSite: "https://slow.com"}, 30*time.Second)
+
+	estimate = progress.Estimate(2, budget)
+	require.Equal(t, 1, estimate.Done)
+	require.Equal(t, 3, progress.Pending())
+	require.Equal(t, int64(30), estimate.ElapsedSeconds)
+	// 2 + 2 + the mean of 40, 2 and 30
+	require.Equal(t, int64(14), estimate.RemainingSeconds)
+	require.Equal(t, 4, estimate.FetchesUsed)
+	require.Equal(t, 12, estimate.FetchesExpected)
+	// the search is remembered for the next jobs
+	require.Equal(t, 35*time.Second, timings.Expected("slow.com"))
+
+	budget.Abort()
+
+	estimate = progress.Estimate(2, budget)
+	require.True(t, estimate.Aborted)
+	require.Zero(t, estimate.RemainingSeconds)
+	require.Zero(t, estimate.FetchesExpected)
+
+	var none *EmailProgress
+
+	none.Queue(&Entry{WebSite: "http://a.com"})
+	none.Done(&Entry{WebSite: "http://a.com"}, time.Second)
+	require.Equal(t, EmailEstimate{}, none.Estimate(1, budget))
+}
+
+func TestEmailProgressMapsEntries(t *testing.T) {
+	progress := NewEmailProgress(nil)
+
+	searched := &Entry{Cid: "1", Title: "searched", WebSite: "http://a.com"}
+	waiting := &Entry{Cid: "2", Title: "waiting", WebSite: "http://b.com"}
+	noWebsite := &Entry{Cid: "3", Title: "no website"}
+
+	progress.Queue(searched)
+	progress.Queue(waiting)
+
+	// the searches change the places queued, not their copies
+	searched.Emails = []string{"info@a.com"}
+	waiting.EmailStatus = "found"
+
+	progress.Done(searched, time.Second)
+
+	entries := progress.MapsEntries([]*Entry{noWebsite, searched})
+	require.Len(t, entries, 3)
+	require.Same(t, noWebsite, entries[0])
+	require.Same(t, searched, entries[1])
+	require.Equal(t, "waiting", entries[2].Title)
+	require.Empty(t, entries[2].EmailStatus)
+
+	// the copies are gone
+	progress.Queue(&Entry{Cid: "4", WebSite: "http://c.com"})
+	require.Len(t, progress.MapsEntries(nil), 0)
+}
@@ -0,0 +1,256 @@
+package gmaps
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEmailProxyRoute is the route key used for websites that match no
+// country-specific route.
+const DefaultEmailProxyRoute = "*"
+
+// EmailProxyRouter picks the proxy used by the email pipeline for a website
+// based on its country. Many EU small-business sites geo-block datacenter
+// IPs, so fetching them through a proxy located in the same country avoids
+// the website_error status at Level 1.
+//
+// Routes are keyed by lowercase country code / ccTLD ("de", "it", "uk") with
+// DefaultEmailProxyRoute as a catch-all. A host always maps to the same proxy
+// of its route, so consecutive requests to one site come from a single IP.
+//
+// Fetch outcomes reported back through ReportFetchError steer the choice: a
+// proxy failing proxyBenchAfter times in a row is skipped for proxyBenchFor,
+// and a site answering 403/429 is moved to the next proxy of its route.
+type EmailProxyRouter struct {
+	routes map[string][]*url.URL
+
+	mu         sync.Mutex
+	failures   map[string]int
+	benchUntil map[string]time.Time
+	hostShift  map[string]uint32
+	now        func() time.Time
+}
+
+const (
+	proxyBenchAfter = 2
+	proxyBenchFor   = 2 * time.Minute
+)
+
+type proxyContextKey struct{}
+
+// withFetchProxy pins the proxy used by the requests made with ctx, so that
+// a fetch and its redirects leave from the proxy the error is reported for.
+func withFetchProxy(ctx context.Context, proxy *url.URL) context.Context {
+	return context.WithValue(ctx, proxyContextKey{}, proxy)
+}
+
+// countryAliases maps ISO 3166 codes to the ccTLD used as route key when they
+// differ.
+var countryAliases = map[string]string{
+	"gb": "uk",
+}
+
+// NewEmailProxyRouter validates the proxy URLs of every route.
+func NewEmailProxyRouter(routes map[string][]string) (*EmailProxyRouter, error) {
+	ans := EmailProxyRouter{
+		routes:     make(map[string][]*url.URL, len(routes)),
+		failures:   make(map[string]int),
+		benchUntil: make(map[string]time.Time),
+		hostShift:  make(map[string]uint32),
+		now:        time.Now,
+	}
+
+	for key, proxies := range routes {
+		key = normalizeRouteKey(key)
+		if key == "" {
+			return nil, fmt.Errorf("empty email proxy route key")
+		}
+
+		for _, p := range proxies {
+			u, err := url.Parse(strings.TrimSpace(p))
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, fmt.Errorf("invalid proxy %q for route %s", p, key)
+			}
+
+			ans.routes[key] = append(ans.routes[key], u)
+		}
+	}
+
+	return &ans, nil
+}
+
+// ParseEmailProxyRoutes parses routes written one per line (or separated by
+// ";") as "<country>=<proxy>[,<proxy>...]", e.g.
+//
+//	de=socks5://de1:1080,socks5://de2:1080
+//	*=http://fallback:8080
+func ParseEmailProxyRoutes(s string) (map[string][]string, error) {
+	ans := make(map[string][]string)
+
+	lines := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\n' || r == ';'
+	})
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, list, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid email proxy route %q (expected country=proxy)", line)
+		}
+
+		key = normalizeRouteKey(key)
+
+		for _, p := range strings.Split(list, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				ans[key] = append(ans[key], p)
+			}
+		}
+	}
+
+	return ans, nil
+}
+
+// FormatEmailProxyRoutes is the inverse of ParseEmailProxyRoutes, with routes
+// sorted by key.
+func FormatEmailProxyRoutes(routes map[string][]string) string {
+	keys := make([]string, 0, len(routes))
+	for k := range routes {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+"="+strings.Join(routes[k], ","))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ProxyFor returns the proxy for a website. The country is taken from the
+// ccTLD of the host and, for generic TLDs (.com, .net, ...), from the
+// country of the business address. It returns nil for a direct connection.
+func (r *EmailProxyRouter) ProxyFor(u *url.URL, country string) *url.URL {
+	if r == nil || u == nil || len(r.routes) == 0 {
+		return nil
+	}
+
+	host := strings.ToLower(u.Hostname())
+
+	proxies := r.routes[tldOf(host)]
+	if len(proxies) == 0 {
+		proxies = r.routes[normalizeRouteKey(country)]
+	}
+
+	if len(proxies) == 0 {
+		proxies = r.routes[DefaultEmailProxyRoute]
+	}
+
+	if len(proxies) == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(host))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := h.Sum32() + r.hostShift[host]
+	now := r.now()
+
+	for i := range uint32(len(proxies)) {
+		p := proxies[(start+i)%uint32(len(proxies))]
+		if !now.Before(r.benchUntil[p.String()]) {
+			return p
+		}
+	}
+
+	// every proxy of the route is benched: keep using the usual one
+	return proxies[start%uint32(len(proxies))]
+}
+
+// ReportFetchError records the failure class (see ClassifyFetchError) of a
+// fetch of u made through proxy.
+func (r *EmailProxyRouter) ReportFetchError(u *url.URL, proxy *url.URL, class string) {
+	if r == nil || proxy == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := proxy.String()
+
+	switch {
+	case isProxyFault(class):
+		r.failures[key]++
+		if r.failures[key] >= proxyBenchAfter {
+			r.benchUntil[key] = r.now().Add(proxyBenchFor)
+			r.failures[key] = 0
+		}
+	case isTargetBlock(class) && u != nil:
+		// the site refuses this exit IP: the retry goes through the next one
+		r.hostShift[strings.ToLower(u.Hostname())]++
+	}
+}
+
+// ReportFetchSuccess resets the consecutive failures of proxy.
+func (r *EmailProxyRouter) ReportFetchSuccess(proxy *url.URL) {
+	if r == nil || proxy == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failures, proxy.String())
+}
+
+// transportProxy returns a proxy function for http.Transport that uses the
+// proxy pinned with withFetchProxy, else routes through r, and falls back to
+// the environment settings.
+func (r *EmailProxyRouter) transportProxy(country string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyContextKey{}).(*url.URL); ok && p != nil {
+			return p, nil
+		}
+
+		if p := r.ProxyFor(req.URL, country); p != nil {
+			return p, nil
+		}
+
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+func tldOf(host string) string {
+	idx := strings.LastIndexByte(host, '.')
+	if idx < 0 {
+		return ""
+	}
+
+	return host[idx+1:]
+}
+
+func normalizeRouteKey(key string) string {
+	key = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(key), "."))
+
+	if alias, ok := countryAliases[key]; ok {
+		return alias
+	}
+
+	return key
+}
@@ -0,0 +1,99 @@
+package gmaps_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestParseEmailProxyRoutes(t *testing.T) {
+	routes, err := gmaps.ParseEmailProxyRoutes("DE=socks5://de1:1080, socks5://de2:1080\n.it=http://it:8080;gb=http://uk:8080\n\n*=http://any:8080")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]string{
+		"de": {"socks5://de1:1080", "socks5://de2:1080"},
+		"it": {"http://it:8080"},
+		"uk": {"http://uk:8080"},
+		"*":  {"http://any:8080"},
+	}, routes)
+
+	require.Equal(t, "*=http://any:8080\nde=socks5://de1:1080,socks5://de2:1080\nit=http://it:8080\nuk=http://uk:8080",
+		gmaps.FormatEmailProxyRoutes(routes))
+
+	_, err = gmaps.ParseEmailProxyRoutes("http://no-key:8080")
+	require.Error(t, err)
+
+	_, err = gmaps.NewEmailProxyRouter(map[string][]string{"de": {"not a proxy"}})
+	require.Error(t, err)
+}
+
+func TestEmailProxyRouterProxyFor(t *testing.T) {
+	router, err := gmaps.NewEmailProxyRouter(map[string][]string{
+		"de": {"socks5://de1:1080", "socks5://de2:1080"},
+		"it": {"http://it:8080"},
+	})
+	require.NoError(t, err)
+
+	proxyHost := func(rawURL, country string) string {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+
+		p := router.ProxyFor(u, country)
+		if p == nil {
+			return ""
+		}
+
+		return p.Host
+	}
+
+	require.Equal(t, "it:8080", proxyHost("https://www.trattoria.it/contatti", ""))
+	require.Equal(t, "it:8080", proxyHost("https://trattoria.com", "IT"))
+	require.Empty(t, proxyHost("https://shop.fr", "FR"))
+
+	// a host sticks to one proxy of its route
+	first := proxyHost("https://baeckerei.de", "")
+	require.Contains(t, []string{"de1:1080", "de2:1080"}, first)
+
+	for range 5 {
+		require.Equal(t, first, proxyHost("https://baeckerei.de/impressum", ""))
+	}
+
+	var nilRouter *gmaps.EmailProxyRouter
+
+	require.Nil(t, nilRouter.ProxyFor(&url.URL{Host: "a.de"}, ""))
+}
+
+func TestEmailProxyRouterRotatesOnFetchErrors(t *testing.T) {
+	router, err := gmaps.NewEmailProxyRouter(map[string][]string{
+		"de": {"socks5://de1:1080", "socks5://de2:1080"},
+	})
+	require.NoError(t, err)
+
+	site, _ := url.Parse("https://baeckerei.de")
+	other, _ := url.Parse("https://metzgerei.de")
+
+	// a site blocking the exit IP moves to the other proxy of the route
+	first := router.ProxyFor(site, "")
+	router.ReportFetchError(site, first, gmaps.FetchErrForbidden)
+
+	second := router.ProxyFor(site, "")
+	require.NotEqual(t, first.Host, second.Host)
+
+	// a proxy failing twice in a row is skipped for every site
+	bad := router.ProxyFor(other, "")
+	router.ReportFetchError(other, bad, gmaps.FetchErrProxy)
+	require.Equal(t, bad.Host, router.ProxyFor(other, "").Host)
+
+	router.ReportFetchError(other, bad, gmaps.FetchErrProxy)
+	require.NotEqual(t, bad.Host, router.ProxyFor(other, "").Host)
+
+	// a success in between resets the count
+	good := router.ProxyFor(other, "")
+	router.ReportFetchError(other, good, gmaps.FetchErrProxy)
+	router.ReportFetchSuccess(good)
+	router.ReportFetchError(other, good, gmaps.FetchErrProxy)
+	require.Equal(t, good.Host, router.ProxyFor(other, "").Host)
+}
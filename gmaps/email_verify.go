@@ -0,0 +1,334 @@
+package gmaps
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Classifications assigned to each email by the verification stage.
+const (
+	// EmailDeliverable: the domain accepts mail and the mailbox was not
+	// rejected (or the mail server could not be probed).
+	EmailDeliverable = "deliverable"
+	// EmailCatchAll: the mail server accepts any address of the domain, so
+	// the mailbox itself cannot be confirmed.
+	EmailCatchAll = "catch_all"
+	// EmailDisposable: the domain belongs to a throwaway mailbox provider.
+	EmailDisposable = "disposable"
+	// EmailInvalid: bad syntax, a domain with no mail server or a mailbox
+	// rejected by the server.
+	EmailInvalid = "invalid"
+	// EmailUnknown: DNS failed temporarily, nothing could be checked.
+	EmailUnknown = "unknown"
+)
+
+const (
+	defaultVerifyTimeout = 10 * time.Second
+	defaultHeloName      = "localhost"
+	smtpPort             = "25"
+	maxProbedMailHosts   = 2
+)
+
+//go:embed disposable_domains.txt
+var disposableDomainsList string
+
+var disposableDomains = sync.OnceValue(func() map[string]bool {
+	domains := make(map[string]bool)
+
+	for _, line := range strings.Split(disposableDomainsList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		domains[strings.ToLower(line)] = true
+	}
+
+	return domains
+})
+
+// isDisposableDomain reports whether domain, or one of its parents, is a
+// disposable mailbox provider.
+func isDisposableDomain(domain string) bool {
+	list := disposableDomains()
+
+	for candidate := strings.ToLower(domain); ; {
+		if list[candidate] {
+			return true
+		}
+
+		_, rest, ok := strings.Cut(candidate, ".")
+		if !ok || !strings.Contains(rest, ".") {
+			return false
+		}
+
+		candidate = rest
+	}
+}
+
+// EmailVerifier classifies extracted emails: disposable domains are matched
+// against a bundled list, the domain must have a mail server (MX, or A as
+// RFC 5321 fallback), and the mail server is probed over SMTP without
+// sending any message. A random mailbox accepted by the server marks the
+// domain as catch-all.
+type EmailVerifier struct {
+	timeout  time.Duration
+	heloName string
+
+	lookupMX   func(ctx context.Context, domain string) ([]*net.MX, error)
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	dial       func(ctx context.Context, network, address string) (net.Conn, error)
+	smtpPort   string
+
+	verified atomic.Int64
+}
+
+// EmailVerifierOption configures an EmailVerifier.
+type EmailVerifierOption func(*EmailVerifier)
+
+// WithEmailVerifierTimeout bounds the DNS lookups and each SMTP session.
+func WithEmailVerifierTimeout(d time.Duration) EmailVerifierOption {
+	return func(v *EmailVerifier) {
+		v.timeout = d
+	}
+}
+
+// WithEmailVerifierHeloName sets the name announced in the SMTP EHLO. Some
+// servers refuse to talk to "localhost".
+func WithEmailVerifierHeloName(name string) EmailVerifierOption {
+	return func(v *EmailVerifier) {
+		v.heloName = name
+	}
+}
+
+// WithEmailVerifierDNSCache resolves the mail servers through a DNSCache
+// shared with other verifiers (for instance across web jobs).
+func WithEmailVerifierDNSCache(c *DNSCache) EmailVerifierOption {
+	return func(v *EmailVerifier) {
+		v.lookupMX = c.LookupMX
+		v.lookupHost = c.LookupHost
+	}
+}
+
+// NewEmailVerifier creates an EmailVerifier. Unless WithEmailVerifierDNSCache
+// is given, lookups go through a DNSCache owned by the verifier.
+func NewEmailVerifier(opts ...EmailVerifierOption) *EmailVerifier {
+	dialer := &net.Dialer{}
+	dnsCache := NewDNSCache()
+
+	v := EmailVerifier{
+		timeout:    defaultVerifyTimeout,
+		heloName:   defaultHeloName,
+		lookupMX:   dnsCache.LookupMX,
+		lookupHost: dnsCache.LookupHost,
+		dial:       dialer.DialContext,
+		smtpPort:   smtpPort,
+	}
+
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	return &v
+}
+
+// Verify returns the classification of every email, in the same order.
+func (v *EmailVerifier) Verify(ctx context.Context, emails []string) []string {
+	v.verified.Add(int64(len(emails)))
+
+	classes := make([]string, len(emails))
+	byDomain := make(map[string][]int)
+
+	for i, email := range emails {
+		_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+
+		switch {
+		case !ok || !isValidEmail(email):
+			classes[i] = EmailInvalid
+		case isDisposableDomain(domain):
+			classes[i] = EmailDisposable
+		default:
+			byDomain[domain] = append(byDomain[domain], i)
+		}
+	}
+
+	for domain, idxs := range byDomain {
+		v.verifyDomain(ctx, domain, emails, idxs, classes)
+	}
+
+	return classes
+}
+
+// Verified returns how many emails were passed to Verify, 0 for a nil
+// verifier.
+func (v *EmailVerifier) Verified() int {
+	if v == nil {
+		return 0
+	}
+
+	return int(v.verified.Load())
+}
+
+// verifyDomain classifies the emails at idxs, which all share domain, with a
+// single SMTP session.
+func (v *EmailVerifier) verifyDomain(ctx context.Context, domain string, emails []string, idxs []int, classes []string) {
+	set := func(class string) {
+		for _, i := range idxs {
+			classes[i] = class
+		}
+	}
+
+	hosts, err := v.mailHosts(ctx, domain)
+	if err != nil {
+		set(EmailUnknown)
+
+		return
+	}
+
+	if len(hosts) == 0 {
+		set(EmailInvalid)
+
+		return
+	}
+
+	// the mailbox can't be checked, but the domain does receive mail
+	set(EmailDeliverable)
+
+	for _, host := range hosts[:min(len(hosts), maxProbedMailHosts)] {
+		if v.probe(ctx, host, domain, emails, idxs, classes) {
+			return
+		}
+	}
+}
+
+// mailHosts returns the mail servers of domain by preference. An empty list
+// with a nil error means the domain cannot receive mail.
+func (v *EmailVerifier) mailHosts(ctx context.Context, domain string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	mxs, err := v.lookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	if len(mxs) > 0 {
+		slices.SortStableFunc(mxs, func(a, b *net.MX) int {
+			return int(a.Pref) - int(b.Pref)
+		})
+
+		hosts := make([]string, 0, len(mxs))
+
+		for _, mx := range mxs {
+			host := strings.TrimSuffix(mx.Host, ".")
+			// RFC 7505 null MX: the domain explicitly accepts no mail
+			if host == "" {
+				return nil, nil
+			}
+
+			hosts = append(hosts, host)
+		}
+
+		return hosts, nil
+	}
+
+	// no MX record: RFC 5321 falls back to the domain address
+	addrs, err := v.lookupHost(ctx, domain)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	return []string{domain}, nil
+}
+
+// probe runs RCPT TO for a random mailbox and for each email against host.
+// It reports false when the server could not be reached, so the next mail
+// host is tried.
+func (v *EmailVerifier) probe(ctx context.Context, host, domain string, emails []string, idxs []int, classes []string) bool {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	conn, err := v.dial(ctx, "tcp", net.JoinHostPort(host, v.smtpPort))
+	if err != nil {
+		return false
+	}
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+
+		return false
+	}
+
+	defer client.Close()
+
+	if err := client.Hello(v.heloName); err != nil {
+		return false
+	}
+
+	if err := client.Mail(""); err != nil {
+		return false
+	}
+
+	catchAll := client.Rcpt(randomLocalPart()+"@"+domain) == nil
+
+	for _, i := range idxs {
+		err := client.Rcpt(emails[i])
+
+		switch {
+		case isPermanentSMTPError(err):
+			classes[i] = EmailInvalid
+		case catchAll:
+			classes[i] = EmailCatchAll
+		default:
+			classes[i] = EmailDeliverable
+		}
+	}
+
+	_ = client.Quit()
+
+	return true
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// isPermanentSMTPError reports a 5xx reply. Temporary 4xx replies
+// (greylisting) say nothing about the mailbox.
+func isPermanentSMTPError(err error) bool {
+	var smtpErr *textproto.Error
+
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600
+}
+
+func randomLocalPart() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return "gms-probe-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,148 @@
+package gmaps
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts the RCPT TO of the mailboxes in accept, or of any
+// mailbox when catchAll is set, and rejects the others with 550.
+func fakeSMTPServer(t *testing.T, accept map[string]bool, catchAll bool) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				tp := textproto.NewConn(conn)
+				_ = tp.PrintfLine("220 fake ESMTP")
+
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+
+					cmd := strings.ToUpper(line)
+
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+						_ = tp.PrintfLine("250 fake")
+					case strings.HasPrefix(cmd, "MAIL FROM"):
+						_ = tp.PrintfLine("250 ok")
+					case strings.HasPrefix(cmd, "RCPT TO"):
+						rcpt := strings.ToLower(strings.Trim(line[len("RCPT TO:"):], "<> "))
+						if catchAll || accept[rcpt] {
+							_ = tp.PrintfLine("250 ok")
+						} else {
+							_ = tp.PrintfLine("550 no such user")
+						}
+					case strings.HasPrefix(cmd, "QUIT"):
+						_ = tp.PrintfLine("221 bye")
+
+						return
+					default:
+						_ = tp.PrintfLine("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+
+	return port
+}
+
+func testEmailVerifier(port string, mx map[string]string) *EmailVerifier {
+	v := NewEmailVerifier()
+	v.smtpPort = port
+	v.lookupMX = func(_ context.Context, domain string) ([]*net.MX, error) {
+		host, ok := mx[domain]
+		if !ok {
+			return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+		}
+
+		return []*net.MX{{Host: host + ".", Pref: 10}}, nil
+	}
+	v.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return v
+}
+
+func TestEmailVerifierClassifies(t *testing.T) {
+	port := fakeSMTPServer(t, map[string]bool{"info@bakery.it": true}, false)
+
+	v := testEmailVerifier(port, map[string]string{"bakery.it": "127.0.0.1"})
+
+	got := v.Verify(context.Background(), []string{
+		"info@bakery.it",
+		"gone@bakery.it",
+		"someone@mailinator.com",
+		"x@sub.yopmail.com",
+		"info@no-mail-server.it",
+		"not-an-email",
+	})
+
+	require.Equal(t, []string{
+		EmailDeliverable,
+		EmailInvalid,
+		EmailDisposable,
+		EmailDisposable,
+		EmailInvalid,
+		EmailInvalid,
+	}, got)
+}
+
+func TestEmailVerifierDetectsCatchAll(t *testing.T) {
+	port := fakeSMTPServer(t, nil, true)
+
+	v := testEmailVerifier(port, map[string]string{"bakery.it": "127.0.0.1"})
+
+	got := v.Verify(context.Background(), []string{"info@bakery.it", "sales@bakery.it"})
+	require.Equal(t, []string{EmailCatchAll, EmailCatchAll}, got)
+}
+
+func TestEmailVerifierUnreachableMailServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, ln.Close())
+
+	v := testEmailVerifier(port, map[string]string{"bakery.it": "127.0.0.1"})
+
+	got := v.Verify(context.Background(), []string{"info@bakery.it"})
+	require.Equal(t, []string{EmailDeliverable}, got)
+}
+
+func TestEmailVerifierCountsVerified(t *testing.T) {
+	var none *EmailVerifier
+	require.Zero(t, none.Verified())
+
+	v := testEmailVerifier("0", nil)
+
+	v.Verify(context.Background(), []string{"someone@mailinator.com", "not-an-email"})
+	v.Verify(context.Background(), []string{"info@no-mail-server.it"})
+
+	require.Equal(t, 3, v.Verified())
+}
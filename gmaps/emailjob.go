@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gosom/scrapemate"
@@ -19,8 +20,21 @@ type EmailExtractJob struct {
 	Entry                   *Entry
 	ExitMonitor             exiter.Exiter
 	WriterManagedCompletion bool
+	ProxyRouter             *EmailProxyRouter
+	Verifier                *EmailVerifier
+	FetchStats              *FetchStats
+	FetchBudget             *FetchBudget
+	StepTimings             *StepTimings
+	EmailProgress           *EmailProgress
+	Breaker                 *DomainBreaker
+	HAR                     *HARCapture
+	Pacer                   *WebsitePacer
 
 	pipelineRan bool
+	// pipeline is the pipeline left to Level 3 by RunHTTP, started at
+	// started
+	pipeline *EmailPipeline
+	started  time.Time
 }
 
 func NewEmailJob(parentID string, entry *Entry, opts ...EmailExtractJobOptions) *EmailExtractJob {
@@ -61,6 +75,74 @@ func WithEmailJobWriterManagedCompletion() EmailExtractJobOptions {
 	}
 }
 
+// WithEmailJobProxyRouter routes website fetches through country-matched
+// proxies.
+func WithEmailJobProxyRouter(r *EmailProxyRouter) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.ProxyRouter = r
+	}
+}
+
+// WithEmailJobVerifier classifies the extracted emails (deliverable,
+// catch_all, disposable, invalid) once the pipeline completes.
+func WithEmailJobVerifier(v *EmailVerifier) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.Verifier = v
+	}
+}
+
+// WithEmailJobFetchStats records the failed website fetches, and the bytes
+// downloaded, into s.
+func WithEmailJobFetchStats(s *FetchStats) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.FetchStats = s
+	}
+}
+
+// WithEmailJobFetchBudget caps the website fetches shared by the email jobs.
+func WithEmailJobFetchBudget(b *FetchBudget) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.FetchBudget = b
+	}
+}
+
+// WithEmailJobStepTimings records the timings of the levels of the pipeline,
+// and of the verification, into t.
+func WithEmailJobStepTimings(t *StepTimings) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.StepTimings = t
+	}
+}
+
+// WithEmailJobProgress records the search of the website into p.
+func WithEmailJobProgress(p *EmailProgress) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.EmailProgress = p
+	}
+}
+
+// WithEmailJobBreaker skips the website while b is open for its domain.
+func WithEmailJobBreaker(b *DomainBreaker) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.Breaker = b
+	}
+}
+
+// WithEmailJobHAR records a HAR of the browser fetches of the website into c
+// when it is sampled.
+func WithEmailJobHAR(c *HARCapture) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.HAR = c
+	}
+}
+
+// WithEmailJobPacer spaces the HTTP fetches of the website with p.
+func WithEmailJobPacer(p *WebsitePacer) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.Pacer = p
+	}
+}
+
 // BrowserActions runs the email pipeline while the browser page is owned
 // exclusively. scrapemate recycles the page back into its pool the moment this
 // returns, so Level 3 navigation MUST happen here, not in Process. Running it
@@ -70,6 +152,17 @@ func (j *EmailExtractJob) BrowserActions(ctx context.Context, page scrapemate.Br
 	var fetcher BrowserFetcher
 	if page != nil {
 		fetcher = &pageBrowserFetcher{page: page}
+
+		j.FetchStats.meterBrowserPage(page, FetchSourceEmail)
+
+		// only when Level 3 is left to run
+		if !j.pipelineRan {
+			if har := j.HAR.record(page, j.Entry.WebSite); har != nil {
+				fetcher = har.fetcher(fetcher)
+
+				defer har.save(ctx)
+			}
+		}
 	}
 
 	j.runPipeline(ctx, fetcher)
@@ -97,8 +190,36 @@ func (j *EmailExtractJob) Process(ctx context.Context, resp *scrapemate.Response
 	return j.Entry, nil, nil
 }
 
+// RunHTTP runs the HTTP levels of the email pipeline ahead of the crawl, on
+// a pool of its own: the job then reaches a crawl worker only to render the
+// website when they found nothing, or to hand over the entry. It does
+// nothing once the pipeline ran.
+func (j *EmailExtractJob) RunHTTP(ctx context.Context) {
+	if j.pipelineRan || j.pipeline != nil {
+		return
+	}
+
+	j.started = time.Now()
+
+	log := scrapemate.GetLoggerFromContext(ctx)
+	log.Info("Processing email pipeline over HTTP", "url", j.URL)
+
+	pipeline := j.newPipeline(nil)
+	if !pipeline.RunHTTP(ctx) {
+		// the browser level runs when the crawl gets the job
+		j.pipeline = pipeline
+
+		return
+	}
+
+	j.pipelineRan = true
+
+	j.finishPipeline(ctx)
+}
+
 // runPipeline executes the email pipeline exactly once. fetcher is non-nil only
 // when a browser page is available (JS mode), enabling Level 3 rendering.
+// After RunHTTP only Level 3 is left.
 func (j *EmailExtractJob) runPipeline(ctx context.Context, fetcher BrowserFetcher) {
 	if j.pipelineRan {
 		return
@@ -106,10 +227,19 @@ func (j *EmailExtractJob) runPipeline(ctx context.Context, fetcher BrowserFetche
 
 	j.pipelineRan = true
 
+	if j.pipeline != nil {
+		j.pipeline.RunBrowser(ctx, fetcher)
+		j.finishPipeline(ctx)
+
+		return
+	}
+
+	j.started = time.Now()
+
 	log := scrapemate.GetLoggerFromContext(ctx)
 	log.Info("Processing email pipeline", "url", j.URL)
 
-	pipeline := NewEmailPipeline(j.Entry, fetcher)
+	pipeline := j.newPipeline(fetcher)
 
 	if err := pipeline.Run(ctx); err != nil {
 		log.Warn("Email pipeline failed", "url", j.URL, "error", err)
@@ -117,11 +247,59 @@ func (j *EmailExtractJob) runPipeline(ctx context.Context, fetcher BrowserFetche
 		j.Entry.EmailStatus = "website_error"
 	}
 
+	j.finishPipeline(ctx)
+}
+
+// newPipeline creates the email pipeline of the job.
+func (j *EmailExtractJob) newPipeline(fetcher BrowserFetcher) *EmailPipeline {
+	var opts []EmailPipelineOption
+	if j.ProxyRouter != nil {
+		opts = append(opts, WithEmailPipelineProxyRouter(j.ProxyRouter))
+	}
+
+	if j.FetchStats != nil {
+		opts = append(opts, WithEmailPipelineFetchStats(j.FetchStats))
+	}
+
+	if j.FetchBudget != nil {
+		opts = append(opts, WithEmailPipelineFetchBudget(j.FetchBudget))
+	}
+
+	if j.StepTimings != nil {
+		opts = append(opts, WithEmailPipelineStepTimings(j.StepTimings))
+	}
+
+	if j.Breaker != nil {
+		opts = append(opts, WithEmailPipelineBreaker(j.Breaker))
+	}
+
+	if j.Pacer != nil {
+		opts = append(opts, WithEmailPipelinePacer(j.Pacer))
+	}
+
+	return NewEmailPipeline(j.Entry, fetcher, opts...)
+}
+
+// finishPipeline verifies the emails found and records the search, once the
+// pipeline settled the entry.
+func (j *EmailExtractJob) finishPipeline(ctx context.Context) {
+	j.pipeline = nil
+
+	if j.Verifier != nil && len(j.Entry.Emails) > 0 {
+		start := time.Now()
+		j.Entry.EmailClassifications = j.Verifier.Verify(ctx, j.Entry.Emails)
+		j.StepTimings.Since(StepEmailVerification, start)
+	}
+
+	j.EmailProgress.Done(j.Entry, time.Since(j.started))
+
+	log := scrapemate.GetLoggerFromContext(ctx)
 	log.Info("Email pipeline completed",
 		"url", j.URL,
 		"emails_found", len(j.Entry.Emails),
 		"status", j.Entry.EmailStatus,
 		"source", j.Entry.EmailSource,
+		"error", j.Entry.EmailError,
 	)
 }
 
@@ -26,17 +26,17 @@ func (f *fakeBrowserPage) Goto(string, scrapemate.WaitUntilState) (*scrapemate.P
 
 	return &scrapemate.PageResponse{StatusCode: 200}, nil
 }
-func (f *fakeBrowserPage) URL() string                                  { return "" }
-func (f *fakeBrowserPage) Content() (string, error)                     { return "", nil }
-func (f *fakeBrowserPage) Reload(scrapemate.WaitUntilState) error       { return nil }
-func (f *fakeBrowserPage) Screenshot(bool) ([]byte, error)              { return nil, nil }
-func (f *fakeBrowserPage) Eval(string, ...any) (any, error)             { return nil, nil }
-func (f *fakeBrowserPage) WaitForURL(string, time.Duration) error       { return nil }
-func (f *fakeBrowserPage) WaitForSelector(string, time.Duration) error  { return nil }
-func (f *fakeBrowserPage) WaitForTimeout(time.Duration)                 {}
-func (f *fakeBrowserPage) Locator(string) scrapemate.Locator            { return nil }
-func (f *fakeBrowserPage) Close() error                                 { f.closeCalls++; return nil }
-func (f *fakeBrowserPage) Unwrap() any                                  { return nil }
+func (f *fakeBrowserPage) URL() string                                 { return "" }
+func (f *fakeBrowserPage) Content() (string, error)                    { return "", nil }
+func (f *fakeBrowserPage) Reload(scrapemate.WaitUntilState) error      { return nil }
+func (f *fakeBrowserPage) Screenshot(bool) ([]byte, error)             { return nil, nil }
+func (f *fakeBrowserPage) Eval(string, ...any) (any, error)            { return nil, nil }
+func (f *fakeBrowserPage) WaitForURL(string, time.Duration) error      { return nil }
+func (f *fakeBrowserPage) WaitForSelector(string, time.Duration) error { return nil }
+func (f *fakeBrowserPage) WaitForTimeout(time.Duration)                {}
+func (f *fakeBrowserPage) Locator(string) scrapemate.Locator           { return nil }
+func (f *fakeBrowserPage) Close() error                                { f.closeCalls++; return nil }
+func (f *fakeBrowserPage) Unwrap() any                                 { return nil }
 
 func homepageWithEmailServer(t *testing.T) *httptest.Server {
 	t.Helper()
@@ -94,3 +94,59 @@ func TestEmailJobRunsPipelineInProcessWhenNoBrowser(t *testing.T) {
 	require.Equal(t, []string{"info@testbiz.com"}, entry.Emails)
 	require.Equal(t, "found", entry.EmailStatus)
 }
+
+// The email lane runs the HTTP levels ahead of the crawl: a website settled
+// there is not fetched again, one left to Level 3 is only rendered.
+func TestEmailJobRunHTTP(t *testing.T) {
+	srv := homepageWithEmailServer(t)
+
+	entry := &Entry{WebSite: srv.URL}
+	progress := NewEmailProgress(nil)
+	progress.Queue(entry)
+
+	job := NewEmailJob("parent", entry, WithEmailJobProgress(progress))
+	job.RunHTTP(context.Background())
+
+	require.Equal(t, []string{"info@testbiz.com"}, entry.Emails)
+	require.Zero(t, progress.Pending())
+
+	page := &fakeBrowserPage{}
+	resp := job.BrowserActions(context.Background(), page)
+	require.NoError(t, resp.Error)
+	require.Zero(t, page.gotoCalls)
+
+	result, _, err := job.Process(context.Background(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, entry, result)
+	require.Equal(t, 1, progress.Estimate(1, nil).Done)
+
+	var hits int
+
+	noEmails := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><p>No emails here</p></body></html>`)
+	}))
+	t.Cleanup(noEmails.Close)
+
+	entry = &Entry{WebSite: noEmails.URL}
+	progress.Queue(entry)
+
+	job = NewEmailJob("parent", entry, WithEmailJobProgress(progress))
+	job.RunHTTP(context.Background())
+
+	require.Empty(t, entry.EmailStatus)
+	require.Equal(t, 1, progress.Pending())
+
+	fetched := hits
+
+	job.runPipeline(context.Background(), &mockBrowserFetcher{
+		html: `<html><body><a href="mailto:browser@biz.com">Email</a></body></html>`,
+	})
+
+	require.Equal(t, []string{"browser@biz.com"}, entry.Emails)
+	require.Equal(t, "browser_homepage", entry.EmailSource)
+	require.Equal(t, fetched, hits)
+	require.Zero(t, progress.Pending())
+}
@@ -0,0 +1,30 @@
+package gmaps
+
+import "context"
+
+// Enricher adds to a place what a source other than Google Maps knows about
+// it, once it is scraped: OSMChecker, RegistrationEnricher.
+type Enricher interface {
+	// Enrich sets the fields of e from the source. A place the source
+	// cannot tell anything about is left as it is.
+	Enrich(ctx context.Context, e *Entry) error
+}
+
+// Enrichers run each place through enrichers in turn. It is a
+// post-processing hook (see the postprocess package), so that the places
+// are enriched before they are written.
+type Enrichers []Enricher
+
+// Process enriches entries. An enricher failing on a place leaves it
+// unenriched by that source; only the end of ctx stops the batch.
+func (enrichers Enrichers) Process(ctx context.Context, entries []*Entry) ([]*Entry, error) {
+	for _, e := range entries {
+		for _, enricher := range enrichers {
+			if err := enricher.Enrich(ctx, e); err != nil && ctx.Err() != nil {
+				return entries, ctx.Err()
+			}
+		}
+	}
+
+	return entries, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"iter"
 	"log"
+	"maps"
 	"math"
 	"net/url"
 	"regexp"
@@ -43,8 +44,15 @@ type Address struct {
 }
 
 type Option struct {
+	// ID is the path of the attribute, like
+	// "/geo/type/establishment_poi/has_delivery".
+	ID      string `json:"id,omitempty"`
 	Name    string `json:"name"`
 	Enabled bool   `json:"enabled"`
+	// Value is the value of an attribute having one, like "Free Wi-Fi" for
+	// Wi-Fi, and Values those of a list, like the credit cards accepted.
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
 }
 
 type About struct {
@@ -72,6 +80,7 @@ type Review struct {
 	TranslatedLang      string  `json:"translated_lang"`
 	TextOriginal        string  `json:"text_original"`
 	TextTranslated      string  `json:"text_translated"`
+	DetectedLanguage    string  `json:"detected_language,omitempty"`
 
 	ReplyText                string     `json:"reply_text,omitempty"`
 	ReplyTextOriginal        string     `json:"reply_text_original,omitempty"`
@@ -131,6 +140,87 @@ type Entry struct {
 	Emails              []string     `json:"emails"`
 	EmailStatus         string       `json:"email_status"`
 	EmailSource         string       `json:"email_source"`
+	// EmailConfidence scores from 0 to MaxEmailConfidence how likely the
+	// first (best) email is the business's real contact.
+	EmailConfidence int `json:"email_confidence"`
+	// EmailClassifications holds, for each email in Emails, its
+	// verification result (EmailDeliverable, EmailCatchAll, ...). It is
+	// empty unless email verification is enabled.
+	EmailClassifications []string `json:"email_classifications"`
+	// EmailError is the failure class (FetchErrTimeout, FetchErrForbidden,
+	// ...) of the website homepage fetch, when it failed and no email was
+	// found afterwards.
+	EmailError string `json:"email_error"`
+	// WebsiteStatus tells whether the website answered, over a valid
+	// certificate, and is not parked, when it was searched for emails.
+	WebsiteStatus *WebsiteStatus `json:"website_status,omitempty"`
+	// Query is the seed keyword whose search produced this entry. Together
+	// with ID (the seed GmapJob) it lets multi-keyword jobs be analyzed per
+	// keyword.
+	Query string `json:"query"`
+	// IsServiceArea marks service-area businesses (plumbers, cleaners, ...)
+	// that hide their address and only state the area they serve.
+	IsServiceArea bool   `json:"is_service_area"`
+	ServiceArea   string `json:"service_area"`
+	// ChainID groups entries of the same brand (see AssignChains). It is
+	// only known once all the results of a job are available.
+	ChainID string `json:"chain_id"`
+	// DescriptionLanguage is the language of Description and
+	// Review.DetectedLanguage that of the text of a review, see
+	// DetectLanguage.
+	DescriptionLanguage string `json:"description_language,omitempty"`
+	// PageLanguage is the language the place page rendered in when it is
+	// not the one asked for, even asked again: the texts of the place, its
+	// category included, are in that language.
+	PageLanguage string `json:"page_language,omitempty"`
+	// ReviewAnalysis measures the owner replies to the reviews collected,
+	// see AnalyzeReviews.
+	ReviewAnalysis *ReviewAnalysis `json:"review_analysis,omitempty"`
+	// Attributes are the attributes of About keyed by group and attribute
+	// ID, whatever the language, see attributesOf.
+	Attributes map[string]map[string]string `json:"attributes,omitempty"`
+	// HotelClass is the star class of a lodging place, 0 when unknown. The
+	// hotel fields are only set for lodging places, see parseHotel.
+	HotelClass   int      `json:"hotel_class,omitempty"`
+	CheckInTime  string   `json:"check_in_time,omitempty"`
+	CheckOutTime string   `json:"check_out_time,omitempty"`
+	Amenities    []string `json:"amenities,omitempty"`
+	// BookingLinks are the links to book a table, a room or an appointment,
+	// with the booking tool behind them.
+	BookingLinks []BookingLink `json:"booking_links,omitempty"`
+	// PriceLevel, PriceCurrency, PriceMin and PriceMax are PriceRange made
+	// machine readable, see normalizePrice: the level 1 to 4 of "€" to
+	// "€€€€", the ISO 4217 code of the currency and the amounts of a range
+	// like "€10–20", PriceMax 0 for "€100+".
+	PriceLevel    int     `json:"price_level,omitempty"`
+	PriceCurrency string  `json:"price_currency,omitempty"`
+	PriceMin      float64 `json:"price_min,omitempty"`
+	PriceMax      float64 `json:"price_max,omitempty"`
+	// OSM is the OpenStreetMap object matching the place, set when the
+	// places are cross-checked against OpenStreetMap (see OSMChecker).
+	OSM *OSMMatch `json:"osm,omitempty"`
+	// RegistrationInfo holds the VAT and the company register numbers read
+	// from the website, see RegistrationEnricher.
+	RegistrationInfo *RegistrationInfo `json:"registration_info,omitempty"`
+	// AI is the summary and the lead score a language model wrote for the
+	// place, when asked to (see AIInsight).
+	AI *AIInsight `json:"ai,omitempty"`
+	// Suppressed is the email or domain of a suppression list the entry
+	// matched, set by the exports flagging them (see SuppressionList). It is
+	// not part of the CSV row.
+	Suppressed string `json:"suppressed,omitempty"`
+	// Territory is the name of the territory holding the place, set by the
+	// exports of the jobs with territories (see Territories). It is not
+	// part of the CSV row either.
+	Territory string `json:"territory,omitempty"`
+	// Validation is the outcome of the validation rules run on the place,
+	// when asked to (see Validator). It is not part of the CSV row.
+	Validation *Validation `json:"validation,omitempty"`
+	// CustomFields holds the values read by the custom extractors of the
+	// job, keyed by field name. They become extra CSV columns.
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+
+	Provenance
 }
 
 // entryAlias is used inside Marshal/UnmarshalJSON to avoid infinite recursion
@@ -249,7 +339,7 @@ func (e *Entry) Validate() error {
 }
 
 func (e *Entry) CsvHeaders() []string {
-	return []string{
+	headers := []string{
 		"input_id",
 		"link",
 		"title",
@@ -287,11 +377,46 @@ func (e *Entry) CsvHeaders() []string {
 		"emails",
 		"email_status",
 		"email_source",
+		"query",
+		"is_service_area",
+		"service_area",
+		"email_confidence",
+		"email_classifications",
+		"email_error",
+		"description_language",
+		"review_reply_rate",
+		"review_median_reply_hours",
+		"hotel_class",
+		"check_in_time",
+		"check_out_time",
+		"amenities",
+		"booking_links",
+		"booking_platforms",
+		"price_level",
+		"price_currency",
+		"price_min",
+		"price_max",
+		"osm_id",
+		"osm_discrepancies",
+		"vat_numbers",
+		"registration_numbers",
+		"vat_valid",
+		"ai_summary",
+		"ai_score",
+		"page_language",
+		"website_reachable",
+		"website_http_status",
+		"website_final_url",
+		"website_tls_valid",
+		"website_tls_expires_at",
+		"website_parked",
 	}
+
+	return append(headers, e.customFieldNames()...)
 }
 
 func (e *Entry) CsvRow() []string {
-	return []string{
+	row := []string{
 		e.ID,
 		e.Link,
 		e.Title,
@@ -329,7 +454,36 @@ func (e *Entry) CsvRow() []string {
 		stringSliceToString(e.Emails),
 		e.EmailStatus,
 		e.EmailSource,
+		e.Query,
+		stringify(e.IsServiceArea),
+		e.ServiceArea,
+		stringify(e.EmailConfidence),
+		stringSliceToString(e.EmailClassifications),
+		e.EmailError,
+		e.DescriptionLanguage,
+	}
+
+	row = append(row, e.reviewAnalysisCsvValues()...)
+	row = append(row, e.hotelCsvValues()...)
+	row = append(row, stringify(e.BookingLinks), stringSliceToString(bookingPlatformNames(e.BookingLinks)))
+	row = append(row, e.priceCsvValues()...)
+	row = append(row, e.osmCsvValues()...)
+	row = append(row, e.registrationCsvValues()...)
+	row = append(row, e.aiCsvValues()...)
+	row = append(row, e.PageLanguage)
+	row = append(row, e.websiteStatusCsvValues()...)
+
+	for _, name := range e.customFieldNames() {
+		row = append(row, e.CustomFields[name])
 	}
+
+	return row
+}
+
+// customFieldNames returns the names of the custom fields in CSV column
+// order.
+func (e *Entry) customFieldNames() []string {
+	return slices.Sorted(maps.Keys(e.CustomFields))
 }
 
 func (e *Entry) AddExtraReviews(pages [][]byte) {
@@ -374,8 +528,16 @@ func extractReviews(data []byte) []Review {
 	return parseReviews(reviewsI)
 }
 
-//nolint:gomnd // it's ok, I need the indexes
 func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error) {
+	onlyReviewCount := len(reviewCountOnly) == 1 && reviewCountOnly[0]
+
+	return entryFromJSON(raw, onlyReviewCount, SkipFields{})
+}
+
+// entryFromJSON parses raw, leaving out the work on the fields skip skips.
+//
+//nolint:gomnd // it's ok, I need the indexes
+func entryFromJSON(raw []byte, onlyReviewCount bool, skip SkipFields) (entry Entry, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("recovered from panic: %v stack: %s", r, debug.Stack())
@@ -384,12 +546,6 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 		}
 	}()
 
-	onlyReviewCount := false
-
-	if len(reviewCountOnly) == 1 && reviewCountOnly[0] {
-		onlyReviewCount = true
-	}
-
 	var jd []any
 	if err := json.Unmarshal(raw, &jd); err != nil {
 		return entry, err
@@ -463,6 +619,10 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 	// Extract Street View URL from images
 	entry.StreetViewURL = extractStreetViewURL(entry.Images)
 
+	if skip.Images {
+		entry.Images = []Image{}
+	}
+
 	entry.Reservations = getLinkSource(getLinkSourceParams{
 		arr:    getNthElementAndCast[[]any](darray, 46),
 		link:   []int{0},
@@ -481,6 +641,8 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 		source: []int{0, 0},
 	})
 
+	entry.BookingLinks = parseBookingLinks(darray)
+
 	entry.Menu = LinkSource{
 		Link:   getNthElementAndCast[string](darray, 38, 0),
 		Source: getNthElementAndCast[string](darray, 38, 1),
@@ -504,31 +666,21 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 		Country:    getNthElementAndCast[string](darray, 183, 1, 6),
 	}
 
-	aboutI := getNthElementAndCast[[]any](darray, 100, 1)
-
-	for i := range aboutI {
-		el := getNthElementAndCast[[]any](aboutI, i)
-		about := About{
-			ID:   getNthElementAndCast[string](el, 0),
-			Name: getNthElementAndCast[string](el, 1),
-		}
-
-		optsI := getNthElementAndCast[[]any](el, 2)
-
-		for j := range optsI {
-			opt := Option{
-				Enabled: (getNthElementAndCast[float64](optsI, j, 2, 1, 0, 0)) == 1,
-				Name:    getNthElementAndCast[string](optsI, j, 1),
-			}
+	entry.normalizePrice()
 
-			if opt.Name != "" {
-				about.Options = append(about.Options, opt)
-			}
-		}
+	if area, ok := serviceAreaFromJSON(darray, entry.Title); ok {
+		entry.markServiceArea(area)
+	}
 
-		entry.About = append(entry.About, about)
+	if skip.About {
+		entry.About = []About{}
+	} else {
+		entry.About = parseAbout(darray)
+		entry.Attributes = attributesOf(entry.About)
 	}
 
+	parseHotel(darray, &entry)
+
 	entry.ReviewsPerRating = map[int]int{
 		1: int(getNthElementAndCast[float64](darray, 175, 3, 0)),
 		2: int(getNthElementAndCast[float64](darray, 175, 3, 1)),
@@ -537,6 +689,12 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 		5: int(getNthElementAndCast[float64](darray, 175, 3, 4)),
 	}
 
+	if skip.Reviews {
+		entry.UserReviews = make([]Review, 0)
+
+		return entry, nil
+	}
+
 	// Parse inline reviews from the page data
 	reviewsI := getNthElementAndCast[[]any](darray, 175, 9, 0, 0)
 	if len(reviewsI) > 0 {
@@ -0,0 +1,167 @@
+package gmaps
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// csvColumnKind tells how a CSV column holds the value of its Entry field.
+type csvColumnKind int
+
+const (
+	// csvText is a string.
+	csvText csvColumnKind = iota
+	// csvJSON is a number, a boolean, an object or an array, written as
+	// JSON by stringify.
+	csvJSON
+	// csvList is a list of strings joined by stringSliceToString.
+	csvList
+)
+
+// csvColumns maps the CSV columns read back by EntriesFromCSV to the JSON
+// keys of Entry. They are the columns of the upstream scraper, which this
+// CSV extends; the columns derived from other fields are not read back.
+var csvColumns = map[string]struct {
+	key  string
+	kind csvColumnKind
+}{
+	"input_id":              {"input_id", csvText},
+	"link":                  {"link", csvText},
+	"title":                 {"title", csvText},
+	"category":              {"category", csvText},
+	"address":               {"address", csvText},
+	"open_hours":            {"open_hours", csvJSON},
+	"popular_times":         {"popular_times", csvJSON},
+	"website":               {"web_site", csvText},
+	"phone":                 {"phone", csvText},
+	"plus_code":             {"plus_code", csvText},
+	"review_count":          {"review_count", csvJSON},
+	"review_rating":         {"review_rating", csvJSON},
+	"reviews_per_rating":    {"reviews_per_rating", csvJSON},
+	"latitude":              {"latitude", csvJSON},
+	"longitude":             {"longitude", csvJSON},
+	"cid":                   {"cid", csvText},
+	"status":                {"status", csvText},
+	"descriptions":          {"description", csvText},
+	"reviews_link":          {"reviews_link", csvText},
+	"thumbnail":             {"thumbnail", csvText},
+	"timezone":              {"timezone", csvText},
+	"price_range":           {"price_range", csvText},
+	"data_id":               {"data_id", csvText},
+	"street_view_url":       {"street_view_url", csvText},
+	"place_id":              {"place_id", csvText},
+	"images":                {"images", csvJSON},
+	"reservations":          {"reservations", csvJSON},
+	"order_online":          {"order_online", csvJSON},
+	"menu":                  {"menu", csvJSON},
+	"owner":                 {"owner", csvJSON},
+	"complete_address":      {"complete_address", csvJSON},
+	"about":                 {"about", csvJSON},
+	"user_reviews":          {"user_reviews", csvJSON},
+	"user_reviews_extended": {"user_reviews_extended", csvJSON},
+	"emails":                {"emails", csvList},
+	"email_status":          {"email_status", csvText},
+	"email_source":          {"email_source", csvText},
+	"query":                 {"query", csvText},
+	"is_service_area":       {"is_service_area", csvJSON},
+	"service_area":          {"service_area", csvText},
+	"email_confidence":      {"email_confidence", csvJSON},
+	"email_classifications": {"email_classifications", csvList},
+	"email_error":           {"email_error", csvText},
+	"description_language":  {"description_language", csvText},
+	"booking_links":         {"booking_links", csvJSON},
+	"page_language":         {"page_language", csvText},
+	"scraped_at":            {"scraped_at", csvText},
+	"source_url":            {"source_url", csvText},
+	"job_id":                {"job_id", csvText},
+	"lang":                  {"lang", csvText},
+	"scraper_version":       {"scraper_version", csvText},
+}
+
+// EntriesFromCSV reads the entries of a CSV written by the upstream
+// scraper, gosom/google-maps-scraper, or by this one. The columns are
+// found by name, so their order does not matter, and the unknown ones are
+// skipped.
+func EntriesFromCSV(r io.Reader) ([]*Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return []*Entry{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header) > 0 {
+		// the utf-8-bom exports
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	if !slices.Contains(header, "title") {
+		return nil, errors.New("not a CSV of places: no title column")
+	}
+
+	entries := []*Entry{}
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := entryFromCSVRow(header, row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		entries = append(entries, e)
+	}
+}
+
+func entryFromCSVRow(header, row []string) (*Entry, error) {
+	fields := map[string]json.RawMessage{}
+
+	for i, name := range header {
+		col, ok := csvColumns[name]
+		if !ok || i >= len(row) || row[i] == "" {
+			continue
+		}
+
+		switch col.kind {
+		case csvText:
+			fields[col.key], _ = json.Marshal(row[i])
+		case csvJSON:
+			if !json.Valid([]byte(row[i])) {
+				return nil, fmt.Errorf("column %s: invalid value %q", name, row[i])
+			}
+
+			fields[col.key] = json.RawMessage(row[i])
+		case csvList:
+			fields[col.key], _ = json.Marshal(strings.Split(row[i], ", "))
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,93 @@
+package gmaps_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestEntriesFromCSVRoundTrip(t *testing.T) {
+	e := &gmaps.Entry{
+		ID:               "seed-1",
+		Link:             "https://www.google.com/maps/place/Kipriakon",
+		Title:            "Kipriakon",
+		Category:         "Restaurant",
+		Address:          "Old port, Limassol 3042",
+		OpenHours:        map[string][]string{"Monday": {"12:30–10 pm"}},
+		WebSite:          "https://kipriakon.example",
+		Phone:            "25 101555",
+		ReviewCount:      396,
+		ReviewRating:     4.2,
+		ReviewsPerRating: map[int]int{1: 10, 5: 300},
+		Latitude:         34.670595,
+		Longtitude:       33.042456,
+		Cid:              "16519582940102929223",
+		Description:      "Greek tavern, by the sea",
+		Images:           []gmaps.Image{{Title: "All", Image: "https://lh5.example/a.jpg"}},
+		Owner:            gmaps.Owner{ID: "1", Name: "Kipriakon"},
+		CompleteAddress:  gmaps.Address{City: "Limassol", Country: "CY"},
+		UserReviews:      []gmaps.Review{{Name: "Anna", Rating: 5, Description: "Great, fresh fish"}},
+		Emails:           []string{"info@kipriakon.example", "booking@kipriakon.example"},
+		IsServiceArea:    true,
+		Query:            "restaurants limassol",
+	}
+
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	require.NoError(t, w.Write(e.CsvHeaders()))
+	require.NoError(t, w.Write(e.CsvRow()))
+	w.Flush()
+
+	entries, err := gmaps.EntriesFromCSV(&buf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	got := entries[0]
+	require.Equal(t, e.Title, got.Title)
+	require.Equal(t, e.WebSite, got.WebSite)
+	require.Equal(t, e.OpenHours, got.OpenHours)
+	require.Equal(t, e.ReviewCount, got.ReviewCount)
+	require.InDelta(t, e.ReviewRating, got.ReviewRating, 1e-6)
+	require.Equal(t, e.ReviewsPerRating, got.ReviewsPerRating)
+	require.InDelta(t, e.Latitude, got.Latitude, 1e-6)
+	require.InDelta(t, e.Longtitude, got.Longtitude, 1e-6)
+	require.Equal(t, e.Description, got.Description)
+	require.Equal(t, e.Images, got.Images)
+	require.Equal(t, e.Owner, got.Owner)
+	require.Equal(t, e.CompleteAddress, got.CompleteAddress)
+	require.Equal(t, e.UserReviews[0].Description, got.UserReviews[0].Description)
+	require.Equal(t, e.Emails, got.Emails)
+	require.True(t, got.IsServiceArea)
+	require.Equal(t, e.Query, got.Query)
+}
+
+func TestEntriesFromCSVUpstream(t *testing.T) {
+	// the columns of the upstream scraper, in its order
+	const data = "input_id,link,title,category,address,open_hours,popular_times,website,phone,plus_code,review_count,review_rating,reviews_per_rating,latitude,longitude,cid,status,descriptions,reviews_link,thumbnail,timezone,price_range,data_id,images,reservations,order_online,menu,owner,complete_address,about,user_reviews,user_reviews_extended,emails\n" +
+		`,https://www.google.com/maps/place/x,Bakery Rossi,Bakery,"Via Roma 1, Milano",null,null,https://rossi.example,02 123,,12,4.500000,"{""5"":12}",45.464000,9.190000,123,,,,,,€,0x1:0x2,null,null,null,"{""link"":"""",""source"":""""}","{""id"":"""",""name"":"""",""link"":""""}","{""borough"":"""",""street"":""Via Roma 1"",""city"":""Milano"",""postal_code"":"""",""state"":"""",""country"":""IT""}",null,null,null,info@rossi.example` + "\n"
+
+	entries, err := gmaps.EntriesFromCSV(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	e := entries[0]
+	require.Equal(t, "Bakery Rossi", e.Title)
+	require.Equal(t, "https://rossi.example", e.WebSite)
+	require.Equal(t, 12, e.ReviewCount)
+	require.InDelta(t, 4.5, e.ReviewRating, 1e-6)
+	require.Equal(t, map[int]int{5: 12}, e.ReviewsPerRating)
+	require.InDelta(t, 9.19, e.Longtitude, 1e-6)
+	require.Equal(t, "Milano", e.CompleteAddress.City)
+	require.Equal(t, []string{"info@rossi.example"}, e.Emails)
+}
+
+func TestEntriesFromCSVNotPlaces(t *testing.T) {
+	_, err := gmaps.EntriesFromCSV(strings.NewReader("name,email\nRossi,info@rossi.example\n"))
+	require.Error(t, err)
+}
@@ -129,6 +129,8 @@ func Test_EntryFromJSON(t *testing.T) {
 			4: 60,
 			5: 256,
 		},
+		PriceLevel:    2,
+		PriceCurrency: "EUR",
 	}
 
 	raw, err := os.ReadFile("../testdata/raw.json")
@@ -148,6 +150,11 @@ func Test_EntryFromJSON(t *testing.T) {
 
 	entry.About = nil
 
+	require.Len(t, entry.Attributes, 10)
+	require.Equal(t, "Mastercard", entry.Attributes["payments"]["pay_credit_card_types_accepted"])
+
+	entry.Attributes = nil
+
 	require.Len(t, entry.PopularTimes, 7)
 
 	for k, v := range entry.PopularTimes {
@@ -251,3 +258,41 @@ func Test_EntryFromJsonC(t *testing.T) {
 		fmt.Printf("%+v\n", entry)
 	}
 }
+
+func Test_EntryFromJSONServiceArea(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/raw.json")
+	require.NoError(t, err)
+
+	var jd []any
+
+	require.NoError(t, json.Unmarshal(raw, &jd))
+
+	// service-area businesses come without address lines and street, and the
+	// display line only names the served area
+	darray := jd[6].([]any)
+	darray[2] = nil
+	darray[18] = "Kipriakon, Limassol"
+	darray[183].([]any)[1].([]any)[1] = nil
+
+	raw, err = json.Marshal(jd)
+	require.NoError(t, err)
+
+	entry, err := gmaps.EntryFromJSON(raw)
+	require.NoError(t, err)
+
+	require.True(t, entry.IsServiceArea)
+	require.Equal(t, "Limassol", entry.ServiceArea)
+	require.Empty(t, entry.Address)
+	require.Empty(t, entry.CompleteAddress.Street)
+	require.Empty(t, entry.CompleteAddress.PostalCode)
+	require.Equal(t, "Limassol", entry.CompleteAddress.City)
+
+	regular, err := os.ReadFile("../testdata/raw.json")
+	require.NoError(t, err)
+
+	entry, err = gmaps.EntryFromJSON(regular)
+	require.NoError(t, err)
+
+	require.False(t, entry.IsServiceArea)
+	require.NotEmpty(t, entry.Address)
+}
@@ -0,0 +1,223 @@
+package gmaps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Exclusions are the places a job must leave alone, such as the ones that
+// asked not to be contacted. The places excluded by name or CID are skipped
+// from the result links, without visiting them. The website of a place is
+// only known once it is visited: the places excluded by domain are dropped
+// then, before their email extraction. The places left out by their
+// category, rating or review count are skipped from the result links when
+// their card tells it, and dropped once visited otherwise. So are the places
+// missing a required contact, once visited. Its methods are safe on nil
+// exclusions, which exclude nothing.
+type Exclusions struct {
+	domains    *SuppressionList
+	names      map[string]bool
+	cids       map[string]bool
+	categories *CategoryFilter
+	ratings    *RatingFilter
+	required   RequiredContacts
+}
+
+// NewExclusions returns the exclusions of the website domains (or URLs) and
+// of the places, given by CID or by name, or nil when there are none.
+func NewExclusions(domains, places []string) (*Exclusions, error) {
+	var valid []string
+
+	for _, d := range domains {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+
+		v, ok := normalizeDomain(strings.ToLower(strings.TrimSpace(d)))
+		if !ok {
+			return nil, fmt.Errorf("invalid excluded domain %q", d)
+		}
+
+		valid = append(valid, v)
+	}
+
+	x := Exclusions{
+		domains: NewSuppressionList(valid),
+		names:   map[string]bool{},
+		cids:    map[string]bool{},
+	}
+
+	for _, p := range places {
+		p = strings.TrimSpace(p)
+
+		switch {
+		case p == "":
+		case isDecimal(p):
+			x.cids[p] = true
+		default:
+			x.names[normalizePlaceName(p)] = true
+		}
+	}
+
+	if x.domains.Len() == 0 && len(x.names) == 0 && len(x.cids) == 0 {
+		return nil, nil
+	}
+
+	return &x, nil
+}
+
+// ReadExclusionList reads the lines of r, skipping the empty ones and the
+// comments starting with '#'.
+func ReadExclusionList(r io.Reader) ([]string, error) {
+	var ans []string
+
+	sc := bufio.NewScanner(r)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			ans = append(ans, line)
+		}
+	}
+
+	return ans, sc.Err()
+}
+
+// normalizePlaceName lowercases name and collapses its spaces, so that the
+// names of the lists match the ones of the pages whatever their case.
+func normalizePlaceName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Len returns the number of domains, names and CIDs x excludes.
+func (x *Exclusions) Len() int {
+	if x == nil {
+		return 0
+	}
+
+	return x.domains.Len() + len(x.names) + len(x.cids)
+}
+
+// orEmpty returns x, or exclusions excluding nothing yet when x is nil.
+func (x *Exclusions) orEmpty() *Exclusions {
+	if x != nil {
+		return x
+	}
+
+	return &Exclusions{
+		domains: NewSuppressionList(nil),
+		names:   map[string]bool{},
+		cids:    map[string]bool{},
+	}
+}
+
+// FilterCategories returns x also leaving out the places f does not keep.
+func (x *Exclusions) FilterCategories(f *CategoryFilter) *Exclusions {
+	if f == nil {
+		return x
+	}
+
+	x = x.orEmpty()
+	x.categories = f
+
+	return x
+}
+
+// FilterRatings returns x also leaving out the places f does not keep.
+func (x *Exclusions) FilterRatings(f *RatingFilter) *Exclusions {
+	if f == nil {
+		return x
+	}
+
+	x = x.orEmpty()
+	x.ratings = f
+
+	return x
+}
+
+// Require returns x also leaving out the places missing a contact of r.
+func (x *Exclusions) Require(r RequiredContacts) *Exclusions {
+	if !r.Any() {
+		return x
+	}
+
+	x = x.orEmpty()
+	x.required = r
+
+	return x
+}
+
+// SkipResult reports whether the place of the result link a is excluded by
+// its CID, name or, as shown by its card, category, rating or review count.
+func (x *Exclusions) SkipResult(a *goquery.Selection) bool {
+	if x == nil {
+		return false
+	}
+
+	return x.SkipPlaceURL(a.AttrOr("href", "")) || !x.categories.keepsCard(a) || !x.ratings.keepsCard(a)
+}
+
+// SkipPlaceURL reports whether the place of the Google Maps link u is
+// excluded by its CID or name, both read from the link.
+func (x *Exclusions) SkipPlaceURL(u string) bool {
+	if x == nil {
+		return false
+	}
+
+	if cid := ParsePlaceIdentity(u).CID; cid != "" && x.cids[cid] {
+		return true
+	}
+
+	name := placeNameFromURL(u)
+
+	return name != "" && x.names[normalizePlaceName(name)]
+}
+
+// Excludes reports whether e is excluded by its CID, title, category,
+// rating, review count, missing contacts or website domain.
+func (x *Exclusions) Excludes(e *Entry) bool {
+	if x == nil {
+		return false
+	}
+
+	if !x.categories.Keeps(e.Category) || !x.ratings.Keeps(e.ReviewRating, e.ReviewCount) || !x.required.Keeps(e) {
+		return true
+	}
+
+	if e.Cid != "" && x.cids[e.Cid] {
+		return true
+	}
+
+	if e.Title != "" && x.names[normalizePlaceName(e.Title)] {
+		return true
+	}
+
+	if e.WebSite == "" {
+		return false
+	}
+
+	return x.domains.Match(&Entry{WebSite: e.WebSite}) != ""
+}
+
+// placeNameFromURL returns the name in the /maps/place/<name>/ path of a
+// place link.
+func placeNameFromURL(u string) string {
+	_, rest, ok := strings.Cut(u, "/maps/place/")
+	if !ok {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(rest, "/")
+
+	name, err := url.QueryUnescape(name)
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
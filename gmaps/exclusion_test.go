@@ -0,0 +1,50 @@
+package gmaps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExclusions(t *testing.T) {
+	x, err := NewExclusions(
+		[]string{"https://www.chain-brand.com/", "optout.de"},
+		[]string{"Pizzeria  Da Mario", "16519582940102929223", ""},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 4, x.Len())
+
+	byCID := "https://www.google.com/maps/place/Some+Place/data=!4m7!3m6!1s0x47c0b01f7e8b2e0f:0xe5415928d6702b47!8m2"
+	byName := "https://www.google.com/maps/place/Pizzeria+da+Mario/@45.46,9.18,17z"
+	other := "https://www.google.com/maps/place/Trattoria+Roma/data=!4m7!3m6!1s0x47c0b01f7e8b2e0f:0x1!8m2"
+
+	require.True(t, x.SkipPlaceURL(byCID))
+	require.True(t, x.SkipPlaceURL(byName))
+	require.False(t, x.SkipPlaceURL(other))
+
+	require.True(t, x.Excludes(&Entry{Title: "PIZZERIA DA MARIO"}))
+	require.True(t, x.Excludes(&Entry{Cid: "16519582940102929223"}))
+	require.True(t, x.Excludes(&Entry{WebSite: "https://shop.chain-brand.com/it"}))
+	require.True(t, x.Excludes(&Entry{WebSite: "http://optout.de"}))
+	require.False(t, x.Excludes(&Entry{Title: "Trattoria Roma", WebSite: "https://roma.it"}))
+	// the emails are not fetched yet when the place is checked
+	require.False(t, x.Excludes(&Entry{Emails: []string{"info@optout.de"}}))
+}
+
+func TestExclusionsEmpty(t *testing.T) {
+	x, err := NewExclusions(nil, []string{" "})
+	require.NoError(t, err)
+	require.Nil(t, x)
+	require.False(t, x.SkipPlaceURL("https://www.google.com/maps/place/Anything"))
+	require.False(t, x.Excludes(&Entry{Title: "Anything"}))
+
+	_, err = NewExclusions([]string{"localhost"}, nil)
+	require.Error(t, err)
+}
+
+func TestReadExclusionList(t *testing.T) {
+	values, err := ReadExclusionList(strings.NewReader("# opt-outs\nexample.com\n\n  Pizzeria Da Mario  \n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"example.com", "Pizzeria Da Mario"}, values)
+}
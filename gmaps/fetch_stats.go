@@ -0,0 +1,330 @@
+package gmaps
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Fetch failure classes. A bare "website_error" does not tell whether to
+// rotate proxies, slow down or give up on a site; these do.
+const (
+	FetchErrConnRefused = "connect_refused"
+	FetchErrDNS         = "dns_error"
+	FetchErrTimeout     = "timeout"
+	FetchErrTLS         = "tls_error"
+	FetchErrProxy       = "proxy_error"
+	FetchErrForbidden   = "http_403"
+	FetchErrRateLimited = "http_429"
+	FetchErrHTTP4xx     = "http_4xx"
+	FetchErrHTTP5xx     = "http_5xx"
+	FetchErrNonHTML     = "non_html"
+	FetchErrOther       = "other"
+)
+
+// Fetch sources recorded in FetchStats.
+const (
+	FetchSourceMaps  = "maps"
+	FetchSourceEmail = "email"
+)
+
+// maxReportedTargets caps the per-website breakdown of a FetchErrorReport.
+const maxReportedTargets = 20
+
+// ClassifyFetchError maps a failed fetch to one of the FetchErr classes. It
+// understands both Go network errors (email pipeline) and the net::ERR_*
+// messages of the browser (Maps crawl). statusCode is used when err is nil.
+func ClassifyFetchError(err error, statusCode int) string {
+	switch {
+	case statusCode == 403:
+		return FetchErrForbidden
+	case statusCode == 429:
+		return FetchErrRateLimited
+	case statusCode == 407:
+		return FetchErrProxy
+	case statusCode >= 500:
+		return FetchErrHTTP5xx
+	case statusCode >= 400:
+		return FetchErrHTTP4xx
+	}
+
+	if err == nil {
+		return ""
+	}
+
+	var (
+		opErr    *net.OpError
+		dnsErr   *net.DNSError
+		certErr  *tls.CertificateVerificationError
+		recErr   tls.RecordHeaderError
+		unkAuth  x509.UnknownAuthorityError
+		hostErr  x509.HostnameError
+		invalErr x509.CertificateInvalidError
+		netErr   net.Error
+	)
+
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		return FetchErrProxy
+	case errors.Is(err, errNonHTMLContent):
+		return FetchErrNonHTML
+	case errors.As(err, &dnsErr):
+		return FetchErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FetchErrConnRefused
+	case errors.As(err, &certErr), errors.As(err, &recErr), errors.As(err, &unkAuth),
+		errors.As(err, &hostErr), errors.As(err, &invalErr):
+		return FetchErrTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return FetchErrTimeout
+	}
+
+	return classifyFetchErrorMessage(err.Error())
+}
+
+// classifyFetchErrorMessage handles errors that only carry a message, such
+// as the Chromium net::ERR_* codes reported by Playwright.
+func classifyFetchErrorMessage(msg string) string {
+	msg = strings.ToLower(msg)
+
+	switch {
+	case strings.Contains(msg, "err_proxy"), strings.Contains(msg, "err_tunnel_connection_failed"),
+		strings.Contains(msg, "proxyconnect"):
+		return FetchErrProxy
+	case strings.Contains(msg, "err_name_not_resolved"), strings.Contains(msg, "no such host"):
+		return FetchErrDNS
+	case strings.Contains(msg, "err_connection_refused"), strings.Contains(msg, "connection refused"):
+		return FetchErrConnRefused
+	case strings.Contains(msg, "err_cert_"), strings.Contains(msg, "err_ssl_"), strings.Contains(msg, "tls:"),
+		strings.Contains(msg, "x509:"):
+		return FetchErrTLS
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed_out"), strings.Contains(msg, "timed out"):
+		return FetchErrTimeout
+	default:
+		return FetchErrOther
+	}
+}
+
+// isProxyFault reports whether a failure class points at the proxy rather
+// than at the website.
+func isProxyFault(class string) bool {
+	return class == FetchErrProxy
+}
+
+// isTargetBlock reports whether the website refused the exit IP, so another
+// proxy may succeed.
+func isTargetBlock(class string) bool {
+	return class == FetchErrForbidden || class == FetchErrRateLimited
+}
+
+// FetchError is returned by the email pipeline fetches. It carries the
+// failure class and the proxy that was used.
+type FetchError struct {
+	URL        string
+	Proxy      *url.URL
+	StatusCode int
+	Class      string
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("HTTP %d for %s", e.StatusCode, e.URL)
+	}
+
+	return fmt.Sprintf("fetching %s: %v", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// proxyLabel identifies a proxy in reports without leaking its credentials.
+func proxyLabel(p *url.URL) string {
+	if p == nil {
+		return "direct"
+	}
+
+	return p.Scheme + "://" + p.Host
+}
+
+type fetchErrorKey struct {
+	source string
+	proxy  string
+	target string
+	class  string
+}
+
+// FetchStats collects the failed fetches of a job by source, proxy and
+// website, and the bytes it downloaded by source and proxy. It is safe for
+// concurrent use; a nil *FetchStats records nothing.
+type FetchStats struct {
+	mu     sync.Mutex
+	errors map[fetchErrorKey]int
+	bytes  map[bandwidthKey]int64
+}
+
+// NewFetchStats creates an empty FetchStats.
+func NewFetchStats() *FetchStats {
+	return &FetchStats{
+		errors: make(map[fetchErrorKey]int),
+		bytes:  make(map[bandwidthKey]int64),
+	}
+}
+
+// RecordError counts a failed fetch of target (a host name) through proxy
+// (a proxyLabel, empty when unknown).
+func (s *FetchStats) RecordError(source, proxy, target, class string) {
+	if s == nil || class == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors[fetchErrorKey{source: source, proxy: proxy, target: target, class: class}]++
+}
+
+// RecordMapsError counts a failed Google Maps fetch. The proxy is not known:
+// scrapemate rotates the proxies of the crawl on its own.
+func (s *FetchStats) RecordMapsError(rawURL string, err error, statusCode int) {
+	if s == nil {
+		return
+	}
+
+	var host string
+	if u, perr := url.Parse(rawURL); perr == nil {
+		host = u.Hostname()
+	}
+
+	s.RecordError(FetchSourceMaps, "", host, ClassifyFetchError(err, statusCode))
+}
+
+// FetchErrorReport is the breakdown of the failed fetches of a job.
+type FetchErrorReport struct {
+	Total    int                       `json:"total"`
+	ByClass  map[string]int            `json:"by_class,omitempty"`
+	BySource map[string]map[string]int `json:"by_source,omitempty"`
+	// ByProxy is keyed by proxy ("direct" for no proxy). Maps crawl errors
+	// are not attributed: scrapemate picks their proxy internally.
+	ByProxy map[string]map[string]int `json:"by_proxy,omitempty"`
+	// Targets lists the websites with the most failures.
+	Targets []FetchTargetErrors `json:"targets,omitempty"`
+}
+
+// FetchTargetErrors is the failure breakdown of a single website.
+type FetchTargetErrors struct {
+	Host    string         `json:"host"`
+	Total   int            `json:"total"`
+	ByClass map[string]int `json:"by_class"`
+}
+
+// ErrorReport summarizes the recorded failures.
+func (s *FetchStats) ErrorReport() FetchErrorReport {
+	var report FetchErrorReport
+
+	if s == nil {
+		return report
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.errors) == 0 {
+		return report
+	}
+
+	report.ByClass = make(map[string]int)
+	report.BySource = make(map[string]map[string]int)
+	report.ByProxy = make(map[string]map[string]int)
+
+	targets := make(map[string]*FetchTargetErrors)
+
+	for k, n := range s.errors {
+		report.Total += n
+		report.ByClass[k.class] += n
+		addNested(report.BySource, k.source, k.class, n)
+
+		if k.proxy != "" {
+			addNested(report.ByProxy, k.proxy, k.class, n)
+		}
+
+		if k.target == "" {
+			continue
+		}
+
+		t, ok := targets[k.target]
+		if !ok {
+			t = &FetchTargetErrors{Host: k.target, ByClass: make(map[string]int)}
+			targets[k.target] = t
+		}
+
+		t.Total += n
+		t.ByClass[k.class] += n
+	}
+
+	for _, t := range targets {
+		report.Targets = append(report.Targets, *t)
+	}
+
+	slices.SortFunc(report.Targets, func(a, b FetchTargetErrors) int {
+		if a.Total != b.Total {
+			return b.Total - a.Total
+		}
+
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	if len(report.Targets) > maxReportedTargets {
+		report.Targets = report.Targets[:maxReportedTargets]
+	}
+
+	if len(report.ByProxy) == 0 {
+		report.ByProxy = nil
+	}
+
+	return report
+}
+
+// Summary renders the classes by count, e.g. "timeout: 5, http_403: 2".
+func (r FetchErrorReport) Summary() string {
+	classes := make([]string, 0, len(r.ByClass))
+	for c := range r.ByClass {
+		classes = append(classes, c)
+	}
+
+	slices.SortFunc(classes, func(a, b string) int {
+		if r.ByClass[a] != r.ByClass[b] {
+			return r.ByClass[b] - r.ByClass[a]
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	parts := make([]string, 0, len(classes))
+	for _, c := range classes {
+		parts = append(parts, fmt.Sprintf("%s: %d", c, r.ByClass[c]))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func addNested(m map[string]map[string]int, key, class string, n int) {
+	inner, ok := m[key]
+	if !ok {
+		inner = make(map[string]int)
+		m[key] = inner
+	}
+
+	inner[class] += n
+}
@@ -0,0 +1,103 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFetchError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   string
+	}{
+		{"forbidden", nil, 403, FetchErrForbidden},
+		{"rate limited", nil, 429, FetchErrRateLimited},
+		{"proxy auth", nil, 407, FetchErrProxy},
+		{"server error", nil, 503, FetchErrHTTP5xx},
+		{"not found", nil, 404, FetchErrHTTP4xx},
+		{"ok", nil, 200, ""},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 0, FetchErrConnRefused},
+		{"proxyconnect", &net.OpError{Op: "proxyconnect", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, 0, FetchErrProxy},
+		{"dns", &net.DNSError{Err: "no such host", Name: "shop.it", IsNotFound: true}, 0, FetchErrDNS},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), 0, FetchErrTimeout},
+		{"non html", fmt.Errorf("%w (application/pdf)", errNonHTMLContent), 0, FetchErrNonHTML},
+		{"tls", errors.New("remote error: tls: handshake failure"), 0, FetchErrTLS},
+		{"browser refused", errors.New("page.goto: net::ERR_CONNECTION_REFUSED at https://shop.it"), 0, FetchErrConnRefused},
+		{"browser timeout", errors.New("page.goto: Timeout 30000ms exceeded."), 0, FetchErrTimeout},
+		{"browser proxy", errors.New("net::ERR_PROXY_CONNECTION_FAILED"), 0, FetchErrProxy},
+		{"browser cert", errors.New("net::ERR_CERT_DATE_INVALID"), 0, FetchErrTLS},
+		{"unknown", errors.New("boom"), 0, FetchErrOther},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ClassifyFetchError(tc.err, tc.status))
+		})
+	}
+}
+
+func TestFetchStatsErrorReport(t *testing.T) {
+	s := NewFetchStats()
+
+	s.RecordError(FetchSourceEmail, "socks5://de1:1080", "baeckerei.de", FetchErrForbidden)
+	s.RecordError(FetchSourceEmail, "socks5://de1:1080", "baeckerei.de", FetchErrForbidden)
+	s.RecordError(FetchSourceEmail, "direct", "shop.it", FetchErrTimeout)
+	s.RecordMapsError("https://www.google.com/maps/search/pizza", errors.New("net::ERR_TIMED_OUT"), 0)
+
+	report := s.ErrorReport()
+
+	require.Equal(t, 4, report.Total)
+	require.Equal(t, map[string]int{FetchErrForbidden: 2, FetchErrTimeout: 2}, report.ByClass)
+	require.Equal(t, map[string]int{FetchErrTimeout: 1}, report.BySource[FetchSourceMaps])
+	require.Equal(t, map[string]int{FetchErrForbidden: 2}, report.ByProxy["socks5://de1:1080"])
+	require.NotContains(t, report.ByProxy, "")
+	require.Equal(t, "baeckerei.de", report.Targets[0].Host)
+	require.Equal(t, "http_403: 2, timeout: 2", report.Summary())
+
+	var nilStats *FetchStats
+
+	nilStats.RecordError(FetchSourceEmail, "", "shop.it", FetchErrOther)
+	require.Zero(t, nilStats.ErrorReport().Total)
+}
+
+func TestEmailPipelineRecordsFetchErrors(t *testing.T) {
+	var hits int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	stats := NewFetchStats()
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil, WithEmailPipelineFetchStats(stats)).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "not_found", entry.EmailStatus)
+	require.Equal(t, FetchErrHTTP4xx, entry.EmailError)
+
+	// a 404 is not retried
+	require.Equal(t, 1, hits)
+
+	u, _ := url.Parse(srv.URL)
+	report := stats.ErrorReport()
+
+	require.Equal(t, 1, report.Total)
+	require.Equal(t, map[string]int{FetchErrHTTP4xx: 1}, report.ByProxy["direct"])
+	require.Equal(t, u.Hostname(), report.Targets[0].Host)
+}
@@ -0,0 +1,358 @@
+package gmaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosom/scrapemate"
+)
+
+// maxHARs caps the websites a HARCapture records.
+const maxHARs = 50
+
+// HARCapture records an HTTP archive (HAR) of the Level 3 browser fetches of
+// a sample of the websites searched for emails, so that the JS-heavy
+// websites that never show their contact info can be looked into: which
+// requests the page made, which failed and what they answered. It is safe
+// for concurrent use; a nil *HARCapture records nothing.
+type HARCapture struct {
+	dir  string
+	rate float64
+
+	mu    sync.Mutex
+	taken int
+}
+
+// NewHARCapture returns a HARCapture saving the HAR files into dir, for
+// about rate (0 to 1) of the websites. It returns nil when dir is empty or
+// rate is not positive.
+func NewHARCapture(dir string, rate float64) *HARCapture {
+	if dir == "" || rate <= 0 {
+		return nil
+	}
+
+	return &HARCapture{dir: dir, rate: min(rate, 1)}
+}
+
+// claim reports whether the browser fetches of website are to be recorded.
+// The sample goes by domain, so that a run again records the same websites.
+func (c *HARCapture) claim(website string) bool {
+	if c == nil {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(websiteDomain(website)))
+
+	if float64(h.Sum32()%10000) >= c.rate*10000 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.taken >= maxHARs {
+		return false
+	}
+
+	c.taken++
+
+	return true
+}
+
+// record starts recording the requests of page for the Level 3 fetches of
+// website, returning nil when the website is not sampled or the page cannot
+// be hooked.
+func (c *HARCapture) record(page scrapemate.BrowserPage, website string) *harRecorder {
+	hooks, ok := page.(scrapemate.RequestHookProvider)
+	if !ok || !c.claim(website) {
+		return nil
+	}
+
+	r := &harRecorder{capture: c, website: website}
+
+	// until scrapemate clears the page hooks
+	hooks.OnRequest(r.onRequest)
+	hooks.OnResponse(r.onResponse)
+
+	return r
+}
+
+// harRecorder builds the HAR of the browser fetches of a website from the
+// requests and the responses of the page. The page hooks tell neither the
+// method nor the timings of the requests: they are recorded as GET, timed
+// from the events, and the requests left without a response have status 0
+// like in the HAR of the browsers.
+type harRecorder struct {
+	capture *HARCapture
+	website string
+
+	mu      sync.Mutex
+	saved   bool
+	pages   []harPage
+	entries []*harEntry
+}
+
+// fetcher wraps base so that each fetch starts a page of the HAR.
+func (r *harRecorder) fetcher(base BrowserFetcher) BrowserFetcher {
+	return &harBrowserFetcher{base: base, recorder: r}
+}
+
+func (r *harRecorder) startPage(pageURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pages = append(r.pages, harPage{
+		StartedDateTime: time.Now().UTC(),
+		ID:              fmt.Sprintf("page_%d", len(r.pages)+1),
+		Title:           pageURL,
+		PageTimings:     struct{}{},
+	})
+}
+
+// onRequest and onResponse run in the event loop of the browser and must
+// not block.
+func (r *harRecorder) onRequest(rawURL string, headers map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.saved || len(r.pages) == 0 {
+		return
+	}
+
+	r.entries = append(r.entries, &harEntry{
+		Pageref:         r.pages[len(r.pages)-1].ID,
+		StartedDateTime: time.Now().UTC(),
+		Request: harRequest{
+			Method:      "GET",
+			URL:         rawURL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []struct{}{},
+			Headers:     harHeaders(headers),
+			QueryString: harQueryString(rawURL),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []struct{}{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache: struct{}{},
+	})
+}
+
+func (r *harRecorder) onResponse(rawURL string, statusCode int, headers map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.saved {
+		return
+	}
+
+	// the oldest request of the URL still waiting
+	for _, e := range r.entries {
+		if e.Request.URL != rawURL || e.answered {
+			continue
+		}
+
+		wait := float64(time.Since(e.StartedDateTime).Microseconds()) / 1000
+
+		e.answered = true
+		e.Time = wait
+		e.Timings.Wait = wait
+		e.Response.Status = statusCode
+		e.Response.StatusText = http.StatusText(statusCode)
+		e.Response.Headers = harHeaders(headers)
+		e.Response.RedirectURL = headers["location"]
+		e.Response.Content = harContent{Size: -1, MimeType: headers["content-type"]}
+
+		return
+	}
+}
+
+// save writes the HAR into the folder of the capture, once.
+func (r *harRecorder) save(ctx context.Context) {
+	r.mu.Lock()
+
+	if r.saved {
+		r.mu.Unlock()
+
+		return
+	}
+
+	r.saved = true
+
+	if len(r.pages) == 0 {
+		r.mu.Unlock()
+
+		return
+	}
+
+	entries := make([]harEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "google-maps-scraper", Version: ScraperVersion()},
+		Pages:   r.pages,
+		Entries: entries,
+	}}
+
+	r.mu.Unlock()
+
+	if err := r.capture.save(websiteDomain(r.website), har); err != nil {
+		scrapemate.GetLoggerFromContext(ctx).Warn("Saving email HAR failed", "url", r.website, "error", err)
+	}
+}
+
+func (c *HARCapture) save(domain string, har harFile) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("saving HAR: %w", err)
+	}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("saving HAR: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.har", domain, uuid.New().String()[:8])
+
+	if err := os.WriteFile(filepath.Join(c.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("saving HAR: %w", err)
+	}
+
+	return nil
+}
+
+// harBrowserFetcher starts a page of the HAR of its recorder at each fetch.
+type harBrowserFetcher struct {
+	base     BrowserFetcher
+	recorder *harRecorder
+}
+
+func (f *harBrowserFetcher) FetchWithBrowser(ctx context.Context, pageURL string) (string, error) {
+	f.recorder.startPage(pageURL)
+
+	return f.base.FetchWithBrowser(ctx, pageURL)
+}
+
+func harHeaders(headers map[string]string) []harNameValue {
+	ans := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		ans = append(ans, harNameValue{Name: name, Value: value})
+	}
+
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Name < ans[j].Name })
+
+	return ans
+}
+
+func harQueryString(rawURL string) []harNameValue {
+	ans := []harNameValue{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ans
+	}
+
+	for name, values := range u.Query() {
+		for _, value := range values {
+			ans = append(ans, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(ans, func(i, j int) bool { return ans[i].Name < ans[j].Name })
+
+	return ans
+}
+
+// The HAR 1.2 format, http://www.softwareishard.com/blog/har-12-spec/, with
+// the fields the browsers and the HAR viewers require.
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Pages   []harPage  `json:"pages"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harPage struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	PageTimings     struct{}  `json:"pageTimings"`
+}
+
+type harEntry struct {
+	Pageref         string      `json:"pageref"`
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+
+	answered bool
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []struct{}     `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []struct{}     `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
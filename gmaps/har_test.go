@@ -0,0 +1,112 @@
+package gmaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+// harBrowserPage is a browser page whose navigations fire the request hooks:
+// the page, a script answering and a tracker never answering.
+type harBrowserPage struct {
+	fakeBrowserPage
+
+	onRequest  func(string, map[string]string)
+	onResponse func(string, int, map[string]string)
+}
+
+func (p *harBrowserPage) OnRequest(h func(string, map[string]string)) { p.onRequest = h }
+
+func (p *harBrowserPage) OnResponse(h func(string, int, map[string]string)) { p.onResponse = h }
+
+func (p *harBrowserPage) Goto(u string, w scrapemate.WaitUntilState) (*scrapemate.PageResponse, error) {
+	p.onRequest(u, map[string]string{"accept": "text/html"})
+	p.onResponse(u, 200, map[string]string{"content-type": "text/html"})
+	p.onRequest(u+"app.js?v=2", nil)
+	p.onResponse(u+"app.js?v=2", 200, map[string]string{"content-type": "text/javascript"})
+	p.onRequest("https://tracker.example/pixel", nil)
+
+	return p.fakeBrowserPage.Goto(u, w)
+}
+
+func TestEmailJobHAR(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><div id="app"></div></body></html>`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	entry := &Entry{WebSite: srv.URL + "/"}
+	job := NewEmailJob("parent", entry, WithEmailJobHAR(NewHARCapture(dir, 1)))
+
+	job.BrowserActions(context.Background(), &harBrowserPage{})
+	require.Equal(t, "not_found", entry.EmailStatus)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.har"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	var har harFile
+	require.NoError(t, json.Unmarshal(data, &har))
+
+	require.Equal(t, "1.2", har.Log.Version)
+	require.Len(t, har.Log.Pages, 1)
+	require.Equal(t, srv.URL+"/", har.Log.Pages[0].Title)
+	require.Len(t, har.Log.Entries, 3)
+
+	script := har.Log.Entries[1]
+	require.Equal(t, "page_1", script.Pageref)
+	require.Equal(t, 200, script.Response.Status)
+	require.Equal(t, "text/javascript", script.Response.Content.MimeType)
+	require.Equal(t, []harNameValue{{Name: "v", Value: "2"}}, script.Request.QueryString)
+
+	// never answered
+	require.Zero(t, har.Log.Entries[2].Response.Status)
+}
+
+func TestHARCaptureSample(t *testing.T) {
+	require.Nil(t, NewHARCapture("", 1))
+	require.Nil(t, NewHARCapture(t.TempDir(), 0))
+
+	var none *HARCapture
+
+	require.False(t, none.claim("https://biz.com"))
+
+	// the same websites every run
+	a, b := NewHARCapture(t.TempDir(), 0.5), NewHARCapture(t.TempDir(), 0.5)
+	sampled := 0
+
+	for i := range 60 {
+		website := fmt.Sprintf("https://biz%d.com", i)
+
+		claimed := a.claim(website)
+		require.Equal(t, claimed, b.claim(website), website)
+
+		if claimed {
+			sampled++
+		}
+	}
+
+	require.Greater(t, sampled, 10)
+	require.Less(t, sampled, 50)
+
+	c := NewHARCapture(t.TempDir(), 1)
+
+	for i := range maxHARs {
+		require.True(t, c.claim(fmt.Sprintf("https://biz%d.com", i)))
+	}
+
+	require.False(t, c.claim("https://one-more.com"))
+}
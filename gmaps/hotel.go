@@ -0,0 +1,169 @@
+package gmaps
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// lodgingWords are the words of the categories of the lodging places.
+var lodgingWords = []string{
+	"hotel", "hôtel", "motel", "hostel", "hostal", "resort", "inn", "lodge",
+	"lodging", "aparthotel", "guesthouse", "b&b", "ryokan", "albergo",
+	"pensione", "pension", "gasthof", "gasthaus", "posada", "agriturismo",
+}
+
+// lodgingPhrases are lodging categories of more than one word.
+var lodgingPhrases = []string{"bed & breakfast", "bed and breakfast", "guest house"}
+
+var (
+	// hotelClassRe matches the hotel class as Google shows it, like
+	// "4-star hotel", "4-Sterne-Hotel", "Hotel a 4 stelle", "Hôtel 4 étoiles"
+	// or "Hotel de 4 estrellas".
+	hotelClassRe = regexp.MustCompile(`(?i)^(?:(?:hôtel|hotel(?:\s+(?:a|de))?)\s+([1-5])[\s-]*(?:star|stars|sterne|stelle|étoiles|estrellas)|([1-5])[\s-]*(?:star|sterne)[\s-]*hotel)$`)
+	// checkInRe and checkOutRe match the labels of the check-in and
+	// check-out times, followed by the time or not.
+	checkInRe  = regexp.MustCompile(`(?i)^check-?in(?:\s+time)?\s*:?\s*(.*)$`)
+	checkOutRe = regexp.MustCompile(`(?i)^check-?out(?:\s+time)?\s*:?\s*(.*)$`)
+	// timeOfDayRe matches "3:00 PM", "3 pm" or "15:00".
+	timeOfDayRe = regexp.MustCompile(`(?i)^\d{1,2}(?:[:.]\d{2})?(?:[\s\x{202f}\x{a0}]*[ap]\.?\s?m\.?)?$`)
+)
+
+// IsLodging reports whether e is a hotel, a hostel, a B&B or another place
+// to stay, from its categories.
+func (e *Entry) IsLodging() bool {
+	categories := append([]string{e.Category}, e.Categories...)
+
+	for _, c := range categories {
+		c = strings.ToLower(c)
+
+		for _, phrase := range lodgingPhrases {
+			if strings.Contains(c, phrase) {
+				return true
+			}
+		}
+
+		words := strings.FieldsFunc(c, func(r rune) bool {
+			return r == ' ' || r == '-' || r == ','
+		})
+
+		for _, w := range words {
+			if slices.Contains(lodgingWords, w) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseHotel sets the hotel fields of entry from darray, when it is a
+// lodging place: the class and the check-in and check-out times, found by
+// their text wherever Google puts them, and the amenities of About.
+func parseHotel(darray []any, entry *Entry) {
+	var found hotelStrings
+
+	found.walk(darray, 0)
+
+	if !entry.IsLodging() && found.class == 0 {
+		return
+	}
+
+	entry.HotelClass = found.class
+	entry.CheckInTime = found.checkIn
+	entry.CheckOutTime = found.checkOut
+	entry.Amenities = amenitiesOf(entry.About)
+}
+
+// hotelMaxDepth bounds the walk of the place data.
+const hotelMaxDepth = 12
+
+// hotelStrings collects the hotel fields found in the place data.
+type hotelStrings struct {
+	class             int
+	checkIn, checkOut string
+}
+
+func (h *hotelStrings) walk(v any, depth int) {
+	arr, ok := v.([]any)
+	if !ok || depth > hotelMaxDepth {
+		return
+	}
+
+	for i, el := range arr {
+		s, ok := el.(string)
+		if !ok {
+			h.walk(el, depth+1)
+
+			continue
+		}
+
+		s = strings.TrimSpace(s)
+
+		if m := hotelClassRe.FindStringSubmatch(s); m != nil && h.class == 0 {
+			h.class, _ = strconv.Atoi(m[1] + m[2])
+
+			continue
+		}
+
+		for _, c := range []struct {
+			re  *regexp.Regexp
+			dst *string
+		}{
+			{checkInRe, &h.checkIn},
+			{checkOutRe, &h.checkOut},
+		} {
+			m := c.re.FindStringSubmatch(s)
+			if m == nil || *c.dst != "" {
+				continue
+			}
+
+			// the time follows the label, in the same string or the next
+			value := strings.TrimSpace(m[1])
+			if value == "" && i+1 < len(arr) {
+				value, _ = arr[i+1].(string)
+				value = strings.TrimSpace(value)
+			}
+
+			if timeOfDayRe.MatchString(value) {
+				*c.dst = value
+			}
+		}
+	}
+}
+
+// amenitiesOf returns the amenities of the About groups, like "Free Wi-Fi"
+// or "Pool", that the place has.
+func amenitiesOf(about []About) []string {
+	var ans []string
+
+	for i := range about {
+		if !strings.Contains(about[i].ID, "amenit") {
+			continue
+		}
+
+		for _, opt := range about[i].Options {
+			switch {
+			case opt.Value != "":
+				ans = append(ans, opt.Value)
+			case len(opt.Values) > 0:
+				ans = append(ans, opt.Name+": "+strings.Join(opt.Values, ", "))
+			case opt.Enabled:
+				ans = append(ans, opt.Name)
+			}
+		}
+	}
+
+	return ans
+}
+
+// hotelCsvValues returns the hotel fields of e for its CSV row.
+func (e *Entry) hotelCsvValues() []string {
+	class := ""
+	if e.HotelClass > 0 {
+		class = strconv.Itoa(e.HotelClass)
+	}
+
+	return []string{class, e.CheckInTime, e.CheckOutTime, stringSliceToString(e.Amenities)}
+}
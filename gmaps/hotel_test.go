@@ -0,0 +1,100 @@
+package gmaps
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLodging(t *testing.T) {
+	tests := []struct {
+		categories []string
+		want       bool
+	}{
+		{[]string{"Hotel"}, true},
+		{[]string{"Resort hotel", "Spa"}, true},
+		{[]string{"Bed & breakfast"}, true},
+		{[]string{"Guest house"}, true},
+		{[]string{"Youth hostel"}, true},
+		{[]string{"Restaurant", "Dinner theater"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		e := Entry{Categories: tt.categories}
+		if len(tt.categories) > 0 {
+			e.Category = tt.categories[0]
+		}
+
+		require.Equal(t, tt.want, e.IsLodging(), tt.categories)
+	}
+}
+
+func TestParseHotel(t *testing.T) {
+	var darray []any
+
+	require.NoError(t, json.Unmarshal([]byte(`[
+		null,
+		["4-star hotel", null],
+		[[["Check-in time", "3:00\u202fPM"], ["Check-out time: 11:00 AM"]]],
+		[["Great hotel, check-in was quick"]]
+	]`), &darray))
+
+	e := Entry{
+		Category: "Hotel",
+		About: []About{
+			{ID: "amenities", Name: "Amenities", Options: []Option{
+				{Name: "Pool", Enabled: true},
+				{Name: "Wi-Fi", Enabled: true, Value: "Free Wi-Fi"},
+				{Name: "Gym", Enabled: false},
+			}},
+			{ID: "payments", Name: "Payments", Options: []Option{{Name: "Credit cards", Enabled: true}}},
+		},
+	}
+
+	parseHotel(darray, &e)
+
+	require.Equal(t, 4, e.HotelClass)
+	require.Equal(t, "3:00\u202fPM", e.CheckInTime)
+	require.Equal(t, "11:00 AM", e.CheckOutTime)
+	require.Equal(t, []string{"Pool", "Free Wi-Fi"}, e.Amenities)
+
+	require.Equal(t, "4", csvValue(t, &e, "hotel_class"))
+	require.Equal(t, "3:00\u202fPM", csvValue(t, &e, "check_in_time"))
+	require.Equal(t, "11:00 AM", csvValue(t, &e, "check_out_time"))
+	require.Equal(t, "Pool, Free Wi-Fi", csvValue(t, &e, "amenities"))
+}
+
+func TestParseHotelClassLanguages(t *testing.T) {
+	for s, want := range map[string]int{
+		"4-star hotel":         4,
+		"5-Sterne-Hotel":       5,
+		"Hotel a 3 stelle":     3,
+		"Hôtel 2 étoiles":      2,
+		"Hotel de 4 estrellas": 4,
+		"5 stars":              0,
+		"6-star hotel":         0,
+	} {
+		e := Entry{Category: "Hotel"}
+
+		parseHotel([]any{s}, &e)
+
+		require.Equal(t, want, e.HotelClass, s)
+	}
+}
+
+func TestParseHotelNotLodging(t *testing.T) {
+	e := Entry{
+		Category: "Restaurant",
+		About:    []About{{ID: "amenities", Options: []Option{{Name: "Toilets", Enabled: true}}}},
+	}
+
+	parseHotel([]any{[]any{"Check-in time", "3:00 PM"}}, &e)
+
+	require.Empty(t, e.CheckInTime)
+	require.Nil(t, e.Amenities)
+
+	require.Empty(t, csvValue(t, &e, "hotel_class"))
+	require.Empty(t, csvValue(t, &e, "amenities"))
+}
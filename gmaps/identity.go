@@ -0,0 +1,189 @@
+package gmaps
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// featureIDRegex matches the feature ID ("0x<hex>:0x<hex>") Google embeds in
+// place URLs, e.g. /data=!4m2!3m1!1s0x14e732fd76f0d90d:0xe5415928d6702b47.
+// The second half of the feature ID is the place CID in hexadecimal.
+var featureIDRegex = regexp.MustCompile(`(0x[0-9a-fA-F]+):(0x[0-9a-fA-F]+)`)
+
+// placeIDRegex matches the "!19s<place_id>" segment that carries the Places
+// API place_id in place URLs.
+var placeIDRegex = regexp.MustCompile(`!19s(ChIJ[A-Za-z0-9_-]+)`)
+
+// PlaceIdentity groups the identifiers Google uses for a place.
+type PlaceIdentity struct {
+	CID     string
+	PlaceID string
+	DataID  string
+}
+
+// ParsePlaceIdentity extracts the place identifiers that can be recovered
+// from a Google Maps URL: the feature ID in the data= segment, the cid or
+// ludocid query parameters, and the place_id in !19s segments or query.
+func ParsePlaceIdentity(rawURL string) PlaceIdentity {
+	var ans PlaceIdentity
+
+	if rawURL == "" {
+		return ans
+	}
+
+	decoded, err := url.PathUnescape(rawURL)
+	if err != nil {
+		decoded = rawURL
+	}
+
+	if m := featureIDRegex.FindStringSubmatch(decoded); len(m) == 3 {
+		ans.DataID = strings.ToLower(m[0])
+		ans.CID = CIDFromDataID(ans.DataID)
+	}
+
+	if m := placeIDRegex.FindStringSubmatch(decoded); len(m) == 2 {
+		ans.PlaceID = m[1]
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ans
+	}
+
+	q := u.Query()
+
+	if ans.CID == "" {
+		for _, key := range []string{"cid", "ludocid"} {
+			if v := q.Get(key); isDecimal(v) {
+				ans.CID = v
+
+				break
+			}
+		}
+	}
+
+	if ans.PlaceID == "" {
+		for _, key := range []string{"place_id", "query_place_id"} {
+			if v := q.Get(key); v != "" {
+				ans.PlaceID = v
+
+				break
+			}
+		}
+	}
+
+	return ans
+}
+
+// CIDFromDataID converts the second half of a feature ID to the decimal CID
+// (the number used in https://maps.google.com/?cid=...). It returns an empty
+// string when dataID is not a feature ID.
+func CIDFromDataID(dataID string) string {
+	_, hexCID, ok := strings.Cut(dataID, ":")
+	if !ok {
+		return ""
+	}
+
+	hexCID = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(hexCID)), "0x")
+	if hexCID == "" {
+		return ""
+	}
+
+	cid, err := strconv.ParseUint(hexCID, 16, 64)
+	if err != nil {
+		return ""
+	}
+
+	return strconv.FormatUint(cid, 10)
+}
+
+// PlaceKey returns the canonical identity key for a place URL, falling back
+// to the URL itself when it carries no identifier. Two URLs for the same
+// place (different slugs, viewports or tracking parameters) share a key.
+func PlaceKey(rawURL string) string {
+	if key := ParsePlaceIdentity(rawURL).key(); key != "" {
+		return key
+	}
+
+	return rawURL
+}
+
+// FillIdentity completes missing identifiers from the ones already known and
+// from the entry link, so every entry carries a CID whenever Google exposes one.
+func (e *Entry) FillIdentity(fallbackURL string) {
+	id := PlaceIdentity{CID: e.Cid, PlaceID: e.PlaceID, DataID: e.DataID}
+
+	if id.CID == "" && id.DataID != "" {
+		id.CID = CIDFromDataID(id.DataID)
+	}
+
+	for _, u := range []string{e.Link, fallbackURL} {
+		if id.CID != "" && id.PlaceID != "" && id.DataID != "" {
+			break
+		}
+
+		id = id.merge(ParsePlaceIdentity(u))
+	}
+
+	e.Cid = id.CID
+	e.PlaceID = id.PlaceID
+	e.DataID = id.DataID
+}
+
+// IdentityKey returns the canonical key used to deduplicate entries across
+// keywords, grid cells and jobs: the CID when known, then the place_id, the
+// feature ID and finally the link.
+func (e *Entry) IdentityKey() string {
+	id := PlaceIdentity{CID: e.Cid, PlaceID: e.PlaceID, DataID: e.DataID}
+
+	if id.CID == "" {
+		id.CID = CIDFromDataID(id.DataID)
+	}
+
+	if key := id.key(); key != "" {
+		return key
+	}
+
+	return e.Link
+}
+
+func (p PlaceIdentity) merge(other PlaceIdentity) PlaceIdentity {
+	if p.CID == "" {
+		p.CID = other.CID
+	}
+
+	if p.PlaceID == "" {
+		p.PlaceID = other.PlaceID
+	}
+
+	if p.DataID == "" {
+		p.DataID = other.DataID
+	}
+
+	return p
+}
+
+func (p PlaceIdentity) key() string {
+	switch {
+	case p.CID != "":
+		return "cid:" + p.CID
+	case p.PlaceID != "":
+		return "place_id:" + p.PlaceID
+	case p.DataID != "":
+		return "data_id:" + p.DataID
+	default:
+		return ""
+	}
+}
+
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	_, err := strconv.ParseUint(s, 10, 64)
+
+	return err == nil
+}
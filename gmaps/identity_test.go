@@ -0,0 +1,69 @@
+package gmaps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestParsePlaceIdentity(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		expect gmaps.PlaceIdentity
+	}{
+		{
+			name: "feature id in data segment",
+			url:  "https://www.google.com/maps/place/Kipriakon/data=!4m2!3m1!1s0x14e732fd76f0d90d:0xe5415928d6702b47!10m1!1e1",
+			expect: gmaps.PlaceIdentity{
+				CID:    "16519582940102929223",
+				DataID: "0x14e732fd76f0d90d:0xe5415928d6702b47",
+			},
+		},
+		{
+			name: "escaped feature id and place id",
+			url:  "https://www.google.com/maps/place/Foo/data=!4m7!3m6!1s0x14e732fd76f0d90d%3A0xe5415928d6702b47!8m2!3d34.6!4d33.0!16s%2Fg%2F1tg!19sChIJDdnwdv0y5xQRRytw1ihZQeU",
+			expect: gmaps.PlaceIdentity{
+				CID:     "16519582940102929223",
+				PlaceID: "ChIJDdnwdv0y5xQRRytw1ihZQeU",
+				DataID:  "0x14e732fd76f0d90d:0xe5415928d6702b47",
+			},
+		},
+		{
+			name:   "cid query parameter",
+			url:    "https://maps.google.com/?cid=16519582940102929223",
+			expect: gmaps.PlaceIdentity{CID: "16519582940102929223"},
+		},
+		{
+			name:   "no identifiers",
+			url:    "https://www.google.com/maps/search/pizza",
+			expect: gmaps.PlaceIdentity{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, gmaps.ParsePlaceIdentity(tt.url))
+		})
+	}
+}
+
+func TestPlaceKeyIgnoresSlugAndViewport(t *testing.T) {
+	a := gmaps.PlaceKey("https://www.google.com/maps/place/Kipriakon/data=!4m2!3m1!1s0x14e732fd76f0d90d:0xe5415928d6702b47!10m1!1e1")
+	b := gmaps.PlaceKey("https://www.google.com/maps/place/Kipriakon+Restaurant/@34.67,33.04,17z/data=!4m2!3m1!1s0x14e732fd76f0d90d:0xe5415928d6702b47?hl=en")
+
+	require.Equal(t, "cid:16519582940102929223", a)
+	require.Equal(t, a, b)
+	require.Equal(t, "https://example.com/x", gmaps.PlaceKey("https://example.com/x"))
+}
+
+func TestEntryFillIdentity(t *testing.T) {
+	e := gmaps.Entry{DataID: "0x14e732fd76f0d90d:0xe5415928d6702b47"}
+	e.FillIdentity("https://www.google.com/maps/place/x/data=!19sChIJDdnwdv0y5xQRRytw1ihZQeU")
+
+	require.Equal(t, "16519582940102929223", e.Cid)
+	require.Equal(t, "ChIJDdnwdv0y5xQRRytw1ihZQeU", e.PlaceID)
+	require.Equal(t, "cid:16519582940102929223", e.IdentityKey())
+}
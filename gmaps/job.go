@@ -24,11 +24,37 @@ type GmapJob struct {
 	MaxDepth     int
 	LangCode     string
 	ExtractEmail bool
+	// Query is the original search keyword, propagated to the entries found
+	// by this job for per-keyword attribution.
+	Query string
 
 	Deduper                 deduper.Deduper
 	ExitMonitor             exiter.Exiter
 	ExtractExtraReviews     bool
 	WriterManagedCompletion bool
+	ExcludeServiceArea      bool
+	EmailProxyRouter        *EmailProxyRouter
+	EmailVerifier           *EmailVerifier
+	FetchStats              *FetchStats
+	Sessions                *SessionPool
+	Provenance              Provenance
+	CustomExtractors        []CustomExtractor
+	EmailFetchBudget        *FetchBudget
+	ReviewerAnonymizer      *ReviewerAnonymizer
+	ReviewLanguages         []string
+	Exclusions              *Exclusions
+	KeywordStats            *KeywordStats
+	PlaceFailures           *PlaceFailures
+	Diagnoser               *Diagnoser
+	LivePages               *LivePages
+	StepTimings             *StepTimings
+	EmailProgress           *EmailProgress
+	EmailBreaker            *DomainBreaker
+	EmailHAR                *HARCapture
+	Throttle                *Throttle
+	Skip                    SkipFields
+	SkipStats               *SkipStats
+	EmailPacer              *WebsitePacer
 }
 
 func NewGmapJob(
@@ -41,6 +67,8 @@ func NewGmapJob(
 ) *GmapJob {
 	var mapURL string
 
+	seedQuery := strings.TrimSpace(query)
+
 	switch {
 	case isGoogleMapsURL(query):
 		mapURL = strings.TrimSpace(query)
@@ -74,6 +102,7 @@ func NewGmapJob(
 		MaxDepth:     maxDepth,
 		LangCode:     langCode,
 		ExtractEmail: extractEmail,
+		Query:        seedQuery,
 	}
 
 	for _, opt := range opts {
@@ -107,6 +136,186 @@ func WithWriterManagedCompletion() GmapJobOptions {
 	}
 }
 
+// WithExcludeServiceArea drops service-area businesses (no public address)
+// from the results.
+func WithExcludeServiceArea() GmapJobOptions {
+	return func(j *GmapJob) {
+		j.ExcludeServiceArea = true
+	}
+}
+
+// WithEmailProxyRouter routes the website fetches of the email extraction
+// through country-matched proxies.
+func WithEmailProxyRouter(r *EmailProxyRouter) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailProxyRouter = r
+	}
+}
+
+// WithEmailVerifier classifies the extracted emails (deliverable,
+// catch_all, disposable, invalid).
+func WithEmailVerifier(v *EmailVerifier) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailVerifier = v
+	}
+}
+
+// WithFetchStats records the failed fetches and the bytes downloaded by the
+// job, and by the place and email jobs it spawns, into s.
+func WithFetchStats(s *FetchStats) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.FetchStats = s
+	}
+}
+
+// WithSessionPool makes the job, and the place jobs it spawns, browse Maps
+// with the identities of s.
+func WithSessionPool(s *SessionPool) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Sessions = s
+	}
+}
+
+// WithProvenance sets the job ID and the scraper version stamped on the
+// entries found by the job.
+func WithProvenance(p Provenance) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Provenance = p
+	}
+}
+
+// WithCustomExtractors reads user defined fields from the place pages.
+func WithCustomExtractors(extractors []CustomExtractor) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.CustomExtractors = extractors
+	}
+}
+
+// WithEmailFetchBudget caps the website fetches of the email extraction of
+// the places found.
+func WithEmailFetchBudget(b *FetchBudget) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailFetchBudget = b
+	}
+}
+
+// WithReviewerAnonymizer strips the reviewer data from the reviews of the
+// places found.
+func WithReviewerAnonymizer(a *ReviewerAnonymizer) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.ReviewerAnonymizer = a
+	}
+}
+
+// WithReviewLanguages keeps only the reviews of the places found detected in
+// one of langs.
+func WithReviewLanguages(langs []string) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.ReviewLanguages = langs
+	}
+}
+
+// WithExclusions leaves out the places x excludes: those excluded by name or
+// CID are not visited at all.
+func WithExclusions(x *Exclusions) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Exclusions = x
+	}
+}
+
+// WithKeywordStats records the outcome of the search, and of the visits of
+// the places it finds, under its query into s.
+func WithKeywordStats(s *KeywordStats) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.KeywordStats = s
+	}
+}
+
+// WithPlaceFailures records the place pages of the search that fail for
+// good into f.
+func WithPlaceFailures(f *PlaceFailures) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.PlaceFailures = f
+	}
+}
+
+// WithDiagnoser captures the page of the search when it lists no place, see
+// Diagnoser. The diagnosis goes to the KeywordStats of the job.
+func WithDiagnoser(d *Diagnoser) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Diagnoser = d
+	}
+}
+
+// WithLivePages keeps the pages of the search, and of the place jobs it
+// spawns, in l while they are in use.
+func WithLivePages(l *LivePages) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.LivePages = l
+	}
+}
+
+// WithStepTimings records the timings of the places found, and of their
+// email extraction, into t.
+func WithStepTimings(t *StepTimings) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.StepTimings = t
+	}
+}
+
+// WithEmailProgress records the email searches of the places found into p.
+func WithEmailProgress(p *EmailProgress) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailProgress = p
+	}
+}
+
+// WithEmailBreaker skips the websites of the places found while b is open
+// for their domain, see DomainBreaker.
+func WithEmailBreaker(b *DomainBreaker) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailBreaker = b
+	}
+}
+
+// WithEmailHAR records a HAR of the browser fetches of the sampled websites
+// of the places found into c, see HARCapture.
+func WithEmailHAR(c *HARCapture) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailHAR = c
+	}
+}
+
+// WithThrottle paces the search, and the place jobs it spawns, with t.
+func WithThrottle(t *Throttle) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Throttle = t
+	}
+}
+
+// WithSkip leaves out the fields skipped by s from the places found.
+func WithSkip(s SkipFields) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.Skip = s
+	}
+}
+
+// WithSkipStats records into s the work the places skipping fields leave
+// undone.
+func WithSkipStats(s *SkipStats) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.SkipStats = s
+	}
+}
+
+// WithEmailPacer spaces the HTTP fetches of the websites of the places
+// found with p, see WebsitePacer.
+func WithEmailPacer(p *WebsitePacer) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.EmailPacer = p
+	}
+}
+
 func (j *GmapJob) UseInResults() bool {
 	return false
 }
@@ -121,7 +330,13 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 		resp.Body = nil
 	}()
 
+	diagnosis, _ := resp.Meta["diagnosis"].(*ZeroResultDiagnosis)
+
 	if resp.Error != nil {
+		j.FetchStats.RecordMapsError(j.GetURL(), resp.Error, resp.StatusCode)
+		j.KeywordStats.SeedFailed(j.Query, resp.Error, resp.StatusCode)
+		j.KeywordStats.SetDiagnosis(j.Query, diagnosis)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrSeedCompleted(1)
 		}
@@ -133,17 +348,28 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 
 	doc, ok := resp.Document.(*goquery.Document)
 	if !ok {
+		err := fmt.Errorf("could not convert to goquery document")
+
+		j.KeywordStats.SeedFailed(j.Query, err, 0)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrSeedCompleted(1)
 		}
 
-		return nil, nil, fmt.Errorf("could not convert to goquery document")
+		return nil, nil, err
 	}
 
-	var next []scrapemate.IJob
+	var (
+		next []scrapemate.IJob
+		// found counts the places listed, also those already visited
+		found int
+	)
 
-	if strings.Contains(resp.URL, "/maps/place/") {
-		jopts := []PlaceJobOptions{}
+	switch {
+	case j.Exclusions.SkipPlaceURL(resp.URL):
+		// the search led straight to an excluded place
+	case strings.Contains(resp.URL, "/maps/place/"):
+		jopts := []PlaceJobOptions{WithPlaceJobQuery(j.Query)}
 		if j.ExitMonitor != nil {
 			jopts = append(jopts, WithPlaceJobExitMonitor(j.ExitMonitor))
 		}
@@ -152,13 +378,96 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 			jopts = append(jopts, WithPlaceJobWriterManagedCompletion())
 		}
 
+		if j.ExcludeServiceArea {
+			jopts = append(jopts, WithPlaceJobExcludeServiceArea())
+		}
+
+		if j.EmailProxyRouter != nil {
+			jopts = append(jopts, WithPlaceJobEmailProxyRouter(j.EmailProxyRouter))
+		}
+
+		if j.EmailVerifier != nil {
+			jopts = append(jopts, WithPlaceJobEmailVerifier(j.EmailVerifier))
+		}
+
+		if j.FetchStats != nil {
+			jopts = append(jopts, WithPlaceJobFetchStats(j.FetchStats))
+		}
+
+		if j.Sessions != nil {
+			jopts = append(jopts, WithPlaceJobSessionPool(j.Sessions))
+		}
+
+		jopts = append(jopts, WithPlaceJobProvenance(j.Provenance))
+
+		if len(j.CustomExtractors) > 0 {
+			jopts = append(jopts, WithPlaceJobCustomExtractors(j.CustomExtractors))
+		}
+
+		if j.EmailFetchBudget != nil {
+			jopts = append(jopts, WithPlaceJobEmailFetchBudget(j.EmailFetchBudget))
+		}
+
+		if j.ReviewerAnonymizer != nil {
+			jopts = append(jopts, WithPlaceJobReviewerAnonymizer(j.ReviewerAnonymizer))
+		}
+
+		if len(j.ReviewLanguages) > 0 {
+			jopts = append(jopts, WithPlaceJobReviewLanguages(j.ReviewLanguages))
+		}
+
+		if j.Exclusions != nil {
+			jopts = append(jopts, WithPlaceJobExclusions(j.Exclusions))
+		}
+
+		if j.KeywordStats != nil {
+			jopts = append(jopts, WithPlaceJobKeywordStats(j.KeywordStats))
+		}
+
+		if j.PlaceFailures != nil {
+			jopts = append(jopts, WithPlaceJobFailures(j.PlaceFailures))
+		}
+
+		if j.LivePages != nil {
+			jopts = append(jopts, WithPlaceJobLivePages(j.LivePages))
+		}
+
+		if j.StepTimings != nil {
+			jopts = append(jopts, WithPlaceJobStepTimings(j.StepTimings))
+		}
+
+		if j.EmailProgress != nil {
+			jopts = append(jopts, WithPlaceJobEmailProgress(j.EmailProgress))
+		}
+
+		if j.EmailBreaker != nil {
+			jopts = append(jopts, WithPlaceJobEmailBreaker(j.EmailBreaker))
+		}
+
+		if j.EmailHAR != nil {
+			jopts = append(jopts, WithPlaceJobEmailHAR(j.EmailHAR))
+		}
+
+		if j.Throttle != nil {
+			jopts = append(jopts, WithPlaceJobThrottle(j.Throttle))
+		}
+
+		if j.Skip.Any() {
+			jopts = append(jopts, WithPlaceJobSkip(j.Skip), WithPlaceJobSkipStats(j.SkipStats))
+		}
+
+		if j.EmailPacer != nil {
+			jopts = append(jopts, WithPlaceJobEmailPacer(j.EmailPacer))
+		}
+
 		placeJob := NewPlaceJob(j.ID, j.LangCode, resp.URL, j.ExtractEmail, j.ExtractExtraReviews, jopts...)
 
 		next = append(next, placeJob)
-	} else {
+		found = 1
+	default:
 		doc.Find(`div[role=feed] div[jsaction]>a`).Each(func(_ int, s *goquery.Selection) {
-			if href := s.AttrOr("href", ""); href != "" {
-				jopts := []PlaceJobOptions{}
+			if href := s.AttrOr("href", ""); href != "" && !j.Exclusions.SkipResult(s) {
+				jopts := []PlaceJobOptions{WithPlaceJobQuery(j.Query)}
 				if j.ExitMonitor != nil {
 					jopts = append(jopts, WithPlaceJobExitMonitor(j.ExitMonitor))
 				}
@@ -167,17 +476,116 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 					jopts = append(jopts, WithPlaceJobWriterManagedCompletion())
 				}
 
+				if j.ExcludeServiceArea {
+					jopts = append(jopts, WithPlaceJobExcludeServiceArea())
+				}
+
+				if j.EmailProxyRouter != nil {
+					jopts = append(jopts, WithPlaceJobEmailProxyRouter(j.EmailProxyRouter))
+				}
+
+				if j.EmailVerifier != nil {
+					jopts = append(jopts, WithPlaceJobEmailVerifier(j.EmailVerifier))
+				}
+
+				if j.FetchStats != nil {
+					jopts = append(jopts, WithPlaceJobFetchStats(j.FetchStats))
+				}
+
+				if j.Sessions != nil {
+					jopts = append(jopts, WithPlaceJobSessionPool(j.Sessions))
+				}
+
+				jopts = append(jopts, WithPlaceJobProvenance(j.Provenance))
+
+				if len(j.CustomExtractors) > 0 {
+					jopts = append(jopts, WithPlaceJobCustomExtractors(j.CustomExtractors))
+				}
+
+				if j.EmailFetchBudget != nil {
+					jopts = append(jopts, WithPlaceJobEmailFetchBudget(j.EmailFetchBudget))
+				}
+
+				if j.ReviewerAnonymizer != nil {
+					jopts = append(jopts, WithPlaceJobReviewerAnonymizer(j.ReviewerAnonymizer))
+				}
+
+				if len(j.ReviewLanguages) > 0 {
+					jopts = append(jopts, WithPlaceJobReviewLanguages(j.ReviewLanguages))
+				}
+
+				if j.Exclusions != nil {
+					jopts = append(jopts, WithPlaceJobExclusions(j.Exclusions))
+				}
+
+				if j.KeywordStats != nil {
+					jopts = append(jopts, WithPlaceJobKeywordStats(j.KeywordStats))
+				}
+
+				if j.PlaceFailures != nil {
+					jopts = append(jopts, WithPlaceJobFailures(j.PlaceFailures))
+				}
+
+				if j.LivePages != nil {
+					jopts = append(jopts, WithPlaceJobLivePages(j.LivePages))
+				}
+
+				if j.StepTimings != nil {
+					jopts = append(jopts, WithPlaceJobStepTimings(j.StepTimings))
+				}
+
+				if j.EmailProgress != nil {
+					jopts = append(jopts, WithPlaceJobEmailProgress(j.EmailProgress))
+				}
+
+				if j.EmailBreaker != nil {
+					jopts = append(jopts, WithPlaceJobEmailBreaker(j.EmailBreaker))
+				}
+
+				if j.EmailHAR != nil {
+					jopts = append(jopts, WithPlaceJobEmailHAR(j.EmailHAR))
+				}
+
+				if j.Throttle != nil {
+					jopts = append(jopts, WithPlaceJobThrottle(j.Throttle))
+				}
+
+				if j.Skip.Any() {
+					jopts = append(jopts, WithPlaceJobSkip(j.Skip), WithPlaceJobSkipStats(j.SkipStats))
+				}
+
+				if j.EmailPacer != nil {
+					jopts = append(jopts, WithPlaceJobEmailPacer(j.EmailPacer))
+				}
+
 				nextJob := NewPlaceJob(j.ID, j.LangCode, href, j.ExtractEmail, j.ExtractExtraReviews, jopts...)
+				found++
 
-				if j.Deduper == nil || j.Deduper.AddIfNotExists(ctx, href) {
+				// dedupe on the place identity so the same place reached through
+				// different keywords or grid cells is visited only once
+				if j.Deduper == nil || j.Deduper.AddIfNotExists(ctx, PlaceKey(href)) {
 					next = append(next, nextJob)
 				}
 			}
 		})
 	}
 
+	switch {
+	case isBlockedPage(resp.URL):
+		j.KeywordStats.SeedFailed(j.Query, fmt.Errorf("%w: unusual traffic page", errMapsBlocked), 0)
+	case found == 0 && diagnosis.blocked():
+		j.KeywordStats.SeedFailed(j.Query, fmt.Errorf("%w: %s", errMapsBlocked, diagnosis.Cause), 0)
+	default:
+		j.KeywordStats.SeedDone(j.Query, found)
+	}
+
+	if found == 0 {
+		j.KeywordStats.SetDiagnosis(j.Query, diagnosis)
+	}
+
 	if j.ExitMonitor != nil {
-		j.ExitMonitor.IncrPlacesFound(len(next))
+		// past the places limit of the job the remaining places are left out
+		next = next[:j.ExitMonitor.ReservePlaces(len(next))]
 		j.ExitMonitor.IncrSeedCompleted(1)
 	}
 
@@ -189,6 +597,26 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPage) scrapemate.Response {
 	var resp scrapemate.Response
 
+	release, err := j.Throttle.acquire(ctx)
+	if err != nil {
+		resp.Error = err
+
+		return resp
+	}
+	defer release()
+
+	// emptyFeed is set when the search shows no result list, or an empty one
+	var emptyFeed bool
+
+	defer func() {
+		j.Throttle.Observe(j.searchBlocked(page, &resp, emptyFeed))
+	}()
+
+	defer j.LivePages.track(page)()
+
+	j.FetchStats.meterBrowserPage(page, FetchSourceMaps)
+	j.Sessions.attach(page)
+
 	pageResponse, err := page.Goto(j.GetFullURL(), scrapemate.WaitUntilDOMContentLoaded)
 	if err != nil {
 		resp.Error = err
@@ -196,7 +624,7 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 		return resp
 	}
 
-	clickRejectCookiesIfRequired(page)
+	j.Sessions.remember(page, clickRejectCookiesIfRequired(page))
 
 	const defaultTimeout = 5 * time.Second
 
@@ -245,6 +673,10 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 	_, err = scroll(ctx, page, j.MaxDepth, scrollSelector)
 	if err != nil {
 		resp.Error = err
+		emptyFeed = true
+
+		// usually there is no result list to scroll
+		j.diagnose(page, &resp)
 
 		return resp
 	}
@@ -257,9 +689,69 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 
 	resp.Body = []byte(body)
 
+	if (j.Diagnoser != nil || j.Throttle != nil) && countFeedPlaces(page) == 0 {
+		emptyFeed = true
+
+		j.diagnose(page, &resp)
+	}
+
 	return resp
 }
 
+// searchBlocked reports whether Google refused the search that ended on
+// page: the unusual traffic page, a 403/429 answer, or a missing or empty
+// result list that is neither a search without results nor a single place.
+func (j *GmapJob) searchBlocked(page scrapemate.BrowserPage, resp *scrapemate.Response, emptyFeed bool) bool {
+	if j.Throttle == nil {
+		return false
+	}
+
+	if isSoftBlock(resp.Error, resp.StatusCode, page.URL()) {
+		return true
+	}
+
+	if !emptyFeed {
+		return false
+	}
+
+	var cause string
+
+	if d, ok := resp.Meta["diagnosis"].(*ZeroResultDiagnosis); ok {
+		cause = d.Cause
+	} else {
+		html, _ := page.Content()
+		cause = DiagnoseZeroResults(page.URL(), html)
+	}
+
+	return cause != ZeroResultEmpty && cause != ZeroResultSinglePlace
+}
+
+// diagnose attaches to resp the diagnosis of the page of a search that
+// listed no place, read back by Process.
+func (j *GmapJob) diagnose(page scrapemate.BrowserPage, resp *scrapemate.Response) {
+	if d := j.Diagnoser.capture(page, j.Query); d != nil {
+		resp.Meta = map[string]any{"diagnosis": d}
+	}
+}
+
+// countFeedPlaces returns the number of place links of the result list of
+// page, -1 when it cannot tell.
+func countFeedPlaces(page scrapemate.BrowserPage) int {
+	n, err := page.Eval(`() => document.querySelectorAll("div[role=feed] div[jsaction]>a").length`)
+	if err != nil {
+		return -1
+	}
+
+	switch v := n.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return -1
+	}
+}
+
 func waitUntilURLContains(ctx context.Context, page scrapemate.BrowserPage, s string) bool {
 	ticker := time.NewTicker(time.Millisecond * 150)
 	defer ticker.Stop()
@@ -276,9 +768,11 @@ func waitUntilURLContains(ctx context.Context, page scrapemate.BrowserPage, s st
 	}
 }
 
-func clickRejectCookiesIfRequired(page scrapemate.BrowserPage) {
+// clickRejectCookiesIfRequired answers the Google consent wall and reports
+// whether it was shown.
+func clickRejectCookiesIfRequired(page scrapemate.BrowserPage) bool {
 	// Use JavaScript to find and click - faster than multiple locator calls
-	_, _ = page.Eval(`() => {
+	clicked, _ := page.Eval(`() => {
 		// Try consent form buttons first
 		const consentForm = document.querySelector('form[action*="consent.google"]');
 		if (consentForm) {
@@ -299,6 +793,10 @@ func clickRejectCookiesIfRequired(page scrapemate.BrowserPage) {
 		}
 		return false;
 	}`)
+
+	shown, _ := clicked.(bool)
+
+	return shown
 }
 
 func scroll(ctx context.Context,
@@ -0,0 +1,242 @@
+package gmaps
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Keyword statuses of a KeywordReport.
+const (
+	// KeywordPending and KeywordRunning are left on the keywords of a job
+	// stopped by its time or places limit before their seeds were done.
+	KeywordPending     = "pending"
+	KeywordRunning     = "running"
+	KeywordCompleted   = "completed"
+	KeywordZeroResults = "zero_results"
+	KeywordBlocked     = "blocked"
+	KeywordFailed      = "failed"
+)
+
+// errMapsBlocked is recorded for the searches Google answers with its
+// unusual traffic page, a captcha or a consent wall.
+var errMapsBlocked = errors.New("blocked by google")
+
+// isBlockedPage reports whether u is the page Google shows to the clients
+// it blocks, see errMapsBlocked.
+func isBlockedPage(u string) bool {
+	return strings.Contains(u, "google.com/sorry/")
+}
+
+type keywordCounts struct {
+	seeds       int
+	seedsDone   int
+	seedErrors  int
+	blocked     int
+	found       int
+	places      int
+	placeErrors int
+	lastError   string
+	diagnosis   *ZeroResultDiagnosis
+	// languageMismatches counts the places whose page rendered in another
+	// language than the one asked for.
+	languageMismatches int
+}
+
+// KeywordStats follows the outcome of each seed keyword of a job, so that a
+// keyword failing among many others does not go unnoticed. A keyword is
+// searched by one seed, or by one per cell in grid mode. It is safe for
+// concurrent use; a nil *KeywordStats records nothing.
+type KeywordStats struct {
+	mu       sync.Mutex
+	keywords map[string]*keywordCounts
+	order    []string
+}
+
+// NewKeywordStats creates an empty KeywordStats.
+func NewKeywordStats() *KeywordStats {
+	return &KeywordStats{keywords: make(map[string]*keywordCounts)}
+}
+
+// get returns the counts of keyword, adding it. s.mu must be held.
+func (s *KeywordStats) get(keyword string) *keywordCounts {
+	keyword = strings.TrimSpace(keyword)
+
+	c, ok := s.keywords[keyword]
+	if !ok {
+		c = &keywordCounts{}
+		s.keywords[keyword] = c
+		s.order = append(s.order, keyword)
+	}
+
+	return c
+}
+
+// AddSeed counts a seed searching keyword.
+func (s *KeywordStats) AddSeed(keyword string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(keyword).seeds++
+}
+
+// SeedDone records that a seed of keyword found found places, those
+// already found by other seeds included.
+func (s *KeywordStats) SeedDone(keyword string, found int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.get(keyword)
+	c.seedsDone++
+	c.found += found
+}
+
+// SeedFailed records that a seed of keyword failed with err, or with the
+// HTTP status statusCode when err is nil.
+func (s *KeywordStats) SeedFailed(keyword string, err error, statusCode int) {
+	if s == nil {
+		return
+	}
+
+	class := ClassifyFetchError(err, statusCode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.get(keyword)
+	c.seedsDone++
+	c.seedErrors++
+
+	if errors.Is(err, errMapsBlocked) || isTargetBlock(class) || isProxyFault(class) {
+		c.blocked++
+	}
+
+	if err != nil {
+		c.lastError = err.Error()
+	} else {
+		c.lastError = class
+	}
+}
+
+// PlaceDone records the visit of a place found by keyword, failed when err
+// is not nil.
+func (s *KeywordStats) PlaceDone(keyword string, err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.get(keyword)
+	if err != nil {
+		c.placeErrors++
+	} else {
+		c.places++
+	}
+}
+
+// LanguageMismatch records that the page of a place found by keyword
+// rendered in another language than the one asked for.
+func (s *KeywordStats) LanguageMismatch(keyword string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(keyword).languageMismatches++
+}
+
+// SetDiagnosis attaches d, the diagnosis of a search of keyword that listed
+// no place, to keyword. A nil d is ignored.
+func (s *KeywordStats) SetDiagnosis(keyword string, d *ZeroResultDiagnosis) {
+	if s == nil || d == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(keyword).diagnosis = d
+}
+
+// KeywordReport is the outcome of a seed keyword.
+type KeywordReport struct {
+	Keyword string `json:"keyword"`
+	// Status is one of the Keyword statuses.
+	Status     string `json:"status"`
+	Seeds      int    `json:"seeds"`
+	SeedErrors int    `json:"seed_errors,omitempty"`
+	// Found counts the places listed by the searches, Places the ones
+	// scraped. A place found by several keywords is scraped once, for the
+	// first of them.
+	Found       int `json:"found"`
+	Places      int `json:"places"`
+	PlaceErrors int `json:"place_errors,omitempty"`
+	// LanguageMismatches counts the places of Places whose page rendered in
+	// another language than the one of the job, see Entry.PageLanguage.
+	LanguageMismatches int `json:"language_mismatches,omitempty"`
+	// Error is the last error of a seed of the keyword.
+	Error string `json:"error,omitempty"`
+	// Diagnosis explains why a search of the keyword listed no place.
+	Diagnosis *ZeroResultDiagnosis `json:"diagnosis,omitempty"`
+}
+
+// Report returns the outcome of the keywords, in the order they were first
+// recorded.
+func (s *KeywordStats) Report() []KeywordReport {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ans := make([]KeywordReport, 0, len(s.order))
+
+	for _, keyword := range s.order {
+		c := s.keywords[keyword]
+
+		ans = append(ans, KeywordReport{
+			Keyword:            keyword,
+			Status:             c.status(),
+			Seeds:              max(c.seeds, c.seedsDone),
+			SeedErrors:         c.seedErrors,
+			Found:              c.found,
+			Places:             c.places,
+			PlaceErrors:        c.placeErrors,
+			Error:              c.lastError,
+			Diagnosis:          c.diagnosis,
+			LanguageMismatches: c.languageMismatches,
+		})
+	}
+
+	return ans
+}
+
+func (c *keywordCounts) status() string {
+	switch {
+	case c.seedsDone == 0:
+		return KeywordPending
+	case c.seedsDone < c.seeds:
+		return KeywordRunning
+	case c.found > 0:
+		return KeywordCompleted
+	case c.blocked > 0:
+		return KeywordBlocked
+	case c.seedErrors > 0:
+		return KeywordFailed
+	default:
+		return KeywordZeroResults
+	}
+}
@@ -0,0 +1,59 @@
+package gmaps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywordStatsReport(t *testing.T) {
+	s := NewKeywordStats()
+
+	for _, kw := range []string{"pizza in rome", "bakery in rome", "florist in rome", "gym in rome", "zoo in rome", "zoo in rome"} {
+		s.AddSeed(kw)
+	}
+
+	s.SeedDone(" pizza in rome ", 3)
+	s.PlaceDone("pizza in rome", nil)
+	s.PlaceDone("pizza in rome", nil)
+	s.PlaceDone("pizza in rome", errors.New("timeout"))
+
+	s.SeedDone("bakery in rome", 0)
+	s.SeedFailed("florist in rome", nil, 429)
+	s.SeedFailed("gym in rome", errors.New("net::ERR_CONNECTION_RESET"), 0)
+	s.SeedDone("zoo in rome", 0)
+
+	report := s.Report()
+	require.Len(t, report, 5)
+
+	require.Equal(t, KeywordReport{
+		Keyword: "pizza in rome", Status: KeywordCompleted, Seeds: 1, Found: 3, Places: 2, PlaceErrors: 1,
+	}, report[0])
+	require.Equal(t, KeywordZeroResults, report[1].Status)
+	require.Equal(t, KeywordBlocked, report[2].Status)
+	require.Equal(t, FetchErrRateLimited, report[2].Error)
+	require.Equal(t, KeywordFailed, report[3].Status)
+	require.Equal(t, 1, report[3].SeedErrors)
+	require.Equal(t, KeywordRunning, report[4].Status)
+	require.Equal(t, 2, report[4].Seeds)
+
+	s.SeedDone("zoo in rome", 4)
+	require.Equal(t, KeywordCompleted, s.Report()[4].Status)
+}
+
+func TestKeywordStatsBlockedPage(t *testing.T) {
+	s := NewKeywordStats()
+	s.AddSeed("dentist")
+	require.Equal(t, KeywordPending, s.Report()[0].Status)
+
+	require.True(t, isBlockedPage("https://www.google.com/sorry/index?continue=https://www.google.com/maps"))
+	s.SeedFailed("dentist", errMapsBlocked, 0)
+	require.Equal(t, KeywordBlocked, s.Report()[0].Status)
+
+	var nilStats *KeywordStats
+
+	nilStats.AddSeed("dentist")
+	nilStats.SeedDone("dentist", 1)
+	require.Nil(t, nilStats.Report())
+}
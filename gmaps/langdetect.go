@@ -0,0 +1,300 @@
+package gmaps
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DetectLanguage returns the ISO 639-1 code of the language text is most
+// likely written in, or an empty string when text is too short or too
+// ambiguous to tell. It is meant for review-sized texts: the script decides
+// for non-Latin alphabets, frequent words and letters for the Latin ones.
+func DetectLanguage(text string) string {
+	var (
+		scripts = map[string]int{}
+		letters int
+	)
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		letters++
+
+		if s := runeScript(r); s != "" {
+			scripts[s]++
+		}
+	}
+
+	if letters < 3 {
+		return ""
+	}
+
+	// non-Latin scripts, kana before Han: Japanese mixes them
+	switch {
+	case scripts["kana"] > 0:
+		return "ja"
+	case scripts["hangul"]*2 > letters:
+		return "ko"
+	case scripts["han"]*2 > letters:
+		return "zh"
+	case scripts["cyrillic"]*2 > letters:
+		return cyrillicLanguage(text)
+	case scripts["arabic"]*2 > letters:
+		if strings.ContainsAny(text, "پچژگ") {
+			return "fa"
+		}
+
+		return "ar"
+	case scripts["greek"]*2 > letters:
+		return "el"
+	case scripts["hebrew"]*2 > letters:
+		return "he"
+	case scripts["thai"]*2 > letters:
+		return "th"
+	case scripts["devanagari"]*2 > letters:
+		return "hi"
+	case scripts["georgian"]*2 > letters:
+		return "ka"
+	case scripts["armenian"]*2 > letters:
+		return "hy"
+	case scripts["latin"]*2 > letters:
+		return latinLanguage(text)
+	}
+
+	return ""
+}
+
+func runeScript(r rune) string {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		return "latin"
+	case unicode.Is(unicode.Cyrillic, r):
+		return "cyrillic"
+	case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+		return "kana"
+	case unicode.Is(unicode.Hangul, r):
+		return "hangul"
+	case unicode.Is(unicode.Han, r):
+		return "han"
+	case unicode.Is(unicode.Arabic, r):
+		return "arabic"
+	case unicode.Is(unicode.Greek, r):
+		return "greek"
+	case unicode.Is(unicode.Hebrew, r):
+		return "hebrew"
+	case unicode.Is(unicode.Thai, r):
+		return "thai"
+	case unicode.Is(unicode.Devanagari, r):
+		return "devanagari"
+	case unicode.Is(unicode.Georgian, r):
+		return "georgian"
+	case unicode.Is(unicode.Armenian, r):
+		return "armenian"
+	}
+
+	return ""
+}
+
+// cyrillicLanguage tells Ukrainian and Bulgarian from Russian by the letters
+// only one of them uses.
+func cyrillicLanguage(text string) string {
+	lower := strings.ToLower(text)
+
+	switch {
+	case strings.ContainsAny(lower, "іїєґ"):
+		return "uk"
+	case !strings.ContainsAny(lower, "ыэё") && strings.ContainsRune(lower, 'ъ'):
+		return "bg"
+	}
+
+	return "ru"
+}
+
+// latinWords are frequent words of the languages written in the Latin
+// alphabet, function words plus the usual words of reviews. A word shared by
+// several languages counts for each of them.
+var latinWords = map[string][]string{
+	"en": strings.Fields("the and is was were are this that with for very good great nice friendly staff food place service would will not they we you our have has been recommend best"),
+	"it": strings.Fields("il lo gli della delle che è sono era con per molto ottimo ottima buono buona bellissimo personale cibo posto servizio consiglio non più anche ma qualità prezzi"),
+	"de": strings.Fields("der die das und ist war sind mit für sehr gut nicht ein eine auch essen freundlich lecker immer wieder gerne zu empfehlen bedienung preis"),
+	"fr": strings.Fields("le la les et est était sont avec pour très bon bonne pas un une des du nous vous accueil service plat prix je recommande au"),
+	"es": strings.Fields("el la los las y es era son con para muy bueno buena no un una del lo comida servicio atención lugar recomiendo todo pero precio"),
+	"pt": strings.Fields("o a os as e é era são com para muito bom boa não um uma do da atendimento comida lugar recomendo ótimo ótima tudo mas preço"),
+	"nl": strings.Fields("de het een en is was zijn met voor zeer heel goed lekker niet ook wel eten vriendelijk personeel aanrader we"),
+	"pl": strings.Fields("i w na jest było są z do bardzo dobre dobry nie to się jedzenie obsługa polecam miejsce ale"),
+	"sv": strings.Fields("och är var med för mycket bra inte en ett det att mat trevlig personal rekommenderar"),
+	"da": strings.Fields("og er var med for meget god godt ikke en et det at mad venlig personale anbefales"),
+	"tr": strings.Fields("ve bir bu çok güzel iyi değil ile için da de yemek personel tavsiye ederim"),
+	"ro": strings.Fields("și este era sunt cu pentru foarte bun bună nu un o din mâncare personal recomand"),
+	"cs": strings.Fields("a je byl jsou s pro velmi dobré dobrý ne to se jídlo obsluha doporučuji"),
+	"hu": strings.Fields("és a az egy nagyon jó nem volt van hogy is étel kiszolgálás ajánlom"),
+	"fi": strings.Fields("ja on oli ovat kanssa erittäin hyvä hyvää ei se että ruoka palvelu suosittelen"),
+}
+
+// latinLetters are letters that point to a language, counted once each.
+var latinLetters = map[rune][]string{
+	'ß': {"de"},
+	'ñ': {"es"},
+	'ã': {"pt"},
+	'õ': {"pt"},
+	'ğ': {"tr"},
+	'ş': {"tr", "ro"},
+	'ı': {"tr"},
+	'ő': {"hu"},
+	'ű': {"hu"},
+	'ł': {"pl"},
+	'ą': {"pl"},
+	'ę': {"pl"},
+	'ś': {"pl"},
+	'ż': {"pl"},
+	'ř': {"cs"},
+	'ů': {"cs"},
+	'ě': {"cs"},
+	'ș': {"ro"},
+	'ț': {"ro"},
+	'ă': {"ro"},
+	'å': {"sv", "da"},
+	'ø': {"da"},
+	'æ': {"da"},
+	'è': {"it", "fr"},
+	'ù': {"it", "fr"},
+	'ê': {"fr", "pt"},
+	'ç': {"fr", "pt", "tr"},
+}
+
+var latinIndex = func() map[string][]string {
+	index := map[string][]string{}
+
+	for lang, words := range latinWords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+
+	return index
+}()
+
+// latinLanguage scores the languages by their frequent words and letters in
+// text and returns the best one, if clearly ahead.
+func latinLanguage(text string) string {
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	scores := map[string]int{}
+
+	for _, w := range words {
+		for _, lang := range latinIndex[w] {
+			scores[lang] += 2
+		}
+	}
+
+	seen := map[rune]bool{}
+
+	for _, r := range lower {
+		if langs, ok := latinLetters[r]; ok && !seen[r] {
+			seen[r] = true
+
+			for _, lang := range langs {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, second := "", 0, 0
+
+	for lang, score := range scores {
+		switch {
+		case score > bestScore || score == bestScore && lang < best:
+			best, second, bestScore = lang, max(second, bestScore), score
+		case score > second:
+			second = score
+		}
+	}
+
+	// one frequent word is enough for a very short text only
+	minScore := 4
+	if len(words) <= 3 {
+		minScore = 2
+	}
+
+	if bestScore < minScore || bestScore == second {
+		return ""
+	}
+
+	return best
+}
+
+// DetectLanguages sets the detected language of the description and of the
+// reviews of e.
+func (e *Entry) DetectLanguages() {
+	e.DescriptionLanguage = DetectLanguage(e.Description)
+
+	for i := range e.UserReviews {
+		e.UserReviews[i].detectLanguage()
+	}
+
+	for i := range e.UserReviewsExtended {
+		e.UserReviewsExtended[i].detectLanguage()
+	}
+}
+
+func (r *Review) detectLanguage() {
+	text := r.Description
+	if r.TextOriginal != "" {
+		text = r.TextOriginal
+	}
+
+	r.DetectedLanguage = DetectLanguage(text)
+}
+
+// KeepReviewLanguages drops the reviews of e whose detected language is not
+// one of langs, those of undetected language included. Empty langs keep all
+// the reviews.
+func (e *Entry) KeepReviewLanguages(langs []string) {
+	if len(langs) == 0 {
+		return
+	}
+
+	drop := func(r Review) bool {
+		return !slices.Contains(langs, r.DetectedLanguage)
+	}
+
+	e.UserReviews = slices.DeleteFunc(e.UserReviews, drop)
+	e.UserReviewsExtended = slices.DeleteFunc(e.UserReviewsExtended, drop)
+}
+
+// ParseLanguages parses a comma separated list of ISO 639-1 codes.
+func ParseLanguages(s string) ([]string, error) {
+	var ans []string
+
+	for _, lang := range strings.Split(s, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang != "" {
+			ans = append(ans, lang)
+		}
+	}
+
+	if err := ValidateLanguages(ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+// ValidateLanguages checks that langs are lowercase 2-letter ISO 639-1
+// codes, as DetectLanguage returns them.
+func ValidateLanguages(langs []string) error {
+	for _, lang := range langs {
+		if len(lang) != 2 || lang[0] < 'a' || lang[0] > 'z' || lang[1] < 'a' || lang[1] > 'z' {
+			return fmt.Errorf("invalid language %q: use 2-letter ISO 639-1 codes", lang)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Great food and very friendly staff, we will come back!", "en"},
+		{"Pizza ottima, personale gentile e prezzi onesti. Consiglio!", "it"},
+		{"Sehr gutes Essen und freundliche Bedienung, immer wieder gerne.", "de"},
+		{"Très bon accueil, le service est rapide et les prix sont corrects.", "fr"},
+		{"La comida es muy buena y el servicio también, lo recomiendo.", "es"},
+		{"Atendimento ótimo, comida muito boa e preço justo.", "pt"},
+		{"Heel lekker gegeten en het personeel is erg vriendelijk.", "nl"},
+		{"Bardzo dobre jedzenie, miła obsługa. Polecam!", "pl"},
+		{"Очень вкусно и быстро, персонал вежливый.", "ru"},
+		{"Дуже смачно, привітний персонал.", "uk"},
+		{"とても美味しかったです。また来たいです。", "ja"},
+		{"음식이 정말 맛있어요", "ko"},
+		{"菜很好吃，服务也很好", "zh"},
+		{"الطعام لذيذ والخدمة ممتازة", "ar"},
+		{"Πολύ ωραίο φαγητό", "el"},
+		{"ok", ""},
+		{"Super", ""},
+		{"", ""},
+		{"👍👍👍", ""},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, DetectLanguage(tt.text), tt.text)
+	}
+}
+
+func TestEntryLanguages(t *testing.T) {
+	e := Entry{
+		Description: "Ristorante storico nel centro della città, con cucina tipica e personale molto gentile.",
+		UserReviews: []Review{
+			{Description: "Great food and very friendly staff, we will come back!"},
+			{Description: "Sehr gutes Essen und freundliche Bedienung, immer wieder gerne."},
+		},
+		UserReviewsExtended: []Review{
+			{
+				Description:  "(Translated by Google) Great food",
+				TextOriginal: "La comida es muy buena y el servicio también, lo recomiendo.",
+			},
+			{Description: "ok"},
+		},
+	}
+
+	e.DetectLanguages()
+
+	require.Equal(t, "it", e.DescriptionLanguage)
+	require.Equal(t, "en", e.UserReviews[0].DetectedLanguage)
+	require.Equal(t, "de", e.UserReviews[1].DetectedLanguage)
+	require.Equal(t, "es", e.UserReviewsExtended[0].DetectedLanguage)
+	require.Empty(t, e.UserReviewsExtended[1].DetectedLanguage)
+
+	e.KeepReviewLanguages(nil)
+	require.Len(t, e.UserReviews, 2)
+	require.Len(t, e.UserReviewsExtended, 2)
+
+	e.KeepReviewLanguages([]string{"en", "es"})
+	require.Len(t, e.UserReviews, 1)
+	require.Equal(t, "en", e.UserReviews[0].DetectedLanguage)
+	require.Len(t, e.UserReviewsExtended, 1)
+	require.Equal(t, "es", e.UserReviewsExtended[0].DetectedLanguage)
+}
+
+func TestParseLanguages(t *testing.T) {
+	langs, err := ParseLanguages(" EN, de ,,")
+	require.NoError(t, err)
+	require.Equal(t, []string{"en", "de"}, langs)
+
+	langs, err = ParseLanguages("")
+	require.NoError(t, err)
+	require.Empty(t, langs)
+
+	_, err = ParseLanguages("en,english")
+	require.Error(t, err)
+
+	require.Error(t, ValidateLanguages([]string{"EN"}))
+}
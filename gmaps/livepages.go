@@ -0,0 +1,109 @@
+package gmaps
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+)
+
+// ErrNoLivePage is returned by LivePages.Screenshot when no page is open.
+var ErrNoLivePage = errors.New("no browser page open")
+
+type livePage struct {
+	page  scrapemate.BrowserPage
+	since time.Time
+}
+
+// LivePages keeps the browser pages a job is working on, so that a crawl
+// that seems stuck can be looked at while it runs. It is safe for concurrent
+// use; a nil *LivePages keeps nothing.
+type LivePages struct {
+	mu     sync.Mutex
+	next   int
+	active map[int]livePage
+	// last is the page released last, still open in the browser pool
+	last *livePage
+}
+
+// NewLivePages creates an empty LivePages.
+func NewLivePages() *LivePages {
+	return &LivePages{active: make(map[int]livePage)}
+}
+
+// track records that page is in use until the returned function is called.
+func (l *LivePages) track(page scrapemate.BrowserPage) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.next
+	l.next++
+	l.active[id] = livePage{page: page, since: time.Now()}
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		p := l.active[id]
+		l.last = &p
+
+		delete(l.active, id)
+	}
+}
+
+// LiveScreenshot is a screenshot of a page of a running job.
+type LiveScreenshot struct {
+	PNG []byte
+	URL string
+	// Busy is how long the job has been working on the page, 0 for a page
+	// no job is working on.
+	Busy time.Duration
+}
+
+// Screenshot captures the page in use for the longest time, the likeliest
+// to be stuck, or else the page released last.
+func (l *LivePages) Screenshot() (LiveScreenshot, error) {
+	if l == nil {
+		return LiveScreenshot{}, ErrNoLivePage
+	}
+
+	l.mu.Lock()
+
+	var target *livePage
+
+	for _, p := range l.active {
+		if target == nil || p.since.Before(target.since) {
+			target = &p
+		}
+	}
+
+	busy := target != nil
+
+	if !busy {
+		target = l.last
+	}
+
+	l.mu.Unlock()
+
+	if target == nil {
+		return LiveScreenshot{}, ErrNoLivePage
+	}
+
+	png, err := target.page.Screenshot(false)
+	if err != nil {
+		return LiveScreenshot{}, err
+	}
+
+	ans := LiveScreenshot{PNG: png, URL: target.page.URL()}
+
+	if busy {
+		ans.Busy = time.Since(target.since)
+	}
+
+	return ans, nil
+}
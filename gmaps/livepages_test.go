@@ -0,0 +1,46 @@
+package gmaps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLivePagesScreenshot(t *testing.T) {
+	l := NewLivePages()
+
+	_, err := l.Screenshot()
+	require.ErrorIs(t, err, ErrNoLivePage)
+
+	stuck := &diagnosisPage{url: "https://www.google.com/maps/search/stuck"}
+	releaseStuck := l.track(stuck)
+
+	time.Sleep(time.Millisecond)
+
+	other := &diagnosisPage{url: "https://www.google.com/maps/place/other"}
+	releaseOther := l.track(other)
+
+	// the page in use for the longest time
+	shot, err := l.Screenshot()
+	require.NoError(t, err)
+	require.Equal(t, stuck.url, shot.URL)
+	require.Equal(t, []byte("png"), shot.PNG)
+	require.Positive(t, shot.Busy)
+
+	releaseStuck()
+	releaseOther()
+
+	// then the page released last
+	shot, err = l.Screenshot()
+	require.NoError(t, err)
+	require.Equal(t, other.url, shot.URL)
+	require.Zero(t, shot.Busy)
+
+	var nilPages *LivePages
+
+	nilPages.track(stuck)()
+
+	_, err = nilPages.Screenshot()
+	require.ErrorIs(t, err, ErrNoLivePage)
+}
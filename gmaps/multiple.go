@@ -64,6 +64,10 @@ func ParseSearchResults(raw []byte) ([]*Entry, error) {
 			return sb.String()
 		}()
 
+		if area, ok := serviceAreaFromJSON(business, entry.Title); ok {
+			entry.markServiceArea(area)
+		}
+
 		entry.Latitude = getNthElementAndCast[float64](business, 9, 2)
 		entry.Longtitude = getNthElementAndCast[float64](business, 9, 3)
 		entry.Phone = strings.ReplaceAll(getNthElementAndCast[string](business, 178, 0, 0), " ", "")
@@ -0,0 +1,373 @@
+package gmaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// The discrepancies between a place and OpenStreetMap, see OSMChecker.
+const (
+	// OSMNotFound: OpenStreetMap has no place of that name around.
+	OSMNotFound = "not_found"
+	// OSMName: the names share too few words.
+	OSMName = "name"
+	// OSMAddress: the postal codes or the house numbers differ.
+	OSMAddress = "address"
+	// OSMLocation: the places are more than osmLocationTolerance apart.
+	OSMLocation = "location"
+)
+
+const (
+	// DefaultOSMURL is the public Nominatim instance. Its usage policy
+	// allows a request per second at most, see DefaultOSMInterval.
+	DefaultOSMURL      = "https://nominatim.openstreetmap.org"
+	DefaultOSMInterval = time.Second
+
+	osmUserAgent = "google-maps-scraper (+https://github.com/gosom/google-maps-scraper)"
+	osmTimeout   = 15 * time.Second
+	// osmSearchRadius is how far, in degrees (about 500 m), from the place
+	// the search looks.
+	osmSearchRadius      = 0.005
+	osmLocationTolerance = 100.0 // meters
+	osmMaxCached         = 10000
+	maxOSMResponse       = 4 << 20
+)
+
+// OSMMatch is the OpenStreetMap object matching a place, see OSMChecker.
+type OSMMatch struct {
+	// Type and ID identify the object: node, way or relation.
+	Type    string `json:"type,omitempty"`
+	ID      int64  `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+	// Distance is in meters from the place.
+	Distance float64 `json:"distance_meters,omitempty"`
+	// Discrepancies lists what tells the place and the object apart
+	// (OSMName, OSMAddress, OSMLocation), or OSMNotFound alone.
+	Discrepancies []string `json:"discrepancies,omitempty"`
+}
+
+// Ref returns the object as in its openstreetmap.org link, like
+// "node/123", or an empty string when none matched.
+func (m *OSMMatch) Ref() string {
+	if m == nil || m.ID == 0 {
+		return ""
+	}
+
+	return m.Type + "/" + strconv.FormatInt(m.ID, 10)
+}
+
+// OSMChecker cross-checks the places against OpenStreetMap, an independent
+// source, for the data-quality audits: it searches Nominatim for the name
+// of each place around its coordinates and sets Entry.OSM to the closest
+// result, with the discrepancies found. The requests are spaced by the
+// interval and the answers cached, so that the places found again by
+// other searches cost nothing.
+type OSMChecker struct {
+	baseURL   string
+	userAgent string
+	interval  time.Duration
+	client    *http.Client
+
+	// mu serializes the requests, to keep them interval apart.
+	mu   sync.Mutex
+	last time.Time
+
+	// cache holds the search results by name and location.
+	cacheMu sync.Mutex
+	cache   map[string][]osmResult
+}
+
+// OSMCheckerOption configures an OSMChecker.
+type OSMCheckerOption func(*OSMChecker)
+
+// WithOSMCheckerURL sets the Nominatim instance, a self-hosted one not
+// needing the rate limit of the public one.
+func WithOSMCheckerURL(u string) OSMCheckerOption {
+	return func(c *OSMChecker) {
+		c.baseURL = strings.TrimRight(u, "/")
+	}
+}
+
+// WithOSMCheckerInterval sets the minimum delay between two requests, 0
+// for none.
+func WithOSMCheckerInterval(d time.Duration) OSMCheckerOption {
+	return func(c *OSMChecker) {
+		c.interval = d
+	}
+}
+
+// WithOSMCheckerUserAgent sets the User-Agent identifying the application,
+// which the Nominatim usage policy requires.
+func WithOSMCheckerUserAgent(ua string) OSMCheckerOption {
+	return func(c *OSMChecker) {
+		c.userAgent = ua
+	}
+}
+
+// WithOSMCheckerClient sets the HTTP client of the requests.
+func WithOSMCheckerClient(client *http.Client) OSMCheckerOption {
+	return func(c *OSMChecker) {
+		c.client = client
+	}
+}
+
+// NewOSMChecker creates an OSMChecker of the public Nominatim instance
+// unless WithOSMCheckerURL is given.
+func NewOSMChecker(opts ...OSMCheckerOption) *OSMChecker {
+	c := OSMChecker{
+		baseURL:   DefaultOSMURL,
+		userAgent: osmUserAgent,
+		interval:  DefaultOSMInterval,
+		client:    &http.Client{Timeout: osmTimeout},
+		cache:     map[string][]osmResult{},
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
+
+// Enrich sets e.OSM, implementing Enricher. The places without a title or
+// coordinates are skipped.
+func (c *OSMChecker) Enrich(ctx context.Context, e *Entry) error {
+	if e.Title == "" || (e.Latitude == 0 && e.Longtitude == 0) {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s|%.4f|%.4f", normalizePlaceName(e.Title), e.Latitude, e.Longtitude)
+
+	c.cacheMu.Lock()
+	results, ok := c.cache[key]
+	c.cacheMu.Unlock()
+
+	if !ok {
+		var err error
+
+		results, err = c.search(ctx, e)
+		if err != nil {
+			return err
+		}
+
+		c.cacheMu.Lock()
+		if len(c.cache) >= osmMaxCached {
+			c.cache = map[string][]osmResult{}
+		}
+
+		c.cache[key] = results
+		c.cacheMu.Unlock()
+	}
+
+	e.OSM = matchOSM(e, results)
+
+	return nil
+}
+
+// osmResult is a result of the Nominatim search API (format=jsonv2).
+type osmResult struct {
+	OSMType     string            `json:"osm_type"`
+	OSMID       int64             `json:"osm_id"`
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Address     map[string]string `json:"address"`
+}
+
+func (c *OSMChecker) search(ctx context.Context, e *Entry) ([]osmResult, error) {
+	query := url.Values{}
+	query.Set("q", e.Title)
+	query.Set("format", "jsonv2")
+	query.Set("addressdetails", "1")
+	query.Set("limit", "10")
+	query.Set("bounded", "1")
+	query.Set("viewbox", fmt.Sprintf("%f,%f,%f,%f",
+		e.Longtitude-osmSearchRadius, e.Latitude+osmSearchRadius,
+		e.Longtitude+osmSearchRadius, e.Latitude-osmSearchRadius))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/search?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("osm search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("osm search: HTTP %d", resp.StatusCode)
+	}
+
+	var results []osmResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOSMResponse)).Decode(&results); err != nil {
+		return nil, fmt.Errorf("osm search: %w", err)
+	}
+
+	return results, nil
+}
+
+// wait blocks until the interval since the previous request is over.
+func (c *OSMChecker) wait(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d := c.interval - time.Since(c.last); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	c.last = time.Now()
+
+	return nil
+}
+
+// matchOSM picks the result closest to e and compares them.
+func matchOSM(e *Entry, results []osmResult) *OSMMatch {
+	var (
+		best         *osmResult
+		bestDistance float64
+	)
+
+	for i := range results {
+		lat, errLat := strconv.ParseFloat(results[i].Lat, 64)
+		lon, errLon := strconv.ParseFloat(results[i].Lon, 64)
+
+		if errLat != nil || errLon != nil {
+			continue
+		}
+
+		if d := e.haversineDistance(lat, lon); best == nil || d < bestDistance {
+			best, bestDistance = &results[i], d
+		}
+	}
+
+	if best == nil {
+		return &OSMMatch{Discrepancies: []string{OSMNotFound}}
+	}
+
+	ans := OSMMatch{
+		Type:     best.OSMType,
+		ID:       best.OSMID,
+		Name:     best.Name,
+		Address:  best.DisplayName,
+		Distance: math.Round(bestDistance),
+	}
+
+	if best.Name != "" && !osmNamesMatch(e.Title, best.Name) {
+		ans.Discrepancies = append(ans.Discrepancies, OSMName)
+	}
+
+	if !osmAddressMatches(e, best.Address) {
+		ans.Discrepancies = append(ans.Discrepancies, OSMAddress)
+	}
+
+	if bestDistance > osmLocationTolerance {
+		ans.Discrepancies = append(ans.Discrepancies, OSMLocation)
+	}
+
+	return &ans
+}
+
+// osmNamesMatch reports whether the names a and b are the same place's:
+// one holds the other, or at least half the words of the shorter are in
+// the longer, whatever the case and the punctuation.
+func osmNamesMatch(a, b string) bool {
+	wa, wb := osmWords(a), osmWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return true
+	}
+
+	ja, jb := strings.Join(wa, " "), strings.Join(wb, " ")
+	if strings.Contains(ja, jb) || strings.Contains(jb, ja) {
+		return true
+	}
+
+	if len(wa) > len(wb) {
+		wa, wb = wb, wa
+	}
+
+	in := map[string]bool{}
+	for _, w := range wb {
+		in[w] = true
+	}
+
+	common := 0
+
+	for _, w := range wa {
+		if in[w] {
+			common++
+		}
+	}
+
+	return 2*common >= len(wa)
+}
+
+func osmWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// osmAddressMatches compares the postal code and the house number of the
+// address of an OSM object with those of e, when both sides have them.
+// The street names are not compared: their abbreviations ("St", "Pkwy")
+// differ too often between the two sources.
+func osmAddressMatches(e *Entry, address map[string]string) bool {
+	postcode := strings.ReplaceAll(address["postcode"], " ", "")
+	if own := strings.ReplaceAll(e.CompleteAddress.PostalCode, " ", ""); postcode != "" && own != "" &&
+		!strings.EqualFold(postcode, own) {
+		return false
+	}
+
+	number := strings.ToLower(address["house_number"])
+	if number == "" || e.CompleteAddress.Street == "" {
+		return true
+	}
+
+	street := osmWords(e.CompleteAddress.Street)
+
+	for _, w := range osmWords(number) {
+		if !slices.Contains(street, w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// osmCsvValues returns the OpenStreetMap fields of e for its CSV row.
+func (e *Entry) osmCsvValues() []string {
+	if e.OSM == nil {
+		return []string{"", ""}
+	}
+
+	return []string{e.OSM.Ref(), stringSliceToString(e.OSM.Discrepancies)}
+}
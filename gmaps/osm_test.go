@@ -0,0 +1,120 @@
+package gmaps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const osmSearchBody = `[
+  {"osm_type":"way","osm_id":42,"lat":"45.4650","lon":"9.1900","name":"Bar Roma","display_name":"Bar Roma, 12, Via Roma, Milano, 20121, Italia","address":{"house_number":"12","road":"Via Roma","postcode":"20121"}},
+  {"osm_type":"node","osm_id":7,"lat":"45.4642","lon":"9.1895","name":"Bar Roma","display_name":"Bar Roma, 3, Via Roma, Milano, 20121, Italia","address":{"house_number":"3","road":"Via Roma","postcode":"20121"}}
+]`
+
+func TestOSMChecker(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		require.Equal(t, "/search", r.URL.Path)
+		require.NotEmpty(t, r.Header.Get("User-Agent"))
+		require.Equal(t, "1", r.URL.Query().Get("bounded"))
+
+		if r.URL.Query().Get("q") == "Nowhere" {
+			_, _ = w.Write([]byte(`[]`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(osmSearchBody))
+	}))
+	defer srv.Close()
+
+	c := NewOSMChecker(WithOSMCheckerURL(srv.URL+"/"), WithOSMCheckerInterval(0))
+
+	e := Entry{
+		Title:           "Bar Roma",
+		Latitude:        45.4642,
+		Longtitude:      9.1895,
+		CompleteAddress: Address{Street: "Via Roma, 3", PostalCode: "20121"},
+	}
+
+	require.NoError(t, c.Enrich(context.Background(), &e))
+	require.Equal(t, "node/7", e.OSM.Ref())
+	require.Zero(t, e.OSM.Distance)
+	require.Empty(t, e.OSM.Discrepancies)
+
+	// the same place again comes from the cache
+	same := Entry{Title: "bar  roma", Latitude: 45.4642, Longtitude: 9.1895, CompleteAddress: Address{Street: "Via Roma 5"}}
+	entries, err := Enrichers{c}.Process(context.Background(), []*Entry{&same, {Title: "no coordinates"}})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, []string{OSMAddress}, same.OSM.Discrepancies)
+	require.Nil(t, entries[1].OSM)
+	require.EqualValues(t, 1, requests.Load())
+
+	missing := Entry{Title: "Nowhere", Latitude: 45, Longtitude: 9}
+	require.NoError(t, c.Enrich(context.Background(), &missing))
+	require.Equal(t, []string{OSMNotFound}, missing.OSM.Discrepancies)
+	require.Empty(t, missing.OSM.Ref())
+}
+
+func TestOSMCheckerInterval(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := NewOSMChecker(WithOSMCheckerURL(srv.URL), WithOSMCheckerInterval(50*time.Millisecond))
+
+	start := time.Now()
+
+	for i := range 3 {
+		e := Entry{Title: "Bar", Latitude: 45 + float64(i), Longtitude: 9}
+		require.NoError(t, c.Enrich(context.Background(), &e))
+	}
+
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	e := Entry{Title: "Bar", Latitude: 50, Longtitude: 9}
+	_, err := Enrichers{c}.Process(ctx, []*Entry{&e})
+	require.Error(t, err)
+	require.Nil(t, e.OSM)
+}
+
+func TestMatchOSM(t *testing.T) {
+	e := Entry{Title: "Caffè Centrale", Latitude: 45.4642, Longtitude: 9.1895, CompleteAddress: Address{PostalCode: "20122"}}
+
+	m := matchOSM(&e, []osmResult{
+		{OSMType: "node", OSMID: 1, Lat: "45.4660", Lon: "9.1895", Name: "Pizzeria Da Mario", Address: map[string]string{"postcode": "20121"}},
+		{OSMType: "node", OSMID: 2, Lat: "bad", Lon: "9.1895", Name: "Caffè Centrale"},
+	})
+	require.Equal(t, "node/1", m.Ref())
+	require.Equal(t, []string{OSMName, OSMAddress, OSMLocation}, m.Discrepancies)
+	require.InDelta(t, 200, m.Distance, 1)
+}
+
+func TestOSMNamesMatch(t *testing.T) {
+	require.True(t, osmNamesMatch("Bar Roma", "bar-roma"))
+	require.True(t, osmNamesMatch("McDonald's Piazza Duomo", "McDonald's"))
+	require.True(t, osmNamesMatch("Hotel Milano Centrale", "Grand Hotel Centrale"))
+	require.False(t, osmNamesMatch("Caffè Centrale", "Pizzeria Da Mario"))
+}
+
+func TestEntryOSMCsv(t *testing.T) {
+	e := Entry{}
+	require.Equal(t, "", csvValue(t, &e, "osm_id"))
+
+	e.OSM = &OSMMatch{Type: "way", ID: 42, Discrepancies: []string{OSMName, OSMAddress}}
+	require.Equal(t, "way/42", csvValue(t, &e, "osm_id"))
+	require.Equal(t, "name, address", csvValue(t, &e, "osm_discrepancies"))
+}
@@ -0,0 +1,89 @@
+package gmaps
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gosom/scrapemate"
+)
+
+// pageLanguageJS reads the language a page rendered in, from the lang
+// attribute of its html element.
+const pageLanguageJS = `() => document.documentElement.lang || ""`
+
+// primaryLanguage returns the lowercased primary subtag of the language tag
+// tag, "pt" for "pt-BR", with the deprecated codes Google still uses mapped
+// to the current ones.
+func primaryLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	switch tag {
+	case "iw":
+		return "he"
+	case "in":
+		return "id"
+	case "ji":
+		return "yi"
+	}
+
+	return tag
+}
+
+// pageLanguage returns the primary language page rendered in, "" when it
+// tells none.
+func pageLanguage(page scrapemate.BrowserPage) string {
+	raw, err := page.Eval(pageLanguageJS)
+	if err != nil {
+		return ""
+	}
+
+	lang, _ := raw.(string)
+
+	return primaryLanguage(lang)
+}
+
+// withLanguage returns rawURL asking Google for the language lang, whatever
+// the hl parameter it had.
+func withLanguage(rawURL, lang string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	q := u.Query()
+	q.Set("hl", lang)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// ensureLanguage checks that page rendered in lang and, when it did not,
+// loads it again asking for lang explicitly. It returns the language the
+// page still renders in when it is another one, "" when it matches or
+// cannot be told.
+func ensureLanguage(page scrapemate.BrowserPage, lang string) string {
+	want := primaryLanguage(lang)
+	if want == "" {
+		return ""
+	}
+
+	got := pageLanguage(page)
+	if got == "" || got == want {
+		return ""
+	}
+
+	if _, err := page.Goto(withLanguage(page.URL(), lang), scrapemate.WaitUntilDOMContentLoaded); err != nil {
+		return got
+	}
+
+	got = pageLanguage(page)
+	if got == "" || got == want {
+		return ""
+	}
+
+	return got
+}
@@ -0,0 +1,84 @@
+package gmaps
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+// langPage renders in the languages of langs, one per load.
+type langPage struct {
+	fakeBrowserPage
+	langs []string
+	url   string
+}
+
+func (p *langPage) Goto(u string, w scrapemate.WaitUntilState) (*scrapemate.PageResponse, error) {
+	p.url = u
+	p.langs = p.langs[1:]
+
+	return p.fakeBrowserPage.Goto(u, w)
+}
+
+func (p *langPage) URL() string { return p.url }
+
+func (p *langPage) Eval(string, ...any) (any, error) { return p.langs[0], nil }
+
+func TestPrimaryLanguage(t *testing.T) {
+	require.Equal(t, "pt", primaryLanguage(" pt-BR"))
+	require.Equal(t, "en", primaryLanguage("en_GB"))
+	require.Equal(t, "he", primaryLanguage("iw"))
+	require.Equal(t, "", primaryLanguage(""))
+}
+
+func TestWithLanguage(t *testing.T) {
+	require.Equal(t, "https://www.google.com/maps/place/a?authuser=0&hl=en",
+		withLanguage("https://www.google.com/maps/place/a?authuser=0&hl=de", "en"))
+}
+
+func TestEnsureLanguage(t *testing.T) {
+	const u = "https://www.google.com/maps/place/a?hl=de"
+
+	page := &langPage{langs: []string{"en-US"}, url: u}
+	require.Empty(t, ensureLanguage(page, "en"))
+	require.Zero(t, page.gotoCalls)
+
+	page = &langPage{langs: []string{"de", "en"}, url: u}
+	require.Empty(t, ensureLanguage(page, "en"))
+	require.Equal(t, 1, page.gotoCalls)
+	require.Equal(t, "https://www.google.com/maps/place/a?hl=en", page.url)
+
+	page = &langPage{langs: []string{"de", "de"}, url: u}
+	require.Equal(t, "de", ensureLanguage(page, "en"))
+
+	page = &langPage{langs: []string{""}, url: u}
+	require.Empty(t, ensureLanguage(page, "en"))
+	require.Empty(t, ensureLanguage(page, ""))
+}
+
+func TestPlaceJobRecordsPageLanguage(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/panic2.json")
+	require.NoError(t, err)
+
+	stats := NewKeywordStats()
+
+	job := NewPlaceJob("parent", "en", "https://www.google.com/maps/place/a", false, false,
+		WithPlaceJobQuery("cafe"), WithPlaceJobKeywordStats(stats))
+
+	meta := map[string]any{"json": raw, "page_language": "de"}
+
+	result, _, err := job.Process(context.Background(), &scrapemate.Response{Meta: meta})
+	require.NoError(t, err)
+
+	entry, ok := result.(*Entry)
+	require.True(t, ok)
+	require.Equal(t, "de", entry.PageLanguage)
+	require.Equal(t, "de", csvValue(t, entry, "page_language"))
+
+	report := stats.Report()
+	require.Len(t, report, 1)
+	require.Equal(t, 1, report[0].LanguageMismatches)
+}
@@ -22,6 +22,29 @@ type PlaceJob struct {
 	ExitMonitor             exiter.Exiter
 	ExtractExtraReviews     bool
 	WriterManagedCompletion bool
+	Query                   string
+	ExcludeServiceArea      bool
+	EmailProxyRouter        *EmailProxyRouter
+	EmailVerifier           *EmailVerifier
+	FetchStats              *FetchStats
+	Sessions                *SessionPool
+	Provenance              Provenance
+	CustomExtractors        []CustomExtractor
+	EmailFetchBudget        *FetchBudget
+	ReviewerAnonymizer      *ReviewerAnonymizer
+	ReviewLanguages         []string
+	Exclusions              *Exclusions
+	KeywordStats            *KeywordStats
+	Failures                *PlaceFailures
+	LivePages               *LivePages
+	StepTimings             *StepTimings
+	EmailProgress           *EmailProgress
+	EmailBreaker            *DomainBreaker
+	EmailHAR                *HARCapture
+	Throttle                *Throttle
+	Skip                    SkipFields
+	SkipStats               *SkipStats
+	EmailPacer              *WebsitePacer
 }
 
 func NewPlaceJob(parentID, langCode, u string, extractEmail, extraExtraReviews bool, opts ...PlaceJobOptions) *PlaceJob {
@@ -65,6 +88,184 @@ func WithPlaceJobWriterManagedCompletion() PlaceJobOptions {
 	}
 }
 
+// WithPlaceJobQuery records the seed keyword that led to this place.
+func WithPlaceJobQuery(query string) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Query = query
+	}
+}
+
+// WithPlaceJobExcludeServiceArea skips the place when it turns out to be a
+// service-area business.
+func WithPlaceJobExcludeServiceArea() PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.ExcludeServiceArea = true
+	}
+}
+
+// WithPlaceJobEmailProxyRouter sets the proxy routing of the email job
+// spawned for this place.
+func WithPlaceJobEmailProxyRouter(r *EmailProxyRouter) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailProxyRouter = r
+	}
+}
+
+// WithPlaceJobEmailVerifier sets the verifier of the email job spawned for
+// this place.
+func WithPlaceJobEmailVerifier(v *EmailVerifier) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailVerifier = v
+	}
+}
+
+// WithPlaceJobFetchStats records the failed fetches and the bytes downloaded
+// by the place page, and by its email job, into s.
+func WithPlaceJobFetchStats(s *FetchStats) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.FetchStats = s
+	}
+}
+
+// WithPlaceJobSessionPool makes the place page browse Maps with the
+// identities of s.
+func WithPlaceJobSessionPool(s *SessionPool) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Sessions = s
+	}
+}
+
+// WithPlaceJobProvenance sets the job ID and the scraper version stamped on
+// the entry of the place.
+func WithPlaceJobProvenance(p Provenance) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Provenance = p
+	}
+}
+
+// WithPlaceJobCustomExtractors reads user defined fields from the place
+// page.
+func WithPlaceJobCustomExtractors(extractors []CustomExtractor) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.CustomExtractors = extractors
+	}
+}
+
+// WithPlaceJobEmailFetchBudget caps the website fetches of the email jobs.
+func WithPlaceJobEmailFetchBudget(b *FetchBudget) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailFetchBudget = b
+	}
+}
+
+// WithPlaceJobReviewerAnonymizer strips the reviewer data from the reviews
+// of the place.
+func WithPlaceJobReviewerAnonymizer(a *ReviewerAnonymizer) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.ReviewerAnonymizer = a
+	}
+}
+
+// WithPlaceJobReviewLanguages keeps only the reviews of the place detected
+// in one of langs.
+func WithPlaceJobReviewLanguages(langs []string) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.ReviewLanguages = langs
+	}
+}
+
+// WithPlaceJobExclusions drops the place when x excludes it, before its
+// email extraction.
+func WithPlaceJobExclusions(x *Exclusions) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Exclusions = x
+	}
+}
+
+// WithPlaceJobKeywordStats records the visit of the place under its query
+// into s.
+func WithPlaceJobKeywordStats(s *KeywordStats) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.KeywordStats = s
+	}
+}
+
+// WithPlaceJobFailures records the page of the place into f when it fails
+// for good.
+func WithPlaceJobFailures(f *PlaceFailures) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Failures = f
+	}
+}
+
+// WithPlaceJobLivePages keeps the page of the place in l while it is in use.
+func WithPlaceJobLivePages(l *LivePages) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.LivePages = l
+	}
+}
+
+// WithPlaceJobStepTimings records the timings of the place, and of its
+// email extraction, into t.
+func WithPlaceJobStepTimings(t *StepTimings) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.StepTimings = t
+	}
+}
+
+// WithPlaceJobEmailProgress records the email search of the place into p.
+func WithPlaceJobEmailProgress(p *EmailProgress) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailProgress = p
+	}
+}
+
+// WithPlaceJobEmailBreaker skips the website of the place while b is open
+// for its domain, see DomainBreaker.
+func WithPlaceJobEmailBreaker(b *DomainBreaker) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailBreaker = b
+	}
+}
+
+// WithPlaceJobEmailHAR records a HAR of the browser fetches of the website
+// of the place into c when it is sampled, see HARCapture.
+func WithPlaceJobEmailHAR(c *HARCapture) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailHAR = c
+	}
+}
+
+// WithPlaceJobThrottle paces the place page with t.
+func WithPlaceJobThrottle(t *Throttle) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Throttle = t
+	}
+}
+
+// WithPlaceJobSkip leaves out the fields of the place skipped by s.
+func WithPlaceJobSkip(s SkipFields) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.Skip = s
+	}
+}
+
+// WithPlaceJobSkipStats records into s the work the place skipping fields
+// leaves undone.
+func WithPlaceJobSkipStats(s *SkipStats) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.SkipStats = s
+	}
+}
+
+// WithPlaceJobEmailPacer spaces the HTTP fetches of the website of the
+// place with p, see WebsitePacer.
+func WithPlaceJobEmailPacer(p *WebsitePacer) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.EmailPacer = p
+	}
+}
+
 func (j *PlaceJob) ProcessOnFetchError() bool {
 	return true
 }
@@ -77,6 +278,10 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 	}()
 
 	if resp.Error != nil {
+		j.FetchStats.RecordMapsError(j.GetURL(), resp.Error, resp.StatusCode)
+		j.KeywordStats.PlaceDone(j.Query, resp.Error)
+		j.Failures.Add(j.GetURL(), j.Query, resp.Error, resp.StatusCode)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrPlacesCompleted(1)
 		}
@@ -86,15 +291,25 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 
 	raw, ok := resp.Meta["json"].([]byte)
 	if !ok {
+		err := fmt.Errorf("could not convert to []byte")
+
+		j.KeywordStats.PlaceDone(j.Query, err)
+		j.Failures.Add(j.GetURL(), j.Query, err, 0)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrPlacesCompleted(1)
 		}
 
-		return nil, nil, fmt.Errorf("could not convert to []byte")
+		return nil, nil, err
 	}
 
-	entry, err := EntryFromJSON(raw)
+	parseStart := time.Now()
+
+	entry, err := entryFromJSON(raw, false, j.Skip)
 	if err != nil {
+		j.KeywordStats.PlaceDone(j.Query, err)
+		j.Failures.Add(j.GetURL(), j.Query, err, 0)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrPlacesCompleted(1)
 		}
@@ -102,12 +317,37 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 		return nil, nil, err
 	}
 
+	if j.ExcludeServiceArea && entry.IsServiceArea || j.Exclusions.Excludes(&entry) {
+		// the writer never sees this place, so count it here even when
+		// completion is writer-managed
+		if j.ExitMonitor != nil {
+			j.ExitMonitor.IncrPlacesCompleted(1)
+		}
+
+		return nil, nil, nil
+	}
+
+	j.KeywordStats.PlaceDone(j.Query, nil)
+
 	entry.ID = j.ParentID
+	entry.Query = j.Query
+	entry.Provenance = j.Provenance.stamp(j.GetURL(), j.URLParams["hl"])
+
+	if fields, ok := resp.Meta["custom_fields"].(map[string]string); ok {
+		entry.CustomFields = fields
+	}
 
 	if entry.Link == "" {
 		entry.Link = j.GetURL()
 	}
 
+	entry.FillIdentity(j.GetURL())
+
+	if lang, ok := resp.Meta["page_language"].(string); ok {
+		entry.PageLanguage = lang
+		j.KeywordStats.LanguageMismatch(j.Query)
+	}
+
 	// Handle RPC-based reviews
 	allReviewsRaw, ok := resp.Meta["reviews_raw"].(FetchReviewsResponse)
 	if ok && len(allReviewsRaw.pages) > 0 {
@@ -121,7 +361,16 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 		entry.UserReviewsExtended = append(entry.UserReviewsExtended, convertedReviews...)
 	}
 
-	if j.ExtractEmail && entry.IsWebsiteValidForEmail() {
+	entry.DetectLanguages()
+	// over all the reviews, before some are dropped by language
+	entry.AnalyzeReviews()
+	entry.KeepReviewLanguages(j.ReviewLanguages)
+
+	j.ReviewerAnonymizer.Anonymize(&entry)
+
+	j.StepTimings.Since(StepPlaceParse, parseStart)
+
+	if j.ExtractEmail && entry.IsWebsiteValidForEmail() && !j.EmailFetchBudget.Exhausted() {
 		opts := []EmailExtractJobOptions{}
 		if j.ExitMonitor != nil {
 			opts = append(opts, WithEmailJobExitMonitor(j.ExitMonitor))
@@ -131,18 +380,61 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 			opts = append(opts, WithEmailJobWriterManagedCompletion())
 		}
 
+		if j.EmailProxyRouter != nil {
+			opts = append(opts, WithEmailJobProxyRouter(j.EmailProxyRouter))
+		}
+
+		if j.EmailVerifier != nil {
+			opts = append(opts, WithEmailJobVerifier(j.EmailVerifier))
+		}
+
+		if j.FetchStats != nil {
+			opts = append(opts, WithEmailJobFetchStats(j.FetchStats))
+		}
+
+		if j.EmailFetchBudget != nil {
+			opts = append(opts, WithEmailJobFetchBudget(j.EmailFetchBudget))
+		}
+
+		if j.StepTimings != nil {
+			opts = append(opts, WithEmailJobStepTimings(j.StepTimings))
+		}
+
+		if j.EmailProgress != nil {
+			opts = append(opts, WithEmailJobProgress(j.EmailProgress))
+		}
+
+		if j.EmailBreaker != nil {
+			opts = append(opts, WithEmailJobBreaker(j.EmailBreaker))
+		}
+
+		if j.EmailHAR != nil {
+			opts = append(opts, WithEmailJobHAR(j.EmailHAR))
+		}
+
+		if j.EmailPacer != nil {
+			opts = append(opts, WithEmailJobPacer(j.EmailPacer))
+		}
+
 		emailJob := NewEmailJob(j.ID, &entry, opts...)
 
+		j.EmailProgress.Queue(&entry)
+
 		j.UsageInResults = false
 
 		return nil, []scrapemate.IJob{emailJob}, nil
 	}
 
 	if j.ExtractEmail {
-		if entry.WebSite == "" {
+		switch {
+		case entry.WebSite == "":
 			entry.EmailStatus = "no_website"
-		} else {
+		case !entry.IsWebsiteValidForEmail():
 			entry.EmailStatus = "blocked_domain"
+		case j.EmailFetchBudget.Aborted():
+			entry.EmailStatus = EmailStatusAborted
+		default:
+			entry.EmailStatus = EmailStatusBudgetExceeded
 		}
 		entry.Emails = []string{}
 	}
@@ -157,6 +449,31 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPage) scrapemate.Response {
 	var resp scrapemate.Response
 
+	release, err := j.Throttle.acquire(ctx)
+	if err != nil {
+		resp.Error = err
+
+		return resp
+	}
+	defer release()
+
+	defer func() {
+		j.Throttle.Observe(isSoftBlock(resp.Error, resp.StatusCode, page.URL()))
+	}()
+
+	defer j.LivePages.track(page)()
+	if j.Skip.Any() {
+		j.SkipStats.place()
+	}
+
+	defer j.Skip.blockImages(page, j.SkipStats)()
+	defer j.StepTimings.Since(StepPlaceTotal, time.Now())
+
+	j.FetchStats.meterBrowserPage(page, FetchSourceMaps)
+	j.Sessions.attach(page)
+
+	start := time.Now()
+
 	pageResponse, err := page.Goto(j.GetURL(), scrapemate.WaitUntilDOMContentLoaded)
 	if err != nil {
 		resp.Error = err
@@ -164,17 +481,27 @@ func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPa
 		return resp
 	}
 
-	clickRejectCookiesIfRequired(page)
+	j.Sessions.remember(page, clickRejectCookiesIfRequired(page))
 
 	const defaultTimeout = 5 * time.Second
 
 	// Ignore WaitForURL errors — Google Maps may redirect slowly especially via proxy
 	_ = page.WaitForURL(page.URL(), defaultTimeout)
 
+	// a page in another language breaks the category mappings downstream
+	pageLang := ensureLanguage(page, j.URLParams["hl"])
+	if pageLang != "" {
+		fmt.Printf("Warning: place page %s rendered in %s instead of %s\n", j.GetURL(), pageLang, j.URLParams["hl"])
+	}
+
+	j.StepTimings.Since(StepPlaceNavigation, start)
+
 	resp.URL = pageResponse.URL
 	resp.StatusCode = pageResponse.StatusCode
 	resp.Headers = pageResponse.Headers
 
+	start = time.Now()
+
 	raw, err := j.extractJSON(page)
 	if err != nil {
 		resp.Error = err
@@ -182,24 +509,55 @@ func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPa
 		return resp
 	}
 
+	j.StepTimings.Since(StepPlaceData, start)
+
 	if resp.Meta == nil {
 		resp.Meta = make(map[string]any)
 	}
 
 	resp.Meta["json"] = raw
 
-	if j.ExtractExtraReviews {
+	if pageLang != "" {
+		resp.Meta["page_language"] = pageLang
+	}
+
+	if len(j.CustomExtractors) > 0 {
+		// the selectors target the place panel, rendered after the data we read
+		_ = page.WaitForSelector("h1", defaultTimeout)
+
+		fields, err := extractCustomFields(page, j.CustomExtractors)
+		if err != nil {
+			fmt.Printf("Warning: custom fields extraction failed: %v\n", err)
+		}
+
+		resp.Meta["custom_fields"] = fields
+	}
+
+	if j.ExtractExtraReviews && j.Skip.Reviews {
+		j.SkipStats.reviewsNotFetched(j.getReviewCount(raw))
+	}
+
+	if j.ExtractExtraReviews && !j.Skip.Reviews {
 		reviewCount := j.getReviewCount(raw)
 		if reviewCount > 0 { // download reviews for any place that has them
 			params := fetchReviewsParams{
 				page:        page,
 				mapURL:      page.URL(),
 				reviewCount: reviewCount,
+				fetchStats:  j.FetchStats,
 			}
 
+			start = time.Now()
+
 			// Use the new fallback mechanism that tries RPC first, then DOM
 			rpcData, domReviews, err := FetchReviewsWithFallback(ctx, params)
 
+			j.StepTimings.Since(StepPlaceReviews, start)
+
+			if err == nil {
+				observeReviewFetch(reviewCount, time.Since(start))
+			}
+
 			switch {
 			case err != nil:
 				fmt.Printf("Warning: review extraction failed: %v\n", err)
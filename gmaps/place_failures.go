@@ -0,0 +1,93 @@
+package gmaps
+
+import (
+	"sync"
+	"time"
+)
+
+// maxPlaceFailures caps the failures a PlaceFailures keeps, so that a run
+// failing wholesale does not bloat the stats of its job.
+const maxPlaceFailures = 10000
+
+// PlaceFailure is a place page that still failed after all its retries.
+type PlaceFailure struct {
+	URL string `json:"url"`
+	// Query is the seed keyword that found the place.
+	Query string `json:"query,omitempty"`
+	// Reason is the class of the error, see ClassifyFetchError, and Error
+	// its message.
+	Reason string    `json:"reason"`
+	Error  string    `json:"error"`
+	Time   time.Time `json:"time"`
+}
+
+// PlaceFailures collects the place pages of a run that failed for good, so
+// that they can be visited again later. A place failing again replaces its
+// previous failure. It is safe for concurrent use; a nil *PlaceFailures
+// records nothing.
+type PlaceFailures struct {
+	mu       sync.Mutex
+	failures []PlaceFailure
+	index    map[string]int
+}
+
+// NewPlaceFailures creates an empty PlaceFailures.
+func NewPlaceFailures() *PlaceFailures {
+	return &PlaceFailures{index: make(map[string]int)}
+}
+
+// Add records that the page u of a place found by query failed with err, or
+// with the HTTP status statusCode when err is nil.
+func (f *PlaceFailures) Add(u, query string, err error, statusCode int) {
+	if f == nil || u == "" {
+		return
+	}
+
+	failure := PlaceFailure{
+		URL:    u,
+		Query:  query,
+		Reason: ClassifyFetchError(err, statusCode),
+		Time:   time.Now().UTC(),
+	}
+
+	if err != nil {
+		failure.Error = err.Error()
+	} else {
+		failure.Error = failure.Reason
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if i, ok := f.index[u]; ok {
+		f.failures[i] = failure
+
+		return
+	}
+
+	if len(f.failures) >= maxPlaceFailures {
+		return
+	}
+
+	f.index[u] = len(f.failures)
+	f.failures = append(f.failures, failure)
+}
+
+// Report returns the failures in the order they were first recorded.
+func (f *PlaceFailures) Report() []PlaceFailure {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.failures) == 0 {
+		return nil
+	}
+
+	ans := make([]PlaceFailure, len(f.failures))
+	copy(ans, f.failures)
+
+	return ans
+}
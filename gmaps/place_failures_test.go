@@ -0,0 +1,50 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceFailures(t *testing.T) {
+	f := NewPlaceFailures()
+
+	f.Add("https://www.google.com/maps/place/a", "cafe", errors.New("net::ERR_TIMED_OUT"), 0)
+	f.Add("https://www.google.com/maps/place/b", "bar", nil, 429)
+	f.Add("https://www.google.com/maps/place/a", "cafe", errors.New("could not convert to []byte"), 0)
+	f.Add("", "cafe", errors.New("ignored"), 0)
+
+	report := f.Report()
+	require.Len(t, report, 2)
+	require.Equal(t, "https://www.google.com/maps/place/a", report[0].URL)
+	require.Equal(t, "cafe", report[0].Query)
+	require.Equal(t, "could not convert to []byte", report[0].Error)
+	require.Equal(t, ClassifyFetchError(nil, 429), report[1].Reason)
+	require.Equal(t, report[1].Reason, report[1].Error)
+	require.False(t, report[1].Time.IsZero())
+
+	var none *PlaceFailures
+
+	none.Add("https://www.google.com/maps/place/a", "cafe", errors.New("x"), 0)
+	require.Nil(t, none.Report())
+	require.Nil(t, NewPlaceFailures().Report())
+}
+
+func TestPlaceJobRecordsFailure(t *testing.T) {
+	failures := NewPlaceFailures()
+
+	job := NewPlaceJob("parent", "en", "https://www.google.com/maps/place/a", false, false,
+		WithPlaceJobQuery("cafe"), WithPlaceJobFailures(failures))
+
+	_, _, err := job.Process(context.Background(), &scrapemate.Response{Error: errors.New("net::ERR_CONNECTION_RESET")})
+	require.Error(t, err)
+
+	report := failures.Report()
+	require.Len(t, report, 1)
+	require.Equal(t, "https://www.google.com/maps/place/a", report[0].URL)
+	require.Equal(t, "cafe", report[0].Query)
+	require.Equal(t, "net::ERR_CONNECTION_RESET", report[0].Error)
+}
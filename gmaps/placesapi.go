@@ -0,0 +1,270 @@
+package gmaps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// PlaceDetailsResponse mirrors the Google Places API "Place Details"
+// response envelope so code written against the official API can consume
+// scraper output without a mapping layer.
+type PlaceDetailsResponse struct {
+	HTMLAttributions []string     `json:"html_attributions"`
+	Result           PlaceDetails `json:"result"`
+	Status           string       `json:"status"`
+}
+
+// PlaceDetails mirrors the "result" object of a Place Details response.
+// Only fields the scraper can populate are emitted.
+type PlaceDetails struct {
+	PlaceID                  string                  `json:"place_id,omitempty"`
+	Name                     string                  `json:"name"`
+	FormattedAddress         string                  `json:"formatted_address,omitempty"`
+	AddressComponents        []PlaceAddressComponent `json:"address_components,omitempty"`
+	FormattedPhoneNumber     string                  `json:"formatted_phone_number,omitempty"`
+	InternationalPhoneNumber string                  `json:"international_phone_number,omitempty"`
+	Website                  string                  `json:"website,omitempty"`
+	URL                      string                  `json:"url,omitempty"`
+	Rating                   float64                 `json:"rating,omitempty"`
+	UserRatingsTotal         int                     `json:"user_ratings_total,omitempty"`
+	PriceLevel               int                     `json:"price_level,omitempty"`
+	BusinessStatus           string                  `json:"business_status,omitempty"`
+	Types                    []string                `json:"types,omitempty"`
+	Geometry                 PlaceGeometry           `json:"geometry"`
+	PlusCode                 *PlacePlusCode          `json:"plus_code,omitempty"`
+	OpeningHours             *PlaceOpeningHours      `json:"opening_hours,omitempty"`
+	EditorialSummary         *PlaceEditorialSummary  `json:"editorial_summary,omitempty"`
+	Reviews                  []PlaceReview           `json:"reviews,omitempty"`
+	Reservable               bool                    `json:"reservable,omitempty"`
+}
+
+type PlaceAddressComponent struct {
+	LongName  string   `json:"long_name"`
+	ShortName string   `json:"short_name"`
+	Types     []string `json:"types"`
+}
+
+type PlaceGeometry struct {
+	Location PlaceLatLng `json:"location"`
+}
+
+type PlaceLatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type PlacePlusCode struct {
+	CompoundCode string `json:"compound_code,omitempty"`
+	GlobalCode   string `json:"global_code,omitempty"`
+}
+
+type PlaceOpeningHours struct {
+	WeekdayText []string `json:"weekday_text"`
+}
+
+type PlaceEditorialSummary struct {
+	Overview string `json:"overview"`
+}
+
+type PlaceReview struct {
+	AuthorName              string `json:"author_name"`
+	AuthorURL               string `json:"author_url,omitempty"`
+	Language                string `json:"language,omitempty"`
+	ProfilePhotoURL         string `json:"profile_photo_url,omitempty"`
+	Rating                  int    `json:"rating"`
+	RelativeTimeDescription string `json:"relative_time_description,omitempty"`
+	Text                    string `json:"text"`
+	Time                    int64  `json:"time,omitempty"`
+}
+
+var weekdayOrder = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// ToPlaceDetailsResponse wraps the entry in a Place Details envelope.
+func (e *Entry) ToPlaceDetailsResponse() PlaceDetailsResponse {
+	return PlaceDetailsResponse{
+		HTMLAttributions: []string{},
+		Result:           e.ToPlaceDetails(),
+		Status:           "OK",
+	}
+}
+
+// ToPlaceDetails converts the entry to the Places API "result" shape.
+func (e *Entry) ToPlaceDetails() PlaceDetails {
+	ans := PlaceDetails{
+		PlaceID:              e.PlaceID,
+		Name:                 e.Title,
+		FormattedAddress:     e.Address,
+		AddressComponents:    placeAddressComponents(&e.CompleteAddress),
+		FormattedPhoneNumber: e.Phone,
+		Website:              e.WebSite,
+		URL:                  e.Link,
+		Rating:               e.ReviewRating,
+		UserRatingsTotal:     e.ReviewCount,
+		PriceLevel:           e.PriceLevel,
+		BusinessStatus:       placeBusinessStatus(e.Status),
+		Types:                placeTypes(e.Categories, e.Category),
+		Geometry: PlaceGeometry{
+			Location: PlaceLatLng{Lat: e.Latitude, Lng: e.Longtitude},
+		},
+		Reservable: len(e.Reservations) > 0,
+	}
+
+	if strings.HasPrefix(e.Phone, "+") {
+		ans.InternationalPhoneNumber = e.Phone
+	}
+
+	if e.Cid != "" {
+		ans.URL = "https://maps.google.com/?cid=" + e.Cid
+	}
+
+	if e.PlusCode != "" {
+		code := &PlacePlusCode{}
+
+		if strings.Contains(e.PlusCode, " ") {
+			code.CompoundCode = e.PlusCode
+		} else {
+			code.GlobalCode = e.PlusCode
+		}
+
+		ans.PlusCode = code
+	}
+
+	if len(e.OpenHours) > 0 {
+		ans.OpeningHours = &PlaceOpeningHours{WeekdayText: weekdayText(e.OpenHours)}
+	}
+
+	if e.Description != "" {
+		ans.EditorialSummary = &PlaceEditorialSummary{Overview: e.Description}
+	}
+
+	reviews := e.UserReviews
+	if len(e.UserReviewsExtended) > 0 {
+		reviews = e.UserReviewsExtended
+	}
+
+	for i := range reviews {
+		ans.Reviews = append(ans.Reviews, placeReview(&reviews[i]))
+	}
+
+	return ans
+}
+
+func placeReview(r *Review) PlaceReview {
+	ans := PlaceReview{
+		AuthorName:              r.Name,
+		AuthorURL:               r.AuthorURL,
+		Language:                r.Language,
+		ProfilePhotoURL:         r.ProfilePicture,
+		Rating:                  r.Rating,
+		RelativeTimeDescription: r.When,
+		Text:                    firstNonEmpty(r.TextOriginal, r.Description),
+	}
+
+	if r.PublishedAt != nil {
+		ans.Time = r.PublishedAt.Unix()
+	}
+
+	return ans
+}
+
+func weekdayText(hours map[string][]string) []string {
+	ans := make([]string, 0, len(hours))
+	seen := make(map[string]bool, len(hours))
+
+	for _, day := range weekdayOrder {
+		if slots, ok := hours[day]; ok {
+			ans = append(ans, fmt.Sprintf("%s: %s", day, strings.Join(slots, ", ")))
+			seen[day] = true
+		}
+	}
+
+	// localized day names (hl != en) keep a deterministic order
+	rest := make([]string, 0, len(hours))
+
+	for day := range hours {
+		if !seen[day] {
+			rest = append(rest, day)
+		}
+	}
+
+	sort.Strings(rest)
+
+	for _, day := range rest {
+		ans = append(ans, fmt.Sprintf("%s: %s", day, strings.Join(hours[day], ", ")))
+	}
+
+	return ans
+}
+
+func placeAddressComponents(a *Address) []PlaceAddressComponent {
+	parts := []struct {
+		value string
+		types []string
+	}{
+		{a.Street, []string{"route"}},
+		{a.Borough, []string{"sublocality", "political"}},
+		{a.City, []string{"locality", "political"}},
+		{a.State, []string{"administrative_area_level_1", "political"}},
+		{a.Country, []string{"country", "political"}},
+		{a.PostalCode, []string{"postal_code"}},
+	}
+
+	var ans []PlaceAddressComponent
+
+	for _, p := range parts {
+		if p.value == "" {
+			continue
+		}
+
+		ans = append(ans, PlaceAddressComponent{
+			LongName:  p.value,
+			ShortName: p.value,
+			Types:     p.types,
+		})
+	}
+
+	return ans
+}
+
+func placeBusinessStatus(status string) string {
+	lower := strings.ToLower(status)
+
+	switch {
+	case strings.Contains(lower, "permanently closed"):
+		return "CLOSED_PERMANENTLY"
+	case strings.Contains(lower, "temporarily closed"):
+		return "CLOSED_TEMPORARILY"
+	default:
+		return "OPERATIONAL"
+	}
+}
+
+// placeTypes converts Maps categories ("Italian restaurant") to Places API
+// style type tokens ("italian_restaurant"), keeping "establishment" last as
+// the official API does.
+func placeTypes(categories []string, category string) []string {
+	if len(categories) == 0 && category != "" {
+		categories = []string{category}
+	}
+
+	ans := make([]string, 0, len(categories)+1)
+	seen := make(map[string]bool, len(categories))
+
+	for _, c := range categories {
+		t := strings.Join(strings.FieldsFunc(strings.ToLower(c), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), "_")
+
+		if t == "" || seen[t] {
+			continue
+		}
+
+		seen[t] = true
+
+		ans = append(ans, t)
+	}
+
+	return append(ans, "establishment")
+}
@@ -0,0 +1,74 @@
+package gmaps_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestEntryToPlaceDetails(t *testing.T) {
+	entry := gmaps.Entry{
+		Title:        "Trattoria Da Mario",
+		Categories:   []string{"Italian restaurant", "Pizza restaurant"},
+		Address:      "Via Roma 1, 00100 Roma RM, Italy",
+		Phone:        "+39 06 123456",
+		WebSite:      "https://example.com",
+		ReviewRating: 4.5,
+		ReviewCount:  120,
+		PriceRange:   "€€",
+		PriceLevel:   2,
+		Status:       "Temporarily closed",
+		Latitude:     41.9,
+		Longtitude:   12.5,
+		Cid:          "16519582940102929223",
+		PlaceID:      "ChIJDdnwdv0y5xQRRytw1ihZQeU",
+		OpenHours: map[string][]string{
+			"Tuesday": {"12–3 PM", "7–11 PM"},
+			"Monday":  {"Closed"},
+		},
+		CompleteAddress: gmaps.Address{
+			Street:     "Via Roma 1",
+			City:       "Roma",
+			PostalCode: "00100",
+			Country:    "IT",
+		},
+		UserReviews: []gmaps.Review{
+			{Name: "Anna", Rating: 5, Description: "Ottimo"},
+		},
+	}
+
+	got := entry.ToPlaceDetailsResponse()
+
+	require.Equal(t, "OK", got.Status)
+	require.NotNil(t, got.HTMLAttributions)
+
+	res := got.Result
+	require.Equal(t, "ChIJDdnwdv0y5xQRRytw1ihZQeU", res.PlaceID)
+	require.Equal(t, "Trattoria Da Mario", res.Name)
+	require.Equal(t, "+39 06 123456", res.InternationalPhoneNumber)
+	require.Equal(t, "https://maps.google.com/?cid=16519582940102929223", res.URL)
+	require.Equal(t, 2, res.PriceLevel)
+	require.Equal(t, "CLOSED_TEMPORARILY", res.BusinessStatus)
+	require.Equal(t, []string{"italian_restaurant", "pizza_restaurant", "establishment"}, res.Types)
+	require.Equal(t, gmaps.PlaceLatLng{Lat: 41.9, Lng: 12.5}, res.Geometry.Location)
+	require.Equal(t, []string{"Monday: Closed", "Tuesday: 12–3 PM, 7–11 PM"}, res.OpeningHours.WeekdayText)
+	require.Len(t, res.AddressComponents, 4)
+	require.Equal(t, []string{"postal_code"}, res.AddressComponents[3].Types)
+	require.Len(t, res.Reviews, 1)
+	require.Equal(t, "Ottimo", res.Reviews[0].Text)
+
+	raw, err := json.Marshal(got)
+	require.NoError(t, err)
+
+	var generic map[string]any
+	require.NoError(t, json.Unmarshal(raw, &generic))
+
+	result, ok := generic["result"].(map[string]any)
+	require.True(t, ok)
+	require.Contains(t, result, "geometry")
+	require.Contains(t, result, "user_ratings_total")
+	require.Contains(t, result, "formatted_address")
+}
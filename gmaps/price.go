@@ -0,0 +1,212 @@
+package gmaps
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// currencySymbols maps the currency symbols Google shows in the price
+// ranges to their ISO 4217 codes. The symbols shared by several currencies
+// are resolved with the country of the place, see dollarCurrencies.
+var currencySymbols = map[string]string{
+	"$":   "USD",
+	"US$": "USD",
+	"CA$": "CAD",
+	"A$":  "AUD",
+	"AU$": "AUD",
+	"NZ$": "NZD",
+	"MX$": "MXN",
+	"HK$": "HKD",
+	"NT$": "TWD",
+	"S$":  "SGD",
+	"R$":  "BRL",
+	"€":   "EUR",
+	"£":   "GBP",
+	"¥":   "JPY",
+	"₹":   "INR",
+	"₩":   "KRW",
+	"₺":   "TRY",
+	"₽":   "RUB",
+	"₪":   "ILS",
+	"฿":   "THB",
+	"₫":   "VND",
+	"₱":   "PHP",
+	"₴":   "UAH",
+	"₸":   "KZT",
+	"₦":   "NGN",
+	"zł":  "PLN",
+	"kr":  "SEK",
+	"Kč":  "CZK",
+	"Ft":  "HUF",
+	"lei": "RON",
+	"RM":  "MYR",
+	"Rp":  "IDR",
+	"R":   "ZAR",
+}
+
+// dollarCurrencies are the currencies of "$", "¥" and "kr" by the country
+// of the place, when not the defaults of currencySymbols.
+var dollarCurrencies = map[string]map[string]string{
+	"$": {
+		"CA": "CAD", "AU": "AUD", "NZ": "NZD", "MX": "MXN", "HK": "HKD",
+		"SG": "SGD", "TW": "TWD", "AR": "ARS", "CL": "CLP", "CO": "COP",
+		"UY": "UYU", "JM": "JMD", "BS": "BSD", "FJ": "FJD",
+	},
+	"¥":  {"CN": "CNY"},
+	"kr": {"NO": "NOK", "DK": "DKK", "IS": "ISK"},
+}
+
+// priceNumberRe matches the amounts of a price range, like "10", "1,000",
+// "1.000", "1 000" or "100K".
+var priceNumberRe = regexp.MustCompile(`\d+(?:[.,\x{a0}\x{202f} ]\d{3})*(?:[.,]\d{1,2})?[kK]?`)
+
+// thousandsRe matches an amount with thousands separators and no decimals.
+var thousandsRe = regexp.MustCompile(`^\d{1,3}(?:[.,\x{a0}\x{202f} ]\d{3})+$`)
+
+// normalizePrice sets the price fields of e from its PriceRange, "€€" or
+// "€10–20" as Google shows it: the level of the glyph ranges, the currency
+// and the amounts of the others.
+func (e *Entry) normalizePrice() {
+	p := parsePriceRange(e.PriceRange, e.CompleteAddress.Country)
+
+	e.PriceLevel = p.level
+	e.PriceCurrency = p.currency
+	e.PriceMin = p.min
+	e.PriceMax = p.max
+}
+
+// priceRange is a parsed Entry.PriceRange.
+type priceRange struct {
+	// level is 1 to 4 for "€" to "€€€€", 0 for the ranges with amounts.
+	level    int
+	currency string
+	// min and max are the amounts of the range, max 0 for "€100+".
+	min, max float64
+}
+
+// parsePriceRange parses the price range s of a place of country, an ISO
+// 3166 code. The parts it does not understand are left zero.
+func parsePriceRange(s, country string) priceRange {
+	var ans priceRange
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ans
+	}
+
+	amounts := priceNumberRe.FindAllString(s, -1)
+
+	if len(amounts) == 0 {
+		// a glyph range, like "€€"
+		var symbol rune
+
+		for _, r := range s {
+			if !unicode.Is(unicode.Sc, r) {
+				continue
+			}
+
+			if symbol == 0 {
+				symbol = r
+			}
+
+			ans.level++
+		}
+
+		ans.level = min(ans.level, 4)
+
+		if symbol != 0 {
+			ans.currency = currencyOf(string(symbol), country)
+		}
+
+		return ans
+	}
+
+	symbol := priceNumberRe.ReplaceAllString(s, "")
+	symbol = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune("-\u2013\u2014~+.,", r) {
+			return -1
+		}
+
+		return r
+	}, symbol)
+
+	ans.currency = currencyOf(symbol, country)
+
+	ans.min = parseAmount(amounts[0])
+	if len(amounts) > 1 {
+		ans.max = parseAmount(amounts[1])
+	} else if !strings.Contains(s, "+") {
+		ans.max = ans.min
+	}
+
+	return ans
+}
+
+// currencyOf returns the ISO code of symbol in country, "" when unknown.
+func currencyOf(symbol, country string) string {
+	if code, ok := dollarCurrencies[symbol][strings.ToUpper(country)]; ok {
+		return code
+	}
+
+	if code, ok := currencySymbols[symbol]; ok {
+		return code
+	}
+
+	// already a code, like "CHF"
+	if len(symbol) == 3 && strings.ToUpper(symbol) == symbol && strings.IndexFunc(symbol, func(r rune) bool {
+		return r < 'A' || r > 'Z'
+	}) < 0 {
+		return symbol
+	}
+
+	return ""
+}
+
+// parseAmount parses an amount of priceNumberRe, 0 when invalid.
+func parseAmount(s string) float64 {
+	mult := 1.0
+
+	if strings.HasSuffix(s, "k") || strings.HasSuffix(s, "K") {
+		mult = 1000
+		s = s[:len(s)-1]
+	}
+
+	if thousandsRe.MatchString(s) {
+		s = strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+
+			return -1
+		}, s)
+	} else {
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return v * mult
+}
+
+// priceCsvValues returns the price fields of e for its CSV row.
+func (e *Entry) priceCsvValues() []string {
+	amount := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	level := ""
+	if e.PriceLevel > 0 {
+		level = strconv.Itoa(e.PriceLevel)
+	}
+
+	return []string{level, e.PriceCurrency, amount(e.PriceMin), amount(e.PriceMax)}
+}
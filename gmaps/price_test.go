@@ -0,0 +1,54 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriceRange(t *testing.T) {
+	tests := []struct {
+		in, country string
+		want        priceRange
+	}{
+		{"€€", "CY", priceRange{level: 2, currency: "EUR"}},
+		{"$$$$$", "US", priceRange{level: 4, currency: "USD"}},
+		{"$$", "CA", priceRange{level: 2, currency: "CAD"}},
+		{"€10–20", "DE", priceRange{currency: "EUR", min: 10, max: 20}},
+		{"10–20 €", "FR", priceRange{currency: "EUR", min: 10, max: 20}},
+		{"$100+", "US", priceRange{currency: "USD", min: 100}},
+		{"R$ 50–100", "BR", priceRange{currency: "BRL", min: 50, max: 100}},
+		{"₹1,000–2,000", "IN", priceRange{currency: "INR", min: 1000, max: 2000}},
+		{"1.000–2.000 Ft", "HU", priceRange{currency: "HUF", min: 1000, max: 2000}},
+		{"₫100K–200K", "VN", priceRange{currency: "VND", min: 100000, max: 200000}},
+		{"20–30 zł", "PL", priceRange{currency: "PLN", min: 20, max: 30}},
+		{"100–200 kr", "NO", priceRange{currency: "NOK", min: 100, max: 200}},
+		{"CHF 20–30", "CH", priceRange{currency: "CHF", min: 20, max: 30}},
+		{"¥1,000–2,000", "CN", priceRange{currency: "CNY", min: 1000, max: 2000}},
+		{"€15", "IT", priceRange{currency: "EUR", min: 15, max: 15}},
+		{"10–20", "", priceRange{min: 10, max: 20}},
+		{"", "US", priceRange{}},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, parsePriceRange(tt.in, tt.country), tt.in)
+	}
+}
+
+func TestEntryPriceCsv(t *testing.T) {
+	e := Entry{PriceRange: "$100+", CompleteAddress: Address{Country: "US"}}
+
+	e.normalizePrice()
+
+	require.Equal(t, "", csvValue(t, &e, "price_level"))
+	require.Equal(t, "USD", csvValue(t, &e, "price_currency"))
+	require.Equal(t, "100", csvValue(t, &e, "price_min"))
+	require.Equal(t, "", csvValue(t, &e, "price_max"))
+
+	e = Entry{PriceRange: "£££", CompleteAddress: Address{Country: "GB"}}
+
+	e.normalizePrice()
+
+	require.Equal(t, "3", csvValue(t, &e, "price_level"))
+	require.Equal(t, "GBP", csvValue(t, &e, "price_currency"))
+}
@@ -0,0 +1,99 @@
+package gmaps
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Provenance records when, where and by what an entry was scraped, so that
+// datasets merged from many jobs remain traceable.
+type Provenance struct {
+	ScrapedAt time.Time `json:"scraped_at,omitzero"`
+	// SourceURL is the page the entry was extracted from.
+	SourceURL string `json:"source_url"`
+	// JobID is the web or API job that produced the entry. It is empty for
+	// command line runs.
+	JobID          string `json:"job_id"`
+	Lang           string `json:"lang"`
+	ScraperVersion string `json:"scraper_version"`
+}
+
+// stamp returns p completed for an entry extracted now from sourceURL, in
+// lang.
+func (p Provenance) stamp(sourceURL, lang string) Provenance {
+	p.ScrapedAt = time.Now().UTC()
+	p.SourceURL = sourceURL
+	p.Lang = lang
+
+	if p.ScraperVersion == "" {
+		p.ScraperVersion = ScraperVersion()
+	}
+
+	return p
+}
+
+// ProvenanceCsvHeaders are the CSV columns of Provenance.CsvValues.
+func ProvenanceCsvHeaders() []string {
+	return []string{
+		"scraped_at",
+		"source_url",
+		"job_id",
+		"lang",
+		"scraper_version",
+	}
+}
+
+// CsvValues returns the CSV columns of p, see ProvenanceCsvHeaders.
+func (p *Provenance) CsvValues() []string {
+	var scrapedAt string
+	if !p.ScrapedAt.IsZero() {
+		scrapedAt = p.ScrapedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		scrapedAt,
+		p.SourceURL,
+		p.JobID,
+		p.Lang,
+		p.ScraperVersion,
+	}
+}
+
+// ProvenanceCSV is an entry whose CSV row ends with its provenance.
+type ProvenanceCSV struct {
+	*Entry
+}
+
+func (e ProvenanceCSV) CsvHeaders() []string {
+	return append(e.Entry.CsvHeaders(), ProvenanceCsvHeaders()...)
+}
+
+func (e ProvenanceCSV) CsvRow() []string {
+	return append(e.Entry.CsvRow(), e.Provenance.CsvValues()...)
+}
+
+// SelectorVersion identifies how the places are read from Google Maps: the
+// JSON paths of EntryFromJSON and the page selectors of the jobs. Bump it
+// with every change to them, so that a shift in the results of two jobs can
+// be traced to it.
+const SelectorVersion = "1"
+
+// ScraperVersion returns the version of the running binary and its commit,
+// or an empty string when the binary carries no build information.
+var ScraperVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	version := info.Main.Version
+
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			version += "-" + s.Value[:min(7, len(s.Value))]
+		}
+	}
+
+	return version
+})
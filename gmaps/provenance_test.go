@@ -0,0 +1,78 @@
+package gmaps
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceStamp(t *testing.T) {
+	before := time.Now().UTC()
+
+	p := Provenance{JobID: "job-1", ScraperVersion: "v1.2.3"}.stamp("https://www.google.com/maps/place/x", "de")
+
+	require.Equal(t, "job-1", p.JobID)
+	require.Equal(t, "v1.2.3", p.ScraperVersion)
+	require.Equal(t, "https://www.google.com/maps/place/x", p.SourceURL)
+	require.Equal(t, "de", p.Lang)
+	require.False(t, p.ScrapedAt.Before(before))
+	require.Equal(t, time.UTC, p.ScrapedAt.Location())
+}
+
+func TestEntryProvenanceJSON(t *testing.T) {
+	e := Entry{
+		Title: "Kipriakon",
+		Provenance: Provenance{
+			ScrapedAt:      time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			SourceURL:      "https://www.google.com/maps/place/x",
+			JobID:          "job-1",
+			Lang:           "en",
+			ScraperVersion: "v1.2.3",
+		},
+	}
+
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	require.Equal(t, "2026-03-01T10:00:00Z", fields["scraped_at"])
+	require.Equal(t, "https://www.google.com/maps/place/x", fields["source_url"])
+	require.Equal(t, "job-1", fields["job_id"])
+	require.Equal(t, "en", fields["lang"])
+	require.Equal(t, "v1.2.3", fields["scraper_version"])
+
+	var back Entry
+	require.NoError(t, json.Unmarshal(data, &back))
+	require.Equal(t, e.Provenance, back.Provenance)
+
+	// entries scraped before provenance existed carry no timestamp
+	data, err = json.Marshal(Entry{Title: "old"})
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "scraped_at")
+}
+
+func TestProvenanceCSV(t *testing.T) {
+	e := &Entry{
+		Title: "Kipriakon",
+		Provenance: Provenance{
+			ScrapedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			JobID:     "job-1",
+		},
+	}
+
+	row := ProvenanceCSV{Entry: e}
+	headers := row.CsvHeaders()
+	values := row.CsvRow()
+
+	require.Len(t, values, len(headers))
+	require.Equal(t, e.CsvHeaders(), headers[:len(e.CsvHeaders())])
+	require.Equal(t, ProvenanceCsvHeaders(), headers[len(e.CsvHeaders()):])
+	require.Equal(t, []string{"2026-03-01T10:00:00Z", "", "job-1", "", ""}, values[len(e.CsvRow()):])
+
+	// the plain entry keeps its columns
+	require.Len(t, e.CsvRow(), len(e.CsvHeaders()))
+}
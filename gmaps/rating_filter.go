@@ -0,0 +1,58 @@
+package gmaps
+
+import "github.com/PuerkitoBio/goquery"
+
+// MaxRating is the highest rating of a place.
+const MaxRating = 5
+
+// RatingFilter keeps the places within a rating and a review count, such as
+// the established businesses only or the brand-new ones only. Its methods
+// are safe on a nil filter, which keeps everything.
+type RatingFilter struct {
+	// MinRating is the lowest rating kept, MinReviews and MaxReviews the
+	// bounds of the review count. 0 means no bound.
+	MinRating  float64
+	MinReviews int
+	MaxReviews int
+}
+
+// NewRatingFilter returns the filter of the bounds, or nil when there are
+// none.
+func NewRatingFilter(minRating float64, minReviews, maxReviews int) *RatingFilter {
+	if minRating <= 0 && minReviews <= 0 && maxReviews <= 0 {
+		return nil
+	}
+
+	return &RatingFilter{MinRating: minRating, MinReviews: minReviews, MaxReviews: maxReviews}
+}
+
+// Keeps reports whether f keeps a place of rating with reviews reviews. A
+// place without reviews has no rating and is dropped by MinRating.
+func (f *RatingFilter) Keeps(rating float64, reviews int) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.MinRating > 0 && rating < f.MinRating {
+		return false
+	}
+
+	if f.MinReviews > 0 && reviews < f.MinReviews {
+		return false
+	}
+
+	return f.MaxReviews <= 0 || reviews <= f.MaxReviews
+}
+
+// keepsCard reports whether f keeps the place of the result card of the
+// link a, from the rating and review count it shows. A card showing none
+// is kept, the place page tells.
+func (f *RatingFilter) keepsCard(a *goquery.Selection) bool {
+	if f == nil {
+		return true
+	}
+
+	rating, reviews, ok := cardRating(a)
+
+	return !ok || f.Keeps(rating, reviews)
+}
@@ -0,0 +1,79 @@
+package gmaps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingFilterKeeps(t *testing.T) {
+	require.Nil(t, NewRatingFilter(0, 0, 0))
+
+	var none *RatingFilter
+	require.True(t, none.Keeps(1, 0))
+
+	f := NewRatingFilter(4, 10, 0)
+	require.True(t, f.Keeps(4.5, 120))
+	require.False(t, f.Keeps(3.9, 120))
+	require.False(t, f.Keeps(4.5, 9))
+	require.False(t, f.Keeps(0, 0))
+
+	f = NewRatingFilter(0, 0, 10)
+	require.True(t, f.Keeps(0, 0))
+	require.True(t, f.Keeps(5, 10))
+	require.False(t, f.Keeps(5, 11))
+}
+
+const ratedFeed = `<div role="feed">
+<div jsaction="a"><a href="https://www.google.com/maps/place/Trattoria+Roma/data=x" aria-label="Trattoria Roma"></a>
+<div><div>Trattoria Roma</div><div><span>4.5</span><span>(1,234)</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/Pizzeria+Nuova/data=x" aria-label="Pizzeria Nuova"></a>
+<div><div>Pizzeria Nuova</div><div><span>4,8</span><span>(7)</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/Bar+Sport/data=x" aria-label="Bar Sport"></a>
+<div><div>Bar Sport</div><div><span>3.2</span><span>(56)</span></div></div></div>
+<div jsaction="a"><a href="https://www.google.com/maps/place/New+Place/data=x" aria-label="New Place"></a>
+<div><div>New Place</div><div><span>No reviews</span></div></div></div>
+</div>`
+
+func TestCardRating(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(ratedFeed))
+	require.NoError(t, err)
+
+	links := doc.Find(`div[role=feed] div[jsaction]>a`)
+
+	rating, reviews, ok := cardRating(links.Eq(0))
+	require.True(t, ok)
+	require.InDelta(t, 4.5, rating, 0.001)
+	require.Equal(t, 1234, reviews)
+
+	rating, reviews, ok = cardRating(links.Eq(1))
+	require.True(t, ok)
+	require.InDelta(t, 4.8, rating, 0.001)
+	require.Equal(t, 7, reviews)
+
+	_, _, ok = cardRating(links.Eq(3))
+	require.False(t, ok)
+}
+
+func TestExclusionsSkipResultByRating(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(ratedFeed))
+	require.NoError(t, err)
+
+	x := (*Exclusions)(nil).FilterRatings(NewRatingFilter(4, 10, 0))
+
+	var skipped []string
+
+	doc.Find(`div[role=feed] div[jsaction]>a`).Each(func(_ int, s *goquery.Selection) {
+		if x.SkipResult(s) {
+			skipped = append(skipped, s.AttrOr("aria-label", ""))
+		}
+	})
+
+	require.Equal(t, []string{"Pizzeria Nuova", "Bar Sport"}, skipped)
+
+	require.True(t, x.Excludes(&Entry{Title: "New Place"}))
+	require.False(t, x.Excludes(&Entry{Title: "Trattoria Roma", ReviewRating: 4.5, ReviewCount: 1234}))
+	require.Zero(t, x.Len())
+}
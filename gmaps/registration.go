@@ -0,0 +1,571 @@
+package gmaps
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// DefaultVIESURL is the REST API of VIES, the VAT registry of the EU which
+// answers from the registries of the member states.
+const DefaultVIESURL = "https://ec.europa.eu/taxation_customs/vies/rest-api"
+
+const (
+	maxRegistrationCached = 10000
+	maxVIESResponse       = 1 << 20
+)
+
+// RegistrationInfo holds the company identifiers of a business, required
+// on the lists for B2B invoicing in the EU, see RegistrationEnricher.
+type RegistrationInfo struct {
+	// VATNumbers are the VAT identification numbers found, with their
+	// country prefix and without separators, like "DE123456789".
+	VATNumbers []string `json:"vat_numbers,omitempty"`
+	// RegistrationNumbers are the numbers in a company register, like
+	// "HRB 12345", "SIREN 123456789", "KvK 12345678", "REA MI-123456",
+	// "FN 123456a" or "CRN 01234567".
+	RegistrationNumbers []string `json:"registration_numbers,omitempty"`
+	// Source is the page they were read from, the imprint of the website
+	// when it has one.
+	Source string `json:"source,omitempty"`
+	// VAT is what a VAT registry knows about the first VAT number, when
+	// looked up.
+	VAT *VATRecord `json:"vat,omitempty"`
+}
+
+// VATRecord is the answer of a VATRegistry about a VAT number.
+type VATRecord struct {
+	Registry string `json:"registry"`
+	Valid    bool   `json:"valid"`
+	// Name and Address are those registered, when the registry discloses
+	// them.
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// VATRegistry looks VAT numbers up in a registry, like VIES or a national
+// one.
+type VATRegistry interface {
+	LookupVAT(ctx context.Context, vat string) (*VATRecord, error)
+}
+
+// VIES looks the EU VAT numbers up through the VIES REST API. The numbers
+// of other countries are not in VIES: their lookup returns nil.
+type VIES struct {
+	// URL defaults to DefaultVIESURL.
+	URL    string
+	Client *http.Client
+}
+
+// LookupVAT implements VATRegistry.
+func (v *VIES) LookupVAT(ctx context.Context, vat string) (*VATRecord, error) {
+	if len(vat) < 3 || !slices.Contains(viesCountries, vat[:2]) {
+		return nil, nil
+	}
+
+	base := v.URL
+	if base == "" {
+		base = DefaultVIESURL
+	}
+
+	u := strings.TrimRight(base, "/") + "/ms/" + vat[:2] + "/vat/" + url.PathEscape(vat[2:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vies: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vies: HTTP %d", resp.StatusCode)
+	}
+
+	var ans struct {
+		IsValid   bool   `json:"isValid"`
+		UserError string `json:"userError"`
+		Name      string `json:"name"`
+		Address   string `json:"address"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVIESResponse)).Decode(&ans); err != nil {
+		return nil, fmt.Errorf("vies: %w", err)
+	}
+
+	// the registry of the member state could not be asked
+	if !ans.IsValid && ans.UserError != "" && ans.UserError != "VALID" && ans.UserError != "INVALID" {
+		return nil, fmt.Errorf("vies: %s", ans.UserError)
+	}
+
+	record := VATRecord{Registry: "vies", Valid: ans.IsValid}
+
+	// "---" stands for the data a member state does not disclose
+	if name := strings.TrimSpace(ans.Name); name != "---" {
+		record.Name = name
+	}
+
+	if address := strings.Join(strings.Fields(ans.Address), " "); address != "---" {
+		record.Address = address
+	}
+
+	return &record, nil
+}
+
+// viesCountries are the VAT prefixes of VIES: the EU member states and
+// Northern Ireland.
+var viesCountries = []string{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "EL", "ES", "FI", "FR", "HR", "HU",
+	"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK", "XI",
+}
+
+// vatFormats are the formats of the VAT numbers by prefix, after it.
+var vatFormats = map[string]*regexp.Regexp{
+	"AT": regexp.MustCompile(`^U\d{8}`),
+	"BE": regexp.MustCompile(`^[01]\d{9}`),
+	"BG": regexp.MustCompile(`^\d{9,10}`),
+	"CY": regexp.MustCompile(`^\d{8}[A-Z]`),
+	"CZ": regexp.MustCompile(`^\d{8,10}`),
+	"DE": regexp.MustCompile(`^\d{9}`),
+	"DK": regexp.MustCompile(`^\d{8}`),
+	"EE": regexp.MustCompile(`^\d{9}`),
+	"EL": regexp.MustCompile(`^\d{9}`),
+	"ES": regexp.MustCompile(`^[A-Z0-9]\d{7}[A-Z0-9]`),
+	"FI": regexp.MustCompile(`^\d{8}`),
+	"FR": regexp.MustCompile(`^[A-HJ-NP-Z0-9]{2}\d{9}`),
+	"GB": regexp.MustCompile(`^\d{9}(\d{3})?`),
+	"HR": regexp.MustCompile(`^\d{11}`),
+	"HU": regexp.MustCompile(`^\d{8}`),
+	"IE": regexp.MustCompile(`^\d[A-Z0-9+*]\d{5}[A-Z]{1,2}`),
+	"IT": regexp.MustCompile(`^\d{11}`),
+	"LT": regexp.MustCompile(`^\d{9}(\d{3})?`),
+	"LU": regexp.MustCompile(`^\d{8}`),
+	"LV": regexp.MustCompile(`^\d{11}`),
+	"MT": regexp.MustCompile(`^\d{8}`),
+	"NL": regexp.MustCompile(`^\d{9}B\d{2}`),
+	"PL": regexp.MustCompile(`^\d{10}`),
+	"PT": regexp.MustCompile(`^\d{9}`),
+	"RO": regexp.MustCompile(`^\d{2,10}`),
+	"SE": regexp.MustCompile(`^\d{12}`),
+	"SI": regexp.MustCompile(`^\d{8}`),
+	"SK": regexp.MustCompile(`^\d{10}`),
+	"XI": regexp.MustCompile(`^\d{9}(\d{3})?`),
+}
+
+var (
+	// vatPrefixedRe finds the VAT numbers written with their prefix, their
+	// groups of digits possibly apart.
+	vatPrefixedRe = regexp.MustCompile(`\b(AT|BE|BG|CY|CZ|DE|DK|EE|EL|ES|FI|FR|GB|HR|HU|IE|IT|LT|LU|LV|MT|NL|PL|PT|RO|SE|SI|SK|XI)[ .\-]?([0-9A-Z][0-9A-Z .\-+*]{6,18})`)
+	// vatLabeledRe finds the VAT numbers written without prefix after their
+	// label, like the Italian "P.IVA 01234567890".
+	vatLabeledRe    = regexp.MustCompile(`(?i)(?:p\.\s?iva|partita\s+iva|ust-?\s?id(?:nr)?\.?|umsatzsteuer-?\s?id(?:entifikationsnummer)?|vat(?:\s+(?:id|no|number|reg(?:istration)?(?:\s+(?:no|number))?))?\.?|n°\s*tva|tva\s+intracommunautaire|btw(?:-?nummer|-?nr)?|nif|cif)\s*[:.#]?\s*([0-9][0-9 .\-]{6,16}[0-9])`)
+	vatSeparatorsRe = regexp.MustCompile(`[ .\-]`)
+
+	registrationRes = []struct {
+		re     *regexp.Regexp
+		format func(m []string) string
+	}{
+		{ // German commercial register
+			regexp.MustCompile(`\b(HR[AB])\s*(\d{1,6})(?:\s?([A-Z]{1,2})\b)?`),
+			func(m []string) string { return strings.TrimSpace(m[1] + " " + m[2] + " " + m[3]) },
+		},
+		{ // Austrian companies register
+			regexp.MustCompile(`\bFN\s*(\d{1,6}\s?[a-z])\b`),
+			func(m []string) string { return "FN " + strings.ReplaceAll(m[1], " ", "") },
+		},
+		{ // French SIREN and SIRET
+			regexp.MustCompile(`(?i)\b(SIRE[NT])\s*(?:n°|no|:)?\s*:?\s*(\d{3}\s?\d{3}\s?\d{3}(?:\s?\d{5})?)\b`),
+			func(m []string) string { return strings.ToUpper(m[1]) + " " + strings.ReplaceAll(m[2], " ", "") },
+		},
+		{ // French trade register, by SIREN
+			regexp.MustCompile(`\bRCS\s+[\p{L}\- ]{2,40}?\s(?:[AB]\s)?(\d{3}\s?\d{3}\s?\d{3})\b`),
+			func(m []string) string { return "SIREN " + strings.ReplaceAll(m[1], " ", "") },
+		},
+		{ // Dutch chamber of commerce
+			regexp.MustCompile(`(?i)\b(?:kvk|kamer van koophandel)(?:-?nummer|-?nr\.?)?\s*[:.]?\s*(\d{8})\b`),
+			func(m []string) string { return "KvK " + m[1] },
+		},
+		{ // Italian economic and administrative index
+			regexp.MustCompile(`\bREA\s*(?:n\.?|n°|:)?\s*:?\s*([A-Z]{2})\s*[-–]?\s*(\d{4,7})\b`),
+			func(m []string) string { return "REA " + m[1] + "-" + m[2] },
+		},
+		{ // UK Companies House
+			regexp.MustCompile(`(?i)\bcompany\s+(?:registration\s+)?(?:no|number)\.?\s*[:.]?\s*((?:SC|NI|OC)?\d{6,8})\b`),
+			func(m []string) string { return "CRN " + strings.ToUpper(m[1]) },
+		},
+	}
+
+	// imprintKeywords name the imprint pages, most telling first.
+	imprintKeywords = []string{
+		"impressum", "imprint", "legal-notice", "legal notice", "mentions-legales",
+		"mentions légales", "mentions legales", "note-legali", "note legali",
+		"informazioni-legali", "aviso-legal", "aviso legal", "colofon", "legal",
+	}
+)
+
+// vatCountryPrefix is the VAT prefix of the places of an ISO country code.
+func vatCountryPrefix(country string) string {
+	country = strings.ToUpper(country)
+	if country == "GR" {
+		return "EL"
+	}
+
+	if _, ok := vatFormats[country]; ok {
+		return country
+	}
+
+	return ""
+}
+
+// parseVAT returns the VAT number of prefix starting compact, "" when it
+// does not have the format of prefix.
+func parseVAT(prefix, compact string) string {
+	format, ok := vatFormats[prefix]
+	if !ok {
+		return ""
+	}
+
+	m := format.FindString(compact)
+	if m == "" {
+		return ""
+	}
+
+	// a longer number is something else
+	if rest := compact[len(m):]; rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+		return ""
+	}
+
+	return prefix + m
+}
+
+// ExtractRegistrationInfo reads the VAT and the company register numbers
+// in text, the numbers written without their VAT prefix taking the one of
+// country, the ISO code of the country of the place. It returns nil when
+// there are none.
+func ExtractRegistrationInfo(text, country string) *RegistrationInfo {
+	var info RegistrationInfo
+
+	addVAT := func(vat string) {
+		if vat != "" && !slices.Contains(info.VATNumbers, vat) {
+			info.VATNumbers = append(info.VATNumbers, vat)
+		}
+	}
+
+	for _, m := range vatPrefixedRe.FindAllStringSubmatch(text, -1) {
+		addVAT(parseVAT(m[1], vatSeparatorsRe.ReplaceAllString(m[2], "")))
+	}
+
+	if prefix := vatCountryPrefix(country); prefix != "" {
+		for _, m := range vatLabeledRe.FindAllStringSubmatch(text, -1) {
+			addVAT(parseVAT(prefix, vatSeparatorsRe.ReplaceAllString(m[1], "")))
+		}
+	}
+
+	for _, r := range registrationRes {
+		for _, m := range r.re.FindAllStringSubmatch(text, -1) {
+			if number := r.format(m); !slices.Contains(info.RegistrationNumbers, number) {
+				info.RegistrationNumbers = append(info.RegistrationNumbers, number)
+			}
+		}
+	}
+
+	if len(info.VATNumbers) == 0 && len(info.RegistrationNumbers) == 0 {
+		return nil
+	}
+
+	return &info
+}
+
+// RegistrationEnricher reads the VAT and the company register numbers of a
+// place from its website: the homepage, whose footer often has them, and
+// the imprint (Impressum, mentions légales, note legali) it links to. With
+// a VATRegistry the first VAT number is then looked up, to tell whether it
+// is valid and under which name it is registered. The websites shared by
+// the places of a chain are read once.
+type RegistrationEnricher struct {
+	client   *http.Client
+	registry VATRegistry
+
+	cacheMu sync.Mutex
+	cache   map[string]*RegistrationInfo
+}
+
+// RegistrationEnricherOption configures a RegistrationEnricher.
+type RegistrationEnricherOption func(*RegistrationEnricher)
+
+// WithRegistrationRegistry looks the VAT numbers found up in r, like VIES.
+func WithRegistrationRegistry(r VATRegistry) RegistrationEnricherOption {
+	return func(re *RegistrationEnricher) {
+		re.registry = r
+	}
+}
+
+// WithRegistrationClient sets the HTTP client fetching the websites.
+func WithRegistrationClient(client *http.Client) RegistrationEnricherOption {
+	return func(re *RegistrationEnricher) {
+		re.client = client
+	}
+}
+
+// NewRegistrationEnricher creates a RegistrationEnricher.
+func NewRegistrationEnricher(opts ...RegistrationEnricherOption) *RegistrationEnricher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // scraper must handle sites with bad certs
+	}
+
+	re := RegistrationEnricher{
+		client: &http.Client{Timeout: httpTimeout, Transport: transport},
+		cache:  map[string]*RegistrationInfo{},
+	}
+
+	for _, opt := range opts {
+		opt(&re)
+	}
+
+	return &re
+}
+
+// Enrich sets e.RegistrationInfo, implementing Enricher. The places
+// without a website, or whose website has none of the numbers, are left
+// as they are.
+func (re *RegistrationEnricher) Enrich(ctx context.Context, e *Entry) error {
+	if e.WebSite == "" {
+		return nil
+	}
+
+	key := e.WebSite + "|" + e.CompleteAddress.Country
+
+	re.cacheMu.Lock()
+	info, ok := re.cache[key]
+	re.cacheMu.Unlock()
+
+	if !ok {
+		var err error
+
+		info, err = re.lookup(ctx, e)
+		if err != nil {
+			return err
+		}
+
+		re.cacheMu.Lock()
+		if len(re.cache) >= maxRegistrationCached {
+			re.cache = map[string]*RegistrationInfo{}
+		}
+
+		re.cache[key] = info
+		re.cacheMu.Unlock()
+	}
+
+	if info != nil {
+		cp := *info
+		e.RegistrationInfo = &cp
+	}
+
+	return nil
+}
+
+func (re *RegistrationEnricher) lookup(ctx context.Context, e *Entry) (*RegistrationInfo, error) {
+	homepage := sanitizeURL(e.WebSite)
+
+	body, err := re.fetch(ctx, homepage)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	info := ExtractRegistrationInfo(pageText(doc), e.CompleteAddress.Country)
+	if info != nil {
+		info.Source = homepage
+	}
+
+	// the imprint is the page the law asks the numbers on
+	if imprint := findImprintLink(doc, homepage); imprint != "" {
+		if body, err := re.fetch(ctx, imprint); err == nil {
+			if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body)); err == nil {
+				if found := ExtractRegistrationInfo(pageText(doc), e.CompleteAddress.Country); found != nil {
+					info = mergeRegistrationInfo(found, info)
+					info.Source = imprint
+				}
+			}
+		}
+	}
+
+	if info == nil {
+		return nil, nil
+	}
+
+	if re.registry != nil && len(info.VATNumbers) > 0 {
+		if record, err := re.registry.LookupVAT(ctx, info.VATNumbers[0]); err == nil {
+			info.VAT = record
+		}
+	}
+
+	return info, nil
+}
+
+// mergeRegistrationInfo adds to a the numbers of b it misses.
+func mergeRegistrationInfo(a, b *RegistrationInfo) *RegistrationInfo {
+	if b == nil {
+		return a
+	}
+
+	for _, vat := range b.VATNumbers {
+		if !slices.Contains(a.VATNumbers, vat) {
+			a.VATNumbers = append(a.VATNumbers, vat)
+		}
+	}
+
+	for _, number := range b.RegistrationNumbers {
+		if !slices.Contains(a.RegistrationNumbers, number) {
+			a.RegistrationNumbers = append(a.RegistrationNumbers, number)
+		}
+	}
+
+	return a
+}
+
+func (re *RegistrationEnricher) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", acceptHeader)
+
+	resp, err := re.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%s: HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTMLContentType(contentType) {
+		return nil, fmt.Errorf("%w (%s)", errNonHTMLContent, contentType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return toUTF8(body, contentType), nil
+}
+
+// findImprintLink returns the link of the imprint among those of the page
+// at base, on the same website, or "" when there is none.
+func findImprintLink(doc *goquery.Document, base string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(baseURL.Hostname(), "www.")
+
+	best, bestRank := "", len(imprintKeywords)
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+
+		u, err := baseURL.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+
+		if !isSameOrSubdomain(strings.TrimPrefix(u.Hostname(), "www."), host) {
+			return
+		}
+
+		u.Fragment = ""
+		if u.String() == baseURL.String() {
+			return
+		}
+
+		label := strings.ToLower(u.Path + " " + strings.TrimSpace(s.Text()))
+
+		for rank, kw := range imprintKeywords[:bestRank] {
+			if strings.Contains(label, kw) {
+				best, bestRank = u.String(), rank
+
+				break
+			}
+		}
+	})
+
+	return best
+}
+
+// pageText returns the text of the page, that of each element apart from
+// the next one, without the scripts and the styles.
+func pageText(doc *goquery.Document) string {
+	var sb strings.Builder
+
+	var walk func(n *html.Node)
+
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "noscript"):
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for _, n := range doc.Nodes {
+		walk(n)
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// registrationCsvValues returns the registration fields of e for its CSV
+// row.
+func (e *Entry) registrationCsvValues() []string {
+	info := e.RegistrationInfo
+	if info == nil {
+		return []string{"", "", ""}
+	}
+
+	valid := ""
+	if info.VAT != nil {
+		valid = stringify(info.VAT.Valid)
+	}
+
+	return []string{stringSliceToString(info.VATNumbers), stringSliceToString(info.RegistrationNumbers), valid}
+}
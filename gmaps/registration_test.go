@@ -0,0 +1,213 @@
+package gmaps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractRegistrationInfo(t *testing.T) {
+	tests := []struct {
+		name, text, country string
+		vat, registration   []string
+	}{
+		{
+			name:         "impressum",
+			text:         "Musterfirma GmbH Amtsgericht München HRB 123456 USt-IdNr.: DE 123 456 789 Telefon 089 1234567",
+			country:      "DE",
+			vat:          []string{"DE123456789"},
+			registration: []string{"HRB 123456"},
+		},
+		{
+			name:    "italian footer without prefix",
+			text:    "© 2024 Pizzeria Da Mario - P.IVA 01234567890 - REA MI-1234567",
+			country: "IT",
+			vat:     []string{"IT01234567890"},
+			registration: []string{
+				"REA MI-1234567",
+			},
+		},
+		{
+			name:         "mentions légales",
+			text:         "SARL Le Bistrot, RCS Paris 123 456 789, SIRET : 123 456 789 00012, N° TVA intracommunautaire : FR 12 123456789",
+			country:      "FR",
+			vat:          []string{"FR12123456789"},
+			registration: []string{"SIRET 12345678900012", "SIREN 123456789"},
+		},
+		{
+			name:         "dutch",
+			text:         "KvK-nummer: 12345678 BTW-nummer: NL123456789B01",
+			country:      "NL",
+			vat:          []string{"NL123456789B01"},
+			registration: []string{"KvK 12345678"},
+		},
+		{
+			name:         "uk",
+			text:         "Registered in England, company number 01234567. VAT No. GB 123 4567 89",
+			country:      "GB",
+			vat:          []string{"GB123456789"},
+			registration: []string{"CRN 01234567"},
+		},
+		{
+			name:    "greek prefix and austrian register",
+			text:    "ΑΦΜ EL123456789 FN 123456a",
+			country: "GR",
+			vat:     []string{"EL123456789"},
+			registration: []string{
+				"FN 123456a",
+			},
+		},
+		{
+			name:    "too many digits",
+			text:    "Call IT 012345678901234 or DE1234567890",
+			country: "IT",
+		},
+		{
+			name:    "label without the country of the place",
+			text:    "VAT number 123456789",
+			country: "US",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ExtractRegistrationInfo(tt.text, tt.country)
+			if tt.vat == nil && tt.registration == nil {
+				require.Nil(t, info)
+
+				return
+			}
+
+			require.NotNil(t, info)
+			require.Equal(t, tt.vat, info.VATNumbers)
+			require.Equal(t, tt.registration, info.RegistrationNumbers)
+		})
+	}
+}
+
+func TestVIES(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ms/DE/vat/123456789":
+			_, _ = w.Write([]byte(`{"isValid":true,"userError":"VALID","name":"---","address":"---"}`))
+		case "/ms/IT/vat/01234567890":
+			_, _ = w.Write([]byte(`{"isValid":true,"userError":"VALID","name":"PIZZERIA DA MARIO SRL","address":"VIA ROMA 1 \n20121 MILANO MI"}`))
+		case "/ms/FR/vat/12123456789":
+			_, _ = w.Write([]byte(`{"isValid":false,"userError":"MS_UNAVAILABLE"}`))
+		default:
+			_, _ = w.Write([]byte(`{"isValid":false,"userError":"INVALID"}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := &VIES{URL: srv.URL}
+
+	record, err := v.LookupVAT(context.Background(), "IT01234567890")
+	require.NoError(t, err)
+	require.Equal(t, &VATRecord{Registry: "vies", Valid: true, Name: "PIZZERIA DA MARIO SRL", Address: "VIA ROMA 1 20121 MILANO MI"}, record)
+
+	record, err = v.LookupVAT(context.Background(), "DE123456789")
+	require.NoError(t, err)
+	require.Equal(t, &VATRecord{Registry: "vies", Valid: true}, record)
+
+	record, err = v.LookupVAT(context.Background(), "DE999999999")
+	require.NoError(t, err)
+	require.False(t, record.Valid)
+
+	_, err = v.LookupVAT(context.Background(), "FR12123456789")
+	require.Error(t, err)
+
+	record, err = v.LookupVAT(context.Background(), "GB123456789")
+	require.NoError(t, err)
+	require.Nil(t, record)
+}
+
+type fakeVATRegistry struct{}
+
+func (fakeVATRegistry) LookupVAT(_ context.Context, vat string) (*VATRecord, error) {
+	return &VATRecord{Registry: "fake", Valid: vat == "DE123456789"}, nil
+}
+
+func TestRegistrationEnricher(t *testing.T) {
+	var requests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<html><body><p>Willkommen</p><script>var x = "DE111111111";</script>
+<footer><a href="/datenschutz">Datenschutz</a> <a href="/impressum">Impressum</a> <a href="https://other.example.org/impressum">Impressum</a> <a href="/kontakt">Kontakt</a></footer></body></html>`))
+	})
+	mux.HandleFunc("/impressum", func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		_, _ = w.Write([]byte(`<html><body><h1>Impressum</h1><p>Registergericht: Amtsgericht Berlin</p><p>HRB 98765 B</p><p>USt-IdNr.:</p><p>DE123456789</p></body></html>`))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	re := NewRegistrationEnricher(WithRegistrationRegistry(fakeVATRegistry{}))
+
+	e := Entry{WebSite: srv.URL, CompleteAddress: Address{Country: "DE"}}
+	require.NoError(t, re.Enrich(context.Background(), &e))
+	require.NotNil(t, e.RegistrationInfo)
+	require.Equal(t, []string{"DE123456789"}, e.RegistrationInfo.VATNumbers)
+	require.Equal(t, []string{"HRB 98765 B"}, e.RegistrationInfo.RegistrationNumbers)
+	require.Equal(t, srv.URL+"/impressum", e.RegistrationInfo.Source)
+	require.True(t, e.RegistrationInfo.VAT.Valid)
+
+	require.Equal(t, []string{"DE123456789", "HRB 98765 B", "true"}, e.registrationCsvValues())
+
+	// the other places of the website come from the cache
+	other := Entry{WebSite: srv.URL, CompleteAddress: Address{Country: "DE"}}
+	entries, err := Enrichers{re}.Process(context.Background(), []*Entry{&other, {Title: "no website"}})
+	require.NoError(t, err)
+	require.Equal(t, e.RegistrationInfo, other.RegistrationInfo)
+	require.Nil(t, entries[1].RegistrationInfo)
+	require.EqualValues(t, 2, requests.Load())
+
+	down := Entry{WebSite: srv.URL + "/missing"}
+	require.Error(t, re.Enrich(context.Background(), &down))
+	require.Nil(t, down.RegistrationInfo)
+}
+
+func TestFindImprintLink(t *testing.T) {
+	tests := []struct {
+		page, want string
+	}{
+		{`<a href="/legal">Legal</a><a href="/mentions-legales">Mentions légales</a>`, "https://example.com/mentions-legales"},
+		{`<a href="/pagina?id=4">Note legali</a>`, "https://example.com/pagina?id=4"},
+		{`<a href="https://other.com/impressum">Impressum</a><a href="mailto:a@example.com">Imprint</a>`, ""},
+		{`<a href="#impressum">Impressum</a>`, ""},
+	}
+
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+		require.NoError(t, err)
+		require.Equal(t, tt.want, findImprintLink(doc, "https://example.com/"), tt.page)
+	}
+}
+
+func TestEntryRegistrationCsv(t *testing.T) {
+	e := Entry{}
+	require.Equal(t, "", csvValue(t, &e, "vat_numbers"))
+	require.Equal(t, "", csvValue(t, &e, "vat_valid"))
+
+	e.RegistrationInfo = &RegistrationInfo{VATNumbers: []string{"IT01234567890"}, RegistrationNumbers: []string{"REA MI-123456"}}
+	require.Equal(t, "IT01234567890", csvValue(t, &e, "vat_numbers"))
+	require.Equal(t, "REA MI-123456", csvValue(t, &e, "registration_numbers"))
+	require.Equal(t, "", csvValue(t, &e, "vat_valid"))
+}
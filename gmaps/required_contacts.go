@@ -0,0 +1,27 @@
+package gmaps
+
+import "strings"
+
+// RequiredContacts are the contacts a place must list to be kept, so that
+// the email campaigns spend no time on the places that can never yield an
+// address. The zero value requires none.
+type RequiredContacts struct {
+	// Website drops the places without a website.
+	Website bool
+	// Phone drops the places without a phone number.
+	Phone bool
+}
+
+// Any tells whether r requires something.
+func (r RequiredContacts) Any() bool {
+	return r.Website || r.Phone
+}
+
+// Keeps reports whether e lists the contacts r requires.
+func (r RequiredContacts) Keeps(e *Entry) bool {
+	if r.Website && strings.TrimSpace(e.WebSite) == "" {
+		return false
+	}
+
+	return !r.Phone || strings.TrimSpace(e.Phone) != ""
+}
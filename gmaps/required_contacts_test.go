@@ -0,0 +1,29 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredContactsKeeps(t *testing.T) {
+	require.False(t, RequiredContacts{}.Any())
+	require.True(t, RequiredContacts{}.Keeps(&Entry{}))
+
+	r := RequiredContacts{Website: true}
+	require.True(t, r.Keeps(&Entry{WebSite: "https://example.com"}))
+	require.False(t, r.Keeps(&Entry{Phone: "+39 02 1234567"}))
+
+	r = RequiredContacts{Website: true, Phone: true}
+	require.False(t, r.Keeps(&Entry{WebSite: "https://example.com", Phone: " "}))
+	require.True(t, r.Keeps(&Entry{WebSite: "https://example.com", Phone: "+39 02 1234567"}))
+}
+
+func TestExclusionsRequire(t *testing.T) {
+	require.Nil(t, (*Exclusions)(nil).Require(RequiredContacts{}))
+
+	x := (*Exclusions)(nil).Require(RequiredContacts{Website: true})
+	require.True(t, x.Excludes(&Entry{Title: "Bar Sport"}))
+	require.False(t, x.Excludes(&Entry{Title: "Trattoria Roma", WebSite: "https://trattoria.example"}))
+	require.Zero(t, x.Len())
+}
@@ -0,0 +1,97 @@
+package gmaps
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// cardSeparator splits the lines of a result card, like
+// "Italian restaurant · Via Roma 1".
+const cardSeparator = "·"
+
+var (
+	// cardRatingRe is the rating of a result card, like "4.5" or "4,5".
+	cardRatingRe = regexp.MustCompile(`^([0-5])[.,](\d)$`)
+	// cardReviewsRe is its review count, like "(1,234)".
+	cardReviewsRe = regexp.MustCompile(`^\((\d[\d.,\s]*)\)$`)
+)
+
+// cardLeaves returns the texts of the elements of the result card of the
+// link a holding no other element, trimmed.
+func cardLeaves(a *goquery.Selection) []string {
+	var ans []string
+
+	a.Parent().Find("*").Each(func(_ int, s *goquery.Selection) {
+		if s.Children().Length() > 0 {
+			return
+		}
+
+		if text := strings.TrimSpace(s.Text()); text != "" {
+			ans = append(ans, text)
+		}
+	})
+
+	return ans
+}
+
+// cardTexts returns the texts of the result card of the link a, split on
+// the separators, without the name of the place and the texts holding a
+// digit, such as the rating, the price and the address.
+func cardTexts(a *goquery.Selection) []string {
+	name := strings.TrimSpace(a.AttrOr("aria-label", ""))
+
+	var ans []string
+
+	for _, leaf := range cardLeaves(a) {
+		for _, text := range strings.Split(leaf, cardSeparator) {
+			text = strings.TrimSpace(text)
+
+			if text == "" || text == name || strings.ContainsAny(text, "0123456789") {
+				continue
+			}
+
+			ans = append(ans, text)
+		}
+	}
+
+	return ans
+}
+
+// cardRating returns the rating and the review count shown by the result
+// card of the link a. ok is false when the card shows none, as for the
+// places without reviews, or when they cannot be read.
+func cardRating(a *goquery.Selection) (rating float64, reviews int, ok bool) {
+	var hasRating, hasReviews bool
+
+	for _, leaf := range cardLeaves(a) {
+		if m := cardRatingRe.FindStringSubmatch(leaf); m != nil && !hasRating {
+			rating, _ = strconv.ParseFloat(m[1]+"."+m[2], 64)
+			hasRating = true
+
+			continue
+		}
+
+		if m := cardReviewsRe.FindStringSubmatch(leaf); m != nil && !hasReviews {
+			digits := strings.Map(func(r rune) rune {
+				if r >= '0' && r <= '9' {
+					return r
+				}
+
+				return -1
+			}, m[1])
+
+			n, err := strconv.Atoi(digits)
+			if err != nil {
+				continue
+			}
+
+			reviews = n
+			hasReviews = true
+		}
+	}
+
+	return rating, reviews, hasRating && hasReviews
+}
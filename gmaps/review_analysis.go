@@ -0,0 +1,115 @@
+package gmaps
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// ReviewAnalysis measures how the owner of a place engages with its
+// reviews, over the reviews collected, see Entry.AnalyzeReviews.
+type ReviewAnalysis struct {
+	// Reviews counts the reviews analyzed, Replied those the owner
+	// answered.
+	Reviews int `json:"reviews"`
+	Replied int `json:"replied"`
+	// ReplyRate is the percentage of the reviews with an owner reply.
+	ReplyRate float64 `json:"reply_rate"`
+	// MedianReplyDelayHours is the median time from a review to its reply,
+	// over the replies whose dates are known. It is nil when none is.
+	MedianReplyDelayHours *float64 `json:"median_reply_delay_hours,omitempty"`
+}
+
+// AnalyzeReviews sets the ReviewAnalysis of e from its reviews, leaving it
+// nil without any. The reviews read from the page instead of the Google
+// Maps data (Review.Source empty) do not tell their replies and are left
+// out, as are the repeated ones.
+func (e *Entry) AnalyzeReviews() {
+	seen := map[string]bool{}
+
+	var (
+		ans    ReviewAnalysis
+		delays []float64
+	)
+
+	for _, reviews := range [][]Review{e.UserReviews, e.UserReviewsExtended} {
+		for i := range reviews {
+			r := &reviews[i]
+
+			if r.Source == "" {
+				continue
+			}
+
+			if r.ReviewID != "" {
+				if seen[r.ReviewID] {
+					continue
+				}
+
+				seen[r.ReviewID] = true
+			}
+
+			ans.Reviews++
+
+			if !r.hasReply() {
+				continue
+			}
+
+			ans.Replied++
+
+			if r.PostedAtUnixMicros > 0 && r.ReplyPostedAtUnixMicros >= r.PostedAtUnixMicros {
+				delay := time.Duration(r.ReplyPostedAtUnixMicros-r.PostedAtUnixMicros) * time.Microsecond
+				delays = append(delays, delay.Hours())
+			}
+		}
+	}
+
+	if ans.Reviews == 0 {
+		e.ReviewAnalysis = nil
+
+		return
+	}
+
+	ans.ReplyRate = roundTenth(float64(ans.Replied) * 100 / float64(ans.Reviews))
+
+	if len(delays) > 0 {
+		median := roundTenth(medianOf(delays))
+		ans.MedianReplyDelayHours = &median
+	}
+
+	e.ReviewAnalysis = &ans
+}
+
+func (r *Review) hasReply() bool {
+	return r.ReplyText != "" || r.ReplyTextOriginal != "" || r.ReplyPostedAtUnixMicros > 0
+}
+
+// medianOf returns the median of values, which it sorts.
+func medianOf(values []float64) float64 {
+	slices.Sort(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+
+	return (values[mid-1] + values[mid]) / 2
+}
+
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// reviewAnalysisCsvValues returns the reply rate and the median reply delay
+// of e for its CSV row, empty when unknown.
+func (e *Entry) reviewAnalysisCsvValues() []string {
+	if e.ReviewAnalysis == nil {
+		return []string{"", ""}
+	}
+
+	delay := ""
+	if d := e.ReviewAnalysis.MedianReplyDelayHours; d != nil {
+		delay = stringify(*d)
+	}
+
+	return []string{stringify(e.ReviewAnalysis.ReplyRate), delay}
+}
@@ -0,0 +1,96 @@
+package gmaps
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func reviewAt(id string, posted time.Time, replied time.Duration) Review {
+	r := Review{ReviewID: id, Source: "Google", PostedAtUnixMicros: posted.UnixMicro()}
+
+	if replied > 0 {
+		r.ReplyText = "Thank you!"
+		r.ReplyPostedAtUnixMicros = posted.Add(replied).UnixMicro()
+	}
+
+	return r
+}
+
+func TestAnalyzeReviews(t *testing.T) {
+	posted := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	e := Entry{
+		UserReviews: []Review{
+			reviewAt("a", posted, 2*time.Hour),
+			reviewAt("b", posted, 0),
+		},
+		UserReviewsExtended: []Review{
+			// repeated from UserReviews
+			reviewAt("a", posted, 2*time.Hour),
+			reviewAt("c", posted, 10*time.Hour),
+			reviewAt("d", posted, 0),
+			// read from the page, without replies
+			{Name: "Maria", Description: "Great"},
+		},
+	}
+
+	e.AnalyzeReviews()
+
+	require.NotNil(t, e.ReviewAnalysis)
+	require.Equal(t, 4, e.ReviewAnalysis.Reviews)
+	require.Equal(t, 2, e.ReviewAnalysis.Replied)
+	require.InDelta(t, 50.0, e.ReviewAnalysis.ReplyRate, 0.001)
+	require.NotNil(t, e.ReviewAnalysis.MedianReplyDelayHours)
+	require.InDelta(t, 6.0, *e.ReviewAnalysis.MedianReplyDelayHours, 0.001)
+
+	require.Equal(t, "50.000000", csvValue(t, &e, "review_reply_rate"))
+	require.Equal(t, "6.000000", csvValue(t, &e, "review_median_reply_hours"))
+}
+
+func TestAnalyzeReviewsReplyWithoutDate(t *testing.T) {
+	e := Entry{
+		UserReviews: []Review{
+			{ReviewID: "a", Source: "Google", ReplyText: "Thanks"},
+			{ReviewID: "b", Source: "Google"},
+			{ReviewID: "c", Source: "Google"},
+		},
+	}
+
+	e.AnalyzeReviews()
+
+	require.Equal(t, 1, e.ReviewAnalysis.Replied)
+	require.InDelta(t, 33.3, e.ReviewAnalysis.ReplyRate, 0.001)
+	require.Nil(t, e.ReviewAnalysis.MedianReplyDelayHours)
+
+	require.Empty(t, csvValue(t, &e, "review_median_reply_hours"))
+}
+
+func TestAnalyzeReviewsNone(t *testing.T) {
+	e := Entry{
+		UserReviewsExtended: []Review{{Name: "Maria", Description: "Great"}},
+		ReviewAnalysis:      &ReviewAnalysis{Reviews: 3},
+	}
+
+	e.AnalyzeReviews()
+
+	require.Nil(t, e.ReviewAnalysis)
+
+	require.Empty(t, csvValue(t, &e, "review_reply_rate"))
+	require.Empty(t, csvValue(t, &e, "review_median_reply_hours"))
+}
+
+// csvValue returns the value of column in the CSV row of e.
+func csvValue(t *testing.T, e *Entry, column string) string {
+	t.Helper()
+
+	headers, row := e.CsvHeaders(), e.CsvRow()
+	require.Len(t, row, len(headers))
+
+	i := slices.Index(headers, column)
+	require.GreaterOrEqual(t, i, 0, column)
+
+	return row[i]
+}
@@ -22,6 +22,7 @@ type fetchReviewsParams struct {
 	page        scrapemate.BrowserPage
 	mapURL      string
 	reviewCount int
+	fetchStats  *FetchStats
 }
 
 type FetchReviewsResponse struct {
@@ -275,6 +276,9 @@ func (f *fetcher) fetchReviewPage(ctx context.Context, u string) ([]byte, error)
 		return nil, fmt.Errorf("%s: unexpected status code: %d", u, resp.StatusCode)
 	}
 
+	// the stealth client connects directly, the browser proxy is not used
+	f.params.fetchStats.RecordBytes(FetchSourceMaps, proxyLabel(nil), int64(len(resp.Body)))
+
 	return resp.Body, nil
 }
 
@@ -0,0 +1,205 @@
+package gmaps
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// routeLinkMaxWaypoints is how many waypoints, between the origin and the
+// destination, Google Maps takes in a directions link.
+const routeLinkMaxWaypoints = 9
+
+// RoutePoint is where a route starts, like the office of a field rep.
+type RoutePoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// RouteStop is a place of a route planned by PlanRoute.
+type RouteStop struct {
+	// Index is that of the place in the entries given to PlanRoute.
+	Index int
+	Entry *Entry
+	// Distance is the straight line distance in meters from the previous
+	// stop, or from the start.
+	Distance float64
+}
+
+// PlanRoute orders entries into a visiting route with the nearest
+// neighbor heuristic: from start, or from the first entry when start is
+// nil, the next stop is always the closest place not visited yet. It is
+// quick and usually within a quarter of the shortest route, which is
+// plenty for a day of door to door visits. The places without coordinates
+// are left out.
+func PlanRoute(entries []*Entry, start *RoutePoint) []RouteStop {
+	var left []int
+
+	for i, e := range entries {
+		if e.Latitude != 0 || e.Longtitude != 0 {
+			left = append(left, i)
+		}
+	}
+
+	ans := make([]RouteStop, 0, len(left))
+
+	if len(left) == 0 {
+		return ans
+	}
+
+	if start == nil {
+		first := entries[left[0]]
+		start = &RoutePoint{Lat: first.Latitude, Lon: first.Longtitude}
+	}
+
+	at := *start
+
+	for len(left) > 0 {
+		best, bestDistance := 0, -1.0
+
+		for k, i := range left {
+			d := entries[i].haversineDistance(at.Lat, at.Lon)
+			if bestDistance < 0 || d < bestDistance {
+				best, bestDistance = k, d
+			}
+		}
+
+		e := entries[left[best]]
+
+		ans = append(ans, RouteStop{Index: left[best], Entry: e, Distance: bestDistance})
+		at = RoutePoint{Lat: e.Latitude, Lon: e.Longtitude}
+		left = append(left[:best], left[best+1:]...)
+	}
+
+	return ans
+}
+
+// RouteDistance returns the length of the route of stops in meters, as the
+// crow flies.
+func RouteDistance(stops []RouteStop) float64 {
+	var ans float64
+
+	for _, s := range stops {
+		ans += s.Distance
+	}
+
+	return ans
+}
+
+// GoogleMapsRouteLinks returns the Google Maps directions links driving
+// through stops from start, or from the first stop when start is nil.
+// A link holds at most 9 waypoints, so a long route is split in links
+// each starting where the previous one ends.
+func GoogleMapsRouteLinks(stops []RouteStop, start *RoutePoint) []string {
+	points := make([]string, 0, len(stops)+1)
+
+	if start != nil {
+		points = append(points, formatRoutePoint(start.Lat, start.Lon))
+	}
+
+	for _, s := range stops {
+		points = append(points, formatRoutePoint(s.Entry.Latitude, s.Entry.Longtitude))
+	}
+
+	var ans []string
+
+	for len(points) > 1 {
+		n := min(len(points), routeLinkMaxWaypoints+2)
+
+		query := url.Values{}
+		query.Set("api", "1")
+		query.Set("origin", points[0])
+		query.Set("destination", points[n-1])
+		query.Set("travelmode", "driving")
+
+		if n > 2 {
+			query.Set("waypoints", strings.Join(points[1:n-1], "|"))
+		}
+
+		ans = append(ans, "https://www.google.com/maps/dir/?"+query.Encode())
+
+		points = points[n-1:]
+	}
+
+	return ans
+}
+
+func formatRoutePoint(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+}
+
+type gpxFile struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+	Route     gpxRoute      `xml:"rte"`
+}
+
+type gpxRoute struct {
+	Name   string        `xml:"name,omitempty"`
+	Points []gpxWaypoint `xml:"rtept"`
+}
+
+type gpxWaypoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+	Link *gpxURL `xml:"link,omitempty"`
+}
+
+type gpxURL struct {
+	Href string `xml:"href,attr"`
+}
+
+// WriteGPX writes the route of stops named name as GPX 1.1, for the
+// navigation apps and the GPS devices: a waypoint per place, with its
+// address and its Google Maps link, and the route through them from start
+// when not nil.
+func WriteGPX(w io.Writer, name string, stops []RouteStop, start *RoutePoint) error {
+	gpx := gpxFile{
+		Version: "1.1",
+		Creator: "google-maps-scraper",
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Route:   gpxRoute{Name: name},
+	}
+
+	if start != nil {
+		gpx.Route.Points = append(gpx.Route.Points, gpxWaypoint{Lat: start.Lat, Lon: start.Lon, Name: "Start"})
+	}
+
+	for _, s := range stops {
+		wpt := gpxWaypoint{
+			Lat:  s.Entry.Latitude,
+			Lon:  s.Entry.Longtitude,
+			Name: s.Entry.Title,
+			Desc: s.Entry.Address,
+		}
+
+		if s.Entry.Link != "" {
+			wpt.Link = &gpxURL{Href: s.Entry.Link}
+		}
+
+		gpx.Waypoints = append(gpx.Waypoints, wpt)
+		gpx.Route.Points = append(gpx.Route.Points, wpt)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(gpx); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
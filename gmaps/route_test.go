@@ -0,0 +1,116 @@
+package gmaps
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func routeIndexes(stops []RouteStop) []int {
+	ans := make([]int, len(stops))
+	for i, s := range stops {
+		ans[i] = s.Index
+	}
+
+	return ans
+}
+
+func TestPlanRoute(t *testing.T) {
+	// along a line of longitude, about 1.1 km apart
+	entries := []*Entry{
+		{Title: "c", Latitude: 45.02, Longtitude: 9},
+		{Title: "a", Latitude: 45.00, Longtitude: 9},
+		{Title: "no coordinates"},
+		{Title: "d", Latitude: 45.03, Longtitude: 9},
+		{Title: "b", Latitude: 45.01, Longtitude: 9},
+	}
+
+	stops := PlanRoute(entries, nil)
+	require.Equal(t, []int{0, 3, 4, 1}, routeIndexes(stops))
+	require.Zero(t, stops[0].Distance)
+	require.InDelta(t, 1112, stops[1].Distance, 5)
+	require.InDelta(t, 4*1112, RouteDistance(stops), 20)
+
+	stops = PlanRoute(entries, &RoutePoint{Lat: 44.99, Lon: 9})
+	require.Equal(t, []int{1, 4, 0, 3}, routeIndexes(stops))
+	require.InDelta(t, 1112, stops[0].Distance, 5)
+	require.InDelta(t, 4*1112, RouteDistance(stops), 20)
+
+	require.Empty(t, PlanRoute([]*Entry{{Title: "no coordinates"}}, nil))
+}
+
+func TestGoogleMapsRouteLinks(t *testing.T) {
+	var entries []*Entry
+	for i := range 12 {
+		entries = append(entries, &Entry{Latitude: 45 + float64(i)/100, Longtitude: 9.5})
+	}
+
+	stops := PlanRoute(entries, nil)
+
+	links := GoogleMapsRouteLinks(stops, &RoutePoint{Lat: 44.9, Lon: 9.5})
+	require.Len(t, links, 2)
+
+	u, err := url.Parse(links[0])
+	require.NoError(t, err)
+	require.Equal(t, "/maps/dir/", u.Path)
+	require.Equal(t, "44.9,9.5", u.Query().Get("origin"))
+	require.Equal(t, "45.09,9.5", u.Query().Get("destination"))
+	require.Len(t, strings.Split(u.Query().Get("waypoints"), "|"), 9)
+
+	u, err = url.Parse(links[1])
+	require.NoError(t, err)
+	require.Equal(t, "45.09,9.5", u.Query().Get("origin"))
+	require.Equal(t, "45.11,9.5", u.Query().Get("destination"))
+	require.Equal(t, "45.1,9.5", u.Query().Get("waypoints"))
+
+	require.Empty(t, GoogleMapsRouteLinks(stops[:1], nil))
+	require.Len(t, GoogleMapsRouteLinks(stops[:1], &RoutePoint{Lat: 44.9, Lon: 9.5}), 1)
+}
+
+func TestWriteGPX(t *testing.T) {
+	entries := []*Entry{
+		{Title: "Bar & Co", Address: "Via Roma 1", Link: "https://maps.google.com/?cid=1", Latitude: 45, Longtitude: 9},
+		{Title: "Pizzeria", Latitude: 45.01, Longtitude: 9},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, WriteGPX(&buf, "Monday", PlanRoute(entries, nil), &RoutePoint{Lat: 44.9, Lon: 9}))
+	require.True(t, strings.HasPrefix(buf.String(), xml.Header))
+	require.Contains(t, buf.String(), "<name>Bar &amp; Co</name>")
+
+	var gpx struct {
+		Waypoints []struct {
+			Lat  string `xml:"lat,attr"`
+			Name string `xml:"name"`
+			Desc string `xml:"desc"`
+			Link struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"wpt"`
+		Route struct {
+			Name   string `xml:"name"`
+			Points []struct {
+				Name string `xml:"name"`
+			} `xml:"rtept"`
+		} `xml:"rte"`
+	}
+
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &gpx))
+	require.Len(t, gpx.Waypoints, 2)
+	require.Equal(t, "45", gpx.Waypoints[0].Lat)
+	require.Equal(t, "Via Roma 1", gpx.Waypoints[0].Desc)
+	require.Equal(t, "https://maps.google.com/?cid=1", gpx.Waypoints[0].Link.Href)
+	require.Equal(t, "Monday", gpx.Route.Name)
+
+	var names []string
+	for _, p := range gpx.Route.Points {
+		names = append(names, p.Name)
+	}
+
+	require.Equal(t, []string{"Start", "Bar & Co", "Pizzeria"}, names)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/gosom/google-maps-scraper/exiter"
@@ -34,6 +35,11 @@ type SearchJob struct {
 	params                  *MapSearchParams
 	ExitMonitor             exiter.Exiter
 	WriterManagedCompletion bool
+	ExcludeServiceArea      bool
+	FetchStats              *FetchStats
+	Provenance              Provenance
+	Exclusions              *Exclusions
+	KeywordStats            *KeywordStats
 }
 
 func NewSearchJob(params *MapSearchParams, opts ...SearchJobOptions) *SearchJob {
@@ -75,6 +81,46 @@ func WithSearchJobWriterManagedCompletion() SearchJobOptions {
 	}
 }
 
+// WithSearchJobExcludeServiceArea drops service-area businesses from the
+// search results.
+func WithSearchJobExcludeServiceArea() SearchJobOptions {
+	return func(j *SearchJob) {
+		j.ExcludeServiceArea = true
+	}
+}
+
+// WithSearchJobExclusions drops the places x excludes from the search
+// results.
+func WithSearchJobExclusions(x *Exclusions) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.Exclusions = x
+	}
+}
+
+// WithSearchJobFetchStats records the failed search fetches, and the bytes
+// downloaded, into s.
+func WithSearchJobFetchStats(s *FetchStats) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.FetchStats = s
+	}
+}
+
+// WithSearchJobKeywordStats records the outcome of the search under its
+// query into s.
+func WithSearchJobKeywordStats(s *KeywordStats) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.KeywordStats = s
+	}
+}
+
+// WithSearchJobProvenance sets the job ID and the scraper version stamped on
+// the entries of the search.
+func WithSearchJobProvenance(p Provenance) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.Provenance = p
+	}
+}
+
 func (j *SearchJob) ProcessOnFetchError() bool {
 	return true
 }
@@ -87,6 +133,9 @@ func (j *SearchJob) Process(_ context.Context, resp *scrapemate.Response) (any,
 	}()
 
 	if resp.Error != nil {
+		j.FetchStats.RecordMapsError(j.GetURL(), resp.Error, resp.StatusCode)
+		j.KeywordStats.SeedFailed(j.params.Query, resp.Error, resp.StatusCode)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrSeedCompleted(1)
 		}
@@ -94,22 +143,52 @@ func (j *SearchJob) Process(_ context.Context, resp *scrapemate.Response) (any,
 		return nil, nil, resp.Error
 	}
 
+	// fast mode fetches over HTTP, outside of the browser hooks
+	j.FetchStats.RecordBytes(FetchSourceMaps, "", int64(len(resp.Body)))
+
 	body := removeFirstLine(resp.Body)
 	if len(body) == 0 {
+		err := fmt.Errorf("empty response body")
+
+		j.KeywordStats.SeedFailed(j.params.Query, err, 0)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrSeedCompleted(1)
 		}
 
-		return nil, nil, fmt.Errorf("empty response body")
+		return nil, nil, err
 	}
 
 	entries, err := ParseSearchResults(body)
 	if err != nil {
+		err = fmt.Errorf("failed to parse search results: %w", err)
+
+		j.KeywordStats.SeedFailed(j.params.Query, err, 0)
+
 		if j.ExitMonitor != nil {
 			j.ExitMonitor.IncrSeedCompleted(1)
 		}
 
-		return nil, nil, fmt.Errorf("failed to parse search results: %w", err)
+		return nil, nil, err
+	}
+
+	provenance := j.Provenance.stamp(j.GetFullURL(), j.params.Hl)
+
+	for _, e := range entries {
+		e.Query = j.params.Query
+		e.Provenance = provenance
+		e.FillIdentity("")
+		e.DetectLanguages()
+	}
+
+	if j.ExcludeServiceArea {
+		entries = slices.DeleteFunc(entries, func(e *Entry) bool {
+			return e.IsServiceArea
+		})
+	}
+
+	if j.Exclusions != nil {
+		entries = slices.DeleteFunc(entries, j.Exclusions.Excludes)
 	}
 
 	entries = filterAndSortEntriesWithinRadius(entries,
@@ -118,8 +197,10 @@ func (j *SearchJob) Process(_ context.Context, resp *scrapemate.Response) (any,
 		j.params.Location.Radius,
 	)
 
+	j.KeywordStats.SeedDone(j.params.Query, len(entries))
+
 	if j.ExitMonitor != nil {
-		j.ExitMonitor.IncrPlacesFound(len(entries))
+		entries = entries[:j.ExitMonitor.ReservePlaces(len(entries))]
 		j.ExitMonitor.IncrSeedCompleted(1)
 
 		if !j.WriterManagedCompletion {
@@ -127,6 +208,12 @@ func (j *SearchJob) Process(_ context.Context, resp *scrapemate.Response) (any,
 		}
 	}
 
+	// fast mode takes the places from the search results, without visiting
+	// them
+	for range entries {
+		j.KeywordStats.PlaceDone(j.params.Query, nil)
+	}
+
 	return entries, nil, nil
 }
 
@@ -0,0 +1,39 @@
+package gmaps
+
+import "strings"
+
+// serviceAreaFromJSON detects service-area businesses in a place (or search
+// result) array. Google omits the address lines (index 2) and the street of
+// the structured address for them; the display line at index 18 then holds
+// the served area ("Title, Rome") instead of a street address.
+func serviceAreaFromJSON(darray []any, title string) (string, bool) {
+	if title == "" {
+		return "", false
+	}
+
+	if len(getNthElementAndCast[[]any](darray, 2)) > 0 {
+		return "", false
+	}
+
+	if getNthElementAndCast[string](darray, 183, 1, 1) != "" {
+		return "", false
+	}
+
+	area := strings.TrimSpace(getNthElementAndCast[string](darray, 18))
+	area = strings.TrimSpace(strings.TrimPrefix(area, title))
+	area = strings.TrimSpace(strings.TrimPrefix(area, ","))
+
+	return area, true
+}
+
+// markServiceArea flags the entry as a service-area business and drops the
+// placeholder address Google shows for it: the served area is kept in
+// ServiceArea and only the locality-level parts of CompleteAddress survive.
+func (e *Entry) markServiceArea(area string) {
+	e.IsServiceArea = true
+	e.ServiceArea = area
+	e.Address = ""
+	e.CompleteAddress.Street = ""
+	e.CompleteAddress.Borough = ""
+	e.CompleteAddress.PostalCode = ""
+}
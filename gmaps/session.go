@@ -0,0 +1,246 @@
+package gmaps
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gosom/scrapemate"
+	"github.com/playwright-community/playwright-go"
+)
+
+// DefaultSessionIdentities is the number of identities kept by a job.
+const DefaultSessionIdentities = 4
+
+// identityUserAgents are the user agents handed out to the identities, one
+// each in turn.
+var identityUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36",
+}
+
+// SessionPool keeps a small set of stable identities (user agent and Google
+// cookies) for the Maps pages of a job. Each browser context presents one
+// identity for its whole life, together with the proxy scrapemate gave it,
+// and a context created later takes over an identity with its cookies, so
+// the consent wall is answered once per identity instead of once per
+// context.
+//
+// A nil *SessionPool leaves the browser contexts as scrapemate creates them.
+type SessionPool struct {
+	mu         sync.Mutex
+	identities []*identity
+	bound      map[playwright.BrowserContext]*identity
+	next       int
+
+	pages        int
+	consentWalls int
+}
+
+type identity struct {
+	userAgent string
+	cookies   []playwright.Cookie
+	// contexts is the number of open browser contexts presenting it.
+	contexts int
+}
+
+// SessionReport tells how well the identities were reused by a job.
+type SessionReport struct {
+	Identities int `json:"identities"`
+	Pages      int `json:"pages"`
+	// ConsentWalls is the number of pages that showed the Google consent
+	// form.
+	ConsentWalls int `json:"consent_walls"`
+}
+
+// NewSessionPool creates a pool of size identities. It returns nil when
+// size is not positive.
+func NewSessionPool(size int) *SessionPool {
+	if size <= 0 {
+		return nil
+	}
+
+	s := SessionPool{
+		identities: make([]*identity, size),
+		bound:      make(map[playwright.BrowserContext]*identity),
+	}
+
+	for i := range s.identities {
+		s.identities[i] = &identity{userAgent: identityUserAgents[i%len(identityUserAgents)]}
+	}
+
+	return &s
+}
+
+// attach makes the browser context of page present an identity. A context
+// keeps the identity it got first; a new context takes the identity with the
+// fewest open contexts, so that an identity stays on a single proxy as long
+// as there are no more contexts than identities.
+func (s *SessionPool) attach(page scrapemate.BrowserPage) {
+	bctx := pageContext(page)
+	if s == nil || bctx == nil {
+		return
+	}
+
+	s.mu.Lock()
+
+	s.pages++
+
+	if _, ok := s.bound[bctx]; ok {
+		s.mu.Unlock()
+
+		return
+	}
+
+	id := s.leastUsed()
+	id.contexts++
+	s.bound[bctx] = id
+
+	userAgent := id.userAgent
+	cookies := optionalCookies(id.cookies)
+
+	s.mu.Unlock()
+
+	bctx.OnClose(func(c playwright.BrowserContext) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if id, ok := s.bound[c]; ok {
+			id.contexts--
+			delete(s.bound, c)
+		}
+	})
+
+	_ = bctx.SetExtraHTTPHeaders(map[string]string{"User-Agent": userAgent})
+	_ = bctx.AddInitScript(playwright.Script{
+		Content: playwright.String(`Object.defineProperty(navigator, 'userAgent', {get: () => ` +
+			strconv.Quote(userAgent) + `});`),
+	})
+
+	if len(cookies) > 0 {
+		_ = bctx.AddCookies(cookies)
+	}
+}
+
+// remember stores the Google cookies of the context of page into its
+// identity once the consent wall (if any) was answered.
+func (s *SessionPool) remember(page scrapemate.BrowserPage, consentShown bool) {
+	bctx := pageContext(page)
+	if s == nil || bctx == nil {
+		return
+	}
+
+	s.mu.Lock()
+
+	if consentShown {
+		s.consentWalls++
+	}
+
+	id, ok := s.bound[bctx]
+	if !ok || (!consentShown && len(id.cookies) > 0) {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.mu.Unlock()
+
+	all, err := bctx.Cookies()
+	if err != nil {
+		return
+	}
+
+	cookies := make([]playwright.Cookie, 0, len(all))
+
+	for _, c := range all {
+		if isGoogleCookieDomain(c.Domain) {
+			cookies = append(cookies, c)
+		}
+	}
+
+	if len(cookies) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id.cookies = cookies
+}
+
+// Report summarizes the use of the identities.
+func (s *SessionPool) Report() SessionReport {
+	if s == nil {
+		return SessionReport{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SessionReport{
+		Identities:   len(s.identities),
+		Pages:        s.pages,
+		ConsentWalls: s.consentWalls,
+	}
+}
+
+func (s *SessionPool) leastUsed() *identity {
+	best := s.identities[s.next%len(s.identities)]
+
+	for i := range s.identities {
+		id := s.identities[(s.next+i)%len(s.identities)]
+		if id.contexts < best.contexts {
+			best = id
+		}
+	}
+
+	s.next++
+
+	return best
+}
+
+func pageContext(page scrapemate.BrowserPage) playwright.BrowserContext {
+	if page == nil {
+		return nil
+	}
+
+	pw, ok := page.Unwrap().(playwright.Page)
+	if !ok {
+		return nil
+	}
+
+	return pw.Context()
+}
+
+func isGoogleCookieDomain(domain string) bool {
+	domain = strings.TrimPrefix(domain, ".")
+
+	return strings.HasPrefix(domain, "google.") || strings.Contains(domain, ".google.")
+}
+
+func optionalCookies(cookies []playwright.Cookie) []playwright.OptionalCookie {
+	out := make([]playwright.OptionalCookie, 0, len(cookies))
+
+	for _, c := range cookies {
+		oc := playwright.OptionalCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   playwright.String(c.Domain),
+			Path:     playwright.String(c.Path),
+			HttpOnly: playwright.Bool(c.HttpOnly),
+			Secure:   playwright.Bool(c.Secure),
+			SameSite: c.SameSite,
+		}
+
+		// -1 marks a session cookie
+		if c.Expires > 0 {
+			oc.Expires = playwright.Float(c.Expires)
+		}
+
+		out = append(out, oc)
+	}
+
+	return out
+}
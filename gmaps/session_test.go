@@ -0,0 +1,125 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContext is a browser context that only keeps cookies and headers.
+type fakeContext struct {
+	playwright.BrowserContext
+
+	cookies []playwright.Cookie
+	headers map[string]string
+	onClose func(playwright.BrowserContext)
+}
+
+func (c *fakeContext) OnClose(fn func(playwright.BrowserContext)) { c.onClose = fn }
+
+func (c *fakeContext) SetExtraHTTPHeaders(h map[string]string) error {
+	c.headers = h
+
+	return nil
+}
+
+func (c *fakeContext) AddInitScript(playwright.Script) error { return nil }
+
+func (c *fakeContext) AddCookies(cookies []playwright.OptionalCookie) error {
+	for _, oc := range cookies {
+		c.cookies = append(c.cookies, playwright.Cookie{Name: oc.Name, Value: oc.Value, Domain: *oc.Domain, Path: *oc.Path})
+	}
+
+	return nil
+}
+
+func (c *fakeContext) Cookies(...string) ([]playwright.Cookie, error) { return c.cookies, nil }
+
+type fakePWPage struct {
+	playwright.Page
+
+	ctx *fakeContext
+}
+
+func (p *fakePWPage) Context() playwright.BrowserContext { return p.ctx }
+
+type contextPage struct {
+	scrapemate.BrowserPage
+
+	pw *fakePWPage
+}
+
+func (p *contextPage) Unwrap() any { return p.pw }
+
+func newContextPage() (*contextPage, *fakeContext) {
+	ctx := &fakeContext{}
+
+	return &contextPage{pw: &fakePWPage{ctx: ctx}}, ctx
+}
+
+func TestSessionPoolCarriesConsentToNewContexts(t *testing.T) {
+	sessions := NewSessionPool(1)
+
+	first, firstCtx := newContextPage()
+
+	sessions.attach(first)
+	require.Contains(t, firstCtx.headers["User-Agent"], "Chrome/")
+
+	// the consent form sets SOCS, other sites' cookies are not kept
+	firstCtx.cookies = []playwright.Cookie{
+		{Name: "SOCS", Value: "CAI", Domain: ".google.com", Path: "/"},
+		{Name: "_ga", Value: "x", Domain: ".example.com", Path: "/"},
+	}
+	sessions.remember(first, true)
+
+	// same context: nothing is re-applied
+	sessions.attach(first)
+	sessions.remember(first, false)
+
+	firstCtx.onClose(firstCtx)
+
+	second, secondCtx := newContextPage()
+
+	sessions.attach(second)
+	sessions.remember(second, false)
+
+	require.Equal(t, firstCtx.headers, secondCtx.headers)
+	require.Len(t, secondCtx.cookies, 1)
+	require.Equal(t, "SOCS", secondCtx.cookies[0].Name)
+
+	require.Equal(t, SessionReport{Identities: 1, Pages: 3, ConsentWalls: 1}, sessions.Report())
+}
+
+func TestSessionPoolSpreadsContexts(t *testing.T) {
+	sessions := NewSessionPool(2)
+
+	a, aCtx := newContextPage()
+	b, bCtx := newContextPage()
+
+	sessions.attach(a)
+	sessions.attach(b)
+	require.NotEqual(t, aCtx.headers["User-Agent"], bCtx.headers["User-Agent"])
+
+	// a replacement context takes the identity that was freed
+	aCtx.onClose(aCtx)
+
+	c, cCtx := newContextPage()
+
+	sessions.attach(c)
+	require.Equal(t, aCtx.headers, cCtx.headers)
+}
+
+func TestNilSessionPool(t *testing.T) {
+	var sessions *SessionPool
+
+	page, ctx := newContextPage()
+
+	sessions.attach(page)
+	sessions.remember(page, true)
+
+	require.Nil(t, ctx.headers)
+	require.Equal(t, SessionReport{}, sessions.Report())
+	require.Nil(t, NewSessionPool(0))
+}
@@ -0,0 +1,178 @@
+package gmaps
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	"github.com/playwright-community/playwright-go"
+)
+
+// SkipFields are the pieces of the place pages a job leaves out, to run
+// leaner when it targets a few fields only. The zero value extracts them
+// all.
+type SkipFields struct {
+	// Reviews fetches no extra reviews and parses none of the reviews of
+	// the page; the rating and the review count are kept.
+	Reviews bool
+	// Images lets the browser load no image of the place page and leaves
+	// the photos out; the thumbnail is kept.
+	Images bool
+	// About parses no attribute of the About tab.
+	About bool
+}
+
+// Any tells whether s skips something.
+func (s SkipFields) Any() bool {
+	return s.Reviews || s.Images || s.About
+}
+
+// defaultReviewFetchRate is the time a review is assumed to take to fetch
+// until the process has fetched some.
+const defaultReviewFetchRate = 50 * time.Millisecond
+
+// reviewFetchRate is the mean time a review took to fetch in this process,
+// weighting the recent fetches more, to estimate the time the jobs skipping
+// the reviews save.
+var reviewFetchRate = struct {
+	sync.Mutex
+	perReview time.Duration
+}{perReview: defaultReviewFetchRate}
+
+// observeReviewFetch records that fetching n reviews took d.
+func observeReviewFetch(n int, d time.Duration) {
+	if n <= 0 {
+		return
+	}
+
+	reviewFetchRate.Lock()
+	defer reviewFetchRate.Unlock()
+
+	reviewFetchRate.perReview = (reviewFetchRate.perReview*4 + d/time.Duration(n)) / 5
+}
+
+func reviewFetchEstimate(n int) time.Duration {
+	reviewFetchRate.Lock()
+	defer reviewFetchRate.Unlock()
+
+	return reviewFetchRate.perReview * time.Duration(n)
+}
+
+// SkipStats records the work a job skipping fields left undone. It is safe
+// for concurrent use; a nil *SkipStats records nothing.
+type SkipStats struct {
+	mu            sync.Mutex
+	places        int
+	reviews       int
+	imagesBlocked int
+	saved         time.Duration
+}
+
+// NewSkipStats creates an empty SkipStats.
+func NewSkipStats() *SkipStats {
+	return &SkipStats{}
+}
+
+// place records a place visited with some fields skipped.
+func (s *SkipStats) place() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.places++
+	s.mu.Unlock()
+}
+
+// reviewsNotFetched records the n extra reviews of a place left unfetched,
+// saving the time they would have taken at the rate observed so far.
+func (s *SkipStats) reviewsNotFetched(n int) {
+	if s == nil || n <= 0 {
+		return
+	}
+
+	saved := reviewFetchEstimate(n)
+
+	s.mu.Lock()
+	s.reviews += n
+	s.saved += saved
+	s.mu.Unlock()
+}
+
+func (s *SkipStats) imageBlocked() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.imagesBlocked++
+	s.mu.Unlock()
+}
+
+// SkipReport sums up the work a job skipping fields left undone. SavedMs
+// is an estimate: the extra reviews not fetched, at the time per review the
+// process observed on the jobs fetching them.
+type SkipReport struct {
+	Places            int   `json:"places"`
+	ReviewsNotFetched int   `json:"reviews_not_fetched"`
+	ImagesBlocked     int   `json:"images_blocked"`
+	SavedMs           int64 `json:"saved_ms"`
+}
+
+// Report returns what s recorded, or nil when it recorded nothing.
+func (s *SkipStats) Report() *SkipReport {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.places == 0 {
+		return nil
+	}
+
+	return &SkipReport{
+		Places:            s.places,
+		ReviewsNotFetched: s.reviews,
+		ImagesBlocked:     s.imagesBlocked,
+		SavedMs:           s.saved.Milliseconds(),
+	}
+}
+
+// blockImages aborts the image requests of page until the returned func is
+// called, when s skips the images, counting them into stats. Pages without
+// request routing load them.
+func (s SkipFields) blockImages(page scrapemate.BrowserPage, stats *SkipStats) func() {
+	if !s.Images {
+		return func() {}
+	}
+
+	pw, ok := page.Unwrap().(playwright.Page)
+	if !ok {
+		return func() {}
+	}
+
+	const pattern = "**/*"
+
+	handler := func(route playwright.Route) {
+		if route.Request().ResourceType() == "image" {
+			_ = route.Abort()
+
+			stats.imageBlocked()
+
+			return
+		}
+
+		_ = route.Fallback()
+	}
+
+	if err := pw.Route(pattern, handler); err != nil {
+		return func() {}
+	}
+
+	// the page goes back to the pool, for jobs loading the images
+	return func() {
+		_ = pw.Unroute(pattern, handler)
+	}
+}
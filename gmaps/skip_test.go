@@ -0,0 +1,65 @@
+package gmaps
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceJobSkipsFields(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/panic2.json")
+	require.NoError(t, err)
+
+	full, err := EntryFromJSON(raw)
+	require.NoError(t, err)
+	require.NotEmpty(t, full.About)
+	require.NotEmpty(t, full.Images)
+
+	require.False(t, SkipFields{}.Any())
+
+	skip := SkipFields{Reviews: true, Images: true, About: true}
+	require.True(t, skip.Any())
+
+	job := NewPlaceJob("parent", "en", "https://www.google.com/maps/place/a", false, false, WithPlaceJobSkip(skip))
+
+	result, next, err := job.Process(context.Background(), &scrapemate.Response{Meta: map[string]any{"json": raw}})
+	require.NoError(t, err)
+	require.Empty(t, next)
+
+	entry, ok := result.(*Entry)
+	require.True(t, ok)
+	require.Equal(t, full.Title, entry.Title)
+	require.Equal(t, full.ReviewCount, entry.ReviewCount)
+	require.Empty(t, entry.UserReviews)
+	require.Empty(t, entry.UserReviewsExtended)
+	require.Empty(t, entry.Images)
+	require.Empty(t, entry.About)
+	require.Empty(t, entry.Attributes)
+}
+
+func TestSkipStatsReport(t *testing.T) {
+	var none *SkipStats
+
+	none.place()
+	none.reviewsNotFetched(10)
+	require.Nil(t, none.Report())
+
+	stats := NewSkipStats()
+	require.Nil(t, stats.Report())
+
+	stats.place()
+	stats.reviewsNotFetched(40)
+	stats.imageBlocked()
+	stats.imageBlocked()
+
+	report := stats.Report()
+	require.NotNil(t, report)
+	require.Equal(t, 1, report.Places)
+	require.Equal(t, 40, report.ReviewsNotFetched)
+	require.Equal(t, 2, report.ImagesBlocked)
+	require.Equal(t, reviewFetchEstimate(40).Milliseconds(), report.SavedMs)
+	require.Positive(t, report.SavedMs)
+}
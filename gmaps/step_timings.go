@@ -0,0 +1,197 @@
+package gmaps
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Steps timed by StepTimings, in the order they are reported.
+const (
+	// StepPlaceNavigation is the load of the place page, cookie wall
+	// included, StepPlaceData the wait for the place data in the page and
+	// StepPlaceReviews the scroll or the requests of the extra reviews.
+	StepPlaceNavigation = "place_navigation"
+	StepPlaceData       = "place_data"
+	StepPlaceReviews    = "place_reviews"
+	// StepPlaceTotal is the whole visit of the place page, from the
+	// navigation to the last review, to compare jobs skipping fields with
+	// the others.
+	StepPlaceTotal = "place_total"
+	// StepPlaceParse is the parsing of the place data into an entry.
+	StepPlaceParse = "place_parse"
+	// The email levels: the homepage, the contact pages and the deep-crawl
+	// pages fetched over HTTP, then the pages rendered by the browser.
+	StepEmailLevel1  = "email_level_1"
+	StepEmailLevel2  = "email_level_2"
+	StepEmailLevel25 = "email_level_2_5"
+	StepEmailLevel3  = "email_level_3"
+	// StepEmailVerification is the MX and SMTP checks of the emails found.
+	StepEmailVerification = "email_verification"
+)
+
+var stepOrder = []string{
+	StepPlaceNavigation,
+	StepPlaceData,
+	StepPlaceReviews,
+	StepPlaceTotal,
+	StepPlaceParse,
+	StepEmailLevel1,
+	StepEmailLevel2,
+	StepEmailLevel25,
+	StepEmailLevel3,
+	StepEmailVerification,
+}
+
+// timingBounds are the upper bounds of the histogram buckets, the last
+// bucket taking the longer durations.
+var timingBounds = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+type stepHistogram struct {
+	count   int
+	total   time.Duration
+	max     time.Duration
+	buckets []int
+}
+
+// StepTimings aggregates how long the steps of the places and of their email
+// extraction take in a job, to tell whether a slow job waits on Google, on
+// its proxies or on the websites. It is safe for concurrent use; a nil
+// *StepTimings records nothing.
+type StepTimings struct {
+	mu    sync.Mutex
+	steps map[string]*stepHistogram
+}
+
+// NewStepTimings creates an empty StepTimings.
+func NewStepTimings() *StepTimings {
+	return &StepTimings{steps: make(map[string]*stepHistogram)}
+}
+
+// Record adds a run of step that took d.
+func (t *StepTimings) Record(step string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.steps[step]
+	if !ok {
+		h = &stepHistogram{buckets: make([]int, len(timingBounds)+1)}
+		t.steps[step] = h
+	}
+
+	h.count++
+	h.total += d
+	h.max = max(h.max, d)
+
+	i := 0
+	for i < len(timingBounds) && d > timingBounds[i] {
+		i++
+	}
+
+	h.buckets[i]++
+}
+
+// Since records a run of step started at start.
+func (t *StepTimings) Since(step string, start time.Time) {
+	t.Record(step, time.Since(start))
+}
+
+// TimingBucket counts the runs of a step that took at most LeMs
+// milliseconds and more than the bound of the previous bucket. LeMs is 0 for
+// the last bucket, without bound.
+type TimingBucket struct {
+	LeMs  int64 `json:"le_ms,omitempty"`
+	Count int   `json:"count"`
+}
+
+// StepTiming sums up the runs of a step. The percentiles are estimated from
+// the buckets.
+type StepTiming struct {
+	Step    string `json:"step"`
+	Count   int    `json:"count"`
+	TotalMs int64  `json:"total_ms"`
+	MeanMs  int64  `json:"mean_ms"`
+	P50Ms   int64  `json:"p50_ms"`
+	P95Ms   int64  `json:"p95_ms"`
+	MaxMs   int64  `json:"max_ms"`
+	// Buckets are the non-empty buckets of the histogram, shortest first.
+	Buckets []TimingBucket `json:"buckets"`
+}
+
+// Report returns the timings of the steps that ran, in the order of the
+// Step constants.
+func (t *StepTimings) Report() []StepTiming {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ans []StepTiming
+
+	for _, step := range stepOrder {
+		h, ok := t.steps[step]
+		if !ok {
+			continue
+		}
+
+		report := StepTiming{
+			Step:    step,
+			Count:   h.count,
+			TotalMs: h.total.Milliseconds(),
+			MeanMs:  (h.total / time.Duration(h.count)).Milliseconds(),
+			P50Ms:   h.percentile(0.5).Milliseconds(),
+			P95Ms:   h.percentile(0.95).Milliseconds(),
+			MaxMs:   h.max.Milliseconds(),
+		}
+
+		for i, n := range h.buckets {
+			if n == 0 {
+				continue
+			}
+
+			var le int64
+			if i < len(timingBounds) {
+				le = timingBounds[i].Milliseconds()
+			}
+
+			report.Buckets = append(report.Buckets, TimingBucket{LeMs: le, Count: n})
+		}
+
+		ans = append(ans, report)
+	}
+
+	return ans
+}
+
+// percentile returns the bound of the bucket holding the p quantile, capped
+// to the longest run.
+func (h *stepHistogram) percentile(p float64) time.Duration {
+	rank := max(1, int(math.Ceil(p*float64(h.count))))
+
+	seen := 0
+
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= rank && i < len(timingBounds) {
+			return min(timingBounds[i], h.max)
+		}
+	}
+
+	return h.max
+}
@@ -0,0 +1,47 @@
+package gmaps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepTimings(t *testing.T) {
+	timings := NewStepTimings()
+
+	timings.Record(StepEmailLevel1, 3*time.Second)
+
+	for range 9 {
+		timings.Record(StepPlaceNavigation, 200*time.Millisecond)
+	}
+
+	timings.Record(StepPlaceNavigation, 90*time.Second)
+
+	report := timings.Report()
+	require.Len(t, report, 2)
+
+	nav := report[0]
+	require.Equal(t, StepPlaceNavigation, nav.Step)
+	require.Equal(t, 10, nav.Count)
+	require.Equal(t, int64(91800), nav.TotalMs)
+	require.Equal(t, int64(9180), nav.MeanMs)
+	require.Equal(t, int64(250), nav.P50Ms)
+	require.Equal(t, int64(90000), nav.P95Ms)
+	require.Equal(t, int64(90000), nav.MaxMs)
+	require.Equal(t, []TimingBucket{{LeMs: 250, Count: 9}, {Count: 1}}, nav.Buckets)
+
+	email := report[1]
+	require.Equal(t, StepEmailLevel1, email.Step)
+	// a percentile never exceeds the longest run
+	require.Equal(t, int64(3000), email.P50Ms)
+	require.Equal(t, []TimingBucket{{LeMs: 5000, Count: 1}}, email.Buckets)
+}
+
+func TestStepTimingsNil(t *testing.T) {
+	var timings *StepTimings
+
+	timings.Record(StepPlaceParse, time.Second)
+	timings.Since(StepPlaceParse, time.Now())
+	require.Nil(t, timings.Report())
+}
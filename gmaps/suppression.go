@@ -0,0 +1,137 @@
+package gmaps
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SuppressionList holds the emails and domains already contacted, to keep
+// the places matching them out of new campaigns. A domain matches its
+// subdomains too. Its methods are safe on a nil list, which matches
+// nothing.
+type SuppressionList struct {
+	emails  map[string]bool
+	domains map[string]bool
+}
+
+// NewSuppressionList returns the list of values, emails or domains as
+// normalized by NormalizeSuppression; the invalid ones are ignored.
+func NewSuppressionList(values []string) *SuppressionList {
+	l := SuppressionList{
+		emails:  map[string]bool{},
+		domains: map[string]bool{},
+	}
+
+	for _, v := range values {
+		v, ok := NormalizeSuppression(v)
+
+		switch {
+		case !ok:
+		case strings.Contains(v, "@"):
+			l.emails[v] = true
+		default:
+			l.domains[v] = true
+		}
+	}
+
+	return &l
+}
+
+// NormalizeSuppression returns value as stored in a suppression list: a
+// lowercase email, or a lowercase domain without scheme, "www.", port and
+// path ("https://www.Example.com/contact" becomes "example.com"). ok is
+// false when value is neither.
+func NormalizeSuppression(value string) (string, bool) {
+	value = strings.ToLower(strings.Trim(strings.TrimSpace(value), `"'<>`))
+	value = strings.TrimPrefix(value, "mailto:")
+
+	if local, domain, found := strings.Cut(value, "@"); found {
+		if local == "" {
+			// "@example.com" stands for the whole domain
+			return normalizeDomain(domain)
+		}
+
+		domain, ok := normalizeDomain(domain)
+		if !ok || strings.ContainsAny(local, " @") {
+			return "", false
+		}
+
+		return local + "@" + domain, true
+	}
+
+	return normalizeDomain(value)
+}
+
+func normalizeDomain(value string) (string, bool) {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(u.Hostname(), "www."), ".")
+	if !strings.Contains(host, ".") || strings.ContainsAny(host, " _") {
+		return "", false
+	}
+
+	return host, true
+}
+
+// Len returns the number of emails and domains of l.
+func (l *SuppressionList) Len() int {
+	if l == nil {
+		return 0
+	}
+
+	return len(l.emails) + len(l.domains)
+}
+
+// Match returns the email or domain of l e matches, looking at its emails
+// and website, or an empty string.
+func (l *SuppressionList) Match(e *Entry) string {
+	if l.Len() == 0 {
+		return ""
+	}
+
+	for _, email := range e.Emails {
+		email, ok := NormalizeSuppression(email)
+		if !ok {
+			continue
+		}
+
+		if l.emails[email] {
+			return email
+		}
+
+		if d := l.matchDomain(email[strings.LastIndex(email, "@")+1:]); d != "" {
+			return d
+		}
+	}
+
+	if e.WebSite != "" {
+		if host, ok := normalizeDomain(strings.ToLower(e.WebSite)); ok {
+			return l.matchDomain(host)
+		}
+	}
+
+	return ""
+}
+
+// matchDomain returns the domain of l host is or is a subdomain of.
+func (l *SuppressionList) matchDomain(host string) string {
+	for {
+		if l.domains[host] {
+			return host
+		}
+
+		_, parent, found := strings.Cut(host, ".")
+		if !found || !strings.Contains(parent, ".") {
+			return ""
+		}
+
+		host = parent
+	}
+}
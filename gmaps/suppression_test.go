@@ -0,0 +1,57 @@
+package gmaps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSuppression(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{" Info@Example.COM ", "info@example.com", true},
+		{"mailto:sales@shop.example.com", "sales@shop.example.com", true},
+		{"https://www.Example.com/contact?x=1", "example.com", true},
+		{"example.org:8080", "example.org", true},
+		{"@example.net", "example.net", true},
+		{`"example.it"`, "example.it", true},
+		{"email", "", false},
+		{"info@localhost", "", false},
+		{"not an email@example.com", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeSuppression(tt.value)
+		require.Equal(t, tt.ok, ok, tt.value)
+		require.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func TestSuppressionListMatch(t *testing.T) {
+	l := NewSuppressionList([]string{"info@pizzeria.it", "https://www.brand.com", "header", "contacted.de"})
+	require.Equal(t, 3, l.Len())
+
+	tests := []struct {
+		entry Entry
+		want  string
+	}{
+		{Entry{Emails: []string{"INFO@pizzeria.it"}}, "info@pizzeria.it"},
+		{Entry{Emails: []string{"other@pizzeria.it"}}, ""},
+		{Entry{WebSite: "https://shop.brand.com/it"}, "brand.com"},
+		{Entry{Emails: []string{"x@mail.contacted.de"}, WebSite: "https://other.de"}, "contacted.de"},
+		{Entry{WebSite: "https://notbrand.com"}, ""},
+		{Entry{}, ""},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, l.Match(&tt.entry), "%+v", tt.entry)
+	}
+
+	var nilList *SuppressionList
+
+	require.Empty(t, nilList.Match(&Entry{Emails: []string{"info@pizzeria.it"}}))
+}
@@ -0,0 +1,261 @@
+package gmaps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Territory is a named area, like the region of a sales rep. Its polygons
+// are lists of rings, the first being the outline and the others its holes,
+// of [longitude, latitude] points as in GeoJSON.
+type Territory struct {
+	Name     string
+	Polygons [][][][2]float64
+}
+
+// Territories are read from and written as a GeoJSON FeatureCollection of
+// Polygon and MultiPolygon features, named by their "name" property. The
+// first territory holding a place wins when they overlap.
+type Territories []Territory
+
+type geoJSONObject struct {
+	Type       string          `json:"type"`
+	Features   []geoJSONObject `json:"features,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+	Geometry   *geoJSONObject  `json:"geometry,omitempty"`
+	ID         any             `json:"id,omitempty"`
+	// Coordinates are those of a Polygon or a MultiPolygon.
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+}
+
+// ParseTerritories reads the territories of a GeoJSON FeatureCollection or
+// Feature.
+func ParseTerritories(data []byte) (Territories, error) {
+	var ans Territories
+
+	if err := json.Unmarshal(data, &ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see Territories.
+func (t *Territories) UnmarshalJSON(data []byte) error {
+	var obj *geoJSONObject
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("invalid territories GeoJSON: %w", err)
+	}
+
+	if obj == nil {
+		*t = nil
+
+		return nil
+	}
+
+	var features []geoJSONObject
+
+	switch obj.Type {
+	case "FeatureCollection":
+		features = obj.Features
+	case "Feature":
+		features = []geoJSONObject{*obj}
+	default:
+		return fmt.Errorf("invalid territories GeoJSON: want a FeatureCollection or a Feature, got %q", obj.Type)
+	}
+
+	ans := make(Territories, 0, len(features))
+
+	for i := range features {
+		territory, err := territoryFromFeature(&features[i])
+		if err != nil {
+			return fmt.Errorf("territory %d: %w", i+1, err)
+		}
+
+		ans = append(ans, territory)
+	}
+
+	*t = ans
+
+	return nil
+}
+
+func territoryFromFeature(f *geoJSONObject) (Territory, error) {
+	ans := Territory{}
+
+	if name, ok := f.Properties["name"].(string); ok {
+		ans.Name = strings.TrimSpace(name)
+	}
+
+	if id, ok := f.ID.(string); ok && ans.Name == "" {
+		ans.Name = strings.TrimSpace(id)
+	}
+
+	if ans.Name == "" {
+		return ans, errors.New("missing name property")
+	}
+
+	if f.Geometry == nil {
+		return ans, fmt.Errorf("%s: missing geometry", ans.Name)
+	}
+
+	var err error
+
+	switch f.Geometry.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+
+		err = json.Unmarshal(f.Geometry.Coordinates, &polygon)
+		ans.Polygons = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		err = json.Unmarshal(f.Geometry.Coordinates, &ans.Polygons)
+	default:
+		return ans, fmt.Errorf("%s: unsupported geometry %q, use Polygon or MultiPolygon", ans.Name, f.Geometry.Type)
+	}
+
+	if err != nil {
+		return ans, fmt.Errorf("%s: invalid coordinates: %w", ans.Name, err)
+	}
+
+	if len(ans.Polygons) == 0 {
+		return ans, fmt.Errorf("%s: no polygon", ans.Name)
+	}
+
+	for _, polygon := range ans.Polygons {
+		if len(polygon) == 0 {
+			return ans, fmt.Errorf("%s: polygon without rings", ans.Name)
+		}
+
+		for _, ring := range polygon {
+			if len(ring) < 3 {
+				return ans, fmt.Errorf("%s: a ring needs at least 3 points", ans.Name)
+			}
+		}
+	}
+
+	return ans, nil
+}
+
+// MarshalJSON implements json.Marshaler, see Territories.
+func (t Territories) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("null"), nil
+	}
+
+	type feature struct {
+		Type       string         `json:"type"`
+		Properties map[string]any `json:"properties"`
+		Geometry   geoJSONObject  `json:"geometry"`
+	}
+
+	features := make([]feature, 0, len(t))
+
+	for _, territory := range t {
+		geometry := geoJSONObject{Type: "MultiPolygon"}
+
+		var coords any = territory.Polygons
+		if len(territory.Polygons) == 1 {
+			geometry.Type = "Polygon"
+			coords = territory.Polygons[0]
+		}
+
+		raw, err := json.Marshal(coords)
+		if err != nil {
+			return nil, err
+		}
+
+		geometry.Coordinates = raw
+
+		features = append(features, feature{
+			Type:       "Feature",
+			Properties: map[string]any{"name": territory.Name},
+			Geometry:   geometry,
+		})
+	}
+
+	return json.Marshal(struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: features})
+}
+
+// Names returns the names of t, in order and once each.
+func (t Territories) Names() []string {
+	var ans []string
+
+	seen := map[string]bool{}
+
+	for _, territory := range t {
+		if !seen[territory.Name] {
+			seen[territory.Name] = true
+			ans = append(ans, territory.Name)
+		}
+	}
+
+	return ans
+}
+
+// Assign returns the name of the first territory of t holding the point
+// at lat and lon, "" when none does.
+func (t Territories) Assign(lat, lon float64) string {
+	for i := range t {
+		if t[i].Contains(lat, lon) {
+			return t[i].Name
+		}
+	}
+
+	return ""
+}
+
+// Contains reports whether the point at lat and lon is in t, inside the
+// outline of one of its polygons and outside its holes.
+func (t *Territory) Contains(lat, lon float64) bool {
+	for _, polygon := range t.Polygons {
+		// with the even-odd rule a point of a hole crosses two rings
+		inside := false
+
+		for _, ring := range polygon {
+			if ringContains(ring, lat, lon) {
+				inside = !inside
+			}
+		}
+
+		if inside {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ringContains casts a ray from the point towards the east and counts the
+// edges of ring it crosses. The ring may be closed or not.
+func ringContains(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// AssignTerritory sets the Territory of e, that of t holding its
+// coordinates. The places without coordinates get none.
+func (e *Entry) AssignTerritory(t Territories) {
+	e.Territory = ""
+
+	if e.Latitude == 0 && e.Longtitude == 0 {
+		return
+	}
+
+	e.Territory = t.Assign(e.Latitude, e.Longtitude)
+}
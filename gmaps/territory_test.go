@@ -0,0 +1,113 @@
+package gmaps
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// territoriesGeoJSON has "north" with a hole around (45.5, 9.5), "south" as
+// a MultiPolygon and "all" overlapping both.
+const territoriesGeoJSON = `{
+	"type": "FeatureCollection",
+	"features": [
+		{
+			"type": "Feature",
+			"properties": {"name": "north", "rep": "Anna"},
+			"geometry": {"type": "Polygon", "coordinates": [
+				[[9, 45], [10, 45], [10, 46], [9, 46], [9, 45]],
+				[[9.4, 45.4], [9.6, 45.4], [9.6, 45.6], [9.4, 45.6], [9.4, 45.4]]
+			]}
+		},
+		{
+			"type": "Feature",
+			"id": "south",
+			"geometry": {"type": "MultiPolygon", "coordinates": [
+				[[[9, 44], [10, 44], [10, 45], [9, 45]]],
+				[[[12, 44], [13, 44], [13, 45], [12, 45], [12, 44]]]
+			]}
+		},
+		{
+			"type": "Feature",
+			"properties": {"name": "all"},
+			"geometry": {"type": "Polygon", "coordinates": [[[0, 40], [20, 40], [20, 50], [0, 50], [0, 40]]]}
+		}
+	]
+}`
+
+func TestParseTerritories(t *testing.T) {
+	territories, err := ParseTerritories([]byte(territoriesGeoJSON))
+	require.NoError(t, err)
+	require.Equal(t, []string{"north", "south", "all"}, territories.Names())
+	require.Len(t, territories[0].Polygons[0], 2)
+	require.Len(t, territories[1].Polygons, 2)
+
+	tests := []struct {
+		lat, lon float64
+		want     string
+	}{
+		{45.2, 9.2, "north"},
+		{45.5, 9.5, "all"},
+		{44.5, 9.5, "south"},
+		{44.5, 12.5, "south"},
+		{48, 15, "all"},
+		{60, 15, ""},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, territories.Assign(tt.lat, tt.lon), "%v,%v", tt.lat, tt.lon)
+	}
+}
+
+func TestTerritoriesJSON(t *testing.T) {
+	territories, err := ParseTerritories([]byte(territoriesGeoJSON))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(territories)
+	require.NoError(t, err)
+
+	var again Territories
+
+	require.NoError(t, json.Unmarshal(data, &again))
+	require.Equal(t, territories, again)
+
+	var wrapped struct {
+		Territories Territories `json:"territories,omitempty"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"territories": null}`), &wrapped))
+	require.Nil(t, wrapped.Territories)
+
+	data, err = json.Marshal(wrapped)
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(data))
+}
+
+func TestParseTerritoriesInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"type": "Polygon", "coordinates": []}`,
+		`{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1]]]}}`,
+		`{"type": "Feature", "properties": {"name": "x"}, "geometry": {"type": "Point", "coordinates": [0, 0]}}`,
+		`{"type": "Feature", "properties": {"name": "x"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0]]]}}`,
+		`{"type": "Feature", "properties": {"name": "x"}}`,
+		`[1, 2]`,
+	} {
+		_, err := ParseTerritories([]byte(data))
+		require.Error(t, err, data)
+	}
+}
+
+func TestAssignTerritory(t *testing.T) {
+	territories, err := ParseTerritories([]byte(territoriesGeoJSON))
+	require.NoError(t, err)
+
+	e := Entry{Latitude: 45.2, Longtitude: 9.2}
+	e.AssignTerritory(territories)
+	require.Equal(t, "north", e.Territory)
+
+	// no coordinates, no territory, even if a polygon holds 0,0
+	e = Entry{Territory: "stale"}
+	e.AssignTerritory(Territories{{Name: "null island", Polygons: [][][][2]float64{{{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}}}}})
+	require.Empty(t, e.Territory)
+}
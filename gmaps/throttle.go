@@ -0,0 +1,258 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Tuning of Throttle.
+const (
+	// throttleWindow is how many of the last Maps pages the block rate is
+	// computed on.
+	throttleWindow = 20
+	// throttleBlockRate is the block rate over which the pages slow down.
+	throttleBlockRate = 0.2
+	// throttleMinBlocks avoids slowing down on a single unlucky page.
+	throttleMinBlocks = 2
+	// throttleRampUp is how many pages in a row must go through before
+	// speeding up again.
+	throttleRampUp = 20
+	// throttleCooldown is the least time between two adjustments, so that
+	// each one can take effect.
+	throttleCooldown = 30 * time.Second
+
+	throttleMinDelay = 2 * time.Second
+	throttleMaxDelay = 30 * time.Second
+)
+
+// Throttle slows down the Maps pages of a job while Google pushes back, with
+// captchas, consent walls, empty feeds or 403/429 answers, and speeds them up
+// again once the pages go through: on a block rate over 20% of the last
+// pages it halves the pages open at once and doubles the pause before each
+// page, and after a run of pages without blocks it opens one more page and
+// halves the pause. It is safe for concurrent use; a nil *Throttle never
+// slows down.
+type Throttle struct {
+	mu       sync.Mutex
+	maxSlots int
+	slots    int
+	inUse    int
+	delay    time.Duration
+	// changed is closed, and replaced, when a slot frees up or the slots
+	// change
+	changed chan struct{}
+
+	window   []bool
+	next     int
+	streak   int
+	adjusted time.Time
+
+	pages      int
+	blocked    int
+	slowdowns  int
+	speedups   int
+	minSlots   int
+	worstDelay time.Duration
+}
+
+// NewThrottle creates a Throttle for a job opening up to concurrency pages at
+// once.
+func NewThrottle(concurrency int) *Throttle {
+	concurrency = max(concurrency, 1)
+
+	return &Throttle{
+		maxSlots: concurrency,
+		slots:    concurrency,
+		minSlots: concurrency,
+		changed:  make(chan struct{}),
+		window:   make([]bool, 0, throttleWindow),
+	}
+}
+
+// acquire waits for a slot and for the pause before a page, and returns the
+// function releasing the slot.
+func (t *Throttle) acquire(ctx context.Context) (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+
+	for {
+		t.mu.Lock()
+
+		if t.inUse < t.slots {
+			t.inUse++
+			delay := t.delay
+			t.mu.Unlock()
+
+			if delay == 0 {
+				return t.release, nil
+			}
+
+			select {
+			case <-ctx.Done():
+				t.release()
+
+				return nil, ctx.Err()
+			case <-time.After(delay):
+				return t.release, nil
+			}
+		}
+
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (t *Throttle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inUse--
+	t.broadcast()
+}
+
+// broadcast wakes up the pages waiting for a slot. t.mu must be held.
+func (t *Throttle) broadcast() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Observe records the outcome of a Maps page, blocked when Google refused
+// it, and adjusts the pace of the pages.
+func (t *Throttle) Observe(blocked bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pages++
+
+	if len(t.window) < throttleWindow {
+		t.window = append(t.window, blocked)
+	} else {
+		t.window[t.next] = blocked
+		t.next = (t.next + 1) % throttleWindow
+	}
+
+	if blocked {
+		t.blocked++
+		t.streak = 0
+	} else {
+		t.streak++
+	}
+
+	if time.Since(t.adjusted) < throttleCooldown {
+		return
+	}
+
+	blocks := 0
+
+	for _, b := range t.window {
+		if b {
+			blocks++
+		}
+	}
+
+	switch {
+	case blocked && blocks >= throttleMinBlocks && float64(blocks) >= throttleBlockRate*float64(len(t.window)):
+		t.slowDown()
+	case t.streak >= throttleRampUp && (t.slots < t.maxSlots || t.delay > 0):
+		t.speedUp()
+	}
+}
+
+// slowDown halves the slots and doubles the pause. t.mu must be held.
+func (t *Throttle) slowDown() {
+	t.slots = max(1, t.slots/2)
+	t.delay = min(throttleMaxDelay, max(throttleMinDelay, 2*t.delay))
+
+	t.slowdowns++
+	t.minSlots = min(t.minSlots, t.slots)
+	t.worstDelay = max(t.worstDelay, t.delay)
+
+	t.reset()
+}
+
+// speedUp opens one more slot and halves the pause. t.mu must be held.
+func (t *Throttle) speedUp() {
+	t.slots = min(t.maxSlots, t.slots+1)
+
+	t.delay /= 2
+	if t.delay < throttleMinDelay {
+		t.delay = 0
+	}
+
+	t.speedups++
+
+	t.reset()
+	t.broadcast()
+}
+
+// reset starts a new observation after an adjustment. t.mu must be held.
+func (t *Throttle) reset() {
+	t.window = t.window[:0]
+	t.next = 0
+	t.streak = 0
+	t.adjusted = time.Now()
+}
+
+// ThrottleReport tells how much a job was slowed down by its Throttle.
+type ThrottleReport struct {
+	Pages   int `json:"pages"`
+	Blocked int `json:"blocked"`
+	// Slowdowns and Speedups count the adjustments.
+	Slowdowns int `json:"slowdowns"`
+	Speedups  int `json:"speedups"`
+	// MaxSlots is the concurrency of the job, MinSlots the lowest it was
+	// taken to and Slots the current one.
+	MaxSlots int `json:"max_slots"`
+	MinSlots int `json:"min_slots"`
+	Slots    int `json:"slots"`
+	// DelayMs is the current pause before each page, MaxDelayMs the longest
+	// it was.
+	DelayMs    int64 `json:"delay_ms"`
+	MaxDelayMs int64 `json:"max_delay_ms"`
+}
+
+// Report returns the adjustments made so far, nil when the pages were never
+// slowed down.
+func (t *Throttle) Report() *ThrottleReport {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.slowdowns == 0 {
+		return nil
+	}
+
+	return &ThrottleReport{
+		Pages:      t.pages,
+		Blocked:    t.blocked,
+		Slowdowns:  t.slowdowns,
+		Speedups:   t.speedups,
+		MaxSlots:   t.maxSlots,
+		MinSlots:   t.minSlots,
+		Slots:      t.slots,
+		DelayMs:    t.delay.Milliseconds(),
+		MaxDelayMs: t.worstDelay.Milliseconds(),
+	}
+}
+
+// isSoftBlock reports whether a Maps page that ended on pageURL, with err
+// or statusCode, was refused by Google.
+func isSoftBlock(err error, statusCode int, pageURL string) bool {
+	return errors.Is(err, errMapsBlocked) || isTargetBlock(ClassifyFetchError(nil, statusCode)) || isBlockedPage(pageURL)
+}
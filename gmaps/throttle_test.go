@@ -0,0 +1,97 @@
+package gmaps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleAdjusts(t *testing.T) {
+	throttle := NewThrottle(4)
+
+	for range 10 {
+		throttle.Observe(false)
+	}
+
+	require.Nil(t, throttle.Report(), "no slowdown without blocks")
+
+	throttle.Observe(true)
+	require.Nil(t, throttle.Report(), "a single block is not enough")
+
+	throttle.Observe(true)
+	throttle.Observe(true)
+
+	rep := throttle.Report()
+	require.NotNil(t, rep)
+	require.Equal(t, 1, rep.Slowdowns)
+	require.Equal(t, 2, rep.Slots)
+	require.Equal(t, int64(2000), rep.DelayMs)
+
+	// within the cooldown nothing changes
+	for range throttleRampUp {
+		throttle.Observe(false)
+	}
+
+	require.Equal(t, 2, throttle.Report().Slots)
+
+	throttle.adjusted = time.Now().Add(-throttleCooldown)
+
+	for range throttleRampUp {
+		throttle.Observe(false)
+	}
+
+	rep = throttle.Report()
+	require.Equal(t, 1, rep.Speedups)
+	require.Equal(t, 3, rep.Slots)
+	require.Equal(t, int64(0), rep.DelayMs)
+	require.Equal(t, 2, rep.MinSlots)
+	require.Equal(t, int64(2000), rep.MaxDelayMs)
+	require.Equal(t, 53, rep.Pages)
+	require.Equal(t, 3, rep.Blocked)
+}
+
+func TestThrottleSlots(t *testing.T) {
+	throttle := NewThrottle(1)
+
+	release, err := throttle.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = throttle.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done := make(chan error)
+
+	go func() {
+		release2, err := throttle.acquire(context.Background())
+		if err == nil {
+			release2()
+		}
+
+		done <- err
+	}()
+
+	release()
+	require.NoError(t, <-done)
+}
+
+func TestThrottleNil(t *testing.T) {
+	var throttle *Throttle
+
+	release, err := throttle.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+	throttle.Observe(true)
+	require.Nil(t, throttle.Report())
+}
+
+func TestIsSoftBlock(t *testing.T) {
+	require.True(t, isSoftBlock(nil, 429, ""))
+	require.True(t, isSoftBlock(nil, 200, "https://www.google.com/sorry/index?continue=x"))
+	require.False(t, isSoftBlock(nil, 200, "https://www.google.com/maps/search/pizza"))
+	require.False(t, isSoftBlock(context.DeadlineExceeded, 0, ""))
+}
@@ -0,0 +1,71 @@
+package gmaps
+
+// UpstreamCsvHeaders are the CSV columns of the upstream scraper,
+// gosom/google-maps-scraper, in its order: the first columns of
+// Entry.CsvHeaders, without those this one added in between.
+func UpstreamCsvHeaders() []string {
+	return []string{
+		"input_id",
+		"link",
+		"title",
+		"category",
+		"address",
+		"open_hours",
+		"popular_times",
+		"website",
+		"phone",
+		"plus_code",
+		"review_count",
+		"review_rating",
+		"reviews_per_rating",
+		"latitude",
+		"longitude",
+		"cid",
+		"status",
+		"descriptions",
+		"reviews_link",
+		"thumbnail",
+		"timezone",
+		"price_range",
+		"data_id",
+		"images",
+		"reservations",
+		"order_online",
+		"menu",
+		"owner",
+		"complete_address",
+		"about",
+		"user_reviews",
+		"user_reviews_extended",
+		"emails",
+	}
+}
+
+// UpstreamCSV is an entry whose CSV row has exactly the columns of the
+// upstream scraper, for the scripts written for it.
+type UpstreamCSV struct {
+	*Entry
+}
+
+func (e UpstreamCSV) CsvHeaders() []string {
+	return UpstreamCsvHeaders()
+}
+
+func (e UpstreamCSV) CsvRow() []string {
+	values := make(map[string]string)
+
+	row := e.Entry.CsvRow()
+
+	for i, name := range e.Entry.CsvHeaders() {
+		values[name] = row[i]
+	}
+
+	headers := UpstreamCsvHeaders()
+	ans := make([]string, len(headers))
+
+	for i, name := range headers {
+		ans[i] = values[name]
+	}
+
+	return ans
+}
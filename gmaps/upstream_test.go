@@ -0,0 +1,43 @@
+package gmaps
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamCSV(t *testing.T) {
+	e := &Entry{
+		Title:        "Kipriakon",
+		WebSite:      "https://kipriakon.example",
+		PlaceID:      "ChIJDdnwdv0y5xQRRytw1ihZQeU",
+		DataID:       "0x1:0x2",
+		Emails:       []string{"a@kipriakon.example", "b@kipriakon.example"},
+		EmailStatus:  "found",
+		CustomFields: map[string]string{"vat": "CY123"},
+	}
+
+	headers := UpstreamCSV{Entry: e}.CsvHeaders()
+	row := UpstreamCSV{Entry: e}.CsvRow()
+
+	require.Len(t, headers, 33)
+	require.Len(t, row, len(headers))
+
+	// every upstream column is one of this CSV, with the same value
+	all := e.CsvHeaders()
+	allRow := e.CsvRow()
+
+	for i, name := range headers {
+		j := slices.Index(all, name)
+		require.GreaterOrEqual(t, j, 0, name)
+		require.Equal(t, allRow[j], row[i], name)
+	}
+
+	require.Equal(t, "Kipriakon", row[2])
+	require.Equal(t, "0x1:0x2", row[22])
+	require.Equal(t, "images", headers[23])
+	require.Equal(t, "a@kipriakon.example, b@kipriakon.example", row[32])
+	require.NotContains(t, headers, "place_id")
+	require.NotContains(t, headers, "vat")
+}
@@ -64,3 +64,11 @@ func TestNewGmapJobBuildsURLFromQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestNewGmapJobKeepsOriginalQuery(t *testing.T) {
+	job := gmaps.NewGmapJob("", "en", "  pizza in NYC ", 0, false, "40.7,-73.9", 15)
+
+	if job.Query != "pizza in NYC" {
+		t.Errorf("NewGmapJob(...).Query = %q, want %q", job.Query, "pizza in NYC")
+	}
+}
@@ -0,0 +1,250 @@
+package gmaps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// The validation rules, see Validator.
+const (
+	// RulePhoneCountry: the phone number is a plausible number of the
+	// country of the place, its dialing code when written in international
+	// form.
+	RulePhoneCountry = "phone_country"
+	// RuleWebsiteResolves: the host of the website resolves.
+	RuleWebsiteResolves = "website_resolves"
+	// RuleEmailDomain: one of the emails is on the domain of the website.
+	RuleEmailDomain = "email_domain"
+)
+
+// ValidationRules are all the rules, in the order they run.
+var ValidationRules = []string{RulePhoneCountry, RuleWebsiteResolves, RuleEmailDomain}
+
+// Bounds of the digits of a phone number, after its dialing code: E.164
+// numbers hold 15 digits at most.
+const (
+	phoneMinDigits = 6
+	phoneMaxDigits = 15
+)
+
+// dialingCodes are the international dialing codes by ISO 3166 country
+// code. The countries missing are not checked against their code.
+var dialingCodes = map[string]string{
+	"AR": "54", "AT": "43", "AU": "61", "BE": "32", "BG": "359", "BR": "55",
+	"CA": "1", "CH": "41", "CL": "56", "CN": "86", "CO": "57", "CY": "357",
+	"CZ": "420", "DE": "49", "DK": "45", "EE": "372", "EG": "20", "ES": "34",
+	"FI": "358", "FR": "33", "GB": "44", "GR": "30", "HK": "852", "HR": "385",
+	"HU": "36", "ID": "62", "IE": "353", "IL": "972", "IN": "91", "IS": "354",
+	"IT": "39", "JP": "81", "KR": "82", "LT": "370", "LU": "352", "LV": "371",
+	"MA": "212", "MT": "356", "MX": "52", "MY": "60", "NG": "234", "NL": "31",
+	"NO": "47", "NZ": "64", "PE": "51", "PH": "63", "PK": "92", "PL": "48",
+	"PT": "351", "RO": "40", "RS": "381", "SA": "966", "SE": "46", "SG": "65",
+	"SI": "386", "SK": "421", "TH": "66", "TR": "90", "TW": "886", "UA": "380",
+	"US": "1", "VN": "84", "ZA": "27",
+}
+
+// Validation is the outcome of the validation rules run on a place, see
+// Validator.
+type Validation struct {
+	// Valid is set when no rule failed.
+	Valid bool `json:"valid"`
+	// Checked lists the rules that applied to the place, Failed the ones
+	// it failed among them. A rule whose data the place lacks, like the
+	// phone rule for a place without phone, does not apply.
+	Checked []string `json:"checked,omitempty"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// Quarantined reports whether the place failed a validation rule.
+func (e *Entry) Quarantined() bool {
+	return e.Validation != nil && !e.Validation.Valid
+}
+
+// ParseValidationRules parses a comma separated list of rules, "all" for
+// all of them.
+func ParseValidationRules(s string) ([]string, error) {
+	var ans []string
+
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+
+		switch {
+		case rule == "":
+		case rule == "all":
+			return slices.Clone(ValidationRules), nil
+		case !slices.Contains(ValidationRules, rule):
+			return nil, fmt.Errorf("unknown validation rule %q (use %s or all)", rule, strings.Join(ValidationRules, ", "))
+		case !slices.Contains(ans, rule):
+			ans = append(ans, rule)
+		}
+	}
+
+	return ans, nil
+}
+
+// Validator runs validation rules on the places and sets Entry.Validation
+// with their outcome. It is an Enricher, so that the places are validated
+// before they are written. The exports can then leave the failing places
+// out, or write them apart as a quarantine.
+type Validator struct {
+	rules []string
+	dns   *DNSCache
+}
+
+// NewValidator returns the validator of rules, see ParseValidationRules,
+// resolving the websites through dns (a new cache when nil). It returns nil
+// when there are no rules.
+func NewValidator(rules []string, dns *DNSCache) *Validator {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if dns == nil {
+		dns = NewDNSCache()
+	}
+
+	return &Validator{rules: rules, dns: dns}
+}
+
+// Enrich implements Enricher.
+func (v *Validator) Enrich(ctx context.Context, e *Entry) error {
+	ans := Validation{Valid: true}
+
+	for _, rule := range v.rules {
+		var ok, applies bool
+
+		switch rule {
+		case RulePhoneCountry:
+			ok, applies = validPhone(e.Phone, e.CompleteAddress.Country)
+		case RuleWebsiteResolves:
+			ok, applies = v.websiteResolves(ctx, e.WebSite)
+		case RuleEmailDomain:
+			ok, applies = emailOnWebsiteDomain(e.Emails, e.WebSite)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !applies {
+			continue
+		}
+
+		ans.Checked = append(ans.Checked, rule)
+
+		if !ok {
+			ans.Valid = false
+			ans.Failed = append(ans.Failed, rule)
+		}
+	}
+
+	e.Validation = &ans
+
+	return nil
+}
+
+// validPhone reports whether phone is a plausible number of country. A
+// number in international form must start with the dialing code of
+// country, when known.
+func validPhone(phone, country string) (ok, applies bool) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return false, false
+	}
+
+	var digits strings.Builder
+
+	international := strings.HasPrefix(phone, "+")
+
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '/':
+		default:
+			return false, true
+		}
+	}
+
+	number := digits.String()
+
+	if !international && strings.HasPrefix(number, "00") {
+		international = true
+		number = number[2:]
+	}
+
+	if international {
+		if len(number) > phoneMaxDigits {
+			return false, true
+		}
+
+		code, known := dialingCodes[strings.ToUpper(strings.TrimSpace(country))]
+		if known {
+			if !strings.HasPrefix(number, code) {
+				return false, true
+			}
+
+			number = number[len(code):]
+		}
+	}
+
+	return len(number) >= phoneMinDigits && len(number) <= phoneMaxDigits, true
+}
+
+// websiteResolves reports whether the host of website resolves. Only a
+// "no such host" answer fails: a resolver timing out tells nothing about
+// the website.
+func (v *Validator) websiteResolves(ctx context.Context, website string) (ok, applies bool) {
+	host := websiteHost(website)
+	if host == "" {
+		return false, strings.TrimSpace(website) != ""
+	}
+
+	_, err := v.dns.LookupHost(ctx, host)
+
+	var dnsErr *net.DNSError
+
+	return !errors.As(err, &dnsErr) || !dnsErr.IsNotFound, true
+}
+
+func websiteHost(website string) string {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return ""
+	}
+
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
+// emailOnWebsiteDomain reports whether one of emails is on the registrable
+// domain of website. It applies to the places with both emails and a
+// website of their own, not a platform shared by unrelated businesses.
+func emailOnWebsiteDomain(emails []string, website string) (ok, applies bool) {
+	domain := ChainDomain(website)
+	if domain == "" || len(emails) == 0 {
+		return false, false
+	}
+
+	for _, email := range emails {
+		_, host, found := strings.Cut(email, "@")
+		if found && ChainDomain(host) == domain {
+			return true, true
+		}
+	}
+
+	return false, true
+}
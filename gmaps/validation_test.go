@@ -0,0 +1,87 @@
+package gmaps
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValidationRules(t *testing.T) {
+	rules, err := ParseValidationRules(" email_domain, phone_country ,email_domain")
+	require.NoError(t, err)
+	require.Equal(t, []string{RuleEmailDomain, RulePhoneCountry}, rules)
+
+	rules, err = ParseValidationRules("all")
+	require.NoError(t, err)
+	require.Equal(t, ValidationRules, rules)
+
+	_, err = ParseValidationRules("phone")
+	require.Error(t, err)
+
+	require.Nil(t, NewValidator(nil, nil))
+}
+
+func TestValidPhone(t *testing.T) {
+	for _, tc := range []struct {
+		phone, country string
+		ok, applies    bool
+	}{
+		{"", "IT", false, false},
+		{"+39 02 1234 5678", "IT", true, true},
+		{"0039 02 1234 5678", "IT", true, true},
+		{"+33 1 23 45 67 89", "IT", false, true},
+		{"+33 1 23 45 67 89", "", true, true},
+		{"02 1234 5678", "IT", true, true},
+		{"(555) 123-4567", "US", true, true},
+		{"123", "IT", false, true},
+		{"call us", "IT", false, true},
+	} {
+		ok, applies := validPhone(tc.phone, tc.country)
+		require.Equal(t, tc.ok, ok, tc.phone)
+		require.Equal(t, tc.applies, applies, tc.phone)
+	}
+}
+
+func TestEmailOnWebsiteDomain(t *testing.T) {
+	ok, applies := emailOnWebsiteDomain([]string{"info@trattoria.it"}, "https://www.trattoria.it/menu")
+	require.True(t, ok)
+	require.True(t, applies)
+
+	ok, applies = emailOnWebsiteDomain([]string{"trattoria@gmail.com"}, "https://shop.trattoria.it")
+	require.False(t, ok)
+	require.True(t, applies)
+
+	_, applies = emailOnWebsiteDomain(nil, "https://trattoria.it")
+	require.False(t, applies)
+}
+
+func TestValidatorEnrich(t *testing.T) {
+	dns := NewDNSCache()
+	dns.lookupHost = func(_ context.Context, host string) ([]string, error) {
+		if host == "gone.example" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+
+		return []string{"192.0.2.1"}, nil
+	}
+
+	v := NewValidator(ValidationRules, dns)
+
+	e := Entry{
+		Phone:           "+39 02 1234 5678",
+		WebSite:         "https://trattoria.example",
+		Emails:          []string{"info@trattoria.example"},
+		CompleteAddress: Address{Country: "IT"},
+	}
+	require.NoError(t, v.Enrich(context.Background(), &e))
+	require.Equal(t, &Validation{Valid: true, Checked: ValidationRules}, e.Validation)
+	require.False(t, e.Quarantined())
+
+	e = Entry{WebSite: "http://gone.example", Emails: []string{"gone@gmail.com"}}
+	require.NoError(t, v.Enrich(context.Background(), &e))
+	require.Equal(t, []string{RuleWebsiteResolves, RuleEmailDomain}, e.Validation.Failed)
+	require.Equal(t, []string{RuleWebsiteResolves, RuleEmailDomain}, e.Validation.Checked)
+	require.True(t, e.Quarantined())
+}
@@ -0,0 +1,153 @@
+package gmaps
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebsiteStatus is what the fetch of the homepage of a place told about its
+// website, set by the email pipeline at Level 1. A website that is down,
+// expired or parked is a lead of its own.
+type WebsiteStatus struct {
+	// Reachable reports whether the server answered, whatever the status.
+	Reachable bool `json:"reachable"`
+	// HTTPStatus is the status of the last answer, after the redirects.
+	HTTPStatus int `json:"http_status,omitempty"`
+	// FinalURL is the URL answering, after the redirects.
+	FinalURL string `json:"final_url,omitempty"`
+	// TLSValid reports whether the certificate of an https FinalURL is
+	// trusted, matches the host and has not expired. It is nil over http.
+	TLSValid *bool `json:"tls_valid,omitempty"`
+	// TLSExpiresAt is when that certificate expires.
+	TLSExpiresAt *time.Time `json:"tls_expires_at,omitempty"`
+	// Parked reports whether the homepage is a parking or a domain for sale
+	// page, see isParkedPage.
+	Parked bool `json:"parked,omitempty"`
+	// Error is the failure class (FetchErrTimeout, FetchErrTLS, ...) of the
+	// homepage when the server did not answer.
+	Error string `json:"error,omitempty"`
+}
+
+// parkingHosts are the domain marketplaces and parking services the
+// parked domains redirect to.
+var parkingHosts = []string{
+	"afternic.com",
+	"bodis.com",
+	"dan.com",
+	"hugedomains.com",
+	"parkingcrew.net",
+	"sedo.com",
+	"sedoparking.com",
+	"undeveloped.com",
+}
+
+// parkingMarkers are the texts and scripts of the parking pages, lower
+// case.
+var parkingMarkers = [][]byte{
+	[]byte("this domain is for sale"),
+	[]byte("this domain may be for sale"),
+	[]byte("buy this domain"),
+	[]byte("domain is parked"),
+	[]byte("this web page is parked"),
+	[]byte("parked free, courtesy of"),
+	[]byte("domain has expired"),
+	[]byte("sedoparking.com"),
+	[]byte("parkingcrew.net"),
+	[]byte("bodis.com"),
+}
+
+// websiteStatusOf returns the status of a homepage answering resp.
+func websiteStatusOf(resp *http.Response) *WebsiteStatus {
+	status := &WebsiteStatus{
+		Reachable:  true,
+		HTTPStatus: resp.StatusCode,
+	}
+
+	if resp.Request != nil {
+		status.FinalURL = resp.Request.URL.String()
+		status.Parked = isParkingHost(resp.Request.URL.Hostname())
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 && resp.Request != nil {
+		valid := verifyPeerCertificates(resp.TLS, resp.Request.URL.Hostname())
+		expires := resp.TLS.PeerCertificates[0].NotAfter.UTC()
+
+		status.TLSValid = &valid
+		status.TLSExpiresAt = &expires
+	}
+
+	return status
+}
+
+// verifyPeerCertificates reports whether the certificates of state are
+// valid for host. The email pipeline fetches without verifying them, not to
+// miss the emails of the websites with a broken certificate.
+func verifyPeerCertificates(state *tls.ConnectionState, host string) bool {
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+
+	return err == nil
+}
+
+func isParkingHost(host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+
+	for _, h := range parkingHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isParkedPage reports whether the homepage body is a parking or a domain
+// for sale page.
+func isParkedPage(body []byte) bool {
+	lower := bytes.ToLower(body)
+
+	for _, m := range parkingMarkers {
+		if bytes.Contains(lower, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// websiteStatusCsvValues returns the website status fields of e for its CSV
+// row.
+func (e *Entry) websiteStatusCsvValues() []string {
+	s := e.WebsiteStatus
+	if s == nil {
+		return []string{"", "", "", "", "", ""}
+	}
+
+	status := ""
+	if s.HTTPStatus != 0 {
+		status = stringify(s.HTTPStatus)
+	}
+
+	valid, expires := "", ""
+	if s.TLSValid != nil {
+		valid = stringify(*s.TLSValid)
+	}
+
+	if s.TLSExpiresAt != nil {
+		expires = s.TLSExpiresAt.Format(time.RFC3339)
+	}
+
+	return []string{stringify(s.Reachable), status, s.FinalURL, valid, expires, stringify(s.Parked)}
+}
@@ -0,0 +1,83 @@
+package gmaps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailPipelineWebsiteStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			fmt.Fprint(w, `<html><body><h1>This domain is for sale!</h1></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &WebsiteStatus{
+		Reachable:  true,
+		HTTPStatus: http.StatusOK,
+		FinalURL:   srv.URL + "/home",
+		Parked:     true,
+	}, entry.WebsiteStatus)
+
+	require.Equal(t, "true", csvValue(t, entry, "website_reachable"))
+	require.Equal(t, "200", csvValue(t, entry, "website_http_status"))
+	require.Equal(t, "", csvValue(t, entry, "website_tls_valid"))
+	require.Equal(t, "true", csvValue(t, entry, "website_parked"))
+}
+
+func TestEmailPipelineWebsiteStatusTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	entry := &Entry{WebSite: srv.URL}
+
+	err := NewEmailPipeline(entry, nil).Run(context.Background())
+	require.NoError(t, err)
+
+	status := entry.WebsiteStatus
+	require.NotNil(t, status)
+	require.True(t, status.Reachable)
+	require.Equal(t, http.StatusNotFound, status.HTTPStatus)
+	// the certificate of httptest is self-signed
+	require.NotNil(t, status.TLSValid)
+	require.False(t, *status.TLSValid)
+	require.NotNil(t, status.TLSExpiresAt)
+	require.False(t, status.Parked)
+}
+
+func TestEmailPipelineWebsiteStatusUnreachable(t *testing.T) {
+	entry := &Entry{WebSite: "http://127.0.0.1:1"}
+
+	err := NewEmailPipeline(entry, nil).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &WebsiteStatus{Error: FetchErrConnRefused}, entry.WebsiteStatus)
+	require.Equal(t, "false", csvValue(t, entry, "website_reachable"))
+	require.Equal(t, "", csvValue(t, entry, "website_http_status"))
+}
+
+func TestEntryWebsiteStatusCsv(t *testing.T) {
+	e := Entry{}
+
+	for _, column := range []string{"website_reachable", "website_final_url", "website_parked"} {
+		require.Equal(t, "", csvValue(t, &e, column))
+	}
+}
@@ -34,11 +34,17 @@ func StripNULFromEntry(entry *gmaps.Entry) {
 	entry.Thumbnail = cleanString(entry.Thumbnail)
 	entry.Timezone = cleanString(entry.Timezone)
 	entry.PriceRange = cleanString(entry.PriceRange)
+	entry.PriceCurrency = cleanString(entry.PriceCurrency)
 	entry.DataID = cleanString(entry.DataID)
 	entry.PlaceID = cleanString(entry.PlaceID)
+	entry.Query = cleanString(entry.Query)
+	entry.ServiceArea = cleanString(entry.ServiceArea)
+	entry.CheckInTime = cleanString(entry.CheckInTime)
+	entry.CheckOutTime = cleanString(entry.CheckOutTime)
 
 	cleanStringSlice(entry.Categories)
 	cleanStringSlice(entry.Emails)
+	cleanStringSlice(entry.Amenities)
 
 	entry.OpenHours = cleanOpenHours(entry.OpenHours)
 	entry.PopularTimes = cleanPopularTimes(entry.PopularTimes)
@@ -46,10 +52,18 @@ func StripNULFromEntry(entry *gmaps.Entry) {
 	cleanImages(entry.Images)
 	cleanLinkSources(entry.Reservations)
 	cleanLinkSources(entry.OrderOnline)
+	cleanBookingLinks(entry.BookingLinks)
+	cleanOSMMatch(entry.OSM)
+	cleanRegistrationInfo(entry.RegistrationInfo)
+
+	if entry.AI != nil {
+		entry.AI.Summary = cleanString(entry.AI.Summary)
+	}
 	cleanLinkSource(&entry.Menu)
 	cleanOwner(&entry.Owner)
 	cleanAddress(&entry.CompleteAddress)
 	cleanAbout(entry.About)
+	entry.Attributes = cleanAttributes(entry.Attributes)
 	cleanReviews(entry.UserReviews)
 	cleanReviews(entry.UserReviewsExtended)
 }
@@ -145,11 +159,66 @@ func cleanAbout(items []gmaps.About) {
 		items[i].Name = cleanString(items[i].Name)
 
 		for j := range items[i].Options {
+			items[i].Options[j].ID = cleanString(items[i].Options[j].ID)
 			items[i].Options[j].Name = cleanString(items[i].Options[j].Name)
+			items[i].Options[j].Value = cleanString(items[i].Options[j].Value)
+			cleanStringSlice(items[i].Options[j].Values)
 		}
 	}
 }
 
+func cleanBookingLinks(items []gmaps.BookingLink) {
+	for i := range items {
+		items[i].Link = cleanString(items[i].Link)
+		items[i].Source = cleanString(items[i].Source)
+		items[i].Platform = cleanString(items[i].Platform)
+	}
+}
+
+func cleanOSMMatch(m *gmaps.OSMMatch) {
+	if m == nil {
+		return
+	}
+
+	m.Name = cleanString(m.Name)
+	m.Address = cleanString(m.Address)
+}
+
+func cleanRegistrationInfo(info *gmaps.RegistrationInfo) {
+	if info == nil {
+		return
+	}
+
+	cleanStringSlice(info.VATNumbers)
+	cleanStringSlice(info.RegistrationNumbers)
+	info.Source = cleanString(info.Source)
+
+	if info.VAT != nil {
+		info.VAT.Name = cleanString(info.VAT.Name)
+		info.VAT.Address = cleanString(info.VAT.Address)
+	}
+}
+
+func cleanAttributes(in map[string]map[string]string) map[string]map[string]string {
+	if len(in) == 0 {
+		return in
+	}
+
+	out := make(map[string]map[string]string, len(in))
+
+	for group, attrs := range in {
+		cleaned := make(map[string]string, len(attrs))
+
+		for k, v := range attrs {
+			cleaned[cleanString(k)] = cleanString(v)
+		}
+
+		out[cleanString(group)] = cleaned
+	}
+
+	return out
+}
+
 func cleanReviews(items []gmaps.Review) {
 	for i := range items {
 		items[i].Name = cleanString(items[i].Name)
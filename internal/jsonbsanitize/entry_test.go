@@ -35,10 +35,13 @@ func TestStripNULFromEntry_NestedFields(t *testing.T) {
 				ID:   "about\x00",
 				Name: "about-name\x00",
 				Options: []gmaps.Option{
-					{Name: "opt\x00", Enabled: true},
+					{Name: "opt\x00", Enabled: true, Value: "val\x00", Values: []string{"v\x001"}},
 				},
 			},
 		},
+		Attributes: map[string]map[string]string{
+			"pay\x00ments": {"pay_credit\x00_card": "ye\x00s"},
+		},
 		UserReviews: []gmaps.Review{
 			{
 				Name:           "reviewer\x00",
@@ -69,6 +72,9 @@ func TestStripNULFromEntry_NestedFields(t *testing.T) {
 	require.Equal(t, "about", entry.About[0].ID)
 	require.Equal(t, "about-name", entry.About[0].Name)
 	require.Equal(t, "opt", entry.About[0].Options[0].Name)
+	require.Equal(t, "val", entry.About[0].Options[0].Value)
+	require.Equal(t, []string{"v1"}, entry.About[0].Options[0].Values)
+	require.Equal(t, map[string]map[string]string{"payments": {"pay_credit_card": "yes"}}, entry.Attributes)
 	require.Equal(t, "reviewer", entry.UserReviews[0].Name)
 	require.Equal(t, "pp", entry.UserReviews[0].ProfilePicture)
 	require.Equal(t, "desc", entry.UserReviews[0].Description)
@@ -74,6 +74,12 @@ func runnerFactory(cfg *runner.Config) (runner.Runner, error) {
 		return installplaywright.New(cfg)
 	case runner.RunModeWeb:
 		return webrunner.New(cfg)
+	case runner.RunModeWorker:
+		return webrunner.NewWorker(cfg)
+	case runner.RunModeBackup, runner.RunModeRestore:
+		return webrunner.NewBackup(cfg)
+	case runner.RunModeMigrate:
+		return webrunner.NewMigrate(cfg)
 	case runner.RunModeAwsLambda:
 		return lambdaaws.New(cfg)
 	case runner.RunModeAwsLambdaInvoker:
@@ -0,0 +1,362 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	// DefaultLLMReviews is the number of review snippets sent per place.
+	DefaultLLMReviews = 3
+	// DefaultLLMBatchSize is the number of places of a request to the
+	// model.
+	DefaultLLMBatchSize = 10
+
+	maxLLMSnippet  = 300
+	maxLLMResponse = 8 << 20
+	// llmAnswerTokens is about what the answer for a place takes.
+	llmAnswerTokens = 60
+)
+
+const llmSystemPrompt = `You assess local businesses for a sales team.
+For each place of the JSON array of the user, write a one-line summary of the business, at most 25 words, and score it as a lead from 0 (worst) to 100 (best) following the instructions below.
+Answer with a JSON object and nothing else: {"places": [{"id": <id of the place>, "summary": "<summary>", "score": <score>}]}
+
+Instructions:
+`
+
+// LLM asks a language model, behind an OpenAI compatible chat completions
+// API, for a one-line summary and a lead score of each place against the
+// Prompt of the user, stored in Entry.AI. The places of a batch share a
+// request. Places the model says nothing about are written without AI.
+type LLM struct {
+	// URL is the base URL of the API, like "https://api.openai.com/v1" or
+	// "http://localhost:11434/v1".
+	URL    string
+	APIKey string
+	Model  string
+	// Prompt tells the model what makes a good lead, like "we sell card
+	// terminals to restaurants".
+	Prompt string
+	// Reviews is the number of review snippets sent per place: 0 for
+	// DefaultLLMReviews, negative for none.
+	Reviews int
+	// MaxTokens caps the tokens of all the requests, prompts and answers,
+	// 0 meaning no cap. Once it would be crossed, the places are written
+	// without AI.
+	MaxTokens int
+	// DryRun writes the requests to DryRunOutput, stderr when nil, with
+	// their estimated tokens, instead of sending them: to check the prompt
+	// and the cost before a real run.
+	DryRun       bool
+	DryRunOutput io.Writer
+	Client       *http.Client
+	Timeout      time.Duration
+
+	mu     sync.Mutex
+	used   int
+	capped bool
+}
+
+// Used returns the tokens used so far, estimated in a dry run.
+func (l *LLM) Used() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.used
+}
+
+type llmPlace struct {
+	ID          int      `json:"id"`
+	Title       string   `json:"title"`
+	Categories  []string `json:"categories,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	WebSite     string   `json:"website,omitempty"`
+	Rating      float64  `json:"rating,omitempty"`
+	ReviewCount int      `json:"review_count,omitempty"`
+	PriceRange  string   `json:"price_range,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	Reviews     []string `json:"reviews,omitempty"`
+}
+
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llmRequest struct {
+	Model          string       `json:"model"`
+	Messages       []llmMessage `json:"messages"`
+	Temperature    float64      `json:"temperature"`
+	ResponseFormat struct {
+		Type string `json:"type"`
+	} `json:"response_format"`
+}
+
+type llmResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message llmMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Process implements Hook.
+func (l *LLM) Process(ctx context.Context, entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	req := llmRequest{
+		Model: l.Model,
+		Messages: []llmMessage{
+			{Role: "system", Content: llmSystemPrompt + l.Prompt},
+			{Role: "user", Content: l.places(entries)},
+		},
+	}
+	req.ResponseFormat.Type = "json_object"
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// about 4 bytes a token
+	estimate := len(body)/4 + llmAnswerTokens*len(entries)
+
+	if !l.reserve(estimate) {
+		return entries, nil
+	}
+
+	// a dry run keeps the estimate reserved as its usage
+	if l.DryRun {
+		out := l.DryRunOutput
+		if out == nil {
+			out = os.Stderr
+		}
+
+		_, err := fmt.Fprintf(out, "llm dry run: %d places, about %d tokens\n%s\n", len(entries), estimate, body)
+
+		return entries, err
+	}
+
+	resp, err := l.send(ctx, body)
+	if err != nil {
+		l.settle(estimate, 0)
+
+		return nil, err
+	}
+
+	used := resp.Usage.TotalTokens
+	if used == 0 {
+		used = estimate
+	}
+
+	l.settle(estimate, used)
+
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("llm: no answer")
+	}
+
+	insights, err := parseLLMAnswer(resp.Choices[0].Message.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = l.Model
+	}
+
+	for _, insight := range insights {
+		if insight.ID < 0 || insight.ID >= len(entries) {
+			continue
+		}
+
+		entries[insight.ID].AI = &gmaps.AIInsight{
+			Summary: strings.TrimSpace(insight.Summary),
+			Score:   int(math.Round(min(max(insight.Score, 0), 100))),
+			Model:   model,
+		}
+	}
+
+	return entries, nil
+}
+
+// reserve reports whether a request of about tokens stays within
+// MaxTokens, and counts them as used when it does, so that the batches
+// sent at once cannot cross the cap together. settle corrects them once
+// the request is answered.
+func (l *LLM) reserve(tokens int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.MaxTokens <= 0 || l.used+tokens <= l.MaxTokens {
+		l.used += tokens
+
+		return true
+	}
+
+	if !l.capped {
+		l.capped = true
+
+		log.Printf("llm: the token cap of %d is reached (%d used), the next places are written without AI", l.MaxTokens, l.used)
+	}
+
+	return false
+}
+
+// settle replaces the reserved tokens of a request with those it used, 0
+// when it failed.
+func (l *LLM) settle(reserved, used int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.used += used - reserved
+}
+
+// places returns the JSON array of the places sent to the model, each
+// with its index in entries as ID.
+func (l *LLM) places(entries []*gmaps.Entry) string {
+	reviews := l.Reviews
+	if reviews == 0 {
+		reviews = DefaultLLMReviews
+	}
+
+	places := make([]llmPlace, 0, len(entries))
+
+	for i, e := range entries {
+		p := llmPlace{
+			ID:          i,
+			Title:       e.Title,
+			Categories:  e.Categories,
+			Address:     e.Address,
+			WebSite:     e.WebSite,
+			Rating:      e.ReviewRating,
+			ReviewCount: e.ReviewCount,
+			PriceRange:  e.PriceRange,
+			Description: e.Description,
+			Status:      e.Status,
+		}
+
+		if len(p.Categories) == 0 && e.Category != "" {
+			p.Categories = []string{e.Category}
+		}
+
+		for _, r := range slices.Concat(e.UserReviews, e.UserReviewsExtended) {
+			if len(p.Reviews) >= reviews {
+				break
+			}
+
+			if text := strings.Join(strings.Fields(r.Description), " "); text != "" {
+				p.Reviews = append(p.Reviews, truncateSnippet(text))
+			}
+		}
+
+		places = append(places, p)
+	}
+
+	data, _ := json.Marshal(places)
+
+	return string(data)
+}
+
+func truncateSnippet(s string) string {
+	if len(s) <= maxLLMSnippet {
+		return s
+	}
+
+	s = s[:maxLLMSnippet]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s + "…"
+}
+
+func (l *LLM) send(ctx context.Context, body []byte) (*llmResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout(l.Timeout))
+	defer cancel()
+
+	u := strings.TrimRight(l.URL, "/") + "/chat/completions"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if l.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.APIKey)
+	}
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("llm: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return nil, fmt.Errorf("llm: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var ans llmResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxLLMResponse)).Decode(&ans); err != nil {
+		return nil, fmt.Errorf("llm: %w", err)
+	}
+
+	return &ans, nil
+}
+
+type llmInsight struct {
+	ID      int     `json:"id"`
+	Summary string  `json:"summary"`
+	Score   float64 `json:"score"`
+}
+
+// parseLLMAnswer reads the insights of the answer of the model, which may
+// come in a Markdown code block.
+func parseLLMAnswer(content string) ([]llmInsight, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+
+	var ans struct {
+		Places []llmInsight `json:"places"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &ans); err != nil {
+		return nil, fmt.Errorf("llm: unexpected answer: %w", err)
+	}
+
+	return ans.Places, nil
+}
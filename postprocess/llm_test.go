@@ -0,0 +1,183 @@
+package postprocess_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/postprocess"
+)
+
+func llmServer(t *testing.T, content string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "small-model", req.Model)
+		require.Len(t, req.Messages, 2)
+		require.True(t, strings.HasSuffix(req.Messages[0].Content, "We sell coffee machines."))
+
+		var places []map[string]any
+
+		require.NoError(t, json.Unmarshal([]byte(req.Messages[1].Content), &places))
+		require.Len(t, places, 3)
+		require.EqualValues(t, 0, places[0]["id"])
+		require.Equal(t, "Kipriakon", places[0]["title"])
+		require.Equal(t, []any{"Great souvlaki"}, places[0]["reviews"])
+
+		answer, _ := json.Marshal(content)
+		_, _ = w.Write([]byte(`{"model":"small-model-2024","choices":[{"message":{"role":"assistant","content":` + string(answer) + `}}],"usage":{"total_tokens":500}}`))
+	}))
+}
+
+func TestLLM(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := llmServer(t, "```json\n"+`{"places":[{"id":1,"summary":" Coffee shop chain ","score":91.6},{"id":0,"summary":"Greek taverna","score":140},{"id":7,"summary":"?","score":1}]}`+"\n```", &calls)
+	defer srv.Close()
+
+	hook := &postprocess.LLM{URL: srv.URL + "/v1/", APIKey: "secret", Model: "small-model", Prompt: "We sell coffee machines."}
+
+	entries := testEntries()
+	entries[0].UserReviews = []gmaps.Review{{Description: "  Great\n souvlaki "}, {Description: ""}}
+
+	entries, err := hook.Process(context.Background(), entries)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, &gmaps.AIInsight{Summary: "Greek taverna", Score: 100, Model: "small-model-2024"}, entries[0].AI)
+	require.Equal(t, &gmaps.AIInsight{Summary: "Coffee shop chain", Score: 92, Model: "small-model-2024"}, entries[1].AI)
+	require.Nil(t, entries[2].AI)
+	require.Equal(t, 500, hook.Used())
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestLLMTokenCap(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := llmServer(t, `{"places":[]}`, &calls)
+	defer srv.Close()
+
+	hook := &postprocess.LLM{URL: srv.URL + "/v1", APIKey: "secret", Model: "small-model", Prompt: "We sell coffee machines.", MaxTokens: 800}
+
+	entries := testEntries()
+	entries[0].UserReviews = []gmaps.Review{{Description: "Great souvlaki"}}
+
+	_, err := hook.Process(context.Background(), entries)
+	require.NoError(t, err)
+
+	// the next request would cross the cap
+	entries, err = hook.Process(context.Background(), entries)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.EqualValues(t, 1, calls.Load())
+	require.Equal(t, 500, hook.Used())
+}
+
+func TestLLMTokenCapConcurrent(t *testing.T) {
+	entries := func() []*gmaps.Entry {
+		ans := testEntries()
+		ans[0].UserReviews = []gmaps.Review{{Description: "Great souvlaki"}}
+
+		return ans
+	}
+
+	dry := &postprocess.LLM{Model: "small-model", Prompt: "We sell coffee machines.", DryRun: true, DryRunOutput: io.Discard}
+
+	_, err := dry.Process(context.Background(), entries())
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+
+	srv := llmServer(t, `{"places":[]}`, &calls)
+	defer srv.Close()
+
+	// room for one request only
+	hook := &postprocess.LLM{URL: srv.URL + "/v1", APIKey: "secret", Model: "small-model", Prompt: "We sell coffee machines.", MaxTokens: dry.Used() * 3 / 2}
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, 4)
+
+	for i := range errs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, errs[i] = hook.Process(context.Background(), entries())
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.EqualValues(t, 1, calls.Load())
+	require.Equal(t, 500, hook.Used())
+}
+
+func TestLLMDryRun(t *testing.T) {
+	var out bytes.Buffer
+
+	hook := &postprocess.LLM{URL: "http://127.0.0.1:1", Model: "small-model", Prompt: "We sell coffee machines.", DryRun: true, DryRunOutput: &out}
+
+	entries, err := hook.Process(context.Background(), testEntries())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Nil(t, entries[0].AI)
+	require.Contains(t, out.String(), "llm dry run: 3 places, about ")
+	require.Contains(t, out.String(), "Coffee Island")
+	require.Positive(t, hook.Used())
+}
+
+func TestLLMErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	hook := &postprocess.LLM{URL: srv.URL, Model: "small-model"}
+
+	_, err := hook.Process(context.Background(), testEntries())
+	require.ErrorContains(t, err, "HTTP 401")
+	// the tokens reserved for a failed request are given back
+	require.Zero(t, hook.Used())
+
+	var calls atomic.Int32
+
+	bad := llmServer(t, "I cannot help with that.", &calls)
+	defer bad.Close()
+
+	hook = &postprocess.LLM{URL: bad.URL + "/v1", APIKey: "secret", Model: "small-model", Prompt: "We sell coffee machines."}
+
+	entries := testEntries()
+	entries[0].UserReviews = []gmaps.Review{{Description: "Great souvlaki"}}
+
+	_, err = hook.Process(context.Background(), entries)
+	require.ErrorContains(t, err, "unexpected answer")
+}
@@ -0,0 +1,164 @@
+// Package postprocess hands the scraped entries to a user hook, a shell
+// command or a webhook, which can transform, filter or annotate them before
+// they are written.
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	// DefaultTimeout bounds a single call of a hook.
+	DefaultTimeout = 30 * time.Second
+
+	maxHookResponse = 64 << 20 // 64MB
+)
+
+// Hook processes a batch of entries. The entries it returns replace the
+// batch: leaving some out drops them.
+type Hook interface {
+	Process(ctx context.Context, entries []*gmaps.Entry) ([]*gmaps.Entry, error)
+}
+
+// ParseHook builds the Hook described by spec:
+//
+//	https://example.com/hook   POST the batch to a webhook (Webhook)
+//	jq -c '...'                pipe the batch through a shell command (Command)
+func ParseHook(spec string) (Hook, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("empty post-processing hook")
+	}
+
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid webhook URL %q", spec)
+		}
+
+		return &Webhook{URL: spec}, nil
+	}
+
+	return &Command{Command: spec}, nil
+}
+
+// Command runs a shell command per batch. The command reads the batch as a
+// JSON array on stdin and writes the entries to keep, as a JSON array, on
+// stdout.
+type Command struct {
+	Command string
+	Timeout time.Duration
+}
+
+// Process implements Hook.
+func (c *Command) Process(ctx context.Context, entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+	input, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(c.Timeout))
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("post-processing command: %w: %s", err, msg)
+		}
+
+		return nil, fmt.Errorf("post-processing command: %w", err)
+	}
+
+	return decodeEntries(&stdout)
+}
+
+// Webhook POSTs each batch, as a JSON array, to URL. It answers with the
+// entries to keep as a JSON array, or with 204 No Content to keep the batch
+// as it is.
+type Webhook struct {
+	URL     string
+	Client  *http.Client
+	Timeout time.Duration
+}
+
+// Process implements Hook.
+func (h *Webhook) Process(ctx context.Context, entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(h.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("post-processing webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		return entries, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("post-processing webhook: HTTP %d", resp.StatusCode)
+	}
+
+	return decodeEntries(io.LimitReader(resp.Body, maxHookResponse))
+}
+
+func decodeEntries(r io.Reader) ([]*gmaps.Entry, error) {
+	var decoded []*gmaps.Entry
+
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decoding post-processed entries: %w", err)
+	}
+
+	ans := make([]*gmaps.Entry, 0, len(decoded))
+
+	for _, e := range decoded {
+		if e != nil {
+			ans = append(ans, e)
+		}
+	}
+
+	return ans, nil
+}
+
+func timeout(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultTimeout
+	}
+
+	return d
+}
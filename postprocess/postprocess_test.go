@@ -0,0 +1,216 @@
+package postprocess_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/postprocess"
+	"github.com/gosom/scrapemate"
+)
+
+func testEntries() []*gmaps.Entry {
+	return []*gmaps.Entry{
+		{Title: "Kipriakon", Category: "Restaurant"},
+		{Title: "Coffee Island", Category: "Coffee shop"},
+		{Title: "Wine Bar", Category: "Bar"},
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	hook, err := postprocess.ParseHook("https://example.com/hook")
+	require.NoError(t, err)
+	require.IsType(t, &postprocess.Webhook{}, hook)
+
+	hook, err = postprocess.ParseHook(" jq -c . ")
+	require.NoError(t, err)
+	require.Equal(t, &postprocess.Command{Command: "jq -c ."}, hook)
+
+	_, err = postprocess.ParseHook("  ")
+	require.Error(t, err)
+
+	_, err = postprocess.ParseHook("https://")
+	require.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	// keeps the input as it is
+	hook := &postprocess.Command{Command: "cat"}
+
+	entries, err := hook.Process(context.Background(), testEntries())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "Kipriakon", entries[0].Title)
+
+	hook = &postprocess.Command{Command: "echo '[null, {\"title\": \"only\"}]'"}
+
+	entries, err = hook.Process(context.Background(), testEntries())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "only", entries[0].Title)
+
+	hook = &postprocess.Command{Command: "echo broken >&2; exit 3"}
+
+	_, err = hook.Process(context.Background(), testEntries())
+	require.ErrorContains(t, err, "broken")
+
+	hook = &postprocess.Command{Command: "echo not json"}
+
+	_, err = hook.Process(context.Background(), testEntries())
+	require.Error(t, err)
+}
+
+func TestWebhook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []*gmaps.Entry
+		if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/keep":
+			w.WriteHeader(http.StatusNoContent)
+		case "/annotate":
+			for _, e := range entries {
+				e.CustomFields = map[string]string{"segment": "horeca"}
+			}
+
+			_ = json.NewEncoder(w).Encode(entries[:1])
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	hook := &postprocess.Webhook{URL: srv.URL + "/annotate"}
+
+	entries, err := hook.Process(context.Background(), testEntries())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]string{"segment": "horeca"}, entries[0].CustomFields)
+
+	hook = &postprocess.Webhook{URL: srv.URL + "/keep"}
+
+	entries, err = hook.Process(context.Background(), testEntries())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	hook = &postprocess.Webhook{URL: srv.URL + "/fail"}
+
+	_, err = hook.Process(context.Background(), testEntries())
+	require.ErrorContains(t, err, "HTTP 500")
+}
+
+type hookFunc func([]*gmaps.Entry) ([]*gmaps.Entry, error)
+
+func (f hookFunc) Process(_ context.Context, entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+	return f(entries)
+}
+
+type collectWriter struct {
+	results []scrapemate.Result
+}
+
+func (c *collectWriter) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	for r := range in {
+		c.results = append(c.results, r)
+	}
+
+	return nil
+}
+
+func (c *collectWriter) titles() []string {
+	var ans []string
+
+	for _, r := range c.results {
+		for _, e := range r.Data.([]*gmaps.Entry) {
+			ans = append(ans, e.Title)
+		}
+	}
+
+	return ans
+}
+
+func runWriter(t *testing.T, hook postprocess.Hook, batchSize int, results ...any) *collectWriter {
+	t.Helper()
+
+	next := &collectWriter{}
+	in := make(chan scrapemate.Result, len(results))
+
+	for _, r := range results {
+		in <- scrapemate.Result{Data: r}
+	}
+
+	close(in)
+
+	require.NoError(t, postprocess.NewWriter(next, hook, batchSize).Run(context.Background(), in))
+
+	return next
+}
+
+func TestWriterFiltersInBatches(t *testing.T) {
+	var batches []int
+
+	onlyRestaurants := hookFunc(func(entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+		batches = append(batches, len(entries))
+
+		var kept []*gmaps.Entry
+
+		for _, e := range entries {
+			if e.Category != "Bar" {
+				kept = append(kept, e)
+			}
+		}
+
+		return kept, nil
+	})
+
+	all := testEntries()
+
+	next := runWriter(t, onlyRestaurants, 2, all[0], all[1], []*gmaps.Entry{all[2]})
+
+	require.Equal(t, []int{2, 1}, batches)
+	// the last batch was dropped entirely and is not written
+	require.Len(t, next.results, 1)
+	require.Equal(t, []string{"Kipriakon", "Coffee Island"}, next.titles())
+}
+
+func TestWriterKeepsBatchOnHookError(t *testing.T) {
+	failing := hookFunc(func([]*gmaps.Entry) ([]*gmaps.Entry, error) {
+		return nil, errors.New("hook down")
+	})
+
+	next := runWriter(t, failing, 10, testEntries())
+
+	require.Equal(t, []string{"Kipriakon", "Coffee Island", "Wine Bar"}, next.titles())
+}
+
+func TestWriterDrainsInputOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	in := make(chan scrapemate.Result)
+	done := make(chan error)
+
+	go func() {
+		done <- postprocess.NewWriter(&collectWriter{}, hookFunc(func(entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+			return entries, nil
+		}), 1).Run(ctx, in)
+	}()
+
+	// the scraper sends without watching ctx: it must not block
+	for range 3 {
+		in <- scrapemate.Result{Data: testEntries()}
+	}
+
+	close(in)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,348 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+const (
+	// DefaultStreamBatchSize is the number of entries of a POST to the
+	// entry webhook.
+	DefaultStreamBatchSize = 10
+	// DefaultStreamFlushInterval bounds how long an entry waits for its
+	// batch to fill.
+	DefaultStreamFlushInterval = 2 * time.Second
+
+	defaultStreamPending  = 8
+	defaultStreamAttempts = 5
+	streamRetryBackoff    = time.Second
+	maxStreamRetryAfter   = time.Minute
+	// streamDrainTimeout bounds how long the batches still pending are
+	// sent for once the scrape is over.
+	streamDrainTimeout = 30 * time.Second
+)
+
+// StreamBatch is the body of a POST to the entry webhook.
+type StreamBatch struct {
+	// JobID is the Web UI / REST API job of the entries, empty on the
+	// command line.
+	JobID string `json:"job_id,omitempty"`
+	// Sequence numbers the batches of a run from 1, so that the receiver
+	// can tell a retried batch from a new one.
+	Sequence int            `json:"sequence"`
+	Entries  []*gmaps.Entry `json:"entries"`
+}
+
+// StreamConfig configures a StreamWriter.
+type StreamConfig struct {
+	URL   string
+	JobID string
+	// Secret, when set, signs each body with HMAC-SHA256 in the
+	// X-Signature header, as "sha256=<hex>".
+	Secret string
+	// BatchSize and FlushInterval default to DefaultStreamBatchSize and
+	// DefaultStreamFlushInterval.
+	BatchSize     int
+	FlushInterval time.Duration
+	// Pending is how many batches may wait for a slow webhook before the
+	// scrape waits for it in turn.
+	Pending int
+	// Attempts is how many times a batch is sent before it is given up.
+	Attempts int
+	Client   *http.Client
+	Timeout  time.Duration
+}
+
+// ValidateStreamURL checks that raw is an http(s) URL the entries can be
+// streamed to.
+func ValidateStreamURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid entry webhook URL %q", raw)
+	}
+
+	return nil
+}
+
+// StreamWriter POSTs the entries to a webhook as they are scraped, in
+// batches, so that a downstream service starts working on them while the
+// scrape runs. The entries are written by the wrapped writer all the same.
+// A webhook slower than the scrape slows it down rather than piling the
+// batches up in memory; a batch it keeps failing (network errors, 429 and
+// 5xx answers) is retried with a growing pause, then given up.
+type StreamWriter struct {
+	next scrapemate.ResultWriter
+	cfg  StreamConfig
+}
+
+// NewStreamWriter wraps next, streaming its entries to cfg.URL.
+func NewStreamWriter(next scrapemate.ResultWriter, cfg StreamConfig) *StreamWriter {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = DefaultStreamBatchSize
+	}
+
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultStreamFlushInterval
+	}
+
+	if cfg.Pending < 1 {
+		cfg.Pending = defaultStreamPending
+	}
+
+	if cfg.Attempts < 1 {
+		cfg.Attempts = defaultStreamAttempts
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	return &StreamWriter{next: next, cfg: cfg}
+}
+
+// Run implements scrapemate.ResultWriter.
+func (w *StreamWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+	queue := make(chan StreamBatch, w.cfg.Pending)
+
+	// the batches still pending are sent after the scrape is cancelled,
+	// for a while
+	sendCtx, cancelSend := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelSend()
+
+	var (
+		wg            sync.WaitGroup
+		sent, dropped int
+	)
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for batch := range queue {
+			if err := w.send(sendCtx, &batch); err != nil {
+				dropped += len(batch.Entries)
+
+				log.Printf("entry webhook: giving up batch %d of %d entries: %v", batch.Sequence, len(batch.Entries), err)
+
+				continue
+			}
+
+			sent += len(batch.Entries)
+		}
+	}()
+
+	go func() {
+		// see Writer.Run
+		defer func() {
+			for range in {
+			}
+		}()
+
+		defer close(out)
+		defer close(queue)
+
+		ticker := time.NewTicker(w.cfg.FlushInterval)
+		defer ticker.Stop()
+
+		var (
+			entries  []*gmaps.Entry
+			sequence int
+		)
+
+		flush := func() bool {
+			if len(entries) == 0 {
+				return true
+			}
+
+			select {
+			case queue <- StreamBatch{JobID: w.cfg.JobID, Sequence: sequence + 1, Entries: entries}:
+				sequence++
+				entries = nil
+
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// once the scrape is cancelled, the entries of the batch being
+		// filled are sent if the queue has room
+		defer func() {
+			if len(entries) > 0 {
+				select {
+				case queue <- StreamBatch{JobID: w.cfg.JobID, Sequence: sequence + 1, Entries: entries}:
+				default:
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !flush() {
+					return
+				}
+			case result, ok := <-in:
+				if !ok {
+					flush()
+
+					return
+				}
+
+				switch data := result.Data.(type) {
+				case *gmaps.Entry:
+					entries = append(entries, data)
+				case []*gmaps.Entry:
+					entries = append(entries, data...)
+				}
+
+				select {
+				case out <- result:
+				case <-ctx.Done():
+					return
+				}
+
+				if len(entries) >= w.cfg.BatchSize && !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	err := w.next.Run(ctx, out)
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(streamDrainTimeout):
+		cancelSend()
+		<-done
+	}
+
+	if sent > 0 || dropped > 0 {
+		log.Printf("entry webhook: %d entries sent, %d given up", sent, dropped)
+	}
+
+	return err
+}
+
+// Send POSTs entries at once, in batches like Run, for results already
+// scraped. It stops at the first batch given up and returns its error.
+func (w *StreamWriter) Send(ctx context.Context, entries []*gmaps.Entry) error {
+	for i, sequence := 0, 1; i < len(entries); i, sequence = i+w.cfg.BatchSize, sequence+1 {
+		batch := StreamBatch{
+			JobID:    w.cfg.JobID,
+			Sequence: sequence,
+			Entries:  entries[i:min(i+w.cfg.BatchSize, len(entries))],
+		}
+
+		if err := w.send(ctx, &batch); err != nil {
+			return fmt.Errorf("batch %d: %w", sequence, err)
+		}
+	}
+
+	return nil
+}
+
+// send POSTs batch, retrying the failures worth it.
+func (w *StreamWriter) send(ctx context.Context, batch *StreamBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	var signature string
+
+	if w.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.cfg.Secret))
+		mac.Write(body)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	backoff := streamRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := w.post(ctx, body, signature)
+		if err == nil {
+			return nil
+		}
+
+		if retryAfter < 0 || attempt >= w.cfg.Attempts {
+			return err
+		}
+
+		pause := max(backoff, retryAfter)
+		backoff *= 2
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(pause):
+		}
+	}
+}
+
+// post sends body once. On failure it returns how long to wait before
+// retrying, from the Retry-After header, or -1 when retrying is useless.
+func (w *StreamWriter) post(ctx context.Context, body []byte, signature string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout(w.cfg.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if signature != "" {
+		req.Header.Set("X-Signature", signature)
+	}
+
+	resp, err := w.cfg.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		var retryAfter time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = min(time.Duration(secs)*time.Second, maxStreamRetryAfter)
+		}
+
+		return retryAfter, fmt.Errorf("HTTP %d", resp.StatusCode)
+	default:
+		return -1, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,173 @@
+package postprocess_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/postprocess"
+	"github.com/gosom/scrapemate"
+)
+
+func runStreamWriter(t *testing.T, cfg postprocess.StreamConfig, results ...any) *collectWriter {
+	t.Helper()
+
+	next := &collectWriter{}
+	in := make(chan scrapemate.Result, len(results))
+
+	for _, r := range results {
+		in <- scrapemate.Result{Data: r}
+	}
+
+	close(in)
+
+	require.NoError(t, postprocess.NewStreamWriter(next, cfg).Run(context.Background(), in))
+
+	return next
+}
+
+func TestStreamWriter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches []postprocess.StreamBatch
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get("X-Signature"))
+
+		var batch postprocess.StreamBatch
+
+		require.NoError(t, json.Unmarshal(body, &batch))
+
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	all := testEntries()
+
+	next := runStreamWriter(t, postprocess.StreamConfig{URL: srv.URL, JobID: "job-1", Secret: "s3cret", BatchSize: 2},
+		[]*gmaps.Entry{all[0]}, []*gmaps.Entry{all[1], all[2]}, []*gmaps.Entry{{Title: "Bakery"}})
+
+	// the wrapped writer gets every result as it is
+	require.Equal(t, []string{"Kipriakon", "Coffee Island", "Wine Bar", "Bakery"}, next.titles())
+
+	require.Len(t, batches, 2)
+	require.Equal(t, "job-1", batches[0].JobID)
+	require.Equal(t, 1, batches[0].Sequence)
+	require.Len(t, batches[0].Entries, 3)
+	require.Equal(t, "Kipriakon", batches[0].Entries[0].Title)
+	require.Equal(t, 2, batches[1].Sequence)
+	require.Len(t, batches[1].Entries, 1)
+	require.Equal(t, "Bakery", batches[1].Entries[0].Title)
+}
+
+func TestStreamWriterFlushesIdleBatch(t *testing.T) {
+	received := make(chan int, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var batch postprocess.StreamBatch
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+
+		received <- len(batch.Entries)
+	}))
+	defer srv.Close()
+
+	in := make(chan scrapemate.Result)
+	done := make(chan error)
+
+	go func() {
+		done <- postprocess.NewStreamWriter(&collectWriter{}, postprocess.StreamConfig{URL: srv.URL, FlushInterval: 50 * time.Millisecond}).Run(context.Background(), in)
+	}()
+
+	in <- scrapemate.Result{Data: testEntries()}
+
+	// sent before the scrape is over
+	select {
+	case n := <-received:
+		require.Equal(t, 3, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the batch was not flushed")
+	}
+
+	close(in)
+	require.NoError(t, <-done)
+}
+
+func TestStreamWriterRetries(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+
+		switch {
+		case r.URL.Path == "/invalid":
+			w.WriteHeader(http.StatusBadRequest)
+		case n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	next := runStreamWriter(t, postprocess.StreamConfig{URL: srv.URL}, testEntries())
+	require.Len(t, next.titles(), 3)
+	require.EqualValues(t, 2, calls.Load())
+
+	// a 4xx answer is not retried, and the entries are written all the same
+	calls.Store(0)
+
+	next = runStreamWriter(t, postprocess.StreamConfig{URL: srv.URL + "/invalid"}, testEntries())
+	require.Len(t, next.titles(), 3)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestStreamWriterSend(t *testing.T) {
+	var sequences []int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch postprocess.StreamBatch
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+
+		sequences = append(sequences, batch.Sequence)
+
+		if r.URL.Path == "/invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sender := postprocess.NewStreamWriter(nil, postprocess.StreamConfig{URL: srv.URL, BatchSize: 2})
+	require.NoError(t, sender.Send(context.Background(), testEntries()))
+	require.Equal(t, []int{1, 2}, sequences)
+
+	// the first batch given up stops the export
+	sequences = nil
+
+	sender = postprocess.NewStreamWriter(nil, postprocess.StreamConfig{URL: srv.URL + "/invalid", BatchSize: 2})
+	require.ErrorContains(t, sender.Send(context.Background(), testEntries()), "batch 1")
+	require.Equal(t, []int{1}, sequences)
+}
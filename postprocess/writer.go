@@ -0,0 +1,132 @@
+package postprocess
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+const (
+	// DefaultBatchSize is the number of entries handed to a hook at once.
+	DefaultBatchSize = 50
+
+	// flushInterval bounds how long an entry waits for its batch to fill,
+	// so that slow jobs keep writing results.
+	flushInterval = 10 * time.Second
+)
+
+// Writer runs Hook over the entries of the results in batches, then hands
+// what the hook returned to the wrapped writer. When the hook fails the
+// batch is written unchanged, so that a broken hook does not lose results.
+type Writer struct {
+	next      scrapemate.ResultWriter
+	hook      Hook
+	batchSize int
+}
+
+// NewWriter wraps next with hook. A batchSize below 1 uses DefaultBatchSize.
+func NewWriter(next scrapemate.ResultWriter, hook Hook, batchSize int) *Writer {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Writer{next: next, hook: hook, batchSize: batchSize}
+}
+
+// Run implements scrapemate.ResultWriter.
+func (w *Writer) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+
+	go func() {
+		// the scraper sends its results without watching ctx, so in is
+		// drained once out is closed
+		defer func() {
+			for range in {
+			}
+		}()
+
+		defer close(out)
+
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		var (
+			batch []*gmaps.Entry
+			job   scrapemate.IJob
+		)
+
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+
+			entries := w.process(ctx, batch)
+			batch = nil
+
+			if len(entries) == 0 {
+				return true
+			}
+
+			select {
+			case out <- scrapemate.Result{Job: job, Data: entries}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !flush() {
+					return
+				}
+			case result, ok := <-in:
+				if !ok {
+					flush()
+
+					return
+				}
+
+				switch data := result.Data.(type) {
+				case *gmaps.Entry:
+					batch = append(batch, data)
+				case []*gmaps.Entry:
+					batch = append(batch, data...)
+				default:
+					select {
+					case out <- result:
+					case <-ctx.Done():
+						return
+					}
+
+					continue
+				}
+
+				job = result.Job
+
+				if len(batch) >= w.batchSize && !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return w.next.Run(ctx, out)
+}
+
+func (w *Writer) process(ctx context.Context, batch []*gmaps.Entry) []*gmaps.Entry {
+	entries, err := w.hook.Process(ctx, batch)
+	if err != nil {
+		log.Printf("post-processing %d entries failed, writing them unchanged: %v", len(batch), err)
+
+		return batch
+	}
+
+	return entries
+}
@@ -193,6 +193,7 @@ func (w *ScrapeWorker) Work(ctx context.Context, job *river.Job[ScrapeJobArgs])
 		searchJob := gmaps.NewSearchJob(params,
 			gmaps.WithSearchJobExitMonitor(exitMon),
 			gmaps.WithSearchJobWriterManagedCompletion(),
+			gmaps.WithSearchJobProvenance(gmaps.Provenance{JobID: jobID}),
 		)
 		searchJob.ID = jobID
 
@@ -206,6 +207,7 @@ func (w *ScrapeWorker) Work(ctx context.Context, job *river.Job[ScrapeJobArgs])
 		opts := []gmaps.GmapJobOptions{
 			gmaps.WithExitMonitor(exitMon),
 			gmaps.WithWriterManagedCompletion(),
+			gmaps.WithProvenance(gmaps.Provenance{JobID: jobID}),
 		}
 
 		if args.ExtraReviews {
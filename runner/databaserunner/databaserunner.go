@@ -21,7 +21,7 @@ type dbrunner struct {
 	cfg      *runner.Config
 	provider scrapemate.JobProvider
 	produce  bool
-	app      *scrapemateapp.ScrapemateApp
+	app      runner.App
 	conn     *sql.DB
 }
 
@@ -95,7 +95,7 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, err
 	}
 
-	ans.app, err = scrapemateapp.NewScrapeMateApp(matecfg)
+	ans.app, err = runner.NewApp(matecfg, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +154,7 @@ func (d *dbrunner) produceSeedJobs(ctx context.Context) error {
 		nil,
 		nil,
 		d.cfg.ExtraReviews,
+		runner.WithSeedExcludeServiceArea(d.cfg.ExcludeServiceArea),
 	)
 	if err != nil {
 		return err
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// emailConcurrencyFactor gives the email workers of -email-concurrency 0,
+// per crawl worker of -c: the email searches mostly wait on the websites.
+const emailConcurrencyFactor = 4
+
+// EmailWorkers returns how many email searches of a crawl run at a time,
+// see NewEmailLane.
+func EmailWorkers(cfg *Config) int {
+	if cfg.EmailConcurrency > 0 {
+		return cfg.EmailConcurrency
+	}
+
+	return cfg.Concurrency * emailConcurrencyFactor
+}
+
+// EmailLane is a job provider running the email jobs on a pool of their own
+// before the crawl gets them. Their HTTP levels run there, workers at a
+// time, without waiting for a crawl worker and its browser page; the crawl
+// then only renders the websites where nothing was found, and hands over
+// the entries. The other jobs go to the provider wrapped.
+type EmailLane struct {
+	next    scrapemate.JobProvider
+	workers int
+
+	queue chan *gmaps.EmailExtractJob
+	once  sync.Once
+}
+
+var _ scrapemate.JobProvider = (*EmailLane)(nil)
+
+// NewEmailLane wraps next with an email pool of workers.
+func NewEmailLane(next scrapemate.JobProvider, workers int) *EmailLane {
+	return &EmailLane{
+		next:    next,
+		workers: max(workers, 1),
+		queue:   make(chan *gmaps.EmailExtractJob),
+	}
+}
+
+// Jobs returns the jobs of the crawl, starting the email pool until ctx
+// ends the first time it is called.
+func (l *EmailLane) Jobs(ctx context.Context) (<-chan scrapemate.IJob, <-chan error) { //nolint:gocritic // unnamedResult: interface implementation requires exact signature
+	l.once.Do(func() {
+		for range l.workers {
+			go l.work(ctx)
+		}
+	})
+
+	return l.next.Jobs(ctx)
+}
+
+// Push queues the email jobs for the email pool and the others for the
+// crawl.
+func (l *EmailLane) Push(ctx context.Context, job scrapemate.IJob) error {
+	emailJob, ok := job.(*gmaps.EmailExtractJob)
+	if !ok {
+		return l.next.Push(ctx, job)
+	}
+
+	// like the memory provider, without holding up the crawl worker
+	go func() {
+		select {
+		case l.queue <- emailJob:
+		case <-ctx.Done():
+		}
+	}()
+
+	return nil
+}
+
+func (l *EmailLane) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-l.queue:
+			job.RunHTTP(ctx)
+
+			if err := l.next.Push(ctx, job); err != nil {
+				return
+			}
+		}
+	}
+}
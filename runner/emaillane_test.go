@@ -0,0 +1,55 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+func TestEmailLane(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="mailto:info@testbiz.com">Email us</a></body></html>`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lane := runner.NewEmailLane(memprovider.New(), 2)
+	jobs, _ := lane.Jobs(ctx)
+
+	entry := &gmaps.Entry{WebSite: srv.URL}
+
+	require.NoError(t, lane.Push(ctx, &scrapemate.Job{ID: "other"}))
+	require.NoError(t, lane.Push(ctx, gmaps.NewEmailJob("parent", entry)))
+
+	seen := map[string]bool{}
+
+	for range 2 {
+		select {
+		case job := <-jobs:
+			if emailJob, ok := job.(*gmaps.EmailExtractJob); ok {
+				// the crawl gets the email job once searched
+				require.Equal(t, []string{"info@testbiz.com"}, emailJob.Entry.Emails)
+				seen["email"] = true
+			} else {
+				seen[job.GetID()] = true
+			}
+		case <-ctx.Done():
+			t.Fatal("the lane did not hand over the jobs")
+		}
+	}
+
+	require.Equal(t, map[string]bool{"email": true, "other": true}, seen)
+}
@@ -0,0 +1,22 @@
+package filerunner
+
+import (
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// csvLayoutWriter lays out the CSV rows of the entries with layout, like
+// gmaps.ProvenanceCSV, before handing them to next.
+func csvLayoutWriter[T any](next scrapemate.ResultWriter, layout func(*gmaps.Entry) T) scrapemate.ResultWriter {
+	return mapEntries(next, func(e *gmaps.Entry) (T, bool) {
+		return layout(e), true
+	})
+}
+
+func provenanceCSV(e *gmaps.Entry) gmaps.ProvenanceCSV {
+	return gmaps.ProvenanceCSV{Entry: e}
+}
+
+func upstreamCSV(e *gmaps.Entry) gmaps.UpstreamCSV {
+	return gmaps.UpstreamCSV{Entry: e}
+}
@@ -0,0 +1,14 @@
+package filerunner
+
+import (
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// emailConfidenceWriter drops the places whose best email scores below
+// minConfidence before handing the results to next.
+func emailConfidenceWriter(next scrapemate.ResultWriter, minConfidence int) scrapemate.ResultWriter {
+	return mapEntries(next, func(e *gmaps.Entry) (*gmaps.Entry, bool) {
+		return e, e.HasEmailConfidence(minConfidence)
+	})
+}
@@ -3,15 +3,21 @@ package filerunner
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/gosom/google-maps-scraper/browserpool"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/exporter"
+	"github.com/gosom/google-maps-scraper/gmaps"
 	"github.com/gosom/google-maps-scraper/grid"
+	"github.com/gosom/google-maps-scraper/postprocess"
+	"github.com/gosom/google-maps-scraper/proxypool"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/google-maps-scraper/tlmt"
 	"github.com/gosom/scrapemate"
@@ -24,8 +30,10 @@ type fileRunner struct {
 	cfg     *runner.Config
 	input   io.Reader
 	writers []scrapemate.ResultWriter
-	app     *scrapemateapp.ScrapemateApp
+	app     runner.App
 	outfile *os.File
+	// quarantine holds the places failing the -validate rules
+	quarantine *os.File
 }
 
 func New(cfg *runner.Config) (runner.Runner, error) {
@@ -75,6 +83,68 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 
 	dedup := deduper.New()
 	exitMonitor := exiter.New()
+	exitMonitor.SetMaxPlaces(r.cfg.MaxPlaces)
+
+	var emailBudget *gmaps.FetchBudget
+	if r.cfg.MaxEmailFetches > 0 {
+		emailBudget = gmaps.NewFetchBudget(r.cfg.MaxEmailFetches)
+	}
+
+	emailBreaker := gmaps.NewDomainBreaker()
+	emailHAR := gmaps.NewHARCapture(r.cfg.EmailHARDir, r.cfg.EmailHARSample)
+
+	emailProxies, err := runner.EmailProxyRouter(r.cfg.EmailProxies)
+	if err != nil {
+		return fmt.Errorf("invalid -email-proxies: %w", err)
+	}
+
+	customExtractors, err := gmaps.ParseCustomExtractors(r.cfg.CustomFields)
+	if err != nil {
+		return fmt.Errorf("invalid -custom-fields: %w", err)
+	}
+
+	anonymizer, err := gmaps.NewReviewerAnonymizer(r.cfg.AnonymizeReviewers, r.cfg.AnonymizeKey)
+	if err != nil {
+		return fmt.Errorf("invalid -anonymize-reviewers: %w", err)
+	}
+
+	reviewLangs, err := gmaps.ParseLanguages(r.cfg.ReviewLangs)
+	if err != nil {
+		return fmt.Errorf("invalid -review-langs: %w", err)
+	}
+
+	exclusions, err := runner.Exclusions(r.cfg, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var emailVerifier *gmaps.EmailVerifier
+	if r.cfg.EmailVerify {
+		emailVerifier = gmaps.NewEmailVerifier()
+	}
+
+	fetchStats := gmaps.NewFetchStats()
+	sessions := gmaps.NewSessionPool(r.cfg.Identities)
+	keywordStats := gmaps.NewKeywordStats()
+	// without -diagnosis-dir the pages are only classified
+	diagnoser := gmaps.NewDiagnoser(r.cfg.DiagnosisDir)
+	stepTimings := gmaps.NewStepTimings()
+
+	var throttle *gmaps.Throttle
+	if r.cfg.AdaptiveThrottle {
+		throttle = gmaps.NewThrottle(r.cfg.Concurrency)
+	}
+
+	defer printThrottle(throttle)
+
+	defer printFetchStats(fetchStats, sessions)
+	defer printStepTimings(stepTimings)
+	defer printKeywordStats(keywordStats)
+
+	emailPacer, err := runner.EmailPacer(r.cfg.EmailHostInterval)
+	if err != nil {
+		return fmt.Errorf("invalid -email-host-interval: %w", err)
+	}
 
 	if r.cfg.GridBBox != "" {
 		if r.cfg.FastMode {
@@ -100,6 +170,23 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 			dedup,
 			exitMonitor,
 			r.cfg.ExtraReviews,
+			runner.WithSeedExcludeServiceArea(r.cfg.ExcludeServiceArea),
+			runner.WithSeedEmailProxyRouter(emailProxies),
+			runner.WithSeedEmailVerifier(emailVerifier),
+			runner.WithSeedFetchStats(fetchStats),
+			runner.WithSeedSessionPool(sessions),
+			runner.WithSeedCustomExtractors(customExtractors),
+			runner.WithSeedEmailFetchBudget(emailBudget),
+			runner.WithSeedEmailBreaker(emailBreaker),
+			runner.WithSeedEmailHAR(emailHAR),
+			runner.WithSeedReviewerAnonymizer(anonymizer),
+			runner.WithSeedReviewLanguages(reviewLangs),
+			runner.WithSeedExclusions(exclusions),
+			runner.WithSeedKeywordStats(keywordStats),
+			runner.WithSeedDiagnoser(diagnoser),
+			runner.WithSeedStepTimings(stepTimings),
+			runner.WithSeedThrottle(throttle),
+			runner.WithSeedEmailPacer(emailPacer),
 		)
 	} else {
 		seedJobs, err = runner.CreateSeedJobs(
@@ -114,6 +201,23 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 			dedup,
 			exitMonitor,
 			r.cfg.ExtraReviews,
+			runner.WithSeedExcludeServiceArea(r.cfg.ExcludeServiceArea),
+			runner.WithSeedEmailProxyRouter(emailProxies),
+			runner.WithSeedEmailVerifier(emailVerifier),
+			runner.WithSeedFetchStats(fetchStats),
+			runner.WithSeedSessionPool(sessions),
+			runner.WithSeedCustomExtractors(customExtractors),
+			runner.WithSeedEmailFetchBudget(emailBudget),
+			runner.WithSeedEmailBreaker(emailBreaker),
+			runner.WithSeedEmailHAR(emailHAR),
+			runner.WithSeedReviewerAnonymizer(anonymizer),
+			runner.WithSeedReviewLanguages(reviewLangs),
+			runner.WithSeedExclusions(exclusions),
+			runner.WithSeedKeywordStats(keywordStats),
+			runner.WithSeedDiagnoser(diagnoser),
+			runner.WithSeedStepTimings(stepTimings),
+			runner.WithSeedThrottle(throttle),
+			runner.WithSeedEmailPacer(emailPacer),
 		)
 	}
 
@@ -132,9 +236,96 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 
 	err = r.app.Start(ctx, seedJobs...)
 
+	printBrowserStats(runner.BrowserStats(r.app))
+
 	return err
 }
 
+// printBrowserStats reports the browsers that crashed, hung or were recycled
+// during the run on stderr.
+func printBrowserStats(stats browserpool.Stats) {
+	if stats.Crashes+stats.Hangs+stats.PageCrashes > 0 {
+		fmt.Fprintf(os.Stderr, "browsers: %d crashed, %d hung, %d page crashes, %d jobs requeued (%d launches for %d browsers)\n",
+			stats.Crashes, stats.Hangs, stats.PageCrashes, stats.Requeues, stats.Launches, stats.Browsers)
+	}
+
+	if len(stats.Recycles) > 0 || stats.PeakMemoryBytes > 0 {
+		fmt.Fprintf(os.Stderr, "browsers: recycled %v, peak memory %d MiB\n", stats.Recycles, stats.PeakMemoryBytes>>20)
+	}
+}
+
+// printFetchStats reports the traffic, the consent walls and the failed
+// fetches of the run on stderr, so that the output file only holds results.
+func printFetchStats(stats *gmaps.FetchStats, sessions *gmaps.SessionPool) {
+	const maxTargets = 5
+
+	if rep := sessions.Report(); rep.Pages > 0 {
+		fmt.Fprintf(os.Stderr, "consent walls: %d over %d pages (%d identities)\n", rep.ConsentWalls, rep.Pages, rep.Identities)
+	}
+
+	if bw := stats.BandwidthReport(); bw.TotalBytes > 0 {
+		fmt.Fprintf(os.Stderr, "downloaded: %s (%v)\n", bw.Total(), bw.BySource)
+
+		for proxy, n := range bw.ByProxy {
+			fmt.Fprintf(os.Stderr, "  proxy %s: %d bytes\n", proxy, n)
+		}
+	}
+
+	rep := stats.ErrorReport()
+	if rep.Total == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "fetch errors: %d (%s)\n", rep.Total, rep.Summary())
+
+	for proxy, classes := range rep.ByProxy {
+		fmt.Fprintf(os.Stderr, "  proxy %s: %v\n", proxy, classes)
+	}
+
+	for _, t := range rep.Targets[:min(len(rep.Targets), maxTargets)] {
+		fmt.Fprintf(os.Stderr, "  %s: %d\n", t.Host, t.Total)
+	}
+}
+
+// printKeywordStats lists on stderr the keywords that found nothing, which
+// would otherwise go unnoticed among the others.
+func printKeywordStats(stats *gmaps.KeywordStats) {
+	for _, k := range stats.Report() {
+		switch k.Status {
+		case gmaps.KeywordBlocked, gmaps.KeywordFailed:
+			fmt.Fprintf(os.Stderr, "keyword %q: %s (%d of %d searches failed: %s)\n", k.Keyword, k.Status, k.SeedErrors, k.Seeds, k.Error)
+		case gmaps.KeywordZeroResults:
+			fmt.Fprintf(os.Stderr, "keyword %q: no results\n", k.Keyword)
+		default:
+			continue
+		}
+
+		if d := k.Diagnosis; d != nil {
+			fmt.Fprintf(os.Stderr, "  diagnosis: %s at %s %s\n", d.Cause, d.URL, strings.TrimSpace(d.Screenshot+" "+d.HTML))
+		}
+	}
+}
+
+// printStepTimings reports how long the steps of the places took, to tell
+// whether the run waited on Google, its proxies or the websites.
+func printStepTimings(timings *gmaps.StepTimings) {
+	for _, t := range timings.Report() {
+		fmt.Fprintf(os.Stderr, "%s: %d runs, mean %dms, p95 %dms, max %dms\n", t.Step, t.Count, t.MeanMs, t.P95Ms, t.MaxMs)
+	}
+}
+
+// printThrottle reports how much the run was slowed down by Google pushing
+// back.
+func printThrottle(throttle *gmaps.Throttle) {
+	rep := throttle.Report()
+	if rep == nil {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "throttled: %d of %d Maps pages blocked, slowed down %d times (down to %d pages at once, pause up to %dms), sped up %d times\n",
+		rep.Blocked, rep.Pages, rep.Slowdowns, rep.MinSlots, rep.MaxDelayMs, rep.Speedups)
+}
+
 func (r *fileRunner) Close(context.Context) error {
 	if r.app != nil {
 		return r.app.Close()
@@ -146,6 +337,12 @@ func (r *fileRunner) Close(context.Context) error {
 		}
 	}
 
+	if r.quarantine != nil {
+		if err := r.quarantine.Close(); err != nil {
+			return err
+		}
+	}
+
 	if r.outfile != nil {
 		return r.outfile.Close()
 	}
@@ -169,7 +366,47 @@ func (r *fileRunner) setInput() error {
 	return nil
 }
 
+// formatWriter returns the writer of the results to w, in the format of the
+// flags.
+func (r *fileRunner) formatWriter(w io.Writer) (scrapemate.ResultWriter, error) {
+	switch {
+	case r.cfg.Exporter != "":
+		e, err := exporter.New(r.cfg.Exporter, w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -exporter: %w", err)
+		}
+
+		return exporter.NewWriter(e, 0), nil
+	case r.cfg.PlacesAPI:
+		return placesAPIWriter(jsonwriter.NewJSONWriter(w)), nil
+	case r.cfg.JSON:
+		return jsonwriter.NewJSONWriter(w), nil
+	case r.cfg.CSVUpstream:
+		return csvLayoutWriter(csvwriter.NewCsvWriter(csv.NewWriter(w)), upstreamCSV), nil
+	case r.cfg.CSVProvenance:
+		return csvLayoutWriter(csvwriter.NewCsvWriter(csv.NewWriter(w)), provenanceCSV), nil
+	default:
+		return csvwriter.NewCsvWriter(csv.NewWriter(w)), nil
+	}
+}
+
 func (r *fileRunner) setWriters() error {
+	hook, err := runner.PostProcessHook(r.cfg.PostProcess)
+	if err != nil {
+		return fmt.Errorf("invalid -post-process: %w", err)
+	}
+
+	validationRules, err := gmaps.ParseValidationRules(r.cfg.Validate)
+	if err != nil {
+		return fmt.Errorf("invalid -validate: %w", err)
+	}
+
+	if r.cfg.EntryWebhook != "" {
+		if err := postprocess.ValidateStreamURL(r.cfg.EntryWebhook); err != nil {
+			return fmt.Errorf("invalid -entry-webhook: %w", err)
+		}
+	}
+
 	switch {
 	case r.cfg.CustomWriter != "":
 		parts := strings.Split(r.cfg.CustomWriter, ":")
@@ -184,7 +421,7 @@ func (r *fileRunner) setWriters() error {
 			return err
 		}
 
-		r.writers = append(r.writers, customWriter)
+		r.writers = append(r.writers, runner.EntryStream(r.cfg, customWriter, r.cfg.EntryWebhook, ""))
 	default:
 		var resultsWriter io.Writer
 
@@ -202,12 +439,80 @@ func (r *fileRunner) setWriters() error {
 			resultsWriter = r.outfile
 		}
 
-		csvWriter := csvwriter.NewCsvWriter(csv.NewWriter(resultsWriter))
+		writer, err := r.formatWriter(resultsWriter)
+		if err != nil {
+			return err
+		}
+
+		// next to the file, the webhook gets the places as written, so
+		// within the quarantine and the email confidence filter
+		writer = runner.EntryStream(r.cfg, writer, r.cfg.EntryWebhook, "")
 
-		if r.cfg.JSON {
-			r.writers = append(r.writers, jsonwriter.NewJSONWriter(resultsWriter))
-		} else {
-			r.writers = append(r.writers, csvWriter)
+		if r.cfg.Quarantine != "" {
+			if len(validationRules) == 0 {
+				return errors.New("-quarantine needs -validate")
+			}
+
+			f, err := os.Create(r.cfg.Quarantine)
+			if err != nil {
+				return err
+			}
+
+			r.quarantine = f
+
+			quarantine, err := r.formatWriter(f)
+			if err != nil {
+				return err
+			}
+
+			writer = &quarantineWriter{next: writer, quarantine: quarantine}
+		}
+
+		if r.cfg.EmailMinConfidence > 0 {
+			writer = emailConfidenceWriter(writer, r.cfg.EmailMinConfidence)
+		}
+
+		r.writers = append(r.writers, writer)
+	}
+
+	if hook != nil {
+		for i := range r.writers {
+			r.writers[i] = postprocess.NewWriter(r.writers[i], hook, r.cfg.PostProcessBatch)
+		}
+	}
+
+	llm, err := runner.LLMHook(r.cfg)
+	if err != nil {
+		return fmt.Errorf("invalid -llm-prompt: %w", err)
+	}
+
+	// before the hook too, which sees the summaries and the scores
+	if llm != nil {
+		for i := range r.writers {
+			r.writers[i] = postprocess.NewWriter(r.writers[i], llm, r.cfg.LLMBatch)
+		}
+	}
+
+	var enrichers gmaps.Enrichers
+
+	if r.cfg.OSMCheck {
+		enrichers = append(enrichers, runner.OSMChecker(r.cfg))
+	}
+
+	if r.cfg.RegistrationLookup {
+		enrichers = append(enrichers, runner.RegistrationEnricher(r.cfg))
+	}
+
+	// last, checking what the other enrichers found too
+	if len(validationRules) > 0 {
+		enrichers = append(enrichers, gmaps.NewValidator(validationRules, nil))
+	}
+
+	// before the model and the hook, which see what they add; the writers
+	// share the enrichers, whose caches look each place up once
+	if len(enrichers) > 0 {
+		for i := range r.writers {
+			r.writers[i] = postprocess.NewWriter(r.writers[i], enrichers, runner.EnrichBatchSize)
 		}
 	}
 
@@ -221,9 +526,28 @@ func (r *fileRunner) setApp() error {
 		scrapemateapp.WithExitOnInactivity(r.cfg.ExitOnInactivityDuration),
 	}
 
-	if len(r.cfg.Proxies) > 0 {
+	proxies := r.cfg.Proxies
+	if len(proxies) == 0 && r.cfg.ProxyProvider != "" {
+		pool, err := runner.ProxyPool(r.cfg, r.cfg.ProxyProvider, proxypool.Geo{
+			Country: r.cfg.ProxyCountry,
+			City:    r.cfg.ProxyCity,
+		})
+		if err != nil {
+			return fmt.Errorf("invalid -proxy-provider: %w", err)
+		}
+
+		// the browser pool is created once, so a file run keeps the
+		// proxies of the first fetch
+		if err := pool.Refresh(context.Background()); err != nil {
+			return fmt.Errorf("fetching proxies from -proxy-provider: %w", err)
+		}
+
+		proxies = pool.Proxies()
+	}
+
+	if len(proxies) > 0 {
 		opts = append(opts,
-			scrapemateapp.WithProxies(r.cfg.Proxies),
+			scrapemateapp.WithProxies(proxies),
 		)
 	}
 
@@ -242,6 +566,10 @@ func (r *fileRunner) setApp() error {
 
 	opts = runner.AppendBrowserCapacityOptions(opts, r.cfg)
 
+	if r.cfg.Email && !r.cfg.FastMode {
+		opts = runner.AppendEmailLaneOption(opts, r.cfg)
+	}
+
 	if !r.cfg.DisablePageReuse {
 		opts = append(opts,
 			scrapemateapp.WithPageReuseLimit(2),
@@ -257,7 +585,7 @@ func (r *fileRunner) setApp() error {
 		return err
 	}
 
-	r.app, err = scrapemateapp.NewScrapeMateApp(matecfg)
+	r.app, err = runner.NewApp(matecfg, r.cfg)
 	if err != nil {
 		return err
 	}
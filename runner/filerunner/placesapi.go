@@ -0,0 +1,14 @@
+package filerunner
+
+import (
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// placesAPIWriter converts entries to the Places API "Place Details" shape
+// before handing them to next.
+func placesAPIWriter(next scrapemate.ResultWriter) scrapemate.ResultWriter {
+	return mapEntries(next, func(e *gmaps.Entry) (gmaps.PlaceDetailsResponse, bool) {
+		return e.ToPlaceDetailsResponse(), true
+	})
+}
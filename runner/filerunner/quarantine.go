@@ -0,0 +1,60 @@
+package filerunner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// quarantineWriter hands the places failing a validation rule to the
+// quarantine writer, and the others to the wrapped writer.
+type quarantineWriter struct {
+	next       scrapemate.ResultWriter
+	quarantine scrapemate.ResultWriter
+}
+
+func (w *quarantineWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+	quarantined := make(chan scrapemate.Result)
+
+	relay(ctx, in, func(result scrapemate.Result, send sendFunc) bool {
+		var kept, failed []*gmaps.Entry
+
+		switch data := result.Data.(type) {
+		case *gmaps.Entry:
+			if data.Quarantined() {
+				return send(quarantined, result)
+			}
+
+			return send(out, result)
+		case []*gmaps.Entry:
+			for _, e := range data {
+				if e.Quarantined() {
+					failed = append(failed, e)
+				} else {
+					kept = append(kept, e)
+				}
+			}
+		default:
+			return send(out, result)
+		}
+
+		if len(failed) > 0 && !send(quarantined, scrapemate.Result{Job: result.Job, Data: failed}) {
+			return false
+		}
+
+		return len(kept) == 0 || send(out, scrapemate.Result{Job: result.Job, Data: kept})
+	}, out, quarantined)
+
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- w.quarantine.Run(ctx, quarantined)
+	}()
+
+	err := w.next.Run(ctx, out)
+
+	return errors.Join(err, <-errc)
+}
@@ -0,0 +1,91 @@
+package filerunner
+
+import (
+	"context"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/scrapemate"
+)
+
+// sendFunc hands result to ch, reporting false once ctx ended.
+type sendFunc func(ch chan<- scrapemate.Result, result scrapemate.Result) bool
+
+// relay runs route over the results of in in a goroutine, route handing them
+// to outs with send, and closes outs once in is closed. When route returns
+// false, as send does once ctx ended, the rest of in is drained: the scraper
+// sends its results without watching ctx and would block on a writer that
+// stopped reading.
+func relay(ctx context.Context, in <-chan scrapemate.Result, route func(scrapemate.Result, sendFunc) bool, outs ...chan scrapemate.Result) {
+	send := func(ch chan<- scrapemate.Result, result scrapemate.Result) bool {
+		select {
+		case ch <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range outs {
+				close(ch)
+			}
+
+			for range in {
+			}
+		}()
+
+		for result := range in {
+			if !route(result, send) {
+				return
+			}
+		}
+	}()
+}
+
+// entriesWriter hands the results to next once fn mapped their entries one
+// by one, fn dropping an entry by returning false. A result left with no
+// entry is dropped, the results without entries pass unchanged.
+type entriesWriter[T any] struct {
+	next scrapemate.ResultWriter
+	fn   func(*gmaps.Entry) (T, bool)
+}
+
+// mapEntries returns an entriesWriter of fn wrapping next.
+func mapEntries[T any](next scrapemate.ResultWriter, fn func(*gmaps.Entry) (T, bool)) scrapemate.ResultWriter {
+	return &entriesWriter[T]{next: next, fn: fn}
+}
+
+func (w *entriesWriter[T]) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+
+	relay(ctx, in, func(result scrapemate.Result, send sendFunc) bool {
+		switch data := result.Data.(type) {
+		case *gmaps.Entry:
+			v, ok := w.fn(data)
+			if !ok {
+				return true
+			}
+
+			result.Data = v
+		case []*gmaps.Entry:
+			kept := make([]T, 0, len(data))
+
+			for _, e := range data {
+				if v, ok := w.fn(e); ok {
+					kept = append(kept, v)
+				}
+			}
+
+			if len(kept) == 0 {
+				return true
+			}
+
+			result.Data = kept
+		}
+
+		return send(out, result)
+	}, out)
+
+	return w.next.Run(ctx, out)
+}
@@ -18,6 +18,234 @@ import (
 	"github.com/gosom/scrapemate"
 )
 
+// SeedJobOption tweaks the jobs produced by CreateSeedJobs and
+// CreateGridSeedJobs.
+type SeedJobOption func(*seedJobConfig)
+
+type seedJobConfig struct {
+	excludeServiceArea bool
+	emailProxyRouter   *gmaps.EmailProxyRouter
+	emailVerifier      *gmaps.EmailVerifier
+	fetchStats         *gmaps.FetchStats
+	sessions           *gmaps.SessionPool
+	jobID              string
+	customExtractors   []gmaps.CustomExtractor
+	emailFetchBudget   *gmaps.FetchBudget
+	reviewerAnonymizer *gmaps.ReviewerAnonymizer
+	reviewLanguages    []string
+	exclusions         *gmaps.Exclusions
+	keywordStats       *gmaps.KeywordStats
+	placeFailures      *gmaps.PlaceFailures
+	diagnoser          *gmaps.Diagnoser
+	livePages          *gmaps.LivePages
+	stepTimings        *gmaps.StepTimings
+	emailProgress      *gmaps.EmailProgress
+	emailBreaker       *gmaps.DomainBreaker
+	emailHAR           *gmaps.HARCapture
+	throttle           *gmaps.Throttle
+	skip               gmaps.SkipFields
+	skipStats          *gmaps.SkipStats
+	emailPacer         *gmaps.WebsitePacer
+}
+
+// WithSeedExcludeServiceArea drops service-area businesses from the results
+// when exclude is true.
+func WithSeedExcludeServiceArea(exclude bool) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.excludeServiceArea = exclude
+	}
+}
+
+// WithSeedEmailProxyRouter routes the email extraction fetches through
+// country-matched proxies. A nil router keeps direct connections.
+func WithSeedEmailProxyRouter(r *gmaps.EmailProxyRouter) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailProxyRouter = r
+	}
+}
+
+// WithSeedEmailVerifier classifies the extracted emails. A nil verifier
+// skips the verification stage.
+func WithSeedEmailVerifier(v *gmaps.EmailVerifier) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailVerifier = v
+	}
+}
+
+// WithSeedFetchStats records the failed fetches of the jobs into s. A nil s
+// records nothing.
+func WithSeedFetchStats(s *gmaps.FetchStats) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.fetchStats = s
+	}
+}
+
+// WithSeedSessionPool makes the Maps pages reuse the identities of s. A nil
+// s leaves every browser context with its own.
+func WithSeedSessionPool(s *gmaps.SessionPool) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.sessions = s
+	}
+}
+
+// WithSeedJobID stamps the entries with the ID of the web or API job they
+// belong to.
+func WithSeedJobID(id string) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.jobID = id
+	}
+}
+
+// WithSeedCustomExtractors reads user defined fields from the place pages.
+// Fast mode does not visit them and ignores the extractors.
+func WithSeedCustomExtractors(extractors []gmaps.CustomExtractor) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.customExtractors = extractors
+	}
+}
+
+// WithSeedEmailFetchBudget caps the website fetches of the email extraction
+// of the whole run. A nil b sets no limit.
+func WithSeedEmailFetchBudget(b *gmaps.FetchBudget) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailFetchBudget = b
+	}
+}
+
+// WithSeedReviewerAnonymizer strips the reviewer data from the reviews of the
+// places. A nil a keeps them. Fast mode extracts no reviews.
+func WithSeedReviewerAnonymizer(a *gmaps.ReviewerAnonymizer) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.reviewerAnonymizer = a
+	}
+}
+
+// WithSeedReviewLanguages keeps only the reviews of the places detected in
+// one of langs. Empty langs keep them all.
+func WithSeedReviewLanguages(langs []string) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.reviewLanguages = langs
+	}
+}
+
+// WithSeedExclusions leaves out the places x excludes. A nil x excludes
+// none.
+func WithSeedExclusions(x *gmaps.Exclusions) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.exclusions = x
+	}
+}
+
+// WithSeedKeywordStats records the outcome of each keyword into s, its seeds
+// counted as they are created. A nil s records nothing.
+func WithSeedKeywordStats(s *gmaps.KeywordStats) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.keywordStats = s
+	}
+}
+
+// WithSeedPlaceFailures records the place pages that fail for good into f,
+// for them to be retried. A nil f records nothing. Fast mode visits no place
+// and ignores it.
+func WithSeedPlaceFailures(f *gmaps.PlaceFailures) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.placeFailures = f
+	}
+}
+
+// WithSeedDiagnoser diagnoses the searches listing no place with d. A nil d
+// diagnoses none. Fast mode has no page to capture and ignores it.
+func WithSeedDiagnoser(d *gmaps.Diagnoser) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.diagnoser = d
+	}
+}
+
+// WithSeedLivePages keeps the Maps pages in use in l, see gmaps.LivePages.
+// A nil l keeps none.
+func WithSeedLivePages(l *gmaps.LivePages) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.livePages = l
+	}
+}
+
+// WithSeedStepTimings records the timings of the places and of their email
+// extraction into t. A nil t records nothing. Fast mode visits no place and
+// ignores it.
+func WithSeedStepTimings(t *gmaps.StepTimings) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.stepTimings = t
+	}
+}
+
+// WithSeedEmailProgress records the email searches of the places into p. A
+// nil p records nothing. Fast mode visits no place and ignores it.
+func WithSeedEmailProgress(p *gmaps.EmailProgress) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailProgress = p
+	}
+}
+
+// WithSeedEmailBreaker skips the websites of the places while b is open for
+// their domain, see gmaps.DomainBreaker. A nil b never skips. Fast mode
+// visits no place and ignores it.
+func WithSeedEmailBreaker(b *gmaps.DomainBreaker) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailBreaker = b
+	}
+}
+
+// WithSeedEmailHAR records a HAR of the browser fetches of the sampled
+// websites into h, see gmaps.HARCapture. A nil h records nothing.
+func WithSeedEmailHAR(h *gmaps.HARCapture) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailHAR = h
+	}
+}
+
+// WithSeedSkip leaves out the fields of the places skipped by s, see
+// gmaps.SkipFields. Fast mode visits no place and ignores it.
+func WithSeedSkip(s gmaps.SkipFields) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.skip = s
+	}
+}
+
+// WithSeedSkipStats records into s the work the places skipping fields
+// leave undone, see gmaps.SkipStats.
+func WithSeedSkipStats(s *gmaps.SkipStats) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.skipStats = s
+	}
+}
+
+// WithSeedThrottle paces the Maps pages with t, see gmaps.Throttle. A nil t
+// never slows down. Fast mode opens no page and ignores it.
+func WithSeedThrottle(t *gmaps.Throttle) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.throttle = t
+	}
+}
+
+// WithSeedEmailPacer spaces the HTTP fetches of the websites of the places
+// with p, see gmaps.WebsitePacer. A nil p never waits. Fast mode visits no
+// place and ignores it.
+func WithSeedEmailPacer(p *gmaps.WebsitePacer) SeedJobOption {
+	return func(c *seedJobConfig) {
+		c.emailPacer = p
+	}
+}
+
+func newSeedJobConfig(opts []SeedJobOption) seedJobConfig {
+	var cfg seedJobConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
 func CreateSeedJobs(
 	fastmode bool,
 	langCode string,
@@ -30,9 +258,12 @@ func CreateSeedJobs(
 	dedup deduper.Deduper,
 	exitMonitor exiter.Exiter,
 	extraReviews bool,
+	seedOpts ...SeedJobOption,
 ) (jobs []scrapemate.IJob, err error) {
 	var lat, lon float64
 
+	seedCfg := newSeedJobConfig(seedOpts)
+
 	if fastmode {
 		if geoCoordinates == "" {
 			return nil, fmt.Errorf("geo coordinates are required in fast mode")
@@ -102,6 +333,94 @@ func CreateSeedJobs(
 				opts = append(opts, gmaps.WithExtraReviews())
 			}
 
+			if seedCfg.excludeServiceArea {
+				opts = append(opts, gmaps.WithExcludeServiceArea())
+			}
+
+			if seedCfg.emailProxyRouter != nil {
+				opts = append(opts, gmaps.WithEmailProxyRouter(seedCfg.emailProxyRouter))
+			}
+
+			if seedCfg.emailVerifier != nil {
+				opts = append(opts, gmaps.WithEmailVerifier(seedCfg.emailVerifier))
+			}
+
+			if seedCfg.fetchStats != nil {
+				opts = append(opts, gmaps.WithFetchStats(seedCfg.fetchStats))
+			}
+
+			if seedCfg.sessions != nil {
+				opts = append(opts, gmaps.WithSessionPool(seedCfg.sessions))
+			}
+
+			if seedCfg.jobID != "" {
+				opts = append(opts, gmaps.WithProvenance(gmaps.Provenance{JobID: seedCfg.jobID}))
+			}
+
+			if len(seedCfg.customExtractors) > 0 {
+				opts = append(opts, gmaps.WithCustomExtractors(seedCfg.customExtractors))
+			}
+
+			if seedCfg.emailFetchBudget != nil {
+				opts = append(opts, gmaps.WithEmailFetchBudget(seedCfg.emailFetchBudget))
+			}
+
+			if seedCfg.reviewerAnonymizer != nil {
+				opts = append(opts, gmaps.WithReviewerAnonymizer(seedCfg.reviewerAnonymizer))
+			}
+
+			if len(seedCfg.reviewLanguages) > 0 {
+				opts = append(opts, gmaps.WithReviewLanguages(seedCfg.reviewLanguages))
+			}
+
+			if seedCfg.exclusions != nil {
+				opts = append(opts, gmaps.WithExclusions(seedCfg.exclusions))
+			}
+
+			if seedCfg.keywordStats != nil {
+				opts = append(opts, gmaps.WithKeywordStats(seedCfg.keywordStats))
+			}
+
+			if seedCfg.placeFailures != nil {
+				opts = append(opts, gmaps.WithPlaceFailures(seedCfg.placeFailures))
+			}
+
+			if seedCfg.diagnoser != nil {
+				opts = append(opts, gmaps.WithDiagnoser(seedCfg.diagnoser))
+			}
+
+			if seedCfg.livePages != nil {
+				opts = append(opts, gmaps.WithLivePages(seedCfg.livePages))
+			}
+
+			if seedCfg.stepTimings != nil {
+				opts = append(opts, gmaps.WithStepTimings(seedCfg.stepTimings))
+			}
+
+			if seedCfg.emailProgress != nil {
+				opts = append(opts, gmaps.WithEmailProgress(seedCfg.emailProgress))
+			}
+
+			if seedCfg.emailBreaker != nil {
+				opts = append(opts, gmaps.WithEmailBreaker(seedCfg.emailBreaker))
+			}
+
+			if seedCfg.emailHAR != nil {
+				opts = append(opts, gmaps.WithEmailHAR(seedCfg.emailHAR))
+			}
+
+			if seedCfg.throttle != nil {
+				opts = append(opts, gmaps.WithThrottle(seedCfg.throttle))
+			}
+
+			if seedCfg.skip.Any() {
+				opts = append(opts, gmaps.WithSkip(seedCfg.skip), gmaps.WithSkipStats(seedCfg.skipStats))
+			}
+
+			if seedCfg.emailPacer != nil {
+				opts = append(opts, gmaps.WithEmailPacer(seedCfg.emailPacer))
+			}
+
 			job = gmaps.NewGmapJob(id, langCode, query, maxDepth, email, geoCoordinates, zoom, opts...)
 		} else {
 			jparams := gmaps.MapSearchParams{
@@ -123,9 +442,31 @@ func CreateSeedJobs(
 				opts = append(opts, gmaps.WithSearchJobExitMonitor(exitMonitor))
 			}
 
+			if seedCfg.excludeServiceArea {
+				opts = append(opts, gmaps.WithSearchJobExcludeServiceArea())
+			}
+
+			if seedCfg.fetchStats != nil {
+				opts = append(opts, gmaps.WithSearchJobFetchStats(seedCfg.fetchStats))
+			}
+
+			if seedCfg.jobID != "" {
+				opts = append(opts, gmaps.WithSearchJobProvenance(gmaps.Provenance{JobID: seedCfg.jobID}))
+			}
+
+			if seedCfg.exclusions != nil {
+				opts = append(opts, gmaps.WithSearchJobExclusions(seedCfg.exclusions))
+			}
+
+			if seedCfg.keywordStats != nil {
+				opts = append(opts, gmaps.WithSearchJobKeywordStats(seedCfg.keywordStats))
+			}
+
 			job = gmaps.NewSearchJob(&jparams, opts...)
 		}
 
+		seedCfg.keywordStats.AddSeed(query)
+
 		jobs = append(jobs, job)
 	}
 
@@ -149,7 +490,10 @@ func CreateGridSeedJobs(
 	dedup deduper.Deduper,
 	exitMonitor exiter.Exiter,
 	extraReviews bool,
+	seedOpts ...SeedJobOption,
 ) ([]scrapemate.IJob, error) {
+	seedCfg := newSeedJobConfig(seedOpts)
+
 	if zoom < 1 || zoom > 21 {
 		return nil, fmt.Errorf("invalid zoom level: %d", zoom)
 	}
@@ -195,6 +539,94 @@ func CreateGridSeedJobs(
 				opts = append(opts, gmaps.WithExtraReviews())
 			}
 
+			if seedCfg.excludeServiceArea {
+				opts = append(opts, gmaps.WithExcludeServiceArea())
+			}
+
+			if seedCfg.emailProxyRouter != nil {
+				opts = append(opts, gmaps.WithEmailProxyRouter(seedCfg.emailProxyRouter))
+			}
+
+			if seedCfg.emailVerifier != nil {
+				opts = append(opts, gmaps.WithEmailVerifier(seedCfg.emailVerifier))
+			}
+
+			if seedCfg.fetchStats != nil {
+				opts = append(opts, gmaps.WithFetchStats(seedCfg.fetchStats))
+			}
+
+			if seedCfg.sessions != nil {
+				opts = append(opts, gmaps.WithSessionPool(seedCfg.sessions))
+			}
+
+			if seedCfg.jobID != "" {
+				opts = append(opts, gmaps.WithProvenance(gmaps.Provenance{JobID: seedCfg.jobID}))
+			}
+
+			if len(seedCfg.customExtractors) > 0 {
+				opts = append(opts, gmaps.WithCustomExtractors(seedCfg.customExtractors))
+			}
+
+			if seedCfg.emailFetchBudget != nil {
+				opts = append(opts, gmaps.WithEmailFetchBudget(seedCfg.emailFetchBudget))
+			}
+
+			if seedCfg.reviewerAnonymizer != nil {
+				opts = append(opts, gmaps.WithReviewerAnonymizer(seedCfg.reviewerAnonymizer))
+			}
+
+			if len(seedCfg.reviewLanguages) > 0 {
+				opts = append(opts, gmaps.WithReviewLanguages(seedCfg.reviewLanguages))
+			}
+
+			if seedCfg.exclusions != nil {
+				opts = append(opts, gmaps.WithExclusions(seedCfg.exclusions))
+			}
+
+			if seedCfg.keywordStats != nil {
+				opts = append(opts, gmaps.WithKeywordStats(seedCfg.keywordStats))
+			}
+
+			if seedCfg.placeFailures != nil {
+				opts = append(opts, gmaps.WithPlaceFailures(seedCfg.placeFailures))
+			}
+
+			if seedCfg.diagnoser != nil {
+				opts = append(opts, gmaps.WithDiagnoser(seedCfg.diagnoser))
+			}
+
+			if seedCfg.livePages != nil {
+				opts = append(opts, gmaps.WithLivePages(seedCfg.livePages))
+			}
+
+			if seedCfg.stepTimings != nil {
+				opts = append(opts, gmaps.WithStepTimings(seedCfg.stepTimings))
+			}
+
+			if seedCfg.emailProgress != nil {
+				opts = append(opts, gmaps.WithEmailProgress(seedCfg.emailProgress))
+			}
+
+			if seedCfg.emailBreaker != nil {
+				opts = append(opts, gmaps.WithEmailBreaker(seedCfg.emailBreaker))
+			}
+
+			if seedCfg.emailHAR != nil {
+				opts = append(opts, gmaps.WithEmailHAR(seedCfg.emailHAR))
+			}
+
+			if seedCfg.throttle != nil {
+				opts = append(opts, gmaps.WithThrottle(seedCfg.throttle))
+			}
+
+			if seedCfg.skip.Any() {
+				opts = append(opts, gmaps.WithSkip(seedCfg.skip), gmaps.WithSkipStats(seedCfg.skipStats))
+			}
+
+			if seedCfg.emailPacer != nil {
+				opts = append(opts, gmaps.WithEmailPacer(seedCfg.emailPacer))
+			}
+
 			job := gmaps.NewGmapJob(
 				cellID,
 				langCode,
@@ -206,6 +638,8 @@ func CreateGridSeedJobs(
 				opts...,
 			)
 
+			seedCfg.keywordStats.AddSeed(queryText)
+
 			jobs = append(jobs, job)
 		}
 	}
@@ -213,6 +647,126 @@ func CreateGridSeedJobs(
 	return jobs, nil
 }
 
+// PlaceURL is a place page to visit without searching for it first, with
+// the query its place is attributed to, if any.
+type PlaceURL struct {
+	URL   string
+	Query string
+}
+
+// CreatePlaceJobs creates a job visiting each of the place pages of places,
+// with the email extraction and the extra reviews when email and
+// extraReviews are set. The options of seedOpts that apply to the places
+// carry over.
+func CreatePlaceJobs(
+	langCode string,
+	places []PlaceURL,
+	email bool,
+	exitMonitor exiter.Exiter,
+	extraReviews bool,
+	seedOpts ...SeedJobOption,
+) []scrapemate.IJob {
+	seedCfg := newSeedJobConfig(seedOpts)
+
+	parentID := uuid.New().String()
+
+	jobs := make([]scrapemate.IJob, 0, len(places))
+
+	for i := range places {
+		opts := []gmaps.PlaceJobOptions{gmaps.WithPlaceJobQuery(places[i].Query)}
+
+		if exitMonitor != nil {
+			opts = append(opts, gmaps.WithPlaceJobExitMonitor(exitMonitor))
+		}
+
+		if seedCfg.excludeServiceArea {
+			opts = append(opts, gmaps.WithPlaceJobExcludeServiceArea())
+		}
+
+		if seedCfg.emailProxyRouter != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailProxyRouter(seedCfg.emailProxyRouter))
+		}
+
+		if seedCfg.emailVerifier != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailVerifier(seedCfg.emailVerifier))
+		}
+
+		if seedCfg.fetchStats != nil {
+			opts = append(opts, gmaps.WithPlaceJobFetchStats(seedCfg.fetchStats))
+		}
+
+		if seedCfg.sessions != nil {
+			opts = append(opts, gmaps.WithPlaceJobSessionPool(seedCfg.sessions))
+		}
+
+		opts = append(opts, gmaps.WithPlaceJobProvenance(gmaps.Provenance{JobID: seedCfg.jobID}))
+
+		if len(seedCfg.customExtractors) > 0 {
+			opts = append(opts, gmaps.WithPlaceJobCustomExtractors(seedCfg.customExtractors))
+		}
+
+		if seedCfg.emailFetchBudget != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailFetchBudget(seedCfg.emailFetchBudget))
+		}
+
+		if seedCfg.reviewerAnonymizer != nil {
+			opts = append(opts, gmaps.WithPlaceJobReviewerAnonymizer(seedCfg.reviewerAnonymizer))
+		}
+
+		if len(seedCfg.reviewLanguages) > 0 {
+			opts = append(opts, gmaps.WithPlaceJobReviewLanguages(seedCfg.reviewLanguages))
+		}
+
+		if seedCfg.exclusions != nil {
+			opts = append(opts, gmaps.WithPlaceJobExclusions(seedCfg.exclusions))
+		}
+
+		if seedCfg.keywordStats != nil {
+			opts = append(opts, gmaps.WithPlaceJobKeywordStats(seedCfg.keywordStats))
+		}
+
+		if seedCfg.placeFailures != nil {
+			opts = append(opts, gmaps.WithPlaceJobFailures(seedCfg.placeFailures))
+		}
+
+		if seedCfg.livePages != nil {
+			opts = append(opts, gmaps.WithPlaceJobLivePages(seedCfg.livePages))
+		}
+
+		if seedCfg.stepTimings != nil {
+			opts = append(opts, gmaps.WithPlaceJobStepTimings(seedCfg.stepTimings))
+		}
+
+		if seedCfg.emailProgress != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailProgress(seedCfg.emailProgress))
+		}
+
+		if seedCfg.emailBreaker != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailBreaker(seedCfg.emailBreaker))
+		}
+
+		if seedCfg.emailHAR != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailHAR(seedCfg.emailHAR))
+		}
+
+		if seedCfg.throttle != nil {
+			opts = append(opts, gmaps.WithPlaceJobThrottle(seedCfg.throttle))
+		}
+
+		if seedCfg.emailPacer != nil {
+			opts = append(opts, gmaps.WithPlaceJobEmailPacer(seedCfg.emailPacer))
+		}
+
+		if seedCfg.skip.Any() {
+			opts = append(opts, gmaps.WithPlaceJobSkip(seedCfg.skip), gmaps.WithPlaceJobSkipStats(seedCfg.skipStats))
+		}
+
+		jobs = append(jobs, gmaps.NewPlaceJob(parentID, langCode, places[i].URL, email, extraReviews, opts...))
+	}
+
+	return jobs
+}
+
 // query holds a parsed input line.
 type query struct {
 	text string
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gosom/google-maps-scraper/gmaps"
 	"github.com/gosom/google-maps-scraper/grid"
 	"github.com/gosom/google-maps-scraper/runner"
 )
@@ -82,3 +83,30 @@ func TestCreateGridSeedJobsRejectsEmptyQueryBeforeCustomID(t *testing.T) {
 		t.Fatalf("expected empty query text error, got %v", err)
 	}
 }
+
+func TestCreatePlaceJobs(t *testing.T) {
+	t.Parallel()
+
+	places := []runner.PlaceURL{
+		{URL: "https://www.google.com/maps/place/a", Query: "coffee"},
+		{URL: "https://www.google.com/maps/place/b", Query: "bakery"},
+	}
+
+	jobs := runner.CreatePlaceJobs("de", places, true, nil, false, runner.WithSeedJobID("job-1"))
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+
+	place, ok := jobs[1].(*gmaps.PlaceJob)
+	if !ok {
+		t.Fatalf("expected a place job, got %T", jobs[1])
+	}
+
+	if place.GetURL() != places[1].URL || place.Query != "bakery" || place.URLParams["hl"] != "de" || !place.ExtractEmail {
+		t.Fatalf("unexpected place job: %s %q %v %v", place.GetURL(), place.Query, place.URLParams, place.ExtractEmail)
+	}
+
+	if place.Provenance.JobID != "job-1" {
+		t.Fatalf("expected the job ID in the provenance, got %q", place.Provenance.JobID)
+	}
+}
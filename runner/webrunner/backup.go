@@ -0,0 +1,103 @@
+package webrunner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+// backupRunner writes a backup of the data folder, or restores one, and
+// exits.
+type backupRunner struct {
+	cfg *runner.Config
+}
+
+func NewBackup(cfg *runner.Config) (runner.Runner, error) {
+	if cfg.RunMode != runner.RunModeBackup && cfg.RunMode != runner.RunModeRestore {
+		return nil, fmt.Errorf("%w: %d", runner.ErrInvalidRunMode, cfg.RunMode)
+	}
+
+	if cfg.DataFolder == "" {
+		return nil, fmt.Errorf("data folder is required")
+	}
+
+	return &backupRunner{cfg: cfg}, nil
+}
+
+func (b *backupRunner) Run(ctx context.Context) error {
+	if b.cfg.RunMode == runner.RunModeRestore {
+		return b.restore()
+	}
+
+	return b.backup(ctx)
+}
+
+// backup runs alongside a web server on the same data folder: the database
+// is snapshotted while in use.
+func (b *backupRunner) backup(ctx context.Context) error {
+	dbpath := filepath.Join(b.cfg.DataFolder, dbfname)
+
+	if _, err := os.Stat(dbpath); err != nil {
+		return fmt.Errorf("no jobs database in %s: %w", b.cfg.DataFolder, err)
+	}
+
+	repo, err := sqlite.New(dbpath)
+	if err != nil {
+		return err
+	}
+
+	svc := web.NewService(repo, b.cfg.DataFolder)
+
+	var manifest web.BackupManifest
+
+	err = web.WriteFileAtomic(b.cfg.Backup, func(w io.Writer) error {
+		var err error
+
+		manifest, err = svc.Backup(ctx, w)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("backup of %s written to %s: %d files", b.cfg.DataFolder, b.cfg.Backup, len(manifest.Files))
+
+	return nil
+}
+
+func (b *backupRunner) restore() error {
+	f, err := os.Open(b.cfg.Restore)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	report, err := web.RestoreBackup(f, b.cfg.DataFolder, web.RestoreOptions{
+		Database:      dbfname,
+		CheckDatabase: sqlite.Check,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("backup of %s restored to %s: %d files", report.CreatedAt.Format("2006-01-02 15:04:05"), b.cfg.DataFolder, report.Files)
+
+	if report.Previous != "" {
+		log.Printf("the previous data folder was moved to %s", report.Previous)
+	}
+
+	return nil
+}
+
+func (b *backupRunner) Close(context.Context) error {
+	return nil
+}
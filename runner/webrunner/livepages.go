@@ -0,0 +1,103 @@
+package webrunner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+// liveJobs holds the pages and the email phase of the jobs running in this
+// process, for the screenshots and the email estimates of the web UI and
+// REST API. A nil *liveJobs holds nothing, remote workers have no API to
+// show them.
+type liveJobs struct {
+	// workers is how many email searches of a job run at a time.
+	workers int
+
+	mu   sync.Mutex
+	jobs map[string]*liveJob
+}
+
+type liveJob struct {
+	pages  *gmaps.LivePages
+	emails *gmaps.EmailProgress
+	budget *gmaps.FetchBudget
+}
+
+func newLiveJobs(workers int) *liveJobs {
+	return &liveJobs{workers: workers, jobs: make(map[string]*liveJob)}
+}
+
+// add returns the pages of job id, kept until remove with its email
+// progress and fetch budget.
+func (l *liveJobs) add(id string, emails *gmaps.EmailProgress, budget *gmaps.FetchBudget) *gmaps.LivePages {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pages := gmaps.NewLivePages()
+	l.jobs[id] = &liveJob{pages: pages, emails: emails, budget: budget}
+
+	return pages
+}
+
+func (l *liveJobs) remove(id string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.jobs, id)
+}
+
+func (l *liveJobs) get(id string) (*liveJob, error) {
+	l.mu.Lock()
+	job, ok := l.jobs[id]
+	l.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w here", web.ErrJobNotRunning)
+	}
+
+	return job, nil
+}
+
+// Screenshot implements web.ScreenshotSource.
+func (l *liveJobs) Screenshot(_ context.Context, id string) (gmaps.LiveScreenshot, error) {
+	job, err := l.get(id)
+	if err != nil {
+		return gmaps.LiveScreenshot{}, err
+	}
+
+	return job.pages.Screenshot()
+}
+
+// EmailEstimate implements web.EmailPhaseSource.
+func (l *liveJobs) EmailEstimate(_ context.Context, id string) (gmaps.EmailEstimate, error) {
+	job, err := l.get(id)
+	if err != nil {
+		return gmaps.EmailEstimate{}, err
+	}
+
+	return job.emails.Estimate(l.workers, job.budget), nil
+}
+
+// AbortEmails implements web.EmailPhaseSource.
+func (l *liveJobs) AbortEmails(_ context.Context, id string) error {
+	job, err := l.get(id)
+	if err != nil {
+		return err
+	}
+
+	job.budget.Abort()
+
+	return nil
+}
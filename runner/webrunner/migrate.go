@@ -0,0 +1,134 @@
+package webrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+// migrateRunner imports the output of the upstream scraper,
+// gosom/google-maps-scraper, and exits.
+type migrateRunner struct {
+	cfg *runner.Config
+}
+
+func NewMigrate(cfg *runner.Config) (runner.Runner, error) {
+	if cfg.RunMode != runner.RunModeMigrate {
+		return nil, fmt.Errorf("%w: %d", runner.ErrInvalidRunMode, cfg.RunMode)
+	}
+
+	if cfg.DataFolder == "" {
+		return nil, fmt.Errorf("data folder is required")
+	}
+
+	return &migrateRunner{cfg: cfg}, nil
+}
+
+func (m *migrateRunner) Run(ctx context.Context) error {
+	info, err := os.Stat(m.cfg.Migrate)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.cfg.DataFolder, os.ModePerm); err != nil {
+		return err
+	}
+
+	repo, err := sqlite.New(filepath.Join(m.cfg.DataFolder, dbfname))
+	if err != nil {
+		return err
+	}
+
+	svc := web.NewService(repo, m.cfg.DataFolder)
+
+	if info.IsDir() {
+		return m.migrateFolder(ctx, svc)
+	}
+
+	return m.migrateFile(ctx, svc, info.ModTime())
+}
+
+// migrateFolder imports the jobs of the data folder of an upstream web
+// runner, with the places of their CSV results.
+func (m *migrateRunner) migrateFolder(ctx context.Context, svc *web.Service) error {
+	jobs, err := sqlite.UpstreamJobs(filepath.Join(m.cfg.Migrate, dbfname))
+	if err != nil {
+		return fmt.Errorf("reading the upstream jobs: %w", err)
+	}
+
+	imported, places := 0, 0
+
+	for i := range jobs {
+		entries, err := web.ReadPlacesFile(filepath.Join(m.cfg.Migrate, jobs[i].ID+".csv"))
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			entries = nil
+		case err != nil:
+			return fmt.Errorf("job %s: %w", jobs[i].ID, err)
+		}
+
+		err = svc.Migrate(ctx, &jobs[i], entries)
+		if errors.Is(err, web.ErrAlreadyExists) {
+			log.Printf("job %s (%s) skipped: it exists already", jobs[i].ID, jobs[i].Name)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		imported++
+		places += len(entries)
+	}
+
+	log.Printf("%d of %d jobs imported from %s, with %d places", imported, len(jobs), m.cfg.Migrate, places)
+
+	return nil
+}
+
+// migrateFile imports the places of a result file as a finished job named
+// after it.
+func (m *migrateRunner) migrateFile(ctx context.Context, svc *web.Service, modified time.Time) error {
+	entries, err := web.ReadPlacesFile(m.cfg.Migrate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.cfg.Migrate, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(m.cfg.Migrate), filepath.Ext(m.cfg.Migrate))
+
+	job := web.Job{
+		ID:     uuid.New().String(),
+		Name:   name,
+		Status: web.StatusOK,
+		Date:   modified.UTC(),
+		Data: web.JobData{
+			Lang:  m.cfg.LangCode,
+			Depth: m.cfg.MaxDepth,
+		},
+	}
+
+	if err := svc.Migrate(ctx, &job, entries); err != nil {
+		return err
+	}
+
+	log.Printf("%d places of %s imported as job %s", len(entries), m.cfg.Migrate, job.ID)
+
+	return nil
+}
+
+func (m *migrateRunner) Close(context.Context) error {
+	return nil
+}
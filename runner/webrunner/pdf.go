@@ -0,0 +1,66 @@
+package webrunner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// pdfPrinter implements web.PDFPrinter with a headless chromium started for
+// each document, the reports being rare enough not to keep one running.
+type pdfPrinter struct {
+	// mu prints one document at a time, each with its own browser
+	mu sync.Mutex
+}
+
+// PrintPDF implements web.PDFPrinter.
+func (p *pdfPrinter) PrintPDF(ctx context.Context, html []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, fmt.Errorf("starting playwright: %w", err)
+	}
+
+	defer func() { _ = pw.Stop() }()
+
+	br, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(true),
+		Args:     []string{"--no-sandbox", "--disable-dev-shm-usage"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("starting chromium: %w", err)
+	}
+
+	defer func() { _ = br.Close() }()
+
+	page, err := br.NewPage()
+	if err != nil {
+		return nil, err
+	}
+
+	err = page.SetContent(string(html), playwright.PageSetContentOptions{
+		WaitUntil: playwright.WaitUntilStateLoad,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.PDF(playwright.PagePdfOptions{
+		Format:          playwright.String("A4"),
+		PrintBackground: playwright.Bool(true),
+		Margin: &playwright.Margin{
+			Top:    playwright.String("12mm"),
+			Bottom: playwright.String("12mm"),
+			Left:   playwright.String("10mm"),
+			Right:  playwright.String("10mm"),
+		},
+	})
+}
@@ -0,0 +1,180 @@
+package webrunner
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+const (
+	// phaseInterval is how often the phases of a running job are checked.
+	phaseInterval = time.Second
+	// mapsSettle is how long the places scraped last take to reach the
+	// results once the Maps phase is over, through the batches of the
+	// post-processing writers.
+	mapsSettle = 30 * time.Second
+)
+
+// phaseWatcher follows the Maps and the email phases of a job searching
+// emails, running in this process, from a single goroutine. Once the Maps phase is over it writes the
+// Maps-only results, and it saves the phases whenever they change.
+type phaseWatcher struct {
+	store     jobStore
+	job       web.Job
+	exits     exiter.Exiter
+	emails    *gmaps.EmailProgress
+	budget    *gmaps.FetchBudget
+	writer    *DualWriter
+	folder    string
+	csvLayout web.CSVLayout
+
+	phases web.JobPhases
+	// overSince is when the places were last seen all scraped
+	overSince time.Time
+	saved     time.Time
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// watchPhases follows the phases of job, set already, until end. The job is
+// copied, it is no more read after.
+func (w *webrunner) watchPhases(ctx context.Context, job *web.Job, exits exiter.Exiter, emails *gmaps.EmailProgress, budget *gmaps.FetchBudget, writer *DualWriter, folder string) *phaseWatcher {
+	ctx, cancel := context.WithCancel(ctx)
+
+	p := phaseWatcher{
+		store:     w.store,
+		job:       *job,
+		exits:     exits,
+		emails:    emails,
+		budget:    budget,
+		writer:    writer,
+		folder:    folder,
+		csvLayout: csvLayout(w.cfg),
+		phases:    *job.Stats.Phases,
+		saved:     time.Now(),
+		stop:      cancel,
+		done:      make(chan struct{}),
+	}
+
+	go p.run(ctx)
+
+	return &p
+}
+
+func (p *phaseWatcher) run(ctx context.Context) {
+	defer close(p.done)
+
+	p.save(ctx)
+
+	ticker := time.NewTicker(phaseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.check(ctx, now)
+		}
+	}
+}
+
+// check updates the phases at now and saves them when a phase started or
+// ended, or at most every progressInterval while they progress.
+func (p *phaseWatcher) check(ctx context.Context, now time.Time) {
+	found, completed, more := p.exits.Progress()
+	pending := p.emails.Pending()
+	progress := p.emails.Estimate(1, p.budget)
+
+	prev := p.phases
+	maps, email := &p.phases.Maps, &p.phases.Email
+
+	// the places waiting for their email search are scraped already
+	maps.Done, maps.Total = completed+pending, found
+	email.Done, email.Total = progress.Done, progress.Queued
+
+	if progress.Queued > 0 {
+		email.Start(now)
+	}
+
+	if progress.Aborted {
+		email.End(web.PhaseAborted, now)
+	}
+
+	if maps.Status == web.StatusWorking {
+		switch {
+		case more || completed+pending < found:
+			p.overSince = time.Time{}
+		case p.overSince.IsZero():
+			p.overSince = now
+		case now.Sub(p.overSince) >= mapsSettle:
+			entries := p.emails.MapsEntries(p.writer.Entries())
+
+			if err := web.WriteMapsResults(p.folder, p.job.ID, entries, p.csvLayout); err != nil {
+				log.Printf("job %s: writing the Maps-only results: %v", p.job.ID, err)
+			}
+
+			maps.End(web.StatusOK, now)
+		}
+	}
+
+	changed := maps.Status != prev.Maps.Status || email.Status != prev.Email.Status
+	progressed := maps.Done != prev.Maps.Done || email.Done != prev.Email.Done || email.Total != prev.Email.Total
+
+	if !changed && (!progressed || now.Sub(p.saved) < progressInterval) {
+		return
+	}
+
+	p.saved = now
+	p.save(ctx)
+}
+
+// save saves the job with its phases.
+func (p *phaseWatcher) save(ctx context.Context) {
+	job := p.job
+	phases := p.phases
+	job.Stats.Phases = &phases
+
+	if err := p.store.Update(ctx, &job); err != nil {
+		log.Printf("job %s: saving its phases: %v", p.job.ID, err)
+	}
+}
+
+// end stops following the phases and returns them as the job ended with
+// status. A nil *phaseWatcher returns nil.
+func (p *phaseWatcher) end(status string) *web.JobPhases {
+	if p == nil {
+		return nil
+	}
+
+	p.stop()
+	<-p.done
+
+	now := time.Now()
+	phases := p.phases
+
+	found, completed, _ := p.exits.Progress()
+	progress := p.emails.Estimate(1, p.budget)
+
+	phases.Maps.Done, phases.Maps.Total = min(completed, found), found
+	phases.Email.Done, phases.Email.Total = progress.Done, progress.Queued
+
+	phases.Maps.End(status, now)
+
+	switch {
+	case progress.Aborted:
+		phases.Email.End(web.PhaseAborted, now)
+	case progress.Queued == 0:
+		// no website to search: the phase never started
+		phases.Email.Status = status
+	default:
+		phases.Email.End(status, now)
+	}
+
+	return &phases
+}
@@ -0,0 +1,233 @@
+package webrunner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/deduper"
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/scrapemate"
+)
+
+// sandboxRunner implements web.Sandbox for the webrunner, which is created
+// after its service.
+type sandboxRunner struct {
+	w *webrunner
+}
+
+// RunSandbox implements web.Sandbox.
+func (s *sandboxRunner) RunSandbox(ctx context.Context, job *web.Job) (web.SandboxResult, error) {
+	return s.w.runSandbox(ctx, job)
+}
+
+// sandboxWriter keeps the places of a sandbox run in memory.
+type sandboxWriter struct {
+	mu      sync.Mutex
+	entries []*gmaps.Entry
+	last    time.Time
+}
+
+// Run implements scrapemate.ResultWriter.
+func (s *sandboxWriter) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	for result := range in {
+		entries := collectEntries([]any{result.Data})
+		if len(entries) == 0 {
+			continue
+		}
+
+		s.mu.Lock()
+		s.entries = append(s.entries, entries...)
+		s.last = time.Now()
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (s *sandboxWriter) result() ([]*gmaps.Entry, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.entries, s.last
+}
+
+// seedTimer is an exiter.Exiter recording when the seed is done, that is
+// when the search page was parsed.
+type seedTimer struct {
+	exiter.Exiter
+
+	mu   sync.Mutex
+	done time.Time
+}
+
+func (s *seedTimer) IncrSeedCompleted(val int) {
+	s.mu.Lock()
+	s.done = time.Now()
+	s.mu.Unlock()
+
+	s.Exiter.IncrSeedCompleted(val)
+}
+
+func (s *seedTimer) seedDone() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done
+}
+
+// runSandbox scrapes the first page of results of the keyword of job, or
+// the place page it is given, without storing anything, see
+// web.SandboxJob and web.LookupJob.
+func (w *webrunner) runSandbox(ctx context.Context, job *web.Job) (web.SandboxResult, error) {
+	start := time.Now()
+
+	var ans web.SandboxResult
+
+	if len(job.Data.Places) > 0 {
+		ans.Keyword = job.Data.Places[0]
+	} else {
+		ans.Keyword = job.Data.Keywords[0]
+	}
+
+	writer := &sandboxWriter{}
+
+	mate, err := w.setupMate(ctx, writer, job)
+	if err != nil {
+		return ans, err
+	}
+
+	defer mate.Close()
+
+	ans.Env = job.Stats.Env
+
+	var coords string
+	if job.Data.Lat != "" && job.Data.Lon != "" {
+		coords = job.Data.Lat + "," + job.Data.Lon
+	}
+
+	exitMonitor := &seedTimer{Exiter: exiter.New()}
+	exitMonitor.SetMaxPlaces(job.Data.MaxPlaces)
+
+	emailProxies, err := w.emailProxyRouter(ctx, job)
+	if err != nil {
+		log.Printf("sandbox: ignoring email proxy routes: %v", err)
+	}
+
+	emailPacer, err := w.emailPacer(ctx, job)
+	if err != nil {
+		log.Printf("sandbox: fetching the websites without delay: %v", err)
+	}
+
+	fetchStats := gmaps.NewFetchStats()
+	keywordStats := gmaps.NewKeywordStats()
+
+	seedOpts := []runner.SeedJobOption{
+		runner.WithSeedEmailProxyRouter(emailProxies),
+		runner.WithSeedFetchStats(fetchStats),
+		runner.WithSeedSessionPool(gmaps.NewSessionPool(w.cfg.Identities)),
+		runner.WithSeedJobID(job.ID),
+		runner.WithSeedEmailFetchBudget(gmaps.NewFetchBudget(job.Data.MaxEmailFetches)),
+		runner.WithSeedEmailBreaker(gmaps.NewDomainBreaker()),
+		runner.WithSeedEmailPacer(emailPacer),
+		runner.WithSeedKeywordStats(keywordStats),
+		// solo la classificazione, nessun file salvato
+		runner.WithSeedDiagnoser(gmaps.NewDiagnoser("")),
+	}
+
+	var seedJobs []scrapemate.IJob
+
+	if len(job.Data.Places) > 0 {
+		places := []runner.PlaceURL{{URL: job.Data.Places[0]}}
+
+		// a place page is no seed: the run ends once it is done
+		seedJobs = runner.CreatePlaceJobs(
+			job.Data.Lang,
+			places[:exitMonitor.ReservePlaces(len(places))],
+			job.Data.Email,
+			exitMonitor,
+			w.cfg.ExtraReviews,
+			seedOpts...,
+		)
+	} else {
+		seedJobs, err = runner.CreateSeedJobs(
+			job.Data.FastMode,
+			job.Data.Lang,
+			strings.NewReader(job.Data.Keywords[0]),
+			1,
+			job.Data.Email,
+			coords,
+			job.Data.Zoom,
+			func() float64 {
+				if job.Data.Radius <= 0 {
+					return 10000 // 10 km
+				}
+
+				return float64(job.Data.Radius)
+			}(),
+			deduper.New(),
+			exitMonitor,
+			w.cfg.ExtraReviews,
+			seedOpts...,
+		)
+		if err != nil {
+			return ans, err
+		}
+
+		exitMonitor.SetSeedCount(len(seedJobs))
+	}
+
+	mateCtx, cancel := context.WithTimeout(ctx, job.Data.MaxTime)
+	defer cancel()
+
+	exitMonitor.SetCancelFunc(cancel)
+
+	go exitMonitor.Run(mateCtx)
+
+	err = mate.Start(mateCtx, seedJobs...)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		ans.Error = err.Error()
+	}
+
+	ans.TimedOut = errors.Is(mateCtx.Err(), context.DeadlineExceeded)
+
+	cancel()
+
+	// come per i job, lascia finire il writer
+	time.Sleep(500 * time.Millisecond)
+
+	mate.Close()
+
+	entries, last := writer.result()
+	searched := exitMonitor.seedDone()
+
+	ans.Places = entries
+	ans.FetchErrors = fetchStats.ErrorReport()
+	ans.Timings.Total = time.Since(start).Milliseconds()
+
+	if !searched.IsZero() {
+		ans.Timings.Search = searched.Sub(start).Milliseconds()
+
+		if last.After(searched) {
+			ans.Timings.Places = last.Sub(searched).Milliseconds()
+		}
+	}
+
+	if report := keywordStats.Report(); len(report) > 0 {
+		ans.Status = report[0].Status
+		ans.Found = report[0].Found
+		ans.Diagnosis = report[0].Diagnosis
+
+		if ans.Error == "" {
+			ans.Error = report[0].Error
+		}
+	}
+
+	return ans, nil
+}
@@ -4,28 +4,94 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/postprocess"
+	"github.com/gosom/google-maps-scraper/proxypool"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/google-maps-scraper/tlmt"
 	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/redisqueue"
 	"github.com/gosom/google-maps-scraper/web/sqlite"
 	"github.com/gosom/scrapemate"
 	"github.com/gosom/scrapemate/scrapemateapp"
 	"golang.org/x/sync/errgroup"
 )
 
+// jobStore is where a runner reads the settings and records the state of its
+// jobs: the local service, or the coordinator for a remote worker.
+type jobStore interface {
+	Update(context.Context, *web.Job) error
+	// ProfileSettings returns the settings of the profile of a job, see
+	// web.Service.ProfileSettings.
+	ProfileSettings(ctx context.Context, name string) (web.Settings, error)
+}
+
+// progressReporter is implemented by the stores that follow a running job,
+// which is abandoned when Progress fails with web.ErrLeaseLost or
+// web.ErrNotFound.
+type progressReporter interface {
+	Progress(ctx context.Context, job *web.Job, results int) error
+}
+
+// progressInterval is how often the progress of a job is reported.
+const progressInterval = 15 * time.Second
+
+// dbfname names the jobs database in the data folder.
+const dbfname = "jobs.db"
+
+// csvLayout returns the layout of the CSV results asked by the flags.
+func csvLayout(cfg *runner.Config) web.CSVLayout {
+	switch {
+	case cfg.CSVUpstream:
+		return web.CSVUpstream
+	case cfg.CSVProvenance:
+		return web.CSVProvenance
+	default:
+		return web.CSVDefault
+	}
+}
+
 type webrunner struct {
-	srv *web.Server
-	svc *web.Service
-	cfg *runner.Config
+	srv   *web.Server
+	svc   *web.Service
+	store jobStore
+	// queue is set when the pending jobs are kept in Redis.
+	queue *redisqueue.Queue
+	cfg   *runner.Config
+	// dnsCache is shared by the email verifiers of all jobs.
+	dnsCache *gmaps.DNSCache
+	// emailTimings is shared by the email phases of all jobs, to estimate
+	// the next ones.
+	emailTimings *gmaps.DomainTimings
+	// hook post-processes the results of every job when set.
+	hook postprocess.Hook
+	// llm summarizes and scores the results of every job when set, its
+	// token cap holding across jobs.
+	llm *postprocess.LLM
+	// osm and registration enrich the results of the jobs asking for
+	// them, shared so that the rate limit and the caches hold across jobs.
+	osm          *gmaps.OSMChecker
+	registration *gmaps.RegistrationEnricher
+	// live holds the pages of the running jobs.
+	live *liveJobs
+	// busy is set while work runs a job, which opens the fast lane.
+	busy atomic.Bool
+
+	// proxyPools holds the running pool of each proxy provider spec,
+	// refreshed until runCtx, the context of Run, ends.
+	mu         sync.Mutex
+	proxyPools map[string]*proxypool.Pool
+	runCtx     context.Context
 }
 
 func New(cfg *runner.Config) (runner.Runner, error) {
@@ -33,12 +99,26 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, fmt.Errorf("data folder is required")
 	}
 
+	hook, err := runner.PostProcessHook(cfg.PostProcess)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -post-process: %w", err)
+	}
+
+	llm, err := runner.LLMHook(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -llm-prompt: %w", err)
+	}
+
+	if cfg.EntryWebhook != "" {
+		if err := postprocess.ValidateStreamURL(cfg.EntryWebhook); err != nil {
+			return nil, fmt.Errorf("invalid -entry-webhook: %w", err)
+		}
+	}
+
 	if err := os.MkdirAll(cfg.DataFolder, os.ModePerm); err != nil {
 		return nil, err
 	}
 
-	const dbfname = "jobs.db"
-
 	dbpath := filepath.Join(cfg.DataFolder, dbfname)
 
 	repo, err := sqlite.New(dbpath)
@@ -46,27 +126,154 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, err
 	}
 
-	svc := web.NewService(repo, cfg.DataFolder)
+	var svcOpts []web.ServiceOption
+
+	var queue *redisqueue.Queue
+
+	if cfg.RedisURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		queue, err = redisqueue.New(ctx, cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+
+		svcOpts = append(svcOpts, web.WithJobQueue(queue), web.WithHost(cfg.WorkerID))
+	}
+
+	switch csvLayout(cfg) {
+	case web.CSVProvenance:
+		svcOpts = append(svcOpts, web.WithCSVProvenance())
+	case web.CSVUpstream:
+		svcOpts = append(svcOpts, web.WithCSVUpstream())
+	}
+
+	svcOpts = append(svcOpts, web.WithLookupCache(cfg.LookupCacheTTL))
+
+	if cfg.ManifestKey != "" {
+		key, err := web.LoadManifestKey(cfg.ManifestKey)
+		if err != nil {
+			return nil, err
+		}
+
+		svcOpts = append(svcOpts, web.WithManifestKey(key))
+	}
+
+	if cfg.ResultsKey != "" {
+		key, err := web.ParseResultsKey(cfg.ResultsKey)
+		if err != nil {
+			return nil, err
+		}
+
+		svcOpts = append(svcOpts, web.WithResultsKey(key))
+	}
+
+	live := newLiveJobs(runner.EmailWorkers(cfg))
+	svcOpts = append(svcOpts, web.WithScreenshotSource(live), web.WithEmailPhaseSource(live))
+
+	// il sandbox usa i browser di questo host, che un coordinator non ha
+	sandbox := &sandboxRunner{}
+	if !cfg.Coordinator {
+		svcOpts = append(svcOpts, web.WithSandbox(sandbox))
+	}
+
+	svc := web.NewService(repo, cfg.DataFolder, svcOpts...)
+
+	var opts []web.ServerOption
+	if cfg.WorkerToken != "" {
+		opts = append(opts, web.WithWorkerToken(cfg.WorkerToken))
+	}
+
+	if cfg.UIDir != "" {
+		opts = append(opts, web.WithUIDir(cfg.UIDir))
+	}
+
+	opts = append(opts, web.WithPDFPrinter(&pdfPrinter{}))
+	opts = append(opts, web.WithTimeouts(web.ServerTimeouts{
+		Read:  cfg.ReadTimeout,
+		Write: cfg.WriteTimeout,
+		Idle:  cfg.IdleTimeout,
+	}))
 
-	srv, err := web.New(svc, cfg.Addr, cfg.APIToken)
+	srv, err := web.New(svc, cfg.Addr, cfg.APIToken, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	ans := webrunner{
-		srv: srv,
-		svc: svc,
-		cfg: cfg,
+		srv:          srv,
+		svc:          svc,
+		store:        svc,
+		queue:        queue,
+		cfg:          cfg,
+		dnsCache:     gmaps.NewDNSCache(),
+		emailTimings: gmaps.NewDomainTimings(),
+		hook:         hook,
+		llm:          llm,
+		osm:          runner.OSMChecker(cfg),
+		registration: runner.RegistrationEnricher(cfg),
+		live:         live,
+		proxyPools:   make(map[string]*proxypool.Pool),
 	}
 
+	sandbox.w = &ans
+
 	return &ans, nil
 }
 
 func (w *webrunner) Run(ctx context.Context) error {
+	w.setRunContext(ctx)
+
+	// jobs created before the queue was configured
+	if err := w.svc.SyncQueue(ctx); err != nil {
+		return err
+	}
+
 	egroup, ctx := errgroup.WithContext(ctx)
 
+	// a coordinator leaves the scraping to its remote workers
+	if !w.cfg.Coordinator {
+		n, err := w.svc.RecoverInterrupted(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			log.Printf("recovered %d jobs interrupted by the last shutdown", n)
+		}
+
+		egroup.Go(func() error {
+			return w.work(ctx)
+		})
+
+		egroup.Go(func() error {
+			return w.fastLane(ctx)
+		})
+	}
+
+	if w.cfg.WorkerToken != "" {
+		egroup.Go(func() error {
+			return w.requeueExpired(ctx)
+		})
+	}
+
+	egroup.Go(func() error {
+		// the jobs that ended before the search index existed
+		n, err := w.svc.IndexSearch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("search index: %v", err)
+		}
+
+		if n > 0 {
+			log.Printf("indexed the results of %d jobs for the search", n)
+		}
+
+		return nil
+	})
+
 	egroup.Go(func() error {
-		return w.work(ctx)
+		return w.refreshWatchlists(ctx)
 	})
 
 	egroup.Go(func() error {
@@ -76,7 +283,20 @@ func (w *webrunner) Run(ctx context.Context) error {
 	return egroup.Wait()
 }
 
+// setRunContext keeps ctx, the context of Run, for what outlives the jobs
+// and the requests that start it.
+func (w *webrunner) setRunContext(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.runCtx = ctx
+}
+
 func (w *webrunner) Close(context.Context) error {
+	if w.queue != nil {
+		return w.queue.Close()
+	}
+
 	return nil
 }
 
@@ -89,49 +309,159 @@ func (w *webrunner) work(ctx context.Context) error {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			jobs, err := w.svc.SelectPending(ctx)
+			job, err := w.svc.Claim(ctx, "")
 			if err != nil {
 				return err
 			}
 
-			for i := range jobs {
-				select {
-				case <-ctx.Done():
-					return nil
-				default:
-					t0 := time.Now().UTC()
-					if err := w.scrapeJob(ctx, &jobs[i]); err != nil {
-						params := map[string]any{
-							"job_count": len(jobs[i].Data.Keywords),
-							"duration":  time.Now().UTC().Sub(t0).String(),
-							"error":     err.Error(),
-						}
-
-						evt := tlmt.NewEvent("web_runner", params)
-
-						_ = runner.Telemetry().Send(ctx, evt)
-
-						log.Printf("error scraping job %s: %v", jobs[i].ID, err)
-					} else {
-						params := map[string]any{
-							"job_count": len(jobs[i].Data.Keywords),
-							"duration":  time.Now().UTC().Sub(t0).String(),
-						}
-
-						_ = runner.Telemetry().Send(ctx, tlmt.NewEvent("web_runner", params))
-
-						log.Printf("job %s scraped successfully", jobs[i].ID)
-					}
+			if job != nil {
+				w.busy.Store(true)
+				w.runLocal(ctx, job)
+				w.busy.Store(false)
+			}
+		}
+	}
+}
+
+// fastLane runs the small jobs created while work is busy, in a slot of
+// their own, see web.Service.ClaimFastLane.
+func (w *webrunner) fastLane(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if !w.busy.Load() {
+				continue
+			}
+
+			job, err := w.svc.ClaimFastLane(ctx, "")
+			if err != nil {
+				return err
+			}
+
+			if job != nil {
+				log.Printf("job %s started in the fast lane", job.ID)
+
+				w.runLocal(ctx, job)
+			}
+		}
+	}
+}
+
+// runLocal runs job, claimed by the local runner, renewing its claim in
+// the queue until it ends.
+func (w *webrunner) runLocal(ctx context.Context, job *web.Job) {
+	renewCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	go func() {
+		ticker := time.NewTicker(web.DefaultLeaseTimeout / 4)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				if err := w.svc.RenewLocal(renewCtx, job.ID); err != nil && renewCtx.Err() == nil {
+					log.Printf("job %s: renewing its claim: %v", job.ID, err)
 				}
 			}
 		}
+	}()
+
+	w.runJob(ctx, job)
+}
+
+// runJob scrapes job and reports the outcome.
+func (w *webrunner) runJob(ctx context.Context, job *web.Job) {
+	t0 := time.Now().UTC()
+	if err := w.scrapeJob(ctx, job); err != nil {
+		params := map[string]any{
+			"job_count": len(job.Data.Keywords),
+			"duration":  time.Now().UTC().Sub(t0).String(),
+			"error":     err.Error(),
+		}
+
+		evt := tlmt.NewEvent("web_runner", params)
+
+		_ = runner.Telemetry().Send(ctx, evt)
+
+		log.Printf("error scraping job %s: %v", job.ID, err)
+	} else {
+		params := map[string]any{
+			"job_count": len(job.Data.Keywords),
+			"duration":  time.Now().UTC().Sub(t0).String(),
+		}
+
+		_ = runner.Telemetry().Send(ctx, tlmt.NewEvent("web_runner", params))
+
+		log.Printf("job %s scraped successfully", job.ID)
+	}
+}
+
+// requeueExpired gives the jobs of the remote workers that went silent to
+// the next worker asking for one.
+func (w *webrunner) requeueExpired(ctx context.Context) error {
+	ticker := time.NewTicker(web.DefaultLeaseTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			n, err := w.svc.RequeueExpired(ctx)
+			if err != nil {
+				log.Printf("requeueing expired jobs: %v", err)
+
+				continue
+			}
+
+			if n > 0 {
+				log.Printf("requeued %d jobs of unresponsive workers", n)
+			}
+		}
+	}
+}
+
+// refreshWatchlists queues the refreshes of the watchlists as they come
+// due.
+func (w *webrunner) refreshWatchlists(ctx context.Context) error {
+	ticker := time.NewTicker(web.WatchlistCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			n, err := w.svc.RefreshWatchlists(ctx)
+			if err != nil {
+				log.Printf("refreshing watchlists: %v", err)
+
+				continue
+			}
+
+			if n > 0 {
+				log.Printf("queued the refresh of %d watchlists", n)
+			}
+		}
 	}
 }
 
 func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
+	// le impostazioni e i file sono quelli del tenant del job
+	ctx = web.WithTenant(ctx, job.Tenant)
+	folder := web.TenantFolder(w.cfg.DataFolder, job.Tenant)
+
 	job.Status = web.StatusWorking
 
-	err := w.svc.Update(ctx, job)
+	err := w.store.Update(ctx, job)
 	if err != nil {
 		return err
 	}
@@ -139,33 +469,59 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 	if len(job.Data.Keywords) == 0 {
 		job.Status = web.StatusFailed
 
-		return w.svc.Update(ctx, job)
+		return w.store.Update(ctx, job)
 	}
 
-	// Crea entrambi i file: CSV e JSON
-	csvPath := filepath.Join(w.cfg.DataFolder, job.ID+".csv")
-	jsonPath := filepath.Join(w.cfg.DataFolder, job.ID+".json")
-
-	csvFile, err := os.Create(csvPath)
-	if err != nil {
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
 		return err
 	}
-	defer csvFile.Close()
 
-	jsonFile, err := os.Create(jsonPath)
+	// Il writer aggiunge i risultati al journal del job man mano che arrivano
+	writer, err := NewDualWriter(folder, job.ID, csvLayout(w.cfg))
 	if err != nil {
-		csvFile.Close()
-		os.Remove(csvPath)
 		return err
 	}
-	defer jsonFile.Close()
+	defer writer.Close()
 
-	// Crea un MultiWriter che scrive su entrambi i file
-	mate, err := w.setupMate(ctx, csvFile, jsonFile, job)
+	// the entry webhook of the job replaces the one of -entry-webhook
+	entryWebhook := job.Data.EntryWebhook
+	if entryWebhook == "" {
+		entryWebhook = w.cfg.EntryWebhook
+	}
+
+	out := runner.EntryStream(w.cfg, writer, entryWebhook, job.ID)
+	if w.hook != nil {
+		out = postprocess.NewWriter(out, w.hook, w.cfg.PostProcessBatch)
+	}
+
+	if w.llm != nil {
+		out = postprocess.NewWriter(out, w.llm, w.cfg.LLMBatch)
+	}
+
+	var enrichers gmaps.Enrichers
+
+	if w.cfg.OSMCheck || job.Data.OSMCheck {
+		enrichers = append(enrichers, w.osm)
+	}
+
+	if w.cfg.RegistrationLookup || job.Data.RegistrationLookup {
+		enrichers = append(enrichers, w.registration)
+	}
+
+	// last, checking what the other enrichers found too
+	if rules := w.validationRules(job); len(rules) > 0 {
+		enrichers = append(enrichers, gmaps.NewValidator(rules, w.dnsCache))
+	}
+
+	if len(enrichers) > 0 {
+		out = postprocess.NewWriter(out, enrichers, runner.EnrichBatchSize)
+	}
+
+	mate, err := w.setupMate(ctx, out, job)
 	if err != nil {
 		job.Status = web.StatusFailed
 
-		err2 := w.svc.Update(ctx, job)
+		err2 := w.store.Update(ctx, job)
 		if err2 != nil {
 			log.Printf("failed to update job status: %v", err2)
 		}
@@ -175,6 +531,11 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 
 	defer mate.Close()
 
+	// salva subito la configurazione con cui gira il job (Stats.Env)
+	if err := w.store.Update(ctx, job); err != nil {
+		log.Printf("job %s: recording its environment: %v", job.ID, err)
+	}
+
 	var coords string
 	if job.Data.Lat != "" && job.Data.Lon != "" {
 		coords = job.Data.Lat + "," + job.Data.Lon
@@ -182,28 +543,158 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 
 	dedup := deduper.New()
 	exitMonitor := exiter.New()
+	// limiti del job, già ridotti dal Claim al budget mensile rimasto
+	exitMonitor.SetMaxPlaces(job.Stats.Usage.MaxPlaces)
+	emailBudget := gmaps.NewFetchBudget(job.Stats.Usage.MaxEmailFetches)
 
-	seedJobs, err := runner.CreateSeedJobs(
-		job.Data.FastMode,
-		job.Data.Lang,
-		strings.NewReader(strings.Join(job.Data.Keywords, "\n")),
-		job.Data.Depth,
-		job.Data.Email,
-		coords,
-		job.Data.Zoom,
-		func() float64 {
-			if job.Data.Radius <= 0 {
-				return 10000 // 10 km
-			}
+	emailProxies, err := w.emailProxyRouter(ctx, job)
+	if err != nil {
+		log.Printf("job %s: ignoring email proxy routes: %v", job.ID, err)
+	}
 
-			return float64(job.Data.Radius)
-		}(),
-		dedup,
-		exitMonitor,
-		w.cfg.ExtraReviews || job.Data.ExtraReviews,
+	emailPacer, err := w.emailPacer(ctx, job)
+	if err != nil {
+		log.Printf("job %s: fetching the websites without delay: %v", job.ID, err)
+	}
+
+	// senza -anonymize-key ogni job ha la sua chiave casuale
+	anonymizer, err := gmaps.NewReviewerAnonymizer(w.anonymizeMode(job), w.cfg.AnonymizeKey)
+	if err != nil {
+		job.Status = web.StatusFailed
+
+		if err2 := w.store.Update(ctx, job); err2 != nil {
+			log.Printf("failed to update job status: %v", err2)
+		}
+
+		return err
+	}
+
+	// le esclusioni del job si aggiungono a quelle dei flag
+	exclusions, err := runner.Exclusions(w.cfg, job.Data.ExcludeDomains, job.Data.ExcludePlaces)
+	if err != nil {
+		job.Status = web.StatusFailed
+
+		if err2 := w.store.Update(ctx, job); err2 != nil {
+			log.Printf("failed to update job status: %v", err2)
+		}
+
+		return err
+	}
+
+	exclusions = exclusions.FilterCategories(gmaps.NewCategoryFilter(job.Data.IncludeCategories, job.Data.ExcludeCategories)).
+		FilterRatings(gmaps.NewRatingFilter(job.Data.MinRating, job.Data.MinReviews, job.Data.MaxReviews)).
+		Require(job.Data.RequiredContacts())
+
+	var emailVerifier *gmaps.EmailVerifier
+	if w.cfg.EmailVerify || job.Data.VerifyEmails {
+		emailVerifier = gmaps.NewEmailVerifier(gmaps.WithEmailVerifierDNSCache(w.dnsCache))
+	}
+
+	fetchStats := gmaps.NewFetchStats()
+	sessions := gmaps.NewSessionPool(w.cfg.Identities)
+	keywordStats := gmaps.NewKeywordStats()
+	placeFailures := gmaps.NewPlaceFailures()
+	diagnoser := gmaps.NewDiagnoser(web.DiagnosisDir(folder, job.ID))
+	stepTimings := gmaps.NewStepTimings()
+	skipStats := gmaps.NewSkipStats()
+	emailProgress := gmaps.NewEmailProgress(w.emailTimings)
+
+	var emailHAR *gmaps.HARCapture
+	if w.cfg.EmailHARDir != "" {
+		emailHAR = gmaps.NewHARCapture(filepath.Join(w.cfg.EmailHARDir, job.ID), w.cfg.EmailHARSample)
+	}
+
+	var throttle *gmaps.Throttle
+	if w.cfg.AdaptiveThrottle {
+		throttle = gmaps.NewThrottle(w.cfg.Concurrency)
+	}
+
+	livePages := w.live.add(job.ID, emailProgress, emailBudget)
+	defer w.live.remove(job.ID)
+
+	seedOpts := []runner.SeedJobOption{
+		runner.WithSeedExcludeServiceArea(w.cfg.ExcludeServiceArea || job.Data.ExcludeServiceArea),
+		runner.WithSeedEmailProxyRouter(emailProxies),
+		runner.WithSeedEmailPacer(emailPacer),
+		runner.WithSeedEmailVerifier(emailVerifier),
+		runner.WithSeedFetchStats(fetchStats),
+		runner.WithSeedSessionPool(sessions),
+		runner.WithSeedJobID(job.ID),
+		runner.WithSeedCustomExtractors(job.Data.CustomExtractors),
+		runner.WithSeedEmailFetchBudget(emailBudget),
+		runner.WithSeedEmailBreaker(gmaps.NewDomainBreaker()),
+		runner.WithSeedEmailHAR(emailHAR),
+		runner.WithSeedReviewerAnonymizer(anonymizer),
+		runner.WithSeedReviewLanguages(w.reviewLangs(job)),
+		runner.WithSeedExclusions(exclusions),
+		runner.WithSeedKeywordStats(keywordStats),
+		runner.WithSeedPlaceFailures(placeFailures),
+		runner.WithSeedDiagnoser(diagnoser),
+		runner.WithSeedLivePages(livePages),
+		runner.WithSeedStepTimings(stepTimings),
+		runner.WithSeedEmailProgress(emailProgress),
+		runner.WithSeedThrottle(throttle),
+		runner.WithSeedSkip(job.Data.Skip()),
+		runner.WithSeedSkipStats(skipStats),
+	}
+
+	var (
+		seedJobs []scrapemate.IJob
+		// a retry of the failures and a job given places, maybe none by
+		// its dependencies, visit place pages instead of searching
+		visitPlaces = job.Stats.Retry != nil || len(job.Data.Places) > 0 || job.Data.InputFrom != ""
+		places      []runner.PlaceURL
 	)
+
+	if retry := job.Stats.Retry; retry != nil {
+		for _, failure := range retry.Places {
+			places = append(places, runner.PlaceURL{URL: failure.URL, Query: failure.Query})
+		}
+	} else {
+		for _, u := range job.Data.Places {
+			places = append(places, runner.PlaceURL{URL: u})
+		}
+	}
+
+	if visitPlaces {
+		// within what is left of the places limit
+		places = places[:exitMonitor.ReservePlaces(len(places))]
+
+		seedJobs = runner.CreatePlaceJobs(
+			job.Data.Lang,
+			places,
+			job.Data.Email,
+			exitMonitor,
+			w.cfg.ExtraReviews || job.Data.ExtraReviews,
+			seedOpts...,
+		)
+	} else {
+		seedJobs, err = runner.CreateSeedJobs(
+			job.Data.FastMode,
+			job.Data.Lang,
+			strings.NewReader(strings.Join(job.Data.Keywords, "\n")),
+			job.Data.Depth,
+			job.Data.Email,
+			coords,
+			job.Data.Zoom,
+			func() float64 {
+				if job.Data.Radius <= 0 {
+					return 10000 // 10 km
+				}
+
+				return float64(job.Data.Radius)
+			}(),
+			dedup,
+			exitMonitor,
+			w.cfg.ExtraReviews || job.Data.ExtraReviews,
+			seedOpts...,
+		)
+	}
+
 	if err != nil {
-		err2 := w.svc.Update(ctx, job)
+		job.Status = web.StatusFailed
+
+		err2 := w.store.Update(ctx, job)
 		if err2 != nil {
 			log.Printf("failed to update job status: %v", err2)
 		}
@@ -211,8 +702,17 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 		return err
 	}
 
+	// il tempo dei browser, fatturato per tenant
+	var (
+		browserTime time.Duration
+		phases      *phaseWatcher
+	)
+
 	if len(seedJobs) > 0 {
-		exitMonitor.SetSeedCount(len(seedJobs))
+		// place pages are no seeds: the run ends once they are done
+		if !visitPlaces {
+			exitMonitor.SetSeedCount(len(seedJobs))
+		}
 
 		allowedSeconds := max(60, len(seedJobs)*10*job.Data.Depth/50+120)
 
@@ -233,12 +733,43 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 
 		go exitMonitor.Run(mateCtx)
 
+		// the phases are followed on this host only, remote workers have no
+		// Maps-only results to serve
+		if job.Data.Email && !job.Data.FastMode && w.live != nil {
+			job.Stats.Phases = web.NewJobPhases(time.Now())
+			phases = w.watchPhases(mateCtx, job, exitMonitor, emailProgress, emailBudget, writer, folder)
+		}
+
+		if reporter, ok := w.store.(progressReporter); ok {
+			go reportProgress(mateCtx, cancel, reporter, job, writer)
+		}
+
+		started := time.Now()
+
 		err = mate.Start(mateCtx, seedJobs...)
+
+		browserTime = time.Since(started)
+
 		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
 			cancel()
 
 			job.Status = web.StatusFailed
-			err2 := w.svc.Update(ctx, job)
+			job.Stats.Phases = phases.end(job.Status)
+			job.Stats.FetchErrors = fetchStats.ErrorReport()
+			job.Stats.Bandwidth = fetchStats.BandwidthReport()
+			job.Stats.Sessions = sessions.Report()
+			job.Stats.Browsers = runner.BrowserStats(mate)
+			job.Stats.Usage.Places = writer.Results()
+			job.Stats.Usage.EmailFetches = emailBudget.Used()
+			job.Stats.Usage.EmailsVerified = emailVerifier.Verified()
+			job.Stats.Usage.BrowserSeconds = browserSeconds(job, browserTime, job.Stats.Browsers.Browsers)
+			job.Stats.Keywords = keywordStats.Report()
+			job.Stats.Failures = placeFailures.Report()
+			job.Stats.Timings = stepTimings.Report()
+			job.Stats.Skip = skipStats.Report()
+			job.Stats.Email = emailReport(job, emailProgress, emailBudget)
+			job.Stats.Throttle = throttle.Report()
+			err2 := w.store.Update(ctx, job)
 			if err2 != nil {
 				log.Printf("failed to update job status: %v", err2)
 			}
@@ -253,27 +784,255 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 	}
 
 	log.Printf("closing scrapemate app for job %s", job.ID)
+	w.live.remove(job.ID)
 	mate.Close()
 
-	// Assicuriamoci che entrambi i file siano stati scritti correttamente
-	if err := csvFile.Sync(); err != nil {
-		log.Printf("error syncing CSV file: %v", err)
-	}
-	if err := jsonFile.Sync(); err != nil {
-		log.Printf("error syncing JSON file: %v", err)
+	// before the final results replace the Maps-only ones
+	job.Stats.Phases = phases.end(web.StatusOK)
+
+	// Assicuriamoci che i file definitivi siano scritti prima di chiudere il job
+	if err := writer.Close(); err != nil {
+		log.Printf("error writing results of job %s: %v", job.ID, err)
 	}
 
 	log.Printf("updating job %s status to OK", job.ID)
 	job.Status = web.StatusOK
-
-	err = w.svc.Update(ctx, job)
+	job.Stats.FetchErrors = fetchStats.ErrorReport()
+	job.Stats.Bandwidth = fetchStats.BandwidthReport()
+	job.Stats.Sessions = sessions.Report()
+	job.Stats.Browsers = runner.BrowserStats(mate)
+	job.Stats.Usage.Places = writer.Results()
+	job.Stats.Usage.EmailFetches = emailBudget.Used()
+	job.Stats.Usage.EmailsVerified = emailVerifier.Verified()
+	job.Stats.Usage.BrowserSeconds = browserSeconds(job, browserTime, job.Stats.Browsers.Browsers)
+	job.Stats.Keywords = keywordStats.Report()
+	job.Stats.Failures = placeFailures.Report()
+	job.Stats.Timings = stepTimings.Report()
+	job.Stats.Skip = skipStats.Report()
+	job.Stats.Email = emailReport(job, emailProgress, emailBudget)
+	job.Stats.Throttle = throttle.Report()
+
+	err = w.store.Update(ctx, job)
 	if err != nil {
 		log.Printf("error updating job %s status: %v", job.ID, err)
 	}
 	return err
 }
 
-func (w *webrunner) setupMate(_ context.Context, csvWriter, jsonWriter io.Writer, job *web.Job) (*scrapemateapp.ScrapemateApp, error) {
+// emailReport returns how the email phase of job went, nil when it searched
+// no email.
+func emailReport(job *web.Job, progress *gmaps.EmailProgress, budget *gmaps.FetchBudget) *gmaps.EmailEstimate {
+	if !job.Data.Email {
+		return nil
+	}
+
+	report := progress.Estimate(1, budget)
+	// nothing is left to run
+	report.RemainingSeconds, report.Workers, report.FetchesExpected = 0, 0, 0
+
+	return &report
+}
+
+// browserSeconds returns the browser time of job, which ran for elapsed:
+// elapsed times the browsers of the pool, or the single browser of
+// scrapemate. The fast mode opens no browser.
+func browserSeconds(job *web.Job, elapsed time.Duration, browsers int) int64 {
+	if job.Data.FastMode {
+		return 0
+	}
+
+	return int64(elapsed.Seconds()) * int64(max(browsers, 1))
+}
+
+// emailProxyRouter returns the email proxy routes of the -email-proxies flag
+// or, when the flag is not set, the ones saved in the settings of job.
+func (w *webrunner) emailProxyRouter(ctx context.Context, job *web.Job) (*gmaps.EmailProxyRouter, error) {
+	if w.cfg.EmailProxies != "" {
+		return runner.EmailProxyRouter(w.cfg.EmailProxies)
+	}
+
+	settings, err := w.store.ProfileSettings(ctx, job.Data.Profile)
+	if err != nil || len(settings.EmailProxies) == 0 {
+		return nil, err
+	}
+
+	return gmaps.NewEmailProxyRouter(settings.EmailProxies)
+}
+
+// emailPacer returns the pacer of the email fetches of a website, spaced by
+// the -email-host-interval flag or, when the flag is not set, by the
+// setting of job.
+func (w *webrunner) emailPacer(ctx context.Context, job *web.Job) (*gmaps.WebsitePacer, error) {
+	if w.cfg.EmailHostInterval != "" {
+		return runner.EmailPacer(w.cfg.EmailHostInterval)
+	}
+
+	settings, err := w.store.ProfileSettings(ctx, job.Data.Profile)
+	if err != nil {
+		return runner.EmailPacer("")
+	}
+
+	return runner.EmailPacer(settings.EmailHostInterval)
+}
+
+// anonymizeMode returns the reviewer anonymization of job, the one of the
+// -anonymize-reviewers flag when the job asks for none.
+func (w *webrunner) anonymizeMode(job *web.Job) string {
+	if job.Data.AnonymizeReviewers != "" {
+		return job.Data.AnonymizeReviewers
+	}
+
+	return w.cfg.AnonymizeReviewers
+}
+
+// reviewLangs returns the review languages of job, those of the
+// -review-langs flag when the job asks for none.
+func (w *webrunner) reviewLangs(job *web.Job) []string {
+	if len(job.Data.ReviewLangs) > 0 {
+		return job.Data.ReviewLangs
+	}
+
+	// già validato all'avvio
+	langs, _ := gmaps.ParseLanguages(w.cfg.ReviewLangs)
+
+	return langs
+}
+
+// validationRules returns the rules run on the places of job, its own or
+// those of -validate.
+func (w *webrunner) validationRules(job *web.Job) []string {
+	if len(job.Data.ValidationRules) > 0 {
+		// già validate alla creazione del job
+		rules, _ := gmaps.ParseValidationRules(strings.Join(job.Data.ValidationRules, ","))
+
+		return rules
+	}
+
+	// già validate all'avvio
+	rules, _ := gmaps.ParseValidationRules(w.cfg.Validate)
+
+	return rules
+}
+
+// recordEnv sets the Env of job to the configuration it runs with, proxies
+// coming from proxySource.
+func (w *webrunner) recordEnv(ctx context.Context, job *web.Job, proxySource string, proxies []string, geo proxypool.Geo) {
+	settings, err := w.store.ProfileSettings(ctx, job.Data.Profile)
+	if err != nil {
+		log.Printf("job %s: reading settings: %v", job.ID, err)
+	}
+
+	if w.cfg.ProxyProvider != "" {
+		settings.ProxyProvider = w.cfg.ProxyProvider
+	}
+
+	if w.cfg.EmailProxies != "" {
+		if routes, err := gmaps.ParseEmailProxyRoutes(w.cfg.EmailProxies); err == nil {
+			settings.EmailProxies = routes
+		}
+	}
+
+	if w.cfg.EmailHostInterval != "" {
+		settings.EmailHostInterval = w.cfg.EmailHostInterval
+	}
+
+	env := web.JobEnv{
+		StartedAt:          time.Now().UTC(),
+		ScraperVersion:     gmaps.ScraperVersion(),
+		SelectorVersion:    gmaps.SelectorVersion,
+		Host:               w.cfg.WorkerID,
+		Profile:            job.Data.Profile,
+		Settings:           settings.Redacted(),
+		ProxySource:        proxySource,
+		ProxyCount:         len(proxies),
+		ProxyHash:          web.ProxyHash(proxies),
+		Concurrency:        w.cfg.Concurrency,
+		Identities:         w.cfg.Identities,
+		PageReuse:          !w.cfg.DisablePageReuse,
+		AdaptiveThrottle:   w.cfg.AdaptiveThrottle,
+		ExtraReviews:       w.cfg.ExtraReviews || job.Data.ExtraReviews,
+		ExcludeServiceArea: w.cfg.ExcludeServiceArea || job.Data.ExcludeServiceArea,
+		VerifyEmails:       w.cfg.EmailVerify || job.Data.VerifyEmails,
+		OSMCheck:           w.cfg.OSMCheck || job.Data.OSMCheck,
+		RegistrationLookup: w.cfg.RegistrationLookup || job.Data.RegistrationLookup,
+		ValidationRules:    w.validationRules(job),
+		EntryWebhook:       w.cfg.EntryWebhook != "" || job.Data.EntryWebhook != "",
+		AnonymizeReviewers: w.anonymizeMode(job),
+		ReviewLangs:        w.reviewLangs(job),
+		SkipReviews:        job.Data.SkipReviews,
+		SkipImages:         job.Data.SkipImages,
+		SkipAbout:          job.Data.SkipAbout,
+	}
+
+	if exclusions, err := runner.Exclusions(w.cfg, job.Data.ExcludeDomains, job.Data.ExcludePlaces); err == nil {
+		env.Exclusions = exclusions.Len()
+	}
+
+	// la location conta solo per i proxy del provider
+	if proxySource == web.ProxySourceProvider && !geo.IsZero() {
+		env.ProxyGeo = geo.String()
+	}
+
+	job.Stats.Env = &env
+}
+
+// providerProxies returns the current proxies of the -proxy-provider flag
+// or, when the flag is not set, of the provider saved in the settings of
+// job, exiting from geo. There is one pool per provider and location: it is
+// created by the first job that needs it and then refreshed in the
+// background until Run returns, whatever the job or the request that
+// created it.
+func (w *webrunner) providerProxies(ctx context.Context, job *web.Job, geo proxypool.Geo) []string {
+	spec := w.cfg.ProxyProvider
+	if spec == "" {
+		settings, err := w.store.ProfileSettings(ctx, job.Data.Profile)
+		if err != nil {
+			return nil
+		}
+
+		spec = settings.ProxyProvider
+	}
+
+	if spec == "" {
+		return nil
+	}
+
+	key := spec + "|" + geo.String()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if pool, ok := w.proxyPools[key]; ok {
+		return pool.Proxies()
+	}
+
+	pool, err := runner.ProxyPool(w.cfg, spec, geo)
+	if err != nil {
+		log.Printf("ignoring proxy provider: %v", err)
+
+		return nil
+	}
+
+	// not kept on failure, so that the next job tries again
+	if err := pool.Refresh(ctx); err != nil {
+		log.Printf("fetching proxies from provider: %v", err)
+
+		return nil
+	}
+
+	w.proxyPools[key] = pool
+
+	runCtx := w.runCtx
+	if runCtx == nil {
+		runCtx = context.Background()
+	}
+
+	go pool.Run(runCtx)
+
+	return pool.Proxies()
+}
+
+func (w *webrunner) setupMate(ctx context.Context, writer scrapemate.ResultWriter, job *web.Job) (runner.App, error) {
 	opts := []func(*scrapemateapp.Config) error{
 		scrapemateapp.WithConcurrency(w.cfg.Concurrency),
 		scrapemateapp.WithExitOnInactivity(time.Minute * 3),
@@ -291,16 +1050,38 @@ func (w *webrunner) setupMate(_ context.Context, csvWriter, jsonWriter io.Writer
 
 	opts = runner.AppendBrowserCapacityOptions(opts, w.cfg)
 
-	hasProxy := false
+	if job.Data.Email && !job.Data.FastMode {
+		opts = runner.AppendEmailLaneOption(opts, w.cfg)
+	}
+
+	geo := job.Data.ProxyGeo()
+	if geo.IsZero() {
+		geo = proxypool.Geo{Country: w.cfg.ProxyCountry, City: w.cfg.ProxyCity}
+	}
+
+	proxySource := web.ProxySourceNone
+
+	var proxies []string
 
 	if len(w.cfg.Proxies) > 0 {
-		opts = append(opts, scrapemateapp.WithProxies(w.cfg.Proxies))
-		hasProxy = true
+		proxySource, proxies = web.ProxySourceFlag, w.cfg.Proxies
 	} else if len(job.Data.Proxies) > 0 {
-		opts = append(opts,
-			scrapemateapp.WithProxies(job.Data.Proxies),
-		)
-		hasProxy = true
+		proxySource, proxies = web.ProxySourceJob, job.Data.Proxies
+	} else if pooled := w.providerProxies(ctx, job, geo); len(pooled) > 0 {
+		proxySource, proxies = web.ProxySourceProvider, pooled
+	}
+
+	hasProxy := len(proxies) > 0
+	if hasProxy {
+		opts = append(opts, scrapemateapp.WithProxies(proxies))
+	}
+
+	w.recordEnv(ctx, job, proxySource, proxies, geo)
+
+	// a fixed proxy list carries no location, only provider endpoints can
+	// be picked by geo
+	if !job.Data.ProxyGeo().IsZero() && (len(w.cfg.Proxies) > 0 || len(job.Data.Proxies) > 0 || !hasProxy) {
+		log.Printf("job %s: proxy location %s ignored, it needs a proxy provider and no explicit proxies", job.ID, geo)
 	}
 
 	if !w.cfg.DisablePageReuse {
@@ -312,10 +1093,7 @@ func (w *webrunner) setupMate(_ context.Context, csvWriter, jsonWriter io.Writer
 
 	log.Printf("job %s has proxy: %v", job.ID, hasProxy)
 
-	// Usa il DualWriter per scrivere su entrambi i formati
-	dualWriter := NewDualWriter(csvWriter, jsonWriter)
-
-	writers := []scrapemate.ResultWriter{dualWriter}
+	writers := []scrapemate.ResultWriter{writer}
 
 	matecfg, err := scrapemateapp.NewConfig(
 		writers,
@@ -325,5 +1103,38 @@ func (w *webrunner) setupMate(_ context.Context, csvWriter, jsonWriter io.Writer
 		return nil, err
 	}
 
-	return scrapemateapp.NewScrapeMateApp(matecfg)
+	app, err := runner.NewApp(matecfg, w.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// reportProgress sends the number of results written for job until ctx ends,
+// and stops the job through cancel when the store gives it away.
+func reportProgress(ctx context.Context, cancel context.CancelFunc, reporter progressReporter, job *web.Job, writer *DualWriter) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := reporter.Progress(ctx, job, writer.Results())
+
+		switch {
+		case errors.Is(err, web.ErrLeaseLost) || errors.Is(err, web.ErrNotFound):
+			log.Printf("job %s: %v, stopping it", job.ID, err)
+
+			cancel()
+
+			return
+		case err != nil:
+			log.Printf("job %s: reporting progress: %v", job.ID, err)
+		}
+	}
 }
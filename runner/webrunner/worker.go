@@ -0,0 +1,193 @@
+package webrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/proxypool"
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+// claimInterval is how long an idle worker waits before asking the
+// coordinator for a job again.
+const claimInterval = 5 * time.Second
+
+// worker scrapes the jobs of a coordinator: it claims them over the worker
+// API, reports their progress and uploads their results. It keeps nothing
+// once a job is done, so workers can be added and removed at will.
+type worker struct {
+	*webrunner
+
+	client *web.WorkerClient
+}
+
+// NewWorker creates a runner working for the coordinator of
+// cfg.CoordinatorURL. cfg.DataFolder only holds the results of the running
+// job until they are uploaded.
+func NewWorker(cfg *runner.Config) (runner.Runner, error) {
+	if cfg.DataFolder == "" {
+		return nil, fmt.Errorf("data folder is required")
+	}
+
+	hook, err := runner.PostProcessHook(cfg.PostProcess)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -post-process: %w", err)
+	}
+
+	if err := os.MkdirAll(cfg.DataFolder, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	client, err := web.NewWorkerClient(cfg.CoordinatorURL, cfg.WorkerToken, cfg.WorkerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ans := worker{
+		webrunner: &webrunner{
+			store:      &remoteStore{client: client, dataFolder: cfg.DataFolder},
+			cfg:        cfg,
+			dnsCache:   gmaps.NewDNSCache(),
+			hook:       hook,
+			proxyPools: make(map[string]*proxypool.Pool),
+		},
+		client: client,
+	}
+
+	return &ans, nil
+}
+
+func (w *worker) Run(ctx context.Context) error {
+	w.setRunContext(ctx)
+
+	log.Printf("worker %s pulling jobs from %s", w.client.WorkerID(), w.cfg.CoordinatorURL)
+
+	for {
+		job, err := w.client.Claim(ctx)
+		if err != nil {
+			log.Printf("claiming a job: %v", err)
+		}
+
+		if job != nil {
+			w.runJob(ctx, job)
+			w.removeResults(job)
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(claimInterval):
+		}
+	}
+}
+
+func (w *worker) removeResults(job *web.Job) {
+	folder := web.TenantFolder(w.cfg.DataFolder, job.Tenant)
+
+	for _, format := range slices.Concat(web.ResultFormats, []string{web.JournalFormat}) {
+		err := os.Remove(filepath.Join(folder, job.ID+"."+format))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("job %s: removing results: %v", job.ID, err)
+		}
+	}
+}
+
+// remoteStore records the jobs of a worker on its coordinator.
+type remoteStore struct {
+	client     *web.WorkerClient
+	dataFolder string
+}
+
+// Update renews the lease of a running job and, once the job is over,
+// uploads its results before reporting its status.
+func (s *remoteStore) Update(ctx context.Context, job *web.Job) error {
+	if job.Status == web.StatusWorking || job.Status == web.StatusPending {
+		return s.client.Heartbeat(ctx, job.ID, 0)
+	}
+
+	for _, format := range web.ResultFormats {
+		if err := s.upload(ctx, job, format); err != nil {
+			return fmt.Errorf("uploading %s results: %w", format, err)
+		}
+	}
+
+	if err := s.uploadDiagnoses(ctx, job); err != nil {
+		return fmt.Errorf("uploading diagnoses: %w", err)
+	}
+
+	return s.client.Complete(ctx, job.ID, job.Status, job.Stats)
+}
+
+// uploadDiagnoses sends the files of the diagnoses of the keywords of job,
+// which are then removed from the worker.
+func (s *remoteStore) uploadDiagnoses(ctx context.Context, job *web.Job) error {
+	dir := web.DiagnosisDir(web.TenantFolder(s.dataFolder, job.Tenant), job.ID)
+
+	for _, k := range job.Stats.Keywords {
+		if k.Diagnosis == nil {
+			continue
+		}
+
+		for _, name := range []string{k.Diagnosis.Screenshot, k.Diagnosis.HTML} {
+			if name == "" {
+				continue
+			}
+
+			if err := s.uploadDiagnosis(ctx, job.ID, dir, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (s *remoteStore) uploadDiagnosis(ctx context.Context, id, dir, name string) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		// sent by an earlier attempt
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return s.client.UploadDiagnosis(ctx, id, name, f)
+}
+
+func (s *remoteStore) upload(ctx context.Context, job *web.Job, format string) error {
+	f, err := os.Open(filepath.Join(web.TenantFolder(s.dataFolder, job.Tenant), job.ID+"."+format))
+	if errors.Is(err, os.ErrNotExist) {
+		// the job failed before writing anything
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return s.client.UploadResults(ctx, job.ID, format, f)
+}
+
+func (s *remoteStore) ProfileSettings(ctx context.Context, name string) (web.Settings, error) {
+	return s.client.ProfileSettings(ctx, name)
+}
+
+func (s *remoteStore) Progress(ctx context.Context, job *web.Job, results int) error {
+	return s.client.Heartbeat(ctx, job.ID, results)
+}
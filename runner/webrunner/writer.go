@@ -2,137 +2,195 @@ package webrunner
 
 import (
 	"context"
-	"encoding/csv"
 	"encoding/json"
 	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
 	"github.com/gosom/scrapemate"
-	"github.com/gosom/scrapemate/adapters/writers/csvwriter"
 )
 
-// DualWriter scrive sia in CSV che in JSON
+// snapshotInterval è ogni quanto il CSV di un job in corso viene riscritto
+const snapshotInterval = 30 * time.Second
+
+// DualWriter scrive i risultati di un job sia in CSV che in JSON. Ogni luogo
+// viene aggiunto subito al journal NDJSON del job, così un crash non perde
+// quanto già raccolto; il CSV viene riscritto periodicamente durante il job
+// e il JSON alla fine, sempre con un rename atomico.
 type DualWriter struct {
-	csvWriter  scrapemate.ResultWriter
-	jsonWriter *JSONWriter
+	dataFolder string
+	id         string
+	// csvLayout sceglie le colonne del CSV
+	csvLayout web.CSVLayout
+
+	mu      sync.Mutex
+	journal *os.File
+	encoder *json.Encoder
+	entries []*gmaps.Entry
+	// snapshot è il numero di luoghi nell'ultimo CSV scritto
+	snapshot int
+	closed   bool
+
+	// results conta i luoghi ricevuti finora
+	results atomic.Int64
 }
 
-// NewDualWriter crea un writer che scrive sia CSV che JSON
-func NewDualWriter(csvW io.Writer, jsonW io.Writer) *DualWriter {
-	return &DualWriter{
-		csvWriter:  csvwriter.NewCsvWriter(csv.NewWriter(csvW)),
-		jsonWriter: NewJSONWriter(jsonW),
+// NewDualWriter crea un writer per i risultati del job id in dataFolder
+func NewDualWriter(dataFolder, id string, csvLayout web.CSVLayout) (*DualWriter, error) {
+	path := filepath.Join(dataFolder, id+"."+web.JournalFormat)
+
+	journal, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
 	}
+
+	ans := DualWriter{
+		dataFolder: dataFolder,
+		id:         id,
+		csvLayout:  csvLayout,
+		journal:    journal,
+		encoder:    json.NewEncoder(journal),
+	}
+
+	return &ans, nil
 }
 
 // Run implementa l'interfaccia ResultWriter
 func (d *DualWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
-	// Creiamo due canali per dividere i risultati
-	csvChan := make(chan scrapemate.Result, 100)
-	jsonChan := make(chan scrapemate.Result, 100)
-
-	// WaitGroup per aspettare che entrambi i writer finiscano
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	// Variabili per catturare eventuali errori
-	var csvErr, jsonErr error
-
-	// Avvia il CSV writer
-	go func() {
-		defer wg.Done()
-		csvErr = d.csvWriter.Run(ctx, csvChan)
-	}()
-
-	// Avvia il JSON writer
-	go func() {
-		defer wg.Done()
-		jsonErr = d.jsonWriter.Run(ctx, jsonChan)
-	}()
-
-	// Distribuisci i risultati a entrambi i writer
-	go func() {
-		defer close(csvChan)
-		defer close(jsonChan)
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case result, ok := <-in:
-				if !ok {
-					return
-				}
-
-				// Invia a entrambi i canali
-				select {
-				case csvChan <- result:
-				case <-ctx.Done():
-					return
-				}
-
-				select {
-				case jsonChan <- result:
-				case <-ctx.Done():
-					return
-				}
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return d.Close()
+		case <-ticker.C:
+			if err := d.writeSnapshot(); err != nil {
+				log.Printf("job %s: writing csv snapshot: %v", d.id, err)
+			}
+		case result, ok := <-in:
+			if !ok {
+				return d.Close()
+			}
+
+			if err := d.append(result.Data); err != nil {
+				return err
 			}
 		}
-	}()
+	}
+}
 
-	// Aspetta che entrambi i writer finiscano
-	wg.Wait()
+// append aggiunge al journal i luoghi di un risultato
+func (d *DualWriter) append(data any) error {
+	entries := collectEntries([]any{data})
+	if len(entries) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	// Restituisci il primo errore se presente
-	if csvErr != nil {
-		return csvErr
+	if d.closed {
+		return nil
 	}
-	return jsonErr
+
+	for _, e := range entries {
+		if err := d.encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	d.entries = append(d.entries, entries...)
+	d.results.Add(int64(len(entries)))
+
+	return nil
 }
 
-// JSONWriter implementa un writer per JSON
-type JSONWriter struct {
-	mu      sync.Mutex
-	results []interface{}
-	writer  io.Writer
-	closed  chan struct{}
+// writeSnapshot riscrive il CSV con i luoghi ricevuti finora, se ce ne sono
+// di nuovi, e sincronizza il journal su disco
+func (d *DualWriter) writeSnapshot() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed || len(d.entries) == d.snapshot {
+		return nil
+	}
+
+	if err := d.journal.Sync(); err != nil {
+		return err
+	}
+
+	entries := d.entries
+
+	err := web.WriteFileAtomic(filepath.Join(d.dataFolder, d.id+".csv"), func(w io.Writer) error {
+		return web.WriteCSV(w, entries, d.csvLayout)
+	})
+	if err != nil {
+		return err
+	}
+
+	d.snapshot = len(entries)
+
+	return nil
 }
 
-// NewJSONWriter crea un nuovo JSONWriter
-func NewJSONWriter(w io.Writer) *JSONWriter {
-	return &JSONWriter{
-		results: make([]interface{}, 0),
-		writer:  w,
-		closed:  make(chan struct{}),
+// Close scrive i file CSV e JSON definitivi e rimuove il journal. Le
+// chiamate successive non fanno nulla.
+func (d *DualWriter) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
 	}
+
+	d.closed = true
+
+	if err := d.journal.Close(); err != nil {
+		return err
+	}
+
+	return web.WriteResults(d.dataFolder, d.id, d.entries, d.csvLayout)
 }
 
-// Run implementa l'interfaccia ResultWriter
-func (j *JSONWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
-	defer close(j.closed)
+// Results restituisce il numero di luoghi ricevuti finora
+func (d *DualWriter) Results() int {
+	return int(d.results.Load())
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return j.Flush()
-		case result, ok := <-in:
-			if !ok {
-				return j.Flush()
-			}
+// Entries restituisce copie dei luoghi ricevuti finora, che si possono
+// modificare senza toccare quelli del writer
+func (d *DualWriter) Entries() []*gmaps.Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-			j.mu.Lock()
-			j.results = append(j.results, result.Data)
-			j.mu.Unlock()
-		}
+	ans := make([]*gmaps.Entry, len(d.entries))
+
+	for i, e := range d.entries {
+		entry := *e
+		ans[i] = &entry
 	}
+
+	return ans
 }
 
-// Flush scrive tutti i risultati nel writer come array JSON
-func (j *JSONWriter) Flush() error {
-	j.mu.Lock()
-	defer j.mu.Unlock()
+func collectEntries(results []any) []*gmaps.Entry {
+	var entries []*gmaps.Entry
+
+	for _, r := range results {
+		switch v := r.(type) {
+		case *gmaps.Entry:
+			entries = append(entries, v)
+		case []*gmaps.Entry:
+			entries = append(entries, v...)
+		}
+	}
 
-	encoder := json.NewEncoder(j.writer)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(j.results)
+	return entries
 }
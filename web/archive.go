@@ -0,0 +1,369 @@
+package web
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	archiveVersion  = 1
+	archiveManifest = "manifest.json"
+	archiveResults  = "results/"
+)
+
+// archiveContents is the manifest of an export archive.
+type archiveContents struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Settings   *Settings `json:"settings,omitempty"`
+	// Profiles holds the settings profiles by name.
+	Profiles map[string]Settings `json:"profiles,omitempty"`
+	// Suppressions holds the values of the suppression lists by name.
+	Suppressions map[string][]string `json:"suppressions,omitempty"`
+	Jobs         []Job               `json:"jobs"`
+}
+
+// ImportOptions tune Service.Import.
+type ImportOptions struct {
+	// NewIDs gives the imported jobs new IDs instead of keeping theirs, so
+	// that an archive can be imported next to the jobs it came from.
+	NewIDs bool
+	// Settings replaces the settings, the profiles and the suppression
+	// lists with the ones of the archive.
+	Settings bool
+}
+
+// ImportReport tells what Service.Import did.
+type ImportReport struct {
+	Imported int `json:"imported"`
+	// IDs maps the ID of every imported job in the archive to its ID here.
+	IDs map[string]string `json:"ids"`
+	// Skipped lists the jobs left out because their ID already exists.
+	Skipped []string `json:"skipped"`
+	Files   int      `json:"files"`
+}
+
+// Export writes every job, the settings, the profiles, the suppression lists
+// and, when withResults is set, the result files to w as a gzipped tar
+// archive for Import. The archive holds the settings as they are, proxy
+// credentials included.
+func (s *Service) Export(ctx context.Context, w io.Writer, withResults bool) error {
+	jobs, err := s.All(ctx)
+	if err != nil {
+		return err
+	}
+
+	contents := archiveContents{
+		Version:    archiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Jobs:       jobs,
+	}
+
+	if repo, ok := s.repo.(SettingsRepository); ok {
+		settings, err := repo.GetSettings(ctx)
+		if err == nil {
+			contents.Settings = &settings
+		}
+	}
+
+	if repo, ok := s.repo.(ProfileRepository); ok {
+		names, err := repo.ListProfiles(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			settings, err := repo.GetProfile(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			if contents.Profiles == nil {
+				contents.Profiles = map[string]Settings{}
+			}
+
+			contents.Profiles[name] = settings
+		}
+	}
+
+	if repo, ok := s.repo.(SuppressionRepository); ok {
+		lists, err := repo.ListSuppressionLists(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range lists {
+			values, err := repo.GetSuppressions(ctx, l.Name)
+			if err != nil {
+				return err
+			}
+
+			if contents.Suppressions == nil {
+				contents.Suppressions = map[string][]string{}
+			}
+
+			contents.Suppressions[l.Name] = values
+		}
+	}
+
+	manifest, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    archiveManifest,
+		Mode:    0o644,
+		Size:    int64(len(manifest)),
+		ModTime: contents.ExportedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	if withResults {
+		for i := range jobs {
+			for _, format := range ResultFormats {
+				if err := addArchiveFile(tw, filepath.Join(s.dataFolder, jobs[i].ID+"."+format)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// addArchiveFile adds the file at p under results/. A missing file is not an
+// error: not every job has results.
+func addArchiveFile(tw *tar.Writer, p string) error {
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    archiveResults + filepath.Base(p),
+		Mode:    0o644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, io.NewSectionReader(f, 0, info.Size()))
+
+	return err
+}
+
+// Import reads an archive written by Export. The jobs keep their ID unless
+// opts.NewIDs is set; those whose ID already exists are skipped. The jobs
+// that were running when exported are imported as failed.
+func (s *Service) Import(ctx context.Context, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	report := ImportReport{IDs: map[string]string{}, Skipped: []string{}}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return report, fmt.Errorf("invalid archive: %w", err)
+	}
+
+	tr := tar.NewReader(zr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != archiveManifest {
+		return report, errors.New("invalid archive: it must start with " + archiveManifest)
+	}
+
+	var contents archiveContents
+
+	if err := json.NewDecoder(tr).Decode(&contents); err != nil {
+		return report, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+
+	if contents.Version != archiveVersion {
+		return report, fmt.Errorf("unsupported archive version %d", contents.Version)
+	}
+
+	for i := range contents.Jobs {
+		if err := contents.Jobs[i].Validate(); err != nil {
+			return report, fmt.Errorf("job %s: %w", contents.Jobs[i].ID, err)
+		}
+
+		if _, err := uuid.Parse(contents.Jobs[i].ID); err != nil {
+			return report, fmt.Errorf("job %s: invalid id", contents.Jobs[i].ID)
+		}
+	}
+
+	if opts.Settings && contents.Settings != nil {
+		if err := s.SaveSettings(ctx, contents.Settings); err != nil {
+			return report, err
+		}
+	}
+
+	if opts.Settings {
+		for name, settings := range contents.Profiles {
+			if err := s.SaveProfile(ctx, name, &settings); err != nil {
+				return report, fmt.Errorf("profile %s: %w", name, err)
+			}
+		}
+
+		for name, values := range contents.Suppressions {
+			if _, err := s.addSuppressions(ctx, name, values, 0, true); err != nil {
+				return report, fmt.Errorf("suppression list %s: %w", name, err)
+			}
+		}
+	}
+
+	for i := range contents.Jobs {
+		job := contents.Jobs[i]
+		oldID := job.ID
+
+		if opts.NewIDs {
+			job.ID = uuid.New().String()
+		} else if _, err := s.repo.Get(ctx, job.ID); err == nil {
+			report.Skipped = append(report.Skipped, job.ID)
+
+			continue
+		}
+
+		if job.Status == StatusWorking {
+			job.Status = StatusFailed
+		}
+
+		job.Stats.Worker = nil
+
+		// a job created with the same idempotency key is already there
+		err := s.Create(ctx, &job)
+		if errors.Is(err, ErrAlreadyExists) {
+			report.Skipped = append(report.Skipped, oldID)
+
+			continue
+		}
+
+		if err != nil {
+			return report, fmt.Errorf("job %s: %w", oldID, err)
+		}
+
+		report.IDs[oldID] = job.ID
+		report.Imported++
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+
+		if err != nil {
+			return report, fmt.Errorf("invalid archive: %w", err)
+		}
+
+		id, format, ok := archiveResultName(hdr.Name)
+		if !ok || report.IDs[id] == "" {
+			continue
+		}
+
+		dst := filepath.Join(s.dataFolder, report.IDs[id]+"."+format)
+
+		err = WriteFileAtomic(dst, func(w io.Writer) error {
+			_, err := io.Copy(w, tr)
+
+			return err
+		})
+		if err != nil {
+			return report, err
+		}
+
+		report.Files++
+	}
+}
+
+// archiveResultName parses the name of a result file of an archive,
+// results/<job id>.<format>.
+func archiveResultName(name string) (id, format string, ok bool) {
+	base, found := strings.CutPrefix(name, archiveResults)
+	if !found || path.Base(base) != base {
+		return "", "", false
+	}
+
+	id, format, found = strings.Cut(base, ".")
+	if !found || !slices.Contains(ResultFormats, format) {
+		return "", "", false
+	}
+
+	return id, format, true
+}
+
+// apiExport streams the export archive of the instance. With results=true
+// it holds the result files too.
+func (s *Server) apiExport(w http.ResponseWriter, r *http.Request) {
+	name := "gmaps-export-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz"
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", name))
+
+	// the archive is streamed, a failure past the first bytes keeps the 200
+	if err := s.svc.Export(r.Context(), w, r.URL.Query().Get("results") == "true"); err != nil {
+		log.Printf("export failed: %v", err)
+	}
+}
+
+// apiImport imports the archive in the request body. ids=new gives the jobs
+// new IDs and settings=true replaces the settings, the profiles and the
+// suppression lists with the archived ones.
+func (s *Server) apiImport(w http.ResponseWriter, r *http.Request) {
+	opts := ImportOptions{
+		NewIDs:   r.URL.Query().Get("ids") == "new",
+		Settings: r.URL.Query().Get("settings") == "true",
+	}
+
+	report, err := s.svc.Import(r.Context(), r.Body, opts)
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,422 @@
+package web
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A backup is a gzipped tar archive of a consistent snapshot of the
+// database and of the files of the data folder, each with its checksum in
+// the manifest written last. Unlike an export archive it restores the
+// instance as it was, and only with RestoreBackup, the server stopped.
+
+const (
+	backupVersion  = 1
+	backupManifest = "backup.json"
+	backupDatabase = "database.db"
+	backupFiles    = "files/"
+)
+
+// ErrBackupUnsupported is returned when backing up a repository that cannot
+// snapshot its database.
+var ErrBackupUnsupported = errors.New("the jobs repository cannot be backed up")
+
+// BackupRepository is implemented by the repositories that can copy their
+// database while it is in use.
+type BackupRepository interface {
+	// Snapshot writes a consistent copy of the database to path, which must
+	// not exist.
+	Snapshot(ctx context.Context, path string) error
+}
+
+// BackupManifest lists the files of a backup.
+type BackupManifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []BackupFile `json:"files"`
+}
+
+// BackupFile is a file of a backup, the database or one of the data folder.
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// RestoreOptions tune RestoreBackup.
+type RestoreOptions struct {
+	// Database names the database file in the data folder.
+	Database string
+	// CheckDatabase checks the restored database, when set, before it
+	// replaces the data folder.
+	CheckDatabase func(path string) error
+}
+
+// RestoreReport tells what RestoreBackup did.
+type RestoreReport struct {
+	CreatedAt time.Time `json:"created_at"`
+	Files     int       `json:"files"`
+	// Previous is where the replaced data folder was moved, empty when there
+	// was none.
+	Previous string `json:"previous,omitempty"`
+}
+
+// Backup writes a backup of the instance to w. The database is snapshotted
+// while no job changes state; the result files are replaced atomically by
+// the jobs, so each is copied whole, and a file still being appended to is
+// copied as far as it was written when the backup reached it.
+func (s *Service) Backup(ctx context.Context, w io.Writer) (BackupManifest, error) {
+	manifest := BackupManifest{
+		Version:   backupVersion,
+		CreatedAt: time.Now().UTC(),
+		Files:     []BackupFile{},
+	}
+
+	repo, ok := s.repo.(BackupRepository)
+	if !ok {
+		return manifest, ErrBackupUnsupported
+	}
+
+	tmp, err := os.MkdirTemp("", "gmaps-backup-*")
+	if err != nil {
+		return manifest, err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	snapshot := filepath.Join(tmp, backupDatabase)
+
+	s.mu.Lock()
+	err = repo.Snapshot(ctx, snapshot)
+	s.mu.Unlock()
+
+	if err != nil {
+		return manifest, fmt.Errorf("snapshot of the database: %w", err)
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	f, err := addBackupFile(tw, backupDatabase, snapshot)
+	if err != nil {
+		return manifest, err
+	}
+
+	manifest.Files = append(manifest.Files, f)
+
+	err = filepath.WalkDir(s.dataFolder, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() || skipBackupFile(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dataFolder, p)
+		if err != nil {
+			return err
+		}
+
+		f, err := addBackupFile(tw, backupFiles+filepath.ToSlash(rel), p)
+
+		// deleted since the walk reached its folder
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, f)
+
+		return ctx.Err()
+	})
+	if err != nil {
+		return manifest, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    backupManifest,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	})
+	if err != nil {
+		return manifest, err
+	}
+
+	if _, err := tw.Write(data); err != nil {
+		return manifest, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+
+	return manifest, zw.Close()
+}
+
+// skipBackupFile tells whether the file name of the data folder stays out of
+// the backups: the live database, which the snapshot replaces, and the
+// files being written by WriteFileAtomic.
+func skipBackupFile(name string) bool {
+	for _, suffix := range []string{".db", ".db-wal", ".db-shm", ".db-journal", ".tmp"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addBackupFile adds the file at p as name, as long as it was when opened.
+func addBackupFile(tw *tar.Writer, name, p string) (BackupFile, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	h := sha256.New()
+
+	if _, err := io.Copy(io.MultiWriter(tw, h), io.NewSectionReader(f, 0, info.Size())); err != nil {
+		return BackupFile{}, err
+	}
+
+	return BackupFile{Name: name, Size: info.Size(), SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// RestoreBackup replaces the data folder with the backup read from r. The
+// backup is extracted next to it and checked against its manifest first:
+// a truncated or corrupted backup leaves the data folder untouched. The
+// replaced data folder is kept, renamed.
+func RestoreBackup(r io.Reader, folder string, opts RestoreOptions) (RestoreReport, error) {
+	var report RestoreReport
+
+	folder = filepath.Clean(folder)
+	staging := folder + ".restore"
+
+	if err := os.RemoveAll(staging); err != nil {
+		return report, err
+	}
+
+	manifest, err := extractBackup(r, staging, opts.Database)
+	if err == nil && opts.CheckDatabase != nil {
+		if err = opts.CheckDatabase(filepath.Join(staging, opts.Database)); err != nil {
+			err = fmt.Errorf("invalid backup: database: %w", err)
+		}
+	}
+
+	if err != nil {
+		_ = os.RemoveAll(staging)
+
+		return report, err
+	}
+
+	report.CreatedAt = manifest.CreatedAt
+	report.Files = len(manifest.Files)
+
+	if _, err := os.Stat(folder); err == nil {
+		report.Previous = folder + ".before-restore-" + time.Now().UTC().Format("20060102-150405")
+
+		if err := os.Rename(folder, report.Previous); err != nil {
+			_ = os.RemoveAll(staging)
+
+			return report, err
+		}
+	}
+
+	return report, os.Rename(staging, folder)
+}
+
+// extractBackup extracts the backup read from r to folder, the database as
+// database, and checks every file against the manifest.
+func extractBackup(r io.Reader, folder, database string) (BackupManifest, error) {
+	var manifest BackupManifest
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	tr := tar.NewReader(zr)
+
+	extracted := map[string]BackupFile{}
+	found := false
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return manifest, fmt.Errorf("invalid backup: %w", err)
+		}
+
+		if found {
+			return manifest, fmt.Errorf("invalid backup: %s after %s", hdr.Name, backupManifest)
+		}
+
+		if hdr.Name == backupManifest {
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return manifest, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+
+			found = true
+
+			continue
+		}
+
+		dst, ok := backupTarget(hdr.Name, folder, database)
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			return manifest, fmt.Errorf("invalid backup: unexpected %s", hdr.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return manifest, err
+		}
+
+		f, err := extractBackupFile(tr, dst)
+		if err != nil {
+			return manifest, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+
+		f.Name = hdr.Name
+		extracted[hdr.Name] = f
+	}
+
+	// without its manifest the backup was cut short
+	if !found {
+		return manifest, fmt.Errorf("invalid backup: no %s, the archive is truncated", backupManifest)
+	}
+
+	if manifest.Version != backupVersion {
+		return manifest, fmt.Errorf("unsupported backup version %d", manifest.Version)
+	}
+
+	for _, want := range manifest.Files {
+		got, ok := extracted[want.Name]
+
+		switch {
+		case !ok:
+			return manifest, fmt.Errorf("invalid backup: %s is missing", want.Name)
+		case got != want:
+			return manifest, fmt.Errorf("invalid backup: %s does not match its checksum", want.Name)
+		}
+
+		delete(extracted, want.Name)
+	}
+
+	for name := range extracted {
+		return manifest, fmt.Errorf("invalid backup: %s is not in the manifest", name)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, database)); err != nil {
+		return manifest, fmt.Errorf("invalid backup: no %s", backupDatabase)
+	}
+
+	return manifest, nil
+}
+
+// backupTarget returns where the file name of a backup goes in folder.
+func backupTarget(name, folder, database string) (string, bool) {
+	if name == backupDatabase {
+		return filepath.Join(folder, database), true
+	}
+
+	rel, ok := strings.CutPrefix(name, backupFiles)
+	if !ok || rel == "" || path.Clean(rel) != rel || !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", false
+	}
+
+	// the live database is not backed up from the data folder
+	if skipBackupFile(path.Base(rel)) {
+		return "", false
+	}
+
+	return filepath.Join(folder, filepath.FromSlash(rel)), true
+}
+
+func extractBackupFile(r io.Reader, dst string) (BackupFile, error) {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return BackupFile{}, err
+	}
+
+	h := sha256.New()
+
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		_ = f.Close()
+
+		return BackupFile{}, err
+	}
+
+	if err := f.Close(); err != nil {
+		return BackupFile{}, err
+	}
+
+	return BackupFile{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// apiBackup streams a backup of the instance.
+func (s *Server) apiBackup(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.svc.repo.(BackupRepository); !ok {
+		renderJSON(w, http.StatusNotImplemented, apiError{
+			Code:    http.StatusNotImplemented,
+			Message: ErrBackupUnsupported.Error(),
+		})
+
+		return
+	}
+
+	name := "gmaps-backup-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz"
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+
+	// the archive is streamed, a failure past the first bytes keeps the 200:
+	// the backup then has no manifest and does not restore
+	if _, err := s.svc.Backup(r.Context(), w); err != nil {
+		log.Printf("backup failed: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package web_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+// tarGz returns a gzipped tar of hdrs, the regular files holding "data".
+func tarGz(t *testing.T, hdrs ...tar.Header) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+
+	for i := range hdrs {
+		hdr := hdrs[i]
+		hdr.Mode = 0o644
+
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len("data"))
+		}
+
+		require.NoError(t, tw.WriteHeader(&hdr))
+
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte("data"))
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	return &buf
+}
+
+func TestRestoreBackup(t *testing.T) {
+	ctx := context.Background()
+	svc, folder := newTestService(t)
+
+	job := endJob(ctx, t, svc, folder, web.JobData{Keywords: []string{"cafe"}})
+
+	var backup bytes.Buffer
+
+	_, err := svc.Backup(ctx, &backup)
+	require.NoError(t, err)
+
+	dst := filepath.Join(t.TempDir(), "data")
+
+	report, err := web.RestoreBackup(&backup, dst, web.RestoreOptions{Database: "jobs.db"})
+	require.NoError(t, err)
+	require.Positive(t, report.Files)
+	require.FileExists(t, filepath.Join(dst, "jobs.db"))
+	require.FileExists(t, filepath.Join(dst, job.ID+".json"))
+}
+
+func TestRestoreBackupRefusesUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  tar.Header
+	}{
+		{"parent of the files", tar.Header{Name: "files/../x", Typeflag: tar.TypeReg}},
+		{"parent deeper in the path", tar.Header{Name: "files/a/../../x", Typeflag: tar.TypeReg}},
+		{"absolute", tar.Header{Name: "/tmp/x", Typeflag: tar.TypeReg}},
+		{"outside of the files", tar.Header{Name: "x", Typeflag: tar.TypeReg}},
+		{"not clean", tar.Header{Name: "files/./x", Typeflag: tar.TypeReg}},
+		{"live database", tar.Header{Name: "files/jobs.db", Typeflag: tar.TypeReg}},
+		{"symlink", tar.Header{Name: "files/x", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+		{"hard link", tar.Header{Name: "files/x", Typeflag: tar.TypeLink, Linkname: "database.db"}},
+		{"directory", tar.Header{Name: "files/x/", Typeflag: tar.TypeDir}},
+		{"fifo", tar.Header{Name: "files/x", Typeflag: tar.TypeFifo}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "data")
+
+			require.NoError(t, os.MkdirAll(dst, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dst, "jobs.db"), []byte("live"), 0o600))
+
+			archive := tarGz(t, tar.Header{Name: "database.db", Typeflag: tar.TypeReg}, tt.hdr)
+
+			_, err := web.RestoreBackup(archive, dst, web.RestoreOptions{Database: "jobs.db"})
+			require.ErrorContains(t, err, "invalid backup: unexpected")
+
+			// the data folder is left as it was, and nothing is written
+			// out of the staging folder, which is removed
+			live, err := os.ReadFile(filepath.Join(dst, "jobs.db"))
+			require.NoError(t, err)
+			require.Equal(t, "live", string(live))
+
+			entries, err := os.ReadDir(parent)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
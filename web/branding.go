@@ -0,0 +1,150 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultProductName names the Web UI without branding.
+const defaultProductName = "Google Maps Scraper"
+
+// brandingTemplate holds the parts of the pages that follow the branding,
+// parsed along with every page.
+const brandingTemplate = "static/templates/branding.html"
+
+var brandColorRe = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Branding white-labels the Web UI: the product name in the titles and the
+// header, the logo and the main colors. The zero value is the stock UI.
+type Branding struct {
+	ProductName string `json:"product_name,omitempty"`
+	// LogoURL is a path on this server, like a file of the -ui-dir served
+	// under /branding/, or a data:image URI.
+	LogoURL string `json:"logo_url,omitempty"`
+	// PrimaryColor is the color of the buttons and the links,
+	// BackgroundColor the one of the pages, both as #rgb or #rrggbb.
+	PrimaryColor    string `json:"primary_color,omitempty"`
+	BackgroundColor string `json:"background_color,omitempty"`
+}
+
+// Name returns the product name shown by the pages.
+func (b Branding) Name() string {
+	if b.ProductName == "" {
+		return defaultProductName
+	}
+
+	return b.ProductName
+}
+
+// Logo returns LogoURL for the templates, which would otherwise reject a
+// data URI.
+func (b Branding) Logo() template.URL {
+	return template.URL(b.LogoURL) //nolint:gosec // checked by Validate
+}
+
+// Validate checks that the branding can be rendered safely.
+func (b *Branding) Validate() error {
+	if len(b.ProductName) > 60 {
+		return errors.New("product name cannot be longer than 60 characters")
+	}
+
+	switch {
+	case b.LogoURL == "":
+	case strings.HasPrefix(b.LogoURL, "data:image/"):
+	case strings.HasPrefix(b.LogoURL, "/") && !strings.HasPrefix(b.LogoURL, "//"):
+	default:
+		return errors.New("logo URL must be a path on this server, like /branding/logo.png, or a data:image URI")
+	}
+
+	for name, color := range map[string]string{"primary": b.PrimaryColor, "background": b.BackgroundColor} {
+		if color != "" && !brandColorRe.MatchString(color) {
+			return fmt.Errorf("invalid %s color %q: use #rgb or #rrggbb", name, color)
+		}
+	}
+
+	return nil
+}
+
+// WithUIDir customizes the Web UI from dir: the pages in dir/templates
+// replace the built-in templates of the same name, the catalogs in dir/i18n
+// amend or add languages, and dir/static is served under /branding/, for the
+// logo for instance.
+func WithUIDir(dir string) ServerOption {
+	return func(s *Server) {
+		s.uiDir = dir
+	}
+}
+
+// parseTemplate parses the page key with its includes and the branding and
+// the languages templates, each taken from the -ui-dir when it has a file of
+// the same name.
+func (s *Server) parseTemplate(key string) (*template.Template, error) {
+	var tmpl *template.Template
+
+	names := append([]string{key}, pageIncludes[key]...)
+	names = append(names, brandingTemplate, languagesTemplate)
+
+	for _, name := range names {
+		src, err := s.templateSource(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if tmpl == nil {
+			// the functions of the translators, bound when rendering
+			tmpl = template.New(path.Base(name)).Funcs(Translator{}.funcs())
+		} else {
+			tmpl = tmpl.New(path.Base(name))
+		}
+
+		if _, err := tmpl.Parse(string(src)); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+	}
+
+	return tmpl.Lookup(path.Base(key)), nil
+}
+
+func (s *Server) templateSource(name string) ([]byte, error) {
+	if s.uiDir != "" {
+		src, err := os.ReadFile(filepath.Join(s.uiDir, "templates", path.Base(name)))
+
+		switch {
+		case err == nil:
+			return src, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	return fs.ReadFile(static, name)
+}
+
+// brandingHandler serves the files of dir/static, 404 without -ui-dir.
+func (s *Server) brandingHandler() http.Handler {
+	if s.uiDir == "" {
+		return http.NotFoundHandler()
+	}
+
+	return http.StripPrefix("/branding/", http.FileServer(http.Dir(filepath.Join(s.uiDir, "static"))))
+}
+
+// brand returns the branding of the pages, that of the default settings.
+func (s *Server) brand(ctx context.Context) Branding {
+	settings, err := s.svc.GetSettings(ctx)
+	if err != nil {
+		log.Printf("reading branding: %v", err)
+	}
+
+	return settings.Branding
+}
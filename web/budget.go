@@ -0,0 +1,95 @@
+package web
+
+import (
+	"context"
+	"time"
+)
+
+// Budgets exhausted by a job, in JobUsage.Exceeded.
+const (
+	BudgetPlaces       = "places"
+	BudgetEmailFetches = "email_fetches"
+)
+
+// monthlyUsage sums what the jobs of tenant created in the month of now
+// consumed. The usage of a job is only known once it ends, see
+// Job.consumed.
+func (s *Service) monthlyUsage(ctx context.Context, tenant string, now time.Time) (JobUsage, error) {
+	var ans JobUsage
+
+	jobs, err := s.repo.Select(ctx, SelectParams{})
+	if err != nil {
+		return ans, err
+	}
+
+	year, month, _ := now.UTC().Date()
+
+	for i := range jobs {
+		if y, m, _ := jobs[i].Date.UTC().Date(); y != year || m != month || jobs[i].Tenant != tenant {
+			continue
+		}
+
+		usage := jobs[i].consumed()
+
+		ans.Places += usage.Places
+		ans.EmailFetches += usage.EmailFetches
+	}
+
+	return ans, nil
+}
+
+// applyBudgets sets the limits job runs with, its own lowered to what is left
+// of the monthly budgets of the settings of its tenant. It returns the budget
+// job cannot run within, if any: the email fetches only matter to jobs
+// extracting emails.
+func (s *Service) applyBudgets(ctx context.Context, job *Job) (string, error) {
+	job.Stats.Usage.MaxPlaces = job.Data.MaxPlaces
+	job.Stats.Usage.MaxEmailFetches = job.Data.MaxEmailFetches
+
+	settings, err := s.GetSettings(WithTenant(ctx, job.Tenant))
+	if err != nil {
+		return "", err
+	}
+
+	if settings.MonthlyMaxPlaces == 0 && settings.MonthlyMaxEmailFetches == 0 {
+		return "", nil
+	}
+
+	used, err := s.monthlyUsage(ctx, job.Tenant, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	var ok bool
+
+	job.Stats.Usage.MaxPlaces, ok = budgetLimit(job.Data.MaxPlaces, settings.MonthlyMaxPlaces, used.Places)
+	if !ok {
+		return BudgetPlaces, nil
+	}
+
+	job.Stats.Usage.MaxEmailFetches, ok = budgetLimit(job.Data.MaxEmailFetches, settings.MonthlyMaxEmailFetches, used.EmailFetches)
+	if !ok && job.Data.Email {
+		return BudgetEmailFetches, nil
+	}
+
+	return "", nil
+}
+
+// budgetLimit lowers the limit own, 0 meaning none, to what is left of
+// budget. It returns false when nothing is left.
+func budgetLimit(own, budget, used int) (int, bool) {
+	if budget == 0 {
+		return own, true
+	}
+
+	left := budget - used
+	if left <= 0 {
+		return 0, false
+	}
+
+	if own == 0 || own > left {
+		own = left
+	}
+
+	return own, true
+}
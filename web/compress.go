@@ -0,0 +1,200 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressMinSize is the size from which a response is compressed: below
+// it the compression costs more than it saves.
+const compressMinSize = 1024
+
+// compressResponses compresses the JSON and CSV responses for the clients
+// accepting gzip or deflate. The small ones, the partial ones and those
+// compressed already, like the result files served from their compressed
+// copy, go as they are.
+func compressResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coding := responseCoding(r)
+		if coding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, coding: coding}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// responseCoding returns the content coding of the response to r, gzip or
+// deflate as its Accept-Encoding prefers them, or "" when it accepts
+// neither.
+func responseCoding(r *http.Request) string {
+	best, bestQ := "", 0.0
+
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		if coding != "gzip" && coding != "deflate" {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		// q=0 refuses the coding, gzip wins a tie
+		if q > 0 && (q > bestQ || (q == bestQ && coding == "gzip")) {
+			best, bestQ = coding, q
+		}
+	}
+
+	return best
+}
+
+// compressible reports whether the responses of contentType are compressed.
+func compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || strings.HasSuffix(mt, "+json") ||
+		mt == "application/x-ndjson" || mt == "text/csv"
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// to compress it: it is compressible and reaches compressMinSize, or is
+// flushed.
+type compressWriter struct {
+	http.ResponseWriter
+	coding string
+
+	code int
+	// buf holds the start of a response that may be compressed.
+	buf bytes.Buffer
+	// plain is set when the response goes as it is, zw when it is
+	// compressed.
+	plain bool
+	zw    io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.code != 0 {
+		return
+	}
+
+	cw.code = code
+
+	h := cw.Header()
+
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusPartialContent ||
+		code == http.StatusNotModified || h.Get("Content-Encoding") != "" || !compressible(h.Get("Content-Type")) {
+		cw.plain = true
+		cw.ResponseWriter.WriteHeader(code)
+
+		return
+	}
+
+	h.Add("Vary", "Accept-Encoding")
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.code == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	switch {
+	case cw.plain:
+		return cw.ResponseWriter.Write(p)
+	case cw.zw != nil:
+		return cw.zw.Write(p)
+	}
+
+	cw.buf.Write(p)
+
+	if cw.buf.Len() >= compressMinSize {
+		if err := cw.compress(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// compress starts the compressed response with what was held back.
+func (cw *compressWriter) compress() error {
+	h := cw.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", cw.coding)
+
+	// the compressed bytes are another representation
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	if cw.coding == "gzip" {
+		cw.zw = gzip.NewWriter(cw.ResponseWriter)
+	} else {
+		cw.zw = zlib.NewWriter(cw.ResponseWriter)
+	}
+
+	_, err := cw.zw.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+
+	return err
+}
+
+// Flush sends what was written so far, compressed when it may be: a
+// streamed response does not wait for compressMinSize.
+func (cw *compressWriter) Flush() {
+	if cw.code == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.plain && cw.zw == nil {
+		if err := cw.compress(); err != nil {
+			return
+		}
+	}
+
+	if f, ok := cw.zw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close ends the response: a short one goes as it is.
+func (cw *compressWriter) close() {
+	switch {
+	case cw.zw != nil:
+		_ = cw.zw.Close()
+	case cw.code != 0 && !cw.plain:
+		cw.ResponseWriter.WriteHeader(cw.code)
+		_, _ = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The JSON views of the results, the records and the job list answer the
+// conditional requests of the clients polling them, If-None-Match and
+// If-Modified-Since, with 304 while nothing changed. Their validators are
+// weak: the same data is encoded again for each response.
+
+// fileValidator returns the ETag and the modification time of the file
+// described by info.
+func fileValidator(info os.FileInfo) (string, time.Time) {
+	return fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()), info.ModTime()
+}
+
+// resultsValidator returns the validator of the results of job id: its
+// JSON results or, while it runs, its journal. ok is false when it has
+// neither.
+func (s *Service) resultsValidator(ctx context.Context, id string) (etag string, modified time.Time, ok bool) {
+	path, err := s.resultsPath(ctx, id)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	for _, p := range []string{path, filepath.Join(filepath.Dir(path), id+"."+JournalFormat)} {
+		if info, err := os.Stat(p); err == nil {
+			etag, modified = fileValidator(info)
+
+			return etag, modified, true
+		}
+	}
+
+	return "", time.Time{}, false
+}
+
+// notModified sets the ETag and, when known, the Last-Modified of the
+// response, and answers 304 and returns true when the request is
+// conditional and the client has them already.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-None-Match wins over If-Modified-Since, RFC 9110 13.2.2
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// etagMatches reports whether the If-None-Match list inm holds etag, by
+// weak comparison.
+func etagMatches(inm, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderJSONConditional is renderJSON with 200 and an ETag hashing the
+// response, answering 304 to a client that has it already.
+func renderJSONConditional(w http.ResponseWriter, r *http.Request, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	sum := sha256.Sum256(body)
+
+	if notModified(w, r, `W/"`+hex.EncodeToString(sum[:16])+`"`, time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write(append(body, '\n'))
+}
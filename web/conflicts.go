@@ -0,0 +1,77 @@
+package web
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// ConflictReport lists the websites and phone numbers shared by different
+// places of a job, see gmaps.FindConflicts.
+type ConflictReport struct {
+	Conflicts []gmaps.Conflict `json:"conflicts"`
+	// Places counts the places involved in a conflict.
+	Places int `json:"places"`
+	// Partial is set while the job runs, see Service.GetRecords.
+	Partial bool `json:"partial"`
+}
+
+// Conflicts returns the conflict report of the results of job id, so far
+// while it runs.
+func (s *Service) Conflicts(ctx context.Context, id string) (ConflictReport, error) {
+	entries, partial, err := s.loadResults(ctx, id)
+	if err != nil {
+		return ConflictReport{}, err
+	}
+
+	ptrs := make([]*gmaps.Entry, len(entries))
+	for i := range entries {
+		ptrs[i] = &entries[i]
+	}
+
+	ans := ConflictReport{
+		Conflicts: gmaps.FindConflicts(ptrs),
+		Partial:   partial,
+	}
+
+	if ans.Conflicts == nil {
+		ans.Conflicts = []gmaps.Conflict{}
+	}
+
+	involved := make(map[gmaps.ConflictPlace]bool)
+
+	for _, c := range ans.Conflicts {
+		for _, p := range c.Places {
+			involved[p] = true
+		}
+	}
+
+	ans.Places = len(involved)
+
+	return ans, nil
+}
+
+func (s *Server) apiConflicts(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	report, err := s.svc.Conflicts(r.Context(), id.String())
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,198 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// InputPlaces is the JobData.InputFrom feeding a job the place pages found
+// by the jobs it depends on.
+const InputPlaces = "places"
+
+func (d *JobData) validateDependencies() error {
+	seen := make(map[string]struct{}, len(d.DependsOn))
+
+	for _, id := range d.DependsOn {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("invalid dependency %q", id)
+		}
+
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("duplicate dependency %s", id)
+		}
+
+		seen[id] = struct{}{}
+	}
+
+	switch d.InputFrom {
+	case "":
+	case InputPlaces:
+		if len(d.DependsOn) == 0 {
+			return errors.New("input_from needs depends_on")
+		}
+	default:
+		return fmt.Errorf("invalid input_from %q: use %s", d.InputFrom, InputPlaces)
+	}
+
+	if len(d.Places) == 0 && d.InputFrom == "" {
+		return nil
+	}
+
+	if len(d.Keywords) > 0 {
+		return errors.New("a job visits either keywords or places")
+	}
+
+	if d.FastMode {
+		return errors.New("fast mode does not visit place pages")
+	}
+
+	for _, raw := range d.Places {
+		if !isPlaceLink(raw) {
+			return fmt.Errorf("invalid place URL %q: use the link of a Google Maps place", raw)
+		}
+	}
+
+	return nil
+}
+
+// checkDependencies checks that the jobs job depends on are jobs of its
+// tenant.
+func (s *Service) checkDependencies(ctx context.Context, job *Job) error {
+	for _, id := range job.Data.DependsOn {
+		parent, err := s.repo.Get(ctx, id)
+		if err != nil || parent.Tenant != job.Tenant {
+			return fmt.Errorf("%w: dependency %s", ErrNotFound, id)
+		}
+	}
+
+	return nil
+}
+
+// dependencyEnded releases or fails the jobs waiting for parent, which just
+// ended or was deleted, and starts the exports of its pipeline stage when it
+// ended ok.
+func (s *Service) dependencyEnded(ctx context.Context, parent *Job) {
+	s.depMu.Lock()
+	defer s.depMu.Unlock()
+
+	if err := s.releaseDependents(ctx, parent); err != nil {
+		log.Printf("job %s: releasing the jobs depending on it: %v", parent.ID, err)
+	}
+
+	if parent.Status == StatusOK {
+		s.startExports(ctx, parent)
+	}
+}
+
+// releaseDependents calls release on the jobs waiting for parent. s.depMu
+// must be held.
+func (s *Service) releaseDependents(ctx context.Context, parent *Job) error {
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusWaiting})
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if jobs[i].Tenant != parent.Tenant || !slices.Contains(jobs[i].Data.DependsOn, parent.ID) {
+			continue
+		}
+
+		if err := s.release(ctx, &jobs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// release queues job, waiting for its dependencies, once they all ended ok,
+// with the input it takes from them. It fails job when one of them failed
+// or is gone, and the jobs waiting for job in turn. s.depMu must be held.
+func (s *Service) release(ctx context.Context, job *Job) error {
+	var (
+		parents []Job
+		waiting bool
+	)
+
+	for _, id := range job.Data.DependsOn {
+		parent, err := s.repo.Get(ctx, id)
+		if err != nil || parent.Tenant != job.Tenant || parent.Status == StatusFailed {
+			job.Status = StatusFailed
+			job.Stats.DependencyFailed = id
+
+			if err := s.repo.Update(ctx, job); err != nil {
+				return err
+			}
+
+			return s.releaseDependents(ctx, job)
+		}
+
+		if parent.Status != StatusOK {
+			waiting = true
+		}
+
+		parents = append(parents, parent)
+	}
+
+	if waiting {
+		return nil
+	}
+
+	if job.Data.InputFrom == InputPlaces {
+		job.Data.Places = s.dependencyPlaces(job.Data.Places, parents)
+	}
+
+	job.Status = StatusPending
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		return err
+	}
+
+	if s.queue != nil {
+		return s.queue.Push(ctx, job.ID)
+	}
+
+	return nil
+}
+
+// dependencyPlaces appends to places the place pages found by parents,
+// skipping the ones already there.
+func (s *Service) dependencyPlaces(places []string, parents []Job) []string {
+	seen := make(map[string]struct{}, len(places))
+
+	for _, u := range places {
+		seen[u] = struct{}{}
+	}
+
+	for i := range parents {
+		path := filepath.Join(TenantFolder(s.dataFolder, parents[i].Tenant), parents[i].ID+".json")
+
+		entries, err := s.readResults(path)
+		if err != nil {
+			log.Printf("job %s: reading its places for the jobs depending on it: %v", parents[i].ID, err)
+
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.Link == "" {
+				continue
+			}
+
+			if _, ok := seen[entry.Link]; ok {
+				continue
+			}
+
+			seen[entry.Link] = struct{}{}
+			places = append(places, entry.Link)
+		}
+	}
+
+	return places
+}
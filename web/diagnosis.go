@@ -0,0 +1,111 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiagnosisDir returns the folder of the screenshots and pages captured for
+// the keywords of job id that found nothing, see gmaps.Diagnoser.
+func DiagnosisDir(dataFolder, id string) string {
+	return filepath.Join(dataFolder, id+"-diagnosis")
+}
+
+// validDiagnosisFile reports whether name can name a file of a diagnosis
+// folder.
+func validDiagnosisFile(name string) bool {
+	ext := filepath.Ext(name)
+
+	return name != "" && filepath.Base(name) == name && !strings.Contains(name, "..") &&
+		(ext == ".png" || ext == ".html")
+}
+
+// DiagnosisFile returns the path of the diagnosis file name of job id,
+// ErrNotFound when there is none.
+func (s *Service) DiagnosisFile(ctx context.Context, id, name string) (string, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return "", err
+	}
+
+	if !validDiagnosisFile(name) {
+		return "", ErrNotFound
+	}
+
+	path := filepath.Join(DiagnosisDir(s.folder(ctx), id), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+
+	return path, nil
+}
+
+// SaveDiagnosis stores the diagnosis file name of job id uploaded by
+// workerID.
+func (s *Service) SaveDiagnosis(ctx context.Context, id, workerID, name string, r io.Reader) error {
+	if !validDiagnosisFile(name) || strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid file name")
+	}
+
+	s.mu.Lock()
+	job, err := s.leased(ctx, id, workerID)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	dir := DiagnosisDir(TenantFolder(s.dataFolder, job.Tenant), id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(filepath.Join(dir, name), func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+
+		return err
+	})
+}
+
+// downloadDiagnosis serves a screenshot or a page captured for a keyword of
+// a job that found nothing. The pages are sent as attachments, so that the
+// browser never runs their scripts.
+func (s *Server) downloadDiagnosis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid ID", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	name := r.PathValue("file")
+	if name == "" {
+		name = r.URL.Query().Get("file")
+	}
+
+	path, err := s.svc.DiagnosisFile(r.Context(), id.String(), name)
+	if err != nil {
+		http.Error(w, "Diagnosis file not found", http.StatusNotFound)
+
+		return
+	}
+
+	if filepath.Ext(name) == ".png" {
+		w.Header().Set("Content-Type", "image/png")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", name))
+	}
+
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gzipSuffix names the compressed copy of a result file, served to the
+// clients accepting gzip.
+const gzipSuffix = ".gz"
+
+// serveResultFile sends the result file at path, downloaded as name, with
+// Content-Length, ETag, Last-Modified and Range support, so that a download
+// of a large export cut short can be resumed. Clients accepting gzip get a
+// compressed copy kept next to the file until the file changes: ranges then
+// apply to the compressed bytes.
+func serveResultFile(w http.ResponseWriter, r *http.Request, path, name, contentType string) {
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+
+		return
+	}
+	defer file.Close()
+
+	// the open file stays the same even if path is replaced meanwhile
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+
+		return
+	}
+
+	etag := fmt.Sprintf("%x-%x", info.Size(), info.ModTime().UnixNano())
+
+	var content io.ReadSeeker = file
+
+	if acceptsGzip(r) {
+		gz, err := gzipCopy(file, path, info)
+		if err != nil {
+			log.Printf("compressing %s: %v", filepath.Base(path), err)
+		} else {
+			defer gz.Close()
+
+			content = gz
+			etag += "-gz"
+
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("ETag", `"`+etag+`"`)
+
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// acceptsGzip reports whether the Accept-Encoding of r allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(params), "q="), 64)
+
+		return params == "" || err != nil || q > 0
+	}
+
+	return false
+}
+
+// gzipCopy opens the compressed copy of src, the file at path described by
+// info, writing it first when it is missing or stale. The copy carries the
+// modification time of the file it was made from.
+func gzipCopy(src *os.File, path string, info os.FileInfo) (*os.File, error) {
+	gzPath := path + gzipSuffix
+
+	if gz, err := os.Open(gzPath); err == nil {
+		if gzInfo, err := gz.Stat(); err == nil && gzInfo.ModTime().Equal(info.ModTime()) {
+			return gz, nil
+		}
+
+		_ = gz.Close()
+	}
+
+	err := WriteFileAtomic(gzPath, func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+
+		if _, err := io.Copy(zw, io.NewSectionReader(src, 0, info.Size())); err != nil {
+			return err
+		}
+
+		return zw.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chtimes(gzPath, time.Time{}, info.ModTime()); err != nil {
+		return nil, err
+	}
+
+	return os.Open(gzPath)
+}
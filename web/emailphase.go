@@ -0,0 +1,235 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// EmailPhaseSource follows the email phase of the jobs running on this
+// host. Both methods return ErrJobNotRunning when job id does not run here.
+type EmailPhaseSource interface {
+	// EmailEstimate returns the progress of the email searches of job id
+	// and what the queued ones are expected to take.
+	EmailEstimate(ctx context.Context, id string) (gmaps.EmailEstimate, error)
+	// AbortEmails stops the email searches of job id, which goes on
+	// scraping the places.
+	AbortEmails(ctx context.Context, id string) error
+}
+
+// WithEmailPhaseSource lets the service estimate and abort the email phase
+// of its running jobs.
+func WithEmailPhaseSource(src EmailPhaseSource) ServiceOption {
+	return func(s *Service) {
+		s.emails = src
+	}
+}
+
+// runningHere returns job id, or ErrJobNotRunning when it does not run on
+// this host.
+func (s *Service) runningHere(ctx context.Context, id string) (Job, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		// the repositories differ in how they tell a missing job
+		return Job{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if job.Status != StatusWorking {
+		return Job{}, ErrJobNotRunning
+	}
+
+	if job.Stats.Worker != nil {
+		return Job{}, fmt.Errorf("%w here, it runs on worker %s", ErrJobNotRunning, job.Stats.Worker.ID)
+	}
+
+	return job, nil
+}
+
+// emailPhase returns the source of the email phase of job id, running on
+// this host and searching emails.
+func (s *Service) emailPhase(ctx context.Context, id string) (EmailPhaseSource, error) {
+	job, err := s.runningHere(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.Data.Email {
+		return nil, ErrNoEmailPhase
+	}
+
+	if s.emails == nil {
+		return nil, fmt.Errorf("%w here", ErrJobNotRunning)
+	}
+
+	return s.emails, nil
+}
+
+// EmailEstimate returns the progress of the email phase of running job id,
+// with how long and how many fetches the websites queued are expected to
+// take, from the times their domains took in the earlier jobs.
+func (s *Service) EmailEstimate(ctx context.Context, id string) (gmaps.EmailEstimate, error) {
+	src, err := s.emailPhase(ctx, id)
+	if err != nil {
+		return gmaps.EmailEstimate{}, err
+	}
+
+	return src.EmailEstimate(ctx, id)
+}
+
+// AbortEmails stops the email phase of running job id: the searches running
+// end at their next fetch and the places found from now on get none, with
+// the email status aborted. The job goes on scraping the places.
+func (s *Service) AbortEmails(ctx context.Context, id string) (gmaps.EmailEstimate, error) {
+	src, err := s.emailPhase(ctx, id)
+	if err != nil {
+		return gmaps.EmailEstimate{}, err
+	}
+
+	if err := src.AbortEmails(ctx, id); err != nil {
+		return gmaps.EmailEstimate{}, err
+	}
+
+	return src.EmailEstimate(ctx, id)
+}
+
+// withEmailEstimates sets the email progress of the jobs searching emails
+// that run on this host.
+func (s *Service) withEmailEstimates(ctx context.Context, jobs []Job) {
+	if s.emails == nil {
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Status != StatusWorking || job.Stats.Worker != nil || !job.Data.Email {
+			continue
+		}
+
+		if estimate, err := s.emails.EmailEstimate(ctx, job.ID); err == nil {
+			job.Stats.Email = &estimate
+		}
+	}
+}
+
+// emailPhaseError renders the error of EmailEstimate or AbortEmails.
+func emailPhaseError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, ErrJobNotRunning) || errors.Is(err, ErrNoEmailPhase):
+		code = http.StatusConflict
+	}
+
+	renderJSON(w, code, apiError{Code: code, Message: err.Error()})
+}
+
+// apiEmailEstimate sends the progress of the email phase of a running job.
+func (s *Server) apiEmailEstimate(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	estimate, err := s.svc.EmailEstimate(r.Context(), id.String())
+	if err != nil {
+		emailPhaseError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, estimate)
+}
+
+// apiAbortEmails aborts the email phase of a running job.
+func (s *Server) apiAbortEmails(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	estimate, err := s.svc.AbortEmails(r.Context(), id.String())
+	if err != nil {
+		emailPhaseError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, estimate)
+}
+
+// abortEmails is the button of the web UI aborting the email phase of a
+// running job.
+func (s *Server) abortEmails(w http.ResponseWriter, r *http.Request) {
+	// the form of the button posts without JavaScript
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid ID", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	ctx := r.Context()
+
+	_, err := s.svc.AbortEmails(ctx, id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+
+		return
+	case errors.Is(err, ErrJobNotRunning) || errors.Is(err, ErrNoEmailPhase):
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if !isHTMX(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/job_row.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	job, err := s.svc.Get(ctx, id.String())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	jobs := []Job{job}
+	s.svc.withEmailEstimates(ctx, jobs)
+
+	s.render(w, r, tmpl, jobs[0])
+}
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Proxy sources of a job, in JobEnv.ProxySource.
+const (
+	ProxySourceFlag     = "flag"
+	ProxySourceJob      = "job"
+	ProxySourceProvider = "provider"
+	ProxySourceNone     = "none"
+)
+
+// JobEnv is the effective configuration a job ran with, recorded when it
+// starts so that its results can be reproduced and an anomaly traced back to
+// a configuration change. It holds no credentials: the proxies are counted
+// and hashed, and the passwords of the settings are masked.
+type JobEnv struct {
+	StartedAt      time.Time `json:"started_at"`
+	ScraperVersion string    `json:"scraper_version"`
+	// SelectorVersion identifies the parsing of the place pages, see
+	// gmaps.SelectorVersion.
+	SelectorVersion string `json:"selector_version"`
+	// Host is the worker ID, the hostname unless -worker-id is set.
+	Host string `json:"host"`
+	// Profile is the settings profile of the job, empty for the global
+	// settings, and Settings what it resolved to, the -proxy-provider and
+	// -email-proxies flags applied.
+	Profile  string   `json:"profile,omitempty"`
+	Settings Settings `json:"settings"`
+	// ProxySource tells where the proxies of the job came from, one of the
+	// ProxySource constants.
+	ProxySource string `json:"proxy_source"`
+	ProxyCount  int    `json:"proxy_count"`
+	// ProxyHash tells whether two jobs ran with the same proxies, see
+	// ProxyHash.
+	ProxyHash   string `json:"proxy_hash,omitempty"`
+	ProxyGeo    string `json:"proxy_geo,omitempty"`
+	Concurrency int    `json:"concurrency"`
+	Identities  int    `json:"identities"`
+	PageReuse   bool   `json:"page_reuse"`
+	// AdaptiveThrottle tells whether the pages slow down while Google
+	// pushes back, see gmaps.Throttle.
+	AdaptiveThrottle bool `json:"adaptive_throttle"`
+	// The options a job or a flag can turn on, as applied.
+	ExtraReviews       bool `json:"extra_reviews"`
+	ExcludeServiceArea bool `json:"exclude_service_area"`
+	VerifyEmails       bool `json:"verify_emails"`
+	OSMCheck           bool `json:"osm_check"`
+	RegistrationLookup bool `json:"registration_lookup"`
+	// ValidationRules are the rules run on the places, if any.
+	ValidationRules []string `json:"validation_rules,omitempty"`
+	// EntryWebhook tells whether the places were streamed to an entry
+	// webhook, whose URL is left out.
+	EntryWebhook bool `json:"entry_webhook"`
+	// AnonymizeReviewers is the reviewer anonymization applied, if any.
+	AnonymizeReviewers string `json:"anonymize_reviewers,omitempty"`
+	// ReviewLangs are the languages the reviews were narrowed to, if any.
+	ReviewLangs []string `json:"review_langs,omitempty"`
+	// SkipReviews, SkipImages and SkipAbout are the fields left out.
+	SkipReviews bool `json:"skip_reviews,omitempty"`
+	SkipImages  bool `json:"skip_images,omitempty"`
+	SkipAbout   bool `json:"skip_about,omitempty"`
+	// Exclusions counts the domains, names and CIDs excluded, those of the
+	// flags included.
+	Exclusions int `json:"exclusions,omitempty"`
+}
+
+// proxySchemes are the schemes of the proxy and provider URLs whose host
+// can be shown.
+var proxySchemes = []string{"http", "https", "socks4", "socks5", "socks5h"}
+
+// ProxyHash returns a short digest of proxies, the same whatever their
+// order, or an empty string without proxies.
+func ProxyHash(proxies []string) string {
+	if len(proxies) == 0 {
+		return ""
+	}
+
+	sorted := slices.Clone(proxies)
+	slices.Sort(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// Redacted returns a copy of s with the passwords and query values of its
+// proxy and provider URLs masked, and the path of its usage webhook too.
+func (s *Settings) Redacted() Settings {
+	ans := *s
+
+	ans.Proxies = make([]string, 0, len(s.Proxies))
+	for _, p := range s.Proxies {
+		ans.Proxies = append(ans.Proxies, RedactURL(p))
+	}
+
+	ans.EmailProxies = make(map[string][]string, len(s.EmailProxies))
+	for country, proxies := range s.EmailProxies {
+		for _, p := range proxies {
+			ans.EmailProxies[country] = append(ans.EmailProxies[country], RedactURL(p))
+		}
+	}
+
+	ans.ProxyProvider = RedactURL(s.ProxyProvider)
+	ans.UsageWebhook = redactWebhook(s.UsageWebhook)
+
+	return ans
+}
+
+// redactWebhook masks a webhook URL like RedactURL, and its path as well:
+// the webhooks of Slack, Discord and the like carry their token there.
+func redactWebhook(s string) string {
+	u, err := url.Parse(RedactURL(s))
+	if err != nil || u.Host == "" || u.Path == "" || u.Path == "/" {
+		return RedactURL(s)
+	}
+
+	u.Path = "/xxxxx"
+
+	return u.String()
+}
+
+// RedactURL masks the password and the query values of the URL s, such as
+// the credentials of a proxy or the API key of a provider. Only the scheme
+// is kept of the provider shorthands (brightdata://USER:PASSWORD) and of what
+// does not parse.
+func RedactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || !slices.Contains(proxySchemes, u.Scheme) {
+		if scheme, _, ok := strings.Cut(s, "://"); ok {
+			return scheme + "://xxxxx"
+		}
+
+		return "xxxxx"
+	}
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		for key := range q {
+			q.Set(key, "xxxxx")
+		}
+
+		u.RawQuery = q.Encode()
+	}
+
+	return u.Redacted()
+}
@@ -5,4 +5,17 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("already exists")
+	// ErrLeaseLost is returned to a worker reporting on a job it no longer
+	// holds, because it was requeued or finished.
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrJobNotRunning is returned when asking a running job for what it is
+	// doing, and the job is not running on this host.
+	ErrJobNotRunning = errors.New("job is not running")
+	// ErrJobNotFinished and ErrNoFailures are returned when retrying the
+	// failures of a job still running or without failures.
+	ErrJobNotFinished = errors.New("job is not finished")
+	ErrNoFailures     = errors.New("job has no failed places")
+	// ErrNoEmailPhase is returned when asking a job that searches no email
+	// for its email phase.
+	ErrNoEmailPhase = errors.New("job does not search emails")
 )
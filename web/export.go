@@ -0,0 +1,234 @@
+package web
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The values of ExportFormat, the first of each list being the default.
+var (
+	exportDelimiters        = []string{"comma", "semicolon", "tab"}
+	exportDecimalSeparators = []string{".", ","}
+	exportEncodings         = []string{"utf-8", "utf-8-bom"}
+)
+
+// utf8BOM lets Excel tell that a CSV file is UTF-8.
+const utf8BOM = "\ufeff"
+
+// The CSV columns rewritten by ExportFormat.DecimalSeparator and
+// ExportFormat.DateFormat.
+var (
+	decimalColumns = []string{"review_rating", "latitude", "longitude", "review_reply_rate", "review_median_reply_hours"}
+	dateColumns    = []string{"scraped_at"}
+)
+
+// ExportFormat shapes the downloaded results, for spreadsheets that expect
+// another CSV dialect, like the European versions of Excel. The zero value
+// downloads the result files as they are written, named after the job ID.
+type ExportFormat struct {
+	// FilenamePattern names the downloaded files, without extension, from
+	// the placeholders {name}, {id} and {date}, the day the job was created
+	// as YYYY-MM-DD. Empty means "{id}".
+	FilenamePattern string `json:"filename_pattern,omitempty"`
+	// Delimiter separates the CSV fields: comma, semicolon or tab.
+	Delimiter string `json:"delimiter,omitempty"`
+	// DecimalSeparator is "." or "," in the ratings and the coordinates.
+	DecimalSeparator string `json:"decimal_separator,omitempty"`
+	// Encoding is utf-8 or utf-8-bom, for Excel to read the accents right.
+	Encoding string `json:"encoding,omitempty"`
+	// DateFormat is the Go layout of the dates of the CSV, like
+	// "02/01/2006 15:04". Empty means RFC 3339.
+	DateFormat string `json:"date_format,omitempty"`
+	// Mapping names the ExportMapping of Settings.ExportMappings laying out
+	// the CSV, none when empty.
+	Mapping string `json:"mapping,omitempty"`
+}
+
+// Validate checks the values of f.
+func (f *ExportFormat) Validate() error {
+	if f.FilenamePattern != "" && strings.ContainsAny(f.FilenamePattern, `/\`) {
+		return errors.New("export filename pattern cannot contain / or \\")
+	}
+
+	for _, c := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"delimiter", f.Delimiter, exportDelimiters},
+		{"decimal separator", f.DecimalSeparator, exportDecimalSeparators},
+		{"encoding", f.Encoding, exportEncodings},
+	} {
+		if c.value != "" && !slices.Contains(c.allowed, c.value) {
+			return fmt.Errorf("invalid export %s %q: use %s", c.name, c.value, strings.Join(c.allowed, ", "))
+		}
+	}
+
+	// a layout without any element of the date formats as itself
+	if f.DateFormat != "" && time.Date(2001, 12, 31, 23, 59, 58, 0, time.UTC).Format(f.DateFormat) == f.DateFormat {
+		return fmt.Errorf("invalid export date format %q: use a Go layout like 02/01/2006 15:04", f.DateFormat)
+	}
+
+	return nil
+}
+
+// Override returns f with the values set in query: filename, delimiter,
+// decimal, encoding, date_format and mapping.
+func (f ExportFormat) Override(query url.Values) ExportFormat {
+	for key, dst := range map[string]*string{
+		"filename":    &f.FilenamePattern,
+		"delimiter":   &f.Delimiter,
+		"decimal":     &f.DecimalSeparator,
+		"encoding":    &f.Encoding,
+		"date_format": &f.DateFormat,
+		"mapping":     &f.Mapping,
+	} {
+		if v := strings.TrimSpace(query.Get(key)); v != "" {
+			*dst = v
+		}
+	}
+
+	return f
+}
+
+// plainCSV reports whether the CSV of f is the result file as written.
+func (f *ExportFormat) plainCSV() bool {
+	return (f.Delimiter == "" || f.Delimiter == exportDelimiters[0]) &&
+		(f.DecimalSeparator == "" || f.DecimalSeparator == exportDecimalSeparators[0]) &&
+		(f.Encoding == "" || f.Encoding == exportEncodings[0]) &&
+		f.DateFormat == "" && f.Mapping == ""
+}
+
+// Filename returns the name of the file of job with extension ext, like
+// "csv".
+func (f *ExportFormat) Filename(job *Job, ext string) string {
+	pattern := f.FilenamePattern
+	if pattern == "" {
+		pattern = "{id}"
+	}
+
+	name := strings.NewReplacer(
+		"{name}", job.Name,
+		"{id}", job.ID,
+		"{date}", job.Date.UTC().Format(time.DateOnly),
+	).Replace(pattern)
+
+	// no paths, quotes or control characters in the header
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\"`, r) {
+			return '_'
+		}
+
+		return r
+	}, strings.TrimSpace(name))
+
+	if name == "" {
+		name = job.ID
+	}
+
+	return name + "." + ext
+}
+
+// contentDisposition returns the Content-Disposition header downloading a
+// file named name, which may not be ASCII.
+func contentDisposition(name string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
+
+// csvExporter writes CSV rows in an ExportFormat, laid out by an optional
+// ExportMapping.
+type csvExporter struct {
+	cw       *csv.Writer
+	format   ExportFormat
+	mapping  *ExportMapping
+	sources  map[string]int
+	decimals []int
+	dates    []int
+}
+
+// newCSVExporter returns an exporter writing to w, starting with the BOM of
+// the encoding of format. mapping may be nil.
+func newCSVExporter(w io.Writer, format ExportFormat, mapping *ExportMapping) (*csvExporter, error) {
+	if format.Encoding == "utf-8-bom" {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+
+	switch format.Delimiter {
+	case "semicolon":
+		cw.Comma = ';'
+	case "tab":
+		cw.Comma = '\t'
+	}
+
+	return &csvExporter{cw: cw, format: format, mapping: mapping}, nil
+}
+
+// WriteHeader writes the header row, that of the mapping if any, and learns
+// the columns of the rows from headers.
+func (e *csvExporter) WriteHeader(headers []string) error {
+	e.sources = make(map[string]int, len(headers))
+
+	for i, h := range headers {
+		e.sources[h] = i
+
+		switch {
+		case slices.Contains(decimalColumns, h):
+			e.decimals = append(e.decimals, i)
+		case slices.Contains(dateColumns, h):
+			e.dates = append(e.dates, i)
+		}
+	}
+
+	if e.mapping != nil {
+		headers = e.mapping.headers()
+	}
+
+	return e.cw.Write(headers)
+}
+
+// Write writes row, which it may modify.
+func (e *csvExporter) Write(row []string) error {
+	if e.format.DecimalSeparator == "," {
+		for _, i := range e.decimals {
+			if i < len(row) {
+				row[i] = strings.Replace(row[i], ".", ",", 1)
+			}
+		}
+	}
+
+	if e.format.DateFormat != "" {
+		for _, i := range e.dates {
+			if i >= len(row) {
+				continue
+			}
+
+			if t, err := time.Parse(time.RFC3339, row[i]); err == nil {
+				row[i] = t.Format(e.format.DateFormat)
+			}
+		}
+	}
+
+	if e.mapping != nil {
+		row = e.mapping.row(row, e.sources)
+	}
+
+	return e.cw.Write(row)
+}
+
+// Flush writes the buffered rows, returning the first error met.
+func (e *csvExporter) Flush() error {
+	e.cw.Flush()
+
+	return e.cw.Error()
+}
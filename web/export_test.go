@@ -0,0 +1,15 @@
+package web
+
+import "net/http"
+
+// Handler returns the handler of the server, for the tests of web_test.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// SealBytes and OpenBytes seal and open a result file, see sealBytes.
+var SealBytes = sealBytes
+
+func (s *Service) OpenBytes(data []byte) ([]byte, error) {
+	return s.openBytes(data)
+}
@@ -0,0 +1,280 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// previousResultsSuffix is appended to the ID of a job retrying its failures
+// for the file its results are kept in meanwhile, merged with the places of
+// the retry once it ends.
+const previousResultsSuffix = ".previous.json"
+
+// JobRetry is what a job visiting its failures again starts from.
+type JobRetry struct {
+	// Places are the failures being retried.
+	Places []gmaps.PlaceFailure `json:"places"`
+	// Usage and Keywords are those of the previous runs: the usage of the
+	// retry adds up to Usage, and the keywords are kept as they were.
+	Usage    JobUsage              `json:"usage"`
+	Keywords []gmaps.KeywordReport `json:"keywords,omitempty"`
+}
+
+// RetryFailures queues job id again to visit only the place pages it failed
+// to scrape, whose results are added to the ones it has. The job must be
+// over.
+func (s *Service) RetryFailures(ctx context.Context, id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		// the repositories differ in how they tell a missing job
+		return Job{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if !isFinished(job.Status) {
+		return Job{}, fmt.Errorf("%w: job %s is %s", ErrJobNotFinished, id, job.Status)
+	}
+
+	if len(job.Stats.Failures) == 0 {
+		return Job{}, fmt.Errorf("%w: job %s", ErrNoFailures, id)
+	}
+
+	folder := TenantFolder(s.dataFolder, job.Tenant)
+	base := filepath.Join(folder, job.ID)
+
+	// the results of the retry replace the JSON of the job until it ends
+	err = os.Rename(base+".json", base+previousResultsSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Job{}, err
+	}
+
+	job.Status = StatusPending
+	job.Stats.Retry = &JobRetry{
+		Places:   job.Stats.Failures,
+		Usage:    job.Stats.Usage,
+		Keywords: job.Stats.Keywords,
+	}
+	job.Stats.Failures = nil
+	job.Stats.Usage = JobUsage{}
+	job.Stats.Worker = nil
+
+	if err := s.repo.Update(ctx, &job); err != nil {
+		return Job{}, err
+	}
+
+	if s.queue != nil {
+		if err := s.queue.Push(ctx, job.ID); err != nil {
+			return Job{}, err
+		}
+	}
+
+	return job, nil
+}
+
+// finishRetry adds the results of job, which just ended a retry of its
+// failures, to its previous ones, and its usage to theirs. It returns the
+// job with the usage of the retry only, to meter.
+func (s *Service) finishRetry(job *Job) (Job, error) {
+	retry := job.Stats.Retry
+	folder := TenantFolder(s.dataFolder, job.Tenant)
+	base := filepath.Join(folder, job.ID)
+
+	previous, err := s.readResults(base + previousResultsSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Job{}, err
+	}
+
+	retried, err := s.readResults(base + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		retried, err = ReadJournal(base + "." + JournalFormat)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("job %s: reading the results of the retry: %v", job.ID, err)
+	}
+
+	if err := WriteResults(folder, job.ID, append(previous, retried...), s.csvLayout); err != nil {
+		return Job{}, err
+	}
+
+	if err := os.Remove(base + previousResultsSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Job{}, err
+	}
+
+	metered := *job
+	metered.Stats.Retry = nil
+
+	job.Stats.Usage.add(&retry.Usage)
+	job.Stats.Keywords = retry.Keywords
+	job.Stats.Retry = nil
+
+	return metered, nil
+}
+
+// add adds the units consumed by o to u.
+func (u *JobUsage) add(o *JobUsage) {
+	u.Places += o.Places
+	u.EmailFetches += o.EmailFetches
+	u.EmailsVerified += o.EmailsVerified
+	u.BrowserSeconds += o.BrowserSeconds
+}
+
+// consumed returns what job consumed so far. A job running on a remote
+// worker counts for the places of its last heartbeat, and a job retrying its
+// failures for its previous runs too.
+func (j *Job) consumed() JobUsage {
+	usage := j.Stats.Usage
+
+	if j.Status == StatusWorking && j.Stats.Worker != nil {
+		usage.Places = max(usage.Places, j.Stats.Worker.Results)
+	}
+
+	if j.Stats.Retry != nil {
+		usage.add(&j.Stats.Retry.Usage)
+	}
+
+	return usage
+}
+
+type apiFailuresResponse struct {
+	JobID string `json:"job_id"`
+	// Retrying tells whether Failures are being visited again right now.
+	Retrying bool                 `json:"retrying"`
+	Failures []gmaps.PlaceFailure `json:"failures"`
+}
+
+func (s *Server) apiFailures(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	job, err := s.svc.Get(r.Context(), id.String())
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	ans := apiFailuresResponse{
+		JobID:    job.ID,
+		Retrying: job.Stats.Retry != nil,
+		Failures: job.Stats.Failures,
+	}
+
+	if job.Stats.Retry != nil {
+		ans.Failures = job.Stats.Retry.Places
+	}
+
+	if ans.Failures == nil {
+		ans.Failures = []gmaps.PlaceFailure{}
+	}
+
+	renderJSON(w, http.StatusOK, ans)
+}
+
+func (s *Server) apiRetryFailures(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	job, err := s.svc.RetryFailures(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	case errors.Is(err, ErrJobNotFinished) || errors.Is(err, ErrNoFailures):
+		renderJSON(w, http.StatusConflict, apiError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		})
+
+		return
+	case err != nil:
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusAccepted, job)
+}
+
+// retryFailures is the retry button of a job row.
+func (s *Server) retryFailures(w http.ResponseWriter, r *http.Request) {
+	// the form of the button posts without JavaScript
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid ID", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	job, err := s.svc.RetryFailures(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+
+		return
+	case errors.Is(err, ErrJobNotFinished) || errors.Is(err, ErrNoFailures):
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if !isHTMX(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/job_row.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	s.render(w, r, tmpl, job)
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"context"
+)
+
+// Size is how much work the job asks for, its keywords times its depth plus
+// its place pages, compared with Settings.FastLaneThreshold.
+func (d *JobData) Size() int {
+	return len(d.Keywords)*max(d.Depth, 1) + len(d.Places)
+}
+
+// ClaimFastLane hands to workerID the oldest pending job no larger than the
+// fast lane threshold of the settings, skipping the larger ones queued
+// before it, and marks it working like Claim. It returns nil when no such
+// job is pending or when the fast lane is disabled.
+//
+// The local runner calls it while it is busy with another job, so that a
+// small job does not wait for the end of a huge one.
+func (s *Service) ClaimFastLane(ctx context.Context, workerID string) (*Job, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.FastLaneThreshold == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.claim(ctx, workerID, func(ctx context.Context, workerID string) (*Job, error) {
+		job, err := s.nextSmall(ctx, workerID, settings.FastLaneThreshold)
+		if job != nil {
+			job.Stats.FastLane = true
+		}
+
+		return job, err
+	})
+}
+
+// nextSmall returns the oldest pending job of size at most threshold,
+// taking it out of the queue when there is one.
+func (s *Service) nextSmall(ctx context.Context, workerID string, threshold int) (*Job, error) {
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusPending})
+	if err != nil {
+		return nil, err
+	}
+
+	owner, visibility := s.claimOwner(workerID)
+
+	// oldest first
+	for i := len(jobs) - 1; i >= 0; i-- {
+		job := &jobs[i]
+
+		if job.Data.Size() > threshold {
+			continue
+		}
+
+		if s.queue == nil {
+			return job, nil
+		}
+
+		ok, err := s.queue.Take(ctx, job.ID, owner, visibility)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return job, nil
+		}
+
+		// another process claimed it in the meantime
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Cell sizes of a heatmap, in meters.
+const (
+	HeatmapDefaultCellSize = 1000
+	HeatmapMinCellSize     = 50
+	HeatmapMaxCellSize     = 100000
+)
+
+// metersPerDegree is the length of a degree of latitude.
+const metersPerDegree = 111320
+
+// Heatmap counts the places of a job in the cells of a grid over their
+// bounding box, for a density map. Only the cells with places are listed.
+type Heatmap struct {
+	// CellSize is the side of a cell in meters, CellLat and CellLon in
+	// degrees.
+	CellSize float64        `json:"cell_size"`
+	CellLat  float64        `json:"cell_lat"`
+	CellLon  float64        `json:"cell_lon"`
+	Bounds   *HeatmapBounds `json:"bounds,omitempty"`
+	Rows     int            `json:"rows"`
+	Cols     int            `json:"cols"`
+	// Places counts the places with coordinates, those binned.
+	Places int           `json:"places"`
+	Cells  []HeatmapCell `json:"cells"`
+	// Partial is set while the job runs, see Service.GetRecords.
+	Partial bool `json:"partial"`
+}
+
+// HeatmapBounds is the bounding box of the places of a heatmap.
+type HeatmapBounds struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// HeatmapCell is a cell of a heatmap, at Row and Col from the south west
+// corner of the bounds, centered on Lat and Lon.
+type HeatmapCell struct {
+	Row   int     `json:"row"`
+	Col   int     `json:"col"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+	Count int     `json:"count"`
+	// AvgRating is the average rating of the Rated places of the cell.
+	AvgRating float64 `json:"avg_rating"`
+	Rated     int     `json:"rated"`
+}
+
+// BuildHeatmap bins entries in cells of cellSize meters. The places without
+// coordinates are left out.
+func BuildHeatmap(entries []gmaps.Entry, cellSize float64) Heatmap {
+	ans := Heatmap{CellSize: cellSize, Cells: []HeatmapCell{}}
+
+	var bounds *HeatmapBounds
+
+	for i := range entries {
+		lat, lon := entries[i].Latitude, entries[i].Longtitude
+		if lat == 0 && lon == 0 {
+			continue
+		}
+
+		if bounds == nil {
+			bounds = &HeatmapBounds{MinLat: lat, MinLon: lon, MaxLat: lat, MaxLon: lon}
+		}
+
+		bounds.MinLat = min(bounds.MinLat, lat)
+		bounds.MinLon = min(bounds.MinLon, lon)
+		bounds.MaxLat = max(bounds.MaxLat, lat)
+		bounds.MaxLon = max(bounds.MaxLon, lon)
+	}
+
+	if bounds == nil {
+		return ans
+	}
+
+	// the cells are square at the middle of the box
+	midLat := (bounds.MinLat + bounds.MaxLat) / 2
+
+	ans.Bounds = bounds
+	ans.CellLat = cellSize / metersPerDegree
+	ans.CellLon = cellSize / (metersPerDegree * math.Max(math.Cos(midLat*math.Pi/180), 0.01))
+	ans.Rows = int((bounds.MaxLat-bounds.MinLat)/ans.CellLat) + 1
+	ans.Cols = int((bounds.MaxLon-bounds.MinLon)/ans.CellLon) + 1
+
+	type key struct{ row, col int }
+
+	cells := map[key]*HeatmapCell{}
+	ratings := map[key]float64{}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Latitude == 0 && e.Longtitude == 0 {
+			continue
+		}
+
+		k := key{
+			row: min(int((e.Latitude-bounds.MinLat)/ans.CellLat), ans.Rows-1),
+			col: min(int((e.Longtitude-bounds.MinLon)/ans.CellLon), ans.Cols-1),
+		}
+
+		cell, ok := cells[k]
+		if !ok {
+			cell = &HeatmapCell{
+				Row: k.row,
+				Col: k.col,
+				Lat: roundCoord(bounds.MinLat + (float64(k.row)+0.5)*ans.CellLat),
+				Lon: roundCoord(bounds.MinLon + (float64(k.col)+0.5)*ans.CellLon),
+			}
+			cells[k] = cell
+		}
+
+		cell.Count++
+		ans.Places++
+
+		if e.ReviewRating > 0 {
+			cell.Rated++
+			ratings[k] += e.ReviewRating
+		}
+	}
+
+	for k, cell := range cells {
+		if cell.Rated > 0 {
+			cell.AvgRating = math.Round(ratings[k]/float64(cell.Rated)*100) / 100
+		}
+
+		ans.Cells = append(ans.Cells, *cell)
+	}
+
+	sort.Slice(ans.Cells, func(i, j int) bool {
+		if ans.Cells[i].Row != ans.Cells[j].Row {
+			return ans.Cells[i].Row < ans.Cells[j].Row
+		}
+
+		return ans.Cells[i].Col < ans.Cells[j].Col
+	})
+
+	return ans
+}
+
+// roundCoord rounds a coordinate to about a meter.
+func roundCoord(v float64) float64 {
+	return math.Round(v*1e5) / 1e5
+}
+
+// Heatmap returns the heatmap of the results of job id, so far while it
+// runs, in cells of cellSize meters.
+func (s *Service) Heatmap(ctx context.Context, id string, cellSize float64) (Heatmap, error) {
+	entries, partial, err := s.loadResults(ctx, id)
+	if err != nil {
+		return Heatmap{}, err
+	}
+
+	ans := BuildHeatmap(entries, cellSize)
+	ans.Partial = partial
+
+	return ans, nil
+}
+
+func (s *Server) apiHeatmap(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	cellSize := float64(HeatmapDefaultCellSize)
+
+	if v := strings.TrimSpace(r.URL.Query().Get("cell_size")); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < HeatmapMinCellSize || parsed > HeatmapMaxCellSize {
+			renderJSON(w, http.StatusUnprocessableEntity, apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: fmt.Sprintf("cell_size must be between %d and %d meters", HeatmapMinCellSize, HeatmapMaxCellSize),
+			})
+
+			return
+		}
+
+		cellSize = parsed
+	}
+
+	heatmap, err := s.svc.Heatmap(r.Context(), id.String(), cellSize)
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, heatmap)
+}
@@ -0,0 +1,179 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// The history of a place is a snapshot of it for each job that found it,
+// keyed by CID, so that its rating, reviews, hours and status can be
+// followed over time across the runs of a monitoring job.
+
+// PlaceSnapshot is the state of a place when a job found it.
+type PlaceSnapshot struct {
+	JobID string `json:"job_id"`
+	// At is when the job ended.
+	At           time.Time           `json:"at"`
+	Title        string              `json:"title"`
+	ReviewRating float64             `json:"review_rating"`
+	ReviewCount  int                 `json:"review_count"`
+	OpenHours    map[string][]string `json:"open_hours"`
+	Status       string              `json:"status"`
+}
+
+// PlaceHistory is the time series of the snapshots of a place, oldest
+// first.
+type PlaceHistory struct {
+	Cid       string          `json:"cid"`
+	Snapshots []PlaceSnapshot `json:"snapshots"`
+}
+
+// HistoryRepository is implemented by the repositories storing the history
+// of the places.
+type HistoryRepository interface {
+	// AddSnapshots stores the snapshots of the places of a job of tenant by
+	// CID, replacing those of the same job.
+	AddSnapshots(ctx context.Context, tenant string, snapshots map[string]PlaceSnapshot) error
+	// PlaceHistory returns the snapshots of place cid of tenant taken from
+	// from and until to, oldest first. A zero time leaves the range open.
+	PlaceHistory(ctx context.Context, tenant, cid string, from, to time.Time) ([]PlaceSnapshot, error)
+}
+
+// recordHistory stores the snapshots of the places of job, which just ended
+// ok. The repositories without history store nothing, and neither does an
+// encrypted job, whose places would be left in the clear in the database.
+func (s *Service) recordHistory(ctx context.Context, job *Job) {
+	repo, ok := s.repo.(HistoryRepository)
+	if !ok || job.Status != StatusOK || job.Data.Encrypt {
+		return
+	}
+
+	entries, err := s.loadEntries(WithTenant(ctx, job.Tenant), job.ID)
+	if err != nil {
+		log.Printf("history of job %s: %v", job.ID, err)
+
+		return
+	}
+
+	at := time.Now().UTC()
+	snapshots := make(map[string]PlaceSnapshot, len(entries))
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Cid == "" {
+			continue
+		}
+
+		snapshots[e.Cid] = snapshotOf(job, at, e)
+	}
+
+	if len(snapshots) == 0 {
+		return
+	}
+
+	if err := repo.AddSnapshots(ctx, job.Tenant, snapshots); err != nil {
+		log.Printf("history of job %s: %v", job.ID, err)
+	}
+}
+
+// snapshotOf returns the snapshot of place e found by job, taken at.
+func snapshotOf(job *Job, at time.Time, e *gmaps.Entry) PlaceSnapshot {
+	return PlaceSnapshot{
+		JobID:        job.ID,
+		At:           at,
+		Title:        e.Title,
+		ReviewRating: e.ReviewRating,
+		ReviewCount:  e.ReviewCount,
+		OpenHours:    e.OpenHours,
+		Status:       e.Status,
+	}
+}
+
+// PlaceHistory returns the history of place cid between from and to, see
+// HistoryRepository.PlaceHistory, and ErrNotFound when no job found it.
+func (s *Service) PlaceHistory(ctx context.Context, cid string, from, to time.Time) (PlaceHistory, error) {
+	repo, ok := s.repo.(HistoryRepository)
+	if !ok {
+		return PlaceHistory{}, errors.New("place history not supported by repository")
+	}
+
+	snapshots, err := repo.PlaceHistory(ctx, TenantFrom(ctx), cid, from, to)
+	if err != nil {
+		return PlaceHistory{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return PlaceHistory{}, fmt.Errorf("%w: place %s", ErrNotFound, cid)
+	}
+
+	return PlaceHistory{Cid: cid, Snapshots: snapshots}, nil
+}
+
+// validCID reports whether cid is the decimal CID of a place.
+func validCID(cid string) bool {
+	return cid != "" && strings.Trim(cid, "0123456789") == ""
+}
+
+// apiPlaceHistory returns the history of a place, with from and to bounding
+// it as RFC 3339 times or days, both included.
+func (s *Server) apiPlaceHistory(w http.ResponseWriter, r *http.Request) {
+	cid := r.PathValue("cid")
+	if !validCID(cid) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid CID",
+		})
+
+		return
+	}
+
+	var bounds [2]time.Time
+
+	for i, name := range []string{"from", "to"} {
+		v := strings.TrimSpace(r.URL.Query().Get(name))
+		if v == "" {
+			continue
+		}
+
+		t, err := parseSince(v)
+		if err != nil {
+			renderJSON(w, http.StatusUnprocessableEntity, apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: fmt.Sprintf("Invalid %s: use a RFC 3339 time or a day", name),
+			})
+
+			return
+		}
+
+		// a day as upper bound includes the whole day
+		if name == "to" && len(v) == len(time.DateOnly) {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+
+		bounds[i] = t
+	}
+
+	history, err := s.svc.PlaceHistory(r.Context(), cid, bounds[0], bounds[1])
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+	case err != nil:
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+	default:
+		renderJSON(w, http.StatusOK, history)
+	}
+}
@@ -0,0 +1,258 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Web UI speaks the language of the catalogs in static/i18n, one JSON
+// object of messages per language keyed by message ID, picked from the
+// ui_lang cookie, set from ?ui_lang=, or else from Accept-Language. A message
+// missing from a catalog falls back to English. The messages are HTML, like
+// the templates they come from.
+
+const (
+	defaultUILanguage = "en"
+	uiLangParam       = "ui_lang"
+	uiLangCookie      = "ui_lang"
+	i18nDir           = "static/i18n"
+	// languagesTemplate links the pages in the other languages, parsed along
+	// with every page.
+	languagesTemplate = "static/templates/languages.html"
+)
+
+// catalogs maps a language to its messages.
+type catalogs map[string]map[string]string
+
+// loadCatalogs reads the built-in catalogs and those of uiDir/i18n, whose
+// messages replace the built-in ones or add a language.
+func loadCatalogs(uiDir string) (catalogs, error) {
+	ans := catalogs{}
+
+	if err := ans.load(static, i18nDir); err != nil {
+		return nil, err
+	}
+
+	if uiDir != "" {
+		err := ans.load(os.DirFS(filepath.Join(uiDir, "i18n")), ".")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if _, ok := ans[defaultUILanguage]; !ok {
+		return nil, errors.New("missing the English catalog of the web ui")
+	}
+
+	return ans, nil
+}
+
+func (c catalogs) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		if _, err := fs.Stat(fsys, dir); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return fmt.Errorf("catalog %s: %w", file, err)
+		}
+
+		lang := strings.TrimSuffix(path.Base(file), ".json")
+
+		if c[lang] == nil {
+			c[lang] = map[string]string{}
+		}
+
+		for id, msg := range messages {
+			c[lang][id] = msg
+		}
+	}
+
+	return nil
+}
+
+// languages returns the languages of the catalogs, English first.
+func (c catalogs) languages() []string {
+	ans := make([]string, 0, len(c))
+
+	for lang := range c {
+		if lang != defaultUILanguage {
+			ans = append(ans, lang)
+		}
+	}
+
+	sort.Strings(ans)
+
+	return append([]string{defaultUILanguage}, ans...)
+}
+
+// match returns the language of the catalogs best matching an
+// Accept-Language header, "" when none does.
+func (c catalogs) match(header string) string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var prefs []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		// "de-CH" is served by the "de" catalog
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := c[base]; ok && q > 0 {
+			prefs = append(prefs, weighted{lang: base, q: q})
+		}
+	}
+
+	// the first of the most wanted, the header order breaking ties
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	if len(prefs) == 0 {
+		return ""
+	}
+
+	return prefs[0].lang
+}
+
+// Translator renders the messages of a language.
+type Translator struct {
+	lang     string
+	messages map[string]string
+	fallback map[string]string
+	all      []string
+}
+
+// T returns message id formatted with args, as fmt.Sprintf does. String args
+// are escaped, the message itself is trusted HTML. An unknown message
+// renders as its ID.
+func (t Translator) T(id string, args ...any) template.HTML {
+	msg, ok := t.messages[id]
+	if !ok {
+		msg, ok = t.fallback[id]
+	}
+
+	if !ok {
+		return template.HTML(template.HTMLEscapeString(id)) //nolint:gosec // escaped
+	}
+
+	if len(args) == 0 {
+		return template.HTML(msg) //nolint:gosec // from the catalogs
+	}
+
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			args[i] = template.HTMLEscapeString(s)
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(msg, args...)) //nolint:gosec // args escaped
+}
+
+// funcs returns the template functions of t: t, the current language lang
+// and the languages of the catalogs langs.
+func (t Translator) funcs() template.FuncMap {
+	return template.FuncMap{
+		"t":     t.T,
+		"lang":  func() string { return t.lang },
+		"langs": func() []string { return t.all },
+	}
+}
+
+// translator returns the translator of the user of r, remembering the
+// language picked with ?ui_lang= in a cookie.
+func (s *Server) translator(w http.ResponseWriter, r *http.Request) Translator {
+	langs := s.catalogs.languages()
+	lang := ""
+
+	if v := r.URL.Query().Get(uiLangParam); slices.Contains(langs, v) {
+		lang = v
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     uiLangCookie,
+			Value:    v,
+			Path:     "/",
+			Expires:  time.Now().AddDate(1, 0, 0),
+			SameSite: http.SameSiteLaxMode,
+		})
+	} else if c, err := r.Cookie(uiLangCookie); err == nil && slices.Contains(langs, c.Value) {
+		lang = c.Value
+	}
+
+	if lang == "" {
+		lang = s.catalogs.match(r.Header.Get("Accept-Language"))
+	}
+
+	if lang == "" {
+		lang = defaultUILanguage
+	}
+
+	return Translator{
+		lang:     lang,
+		messages: s.catalogs[lang],
+		fallback: s.catalogs[defaultUILanguage],
+		all:      langs,
+	}
+}
+
+// render executes tmpl in the language of the user of r.
+func (s *Server) render(w http.ResponseWriter, r *http.Request, tmpl *template.Template, data any) {
+	s.renderStatus(w, r, http.StatusOK, tmpl, data)
+}
+
+// renderStatus is render answering with the HTTP status code.
+func (s *Server) renderStatus(w http.ResponseWriter, r *http.Request, code int, tmpl *template.Template, data any) {
+	tr := s.translator(w, r)
+
+	page, err := tmpl.Clone()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if code != http.StatusOK {
+		w.WriteHeader(code)
+	}
+
+	_ = page.Funcs(tr.funcs()).Execute(w, data)
+}
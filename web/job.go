@@ -3,7 +3,13 @@ package web
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/gosom/google-maps-scraper/browserpool"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/proxypool"
 )
 
 var jobs []Job
@@ -13,15 +19,21 @@ const (
 	StatusWorking = "working"
 	StatusOK      = "ok"
 	StatusFailed  = "failed"
+	// StatusWaiting is a job waiting for the jobs it depends on to end.
+	StatusWaiting = "waiting"
 )
 
 type SelectParams struct {
 	Status string
 	Limit  int
+	// ClientJobID selects the jobs created with this idempotency key.
+	ClientJobID string
 }
 
 type JobRepository interface {
 	Get(context.Context, string) (Job, error)
+	// Create returns ErrAlreadyExists when the tenant of the job has a job
+	// with its ClientJobID already.
 	Create(context.Context, *Job) error
 	Delete(context.Context, string) error
 	Select(context.Context, SelectParams) ([]Job, error)
@@ -34,6 +46,91 @@ type Job struct {
 	Date   time.Time
 	Status string
 	Data   JobData
+	Stats  JobStats
+	// Tenant is the client account owning the job, "" for the default
+	// tenant.
+	Tenant string `json:",omitempty"`
+	// ClientJobID is the idempotency key the job was created with through
+	// the REST API: creating a job again with the same key returns this one.
+	ClientJobID string `json:",omitempty"`
+}
+
+// JobStats holds what the scraper recorded while running a job.
+type JobStats struct {
+	FetchErrors gmaps.FetchErrorReport `json:"fetch_errors"`
+	Bandwidth   gmaps.BandwidthReport  `json:"bandwidth"`
+	Sessions    gmaps.SessionReport    `json:"sessions"`
+	Browsers    browserpool.Stats      `json:"browsers"`
+	Usage       JobUsage               `json:"usage"`
+	// Keywords is the outcome of each seed keyword, in input order.
+	Keywords []gmaps.KeywordReport `json:"keywords,omitempty"`
+	// Timings are the durations of the steps of the places and of their
+	// email extraction.
+	Timings []gmaps.StepTiming `json:"timings,omitempty"`
+	// Skip is the work left undone by a job skipping fields, and the time
+	// it saved.
+	Skip *gmaps.SkipReport `json:"skip,omitempty"`
+	// DependencyFailed is the job this one depended on that failed or was
+	// deleted, failing it.
+	DependencyFailed string `json:"dependency_failed,omitempty"`
+	// SealError is why the results of an encrypted job could not be
+	// sealed, failing it: the results are removed rather than kept in the
+	// clear.
+	SealError string `json:"seal_error,omitempty"`
+	// Failures are the place pages that failed after all their retries,
+	// which RetryFailures visits again.
+	Failures []gmaps.PlaceFailure `json:"failures,omitempty"`
+	// Retry is set while the job visits its failures again.
+	Retry *JobRetry `json:"retry,omitempty"`
+	// Phases are set on the jobs searching emails, see JobPhases.
+	Phases *JobPhases `json:"phases,omitempty"`
+	// Email is the progress of the email phase, set on the running jobs of
+	// this host when they are read through the API or the web UI, and on
+	// the jobs searching emails when they end.
+	Email *gmaps.EmailEstimate `json:"email,omitempty"`
+	// Throttle is set when the job was slowed down by Google pushing back.
+	Throttle *gmaps.ThrottleReport `json:"throttle,omitempty"`
+	// FastLane is set when the job was started in the fast lane, while a
+	// another job was running.
+	FastLane bool `json:"fast_lane,omitempty"`
+	// Worker is set while a remote worker holds the job.
+	Worker *WorkerLease `json:"worker,omitempty"`
+	// Env is the configuration the job ran with, set when it starts.
+	Env *JobEnv `json:"env,omitempty"`
+}
+
+// KeywordIssues returns the keywords of the job that found nothing: blocked,
+// failed or without results.
+func (s JobStats) KeywordIssues() []gmaps.KeywordReport {
+	var ans []gmaps.KeywordReport
+
+	for _, k := range s.Keywords {
+		switch k.Status {
+		case gmaps.KeywordBlocked, gmaps.KeywordFailed, gmaps.KeywordZeroResults:
+			ans = append(ans, k)
+		}
+	}
+
+	return ans
+}
+
+// JobUsage records what a job consumed against its limits.
+type JobUsage struct {
+	Places       int `json:"places"`
+	EmailFetches int `json:"email_fetches"`
+	// EmailsVerified counts the emails checked by the email verification,
+	// BrowserSeconds the time the browsers of the job were open times how
+	// many there were. Both are billed, see Service.Usage.
+	EmailsVerified int   `json:"emails_verified,omitempty"`
+	BrowserSeconds int64 `json:"browser_seconds,omitempty"`
+	// MaxPlaces and MaxEmailFetches are the limits the job runs with: its
+	// own, lowered to what is left of the monthly budgets when it was
+	// claimed. 0 means no limit.
+	MaxPlaces       int `json:"max_places,omitempty"`
+	MaxEmailFetches int `json:"max_email_fetches,omitempty"`
+	// Exceeded names the monthly budget (BudgetPlaces, BudgetEmailFetches)
+	// that was exhausted when the job was claimed, failing it.
+	Exceeded string `json:"exceeded,omitempty"`
 }
 
 func (j *Job) Validate() error {
@@ -73,10 +170,105 @@ type JobData struct {
 	ExtraReviews bool          `json:"extra_reviews"`
 	MaxTime      time.Duration `json:"max_time"`
 	Proxies      []string      `json:"proxies"`
+	// ExcludeServiceArea drops service-area businesses (no public address).
+	ExcludeServiceArea bool `json:"exclude_service_area"`
+	// VerifyEmails classifies the extracted emails (deliverable, catch_all,
+	// disposable, invalid).
+	VerifyEmails bool `json:"verify_emails"`
+	// OSMCheck cross-checks the places against OpenStreetMap, see
+	// gmaps.OSMChecker.
+	OSMCheck bool `json:"osm_check,omitempty"`
+	// RegistrationLookup reads the VAT and company register numbers of the
+	// places from their website, see gmaps.RegistrationEnricher.
+	RegistrationLookup bool `json:"registration_lookup,omitempty"`
+	// ValidationRules are run on every place, reported in its validation
+	// field, see gmaps.Validator. The exports can then leave the failing
+	// places out, or take them alone as a quarantine.
+	ValidationRules []string `json:"validation_rules,omitempty"`
+	// Encrypt seals the result files at rest once the job ends, see
+	// WithResultsKey.
+	Encrypt bool `json:"encrypt,omitempty"`
+	// Watchlist is the watchlist the job refreshes, see Service.RefreshWatchlists.
+	Watchlist string `json:"watchlist,omitempty"`
+	// EntryWebhook receives the places in batches while the job runs, see
+	// postprocess.StreamWriter.
+	EntryWebhook string `json:"entry_webhook,omitempty"`
+	// ProxyCountry and ProxyCity ask the proxy provider for endpoints
+	// exiting from that location (e.g. "de", "Berlin").
+	ProxyCountry string `json:"proxy_country"`
+	ProxyCity    string `json:"proxy_city"`
+	// CustomExtractors read extra fields from the place pages, returned in
+	// the custom_fields of the entries and as extra CSV columns.
+	CustomExtractors []gmaps.CustomExtractor `json:"custom_extractors,omitempty"`
+	// MaxPlaces stops the job once that many places are scraped and
+	// MaxEmailFetches caps the website fetches of the email extraction.
+	// 0 means no limit.
+	MaxPlaces       int `json:"max_places"`
+	MaxEmailFetches int `json:"max_email_fetches"`
+	// AnonymizeReviewers strips the reviewer data from the reviews, see
+	// gmaps.NewReviewerAnonymizer: "hash", "drop" or empty to keep it.
+	AnonymizeReviewers string `json:"anonymize_reviewers,omitempty"`
+	// ReviewLangs keeps only the reviews detected in one of these ISO 639-1
+	// languages, all of them when empty.
+	ReviewLangs []string `json:"review_langs,omitempty"`
+	// ExcludeDomains and ExcludePlaces (names or CIDs) are the places the
+	// job leaves alone, see gmaps.Exclusions.
+	ExcludeDomains []string `json:"exclude_domains,omitempty"`
+	ExcludePlaces  []string `json:"exclude_places,omitempty"`
+	// IncludeCategories and ExcludeCategories keep the places of some main
+	// categories only, or drop those of others, before visiting them when
+	// the results list tells, see gmaps.CategoryFilter.
+	IncludeCategories []string `json:"include_categories,omitempty"`
+	ExcludeCategories []string `json:"exclude_categories,omitempty"`
+	// MinRating, MinReviews and MaxReviews keep the places within that
+	// rating and review count, before visiting them when the results list
+	// tells, see gmaps.RatingFilter. 0 means no bound.
+	MinRating  float64 `json:"min_rating,omitempty"`
+	MinReviews int     `json:"min_reviews,omitempty"`
+	MaxReviews int     `json:"max_reviews,omitempty"`
+	// RequireWebsite and RequirePhone drop the places without a website or
+	// a phone number, before their email extraction.
+	RequireWebsite bool `json:"require_website,omitempty"`
+	RequirePhone   bool `json:"require_phone,omitempty"`
+	// Profile names the settings profile the job runs with, the global
+	// settings when empty.
+	Profile string `json:"profile,omitempty"`
+	// Territories are the named polygons, a GeoJSON FeatureCollection, the
+	// exports assign the places of the job to, replacing those of the
+	// settings.
+	Territories gmaps.Territories `json:"territories,omitempty"`
+	// DependsOn are the jobs this one runs after: it waits until they all
+	// end ok, and fails when one of them fails.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// InputFrom feeds the results of DependsOn to the job once they end:
+	// InputPlaces adds the place pages they found to Places.
+	InputFrom string `json:"input_from,omitempty"`
+	// Places are place pages the job visits instead of searching keywords.
+	Places []string `json:"places,omitempty"`
+	// SkipReviews, SkipImages and SkipAbout leave those fields out of the
+	// places for a leaner run, see gmaps.SkipFields.
+	SkipReviews bool `json:"skip_reviews,omitempty"`
+	SkipImages  bool `json:"skip_images,omitempty"`
+	SkipAbout   bool `json:"skip_about,omitempty"`
+}
+
+// Skip returns the fields of the places the job leaves out.
+func (d *JobData) Skip() gmaps.SkipFields {
+	return gmaps.SkipFields{Reviews: d.SkipReviews, Images: d.SkipImages, About: d.SkipAbout}
+}
+
+// RequiredContacts returns the contacts the places of the job must list.
+func (d *JobData) RequiredContacts() gmaps.RequiredContacts {
+	return gmaps.RequiredContacts{Website: d.RequireWebsite, Phone: d.RequirePhone}
+}
+
+// ProxyGeo returns the proxy exit location requested by the job.
+func (d *JobData) ProxyGeo() proxypool.Geo {
+	return proxypool.Geo{Country: d.ProxyCountry, City: d.ProxyCity}
 }
 
 func (d *JobData) Validate() error {
-	if len(d.Keywords) == 0 {
+	if len(d.Keywords) == 0 && len(d.Places) == 0 && d.InputFrom == "" {
 		return errors.New("missing keywords")
 	}
 
@@ -100,5 +292,63 @@ func (d *JobData) Validate() error {
 		return errors.New("missing geo coordinates")
 	}
 
+	if err := d.ProxyGeo().Validate(); err != nil {
+		return err
+	}
+
+	if err := gmaps.ValidateCustomExtractors(d.CustomExtractors); err != nil {
+		return err
+	}
+
+	if d.ExtraReviews && d.SkipReviews {
+		return errors.New("extra reviews and skip reviews exclude each other")
+	}
+
+	if d.MaxPlaces < 0 || d.MaxEmailFetches < 0 {
+		return errors.New("limits cannot be negative")
+	}
+
+	if _, err := gmaps.ParseValidationRules(strings.Join(d.ValidationRules, ",")); err != nil {
+		return err
+	}
+
+	if d.MinRating < 0 || d.MinRating > gmaps.MaxRating {
+		return fmt.Errorf("min rating must be between 0 and %d", gmaps.MaxRating)
+	}
+
+	if d.MinReviews < 0 || d.MaxReviews < 0 {
+		return errors.New("review counts cannot be negative")
+	}
+
+	if d.MaxReviews > 0 && d.MaxReviews < d.MinReviews {
+		return errors.New("max reviews is below min reviews")
+	}
+
+	if err := gmaps.ValidateReviewerAnonymization(d.AnonymizeReviewers); err != nil {
+		return err
+	}
+
+	if err := gmaps.ValidateLanguages(d.ReviewLangs); err != nil {
+		return err
+	}
+
+	if _, err := gmaps.NewExclusions(d.ExcludeDomains, d.ExcludePlaces); err != nil {
+		return err
+	}
+
+	if err := validateWebhookURL("entry webhook", d.EntryWebhook); err != nil {
+		return err
+	}
+
+	if d.Profile != "" {
+		if err := ValidateProfileName(d.Profile); err != nil {
+			return err
+		}
+	}
+
+	if err := d.validateDependencies(); err != nil {
+		return err
+	}
+
 	return nil
 }
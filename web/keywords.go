@@ -0,0 +1,395 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxExpandedKeywords caps the size of a categories × locations expansion so a
+// pasted spreadsheet column can't silently create a job with millions of seeds.
+const MaxExpandedKeywords = 10000
+
+var ErrTooManyKeywords = errors.New("too many keywords")
+
+// ExpandKeywords returns the cross product of categories and locations as
+// "<category> in <location>" search queries. Blank items are skipped and
+// duplicates are dropped (case-insensitive) while preserving input order.
+// When locations is empty the categories are returned as they are.
+func ExpandKeywords(categories, locations []string) []string {
+	categories = cleanLines(categories)
+	locations = cleanLines(locations)
+
+	if len(categories) == 0 {
+		return nil
+	}
+
+	if len(locations) == 0 {
+		return categories
+	}
+
+	ans := make([]string, 0, len(categories)*len(locations))
+	seen := make(map[string]struct{}, len(categories)*len(locations))
+
+	for _, c := range categories {
+		for _, l := range locations {
+			kw := c + " in " + l
+
+			key := strings.ToLower(kw)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			ans = append(ans, kw)
+		}
+	}
+
+	return ans
+}
+
+var (
+	templateVarRe  = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+	templateNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ExpandTemplates resolves the {{variable}} placeholders of keywords with
+// every combination of the values of vars, so "{{category}} in {{city}}"
+// becomes one keyword per category and city. Keywords without placeholders
+// are kept as they are. Duplicates are dropped (case-insensitive) while
+// preserving input order. It fails on undefined or empty variables and when
+// the expansion exceeds MaxExpandedKeywords.
+func ExpandTemplates(keywords []string, vars map[string][]string) ([]string, error) {
+	var ans []string
+
+	seen := make(map[string]struct{}, len(keywords))
+
+	for _, kw := range cleanLines(keywords) {
+		var names []string
+
+		for _, m := range templateVarRe.FindAllStringSubmatch(kw, -1) {
+			if !slices.Contains(names, m[1]) {
+				names = append(names, m[1])
+			}
+		}
+
+		if rest := templateVarRe.ReplaceAllString(kw, ""); strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+			return nil, fmt.Errorf("invalid placeholder in keyword %q", kw)
+		}
+
+		count := 1
+
+		for _, name := range names {
+			values, ok := vars[name]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable %q in keyword %q", name, kw)
+			}
+
+			if len(cleanLines(values)) == 0 {
+				return nil, fmt.Errorf("variable %q has no values", name)
+			}
+
+			count *= len(cleanLines(values))
+			if len(ans)+count > MaxExpandedKeywords {
+				return nil, fmt.Errorf("%w: more than %d", ErrTooManyKeywords, MaxExpandedKeywords)
+			}
+		}
+
+		for _, expanded := range expandTemplate(kw, names, vars) {
+			key := strings.ToLower(expanded)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			ans = append(ans, expanded)
+		}
+	}
+
+	return ans, nil
+}
+
+// expandTemplate returns kw with the variables names replaced by each
+// combination of their values, the first variable varying slowest.
+func expandTemplate(kw string, names []string, vars map[string][]string) []string {
+	ans := []string{kw}
+
+	for _, name := range names {
+		values := cleanLines(vars[name])
+		next := make([]string, 0, len(ans)*len(values))
+
+		for _, partial := range ans {
+			for _, v := range values {
+				next = append(next, templateVarRe.ReplaceAllStringFunc(partial, func(m string) string {
+					if templateVarRe.FindStringSubmatch(m)[1] != name {
+						return m
+					}
+
+					return v
+				}))
+			}
+		}
+
+		ans = next
+	}
+
+	return ans
+}
+
+// ParseTemplateVariables parses the variables of the scrape form, written
+// one value per line as "name = value". A name repeated on several lines
+// collects all their values.
+func ParseTemplateVariables(s string) (map[string][]string, error) {
+	vars := make(map[string][]string)
+
+	for _, line := range splitLines(s) {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid variable %q: expected name = value", line)
+		}
+
+		name = strings.TrimSpace(name)
+		if !templateNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+
+		vars[name] = append(vars[name], strings.TrimSpace(value))
+	}
+
+	return vars, nil
+}
+
+// splitLines splits a textarea value into trimmed, non-empty lines.
+func splitLines(s string) []string {
+	return cleanLines(strings.Split(s, "\n"))
+}
+
+func cleanLines(items []string) []string {
+	ans := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key := strings.ToLower(item)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		ans = append(ans, item)
+	}
+
+	return ans
+}
+
+type apiExpandKeywordsRequest struct {
+	Name       string              `json:"name"`
+	Categories []string            `json:"categories"`
+	Locations  []string            `json:"locations"`
+	Variables  map[string][]string `json:"variables"`
+	Create     bool                `json:"create"`
+	JobData
+}
+
+type apiExpandKeywordsResponse struct {
+	Count    int      `json:"count"`
+	Keywords []string `json:"keywords"`
+	ID       string   `json:"id,omitempty"`
+}
+
+// apiExpandKeywords previews the categories × locations expansion and, when
+// create is set, submits a single job with the expanded keywords.
+func (s *Server) apiExpandKeywords(w http.ResponseWriter, r *http.Request) {
+	var req apiExpandKeywordsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	keywords, err := ExpandTemplates(req.Keywords, req.Variables)
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	keywords = append(keywords, ExpandKeywords(req.Categories, req.Locations)...)
+	if len(keywords) > MaxExpandedKeywords {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("%s: %d (max %d)", ErrTooManyKeywords, len(keywords), MaxExpandedKeywords),
+		})
+
+		return
+	}
+
+	ans := apiExpandKeywordsResponse{
+		Count:    len(keywords),
+		Keywords: keywords,
+	}
+
+	if !req.Create {
+		renderJSON(w, http.StatusOK, ans)
+
+		return
+	}
+
+	newJob := Job{
+		ID:     uuid.New().String(),
+		Name:   req.Name,
+		Date:   time.Now().UTC(),
+		Status: StatusPending,
+		Data:   req.JobData,
+	}
+
+	newJob.Data.Keywords = keywords
+
+	if newJob.Name == "" && len(keywords) > 0 {
+		newJob.Name = fmt.Sprintf("%s (+%d)", keywords[0], len(keywords)-1)
+	}
+
+	// convert to seconds
+	newJob.Data.MaxTime *= time.Second
+
+	if err := s.svc.ApplyProfile(r.Context(), &newJob.Data); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := newJob.Validate(); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	err = s.svc.Create(r.Context(), &newJob)
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	ans.ID = newJob.ID
+
+	renderJSON(w, http.StatusCreated, ans)
+}
+
+type keywordsPreviewData struct {
+	Count    int
+	Sample   []string
+	Overflow int
+	TooMany  bool
+	Max      int
+	Error    string
+}
+
+// keywordsPreview renders the keyword builder preview shown under the
+// categories/locations textareas of the scrape form.
+func (s *Server) keywordsPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	const sampleSize = 10
+
+	data := keywordsPreviewData{Max: MaxExpandedKeywords}
+
+	keywords, err := previewKeywords(r.Form)
+
+	switch {
+	case errors.Is(err, ErrTooManyKeywords):
+		data.TooMany = true
+	case err != nil:
+		data.Error = err.Error()
+	default:
+		data.Count = len(keywords)
+		data.Sample = keywords[:min(sampleSize, len(keywords))]
+		data.TooMany = len(keywords) > MaxExpandedKeywords
+	}
+
+	data.Overflow = data.Count - len(data.Sample)
+
+	tmpl, ok := s.tmpl["static/templates/keywords_preview.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	s.render(w, r, tmpl, data)
+}
+
+// previewKeywords expands the keyword templates and the categories ×
+// locations of the scrape form. The plain keywords are left out, the form
+// shows them already.
+func previewKeywords(form url.Values) ([]string, error) {
+	vars, err := ParseTemplateVariables(form.Get("variables"))
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []string
+
+	for _, kw := range splitLines(form.Get("keywords")) {
+		if strings.Contains(kw, "{{") {
+			templates = append(templates, kw)
+		}
+	}
+
+	keywords, err := ExpandTemplates(templates, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(keywords, ExpandKeywords(splitLines(form.Get("categories")), splitLines(form.Get("locations")))...), nil
+}
@@ -0,0 +1,251 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Timeouts of a lookup, kept short for a user waiting on a button.
+const (
+	LookupDefaultTimeout = 30 * time.Second
+	LookupMaxTimeout     = 60 * time.Second
+)
+
+// lookupCacheHeader tells whether a lookup was served from the cache.
+const lookupCacheHeader = "X-Cache"
+
+var (
+	// ErrLookupNotFound is returned when a lookup finds no place.
+	ErrLookupNotFound = errors.New("no place found")
+	// ErrLookupTimeout is returned when a lookup finds no place within its
+	// timeout.
+	ErrLookupTimeout = errors.New("lookup timed out")
+)
+
+// LookupRequest is a single place to scrape while the request waits, given
+// by a search query or by the link of its page.
+type LookupRequest struct {
+	// Query is searched and its first place scraped; a link to a place
+	// page is visited instead.
+	Query string
+	// Timeout is in seconds, LookupDefaultTimeout when 0.
+	Timeout int
+	// Lang is the language of the settings when empty.
+	Lang    string
+	Email   bool
+	Profile string
+	// Fresh scrapes the place even when it is cached.
+	Fresh bool
+}
+
+// LookupJob returns the job looking req up, with the defaults of its
+// profile. The job is not stored.
+func (s *Service) LookupJob(ctx context.Context, req *LookupRequest) (*Job, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, errors.New("missing query")
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Second
+
+	switch {
+	case timeout < 0 || timeout > LookupMaxTimeout:
+		return nil, fmt.Errorf("timeout must be between 1 and %d seconds", int(LookupMaxTimeout.Seconds()))
+	case timeout == 0:
+		timeout = LookupDefaultTimeout
+	}
+
+	job := Job{
+		ID:     uuid.New().String(),
+		Name:   "lookup: " + query,
+		Date:   time.Now().UTC(),
+		Status: StatusWorking,
+		Data: JobData{
+			Lang:    req.Lang,
+			Email:   req.Email,
+			Profile: req.Profile,
+		},
+		Tenant: TenantFrom(ctx),
+	}
+
+	if err := s.ApplyProfile(ctx, &job.Data); err != nil {
+		return nil, err
+	}
+
+	if job.Data.Lang == "" {
+		settings, err := s.GetSettings(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		job.Data.Lang = settings.Language
+	}
+
+	if isPlaceLink(query) {
+		job.Data.Places = []string{query}
+	} else {
+		job.Data.Keywords = []string{query}
+		job.Data.Zoom = 15
+	}
+
+	job.Data.Depth = 1
+	job.Data.MaxTime = timeout
+	job.Data.MaxPlaces = 1
+	job.Data.MaxEmailFetches = 1
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// googleHost matches the hosts of Google, google.com, www.google.it or
+// maps.google.co.uk, the first label of the host in its first group.
+var googleHost = regexp.MustCompile(`^(?:(www|maps)\.)?google\.(?:com|[a-z]{2}|co\.[a-z]{2}|com\.[a-z]{2})$`)
+
+// isPlaceLink reports whether s is a link to a Google Maps page, one of
+// google.<tld>/maps, maps.google.<tld> or maps.app.goo.gl, to visit rather
+// than search. Only those are visited, so that a job never makes the
+// browser open an address of the network of the server.
+func isPlaceLink(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User != nil || u.Port() != "" {
+		return false
+	}
+
+	host := strings.ToLower(u.Host)
+	if host == "maps.app.goo.gl" {
+		return true
+	}
+
+	m := googleHost.FindStringSubmatch(host)
+
+	switch {
+	case m == nil:
+		return false
+	case m[1] == "maps":
+		return true
+	default:
+		return u.Path == "/maps" || strings.HasPrefix(u.Path, "/maps/")
+	}
+}
+
+// Lookup runs job, see LookupJob, on the sandbox and returns its place.
+// Unless fresh is set, a place looked up within the TTL of the lookup cache
+// is served from it, and cached is set.
+func (s *Service) Lookup(ctx context.Context, job *Job, fresh bool) (entry *gmaps.Entry, cached bool, err error) {
+	if !fresh {
+		if entry, ok := s.lookups.get(job); ok {
+			return entry, true, nil
+		}
+	}
+
+	res, err := s.RunSandbox(ctx, job)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case len(res.Places) > 0:
+		s.lookups.put(job, res.Places[0])
+
+		return res.Places[0], false, nil
+	case res.TimedOut:
+		return nil, false, ErrLookupTimeout
+	case res.Error != "":
+		return nil, false, errors.New(res.Error)
+	default:
+		return nil, false, ErrLookupNotFound
+	}
+}
+
+// lookupStatus returns the HTTP status of a lookup error.
+func lookupStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrLookupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrLookupTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return sandboxStatus(err)
+	}
+}
+
+func (s *Server) apiLookup(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	req := LookupRequest{
+		Query:   q.Get("query"),
+		Lang:    q.Get("lang"),
+		Email:   q.Get("email") == "true",
+		Profile: q.Get("profile"),
+		Fresh:   q.Get("fresh") == "true",
+	}
+
+	if req.Query == "" {
+		req.Query = q.Get("url")
+	}
+
+	if v := q.Get("timeout"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			ans := apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "invalid timeout",
+			}
+
+			renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+			return
+		}
+
+		req.Timeout = n
+	}
+
+	job, err := s.svc.LookupJob(r.Context(), &req)
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	extendWriteDeadline(w, job)
+
+	entry, cached, err := s.svc.Lookup(r.Context(), job, req.Fresh)
+	if err != nil {
+		code := lookupStatus(err)
+
+		ans := apiError{
+			Code:    code,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, code, ans)
+
+		return
+	}
+
+	if cached {
+		w.Header().Set(lookupCacheHeader, "HIT")
+	} else {
+		w.Header().Set(lookupCacheHeader, "MISS")
+	}
+
+	renderJSON(w, http.StatusOK, entry)
+}
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// maxLookupCache bounds the places and queries the lookup cache holds.
+const maxLookupCache = 10000
+
+// lookupCache keeps the looked up places by tenant, CID, language and the
+// options shaping the place, so that the same business asked again within
+// the TTL is not scraped again. The queries and the links without a CID are
+// aliases of the CID they found. It is safe for concurrent use; a nil
+// *lookupCache caches nothing.
+type lookupCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	places  map[string]cachedPlace
+	aliases map[string]string
+}
+
+type cachedPlace struct {
+	// entry is the JSON of the place, decoded anew for each lookup so that
+	// no caller changes the place of another.
+	entry []byte
+	// email is set when the emails of the place were extracted
+	email  bool
+	stored time.Time
+}
+
+// WithLookupCache serves the places looked up again within ttl from memory
+// instead of scraping them. A ttl of 0 caches nothing.
+func WithLookupCache(ttl time.Duration) ServiceOption {
+	return func(s *Service) {
+		if ttl <= 0 {
+			s.lookups = nil
+
+			return
+		}
+
+		s.lookups = &lookupCache{
+			ttl:     ttl,
+			places:  map[string]cachedPlace{},
+			aliases: map[string]string{},
+		}
+	}
+}
+
+// lookupKeys returns the key of the CID of the place job looks up, when its
+// link tells it, and the key of its query or link otherwise.
+func lookupKeys(job *Job) (cidKey, aliasKey string) {
+	scope := lookupScope(job)
+
+	if len(job.Data.Places) > 0 {
+		link := job.Data.Places[0]
+
+		if cid := gmaps.ParsePlaceIdentity(link).CID; cid != "" {
+			return scope + "/" + cid, ""
+		}
+
+		return "", scope + "/" + link
+	}
+
+	query := strings.Join(strings.Fields(strings.ToLower(job.Data.Keywords[0])), " ")
+
+	return "", scope + "/q/" + query
+}
+
+// lookupScope returns the part of the keys of job shared by the lookups
+// that get the same place: its tenant, its language and a digest of the
+// options of its profile changing what a place holds.
+func lookupScope(job *Job) string {
+	langs := make([]string, 0, len(job.Data.ReviewLangs))
+	for _, l := range job.Data.ReviewLangs {
+		langs = append(langs, strings.ToLower(l))
+	}
+
+	slices.Sort(langs)
+
+	options, _ := json.Marshal(struct {
+		AnonymizeReviewers string
+		CustomExtractors   []gmaps.CustomExtractor
+		ReviewLangs        []string
+		Skip               gmaps.SkipFields
+	}{job.Data.AnonymizeReviewers, job.Data.CustomExtractors, langs, job.Data.Skip()})
+
+	sum := sha256.Sum256(options)
+
+	return job.Tenant + "/" + strings.ToLower(job.Data.Lang) + "/" + hex.EncodeToString(sum[:8])
+}
+
+// get returns a copy of the place job looks up, when it is cached and
+// fresh, with its emails when job extracts them.
+func (c *lookupCache) get(job *Job) (*gmaps.Entry, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	cidKey, aliasKey := lookupKeys(job)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cidKey == "" {
+		cidKey = c.aliases[aliasKey]
+	}
+
+	p, ok := c.places[cidKey]
+	if !ok || time.Since(p.stored) > c.ttl || job.Data.Email && !p.email {
+		return nil, false
+	}
+
+	var entry gmaps.Entry
+	if err := json.Unmarshal(p.entry, &entry); err != nil {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// put caches entry, the place job looked up.
+func (c *lookupCache) put(job *Job, entry *gmaps.Entry) {
+	if c == nil || entry.Cid == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	_, aliasKey := lookupKeys(job)
+	cidKey := lookupScope(job) + "/" + entry.Cid
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.places)+len(c.aliases) >= maxLookupCache {
+		c.purge()
+	}
+
+	if len(c.places)+len(c.aliases) >= maxLookupCache {
+		return
+	}
+
+	c.places[cidKey] = cachedPlace{entry: data, email: job.Data.Email, stored: time.Now()}
+
+	if aliasKey != "" {
+		c.aliases[aliasKey] = cidKey
+	}
+}
+
+// purge drops the expired places and the aliases left without a place.
+func (c *lookupCache) purge() {
+	for key, p := range c.places {
+		if time.Since(p.stored) > c.ttl {
+			delete(c.places, key)
+		}
+	}
+
+	for alias, key := range c.aliases {
+		if _, ok := c.places[key]; !ok {
+			delete(c.aliases, alias)
+		}
+	}
+}
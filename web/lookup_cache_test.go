@@ -0,0 +1,54 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func TestLookupCacheByTenant(t *testing.T) {
+	svc := &Service{}
+	WithLookupCache(time.Hour)(svc)
+
+	lookup := func(tenant, query string) *Job {
+		job := &Job{Tenant: tenant, Data: JobData{Lang: "en"}}
+		if gmaps.ParsePlaceIdentity(query).CID != "" {
+			job.Data.Places = []string{query}
+		} else {
+			job.Data.Keywords = []string{query}
+		}
+
+		return job
+	}
+
+	svc.lookups.put(lookup("acme", "caffe sport roma"), &gmaps.Entry{Cid: "1234567890", Title: "Caffe Sport"})
+
+	link := "https://www.google.com/maps?cid=1234567890"
+
+	tests := []struct {
+		name   string
+		tenant string
+		query  string
+		hit    bool
+	}{
+		{"same tenant, same query", "acme", "Caffe  Sport Roma", true},
+		{"other tenant, same query", "globex", "caffe sport roma", false},
+		{"default tenant, same query", "", "caffe sport roma", false},
+		{"same tenant, link of the place", "acme", link, true},
+		{"other tenant, link of the place", "globex", link, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := svc.lookups.get(lookup(tt.tenant, tt.query))
+			require.Equal(t, tt.hit, ok)
+
+			if tt.hit {
+				require.Equal(t, "Caffe Sport", entry.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestSuffix names the manifest of the result files of a job, next to
+// them.
+const manifestSuffix = ".manifest.json"
+
+// ManifestSignatureHeader carries the signature of a manifest, the base64
+// Ed25519 signature of its exact bytes.
+const ManifestSignatureHeader = "X-Manifest-Signature"
+
+// Manifest describes the result files of a finished job, so that a client
+// can check the files it was handed are complete and unchanged.
+type Manifest struct {
+	JobID   string    `json:"job_id"`
+	JobName string    `json:"job_name"`
+	Status  string    `json:"status"`
+	JobDate time.Time `json:"job_date"`
+	// Date is when the manifest was written, again when the results are
+	// edited.
+	Date time.Time `json:"date"`
+	// Params are the parameters of the job, without its proxies and entry
+	// webhook.
+	Params    JobData            `json:"params"`
+	Artifacts []ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact is a result file of a job.
+type ManifestArtifact struct {
+	Name     string    `json:"name"`
+	Format   string    `json:"format"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256"`
+	// Rows counts the places in the file.
+	Rows int `json:"rows"`
+}
+
+// WithManifestKey signs the manifests of the jobs with key.
+func WithManifestKey(key ed25519.PrivateKey) ServiceOption {
+	return func(s *Service) {
+		s.manifestKey = key
+	}
+}
+
+// LoadManifestKey reads an Ed25519 private key from a PEM file in PKCS #8,
+// as written by openssl genpkey -algorithm ed25519.
+func LoadManifestKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	ans, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+
+	return ans, nil
+}
+
+// ManifestPublicKey returns the public key verifying the manifests as PEM,
+// ErrNotFound when they are not signed.
+func (s *Service) ManifestPublicKey() ([]byte, error) {
+	if s.manifestKey == nil {
+		return nil, fmt.Errorf("%w: the manifests are not signed", ErrNotFound)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(s.manifestKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// manifestEnded writes the manifest of job, which just ended ok.
+func (s *Service) manifestEnded(ctx context.Context, job *Job) {
+	if job.Status != StatusOK {
+		return
+	}
+
+	if _, err := s.writeManifest(WithTenant(ctx, job.Tenant), job); err != nil {
+		log.Printf("manifest of job %s: %v", job.ID, err)
+	}
+}
+
+// Manifest returns the manifest of job id as written, with its signature
+// when the manifests are signed. The manifest is written again when the
+// result files changed since.
+func (s *Service) Manifest(ctx context.Context, id string) (data []byte, signature string, err error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		// the repositories differ in how they tell a missing job
+		return nil, "", fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if !isFinished(job.Status) {
+		return nil, "", fmt.Errorf("%w: job %s is %s", ErrJobNotFinished, id, job.Status)
+	}
+
+	data, err = os.ReadFile(filepath.Join(s.folder(ctx), job.ID+manifestSuffix))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+
+	if err != nil || s.manifestStale(ctx, data) {
+		data, err = s.writeManifest(ctx, &job)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if s.manifestKey != nil {
+		signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.manifestKey, data))
+	}
+
+	return data, signature, nil
+}
+
+// manifestStale tells whether the result files described by the manifest
+// data changed since it was written. The sizes are of the decrypted files,
+// so the modification times tell.
+func (s *Service) manifestStale(ctx context.Context, data []byte) bool {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return true
+	}
+
+	for _, a := range m.Artifacts {
+		info, err := os.Stat(filepath.Join(s.folder(ctx), a.Name))
+		if err != nil || !info.ModTime().Equal(a.Modified) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeManifest writes the manifest of the result files of job in the
+// folder of the tenant of ctx, and returns it.
+func (s *Service) writeManifest(ctx context.Context, job *Job) ([]byte, error) {
+	params := job.Data
+	params.Proxies = nil
+	params.EntryWebhook = ""
+
+	m := Manifest{
+		JobID:     job.ID,
+		JobName:   job.Name,
+		Status:    job.Status,
+		JobDate:   job.Date,
+		Date:      time.Now().UTC(),
+		Params:    params,
+		Artifacts: []ManifestArtifact{},
+	}
+
+	for _, format := range ResultFormats {
+		a, err := s.manifestArtifact(filepath.Join(s.folder(ctx), job.ID+"."+format), format)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.Artifacts = append(m.Artifacts, a)
+	}
+
+	if len(m.Artifacts) == 0 {
+		return nil, fmt.Errorf("%w: no result files for job %s", ErrNotFound, job.ID)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	data = append(data, '\n')
+
+	err = WriteFileAtomic(filepath.Join(s.folder(ctx), job.ID+manifestSuffix), func(w io.Writer) error {
+		_, err := w.Write(data)
+
+		return err
+	})
+
+	return data, err
+}
+
+// manifestArtifact hashes the result file at path and counts its places.
+// A sealed file is described as downloaded, decrypted.
+func (s *Service) manifestArtifact(path, format string) (ManifestArtifact, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ManifestArtifact{}, err
+	}
+
+	body, err := s.readResultFile(path)
+	if err != nil {
+		return ManifestArtifact{}, err
+	}
+
+	sum := sha256.Sum256(body)
+
+	ans := ManifestArtifact{
+		Name:     filepath.Base(path),
+		Format:   format,
+		Size:     int64(len(body)),
+		Modified: info.ModTime(),
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+
+	switch format {
+	case "csv":
+		r := csv.NewReader(bytes.NewReader(body))
+		r.FieldsPerRecord = -1
+
+		records, err := r.ReadAll()
+		if err != nil {
+			return ManifestArtifact{}, fmt.Errorf("%s: %w", ans.Name, err)
+		}
+
+		// the header row
+		ans.Rows = max(len(records)-1, 0)
+	case "json":
+		var entries []json.RawMessage
+		if err := json.Unmarshal(body, &entries); err != nil {
+			return ManifestArtifact{}, fmt.Errorf("%s: %w", ans.Name, err)
+		}
+
+		ans.Rows = len(entries)
+	}
+
+	return ans, nil
+}
+
+// downloadManifest sends the manifest of a job, with its signature in the
+// ManifestSignatureHeader when the manifests are signed.
+func (s *Server) downloadManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid ID", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	data, signature, err := s.svc.Manifest(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	case errors.Is(err, ErrJobNotFinished):
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if signature != "" {
+		w.Header().Set(ManifestSignatureHeader, signature)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", contentDisposition(id.String()+manifestSuffix))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func (s *Server) apiManifestKey(w http.ResponseWriter, _ *http.Request) {
+	key, err := s.svc.ManifestPublicKey()
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write(key)
+}
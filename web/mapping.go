@@ -0,0 +1,271 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transforms of the values of a MappedColumn.
+const (
+	TransformUpper  = "upper"
+	TransformLower  = "lower"
+	TransformTitle  = "title"
+	TransformTrim   = "trim"
+	TransformDigits = "digits"
+	TransformFirst  = "first"
+	TransformDomain = "domain"
+)
+
+var mappingTransforms = []string{
+	TransformUpper, TransformLower, TransformTitle, TransformTrim,
+	TransformDigits, TransformFirst, TransformDomain,
+}
+
+// ExportMapping lays the CSV results out for a destination system, like a
+// CRM import: the CSV has exactly its columns, in order.
+type ExportMapping struct {
+	Columns []MappedColumn `json:"columns"`
+}
+
+// MappedColumn is a column of an ExportMapping: Header filled from the CSV
+// column Source of the results, or with the constant Value.
+type MappedColumn struct {
+	Header string `json:"header"`
+	Source string `json:"source,omitempty"`
+	Value  string `json:"value,omitempty"`
+	// Transforms are applied to the value in order: upper, lower, title,
+	// trim, digits (keeps the digits and a leading +), first (the first of
+	// a comma separated list, like the emails) and domain (the host of a
+	// URL, without www.).
+	Transforms []string `json:"transforms,omitempty"`
+}
+
+// validateExportMappings checks mappings, keyed by name.
+func validateExportMappings(mappings map[string]ExportMapping) error {
+	for name, m := range mappings {
+		if !profileNameRe.MatchString(name) {
+			return fmt.Errorf("invalid export mapping name %q: use up to 40 lowercase letters, digits, '-' and '_'", name)
+		}
+
+		if len(m.Columns) == 0 {
+			return fmt.Errorf("export mapping %s has no columns", name)
+		}
+
+		for _, c := range m.Columns {
+			if strings.TrimSpace(c.Header) == "" {
+				return fmt.Errorf("export mapping %s: a column has no header", name)
+			}
+
+			if c.Source != "" && c.Value != "" {
+				return fmt.Errorf("export mapping %s: column %q has both a source and a value", name, c.Header)
+			}
+
+			for _, t := range c.Transforms {
+				if !slices.Contains(mappingTransforms, t) {
+					return fmt.Errorf("export mapping %s: unknown transform %q of column %q: use %s", name, t, c.Header, strings.Join(mappingTransforms, ", "))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseExportMappings parses the mappings written as a "[name]" line
+// followed by one column per line, "Header = source | transform ..." or
+// "Header = \"constant\"", like:
+//
+//	[salesforce]
+//	Company = title
+//	Phone = phone | digits
+//	Lead Source = "Google Maps"
+func ParseExportMappings(text string) (map[string]ExportMapping, error) {
+	ans := map[string]ExportMapping{}
+	name := ""
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := ans[name]; ok {
+				return nil, fmt.Errorf("export mapping %s defined twice", name)
+			}
+
+			ans[name] = ExportMapping{}
+
+			continue
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("export mapping column %q before any [name]", line)
+		}
+
+		header, spec, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid export mapping column %q: use Header = source or Header = \"value\"", line)
+		}
+
+		col := MappedColumn{Header: strings.TrimSpace(header)}
+
+		spec = strings.TrimSpace(spec)
+
+		if strings.HasPrefix(spec, `"`) {
+			value, err := strconv.Unquote(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constant of export mapping column %q", col.Header)
+			}
+
+			col.Value = value
+		} else {
+			parts := strings.Split(spec, "|")
+			col.Source = strings.TrimSpace(parts[0])
+
+			for _, t := range parts[1:] {
+				col.Transforms = append(col.Transforms, strings.TrimSpace(t))
+			}
+		}
+
+		m := ans[name]
+		m.Columns = append(m.Columns, col)
+		ans[name] = m
+	}
+
+	if err := validateExportMappings(ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+// FormatExportMappings writes mappings as ParseExportMappings reads them,
+// sorted by name.
+func FormatExportMappings(mappings map[string]ExportMapping) string {
+	names := make([]string, 0, len(mappings))
+	for name := range mappings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var lines []string
+
+	for i, name := range names {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, "["+name+"]")
+
+		for _, c := range mappings[name].Columns {
+			if c.Source == "" {
+				lines = append(lines, c.Header+" = "+strconv.Quote(c.Value))
+
+				continue
+			}
+
+			lines = append(lines, strings.Join(append([]string{c.Header + " = " + c.Source}, c.Transforms...), " | "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// headers returns the header row of m.
+func (m *ExportMapping) headers() []string {
+	ans := make([]string, len(m.Columns))
+	for i, c := range m.Columns {
+		ans[i] = c.Header
+	}
+
+	return ans
+}
+
+// row returns the row of m from the row of the results, whose columns are
+// at the indexes of sources. A source missing from the results is empty.
+func (m *ExportMapping) row(src []string, sources map[string]int) []string {
+	ans := make([]string, len(m.Columns))
+
+	for i, c := range m.Columns {
+		v := c.Value
+
+		if c.Source != "" {
+			v = ""
+			if j, ok := sources[c.Source]; ok && j < len(src) {
+				v = src[j]
+			}
+		}
+
+		for _, t := range c.Transforms {
+			v = transformValue(t, v)
+		}
+
+		ans[i] = v
+	}
+
+	return ans
+}
+
+func transformValue(transform, v string) string {
+	switch transform {
+	case TransformUpper:
+		return strings.ToUpper(v)
+	case TransformLower:
+		return strings.ToLower(v)
+	case TransformTitle:
+		words := strings.Fields(strings.ToLower(v))
+
+		for i, w := range words {
+			r, size := utf8.DecodeRuneInString(w)
+			words[i] = string(unicode.ToTitle(r)) + w[size:]
+		}
+
+		return strings.Join(words, " ")
+	case TransformTrim:
+		return strings.TrimSpace(v)
+	case TransformDigits:
+		v = strings.TrimSpace(v)
+		plus := strings.HasPrefix(v, "+")
+
+		v = strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+
+			return -1
+		}, v)
+
+		if plus && v != "" {
+			v = "+" + v
+		}
+
+		return v
+	case TransformFirst:
+		first, _, _ := strings.Cut(v, ",")
+
+		return strings.TrimSpace(first)
+	case TransformDomain:
+		raw := strings.TrimSpace(v)
+		if raw != "" && !strings.Contains(raw, "://") {
+			raw = "http://" + raw
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil {
+			return v
+		}
+
+		return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	default:
+		return v
+	}
+}
@@ -0,0 +1,389 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Billable units metered per tenant, the keys of Settings.UsageThresholds.
+const (
+	UnitPlaces         = "places"
+	UnitEmailsVerified = "emails_verified"
+	UnitBrowserMinutes = "browser_minutes"
+)
+
+var usageUnits = []string{UnitPlaces, UnitEmailsVerified, UnitBrowserMinutes}
+
+// UsageEventThreshold is the event of a usage threshold crossed.
+const UsageEventThreshold = "usage.threshold"
+
+const (
+	usageMonthLayout     = "2006-01"
+	usageWebhookAttempts = 3
+)
+
+var usageWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// UsageTotals sums the billable units of the jobs of a month. Like the
+// budgets, a job counts for the month it was created in.
+type UsageTotals struct {
+	Jobs           int     `json:"jobs"`
+	Places         int     `json:"places"`
+	EmailsVerified int     `json:"emails_verified"`
+	BrowserMinutes float64 `json:"browser_minutes"`
+
+	browserSeconds int64
+}
+
+// add adds the units consumed by job.
+func (t *UsageTotals) add(job *Job) {
+	usage := job.consumed()
+
+	t.Jobs++
+	t.Places += usage.Places
+	t.EmailsVerified += usage.EmailsVerified
+	t.browserSeconds += usage.BrowserSeconds
+	t.BrowserMinutes = math.Round(float64(t.browserSeconds)/60*100) / 100
+}
+
+// value returns the units of unit.
+func (t *UsageTotals) value(unit string) float64 {
+	switch unit {
+	case UnitPlaces:
+		return float64(t.Places)
+	case UnitEmailsVerified:
+		return float64(t.EmailsVerified)
+	case UnitBrowserMinutes:
+		return t.BrowserMinutes
+	default:
+		return 0
+	}
+}
+
+// TenantUsage is the usage of a tenant in a month.
+type TenantUsage struct {
+	Tenant string `json:"tenant"`
+	Month  string `json:"month"`
+	UsageTotals
+}
+
+// UsageEvent is posted to the usage webhook of a tenant when the usage of
+// the month crosses one of its thresholds.
+type UsageEvent struct {
+	Event     string      `json:"event"`
+	Tenant    string      `json:"tenant"`
+	Month     string      `json:"month"`
+	Unit      string      `json:"unit"`
+	Threshold int         `json:"threshold"`
+	Value     float64     `json:"value"`
+	Usage     UsageTotals `json:"usage"`
+	// JobID is the job that crossed the threshold.
+	JobID string    `json:"job_id"`
+	Time  time.Time `json:"time"`
+}
+
+// ParseUsageThresholds parses the usage thresholds written one unit per line
+// as "unit=threshold[,threshold...]", like "places=1000,5000".
+func ParseUsageThresholds(text string) (map[string][]int, error) {
+	ans := map[string][]int{}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		unit, values, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid usage threshold %q: use unit=threshold[,threshold...]", line)
+		}
+
+		unit = strings.TrimSpace(unit)
+
+		for _, v := range strings.Split(values, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("invalid usage threshold %q of %s", strings.TrimSpace(v), unit)
+			}
+
+			ans[unit] = append(ans[unit], n)
+		}
+	}
+
+	if err := validateUsageThresholds(ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+// FormatUsageThresholds writes thresholds as ParseUsageThresholds reads them.
+func FormatUsageThresholds(thresholds map[string][]int) string {
+	var lines []string
+
+	for _, unit := range usageUnits {
+		values := thresholds[unit]
+		if len(values) == 0 {
+			continue
+		}
+
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = strconv.Itoa(v)
+		}
+
+		lines = append(lines, unit+"="+strings.Join(parts, ","))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func validateUsageThresholds(thresholds map[string][]int) error {
+	for unit, values := range thresholds {
+		if !slices.Contains(usageUnits, unit) {
+			return fmt.Errorf("unknown usage unit %q: use %s", unit, strings.Join(usageUnits, ", "))
+		}
+
+		for _, v := range values {
+			if v <= 0 {
+				return fmt.Errorf("usage thresholds of %s must be positive", unit)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateUsageWebhook(raw string) error {
+	return validateWebhookURL("usage webhook", raw)
+}
+
+// validateWebhookURL checks that the webhook called name, if set, is an
+// http(s) URL.
+func validateWebhookURL(name, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an http(s) URL", name)
+	}
+
+	return nil
+}
+
+// ParseUsageMonth parses a month written as "2006-01", the current month
+// (UTC) when empty.
+func ParseUsageMonth(month string) (time.Time, error) {
+	if month == "" {
+		return time.Now().UTC(), nil
+	}
+
+	t, err := time.Parse(usageMonthLayout, month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q: use YYYY-MM", month)
+	}
+
+	return t, nil
+}
+
+func sameMonth(a, b time.Time) bool {
+	ya, ma, _ := a.UTC().Date()
+	yb, mb, _ := b.UTC().Date()
+
+	return ya == yb && ma == mb
+}
+
+// Usage returns the usage of the month of month: for a tenant its own, for
+// the default tenant the usage of every tenant, the default one first.
+func (s *Service) Usage(ctx context.Context, month time.Time) ([]TenantUsage, error) {
+	jobs, err := s.repo.Select(ctx, SelectParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]*UsageTotals{"": {}}
+
+	if tenant := TenantFrom(ctx); tenant != "" {
+		totals = map[string]*UsageTotals{tenant: {}}
+	} else {
+		tenants, err := s.Tenants(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range tenants {
+			totals[tenants[i].Name] = &UsageTotals{}
+		}
+	}
+
+	for i := range jobs {
+		t, ok := totals[jobs[i].Tenant]
+		if !ok || !sameMonth(jobs[i].Date, month) {
+			continue
+		}
+
+		t.add(&jobs[i])
+	}
+
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	ans := make([]TenantUsage, 0, len(names))
+
+	for _, name := range names {
+		ans = append(ans, TenantUsage{
+			Tenant:      name,
+			Month:       month.UTC().Format(usageMonthLayout),
+			UsageTotals: *totals[name],
+		})
+	}
+
+	return ans, nil
+}
+
+// meterUsage posts the usage events of the thresholds crossed by job, which
+// just finished, to the usage webhook of its tenant.
+func (s *Service) meterUsage(ctx context.Context, job *Job) {
+	settings, err := s.GetSettings(WithTenant(ctx, job.Tenant))
+	if err != nil {
+		log.Printf("usage of job %s: reading settings: %v", job.ID, err)
+
+		return
+	}
+
+	if settings.UsageWebhook == "" || len(settings.UsageThresholds) == 0 {
+		return
+	}
+
+	usage, err := s.Usage(WithTenant(ctx, job.Tenant), job.Date)
+	if err != nil || len(usage) == 0 {
+		log.Printf("usage of job %s: %v", job.ID, err)
+
+		return
+	}
+
+	after := usage[0].UsageTotals
+
+	// the usage before job, whose units are already in after
+	var own UsageTotals
+
+	own.add(job)
+
+	for _, unit := range usageUnits {
+		now := after.value(unit)
+		before := now - own.value(unit)
+
+		for _, threshold := range settings.UsageThresholds[unit] {
+			if before >= float64(threshold) || now < float64(threshold) {
+				continue
+			}
+
+			ev := UsageEvent{
+				Event:     UsageEventThreshold,
+				Tenant:    job.Tenant,
+				Month:     usage[0].Month,
+				Unit:      unit,
+				Threshold: threshold,
+				Value:     now,
+				Usage:     after,
+				JobID:     job.ID,
+				Time:      time.Now().UTC(),
+			}
+
+			go postUsageEvent(settings.UsageWebhook, &ev)
+		}
+	}
+}
+
+// postUsageEvent posts ev to webhook, retrying with a growing pause.
+func postUsageEvent(webhook string, ev *UsageEvent) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("usage webhook: %v", err)
+
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = sendWebhook(webhook, body)
+		if err == nil {
+			return
+		}
+
+		if attempt == usageWebhookAttempts {
+			log.Printf("usage webhook: %s of %s over %d: giving up after %d attempts: %v", ev.Unit, ev.Tenant, ev.Threshold, attempt, err)
+
+			return
+		}
+
+		time.Sleep(time.Duration(attempt) * 5 * time.Second)
+	}
+}
+
+// sendWebhook posts the JSON body to webhook.
+func sendWebhook(webhook string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := usageWebhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type apiUsageResponse struct {
+	Usage []TenantUsage `json:"usage"`
+}
+
+func (s *Server) apiGetUsage(w http.ResponseWriter, r *http.Request) {
+	month, err := ParseUsageMonth(r.URL.Query().Get("month"))
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	usage, err := s.svc.Usage(r.Context(), month)
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiUsageResponse{Usage: usage})
+}
@@ -0,0 +1,145 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Migrate creates job, a job of the upstream scraper,
+// gosom/google-maps-scraper, with entries as its results, written in the
+// formats of this one. The job keeps its ID: ErrAlreadyExists when it is
+// taken. Like the imported ones, a job that was running is created failed
+// and a pending one is queued.
+func (s *Service) Migrate(ctx context.Context, job *Job, entries []*gmaps.Entry) error {
+	if _, err := s.repo.Get(ctx, job.ID); err == nil {
+		return fmt.Errorf("job %s: %w", job.ID, ErrAlreadyExists)
+	}
+
+	if job.Status == StatusWorking {
+		job.Status = StatusFailed
+	}
+
+	// a bare result file only tells the places: the keywords are those that
+	// found them, and the rest comes from the settings
+	if len(job.Data.Keywords) == 0 {
+		for _, e := range entries {
+			if e.Query != "" && !slices.Contains(job.Data.Keywords, e.Query) {
+				job.Data.Keywords = append(job.Data.Keywords, e.Query)
+			}
+		}
+
+		if len(job.Data.Keywords) == 0 {
+			job.Data.Keywords = []string{job.Name}
+		}
+	}
+
+	if settings, err := s.GetSettings(ctx); err == nil {
+		proxies := job.Data.Proxies
+		settings.ApplyTo(&job.Data)
+		job.Data.Proxies = proxies
+	}
+
+	if err := job.Validate(); err != nil {
+		return fmt.Errorf("job %s: %w", job.ID, err)
+	}
+
+	if entries != nil {
+		folder := s.folder(ctx)
+
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if e.JobID == "" {
+				e.JobID = job.ID
+			}
+		}
+
+		if err := WriteResults(folder, job.ID, entries, s.csvLayout); err != nil {
+			return err
+		}
+	}
+
+	return s.Create(ctx, job)
+}
+
+// ReadPlacesFile reads the places of a result file of the upstream scraper
+// or of this one: a CSV, or JSON with one place per line or an array of
+// them.
+func ReadPlacesFile(path string) ([]*gmaps.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return gmaps.EntriesFromCSV(f)
+	}
+
+	r := bufio.NewReader(f)
+
+	first, err := firstNonSpace(r)
+	if errors.Is(err, io.EOF) {
+		return []*gmaps.Entry{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(r)
+
+	if first == '[' {
+		var entries []*gmaps.Entry
+		if err := dec.Decode(&entries); err != nil {
+			return nil, err
+		}
+
+		return entries, nil
+	}
+
+	entries := []*gmaps.Entry{}
+
+	for {
+		var e gmaps.Entry
+
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("place %d: %w", len(entries)+1, err)
+		}
+
+		entries = append(entries, &e)
+	}
+}
+
+// firstNonSpace returns the first byte of r that is not a space, left
+// unread.
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			return b, r.UnreadByte()
+		}
+	}
+}
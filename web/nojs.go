@@ -0,0 +1,35 @@
+package web
+
+import "net/http"
+
+// The Web UI works without JavaScript: htmx enhances forms and links that
+// post and navigate on their own, and the handlers answer those plain
+// requests with a full page, or a redirect to one, instead of the fragment
+// htmx swaps in.
+
+// pageIncludes are the templates a page renders in place of what htmx would
+// load into it, or shares with another page, parsed along with the page.
+var pageIncludes = map[string][]string{
+	"static/templates/index.html":    {"static/templates/job_rows.html", "static/templates/job_row.html", "static/templates/preview.html"},
+	"static/templates/job_rows.html": {"static/templates/job_row.html"},
+	"static/templates/settings.html": {"static/templates/settings_success.html"},
+	"static/templates/sandbox.html":  {"static/templates/sandbox_result.html"},
+}
+
+// isHTMX reports whether r was sent by htmx, which wants a fragment.
+func isHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// redirectAfter sends the browser to target once an action is done, through
+// htmx when it sent r.
+func redirectAfter(w http.ResponseWriter, r *http.Request, target string) {
+	if isHTMX(r) {
+		w.Header().Set("HX-Redirect", target)
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
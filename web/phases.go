@@ -0,0 +1,152 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// mapsResultsSuffix ends the names of the Maps-only results of a job, see
+// WriteMapsResults.
+const mapsResultsSuffix = ".maps"
+
+// PhaseAborted is the status of an email phase that was aborted, see
+// Service.AbortEmails. The phases otherwise take the statuses of the jobs.
+const PhaseAborted = "aborted"
+
+// JobPhases are the phases of a job searching emails: the Maps scraping of
+// its places, then the email searches of their websites. The email searches
+// start as soon as the first places are scraped, the email phase ends after
+// the Maps one.
+type JobPhases struct {
+	Maps  JobPhase `json:"maps"`
+	Email JobPhase `json:"email"`
+}
+
+// JobPhase is the status and the progress of a phase of a job.
+type JobPhase struct {
+	// Status is pending, working, ok, failed or, for the email phase,
+	// aborted.
+	Status    string     `json:"status"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	// Done counts the places scraped by the Maps phase, and the websites
+	// searched by the email phase out of the Total queued so far.
+	Done  int `json:"done"`
+	Total int `json:"total,omitempty"`
+}
+
+// NewJobPhases returns the phases of a job starting at now: the Maps one
+// working, the email one pending.
+func NewJobPhases(now time.Time) *JobPhases {
+	return &JobPhases{
+		Maps:  JobPhase{Status: StatusWorking, StartedAt: &now},
+		Email: JobPhase{Status: StatusPending},
+	}
+}
+
+// Start marks the phase working since now, unless it started already.
+func (p *JobPhase) Start(now time.Time) {
+	if p.StartedAt == nil {
+		p.Status, p.StartedAt = StatusWorking, &now
+	}
+}
+
+// End marks the phase over at now with status, unless it ended already.
+func (p *JobPhase) End(status string, now time.Time) {
+	if p.EndedAt == nil && p.Status != StatusOK && p.Status != StatusFailed && p.Status != PhaseAborted {
+		p.Status, p.EndedAt = status, &now
+	}
+}
+
+// MapsReady reports whether the Maps-only results of the job can be
+// downloaded: its Maps phase is over and its emails are still searched.
+func (j Job) MapsReady() bool {
+	return j.Status == StatusWorking && j.Stats.Phases != nil && j.Stats.Phases.Maps.Status == StatusOK
+}
+
+// WriteMapsResults writes the Maps-only results of running job id in folder,
+// as CSV and JSON: its places once they are all scraped, without the
+// emails of those still searched. The final results replace them, see
+// WriteResults.
+func WriteMapsResults(folder, id string, entries []*gmaps.Entry, csvLayout CSVLayout) error {
+	if entries == nil {
+		entries = []*gmaps.Entry{}
+	}
+
+	gmaps.AssignChains(entries)
+
+	base := filepath.Join(folder, id+mapsResultsSuffix)
+
+	err := WriteFileAtomic(base+".csv", func(w io.Writer) error {
+		return WriteCSV(w, entries, csvLayout)
+	})
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(base+".json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(entries)
+	})
+}
+
+// removeMapsResults removes the Maps-only results of job id from folder,
+// with their compressed copies.
+func removeMapsResults(folder, id string) error {
+	for _, ext := range []string{".csv", ".json"} {
+		path := filepath.Join(folder, id+mapsResultsSuffix+ext)
+
+		for _, p := range []string{path, path + gzipSuffix} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetMapsResults returns the path of the Maps-only results of job id in
+// format, csv or json, while they exist.
+func (s *Service) GetMapsResults(ctx context.Context, id, format string) (string, error) {
+	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid file name")
+	}
+
+	datapath := filepath.Join(s.folder(ctx), id+mapsResultsSuffix+"."+format)
+
+	if _, err := os.Stat(datapath); os.IsNotExist(err) {
+		return "", fmt.Errorf("no Maps-only %s results for job %s: its Maps phase is not over, or the job ended", format, id)
+	}
+
+	return datapath, nil
+}
+
+// phaseMaps selects the Maps-only results in the download endpoints.
+const phaseMaps = "maps"
+
+// downloadMaps sends the Maps-only results of a job in format, as they are:
+// the filters and the export format apply to the final results only.
+func (s *Server) downloadMaps(w http.ResponseWriter, r *http.Request, id, format, name, contentType string) {
+	filePath, err := s.svc.GetMapsResults(r.Context(), id, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	name = strings.TrimSuffix(name, "."+format) + "_maps." + format
+
+	serveResultFile(w, r, filePath, name, contentType)
+}
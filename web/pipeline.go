@@ -0,0 +1,598 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gosom/google-maps-scraper/postprocess"
+)
+
+// A pipeline runs the stages of a workflow as one unit: a Maps scrape, then
+// enrichment and verification passes over the places found, and exports of
+// the results. Every stage but the exports is a job depending on the job of
+// the stage before it, see JobData.DependsOn; an export POSTs the results of
+// the job before it to a webhook once that job ends ok.
+
+const (
+	// StageScrape searches the keywords, or visits the places, of its job.
+	// It is the first stage, and only that.
+	StageScrape = "scrape"
+	// StageEnrich visits the places found by the stage before it with the
+	// email extraction.
+	StageEnrich = "enrich"
+	// StageVerify visits them with the email extraction and verification.
+	StageVerify = "verify"
+	// StageExport POSTs the results of the stage before it to a webhook.
+	StageExport = "export"
+
+	maxPipelineStages = 10
+	// pipelineExportBatch is the number of places of a POST of an export.
+	pipelineExportBatch = 100
+)
+
+// PipelineRepository is implemented by the repositories storing the
+// pipelines.
+type PipelineRepository interface {
+	ListPipelines(context.Context) ([]Pipeline, error)
+	// GetPipeline returns ErrNotFound when there is no pipeline id.
+	GetPipeline(ctx context.Context, id string) (Pipeline, error)
+	UpsertPipeline(ctx context.Context, p *Pipeline) error
+	DeletePipeline(ctx context.Context, id string) error
+}
+
+// Pipeline is a workflow of stages run one after the other.
+type Pipeline struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+	// Tenant is the client account owning the pipeline, "" for the default
+	// tenant.
+	Tenant string `json:"tenant,omitempty"`
+	// Status sums up the stages: failed as soon as one failed, ok once
+	// they all are, working once one started.
+	Status string          `json:"status"`
+	Stages []PipelineStage `json:"stages"`
+}
+
+// PipelineStage is a step of a pipeline.
+type PipelineStage struct {
+	Kind string `json:"kind"`
+	// Job holds the options of the job of a scrape, enrich or verify stage.
+	// The language, depth, max time, proxies and profile left empty are
+	// those of the first stage.
+	Job *JobData `json:"job,omitempty"`
+	// Webhook receives the results of an export stage, in the batches of
+	// the entry webhook.
+	Webhook string `json:"webhook,omitempty"`
+	// JobID is the job of the stage, and Status and Error its outcome.
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (s *Service) pipelineRepo() (PipelineRepository, error) {
+	repo, ok := s.repo.(PipelineRepository)
+	if !ok {
+		return nil, errors.New("pipelines not supported by repository")
+	}
+
+	return repo, nil
+}
+
+// CreatePipeline stores p for the tenant of ctx and creates the jobs of its
+// stages, the first one queued and the others waiting for the one before
+// them. Nothing is created when a stage is invalid, and what was created is
+// deleted again when a job cannot be.
+func (s *Service) CreatePipeline(ctx context.Context, p *Pipeline) error {
+	repo, err := s.pipelineRepo()
+	if err != nil {
+		return err
+	}
+
+	if p.Name == "" {
+		return errors.New("missing name")
+	}
+
+	if len(p.Stages) == 0 || len(p.Stages) > maxPipelineStages {
+		return fmt.Errorf("a pipeline has 1 to %d stages", maxPipelineStages)
+	}
+
+	p.ID = uuid.New().String()
+	p.Date = time.Now().UTC()
+	p.Tenant = TenantFrom(ctx)
+
+	var (
+		jobs     []Job
+		previous string
+		errs     []error
+	)
+
+	// every stage is checked before anything is created
+	for i := range p.Stages {
+		stage := &p.Stages[i]
+
+		job, err := s.stageJob(ctx, p, i, previous)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stage %d: %w", i+1, err))
+
+			continue
+		}
+
+		stage.Status = StatusWaiting
+
+		if job == nil {
+			continue
+		}
+
+		stage.JobID = job.ID
+		stage.Status = job.Status
+		previous = job.ID
+
+		jobs = append(jobs, *job)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	// stored first, for the exports of a job ending right away
+	if err := repo.UpsertPipeline(ctx, p); err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if err := s.Create(ctx, &jobs[i]); err != nil {
+			err = fmt.Errorf("creating the job of %s: %w", jobs[i].Name, err)
+
+			return errors.Join(err, s.rollbackPipeline(ctx, repo, p.ID, jobs[:i]))
+		}
+	}
+
+	s.refresh(ctx, p)
+
+	return nil
+}
+
+// rollbackPipeline deletes the jobs created for pipeline id, the last ones
+// first so that none is released on the way, then the pipeline.
+func (s *Service) rollbackPipeline(ctx context.Context, repo PipelineRepository, id string, created []Job) error {
+	var errs []error
+
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := s.Delete(ctx, created[i].ID); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("rolling back job %s: %w", created[i].ID, err))
+		}
+	}
+
+	if err := repo.DeletePipeline(ctx, id); err != nil {
+		errs = append(errs, fmt.Errorf("rolling back pipeline %s: %w", id, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// stageJob checks stage i of p and returns its job, nil for an export. The
+// job depends on previous, the job of the stages before it.
+func (s *Service) stageJob(ctx context.Context, p *Pipeline, i int, previous string) (*Job, error) {
+	stage := &p.Stages[i]
+
+	if (stage.Kind == StageScrape) != (i == 0) {
+		return nil, errors.New("a pipeline starts with its only scrape stage")
+	}
+
+	if stage.Kind == StageExport {
+		if stage.Webhook == "" {
+			return nil, errors.New("missing webhook")
+		}
+
+		return nil, validateWebhookURL("webhook", stage.Webhook)
+	}
+
+	if stage.Webhook != "" {
+		return nil, fmt.Errorf("a %s stage has no webhook", stage.Kind)
+	}
+
+	var data JobData
+	if stage.Job != nil {
+		data = *stage.Job
+	}
+
+	switch stage.Kind {
+	case StageScrape:
+	case StageEnrich, StageVerify:
+		data.InputFrom = InputPlaces
+		data.Email = true
+		data.VerifyEmails = data.VerifyEmails || stage.Kind == StageVerify
+		data.DependsOn = []string{previous}
+	default:
+		return nil, fmt.Errorf("invalid stage kind %q: use %s, %s, %s or %s", stage.Kind, StageScrape, StageEnrich, StageVerify, StageExport)
+	}
+
+	if first := p.Stages[0].Job; first != nil && i > 0 {
+		data.inherit(first)
+	}
+
+	if err := s.ApplyProfile(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Encrypt && s.resultsKey == nil {
+		return nil, ErrNoResultsKey
+	}
+
+	job := Job{
+		ID:     uuid.New().String(),
+		Name:   fmt.Sprintf("%s: %d %s", p.Name, i+1, stage.Kind),
+		Date:   p.Date,
+		Status: StatusPending,
+		Data:   data,
+	}
+
+	// the dependencies are checked once the jobs before it are created
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// inherit fills the defaults of d left empty with those of first.
+func (d *JobData) inherit(first *JobData) {
+	if d.Lang == "" {
+		d.Lang = first.Lang
+	}
+
+	if d.Depth == 0 {
+		d.Depth = first.Depth
+	}
+
+	if d.MaxTime == 0 {
+		d.MaxTime = first.MaxTime
+	}
+
+	if len(d.Proxies) == 0 {
+		d.Proxies = first.Proxies
+	}
+
+	if d.Profile == "" {
+		d.Profile = first.Profile
+	}
+}
+
+// Pipelines returns the pipelines of the tenant of ctx, newest first.
+func (s *Service) Pipelines(ctx context.Context) ([]Pipeline, error) {
+	repo, ok := s.repo.(PipelineRepository)
+	if !ok {
+		return []Pipeline{}, nil
+	}
+
+	pipelines, err := repo.ListPipelines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ans := []Pipeline{}
+
+	for i := range pipelines {
+		if pipelines[i].Tenant != TenantFrom(ctx) {
+			continue
+		}
+
+		s.refresh(ctx, &pipelines[i])
+
+		ans = append(ans, pipelines[i])
+	}
+
+	return ans, nil
+}
+
+// GetPipeline returns pipeline id with the current status of its stages,
+// ErrNotFound when it belongs to another tenant than the one of ctx.
+func (s *Service) GetPipeline(ctx context.Context, id string) (Pipeline, error) {
+	repo, err := s.pipelineRepo()
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	p, err := repo.GetPipeline(ctx, id)
+	if err != nil {
+		return Pipeline{}, err
+	}
+
+	if p.Tenant != TenantFrom(ctx) {
+		return Pipeline{}, fmt.Errorf("%w: pipeline %s", ErrNotFound, id)
+	}
+
+	s.refresh(ctx, &p)
+
+	return p, nil
+}
+
+// DeletePipeline deletes pipeline id with the jobs of its stages.
+func (s *Service) DeletePipeline(ctx context.Context, id string) error {
+	repo, err := s.pipelineRepo()
+	if err != nil {
+		return err
+	}
+
+	p, err := s.GetPipeline(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// the last stages first, so that none is released on the way
+	for i := len(p.Stages) - 1; i >= 0; i-- {
+		if p.Stages[i].JobID == "" {
+			continue
+		}
+
+		if err := s.Delete(ctx, p.Stages[i].JobID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	return repo.DeletePipeline(ctx, id)
+}
+
+// refresh sets the status of the job stages of p from their jobs, and fails
+// the exports of a job that failed.
+func (s *Service) refresh(ctx context.Context, p *Pipeline) {
+	source := -1
+
+	for i := range p.Stages {
+		stage := &p.Stages[i]
+
+		if stage.Kind != StageExport {
+			source = i
+
+			job, err := s.repo.Get(ctx, stage.JobID)
+
+			switch {
+			case err != nil:
+				stage.Status = StatusFailed
+				stage.Error = "job deleted"
+			case job.Stats.DependencyFailed != "":
+				stage.Status = job.Status
+				stage.Error = fmt.Sprintf("job %s failed", job.Stats.DependencyFailed)
+			default:
+				stage.Status = job.Status
+			}
+
+			continue
+		}
+
+		if stage.Status == StatusWaiting && source >= 0 && p.Stages[source].Status == StatusFailed {
+			stage.Status = StatusFailed
+			stage.Error = fmt.Sprintf("stage %d failed", source+1)
+		}
+	}
+
+	p.summarize()
+}
+
+// summarize sets the status of p from those of its stages.
+func (p *Pipeline) summarize() {
+	var ok, started int
+
+	for i := range p.Stages {
+		switch p.Stages[i].Status {
+		case StatusFailed:
+			p.Status = StatusFailed
+
+			return
+		case StatusOK:
+			ok++
+			started++
+		case StatusWorking:
+			started++
+		}
+	}
+
+	switch {
+	case ok == len(p.Stages):
+		p.Status = StatusOK
+	case started > 0:
+		p.Status = StatusWorking
+	default:
+		p.Status = StatusPending
+	}
+}
+
+// startExports starts the exports following job, which just ended ok, in
+// its pipeline if it has one.
+func (s *Service) startExports(ctx context.Context, job *Job) {
+	repo, ok := s.repo.(PipelineRepository)
+	if !ok {
+		return
+	}
+
+	s.pipelineMu.Lock()
+	defer s.pipelineMu.Unlock()
+
+	pipelines, err := repo.ListPipelines(ctx)
+	if err != nil {
+		log.Printf("job %s: listing the pipelines: %v", job.ID, err)
+
+		return
+	}
+
+	for i := range pipelines {
+		p := &pipelines[i]
+
+		if p.Tenant != job.Tenant {
+			continue
+		}
+
+		var exports []int
+
+		for k := range p.Stages {
+			if p.Stages[k].JobID != job.ID {
+				continue
+			}
+
+			for k++; k < len(p.Stages) && p.Stages[k].Kind == StageExport; k++ {
+				p.Stages[k].Status = StatusWorking
+				p.Stages[k].Error = ""
+
+				exports = append(exports, k)
+			}
+
+			break
+		}
+
+		if len(exports) == 0 {
+			continue
+		}
+
+		if err := repo.UpsertPipeline(ctx, p); err != nil {
+			log.Printf("pipeline %s: %v", p.ID, err)
+
+			return
+		}
+
+		for _, k := range exports {
+			go s.export(context.WithoutCancel(ctx), p.ID, k, job)
+		}
+
+		return
+	}
+}
+
+// export POSTs the results of job to the webhook of stage k of pipeline id,
+// and records how it went.
+func (s *Service) export(ctx context.Context, id string, k int, job *Job) {
+	repo, err := s.pipelineRepo()
+	if err != nil {
+		return
+	}
+
+	p, err := repo.GetPipeline(ctx, id)
+	if err != nil {
+		return
+	}
+
+	entries, err := s.readResults(filepath.Join(TenantFolder(s.dataFolder, job.Tenant), job.ID+".json"))
+	if err == nil {
+		sender := postprocess.NewStreamWriter(nil, postprocess.StreamConfig{
+			URL:       p.Stages[k].Webhook,
+			JobID:     job.ID,
+			BatchSize: pipelineExportBatch,
+		})
+
+		err = sender.Send(ctx, entries)
+	}
+
+	s.pipelineMu.Lock()
+	defer s.pipelineMu.Unlock()
+
+	// the pipeline may have changed or be gone meanwhile
+	p, perr := repo.GetPipeline(ctx, id)
+	if perr != nil || k >= len(p.Stages) {
+		return
+	}
+
+	p.Stages[k].Status = StatusOK
+	p.Stages[k].Error = ""
+
+	if err != nil {
+		log.Printf("pipeline %s: export of stage %d: %v", id, k+1, err)
+
+		p.Stages[k].Status = StatusFailed
+		p.Stages[k].Error = err.Error()
+	}
+
+	if err := repo.UpsertPipeline(ctx, &p); err != nil {
+		log.Printf("pipeline %s: %v", id, err)
+	}
+}
+
+type apiPipelinesResponse struct {
+	Pipelines []Pipeline `json:"pipelines"`
+}
+
+// pipelineStatus returns the HTTP status of a pipeline error.
+func pipelineStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func renderPipelineError(w http.ResponseWriter, err error) {
+	code := pipelineStatus(err)
+
+	renderJSON(w, code, apiError{
+		Code:    code,
+		Message: err.Error(),
+	})
+}
+
+func (s *Server) apiGetPipelines(w http.ResponseWriter, r *http.Request) {
+	pipelines, err := s.svc.Pipelines(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiPipelinesResponse{Pipelines: pipelines})
+}
+
+func (s *Server) apiCreatePipeline(w http.ResponseWriter, r *http.Request) {
+	var p Pipeline
+
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		renderPipelineError(w, err)
+
+		return
+	}
+
+	// in seconds, like the jobs
+	for i := range p.Stages {
+		if p.Stages[i].Job != nil {
+			p.Stages[i].Job.MaxTime *= time.Second
+		}
+	}
+
+	if err := s.svc.CreatePipeline(r.Context(), &p); err != nil {
+		renderPipelineError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) apiGetPipeline(w http.ResponseWriter, r *http.Request) {
+	p, err := s.svc.GetPipeline(r.Context(), r.PathValue("id"))
+	if err != nil {
+		renderPipelineError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) apiDeletePipeline(w http.ResponseWriter, r *http.Request) {
+	if err := s.svc.DeletePipeline(r.Context(), r.PathValue("id")); err != nil {
+		renderPipelineError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,256 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// A profile is a named set of settings ("aggressive", "polite-eu") a job can
+// be created with instead of the global settings: it gives the defaults of
+// the job and the email proxy routes and proxy provider it runs with. The
+// monthly budgets stay global, those of a profile are ignored.
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// ProfileRepository is implemented by the repositories storing the settings
+// profiles.
+type ProfileRepository interface {
+	ListProfiles(context.Context) ([]string, error)
+	// GetProfile returns ErrNotFound when there is no profile name.
+	GetProfile(ctx context.Context, name string) (Settings, error)
+	UpsertProfile(ctx context.Context, name string, settings *Settings) error
+	DeleteProfile(ctx context.Context, name string) error
+}
+
+// ValidateProfileName checks that name can name a profile: lowercase letters,
+// digits, '-' and '_', up to 40 characters.
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 40 lowercase letters, digits, '-' and '_'", name)
+	}
+
+	return nil
+}
+
+// ApplyTo fills the fields of d left empty with the defaults of s.
+func (s *Settings) ApplyTo(d *JobData) {
+	if d.Lang == "" {
+		d.Lang = s.Language
+	}
+
+	if d.Depth == 0 {
+		d.Depth = s.Depth
+	}
+
+	if d.MaxTime == 0 {
+		d.MaxTime, _ = time.ParseDuration(s.MaxTime)
+	}
+
+	if len(d.Proxies) == 0 {
+		d.Proxies = s.Proxies
+	}
+}
+
+// Profiles returns the names of the settings profiles, sorted.
+func (s *Service) Profiles(ctx context.Context) ([]string, error) {
+	repo, ok := s.repo.(ProfileRepository)
+	if !ok {
+		return []string{}, nil
+	}
+
+	return repo.ListProfiles(ctx)
+}
+
+// GetProfile returns the settings of profile name. The profiles are not
+// available to the tenants.
+func (s *Service) GetProfile(ctx context.Context, name string) (Settings, error) {
+	repo, ok := s.repo.(ProfileRepository)
+	if !ok || TenantFrom(ctx) != "" {
+		return Settings{}, ErrNotFound
+	}
+
+	settings, err := repo.GetProfile(ctx, name)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings.ApplyDefaults()
+
+	return settings, nil
+}
+
+// ProfileSettings returns the settings a job of profile name runs with:
+// those of the profile or the global settings when name is empty or the
+// profile was deleted since.
+func (s *Service) ProfileSettings(ctx context.Context, name string) (Settings, error) {
+	if name == "" {
+		return s.GetSettings(ctx)
+	}
+
+	settings, err := s.GetProfile(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return s.GetSettings(ctx)
+	}
+
+	return settings, err
+}
+
+// ApplyProfile fills the fields of d left empty with the defaults of its
+// profile, which must exist.
+func (s *Service) ApplyProfile(ctx context.Context, d *JobData) error {
+	if d.Profile == "" {
+		return nil
+	}
+
+	settings, err := s.GetProfile(ctx, d.Profile)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("unknown profile %q", d.Profile)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	settings.ApplyTo(d)
+
+	return nil
+}
+
+// SaveProfile creates or replaces profile name.
+func (s *Service) SaveProfile(ctx context.Context, name string, settings *Settings) error {
+	repo, ok := s.repo.(ProfileRepository)
+	if !ok {
+		return errors.New("profiles not supported by repository")
+	}
+
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.ApplyDefaults()
+
+	return repo.UpsertProfile(ctx, name, settings)
+}
+
+// DeleteProfile deletes profile name. The jobs created with it run with the
+// global settings from then on.
+func (s *Service) DeleteProfile(ctx context.Context, name string) error {
+	repo, ok := s.repo.(ProfileRepository)
+	if !ok {
+		return ErrNotFound
+	}
+
+	return repo.DeleteProfile(ctx, name)
+}
+
+// deleteProfile deletes the profile posted from the settings page and goes
+// back to the global settings.
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	err := s.svc.DeleteProfile(r.Context(), r.FormValue("profile"))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	redirectAfter(w, r, "/settings")
+}
+
+type apiProfilesResponse struct {
+	Profiles []string `json:"profiles"`
+}
+
+func (s *Server) apiGetProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.svc.Profiles(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiProfilesResponse{Profiles: profiles})
+}
+
+func (s *Server) apiGetProfile(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.svc.GetProfile(r.Context(), r.PathValue("name"))
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: "Profile not found",
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) apiSaveProfile(w http.ResponseWriter, r *http.Request) {
+	var settings Settings
+
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err == nil {
+		err = s.svc.SaveProfile(r.Context(), r.PathValue("name"), &settings)
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) apiDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	err := s.svc.DeleteProfile(r.Context(), r.PathValue("name"))
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: "Profile not found",
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// NackDelay is how long a job whose claim failed halfway, on an error of
+// the repository, waits before it can be claimed again.
+const NackDelay = 30 * time.Second
+
+// JobQueue hands out the pending jobs. Without one the Service claims the
+// jobs straight from the repository, which is only safe within a single
+// process.
+type JobQueue interface {
+	// Push queues job id, unless it is already queued or claimed.
+	Push(ctx context.Context, id string) error
+	// Claim takes the next job for owner and hides it from the other
+	// claims for visibility, or for good when visibility is 0. It returns
+	// an empty id when the queue is empty.
+	Claim(ctx context.Context, owner string, visibility time.Duration) (string, error)
+	// Take claims job id like Claim, out of turn. It returns false when
+	// the job is not queued.
+	Take(ctx context.Context, id, owner string, visibility time.Duration) (bool, error)
+	// Extend keeps job id hidden for visibility more. It returns
+	// ErrLeaseLost when owner no longer holds the job.
+	Extend(ctx context.Context, id, owner string, visibility time.Duration) error
+	// Ack drops a finished job. It returns ErrLeaseLost when owner no
+	// longer holds the job; an empty owner drops it whoever holds it.
+	Ack(ctx context.Context, id, owner string) error
+	// Nack hands job id back to the claims once delay is over. It returns
+	// ErrLeaseLost when owner no longer holds the job; an empty owner
+	// hands it back whoever holds it.
+	Nack(ctx context.Context, id, owner string, delay time.Duration) error
+	// Owner returns the owner of job id while its claim holds, or an
+	// empty string when the job is not claimed or its visibility ran out.
+	Owner(ctx context.Context, id string) (string, error)
+	// Expired drops the claims whose visibility ran out and returns their
+	// ids, for the caller to push them again once they are pending.
+	Expired(ctx context.Context) ([]string, error)
+	// Remove drops job id whatever its state.
+	Remove(ctx context.Context, id string) error
+}
+
+// ServiceOption configures a Service.
+type ServiceOption func(*Service)
+
+// WithJobQueue makes the Service claim the jobs from q, which lets several
+// processes share the pending jobs.
+func WithJobQueue(q JobQueue) ServiceOption {
+	return func(s *Service) {
+		s.queue = q
+	}
+}
+
+// WithHost names this process, such as by its -worker-id, in the queue
+// claims of its local jobs. The claims then expire unless renewed, see
+// RenewLocal, so that a restart recovers the jobs of this process only, and
+// those of a process gone for good, and leaves the other processes sharing
+// the queue to their jobs.
+func WithHost(host string) ServiceOption {
+	return func(s *Service) {
+		s.host = host
+	}
+}
+
+// claimOwner returns the owner of the queue claims of workerID and how long
+// they hold without a renewal, 0 for good.
+func (s *Service) claimOwner(workerID string) (string, time.Duration) {
+	switch {
+	case workerID != "":
+		return workerID, DefaultLeaseTimeout
+	case s.host != "":
+		return s.host, DefaultLeaseTimeout
+	default:
+		// the local runner of a process without a name renews nothing
+		return "", 0
+	}
+}
+
+// RenewLocal keeps the queue claim of the local job id for another
+// DefaultLeaseTimeout. The local runner calls it while the job runs; it
+// does nothing without a queue or a host.
+func (s *Service) RenewLocal(ctx context.Context, id string) error {
+	if s.queue == nil || s.host == "" {
+		return nil
+	}
+
+	return s.queue.Extend(ctx, id, s.host, DefaultLeaseTimeout)
+}
+
+// SyncQueue pushes to the queue the pending jobs of the repository, such as
+// the ones created before the queue was configured.
+func (s *Service) SyncQueue(ctx context.Context) error {
+	if s.queue == nil {
+		return nil
+	}
+
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusPending})
+	if err != nil {
+		return err
+	}
+
+	// oldest first, like the jobs pushed as they are created
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if err := s.queue.Push(ctx, jobs[i].ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claimQueued takes the next job of the queue for workerID. The entries of
+// jobs deleted or no longer pending are dropped on the way; a job the
+// repository fails to read is handed back for later.
+func (s *Service) claimQueued(ctx context.Context, workerID string) (*Job, error) {
+	owner, visibility := s.claimOwner(workerID)
+
+	for {
+		id, err := s.queue.Claim(ctx, owner, visibility)
+		if err != nil || id == "" {
+			return nil, err
+		}
+
+		job, err := s.repo.Get(ctx, id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, s.nack(ctx, id, owner, err)
+		}
+
+		if err != nil || job.Status != StatusPending {
+			if err := s.queue.Ack(ctx, id, ""); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		return &job, nil
+	}
+}
+
+// nack hands job id, claimed by workerID, back to the queue after err kept
+// it from starting, for another claim once NackDelay is over. It returns
+// err, joined with the error of the queue if any.
+func (s *Service) nack(ctx context.Context, id, workerID string, err error) error {
+	if s.queue == nil {
+		return err
+	}
+
+	return errors.Join(err, s.queue.Nack(ctx, id, workerID, NackDelay))
+}
+
+// isFinished reports whether status ends a job.
+func isFinished(status string) bool {
+	return status == StatusOK || status == StatusFailed
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// A records cursor marks the last record of a page by its position and by
+// the identity of its place. Records deleted before it shift it down, so
+// the next page starts after wherever its place is now, instead of skipping
+// or repeating records as a page number would.
+
+// ErrInvalidCursor is returned for a records cursor that was not made by
+// GetRecordsAfter with the same filter.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// recordSet is the records of a job matching a filter.
+type recordSet struct {
+	// matched are the positions of the records matching, in order.
+	matched []int
+	// size is the number of records, matching or not.
+	size int
+	// key returns the identity of the place of the record at position i.
+	key func(i int) string
+	// load reads the records at positions.
+	load    func(positions []int) ([]gmaps.Entry, error)
+	partial bool
+}
+
+// get returns the records at positions.
+func (set *recordSet) get(positions []int) ([]IndexedEntry, error) {
+	entries, err := set.load(positions)
+	if err != nil {
+		return nil, err
+	}
+
+	ans := make([]IndexedEntry, len(entries))
+	for i := range entries {
+		ans[i] = IndexedEntry{Entry: entries[i], Index: positions[i]}
+	}
+
+	return ans, nil
+}
+
+// after returns where the records after the one of c start in set.matched.
+func (set *recordSet) after(c recordCursor) int {
+	// records are only deleted or appended, so the place is at its position
+	// or before it
+	for i := min(c.Index, set.size-1); i >= 0; i-- {
+		if set.key(i) == c.Key {
+			return sort.SearchInts(set.matched, i+1)
+		}
+	}
+
+	// deleted itself: the records after it took its position
+	return sort.SearchInts(set.matched, c.Index)
+}
+
+type recordCursor struct {
+	// Index is the position of the last record returned.
+	Index int `json:"i"`
+	// Key is the identity of its place.
+	Key string `json:"k"`
+	// Filter is the hash of the filter of the records.
+	Filter string `json:"f"`
+}
+
+func (c recordCursor) String() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseRecordCursor(s string) (recordCursor, error) {
+	var c recordCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.Index < 0 {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// hash identifies the filter in the cursors.
+func (f *RecordFilter) hash() string {
+	h := sha256.Sum256(fmt.Appendf(nil, "%q|%t|%d", f.Search, f.ExcludeChains, f.MinEmailConfidence))
+
+	return hex.EncodeToString(h[:8])
+}
+
+// GetRecordsAfter returns up to limit records of the job results matching
+// filter after cursor, from the first one when cursor is empty, with their
+// total and the cursor of the next records. The next cursor is empty after
+// the last record of a finished job; while the job runs the records are the
+// ones written so far, partial is true and the cursor stays, for the next
+// records to come.
+func (s *Service) GetRecordsAfter(ctx context.Context, jobID, cursor string, limit int, filter RecordFilter) (records []IndexedEntry, next string, total int, partial bool, err error) {
+	start := 0
+
+	var c recordCursor
+
+	if cursor != "" {
+		c, err = parseRecordCursor(cursor)
+		if err == nil && c.Filter != filter.hash() {
+			err = fmt.Errorf("%w: it was made with other filters", ErrInvalidCursor)
+		}
+
+		if err != nil {
+			return nil, "", 0, false, err
+		}
+	}
+
+	set, err := s.records(ctx, jobID, filter)
+	if err != nil {
+		return nil, "", 0, false, err
+	}
+
+	total = len(set.matched)
+
+	if cursor != "" {
+		start = set.after(c)
+	}
+
+	end := min(start+limit, total)
+
+	records, err = set.get(set.matched[start:end])
+	if err != nil {
+		return nil, "", 0, false, err
+	}
+
+	if len(records) > 0 {
+		last := set.matched[end-1]
+		next = recordCursor{Index: last, Key: set.key(last), Filter: filter.hash()}.String()
+	} else if set.partial {
+		next = cursor
+	}
+
+	if end == total && !set.partial {
+		next = ""
+	}
+
+	return records, next, total, set.partial, nil
+}
@@ -0,0 +1,280 @@
+// Package redisqueue keeps the pending jobs of the web runner in Redis, so
+// that several processes can claim them without handing the same job twice.
+//
+// The jobs waiting are a sorted set scored by push time, taken oldest first;
+// a job handed back is scored by the end of its delay, and not taken before.
+// A claimed job moves to a second sorted set scored by the end of its
+// visibility, with its owner in a hash. Every transition runs as a Lua
+// script, so a claim is atomic whatever the number of competing processes.
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix is the prefix of the keys of the queue.
+const DefaultPrefix = "gmaps:jobs"
+
+var (
+	// KEYS: pending, claimed, owners
+	// ARGV: owner, deadline, now
+	claimScript = redis.NewScript(`
+local ready = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[3], 'LIMIT', 0, 1)
+if #ready == 0 then
+	return false
+end
+local id = ready[1]
+redis.call('ZREM', KEYS[1], id)
+redis.call('ZADD', KEYS[2], ARGV[2], id)
+redis.call('HSET', KEYS[3], id, ARGV[1])
+return id
+`)
+
+	// KEYS: pending, claimed, owners
+	// ARGV: id, owner, deadline
+	takeScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+return 1
+`)
+
+	// KEYS: pending, claimed
+	// ARGV: id, score
+	pushScript = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	return 0
+end
+return redis.call('ZADD', KEYS[1], 'NX', ARGV[2], ARGV[1])
+`)
+
+	// KEYS: claimed, owners
+	// ARGV: id, owner, deadline
+	extendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] or not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[3], ARGV[1])
+return 1
+`)
+
+	// KEYS: claimed, owners
+	// ARGV: id, owner ("" for any)
+	ackScript = redis.NewScript(`
+if ARGV[2] ~= '' and redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+	// KEYS: pending, claimed, owners
+	// ARGV: id, owner ("" for any), score
+	nackScript = redis.NewScript(`
+if ARGV[2] ~= '' and redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HDEL', KEYS[3], ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
+	// KEYS: claimed, owners
+	// ARGV: now
+	expiredScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('HDEL', KEYS[2], id)
+end
+return ids
+`)
+)
+
+// Queue is a web.JobQueue stored in Redis.
+type Queue struct {
+	client *redis.Client
+
+	pending string
+	claimed string
+	owners  string
+}
+
+var _ web.JobQueue = (*Queue)(nil)
+
+// Option configures a Queue.
+type Option func(*Queue)
+
+// WithPrefix sets the prefix of the keys, to share a Redis database between
+// several queues.
+func WithPrefix(prefix string) Option {
+	return func(q *Queue) {
+		q.setPrefix(prefix)
+	}
+}
+
+// New connects to the Redis server of url, such as
+// redis://:password@localhost:6379/0.
+func New(ctx context.Context, url string, opts ...Option) (*Queue, error) {
+	ropts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	q := Queue{client: redis.NewClient(ropts)}
+	q.setPrefix(DefaultPrefix)
+
+	for _, opt := range opts {
+		opt(&q)
+	}
+
+	if err := q.client.Ping(ctx).Err(); err != nil {
+		_ = q.client.Close()
+
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+
+	return &q, nil
+}
+
+func (q *Queue) setPrefix(prefix string) {
+	q.pending = prefix + ":pending"
+	q.claimed = prefix + ":claimed"
+	q.owners = prefix + ":owners"
+}
+
+func (q *Queue) Push(ctx context.Context, id string) error {
+	score := float64(time.Now().UnixMilli())
+
+	return pushScript.Run(ctx, q.client, []string{q.pending, q.claimed}, id, score).Err()
+}
+
+func (q *Queue) Claim(ctx context.Context, owner string, visibility time.Duration) (string, error) {
+	now := float64(time.Now().UnixMilli())
+
+	id, err := claimScript.Run(ctx, q.client, []string{q.pending, q.claimed, q.owners}, owner, deadline(visibility), now).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+
+	return id, err
+}
+
+func (q *Queue) Take(ctx context.Context, id, owner string, visibility time.Duration) (bool, error) {
+	ok, err := takeScript.Run(ctx, q.client, []string{q.pending, q.claimed, q.owners}, id, owner, deadline(visibility)).Int()
+
+	return ok == 1, err
+}
+
+func (q *Queue) Extend(ctx context.Context, id, owner string, visibility time.Duration) error {
+	ok, err := extendScript.Run(ctx, q.client, []string{q.claimed, q.owners}, id, owner, deadline(visibility)).Int()
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		return web.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (q *Queue) Ack(ctx context.Context, id, owner string) error {
+	ok, err := ackScript.Run(ctx, q.client, []string{q.claimed, q.owners}, id, owner).Int()
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		return web.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (q *Queue) Nack(ctx context.Context, id, owner string, delay time.Duration) error {
+	score := float64(time.Now().Add(delay).UnixMilli())
+
+	ok, err := nackScript.Run(ctx, q.client, []string{q.pending, q.claimed, q.owners}, id, owner, score).Int()
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		return web.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (q *Queue) Owner(ctx context.Context, id string) (string, error) {
+	var (
+		score *redis.FloatCmd
+		owner *redis.StringCmd
+	)
+
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		score = pipe.ZScore(ctx, q.claimed, id)
+		owner = pipe.HGet(ctx, q.owners, id)
+
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if score.Val() < float64(time.Now().UnixMilli()) {
+		return "", nil
+	}
+
+	return owner.Val(), nil
+}
+
+func (q *Queue) Expired(ctx context.Context) ([]string, error) {
+	now := float64(time.Now().UnixMilli())
+
+	return expiredScript.Run(ctx, q.client, []string{q.claimed, q.owners}, now).StringSlice()
+}
+
+func (q *Queue) Remove(ctx context.Context, id string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.pending, id)
+		pipe.ZRem(ctx, q.claimed, id)
+		pipe.HDel(ctx, q.owners, id)
+
+		return nil
+	})
+
+	return err
+}
+
+// Close closes the connections to Redis.
+func (q *Queue) Close() error {
+	return q.client.Close()
+}
+
+// deadline is the score of a claim visible again after visibility, or never
+// when visibility is 0.
+func deadline(visibility time.Duration) any {
+	if visibility <= 0 {
+		return "+inf"
+	}
+
+	return float64(time.Now().Add(visibility).UnixMilli())
+}
@@ -0,0 +1,464 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Sizes of a market report.
+const (
+	ReportTopCategories = 10
+	ReportTopRated      = 10
+	// ReportMinReviews is the number of reviews a place needs to be among
+	// the top rated, so that a single 5 star review does not make it.
+	ReportMinReviews = 10
+	// ReportMaxLeads is the number of places without a website listed.
+	ReportMaxLeads = 25
+)
+
+// Size of the map thumbnail of a report, in SVG units.
+const (
+	reportMapWidth  = 480
+	reportMapHeight = 320
+	reportMapMargin = 10
+)
+
+// reportRatingBuckets are the bounds of the rating distribution of a
+// report, the lower one included.
+var reportRatingBuckets = []struct {
+	label    string
+	min, max float64
+}{
+	{"1 – 2", 0, 2},
+	{"2 – 3", 2, 3},
+	{"3 – 4", 3, 4},
+	{"4 – 4.5", 4, 4.5},
+	{"4.5 – 5", 4.5, 5.01},
+}
+
+// MarketReport sums up the places of a finished job as a market summary an
+// agency can hand to its client.
+type MarketReport struct {
+	JobID    string    `json:"job_id"`
+	JobName  string    `json:"job_name"`
+	Keywords []string  `json:"keywords"`
+	JobDate  time.Time `json:"job_date"`
+	Date     time.Time `json:"date"`
+	Places   int       `json:"places"`
+	// Categories counts the places by category, the most frequent first,
+	// the others summed up in Other.
+	Categories []ReportCount `json:"categories"`
+	Other      int           `json:"other_categories"`
+	// Ratings is the rating distribution of the Rated places, AvgRating
+	// their average.
+	Ratings   []ReportCount `json:"ratings"`
+	Rated     int           `json:"rated"`
+	AvgRating float64       `json:"avg_rating"`
+	// TopRated are the best rated places with at least ReportMinReviews
+	// reviews.
+	TopRated []ReportPlace `json:"top_rated"`
+	// NoWebsite counts the places without a website, Leads lists those
+	// with the most reviews.
+	NoWebsite ReportCount   `json:"no_website"`
+	Leads     []ReportPlace `json:"leads"`
+	// NoEmail counts the places without an email, only when the job
+	// searched for them.
+	NoEmail *ReportCount `json:"no_email,omitempty"`
+	// Map is nil when no place has coordinates.
+	Map *ReportMap `json:"map,omitempty"`
+}
+
+// ReportCount is a number of places, with their share of all the places of
+// a report, rounded down.
+type ReportCount struct {
+	Label   string `json:"label,omitempty"`
+	Count   int    `json:"count"`
+	Percent int    `json:"percent"`
+}
+
+// ReportPlace is a place listed by a report.
+type ReportPlace struct {
+	Title       string  `json:"title"`
+	Category    string  `json:"category"`
+	Address     string  `json:"address"`
+	Phone       string  `json:"phone"`
+	Rating      float64 `json:"rating"`
+	ReviewCount int     `json:"review_count"`
+	Link        string  `json:"link"`
+}
+
+// ReportMap places the places with coordinates in a Width by Height box,
+// north up, for a map thumbnail without tiles.
+type ReportMap struct {
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Bounds HeatmapBounds `json:"bounds"`
+	Points []ReportPoint `json:"points"`
+}
+
+// ReportPoint is a place on a ReportMap, Rated when it has a rating of 4 or
+// more.
+type ReportPoint struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Rated bool    `json:"rated"`
+}
+
+// PDFPrinter prints an HTML page to a PDF document. The page is standalone:
+// it cannot load anything from the server.
+type PDFPrinter interface {
+	PrintPDF(ctx context.Context, html []byte) ([]byte, error)
+}
+
+// WithPDFPrinter lets the server give the market reports as PDF.
+func WithPDFPrinter(p PDFPrinter) ServerOption {
+	return func(s *Server) {
+		s.pdf = p
+	}
+}
+
+// BuildMarketReport sums up entries, those of job. emails tells whether the
+// job searched for emails.
+func BuildMarketReport(entries []gmaps.Entry, emails bool) MarketReport {
+	ans := MarketReport{
+		Places:     len(entries),
+		Categories: []ReportCount{},
+		TopRated:   []ReportPlace{},
+		Leads:      []ReportPlace{},
+	}
+
+	share := func(label string, n int) ReportCount {
+		c := ReportCount{Label: label, Count: n}
+		if len(entries) > 0 {
+			c.Percent = n * 100 / len(entries)
+		}
+
+		return c
+	}
+
+	counts := map[string]int{}
+	ratings := make([]int, len(reportRatingBuckets))
+	sum := 0.0
+	noWebsite := 0
+	noEmail := 0
+
+	var top, leads []*gmaps.Entry
+
+	for i := range entries {
+		e := &entries[i]
+
+		category := strings.TrimSpace(e.Category)
+		if category == "" {
+			category = "—"
+		}
+
+		counts[category]++
+
+		if e.ReviewRating > 0 {
+			ans.Rated++
+			sum += e.ReviewRating
+
+			for j, b := range reportRatingBuckets {
+				if e.ReviewRating >= b.min && e.ReviewRating < b.max {
+					ratings[j]++
+
+					break
+				}
+			}
+
+			if e.ReviewCount >= ReportMinReviews {
+				top = append(top, e)
+			}
+		}
+
+		if strings.TrimSpace(e.WebSite) == "" {
+			noWebsite++
+
+			leads = append(leads, e)
+		}
+
+		if len(e.Emails) == 0 {
+			noEmail++
+		}
+	}
+
+	for name, n := range counts {
+		ans.Categories = append(ans.Categories, share(name, n))
+	}
+
+	sort.Slice(ans.Categories, func(i, j int) bool {
+		a, b := ans.Categories[i], ans.Categories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+
+		return a.Label < b.Label
+	})
+
+	if len(ans.Categories) > ReportTopCategories {
+		for _, c := range ans.Categories[ReportTopCategories:] {
+			ans.Other += c.Count
+		}
+
+		ans.Categories = ans.Categories[:ReportTopCategories]
+	}
+
+	for i, b := range reportRatingBuckets {
+		c := ReportCount{Label: b.label, Count: ratings[i]}
+		if ans.Rated > 0 {
+			c.Percent = ratings[i] * 100 / ans.Rated
+		}
+
+		ans.Ratings = append(ans.Ratings, c)
+	}
+
+	if ans.Rated > 0 {
+		ans.AvgRating = math.Round(sum/float64(ans.Rated)*100) / 100
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].ReviewRating != top[j].ReviewRating {
+			return top[i].ReviewRating > top[j].ReviewRating
+		}
+
+		return top[i].ReviewCount > top[j].ReviewCount
+	})
+
+	for _, e := range top[:min(len(top), ReportTopRated)] {
+		ans.TopRated = append(ans.TopRated, reportPlace(e))
+	}
+
+	// the places with the most reviews are the busiest businesses without
+	// a website, the best leads
+	sort.SliceStable(leads, func(i, j int) bool {
+		return leads[i].ReviewCount > leads[j].ReviewCount
+	})
+
+	for _, e := range leads[:min(len(leads), ReportMaxLeads)] {
+		ans.Leads = append(ans.Leads, reportPlace(e))
+	}
+
+	ans.NoWebsite = share("", noWebsite)
+
+	if emails {
+		c := share("", noEmail)
+		ans.NoEmail = &c
+	}
+
+	ans.Map = buildReportMap(entries)
+
+	return ans
+}
+
+func reportPlace(e *gmaps.Entry) ReportPlace {
+	return ReportPlace{
+		Title:       e.Title,
+		Category:    e.Category,
+		Address:     e.Address,
+		Phone:       e.Phone,
+		Rating:      e.ReviewRating,
+		ReviewCount: e.ReviewCount,
+		Link:        e.Link,
+	}
+}
+
+// buildReportMap projects the places with coordinates on the map
+// thumbnail, nil when there are none.
+func buildReportMap(entries []gmaps.Entry) *ReportMap {
+	heatmap := BuildHeatmap(entries, HeatmapMaxCellSize)
+	if heatmap.Bounds == nil {
+		return nil
+	}
+
+	b := *heatmap.Bounds
+
+	// a degree of longitude shrinks away from the equator
+	scaleX := math.Max(math.Cos((b.MinLat+b.MaxLat)/2*math.Pi/180), 0.01)
+	spanX := math.Max((b.MaxLon-b.MinLon)*scaleX, 1e-6)
+	spanY := math.Max(b.MaxLat-b.MinLat, 1e-6)
+
+	inner := [2]float64{reportMapWidth - 2*reportMapMargin, reportMapHeight - 2*reportMapMargin}
+	scale := math.Min(inner[0]/spanX, inner[1]/spanY)
+
+	// centered in the box
+	offX := reportMapMargin + (inner[0]-spanX*scale)/2
+	offY := reportMapMargin + (inner[1]-spanY*scale)/2
+
+	ans := ReportMap{
+		Width:  reportMapWidth,
+		Height: reportMapHeight,
+		Bounds: b,
+		Points: []ReportPoint{},
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Latitude == 0 && e.Longtitude == 0 {
+			continue
+		}
+
+		ans.Points = append(ans.Points, ReportPoint{
+			X:     math.Round((offX+(e.Longtitude-b.MinLon)*scaleX*scale)*10) / 10,
+			Y:     math.Round((offY+(b.MaxLat-e.Latitude)*scale)*10) / 10,
+			Rated: e.ReviewRating >= 4,
+		})
+	}
+
+	return &ans
+}
+
+// MarketReport returns the market report of job id, ErrJobNotFinished while
+// it runs.
+func (s *Service) MarketReport(ctx context.Context, id string) (MarketReport, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		// the repositories differ in how they tell a missing job
+		return MarketReport{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if !isFinished(job.Status) {
+		return MarketReport{}, fmt.Errorf("%w: job %s is %s", ErrJobNotFinished, id, job.Status)
+	}
+
+	entries, err := s.loadEntries(ctx, id)
+	if err != nil {
+		return MarketReport{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	ans := BuildMarketReport(entries, job.Data.Email)
+	ans.JobID = job.ID
+	ans.JobName = job.Name
+	ans.Keywords = job.Data.Keywords
+	ans.JobDate = job.Date
+	ans.Date = time.Now().UTC()
+
+	return ans, nil
+}
+
+// apiReport sends the market report of a job, as a standalone HTML page, a
+// PDF document with format=pdf or JSON with format=json.
+func (s *Server) apiReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+
+	switch format {
+	case "", "html", "json":
+	case "pdf":
+		if s.pdf == nil {
+			renderJSON(w, http.StatusNotImplemented, apiError{
+				Code:    http.StatusNotImplemented,
+				Message: "PDF reports are not available on this server",
+			})
+
+			return
+		}
+	default:
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "format must be html, pdf or json",
+		})
+
+		return
+	}
+
+	report, err := s.svc.MarketReport(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	case errors.Is(err, ErrJobNotFinished):
+		renderJSON(w, http.StatusConflict, apiError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		})
+
+		return
+	case err != nil:
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if format == "json" {
+		renderJSON(w, http.StatusOK, report)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/report.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	data := struct {
+		MarketReport
+		Brand      Branding
+		MinReviews int
+	}{report, s.brand(r.Context()), ReportMinReviews}
+
+	if format != "pdf" {
+		s.render(w, r, tmpl, data)
+
+		return
+	}
+
+	rec := &bufferResponse{header: http.Header{}}
+	s.render(rec, r, tmpl, data)
+
+	pdf, err := s.pdf.PrintPDF(r.Context(), rec.body.Bytes())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "report-"+report.JobID+".pdf"))
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write(pdf)
+}
+
+// bufferResponse is a http.ResponseWriter keeping the body, to print a
+// rendered page.
+type bufferResponse struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *bufferResponse) Header() http.Header { return b.header }
+
+func (b *bufferResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferResponse) WriteHeader(int) {}
@@ -0,0 +1,455 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// The JSON results of a big job weigh hundreds of MB. The records and the
+// preview page them through an index kept next to them, which locates every
+// place in the file and holds the few fields they filter and show: a page
+// then reads only its places from the file. The index is written again when
+// the results change, and the sealed results are not indexed, so that no
+// place is left in the clear next to them.
+
+// resultIndexSuffix names the index of the JSON results, after their name.
+const resultIndexSuffix = ".idx"
+
+const resultIndexVersion = 2
+
+// resultIndexCacheSize is the number of indexes kept in memory.
+const resultIndexCacheSize = 8
+
+// errNotIndexed is returned for results that are not indexed: sealed, or
+// not written yet.
+var errNotIndexed = errors.New("results not indexed")
+
+// resultIndex locates the places of a JSON results file, as it was when
+// indexed.
+type resultIndex struct {
+	Version  int
+	Size     int64
+	Modified int64
+	Rows     []resultIndexRow
+}
+
+// resultIndexRow is a place of the results: where it is in the file and
+// the fields of the preview and of the records filters.
+type resultIndexRow struct {
+	Offset int64 `json:"-"`
+	Length int64 `json:"-"`
+
+	Title           string   `json:"title"`
+	Category        string   `json:"category"`
+	Address         string   `json:"address"`
+	Phone           string   `json:"phone"`
+	WebSite         string   `json:"web_site"`
+	ReviewCount     int      `json:"review_count"`
+	ReviewRating    float64  `json:"review_rating"`
+	Emails          []string `json:"emails"`
+	EmailConfidence int      `json:"email_confidence"`
+	Query           string   `json:"query"`
+	ChainID         string   `json:"chain_id"`
+
+	// the identity of the place, see gmaps.Entry.IdentityKey
+	Cid     string `json:"cid"`
+	PlaceID string `json:"place_id"`
+	DataID  string `json:"data_id"`
+	Link    string `json:"link"`
+}
+
+// entry returns the place of r as far as the index knows it.
+func (r *resultIndexRow) entry() gmaps.Entry {
+	return gmaps.Entry{
+		Title:           r.Title,
+		Category:        r.Category,
+		Address:         r.Address,
+		Phone:           r.Phone,
+		WebSite:         r.WebSite,
+		ReviewCount:     r.ReviewCount,
+		ReviewRating:    r.ReviewRating,
+		Emails:          r.Emails,
+		EmailConfidence: r.EmailConfidence,
+		Query:           r.Query,
+		ChainID:         r.ChainID,
+		Cid:             r.Cid,
+		PlaceID:         r.PlaceID,
+		DataID:          r.DataID,
+		Link:            r.Link,
+	}
+}
+
+// resultIndexCache holds the indexes read last, by results path.
+type resultIndexCache struct {
+	mu      sync.Mutex
+	indexes map[string]*resultIndex
+	order   []string
+}
+
+func (c *resultIndexCache) get(path string, info os.FileInfo) *resultIndex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.indexes[path]
+	if idx == nil || !idx.matches(info) {
+		return nil
+	}
+
+	return idx
+}
+
+func (c *resultIndexCache) put(path string, idx *resultIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexes == nil {
+		c.indexes = map[string]*resultIndex{}
+	}
+
+	if _, ok := c.indexes[path]; !ok {
+		c.order = append(c.order, path)
+	}
+
+	c.indexes[path] = idx
+
+	if len(c.order) > resultIndexCacheSize {
+		delete(c.indexes, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (idx *resultIndex) matches(info os.FileInfo) bool {
+	return idx.Version == resultIndexVersion && idx.Size == info.Size() && idx.Modified == info.ModTime().UnixNano()
+}
+
+// resultsIndex returns the index of the JSON results at path, read from
+// memory or from its file, or built again when the results changed since.
+func (s *Service) resultsIndex(path string) (*resultIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx := s.indexes.get(path, info); idx != nil {
+		return idx, nil
+	}
+
+	if sealed, err := isSealedFile(path); err != nil || sealed {
+		return nil, errNotIndexed
+	}
+
+	idx, err := readResultIndex(path+resultIndexSuffix, info)
+	if err != nil {
+		idx, err = buildResultIndex(path, info)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeResultIndex(path+resultIndexSuffix, idx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.indexes.put(path, idx)
+
+	return idx, nil
+}
+
+func readResultIndex(path string, info os.FileInfo) (*resultIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var idx resultIndex
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&idx); err != nil {
+		return nil, err
+	}
+
+	if !idx.matches(info) {
+		return nil, errors.New("stale index")
+	}
+
+	return &idx, nil
+}
+
+func writeResultIndex(path string, idx *resultIndex) error {
+	return WriteFileAtomic(path, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+
+		if err := gob.NewEncoder(bw).Encode(idx); err != nil {
+			return err
+		}
+
+		return bw.Flush()
+	})
+}
+
+// buildResultIndex indexes the JSON results at path, reading one place at a
+// time.
+func buildResultIndex(path string, info os.FileInfo) (*resultIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	idx := resultIndex{
+		Version:  resultIndexVersion,
+		Size:     info.Size(),
+		Modified: info.ModTime().UnixNano(),
+		Rows:     []resultIndexRow{},
+	}
+
+	err = scanEntries(f, func(raw json.RawMessage, offset int64) error {
+		var row resultIndexRow
+		if err := json.Unmarshal(raw, &row); err != nil {
+			return err
+		}
+
+		row.Offset = offset
+		row.Length = int64(len(raw))
+		idx.Rows = append(idx.Rows, row)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("indexing %s: %w", path, err)
+	}
+
+	// older result files carry no chain_id
+	entries := make([]*gmaps.Entry, len(idx.Rows))
+
+	for i := range idx.Rows {
+		e := idx.Rows[i].entry()
+		entries[i] = &e
+	}
+
+	gmaps.AssignChains(entries)
+
+	for i := range idx.Rows {
+		idx.Rows[i].ChainID = entries[i].ChainID
+	}
+
+	return &idx, nil
+}
+
+// indexedRecords returns the records of the final results of job jobID
+// matching filter through their index, which reads only the places asked
+// for. It fails when the job has no indexed results.
+func (s *Service) indexedRecords(ctx context.Context, jobID string, filter RecordFilter) (recordSet, error) {
+	path, err := s.resultsPath(ctx, jobID)
+	if err != nil {
+		return recordSet{}, err
+	}
+
+	idx, err := s.resultsIndex(path)
+	if err != nil {
+		return recordSet{}, err
+	}
+
+	search := strings.ToLower(filter.Search)
+
+	set := recordSet{
+		key: func(i int) string {
+			e := idx.Rows[i].entry()
+
+			return e.IdentityKey()
+		},
+		load: func(positions []int) ([]gmaps.Entry, error) {
+			rows := make([]resultIndexRow, len(positions))
+			for i, p := range positions {
+				rows[i] = idx.Rows[p]
+			}
+
+			return readIndexed(path, rows)
+		},
+		size: len(idx.Rows),
+	}
+
+	for i := range idx.Rows {
+		e := idx.Rows[i].entry()
+
+		if recordMatches(&e, &filter, search) {
+			set.matched = append(set.matched, i)
+		}
+	}
+
+	return set, nil
+}
+
+// summaryResults is GetResults with, when the results are indexed, only the
+// fields of the index: title, category, address, phone, website, reviews,
+// emails, query and chain.
+func (s *Service) summaryResults(ctx context.Context, id string) ([]gmaps.Entry, bool, error) {
+	if path, err := s.resultsPath(ctx, id); err == nil {
+		if idx, err := s.resultsIndex(path); err == nil {
+			entries := make([]gmaps.Entry, len(idx.Rows))
+			for i := range idx.Rows {
+				entries[i] = idx.Rows[i].entry()
+			}
+
+			return entries, false, nil
+		}
+	}
+
+	return s.GetResults(ctx, id)
+}
+
+// resultsPath returns the path of the final JSON results of job id.
+func (s *Service) resultsPath(ctx context.Context, id string) (string, error) {
+	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid file name")
+	}
+
+	return filepath.Join(s.folder(ctx), id+".json"), nil
+}
+
+// openResultFile opens the result file at path to be read as it was
+// written: a sealed file is decrypted, in memory.
+func (s *Service) openResultFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+
+	head, _ := r.Peek(len(sealedMagic))
+	if !bytes.Equal(head, sealedMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{r, f}, nil
+	}
+
+	defer f.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = s.openBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// readIndexed reads the places of rows from the JSON results at path.
+func readIndexed(path string, rows []resultIndexRow) ([]gmaps.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	entries := make([]gmaps.Entry, len(rows))
+
+	for i := range rows {
+		raw := make([]byte, rows[i].Length)
+
+		if _, err := f.ReadAt(raw, rows[i].Offset); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(raw, &entries[i]); err != nil {
+			return nil, err
+		}
+
+		entries[i].ChainID = rows[i].ChainID
+	}
+
+	return entries, nil
+}
+
+// scanEntries calls fn with every place of the JSON array of r and its
+// offset in r, holding one place in memory at a time.
+func scanEntries(r io.Reader, fn func(raw json.RawMessage, offset int64) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	// no places, written as null
+	if tok == nil {
+		return nil
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("the results are not a JSON array")
+	}
+
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		if err := fn(raw, dec.InputOffset()-int64(len(raw))); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+
+	return err
+}
+
+// decodeEntries decodes the JSON array of places of r one at a time,
+// instead of holding the whole array and its places in memory.
+func decodeEntries[E gmaps.Entry | *gmaps.Entry](r io.Reader) ([]E, error) {
+	entries := []E{}
+
+	err := scanEntries(r, func(raw json.RawMessage, _ int64) error {
+		var e E
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+
+		entries = append(entries, e)
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// recordMatches tells whether the records filtered by filter, searching
+// search in lowercase, keep e.
+func recordMatches(e *gmaps.Entry, filter *RecordFilter, search string) bool {
+	if filter.ExcludeChains && e.ChainID != "" {
+		return false
+	}
+
+	if !e.HasEmailConfidence(filter.MinEmailConfidence) {
+		return false
+	}
+
+	return search == "" ||
+		strings.Contains(strings.ToLower(e.Title), search) ||
+		strings.Contains(strings.ToLower(e.Address), search) ||
+		strings.Contains(strings.ToLower(e.Phone), search) ||
+		strings.Contains(strings.ToLower(strings.Join(e.Emails, " ")), search) ||
+		strings.Contains(strings.ToLower(e.Query), search)
+}
@@ -0,0 +1,258 @@
+package web
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// JournalFormat is the extension of the file a running job appends its
+// results to, one JSON entry per line, until its final files are written.
+const JournalFormat = "ndjson"
+
+// WriteFileAtomic writes path through write into a temporary file of the
+// same folder, renamed over path once complete: readers see the previous
+// file or the new one, never a truncated one.
+func WriteFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// CSVLayout picks the columns of the CSV results.
+type CSVLayout int
+
+const (
+	// CSVDefault has the columns of gmaps.Entry.CsvHeaders.
+	CSVDefault CSVLayout = iota
+	// CSVProvenance appends the provenance of the entries to them.
+	CSVProvenance
+	// CSVUpstream has exactly the columns of the upstream scraper, see
+	// gmaps.UpstreamCSV.
+	CSVUpstream
+)
+
+// WithCSVProvenance appends the provenance of the entries to the columns of
+// the CSV results.
+func WithCSVProvenance() ServiceOption {
+	return func(s *Service) {
+		s.csvLayout = CSVProvenance
+	}
+}
+
+// WithCSVUpstream writes the CSV results with exactly the columns of the
+// upstream scraper.
+func WithCSVUpstream() ServiceOption {
+	return func(s *Service) {
+		s.csvLayout = CSVUpstream
+	}
+}
+
+// WriteCSV writes entries as CSV in layout, with a header row unless there
+// are none.
+func WriteCSV(w io.Writer, entries []*gmaps.Entry, layout CSVLayout) error {
+	cw := csv.NewWriter(w)
+
+	if len(entries) > 0 {
+		if err := cw.Write(csvHeaders(entries[0], layout)); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if err := cw.Write(csvRow(e, layout)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// csvHeaders returns the CSV columns of the entries of a job, which share
+// the custom fields of e.
+func csvHeaders(e *gmaps.Entry, layout CSVLayout) []string {
+	switch layout {
+	case CSVProvenance:
+		return gmaps.ProvenanceCSV{Entry: e}.CsvHeaders()
+	case CSVUpstream:
+		return gmaps.UpstreamCSV{Entry: e}.CsvHeaders()
+	default:
+		return e.CsvHeaders()
+	}
+}
+
+func csvRow(e *gmaps.Entry, layout CSVLayout) []string {
+	switch layout {
+	case CSVProvenance:
+		return gmaps.ProvenanceCSV{Entry: e}.CsvRow()
+	case CSVUpstream:
+		return gmaps.UpstreamCSV{Entry: e}.CsvRow()
+	default:
+		return e.CsvRow()
+	}
+}
+
+// WriteResults writes the final CSV and JSON results of job id in folder,
+// then drops its journal. csvLayout is passed to WriteCSV.
+func WriteResults(folder, id string, entries []*gmaps.Entry, csvLayout CSVLayout) error {
+	if entries == nil {
+		entries = []*gmaps.Entry{}
+	}
+
+	// chains can only be found once all the results are known
+	gmaps.AssignChains(entries)
+
+	base := filepath.Join(folder, id)
+
+	err := WriteFileAtomic(base+".csv", func(w io.Writer) error {
+		return WriteCSV(w, entries, csvLayout)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = WriteFileAtomic(base+".json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(base + "." + JournalFormat); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return removeMapsResults(folder, id)
+}
+
+// readResults reads the final JSON results of a job.
+func (s *Service) readResults(path string) ([]*gmaps.Entry, error) {
+	f, err := s.openResultFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return decodeEntries[*gmaps.Entry](f)
+}
+
+// ReadJournal reads the entries appended to a journal. A last entry cut
+// short by a crash is left out.
+func ReadJournal(path string) ([]*gmaps.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var entries []*gmaps.Entry
+
+	dec := json.NewDecoder(f)
+
+	for {
+		var e gmaps.Entry
+
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, &e)
+	}
+}
+
+// RecoverInterrupted fails the local jobs left working by a previous run,
+// which stopped before finishing them, and keeps the results they had
+// already written. It returns the number of jobs recovered. With a queue,
+// only the jobs claimed by this host, or whose claim expired, were left by
+// a previous run: the others run in the processes sharing the queue.
+func (s *Service) RecoverInterrupted(ctx context.Context) (int, error) {
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusWorking})
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+
+	for i := range jobs {
+		// the jobs of the remote workers are requeued once their lease expires
+		if jobs[i].Stats.Worker != nil {
+			continue
+		}
+
+		if s.queue != nil {
+			owner, err := s.queue.Owner(ctx, jobs[i].ID)
+			if err != nil {
+				return recovered, err
+			}
+
+			if owner != "" && owner != s.host {
+				continue
+			}
+		}
+
+		folder := TenantFolder(s.dataFolder, jobs[i].Tenant)
+
+		entries, err := ReadJournal(filepath.Join(folder, jobs[i].ID+"."+JournalFormat))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("job %s: reading interrupted results: %v", jobs[i].ID, err)
+		}
+
+		if entries != nil {
+			if err := WriteResults(folder, jobs[i].ID, entries, s.csvLayout); err != nil {
+				return recovered, err
+			}
+		}
+
+		jobs[i].Status = StatusFailed
+
+		if err := s.Update(ctx, &jobs[i]); err != nil {
+			return recovered, err
+		}
+
+		log.Printf("job %s was interrupted, kept its %d results", jobs[i].ID, len(entries))
+
+		recovered++
+	}
+
+	return recovered, nil
+}
@@ -0,0 +1,241 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// RouteMaxStops caps the records of a route.
+const RouteMaxStops = 500
+
+// ErrUnknownRecord is returned for a record ID missing from the results of
+// a job.
+var ErrUnknownRecord = errors.New("unknown record")
+
+// Route is the visiting route through records of a job, see
+// gmaps.PlanRoute.
+type Route struct {
+	Stops []gmaps.RouteStop
+	// Skipped are the records without coordinates, left out of the route.
+	Skipped []int
+	Partial bool
+}
+
+// Route plans the route through the records of job id, their IDs as in
+// the records API (1 for the first place), from start or from the first
+// record when nil. While the job runs it covers the places written so far.
+func (s *Service) Route(ctx context.Context, id string, records []int, start *gmaps.RoutePoint) (Route, error) {
+	entries, partial, err := s.loadResults(ctx, id)
+	if err != nil {
+		return Route{}, err
+	}
+
+	ans := Route{Partial: partial, Skipped: []int{}}
+
+	selected := make([]*gmaps.Entry, 0, len(records))
+	ids := make([]int, 0, len(records))
+	seen := map[int]bool{}
+
+	for _, record := range records {
+		if record < 1 || record > len(entries) {
+			return Route{}, fmt.Errorf("%w %d", ErrUnknownRecord, record)
+		}
+
+		if seen[record] {
+			continue
+		}
+
+		seen[record] = true
+
+		e := &entries[record-1]
+		if e.Latitude == 0 && e.Longtitude == 0 {
+			ans.Skipped = append(ans.Skipped, record)
+
+			continue
+		}
+
+		selected = append(selected, e)
+		ids = append(ids, record)
+	}
+
+	ans.Stops = gmaps.PlanRoute(selected, start)
+
+	// back to the record IDs
+	for i := range ans.Stops {
+		ans.Stops[i].Index = ids[ans.Stops[i].Index]
+	}
+
+	return ans, nil
+}
+
+// parseRouteRecords parses the comma separated record IDs of a route.
+func parseRouteRecords(v string) ([]int, error) {
+	var ans []int
+
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record %q", part)
+		}
+
+		ans = append(ans, n)
+	}
+
+	if len(ans) == 0 {
+		return nil, errors.New("missing records")
+	}
+
+	if len(ans) > RouteMaxStops {
+		return nil, fmt.Errorf("too many records, a route has at most %d", RouteMaxStops)
+	}
+
+	return ans, nil
+}
+
+// parseRoutePoint parses the start of a route, "lat,lon".
+func parseRoutePoint(v string) (*gmaps.RoutePoint, error) {
+	latS, lonS, ok := strings.Cut(v, ",")
+
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(latS), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(lonS), 64)
+
+	if !ok || errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("invalid start %q: use lat,lon", v)
+	}
+
+	return &gmaps.RoutePoint{Lat: lat, Lon: lon}, nil
+}
+
+type apiRouteStop struct {
+	Record   int     `json:"record"`
+	Title    string  `json:"title"`
+	Address  string  `json:"address"`
+	Phone    string  `json:"phone"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Link     string  `json:"link"`
+	Distance float64 `json:"distance_meters"`
+}
+
+type apiRouteResponse struct {
+	Stops           []apiRouteStop `json:"stops"`
+	DistanceMeters  float64        `json:"distance_meters"`
+	GoogleMapsLinks []string       `json:"google_maps_links"`
+	Skipped         []int          `json:"skipped"`
+	Partial         bool           `json:"partial"`
+}
+
+// apiRoute orders the records of a job into a visiting route, returned as
+// JSON with its Google Maps directions links, or with format=gpx as a GPX
+// file.
+func (s *Server) apiRoute(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	records, err := parseRouteRecords(r.URL.Query().Get("records"))
+
+	var start *gmaps.RoutePoint
+	if v := r.URL.Query().Get("start"); err == nil && v != "" {
+		start, err = parseRoutePoint(v)
+	}
+
+	outFormat := r.URL.Query().Get("format")
+	if err == nil && outFormat != "" && outFormat != "json" && outFormat != "gpx" {
+		err = fmt.Errorf("invalid format %q: use json or gpx", outFormat)
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	route, err := s.svc.Route(r.Context(), id.String(), records, start)
+	if errors.Is(err, ErrUnknownRecord) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if outFormat == "gpx" {
+		format, _, err := s.exportFormat(r)
+		if err != nil {
+			format = ExportFormat{}
+		}
+
+		name := s.exportFilename(r.Context(), id.String(), &format, "gpx")
+
+		w.Header().Set("Content-Disposition", contentDisposition(name))
+		w.Header().Set("Content-Type", "application/gpx+xml")
+
+		routeName := id.String()
+		if job, err := s.svc.Get(r.Context(), id.String()); err == nil {
+			routeName = job.Name
+		}
+
+		_ = gmaps.WriteGPX(w, routeName, route.Stops, start)
+
+		return
+	}
+
+	ans := apiRouteResponse{
+		Stops:           make([]apiRouteStop, 0, len(route.Stops)),
+		DistanceMeters:  math.Round(gmaps.RouteDistance(route.Stops)),
+		GoogleMapsLinks: gmaps.GoogleMapsRouteLinks(route.Stops, start),
+		Skipped:         route.Skipped,
+		Partial:         route.Partial,
+	}
+
+	if ans.GoogleMapsLinks == nil {
+		ans.GoogleMapsLinks = []string{}
+	}
+
+	for _, stop := range route.Stops {
+		ans.Stops = append(ans.Stops, apiRouteStop{
+			Record:   stop.Index,
+			Title:    stop.Entry.Title,
+			Address:  stop.Entry.Address,
+			Phone:    stop.Entry.Phone,
+			Lat:      stop.Entry.Latitude,
+			Lon:      stop.Entry.Longtitude,
+			Link:     stop.Entry.Link,
+			Distance: math.Round(stop.Distance),
+		})
+	}
+
+	renderJSON(w, http.StatusOK, ans)
+}
@@ -0,0 +1,382 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Limits of a sandbox run, kept low so that it answers while the user waits.
+const (
+	SandboxDefaultPlaces  = 5
+	SandboxMaxPlaces      = 20
+	SandboxDefaultTimeout = 60 * time.Second
+	SandboxMaxTimeout     = 120 * time.Second
+)
+
+var (
+	// ErrSandboxBusy is returned while another sandbox run is in progress.
+	ErrSandboxBusy = errors.New("another sandbox run is in progress")
+	// ErrSandboxUnavailable is returned by the hosts that do not scrape,
+	// like a coordinator of remote workers.
+	ErrSandboxUnavailable = errors.New("sandbox not available on this host")
+)
+
+// SandboxRequest is a single keyword to try with the settings of a job.
+// The depth is always 1 and the places are capped to SandboxMaxPlaces.
+type SandboxRequest struct {
+	Keyword string `json:"keyword"`
+	// Timeout is in seconds, SandboxDefaultTimeout when 0.
+	Timeout int `json:"timeout"`
+	JobData
+}
+
+// SandboxTimings tells where the time of a sandbox run went, in
+// milliseconds.
+type SandboxTimings struct {
+	// Search is the time until the search page was parsed, Places the time
+	// the places took after it.
+	Search int64 `json:"search_ms"`
+	Places int64 `json:"places_ms"`
+	Total  int64 `json:"total_ms"`
+}
+
+// SandboxResult is the outcome of a sandbox run.
+type SandboxResult struct {
+	Keyword string `json:"keyword"`
+	// Status is one of the gmaps keyword statuses.
+	Status string `json:"status"`
+	// TimedOut is set when the run was stopped by its timeout.
+	TimedOut bool `json:"timed_out"`
+	// Found counts the places listed by the first page of results, Places
+	// the ones scraped among them.
+	Found       int                    `json:"found"`
+	Places      []*gmaps.Entry         `json:"places"`
+	Timings     SandboxTimings         `json:"timings"`
+	FetchErrors gmaps.FetchErrorReport `json:"fetch_errors"`
+	// Env is the configuration the keyword ran with.
+	Env       *JobEnv                    `json:"env,omitempty"`
+	Diagnosis *gmaps.ZeroResultDiagnosis `json:"diagnosis,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+}
+
+// Sandbox runs the sandbox jobs, synchronously.
+type Sandbox interface {
+	// RunSandbox scrapes job until it is done or its MaxTime is over.
+	RunSandbox(ctx context.Context, job *Job) (SandboxResult, error)
+}
+
+// WithSandbox lets the users try a keyword before creating a job.
+func WithSandbox(sandbox Sandbox) ServiceOption {
+	return func(s *Service) {
+		s.sandbox = sandbox
+	}
+}
+
+// SandboxJob returns the job trying req, with the defaults of its profile.
+// The job is not stored.
+func (s *Service) SandboxJob(ctx context.Context, req *SandboxRequest) (*Job, error) {
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword == "" {
+		return nil, errors.New("missing keyword")
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Second
+
+	switch {
+	case timeout < 0 || timeout > SandboxMaxTimeout:
+		return nil, fmt.Errorf("timeout must be between 1 and %d seconds", int(SandboxMaxTimeout.Seconds()))
+	case timeout == 0:
+		timeout = SandboxDefaultTimeout
+	}
+
+	job := Job{
+		ID:     uuid.New().String(),
+		Name:   "sandbox: " + keyword,
+		Date:   time.Now().UTC(),
+		Status: StatusWorking,
+		Data:   req.JobData,
+		Tenant: TenantFrom(ctx),
+	}
+
+	if err := s.ApplyProfile(ctx, &job.Data); err != nil {
+		return nil, err
+	}
+
+	job.Data.Keywords = []string{keyword}
+	job.Data.Depth = 1
+	job.Data.MaxTime = timeout
+
+	if job.Data.Zoom == 0 {
+		job.Data.Zoom = 15
+	}
+
+	switch {
+	case job.Data.MaxPlaces < 0 || job.Data.MaxPlaces > SandboxMaxPlaces:
+		return nil, fmt.Errorf("max places must be between 1 and %d", SandboxMaxPlaces)
+	case job.Data.MaxPlaces == 0:
+		job.Data.MaxPlaces = SandboxDefaultPlaces
+	}
+
+	job.Data.MaxEmailFetches = min(job.Data.MaxEmailFetches, job.Data.MaxPlaces)
+	if job.Data.MaxEmailFetches == 0 {
+		job.Data.MaxEmailFetches = job.Data.MaxPlaces
+	}
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// RunSandbox runs job, see SandboxJob, one at a time.
+func (s *Service) RunSandbox(ctx context.Context, job *Job) (SandboxResult, error) {
+	if s.sandbox == nil {
+		return SandboxResult{}, ErrSandboxUnavailable
+	}
+
+	if !s.sandboxBusy.CompareAndSwap(false, true) {
+		return SandboxResult{}, ErrSandboxBusy
+	}
+	defer s.sandboxBusy.Store(false)
+
+	return s.sandbox.RunSandbox(ctx, job)
+}
+
+// sandboxStatus returns the HTTP status of a sandbox error.
+func sandboxStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrSandboxBusy):
+		return http.StatusConflict
+	case errors.Is(err, ErrSandboxUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// extendWriteDeadline lets a response wait for a sandbox run of job, longer
+// than the write timeout of the server.
+func extendWriteDeadline(w http.ResponseWriter, job *Job) {
+	rc := http.NewResponseController(w)
+
+	if err := rc.SetWriteDeadline(time.Now().Add(job.Data.MaxTime + 30*time.Second)); err != nil {
+		log.Printf("sandbox: extending the write deadline: %v", err)
+	}
+}
+
+// sandboxOutcome is what sandbox_result.html renders.
+type sandboxOutcome struct {
+	SandboxResult
+	Failure string
+}
+
+type sandboxPageData struct {
+	formData
+	Keyword        string
+	Timeout        int
+	MaxPlacesLimit int
+	MaxTimeout     int
+	// Result is the run posted without JavaScript, see runSandbox.
+	Result *sandboxOutcome
+}
+
+func (s *Server) sandboxPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	s.renderSandboxPage(w, r, s.sandboxData(r))
+}
+
+func (s *Server) renderSandboxPage(w http.ResponseWriter, r *http.Request, data *sandboxPageData) {
+	tmpl, ok := s.tmpl["static/templates/sandbox.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	s.render(w, r, tmpl, data)
+}
+
+// sandboxData returns the sandbox page with the default settings.
+func (s *Server) sandboxData(r *http.Request) *sandboxPageData {
+	settings, err := s.svc.GetSettings(r.Context())
+	if err != nil {
+		log.Printf("reading settings: %v", err)
+	}
+
+	profiles, err := s.svc.Profiles(r.Context())
+	if err != nil {
+		log.Printf("listing profiles: %v", err)
+	}
+
+	return &sandboxPageData{
+		formData: formData{
+			Language:  settings.Language,
+			Zoom:      15,
+			Radius:    10000,
+			Lat:       "0",
+			Lon:       "0",
+			Email:     settings.Email,
+			Proxies:   settings.Proxies,
+			APIToken:  s.apiToken,
+			Profiles:  profiles,
+			MaxPlaces: SandboxDefaultPlaces,
+			Brand:     s.brand(r.Context()),
+		},
+		Timeout:        int(SandboxDefaultTimeout.Seconds()),
+		MaxPlacesLimit: SandboxMaxPlaces,
+		MaxTimeout:     int(SandboxMaxTimeout.Seconds()),
+	}
+}
+
+// runSandbox runs the keyword of the sandbox form and renders the result.
+func (s *Server) runSandbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/sandbox_result.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	render := func(res SandboxResult, errMsg string) {
+		outcome := sandboxOutcome{res, errMsg}
+
+		if isHTMX(r) {
+			s.render(w, r, tmpl, outcome)
+
+			return
+		}
+
+		// without JavaScript the result comes with the page and its form
+		data := s.sandboxData(r)
+		data.Result = &outcome
+		data.Keyword = r.Form.Get("keyword")
+
+		s.renderSandboxPage(w, r, data)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		render(SandboxResult{}, err.Error())
+
+		return
+	}
+
+	req := SandboxRequest{
+		Keyword: r.Form.Get("keyword"),
+		JobData: JobData{
+			Lang:         r.Form.Get("lang"),
+			FastMode:     r.Form.Get("fastmode") == "on",
+			Lat:          r.Form.Get("latitude"),
+			Lon:          r.Form.Get("longitude"),
+			Email:        r.Form.Get("email") == "on",
+			Proxies:      formLines(r.Form, "proxies"),
+			ProxyCountry: strings.ToLower(strings.TrimSpace(r.Form.Get("proxy_country"))),
+			ProxyCity:    strings.TrimSpace(r.Form.Get("proxy_city")),
+			Profile:      r.Form.Get("profile"),
+		},
+	}
+
+	var err error
+
+	for key, dst := range map[string]*int{
+		"zoom":       &req.Zoom,
+		"radius":     &req.Radius,
+		"max_places": &req.MaxPlaces,
+		"timeout":    &req.Timeout,
+	} {
+		v := strings.TrimSpace(r.Form.Get(key))
+		if v == "" {
+			continue
+		}
+
+		if *dst, err = strconv.Atoi(v); err != nil {
+			render(SandboxResult{}, "invalid "+strings.ReplaceAll(key, "_", " "))
+
+			return
+		}
+	}
+
+	job, err := s.svc.SandboxJob(r.Context(), &req)
+	if err != nil {
+		render(SandboxResult{}, err.Error())
+
+		return
+	}
+
+	extendWriteDeadline(w, job)
+
+	res, err := s.svc.RunSandbox(r.Context(), job)
+	if err != nil {
+		render(res, err.Error())
+
+		return
+	}
+
+	render(res, "")
+}
+
+func (s *Server) apiSandbox(w http.ResponseWriter, r *http.Request) {
+	var req SandboxRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	job, err := s.svc.SandboxJob(r.Context(), &req)
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	extendWriteDeadline(w, job)
+
+	res, err := s.svc.RunSandbox(r.Context(), job)
+	if err != nil {
+		code := sandboxStatus(err)
+
+		ans := apiError{
+			Code:    code,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, code, ans)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, res)
+}
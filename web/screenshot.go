@@ -0,0 +1,83 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// ScreenshotSource takes screenshots of the browser pages of the jobs
+// running on this host.
+type ScreenshotSource interface {
+	// Screenshot returns ErrJobNotRunning when job id does not run here.
+	Screenshot(ctx context.Context, id string) (gmaps.LiveScreenshot, error)
+}
+
+// WithScreenshotSource lets the service show what the browsers of its
+// running jobs are looking at.
+func WithScreenshotSource(src ScreenshotSource) ServiceOption {
+	return func(s *Service) {
+		s.screenshots = src
+	}
+}
+
+// Screenshot returns a screenshot of a browser page of running job id, see
+// gmaps.LivePages.Screenshot.
+func (s *Service) Screenshot(ctx context.Context, id string) (gmaps.LiveScreenshot, error) {
+	if _, err := s.runningHere(ctx, id); err != nil {
+		return gmaps.LiveScreenshot{}, err
+	}
+
+	if s.screenshots == nil {
+		return gmaps.LiveScreenshot{}, fmt.Errorf("%w here", ErrJobNotRunning)
+	}
+
+	return s.screenshots.Screenshot(ctx, id)
+}
+
+// screenshot sends a current screenshot of a browser page of a running job,
+// with the URL of the page and the seconds it has been in use in the
+// X-Page-URL and X-Page-Busy headers.
+func (s *Server) screenshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		http.Error(w, "Invalid ID", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	shot, err := s.svc.Screenshot(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+
+		return
+	case errors.Is(err, ErrJobNotRunning) || errors.Is(err, gmaps.ErrNoLivePage):
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Page-URL", shot.URL)
+	w.Header().Set("X-Page-Busy", strconv.Itoa(int(shot.Busy.Seconds())))
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write(shot.PNG)
+}
@@ -0,0 +1,263 @@
+package web
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The result files of a job created with encrypt are sealed with AES-256-GCM
+// once it ends: a sealed file starts with sealedMagic, then the nonce and
+// the ciphertext. The readers of the results open both sealed and plain
+// files, so the downloads decrypt them for whoever may download them.
+
+var sealedMagic = []byte("GMSSEAL1")
+
+var (
+	// ErrResultsSealed is returned when reading a sealed result file
+	// without the key, or with another one.
+	ErrResultsSealed = errors.New("the results are encrypted and cannot be decrypted with the key of this server")
+	// ErrNoResultsKey is returned when creating an encrypted job without a
+	// key.
+	ErrNoResultsKey = errors.New("encrypted results need the server to run with -results-key")
+)
+
+// WithResultsKey seals the results of the jobs created with encrypt with
+// key, 32 bytes.
+func WithResultsKey(key []byte) ServiceOption {
+	return func(s *Service) {
+		s.resultsKey = key
+	}
+}
+
+// ParseResultsKey decodes a key of WithResultsKey given in base64.
+func ParseResultsKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("results key: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("results key: %d bytes instead of 32", len(key))
+	}
+
+	return key, nil
+}
+
+func resultsAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealBytes encrypts plain with key.
+func sealBytes(key, plain []byte) ([]byte, error) {
+	aead, err := resultsAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	ans := make([]byte, 0, len(sealedMagic)+len(nonce)+len(plain)+aead.Overhead())
+	ans = append(ans, sealedMagic...)
+	ans = append(ans, nonce...)
+
+	return aead.Seal(ans, nonce, plain, nil), nil
+}
+
+// openBytes returns the content of a result file, decrypted when sealed.
+func (s *Service) openBytes(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, sealedMagic) {
+		return data, nil
+	}
+
+	if s.resultsKey == nil {
+		return nil, ErrResultsSealed
+	}
+
+	aead, err := resultsAEAD(s.resultsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(sealedMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, ErrResultsSealed
+	}
+
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrResultsSealed
+	}
+
+	return plain, nil
+}
+
+// readResultFile reads the result file at path, decrypted when sealed.
+func (s *Service) readResultFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.openBytes(data)
+}
+
+// isSealedFile reports whether the file at path is sealed.
+func isSealedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+
+	head := make([]byte, len(sealedMagic))
+
+	_, err = io.ReadFull(f, head)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	}
+
+	return bytes.Equal(head, sealedMagic), err
+}
+
+// writeResultFile writes data to the result file at path, sealed when
+// sealed is set.
+func (s *Service) writeResultFile(path string, data []byte, sealed bool) error {
+	if sealed {
+		if s.resultsKey == nil {
+			return ErrNoResultsKey
+		}
+
+		var err error
+
+		data, err = sealBytes(s.resultsKey, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := WriteFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// a compressed copy or an index would keep the plain results
+	for _, p := range []string{path + gzipSuffix, path + resultIndexSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sealFile seals the result file at path, unless it is missing or sealed
+// already.
+func (s *Service) sealFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || bytes.HasPrefix(data, sealedMagic) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.writeResultFile(path, data, true)
+}
+
+// sealEnded seals the result files of job, which just ended, when it was
+// created with encrypt, and removes the journal it may have left. A file
+// still failing at a second try is removed, with its copies, rather than
+// left in the clear, and job fails with its Stats.SealError; job is then
+// to be saved.
+func (s *Service) sealEnded(job *Job) {
+	if !job.Data.Encrypt {
+		return
+	}
+
+	folder := TenantFolder(s.dataFolder, job.Tenant)
+
+	journal := filepath.Join(folder, job.ID+"."+JournalFormat)
+	if err := os.Remove(journal); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("job %s: removing the journal left in the clear: %v", job.ID, err)
+	}
+
+	for _, format := range ResultFormats {
+		path := filepath.Join(folder, job.ID+"."+format)
+
+		err := s.sealFile(path)
+		if err != nil {
+			err = s.sealFile(path)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		log.Printf("job %s: encrypting the %s results: %v", job.ID, format, err)
+
+		for _, p := range []string{path, path + gzipSuffix, path + resultIndexSuffix} {
+			if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("job %s: removing the %s results left in the clear: %v", job.ID, format, err)
+			}
+		}
+
+		job.Status = StatusFailed
+		job.Stats.SealError = fmt.Sprintf("encrypting the %s results: %v", format, err)
+	}
+}
+
+// serveResult is serveResultFile decrypting the sealed files, which are
+// sent from memory, without a compressed copy.
+func (s *Server) serveResult(w http.ResponseWriter, r *http.Request, path, name, contentType string) {
+	sealed, err := isSealedFile(path)
+	if err != nil || !sealed {
+		serveResultFile(w, r, path, name, contentType)
+
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+
+		return
+	}
+
+	data, err := s.svc.readResultFile(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x-sealed"`, info.Size(), info.ModTime().UnixNano()))
+
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+}
@@ -0,0 +1,86 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func TestEncryptedJobLeavesNoPlainData(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	svc, folder := newTestService(t, web.WithResultsKey(key))
+	ctx := context.Background()
+
+	// a plain job keeps its history, so the encrypted one is not vacuous
+	endJob(ctx, t, svc, folder, web.JobData{Keywords: []string{"cafe"}})
+
+	_, err := svc.PlaceHistory(ctx, "1234567890", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	svc, folder = newTestService(t, web.WithResultsKey(key))
+	job := endJob(ctx, t, svc, folder, web.JobData{Keywords: []string{"cafe"}, Encrypt: true})
+
+	require.Equal(t, web.StatusOK, job.Status)
+
+	_, err = svc.PlaceHistory(ctx, "1234567890", time.Time{}, time.Time{})
+	require.ErrorIs(t, err, web.ErrNotFound)
+
+	base := filepath.Join(folder, job.ID)
+
+	for _, p := range []string{base + ".json.gz", base + ".csv.gz", base + ".json.idx", base + ".ndjson"} {
+		_, err := os.Stat(p)
+		require.ErrorIs(t, err, os.ErrNotExist, p)
+	}
+
+	for _, p := range []string{base + ".json", base + ".csv"} {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "Caffe Sport", p)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plain := []byte(`[{"title":"Caffe Sport"}]`)
+
+	sealed, err := web.SealBytes(key, plain)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "Caffe Sport")
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	svc, _ := newTestService(t, web.WithResultsKey(key))
+	other, _ := newTestService(t, web.WithResultsKey(bytes.Repeat([]byte{8}, 32)))
+	keyless, _ := newTestService(t)
+
+	tests := []struct {
+		name string
+		svc  *web.Service
+		data []byte
+		want []byte
+		err  error
+	}{
+		{"same key", svc, sealed, plain, nil},
+		{"plain file", keyless, plain, plain, nil},
+		{"other key", other, sealed, nil, web.ErrResultsSealed},
+		{"no key", keyless, sealed, nil, web.ErrResultsSealed},
+		{"tampered", svc, tampered, nil, web.ErrResultsSealed},
+		{"truncated", svc, sealed[:10], nil, web.ErrResultsSealed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.svc.OpenBytes(tt.data)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
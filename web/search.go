@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// The search indexes the title, category, address and emails of the places
+// of every job once it ends, so that a place can be found without knowing
+// which job scraped it. The jobs that ended before the index existed are
+// indexed when the server starts, and the encrypted jobs are not.
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// SearchPlace is a place of the results of a job, as found by the search.
+type SearchPlace struct {
+	JobID   string `json:"job_id"`
+	JobName string `json:"job_name"`
+	// JobDate is when the job was created.
+	JobDate time.Time `json:"job_date"`
+	// Record is the ID of the place in the records of its job.
+	Record   int      `json:"record"`
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Address  string   `json:"address"`
+	Phone    string   `json:"phone"`
+	WebSite  string   `json:"web_site"`
+	Emails   []string `json:"emails"`
+}
+
+// SearchResults is a page of the places matching a search.
+type SearchResults struct {
+	Query   string        `json:"query"`
+	Total   int           `json:"total"`
+	Results []SearchPlace `json:"results"`
+}
+
+// SearchRepository is implemented by the repositories keeping a full-text
+// index of the places.
+type SearchRepository interface {
+	// IndexPlaces indexes the places of job jobID of tenant, replacing those
+	// indexed before.
+	IndexPlaces(ctx context.Context, tenant, jobID string, places []SearchPlace) error
+	// RemovePlaces removes the places of job jobID from the index.
+	RemovePlaces(ctx context.Context, jobID string) error
+	// IndexedJobs returns the IDs of the jobs indexed.
+	IndexedJobs(ctx context.Context) (map[string]bool, error)
+	// SearchPlaces returns the places of tenant matching every word of
+	// query, as prefixes, best first, skipping offset of them, and their
+	// total. The job fields are left empty.
+	SearchPlaces(ctx context.Context, tenant, query string, limit, offset int) ([]SearchPlace, int, error)
+}
+
+// indexSearch indexes entries, the results of job id of the tenant of ctx.
+// The repositories without search index nothing.
+func (s *Service) indexSearch(ctx context.Context, id string, entries []gmaps.Entry) {
+	repo, ok := s.repo.(SearchRepository)
+	if !ok {
+		return
+	}
+
+	places := make([]SearchPlace, len(entries))
+
+	for i := range entries {
+		e := &entries[i]
+
+		places[i] = SearchPlace{
+			Record:   i + 1,
+			Title:    e.Title,
+			Category: e.Category,
+			Address:  e.Address,
+			Phone:    e.Phone,
+			WebSite:  e.WebSite,
+			Emails:   e.Emails,
+		}
+	}
+
+	if err := repo.IndexPlaces(ctx, TenantFrom(ctx), id, places); err != nil {
+		log.Printf("search index of job %s: %v", id, err)
+	}
+}
+
+// searchEnded indexes the results of job, which just ended. A job that ended
+// without results is indexed empty, so that it is not looked at again, and
+// so is an encrypted one, whose places would be left in the clear in the
+// index.
+func (s *Service) searchEnded(ctx context.Context, job *Job) {
+	ctx = WithTenant(ctx, job.Tenant)
+
+	var entries []gmaps.Entry
+
+	if !job.Data.Encrypt {
+		entries, _ = s.loadEntries(ctx, job.ID)
+	}
+
+	s.indexSearch(ctx, job.ID, entries)
+}
+
+// IndexSearch indexes the results of the jobs that ended before the search
+// existed, or while it was failing, and returns how many it indexed.
+func (s *Service) IndexSearch(ctx context.Context) (int, error) {
+	repo, ok := s.repo.(SearchRepository)
+	if !ok {
+		return 0, nil
+	}
+
+	indexed, err := repo.IndexedJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	jobs, err := s.repo.Select(ctx, SelectParams{})
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for i := range jobs {
+		if indexed[jobs[i].ID] || !isFinished(jobs[i].Status) {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		s.searchEnded(ctx, &jobs[i])
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Search returns the places of the jobs of the tenant of ctx matching
+// query, see SearchRepository.SearchPlaces.
+func (s *Service) Search(ctx context.Context, query string, limit, offset int) (SearchResults, error) {
+	repo, ok := s.repo.(SearchRepository)
+	if !ok {
+		return SearchResults{}, errors.New("search not supported by repository")
+	}
+
+	places, total, err := repo.SearchPlaces(ctx, TenantFrom(ctx), query, limit, offset)
+	if err != nil {
+		return SearchResults{}, err
+	}
+
+	jobs := map[string]*Job{}
+
+	for i := range places {
+		p := &places[i]
+
+		job, ok := jobs[p.JobID]
+		if !ok {
+			if j, err := s.repo.Get(ctx, p.JobID); err == nil {
+				job = &j
+			}
+
+			jobs[p.JobID] = job
+		}
+
+		if job != nil {
+			p.JobName = job.Name
+			p.JobDate = job.Date
+		}
+	}
+
+	return SearchResults{Query: query, Total: total, Results: places}, nil
+}
+
+// apiSearch searches the places of all the jobs, q holding the words to
+// find, limit and offset paging them.
+func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Missing q",
+		})
+
+		return
+	}
+
+	limit, offset := defaultSearchLimit, 0
+
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || (name == "limit" && n == 0) {
+			renderJSON(w, http.StatusUnprocessableEntity, apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Invalid " + name,
+			})
+
+			return
+		}
+
+		*dst = n
+	}
+
+	limit = min(limit, maxSearchLimit)
+
+	results, err := s.svc.Search(r.Context(), q, limit, offset)
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, results)
+}
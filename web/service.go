@@ -2,11 +2,17 @@ package web
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gosom/google-maps-scraper/gmaps"
 )
@@ -14,25 +20,151 @@ import (
 type Service struct {
 	repo       JobRepository
 	dataFolder string
+	queue      JobQueue
+	// host names this process in the queue claims of its local jobs, see
+	// WithHost
+	host string
+	// csvLayout picks the columns of the CSV results
+	csvLayout CSVLayout
+	// screenshots is set when the jobs can run on this host
+	screenshots ScreenshotSource
+	// emails is set when the jobs can run on this host
+	emails EmailPhaseSource
+	// sandbox is set when keywords can be tried on this host
+	sandbox     Sandbox
+	sandboxBusy atomic.Bool
+	// lookups caches the places looked up, see WithLookupCache
+	lookups *lookupCache
+	// indexes caches the indexes of the JSON results read last
+	indexes resultIndexCache
+
+	// mu serializes the claims and the lease updates of the jobs
+	mu sync.Mutex
+	// depMu serializes the releases of the jobs waiting for others
+	depMu sync.Mutex
+	// pipelineMu serializes the updates of the stages of the pipelines
+	pipelineMu sync.Mutex
+	// watchlistMu serializes the updates of the watchlists
+	watchlistMu sync.Mutex
+	// manifestKey signs the manifests of the jobs, see WithManifestKey.
+	manifestKey ed25519.PrivateKey
+	// resultsKey seals the results of the encrypted jobs, see
+	// WithResultsKey.
+	resultsKey []byte
+	// shareSecret signs the share links, loaded by shareKey
+	shareMu     sync.Mutex
+	shareSecret []byte
 }
 
-func NewService(repo JobRepository, dataFolder string) *Service {
-	return &Service{
+func NewService(repo JobRepository, dataFolder string, opts ...ServiceOption) *Service {
+	ans := Service{
 		repo:       repo,
 		dataFolder: dataFolder,
 	}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	return &ans
 }
 
+// Create stores job and queues it, or makes it wait for the jobs it depends
+// on. When the tenant of ctx already has a job with the ClientJobID of job,
+// nothing is created: job is set to that job and ErrAlreadyExists returned.
 func (s *Service) Create(ctx context.Context, job *Job) error {
-	return s.repo.Create(ctx, job)
+	job.Tenant = TenantFrom(ctx)
+
+	if job.Data.Encrypt && s.resultsKey == nil {
+		return ErrNoResultsKey
+	}
+
+	if job.ClientJobID != "" {
+		if err := s.existing(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	if len(job.Data.DependsOn) > 0 && job.Status == StatusPending {
+		if err := s.checkDependencies(ctx, job); err != nil {
+			return err
+		}
+
+		job.Status = StatusWaiting
+	}
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		// created by another request since
+		if errors.Is(err, ErrAlreadyExists) && job.ClientJobID != "" {
+			if err := s.existing(ctx, job); err != nil {
+				return err
+			}
+		}
+
+		return err
+	}
+
+	// the dependencies may be over already
+	if job.Status == StatusWaiting {
+		s.depMu.Lock()
+		defer s.depMu.Unlock()
+
+		return s.release(ctx, job)
+	}
+
+	if s.queue != nil && job.Status == StatusPending {
+		return s.queue.Push(ctx, job.ID)
+	}
+
+	return nil
 }
 
+// existing sets job to the job of its tenant created with its ClientJobID
+// and returns ErrAlreadyExists, or returns nil when there is none.
+func (s *Service) existing(ctx context.Context, job *Job) error {
+	jobs, err := s.repo.Select(ctx, SelectParams{ClientJobID: job.ClientJobID})
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if jobs[i].Tenant == job.Tenant {
+			*job = jobs[i]
+
+			return fmt.Errorf("job %s: %w", job.ClientJobID, ErrAlreadyExists)
+		}
+	}
+
+	return nil
+}
+
+// All returns the jobs of the tenant of ctx, newest first.
 func (s *Service) All(ctx context.Context) ([]Job, error) {
-	return s.repo.Select(ctx, SelectParams{})
+	jobs, err := s.repo.Select(ctx, SelectParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := TenantFrom(ctx)
+
+	return slices.DeleteFunc(jobs, func(j Job) bool {
+		return j.Tenant != tenant
+	}), nil
 }
 
+// Get returns job id, ErrNotFound when it belongs to another tenant than
+// the one of ctx.
 func (s *Service) Get(ctx context.Context, id string) (Job, error) {
-	return s.repo.Get(ctx, id)
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	if job.Tenant != TenantFrom(ctx) {
+		return Job{}, fmt.Errorf("%w: job %s", ErrNotFound, id)
+	}
+
+	return job, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id string) error {
@@ -40,33 +172,101 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("invalid file name")
 	}
 
-	// Elimina sia il file CSV che JSON
-	csvPath := filepath.Join(s.dataFolder, id+".csv")
-	jsonPath := filepath.Join(s.dataFolder, id+".json")
+	if job, err := s.repo.Get(ctx, id); err == nil && job.Tenant != TenantFrom(ctx) {
+		return fmt.Errorf("%w: job %s", ErrNotFound, id)
+	}
+
+	// Elimina i file CSV e JSON con le loro copie compresse, e il journal
+	// di un job interrotto
+	for _, format := range slices.Concat(ResultFormats, []string{JournalFormat}) {
+		path := filepath.Join(s.folder(ctx), id+"."+format)
 
-	// Rimuovi il file CSV se esiste
-	if _, err := os.Stat(csvPath); err == nil {
-		if err := os.Remove(csvPath); err != nil {
+		for _, p := range []string{path, path + gzipSuffix} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	for _, suffix := range []string{previousResultsSuffix, manifestSuffix, ".json" + resultIndexSuffix} {
+		if err := os.Remove(filepath.Join(s.folder(ctx), id+suffix)); err != nil && !os.IsNotExist(err) {
 			return err
 		}
-	} else if !os.IsNotExist(err) {
+	}
+
+	if err := removeMapsResults(s.folder(ctx), id); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(DiagnosisDir(s.folder(ctx), id)); err != nil {
 		return err
 	}
 
-	// Rimuovi il file JSON se esiste
-	if _, err := os.Stat(jsonPath); err == nil {
-		if err := os.Remove(jsonPath); err != nil {
+	if s.queue != nil {
+		if err := s.queue.Remove(ctx, id); err != nil {
 			return err
 		}
-	} else if !os.IsNotExist(err) {
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return err
 	}
 
-	return s.repo.Delete(ctx, id)
+	if repo, ok := s.repo.(SearchRepository); ok {
+		if err := repo.RemovePlaces(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	// the jobs waiting for it fail
+	s.dependencyEnded(ctx, &Job{ID: id, Tenant: TenantFrom(ctx)})
+
+	return nil
 }
 
 func (s *Service) Update(ctx context.Context, job *Job) error {
-	return s.repo.Update(ctx, job)
+	// the usage of a job is metered once, when it ends
+	ending := false
+
+	if isFinished(job.Status) {
+		prev, err := s.repo.Get(ctx, job.ID)
+		ending = err == nil && !isFinished(prev.Status)
+	}
+
+	// a retry of the failures is metered for its own usage
+	metered := job
+
+	if ending && job.Stats.Retry != nil {
+		retried, err := s.finishRetry(job)
+		if err != nil {
+			return err
+		}
+
+		metered = &retried
+	}
+
+	if ending {
+		s.sealEnded(job)
+	}
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		return err
+	}
+
+	if ending {
+		s.meterUsage(ctx, metered)
+		s.watchlistEnded(ctx, job)
+		s.recordHistory(ctx, job)
+		s.searchEnded(ctx, job)
+		s.manifestEnded(ctx, job)
+		s.dependencyEnded(ctx, job)
+	}
+
+	if s.queue != nil && isFinished(job.Status) {
+		return s.queue.Ack(ctx, job.ID, "")
+	}
+
+	return nil
 }
 
 func (s *Service) SelectPending(ctx context.Context) ([]Job, error) {
@@ -74,12 +274,12 @@ func (s *Service) SelectPending(ctx context.Context) ([]Job, error) {
 }
 
 // GetCSV restituisce il percorso del file CSV per un job
-func (s *Service) GetCSV(_ context.Context, id string) (string, error) {
+func (s *Service) GetCSV(ctx context.Context, id string) (string, error) {
 	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
 		return "", fmt.Errorf("invalid file name")
 	}
 
-	datapath := filepath.Join(s.dataFolder, id+".csv")
+	datapath := filepath.Join(s.folder(ctx), id+".csv")
 
 	if _, err := os.Stat(datapath); os.IsNotExist(err) {
 		return "", fmt.Errorf("csv file not found for job %s", id)
@@ -88,7 +288,21 @@ func (s *Service) GetCSV(_ context.Context, id string) (string, error) {
 	return datapath, nil
 }
 
+// GetSettings returns the settings of the tenant of ctx.
 func (s *Service) GetSettings(ctx context.Context) (Settings, error) {
+	if tenant := TenantFrom(ctx); tenant != "" {
+		settings, err := s.TenantSettings(ctx, tenant)
+		if errors.Is(err, ErrNotFound) {
+			// the tenant was deleted since
+			settings = Settings{}
+			settings.ApplyDefaults()
+
+			return settings, nil
+		}
+
+		return settings, err
+	}
+
 	repo, ok := s.repo.(SettingsRepository)
 	if !ok {
 		settings := Settings{}
@@ -109,7 +323,12 @@ func (s *Service) GetSettings(ctx context.Context) (Settings, error) {
 	return settings, nil
 }
 
+// SaveSettings replaces the settings of the tenant of ctx.
 func (s *Service) SaveSettings(ctx context.Context, settings *Settings) error {
+	if tenant := TenantFrom(ctx); tenant != "" {
+		return s.SaveTenantSettings(ctx, tenant, settings)
+	}
+
 	repo, ok := s.repo.(SettingsRepository)
 	if !ok {
 		return fmt.Errorf("settings not supported by repository")
@@ -125,12 +344,12 @@ func (s *Service) SaveSettings(ctx context.Context, settings *Settings) error {
 }
 
 // GetJSON restituisce il percorso del file JSON per un job
-func (s *Service) GetJSON(_ context.Context, id string) (string, error) {
+func (s *Service) GetJSON(ctx context.Context, id string) (string, error) {
 	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
 		return "", fmt.Errorf("invalid file name")
 	}
 
-	datapath := filepath.Join(s.dataFolder, id+".json")
+	datapath := filepath.Join(s.folder(ctx), id+".json")
 
 	if _, err := os.Stat(datapath); os.IsNotExist(err) {
 		return "", fmt.Errorf("json file not found for job %s", id)
@@ -139,14 +358,186 @@ func (s *Service) GetJSON(_ context.Context, id string) (string, error) {
 	return datapath, nil
 }
 
-func (s *Service) loadEntries(id string) ([]gmaps.Entry, error) {
+// GetPlacesAPI returns the job results in the Google Places API "Place
+// Details" shape, one response envelope per place.
+func (s *Service) GetPlacesAPI(ctx context.Context, id string, filter ExportFilter) ([]gmaps.PlaceDetailsResponse, error) {
+	entries, err := s.GetEntries(ctx, id, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ans := make([]gmaps.PlaceDetailsResponse, 0, len(entries))
+
+	for i := range entries {
+		ans = append(ans, entries[i].ToPlaceDetailsResponse())
+	}
+
+	return ans, nil
+}
+
+// Validation filters of the exports, see ExportFilter.
+const (
+	ValidationValid      = "valid"
+	ValidationQuarantine = "quarantine"
+)
+
+// ExportFilter narrows the job results returned by GetEntries.
+type ExportFilter struct {
+	// MinEmailConfidence keeps only the places whose best email scores at
+	// least this much. Zero disables the filter.
+	MinEmailConfidence int
+	// ReviewLanguages keeps only the reviews detected in one of these
+	// languages, see gmaps.DetectLanguage. Empty keeps them all.
+	ReviewLanguages []string
+	// Suppress names the suppression lists the places are checked against,
+	// SuppressAction tells whether the matching ones are dropped
+	// (SuppressExclude, the default) or kept with their Suppressed field
+	// set (SuppressFlag).
+	Suppress       []string
+	SuppressAction string
+	// NewSinceJob keeps only the places missing from the results of that
+	// job, NewSince those missing from the results of every job created
+	// before it: a feed of the places new since then. Places are told apart
+	// by gmaps.Entry.IdentityKey, their CID when known.
+	NewSinceJob string
+	NewSince    time.Time
+	// Territories assign each place to the territory holding it, in its
+	// Territory field and a last CSV column. Territory then keeps only the
+	// places of that territory, all of them when empty.
+	Territories gmaps.Territories
+	Territory   string
+	// Validation keeps only the places passing their validation rules
+	// (ValidationValid, the places never validated included) or only the
+	// failing ones (ValidationQuarantine), all of them when empty.
+	Validation string
+}
+
+// IsZero reports whether f keeps the results as they are.
+func (f *ExportFilter) IsZero() bool {
+	return f.MinEmailConfidence <= 0 && len(f.ReviewLanguages) == 0 && len(f.Suppress) == 0 &&
+		f.NewSinceJob == "" && f.NewSince.IsZero() && len(f.Territories) == 0 && f.Validation == ""
+}
+
+// GetEntries returns the job results narrowed by filter.
+func (s *Service) GetEntries(ctx context.Context, id string, filter ExportFilter) ([]gmaps.Entry, error) {
+	entries, err := s.loadEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.IsZero() {
+		return entries, nil
+	}
+
+	suppressions, err := s.Suppressions(ctx, filter.Suppress)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := s.knownPlaces(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := entries[:0]
+
+	for i := range entries {
+		if !entries[i].HasEmailConfidence(filter.MinEmailConfidence) {
+			continue
+		}
+
+		if known[entries[i].IdentityKey()] {
+			continue
+		}
+
+		if filter.Validation != "" && entries[i].Quarantined() != (filter.Validation == ValidationQuarantine) {
+			continue
+		}
+
+		if len(filter.Territories) > 0 {
+			entries[i].AssignTerritory(filter.Territories)
+
+			if filter.Territory != "" && entries[i].Territory != filter.Territory {
+				continue
+			}
+		}
+
+		if match := suppressions.Match(&entries[i]); match != "" {
+			if filter.SuppressAction != SuppressFlag {
+				continue
+			}
+
+			entries[i].Suppressed = match
+		}
+
+		if len(filter.ReviewLanguages) > 0 {
+			// results scraped before the detection carry no language
+			entries[i].DetectLanguages()
+			entries[i].KeepReviewLanguages(filter.ReviewLanguages)
+		}
+
+		kept = append(kept, entries[i])
+	}
+
+	return kept, nil
+}
+
+// knownPlaces returns the identity keys of the places found before the
+// delta of filter, nil without delta.
+func (s *Service) knownPlaces(ctx context.Context, filter *ExportFilter) (map[string]bool, error) {
+	var refs []string
+
+	if filter.NewSinceJob != "" {
+		refs = append(refs, filter.NewSinceJob)
+	}
+
+	if !filter.NewSince.IsZero() {
+		jobs, err := s.All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range jobs {
+			if jobs[i].Date.Before(filter.NewSince) {
+				refs = append(refs, jobs[i].ID)
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	ans := map[string]bool{}
+
+	for _, ref := range refs {
+		entries, err := s.loadEntries(ctx, ref)
+		if err != nil {
+			// the reference job must have results, the older jobs may
+			// have failed without any
+			if ref == filter.NewSinceJob {
+				return nil, err
+			}
+
+			continue
+		}
+
+		for i := range entries {
+			ans[entries[i].IdentityKey()] = true
+		}
+	}
+
+	return ans, nil
+}
+
+func (s *Service) loadEntries(ctx context.Context, id string) ([]gmaps.Entry, error) {
 	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
 		return nil, fmt.Errorf("invalid file name")
 	}
 
-	datapath := filepath.Join(s.dataFolder, id+".json")
+	datapath := filepath.Join(s.folder(ctx), id+".json")
 
-	data, err := os.ReadFile(datapath)
+	f, err := s.openResultFile(datapath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("json file not found for job %s", id)
@@ -155,27 +546,86 @@ func (s *Service) loadEntries(id string) ([]gmaps.Entry, error) {
 		return nil, err
 	}
 
-	var entries []gmaps.Entry
-	if err := json.Unmarshal(data, &entries); err != nil {
+	defer f.Close()
+
+	entries, err := decodeEntries[gmaps.Entry](f)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse json file: %w", err)
 	}
 
 	return entries, nil
 }
 
-func (s *Service) saveEntries(id string, entries []gmaps.Entry) error {
+// GetResults returns the job results or, while the job runs, the places it
+// has written so far, in which case partial is true.
+func (s *Service) GetResults(ctx context.Context, id string) ([]gmaps.Entry, bool, error) {
+	return s.loadResults(ctx, id)
+}
+
+func (s *Service) loadResults(ctx context.Context, id string) ([]gmaps.Entry, bool, error) {
+	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return nil, false, fmt.Errorf("invalid file name")
+	}
+
+	entries, err := s.loadEntries(ctx, id)
+	if err == nil {
+		return entries, false, nil
+	}
+
+	// the json file is only written once the job is over
+	journal, jerr := ReadJournal(filepath.Join(s.folder(ctx), id+"."+JournalFormat))
+	if jerr != nil {
+		return nil, false, err
+	}
+
+	entries = make([]gmaps.Entry, len(journal))
+	for i := range journal {
+		entries[i] = *journal[i]
+	}
+
+	return entries, true, nil
+}
+
+func (s *Service) saveEntries(ctx context.Context, id string, entries []gmaps.Entry) error {
 	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
 		return fmt.Errorf("invalid file name")
 	}
 
-	datapath := filepath.Join(s.dataFolder, id+".json")
+	datapath := filepath.Join(s.folder(ctx), id+".json")
 
 	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to encode json: %w", err)
 	}
 
-	return os.WriteFile(datapath, data, 0o644)
+	// the edits of sealed results stay sealed
+	sealed, err := isSealedFile(datapath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := s.writeResultFile(datapath, data, sealed); err != nil {
+		return err
+	}
+
+	// the records keep their place in the search, the sealed ones stay out
+	// of it
+	if !sealed {
+		s.indexSearch(ctx, id, entries)
+	}
+
+	return nil
+}
+
+// RecordFilter narrows the records returned by GetRecords.
+type RecordFilter struct {
+	Search string
+	// ExcludeChains drops entries grouped into a chain (franchise branches),
+	// leaving independent businesses only.
+	ExcludeChains bool
+	// MinEmailConfidence keeps only entries whose best email scores at
+	// least this much. Zero disables the filter.
+	MinEmailConfidence int
 }
 
 type IndexedEntry struct {
@@ -183,48 +633,120 @@ type IndexedEntry struct {
 	Index int // 0-based index in the original array
 }
 
-func (s *Service) GetRecords(_ context.Context, jobID string, page, pageSize int, search string) ([]IndexedEntry, int, error) {
-	entries, err := s.loadEntries(jobID)
+// GetRecords returns a page of the job results matching filter and their
+// total. While the job runs the records are the ones written so far and
+// partial is true.
+func (s *Service) GetRecords(ctx context.Context, jobID string, page, pageSize int, filter RecordFilter) (records []IndexedEntry, total int, partial bool, err error) {
+	set, err := s.records(ctx, jobID, filter)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	total = len(set.matched)
+
+	start := (page - 1) * pageSize
+	if start >= total {
+		return []IndexedEntry{}, total, set.partial, nil
+	}
+
+	end := min(start+pageSize, total)
+
+	records, err = set.get(set.matched[start:end])
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 
-	indexed := make([]IndexedEntry, 0, len(entries))
+	return records, total, set.partial, nil
+}
 
-	if search != "" {
-		search = strings.ToLower(search)
+// records returns the records of job jobID matching filter, through the
+// index of its final results when it has one.
+func (s *Service) records(ctx context.Context, jobID string, filter RecordFilter) (recordSet, error) {
+	if set, err := s.indexedRecords(ctx, jobID, filter); err == nil {
+		return set, nil
+	}
 
-		for i, e := range entries {
-			if strings.Contains(strings.ToLower(e.Title), search) ||
-				strings.Contains(strings.ToLower(e.Address), search) ||
-				strings.Contains(strings.ToLower(e.Phone), search) ||
-				strings.Contains(strings.ToLower(strings.Join(e.Emails, " ")), search) {
-				indexed = append(indexed, IndexedEntry{Entry: e, Index: i})
+	entries, partial, err := s.loadResults(ctx, jobID)
+	if err != nil {
+		return recordSet{}, err
+	}
+
+	// older result files carry no chain_id
+	assignChains(entries)
+
+	set := recordSet{
+		key: func(i int) string {
+			return entries[i].IdentityKey()
+		},
+		load: func(positions []int) ([]gmaps.Entry, error) {
+			ans := make([]gmaps.Entry, len(positions))
+			for i, p := range positions {
+				ans[i] = entries[p]
 			}
-		}
-	} else {
-		for i, e := range entries {
-			indexed = append(indexed, IndexedEntry{Entry: e, Index: i})
+
+			return ans, nil
+		},
+		size:    len(entries),
+		partial: partial,
+	}
+
+	search := strings.ToLower(filter.Search)
+
+	for i := range entries {
+		if recordMatches(&entries[i], &filter, search) {
+			set.matched = append(set.matched, i)
 		}
 	}
 
-	total := len(indexed)
+	return set, nil
+}
 
-	start := (page - 1) * pageSize
-	if start >= total {
-		return []IndexedEntry{}, total, nil
+// ChainGroup is a chain with its member entries.
+type ChainGroup struct {
+	Chain   gmaps.Chain
+	Entries []IndexedEntry
+}
+
+// GetChains groups the job results by brand. Files written before chain
+// detection existed, and the results of a running job, which are partial,
+// are grouped on the fly.
+func (s *Service) GetChains(ctx context.Context, jobID string) ([]ChainGroup, int, bool, error) {
+	entries, partial, err := s.loadResults(ctx, jobID)
+	if err != nil {
+		return nil, 0, false, err
 	}
 
-	end := start + pageSize
-	if end > total {
-		end = total
+	chains := assignChains(entries)
+
+	ans := make([]ChainGroup, 0, len(chains))
+	grouped := 0
+
+	for _, c := range chains {
+		group := ChainGroup{Chain: c, Entries: make([]IndexedEntry, 0, len(c.Members))}
+
+		for _, m := range c.Members {
+			group.Entries = append(group.Entries, IndexedEntry{Entry: entries[m], Index: m})
+		}
+
+		grouped += len(c.Members)
+
+		ans = append(ans, group)
+	}
+
+	return ans, len(entries) - grouped, partial, nil
+}
+
+func assignChains(entries []gmaps.Entry) []gmaps.Chain {
+	ptrs := make([]*gmaps.Entry, len(entries))
+	for i := range entries {
+		ptrs[i] = &entries[i]
 	}
 
-	return indexed[start:end], total, nil
+	return gmaps.AssignChains(ptrs)
 }
 
-func (s *Service) UpdateRecord(_ context.Context, jobID string, recordID int, updates map[string]interface{}) (gmaps.Entry, error) {
-	entries, err := s.loadEntries(jobID)
+func (s *Service) UpdateRecord(ctx context.Context, jobID string, recordID int, updates map[string]interface{}) (gmaps.Entry, error) {
+	entries, err := s.loadEntries(ctx, jobID)
 	if err != nil {
 		return gmaps.Entry{}, err
 	}
@@ -321,15 +843,15 @@ func (s *Service) UpdateRecord(_ context.Context, jobID string, recordID int, up
 		}
 	}
 
-	if err := s.saveEntries(jobID, entries); err != nil {
+	if err := s.saveEntries(ctx, jobID, entries); err != nil {
 		return gmaps.Entry{}, err
 	}
 
 	return entries[idx], nil
 }
 
-func (s *Service) DeleteRecord(_ context.Context, jobID string, recordID int) error {
-	entries, err := s.loadEntries(jobID)
+func (s *Service) DeleteRecord(ctx context.Context, jobID string, recordID int) error {
+	entries, err := s.loadEntries(ctx, jobID)
 	if err != nil {
 		return err
 	}
@@ -341,5 +863,5 @@ func (s *Service) DeleteRecord(_ context.Context, jobID string, recordID int) er
 
 	entries = append(entries[:idx], entries[idx+1:]...)
 
-	return s.saveEntries(jobID, entries)
+	return s.saveEntries(ctx, jobID, entries)
 }
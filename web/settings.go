@@ -3,7 +3,11 @@ package web
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/proxypool"
 )
 
 type Settings struct {
@@ -12,6 +16,44 @@ type Settings struct {
 	Email    bool     `json:"email"`
 	MaxTime  string   `json:"max_time"`
 	Proxies  []string `json:"proxies"`
+	// EmailProxies routes the email extraction fetches through proxies
+	// matched to the website country, keyed by ccTLD ("de", "it") with "*"
+	// as catch-all. See gmaps.EmailProxyRouter.
+	EmailProxies map[string][]string `json:"email_proxies"`
+	// EmailHostInterval is the time between two email fetches of the same
+	// website, a Go duration, "" for gmaps.DefaultEmailHostInterval and
+	// "0" for none.
+	EmailHostInterval string `json:"email_host_interval"`
+	// ProxyProvider pulls the proxies of jobs without their own from a
+	// provider API (see proxypool.ParseProvider), refreshed on a schedule.
+	ProxyProvider string `json:"proxy_provider"`
+	// MonthlyMaxPlaces and MonthlyMaxEmailFetches cap the places and the
+	// email fetches of all the jobs created in a calendar month (UTC).
+	// 0 means no limit.
+	MonthlyMaxPlaces       int `json:"monthly_max_places"`
+	MonthlyMaxEmailFetches int `json:"monthly_max_email_fetches"`
+	// FastLaneThreshold is the largest size, keywords times depth, of the
+	// jobs the local runner may start while another job is running, so that
+	// a small job does not wait behind a huge one. 0 disables the fast lane.
+	FastLaneThreshold int `json:"fast_lane_threshold"`
+	// UsageWebhook receives a UsageEvent when the usage of the month crosses
+	// one of the UsageThresholds, listed by unit (UnitPlaces,
+	// UnitEmailsVerified, UnitBrowserMinutes).
+	UsageWebhook    string           `json:"usage_webhook"`
+	UsageThresholds map[string][]int `json:"usage_thresholds"`
+	// Branding white-labels the Web UI. The Web UI acts for the default
+	// tenant, so only its branding is shown; a tenant keeps its own for the
+	// frontends built on the REST API.
+	Branding Branding `json:"branding"`
+	// Export shapes the downloads of the results, each download request
+	// being able to override it.
+	Export ExportFormat `json:"export"`
+	// ExportMappings are the CSV layouts of the destination systems of the
+	// results, by name, picked by Export.Mapping or per download.
+	ExportMappings map[string]ExportMapping `json:"export_mappings"`
+	// Territories are the named polygons the exports assign the places
+	// to, for the jobs without their own, see Service.JobTerritories.
+	Territories gmaps.Territories `json:"territories,omitempty"`
 }
 
 func (s *Settings) Validate() error {
@@ -23,12 +65,58 @@ func (s *Settings) Validate() error {
 		return errors.New("depth cannot be negative")
 	}
 
+	if s.MonthlyMaxPlaces < 0 || s.MonthlyMaxEmailFetches < 0 {
+		return errors.New("monthly budgets cannot be negative")
+	}
+
+	if s.FastLaneThreshold < 0 {
+		return errors.New("fast lane threshold cannot be negative")
+	}
+
+	if err := validateUsageWebhook(s.UsageWebhook); err != nil {
+		return err
+	}
+
+	if err := validateUsageThresholds(s.UsageThresholds); err != nil {
+		return err
+	}
+
+	if err := s.Branding.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Export.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateExportMappings(s.ExportMappings); err != nil {
+		return err
+	}
+
+	if _, ok := s.ExportMappings[s.Export.Mapping]; s.Export.Mapping != "" && !ok {
+		return fmt.Errorf("unknown export mapping %q", s.Export.Mapping)
+	}
+
 	if s.MaxTime != "" {
 		if _, err := time.ParseDuration(s.MaxTime); err != nil {
 			return errors.New("invalid max time format (use Go duration like 10m, 1h30m)")
 		}
 	}
 
+	if _, err := gmaps.NewEmailProxyRouter(s.EmailProxies); err != nil {
+		return err
+	}
+
+	if _, err := gmaps.ParseEmailHostInterval(s.EmailHostInterval); err != nil {
+		return err
+	}
+
+	if s.ProxyProvider != "" {
+		if _, err := proxypool.ParseProvider(s.ProxyProvider); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -48,6 +136,14 @@ func (s *Settings) ApplyDefaults() {
 	if s.Proxies == nil {
 		s.Proxies = []string{}
 	}
+
+	if s.EmailProxies == nil {
+		s.EmailProxies = map[string][]string{}
+	}
+
+	if s.UsageThresholds == nil {
+		s.UsageThresholds = map[string][]int{}
+	}
 }
 
 type SettingsRepository interface {
@@ -0,0 +1,330 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A share link lets anyone holding it download the results of a job until
+// it expires, without an API token: the link carries the job, its tenant
+// and the expiry, signed with the share key of the server, so nothing is
+// stored.
+
+// sharedAPIPrefix serves the shared results, without authentication.
+const sharedAPIPrefix = "/api/v1/shared/"
+
+// shareKeyFile holds the key signing the share links, in the data folder.
+// Replacing it revokes all the links.
+const shareKeyFile = "share.key"
+
+// Lifetimes of a share link.
+const (
+	ShareDefaultExpiry = 7 * 24 * time.Hour
+	ShareMaxExpiry     = 30 * 24 * time.Hour
+)
+
+var errInvalidShare = errors.New("invalid or expired share link")
+
+// ShareRequest is the body of POST /api/v1/jobs/{id}/share.
+type ShareRequest struct {
+	// ExpiresIn is the lifetime of the link in seconds, ShareDefaultExpiry
+	// when 0.
+	ExpiresIn int `json:"expires_in"`
+	// Format limits the link to one of ResultFormats, both when empty.
+	Format string `json:"format,omitempty"`
+}
+
+// Share is a share link of the results of a job.
+type Share struct {
+	JobID     string    `json:"job_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token"`
+	// URLs are the download links by format.
+	URLs map[string]string `json:"urls"`
+}
+
+// shareClaims is what a share token signs.
+type shareClaims struct {
+	Job     string `json:"j"`
+	Tenant  string `json:"t,omitempty"`
+	Format  string `json:"f,omitempty"`
+	Expires int64  `json:"e"`
+}
+
+// shareKey returns the key signing the share links, created on first use.
+func (s *Service) shareKey() ([]byte, error) {
+	s.shareMu.Lock()
+	defer s.shareMu.Unlock()
+
+	if s.shareSecret != nil {
+		return s.shareSecret, nil
+	}
+
+	path := filepath.Join(s.dataFolder, shareKeyFile)
+
+	key, err := os.ReadFile(path)
+
+	switch {
+	case err == nil && len(key) >= 32:
+	case err == nil || errors.Is(err, os.ErrNotExist):
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(path, key, 0o600); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	s.shareSecret = key
+
+	return key, nil
+}
+
+// CreateShare returns a link to download the results of job id, of the
+// tenant of ctx, until it expires.
+func (s *Service) CreateShare(ctx context.Context, id string, req ShareRequest) (Share, error) {
+	expiry := time.Duration(req.ExpiresIn) * time.Second
+
+	switch {
+	case req.ExpiresIn < 0:
+		return Share{}, errors.New("expires_in cannot be negative")
+	case expiry > ShareMaxExpiry:
+		return Share{}, fmt.Errorf("expires_in cannot be over %d seconds", int(ShareMaxExpiry.Seconds()))
+	case expiry == 0:
+		expiry = ShareDefaultExpiry
+	}
+
+	if req.Format != "" && !slices.Contains(ResultFormats, req.Format) {
+		return Share{}, fmt.Errorf("format must be one of %s", strings.Join(ResultFormats, ", "))
+	}
+
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		// the repositories differ in how they tell a missing job
+		return Share{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if job.Status != StatusOK {
+		return Share{}, fmt.Errorf("%w: job %s is %s", ErrJobNotFinished, id, job.Status)
+	}
+
+	key, err := s.shareKey()
+	if err != nil {
+		return Share{}, err
+	}
+
+	claims := shareClaims{
+		Job:     job.ID,
+		Tenant:  job.Tenant,
+		Format:  req.Format,
+		Expires: time.Now().Add(expiry).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return Share{}, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signShare(key, payload))
+
+	return Share{
+		JobID:     job.ID,
+		ExpiresAt: time.Unix(claims.Expires, 0).UTC(),
+		Token:     token,
+	}, nil
+}
+
+func signShare(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// SharedResult returns the path of the format result file of the job shared
+// by token, and the context of its tenant.
+func (s *Service) SharedResult(ctx context.Context, token, format string) (context.Context, Job, string, error) {
+	payload64, sig64, ok := strings.Cut(token, ".")
+	if !ok {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payload64)
+	if err != nil {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(sig64)
+	if err != nil {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	key, err := s.shareKey()
+	if err != nil {
+		return ctx, Job{}, "", err
+	}
+
+	if !hmac.Equal(sig, signShare(key, payload)) {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	var claims shareClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	if time.Now().Unix() > claims.Expires || (claims.Format != "" && claims.Format != format) {
+		return ctx, Job{}, "", errInvalidShare
+	}
+
+	ctx = WithTenant(ctx, claims.Tenant)
+
+	job, err := s.Get(ctx, claims.Job)
+	if err != nil {
+		return ctx, Job{}, "", fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	var path string
+
+	switch format {
+	case "csv":
+		path, err = s.GetCSV(ctx, job.ID)
+	case "json":
+		path, err = s.GetJSON(ctx, job.ID)
+	default:
+		return ctx, Job{}, "", fmt.Errorf("%w: format %q", ErrNotFound, format)
+	}
+
+	if err != nil {
+		return ctx, Job{}, "", fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	return ctx, job, path, nil
+}
+
+// requestOrigin returns the scheme and host r was sent to, as seen by the
+// client behind a proxy.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+func (s *Server) apiCreateShare(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	var req ShareRequest
+
+	// an empty body takes the defaults
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	share, err := s.svc.CreateShare(r.Context(), id.String(), req)
+
+	code := http.StatusUnprocessableEntity
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, ErrJobNotFinished):
+		code = http.StatusConflict
+	}
+
+	if err != nil {
+		renderJSON(w, code, apiError{
+			Code:    code,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	share.URLs = map[string]string{}
+
+	for _, format := range ResultFormats {
+		if req.Format == "" || req.Format == format {
+			share.URLs[format] = requestOrigin(r) + sharedAPIPrefix + share.Token + "/" + format
+		}
+	}
+
+	renderJSON(w, http.StatusCreated, share)
+}
+
+// sharedDownload serves a result file of a shared job to anyone holding the
+// link.
+func (s *Server) sharedDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	format := r.PathValue("format")
+
+	ctx, job, path, err := s.svc.SharedResult(r.Context(), r.PathValue("token"), format)
+
+	switch {
+	case errors.Is(err, errInvalidShare):
+		http.Error(w, err.Error(), http.StatusForbidden)
+
+		return
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Results not found", http.StatusNotFound)
+
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	var export ExportFormat
+
+	if settings, err := s.svc.GetSettings(ctx); err == nil {
+		export = settings.Export
+	}
+
+	contentType := "text/csv"
+	if format == "json" {
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	s.serveResult(w, r, path, export.Filename(&job, format), contentType)
+}
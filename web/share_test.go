@@ -0,0 +1,79 @@
+package web_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+// signShare returns a share token of payload signed with key, like the
+// server does.
+func signShare(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestSharedResultRefusesBadTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, folder := newTestService(t)
+
+	job := endJob(ctx, t, svc, folder, web.JobData{Keywords: []string{"cafe"}})
+
+	share, err := svc.CreateShare(ctx, job.ID, web.ShareRequest{Format: "json"})
+	require.NoError(t, err)
+
+	_, got, _, err := svc.SharedResult(ctx, share.Token, "json")
+	require.NoError(t, err)
+	require.Equal(t, job.ID, got.ID)
+
+	key, err := os.ReadFile(filepath.Join(folder, "share.key"))
+	require.NoError(t, err)
+
+	payload, sig, _ := strings.Cut(share.Token, ".")
+	future := time.Now().Add(time.Hour).Unix()
+
+	claims := func(expires int64) string {
+		return fmt.Sprintf(`{"j":%q,"e":%d}`, job.ID, expires)
+	}
+
+	// a token signed like the server's is taken
+	_, _, _, err = svc.SharedResult(ctx, signShare(key, claims(future)), "json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		format string
+	}{
+		{"signed with another key", signShare([]byte(strings.Repeat("k", 32)), claims(future)), "json"},
+		{"payload changed", base64.RawURLEncoding.EncodeToString([]byte(claims(future+60))) + "." + sig, "json"},
+		{"signature dropped", payload, "json"},
+		{"empty signature", payload + ".", "json"},
+		{"expired", signShare(key, claims(time.Now().Add(-time.Minute).Unix())), "json"},
+		{"other format", share.Token, "csv"},
+		{"not base64", "a!b.c!d", "json"},
+		{"not JSON", signShare(key, "not json"), "json"},
+		{"empty", "", "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, path, err := svc.SharedResult(ctx, tt.token, tt.format)
+			require.ErrorContains(t, err, "invalid or expired share link")
+			require.Empty(t, path)
+		})
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
 	"time"
+	"unicode"
 
 	_ "modernc.org/sqlite" // sqlite driver
 
@@ -12,17 +17,29 @@ import (
 )
 
 func (repo *repo) GetSettings(ctx context.Context) (web.Settings, error) {
-	const q = `SELECT language, depth, email, max_time, proxies FROM settings WHERE id = 1`
+	const q = `SELECT language, depth, email, max_time, proxies, email_proxies, email_host_interval, proxy_provider, monthly_max_places, monthly_max_email_fetches, fast_lane_threshold, usage_webhook, usage_thresholds, branding, export, export_mappings, territories FROM settings WHERE id = 1`
 
 	var (
-		language string
-		depth    int
-		email    int
-		maxTime  string
-		proxies  string
+		language               string
+		depth                  int
+		email                  int
+		maxTime                string
+		proxies                string
+		emailProxies           string
+		emailHostInterval      string
+		proxyProvider          string
+		monthlyMaxPlaces       int
+		monthlyMaxEmailFetches int
+		fastLaneThreshold      int
+		usageWebhook           string
+		usageThresholds        string
+		branding               string
+		export                 string
+		exportMappings         string
+		territories            string
 	)
 
-	err := repo.db.QueryRowContext(ctx, q).Scan(&language, &depth, &email, &maxTime, &proxies)
+	err := repo.db.QueryRowContext(ctx, q).Scan(&language, &depth, &email, &maxTime, &proxies, &emailProxies, &emailHostInterval, &proxyProvider, &monthlyMaxPlaces, &monthlyMaxEmailFetches, &fastLaneThreshold, &usageWebhook, &usageThresholds, &branding, &export, &exportMappings, &territories)
 	if err != nil {
 		return web.Settings{}, err
 	}
@@ -34,60 +51,878 @@ func (repo *repo) GetSettings(ctx context.Context) (web.Settings, error) {
 		MaxTime:  maxTime,
 	}
 
+	ans.EmailHostInterval = emailHostInterval
+	ans.ProxyProvider = proxyProvider
+	ans.MonthlyMaxPlaces = monthlyMaxPlaces
+	ans.MonthlyMaxEmailFetches = monthlyMaxEmailFetches
+	ans.FastLaneThreshold = fastLaneThreshold
+	ans.UsageWebhook = usageWebhook
+
 	if err := json.Unmarshal([]byte(proxies), &ans.Proxies); err != nil {
 		ans.Proxies = []string{}
 	}
 
-	return ans, nil
+	if err := json.Unmarshal([]byte(emailProxies), &ans.EmailProxies); err != nil {
+		ans.EmailProxies = map[string][]string{}
+	}
+
+	if err := json.Unmarshal([]byte(usageThresholds), &ans.UsageThresholds); err != nil {
+		ans.UsageThresholds = map[string][]int{}
+	}
+
+	if err := json.Unmarshal([]byte(branding), &ans.Branding); err != nil {
+		ans.Branding = web.Branding{}
+	}
+
+	if err := json.Unmarshal([]byte(export), &ans.Export); err != nil {
+		ans.Export = web.ExportFormat{}
+	}
+
+	if err := json.Unmarshal([]byte(exportMappings), &ans.ExportMappings); err != nil {
+		ans.ExportMappings = map[string]web.ExportMapping{}
+	}
+
+	if err := json.Unmarshal([]byte(territories), &ans.Territories); err != nil {
+		ans.Territories = nil
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) UpsertSettings(ctx context.Context, settings *web.Settings) error {
+	proxiesJSON, err := json.Marshal(settings.Proxies)
+	if err != nil {
+		return err
+	}
+
+	emailProxiesJSON, err := json.Marshal(settings.EmailProxies)
+	if err != nil {
+		return err
+	}
+
+	usageThresholdsJSON, err := json.Marshal(settings.UsageThresholds)
+	if err != nil {
+		return err
+	}
+
+	brandingJSON, err := json.Marshal(settings.Branding)
+	if err != nil {
+		return err
+	}
+
+	exportJSON, err := json.Marshal(settings.Export)
+	if err != nil {
+		return err
+	}
+
+	exportMappingsJSON, err := json.Marshal(settings.ExportMappings)
+	if err != nil {
+		return err
+	}
+
+	territoriesJSON, err := json.Marshal(settings.Territories)
+	if err != nil {
+		return err
+	}
+
+	emailInt := 0
+	if settings.Email {
+		emailInt = 1
+	}
+
+	const q = `INSERT OR REPLACE INTO settings (id, language, depth, email, max_time, proxies, email_proxies, email_host_interval, proxy_provider, monthly_max_places, monthly_max_email_fetches, fast_lane_threshold, usage_webhook, usage_thresholds, branding, export, export_mappings, territories, created_at, updated_at) VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE((SELECT created_at FROM settings WHERE id = 1), ?), ?)`
+
+	now := time.Now().UTC().Unix()
+
+	_, err = repo.db.ExecContext(ctx, q,
+		settings.Language,
+		settings.Depth,
+		emailInt,
+		settings.MaxTime,
+		string(proxiesJSON),
+		string(emailProxiesJSON),
+		settings.EmailHostInterval,
+		settings.ProxyProvider,
+		settings.MonthlyMaxPlaces,
+		settings.MonthlyMaxEmailFetches,
+		settings.FastLaneThreshold,
+		settings.UsageWebhook,
+		string(usageThresholdsJSON),
+		string(brandingJSON),
+		string(exportJSON),
+		string(exportMappingsJSON),
+		string(territoriesJSON),
+		now,
+		now,
+	)
+
+	return err
+}
+
+func (repo *repo) ListProfiles(ctx context.Context) ([]string, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT name FROM settings_profiles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ans := []string{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, name)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) GetProfile(ctx context.Context, name string) (web.Settings, error) {
+	var raw string
+
+	err := repo.db.QueryRowContext(ctx, `SELECT settings FROM settings_profiles WHERE name = ?`, name).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Settings{}, web.ErrNotFound
+	}
+
+	if err != nil {
+		return web.Settings{}, err
+	}
+
+	var ans web.Settings
+
+	err = json.Unmarshal([]byte(raw), &ans)
+
+	return ans, err
+}
+
+func (repo *repo) UpsertProfile(ctx context.Context, name string, settings *web.Settings) error {
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	const q = `INSERT INTO settings_profiles (name, settings, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET settings = excluded.settings, updated_at = excluded.updated_at`
+
+	now := time.Now().UTC().Unix()
+
+	_, err = repo.db.ExecContext(ctx, q, name, string(raw), now, now)
+
+	return err
+}
+
+func (repo *repo) DeleteProfile(ctx context.Context, name string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM settings_profiles WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return web.ErrNotFound
+	}
+
+	return err
+}
+
+func (repo *repo) CreateTenant(ctx context.Context, name, tokenHash string) (web.Tenant, error) {
+	const q = `INSERT INTO tenants (name, token_hash, settings, created_at, updated_at) VALUES (?, ?, '{}', ?, ?)
+		ON CONFLICT (name) DO NOTHING`
+
+	now := time.Now().UTC()
+
+	res, err := repo.db.ExecContext(ctx, q, name, tokenHash, now.Unix(), now.Unix())
+	if err != nil {
+		return web.Tenant{}, err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return web.Tenant{}, fmt.Errorf("tenant %s: %w", name, web.ErrAlreadyExists)
+	}
+
+	return web.Tenant{Name: name, CreatedAt: time.Unix(now.Unix(), 0).UTC()}, nil
+}
+
+func (repo *repo) TenantByToken(ctx context.Context, tokenHash string) (web.Tenant, error) {
+	var (
+		ans       web.Tenant
+		createdAt int64
+	)
+
+	err := repo.db.QueryRowContext(ctx, `SELECT name, created_at FROM tenants WHERE token_hash = ?`, tokenHash).Scan(&ans.Name, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Tenant{}, web.ErrNotFound
+	}
+
+	ans.CreatedAt = time.Unix(createdAt, 0).UTC()
+
+	return ans, err
+}
+
+func (repo *repo) ListTenants(ctx context.Context) ([]web.Tenant, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT name, created_at FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ans := []web.Tenant{}
+
+	for rows.Next() {
+		var (
+			t         web.Tenant
+			createdAt int64
+		)
+
+		if err := rows.Scan(&t.Name, &createdAt); err != nil {
+			return nil, err
+		}
+
+		t.CreatedAt = time.Unix(createdAt, 0).UTC()
+
+		ans = append(ans, t)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) GetTenantSettings(ctx context.Context, name string) (web.Settings, error) {
+	var raw string
+
+	err := repo.db.QueryRowContext(ctx, `SELECT settings FROM tenants WHERE name = ?`, name).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Settings{}, web.ErrNotFound
+	}
+
+	if err != nil {
+		return web.Settings{}, err
+	}
+
+	var ans web.Settings
+
+	err = json.Unmarshal([]byte(raw), &ans)
+
+	return ans, err
+}
+
+func (repo *repo) UpsertTenantSettings(ctx context.Context, name string, settings *web.Settings) error {
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	res, err := repo.db.ExecContext(ctx, `UPDATE tenants SET settings = ?, updated_at = ? WHERE name = ?`,
+		string(raw), time.Now().UTC().Unix(), name)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return web.ErrNotFound
+	}
+
+	return err
+}
+
+func (repo *repo) DeleteTenant(ctx context.Context, name string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM tenants WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return web.ErrNotFound
+	}
+
+	return err
+}
+
+func (repo *repo) ListPipelines(ctx context.Context) ([]web.Pipeline, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT data FROM pipelines ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ans := []web.Pipeline{}
+
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+
+		var p web.Pipeline
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, p)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) GetPipeline(ctx context.Context, id string) (web.Pipeline, error) {
+	var raw string
+
+	err := repo.db.QueryRowContext(ctx, `SELECT data FROM pipelines WHERE id = ?`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Pipeline{}, fmt.Errorf("%w: pipeline %s", web.ErrNotFound, id)
+	}
+
+	if err != nil {
+		return web.Pipeline{}, err
+	}
+
+	var ans web.Pipeline
+
+	err = json.Unmarshal([]byte(raw), &ans)
+
+	return ans, err
+}
+
+func (repo *repo) UpsertPipeline(ctx context.Context, p *web.Pipeline) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	const q = `INSERT INTO pipelines (id, tenant, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`
+
+	_, err = repo.db.ExecContext(ctx, q, p.ID, p.Tenant, string(raw), p.Date.Unix(), time.Now().UTC().Unix())
+
+	return err
+}
+
+func (repo *repo) DeletePipeline(ctx context.Context, id string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM pipelines WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: pipeline %s", web.ErrNotFound, id)
+	}
+
+	return err
+}
+
+func (repo *repo) ListWatchlists(ctx context.Context) ([]web.Watchlist, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT data FROM watchlists ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ans := []web.Watchlist{}
+
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+
+		var wl web.Watchlist
+		if err := json.Unmarshal([]byte(raw), &wl); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, wl)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) GetWatchlist(ctx context.Context, id string) (web.Watchlist, error) {
+	var raw string
+
+	err := repo.db.QueryRowContext(ctx, `SELECT data FROM watchlists WHERE id = ?`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Watchlist{}, fmt.Errorf("%w: watchlist %s", web.ErrNotFound, id)
+	}
+
+	if err != nil {
+		return web.Watchlist{}, err
+	}
+
+	var ans web.Watchlist
+
+	err = json.Unmarshal([]byte(raw), &ans)
+
+	return ans, err
+}
+
+func (repo *repo) UpsertWatchlist(ctx context.Context, wl *web.Watchlist) error {
+	raw, err := json.Marshal(wl)
+	if err != nil {
+		return err
+	}
+
+	const q = `INSERT INTO watchlists (id, tenant, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`
+
+	_, err = repo.db.ExecContext(ctx, q, wl.ID, wl.Tenant, string(raw), wl.Date.Unix(), time.Now().UTC().Unix())
+
+	return err
+}
+
+func (repo *repo) DeleteWatchlist(ctx context.Context, id string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM watchlists WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: watchlist %s", web.ErrNotFound, id)
+	}
+
+	return err
+}
+
+func (repo *repo) ListSuppressionLists(ctx context.Context) ([]web.SuppressionListInfo, error) {
+	const q = `SELECT list, SUM(INSTR(value, '@') > 0), SUM(INSTR(value, '@') = 0), MAX(added_at)
+		FROM suppressions GROUP BY list ORDER BY list`
+
+	rows, err := repo.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ans := []web.SuppressionListInfo{}
+
+	for rows.Next() {
+		var (
+			info    web.SuppressionListInfo
+			addedAt int64
+		)
+
+		if err := rows.Scan(&info.Name, &info.Emails, &info.Domains, &addedAt); err != nil {
+			return nil, err
+		}
+
+		info.UpdatedAt = time.Unix(addedAt, 0).UTC()
+
+		ans = append(ans, info)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) AddSuppressions(ctx context.Context, name string, values []string, replace bool) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM suppressions WHERE list = ?`, name); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO suppressions (list, value, added_at) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	now := time.Now().UTC().Unix()
+
+	for _, v := range values {
+		if _, err := stmt.ExecContext(ctx, name, v, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *repo) GetSuppressions(ctx context.Context, name string) ([]string, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT value FROM suppressions WHERE list = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []string
+
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ans) == 0 {
+		return nil, web.ErrNotFound
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) DeleteSuppressionList(ctx context.Context, name string) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM suppressions WHERE list = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return web.ErrNotFound
+	}
+
+	return err
+}
+
+type repo struct {
+	db *sql.DB
+}
+
+func New(path string) (web.JobRepository, error) {
+	db, err := initDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repo{db: db}, nil
+}
+
+// Snapshot writes a consistent copy of the database to path, while the
+// jobs keep writing to it.
+func (repo *repo) Snapshot(ctx context.Context, path string) error {
+	_, err := repo.db.ExecContext(ctx, "VACUUM INTO ?", path)
+
+	return err
+}
+
+// Check runs the integrity check of SQLite on the database at path.
+func Check(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+
+	if result != "ok" {
+		return fmt.Errorf("integrity check: %s", result)
+	}
+
+	return nil
+}
+
+// UpstreamJobs reads the jobs of a jobs.db of the upstream scraper,
+// gosom/google-maps-scraper, without changing it: its jobs table is the
+// first version of this one.
+func UpstreamJobs(path string) ([]web.Job, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, name, status, data, created_at FROM jobs ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Job
+
+	for rows.Next() {
+		var (
+			j         web.Job
+			data      string
+			createdAt int64
+		)
+
+		if err := rows.Scan(&j.ID, &j.Name, &j.Status, &data, &createdAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(data), &j.Data); err != nil {
+			return nil, fmt.Errorf("job %s: %w", j.ID, err)
+		}
+
+		j.Date = time.Unix(createdAt, 0).UTC()
+
+		ans = append(ans, j)
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) AddSnapshots(ctx context.Context, tenant string, snapshots map[string]web.PlaceSnapshot) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO place_history
+		(tenant, cid, job_id, taken_at, title, review_rating, review_count, open_hours, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for cid, snap := range snapshots {
+		hours, err := json.Marshal(snap.OpenHours)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx, tenant, cid, snap.JobID, snap.At.Unix(), snap.Title, snap.ReviewRating, snap.ReviewCount, string(hours), snap.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *repo) PlaceHistory(ctx context.Context, tenant, cid string, from, to time.Time) ([]web.PlaceSnapshot, error) {
+	q := `SELECT job_id, taken_at, title, review_rating, review_count, open_hours, status
+		FROM place_history WHERE tenant = ? AND cid = ?`
+	args := []any{tenant, cid}
+
+	if !from.IsZero() {
+		q += ` AND taken_at >= ?`
+		args = append(args, from.Unix())
+	}
+
+	if !to.IsZero() {
+		q += ` AND taken_at <= ?`
+		args = append(args, to.Unix())
+	}
+
+	q += ` ORDER BY taken_at, job_id`
+
+	rows, err := repo.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.PlaceSnapshot
+
+	for rows.Next() {
+		var (
+			snap    web.PlaceSnapshot
+			takenAt int64
+			hours   string
+		)
+
+		err := rows.Scan(&snap.JobID, &takenAt, &snap.Title, &snap.ReviewRating, &snap.ReviewCount, &hours, &snap.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(hours), &snap.OpenHours); err != nil {
+			return nil, err
+		}
+
+		snap.At = time.Unix(takenAt, 0).UTC()
+
+		ans = append(ans, snap)
+	}
+
+	return ans, rows.Err()
 }
 
-func (repo *repo) UpsertSettings(ctx context.Context, settings *web.Settings) error {
-	proxiesJSON, err := json.Marshal(settings.Proxies)
+func (repo *repo) IndexPlaces(ctx context.Context, tenant, jobID string, places []web.SearchPlace) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	emailInt := 0
-	if settings.Email {
-		emailInt = 1
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_places WHERE job_id = ?`, jobID); err != nil {
+		return err
 	}
 
-	const q = `INSERT OR REPLACE INTO settings (id, language, depth, email, max_time, proxies, created_at, updated_at) VALUES (1, ?, ?, ?, ?, ?, COALESCE((SELECT created_at FROM settings WHERE id = 1), ?), ?)`
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO search_places
+		(title, category, address, emails, tenant, job_id, record, phone, web_site)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
 
-	now := time.Now().UTC().Unix()
+	defer stmt.Close()
 
-	_, err = repo.db.ExecContext(ctx, q,
-		settings.Language,
-		settings.Depth,
-		emailInt,
-		settings.MaxTime,
-		string(proxiesJSON),
-		now,
-		now,
-	)
+	for i := range places {
+		p := &places[i]
 
-	return err
+		_, err := stmt.ExecContext(ctx, p.Title, p.Category, p.Address, strings.Join(p.Emails, " "), tenant, jobID, p.Record, p.Phone, p.WebSite)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO search_jobs (job_id, indexed_at) VALUES (?, ?)`, jobID, time.Now().UTC().Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-type repo struct {
-	db *sql.DB
+func (repo *repo) RemovePlaces(ctx context.Context, jobID string) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_places WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_jobs WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func New(path string) (web.JobRepository, error) {
-	db, err := initDatabase(path)
+func (repo *repo) IndexedJobs(ctx context.Context) (map[string]bool, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT job_id FROM search_jobs`)
 	if err != nil {
 		return nil, err
 	}
 
-	return &repo{db: db}, nil
+	defer rows.Close()
+
+	ans := map[string]bool{}
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ans[id] = true
+	}
+
+	return ans, rows.Err()
+}
+
+func (repo *repo) SearchPlaces(ctx context.Context, tenant, query string, limit, offset int) ([]web.SearchPlace, int, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return []web.SearchPlace{}, 0, nil
+	}
+
+	var total int
+
+	err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM search_places WHERE search_places MATCH ? AND tenant = ?`, match, tenant).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := repo.db.QueryContext(ctx, `SELECT job_id, record, title, category, address, emails, phone, web_site
+		FROM search_places WHERE search_places MATCH ? AND tenant = ?
+		ORDER BY bm25(search_places), job_id, record LIMIT ? OFFSET ?`, match, tenant, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	ans := []web.SearchPlace{}
+
+	for rows.Next() {
+		var (
+			p      web.SearchPlace
+			emails string
+		)
+
+		err := rows.Scan(&p.JobID, &p.Record, &p.Title, &p.Category, &p.Address, &emails, &p.Phone, &p.WebSite)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		p.Emails = strings.Fields(emails)
+
+		ans = append(ans, p)
+	}
+
+	return ans, total, rows.Err()
+}
+
+// ftsQuery turns the words of query into a FTS5 query matching the places
+// holding all of them, each word as a prefix. The words are quoted, so that
+// the FTS5 syntax in query is searched as text.
+func ftsQuery(query string) string {
+	var terms []string
+
+	for _, word := range strings.Fields(query) {
+		if !strings.ContainsFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+
+	return strings.Join(terms, " ")
 }
 
 func (repo *repo) Get(ctx context.Context, id string) (web.Job, error) {
-	const q = `SELECT * from jobs WHERE id = ?`
+	const q = `SELECT ` + jobColumns + ` FROM jobs WHERE id = ?`
 
 	row := repo.db.QueryRowContext(ctx, q, id)
 
-	return rowToJob(row)
+	job, err := rowToJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Job{}, fmt.Errorf("%w: job %s", web.ErrNotFound, id)
+	}
+
+	return job, err
 }
 
 func (repo *repo) Create(ctx context.Context, job *web.Job) error {
@@ -96,13 +931,18 @@ func (repo *repo) Create(ctx context.Context, job *web.Job) error {
 		return err
 	}
 
-	const q = `INSERT INTO jobs (id, name, status, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	const q = `INSERT INTO jobs (` + jobColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, client_job_id) WHERE client_job_id != '' DO NOTHING`
 
-	_, err = repo.db.ExecContext(ctx, q, item.ID, item.Name, item.Status, item.Data, item.CreatedAt, item.UpdatedAt)
+	res, err := repo.db.ExecContext(ctx, q, item.ID, item.Name, item.Status, item.Data, item.Stats, item.CreatedAt, item.UpdatedAt, item.Tenant, item.ClientJobID)
 	if err != nil {
 		return err
 	}
 
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("job %s: %w", item.ClientJobID, web.ErrAlreadyExists)
+	}
+
 	return nil
 }
 
@@ -115,16 +955,27 @@ func (repo *repo) Delete(ctx context.Context, id string) error {
 }
 
 func (repo *repo) Select(ctx context.Context, params web.SelectParams) ([]web.Job, error) {
-	q := `SELECT * from jobs`
+	q := `SELECT ` + jobColumns + ` FROM jobs`
 
-	var args []any
+	var (
+		where []string
+		args  []any
+	)
 
 	if params.Status != "" {
-		q += ` WHERE status = ?`
-
+		where = append(where, `status = ?`)
 		args = append(args, params.Status)
 	}
 
+	if params.ClientJobID != "" {
+		where = append(where, `client_job_id = ?`)
+		args = append(args, params.ClientJobID)
+	}
+
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
 	q += " ORDER BY created_at DESC"
 
 	if params.Limit > 0 {
@@ -164,13 +1015,16 @@ func (repo *repo) Update(ctx context.Context, job *web.Job) error {
 		return err
 	}
 
-	const q = `UPDATE jobs SET name = ?, status = ?, data = ?, updated_at = ? WHERE id = ?`
+	const q = `UPDATE jobs SET name = ?, status = ?, data = ?, stats = ?, updated_at = ? WHERE id = ?`
 
-	_, err = repo.db.ExecContext(ctx, q, item.Name, item.Status, item.Data, item.UpdatedAt, item.ID)
+	_, err = repo.db.ExecContext(ctx, q, item.Name, item.Status, item.Data, item.Stats, item.UpdatedAt, item.ID)
 
 	return err
 }
 
+// jobColumns lists the jobs columns in the order scanned by rowToJob.
+const jobColumns = `id, name, status, data, stats, created_at, updated_at, tenant, client_job_id`
+
 type scannable interface {
 	Scan(dest ...any) error
 }
@@ -178,16 +1032,18 @@ type scannable interface {
 func rowToJob(row scannable) (web.Job, error) {
 	var j job
 
-	err := row.Scan(&j.ID, &j.Name, &j.Status, &j.Data, &j.CreatedAt, &j.UpdatedAt)
+	err := row.Scan(&j.ID, &j.Name, &j.Status, &j.Data, &j.Stats, &j.CreatedAt, &j.UpdatedAt, &j.Tenant, &j.ClientJobID)
 	if err != nil {
 		return web.Job{}, err
 	}
 
 	ans := web.Job{
-		ID:     j.ID,
-		Name:   j.Name,
-		Status: j.Status,
-		Date:   time.Unix(j.CreatedAt, 0).UTC(),
+		ID:          j.ID,
+		Name:        j.Name,
+		Status:      j.Status,
+		Date:        time.Unix(j.CreatedAt, 0).UTC(),
+		Tenant:      j.Tenant,
+		ClientJobID: j.ClientJobID,
 	}
 
 	err = json.Unmarshal([]byte(j.Data), &ans.Data)
@@ -195,6 +1051,9 @@ func rowToJob(row scannable) (web.Job, error) {
 		return web.Job{}, err
 	}
 
+	// stats are informational: a bad value must not hide the job
+	_ = json.Unmarshal([]byte(j.Stats), &ans.Stats)
+
 	return ans, nil
 }
 
@@ -204,23 +1063,34 @@ func jobToRow(item *web.Job) (job, error) {
 		return job{}, err
 	}
 
+	stats, err := json.Marshal(item.Stats)
+	if err != nil {
+		return job{}, err
+	}
+
 	return job{
-		ID:        item.ID,
-		Name:      item.Name,
-		Status:    item.Status,
-		Data:      string(data),
-		CreatedAt: item.Date.Unix(),
-		UpdatedAt: time.Now().UTC().Unix(),
+		ID:          item.ID,
+		Name:        item.Name,
+		Status:      item.Status,
+		Data:        string(data),
+		Stats:       string(stats),
+		CreatedAt:   item.Date.Unix(),
+		UpdatedAt:   time.Now().UTC().Unix(),
+		Tenant:      item.Tenant,
+		ClientJobID: item.ClientJobID,
 	}, nil
 }
 
 type job struct {
-	ID        string
-	Name      string
-	Status    string
-	Data      string
-	CreatedAt int64
-	UpdatedAt int64
+	ID          string
+	Name        string
+	Status      string
+	Data        string
+	Stats       string
+	CreatedAt   int64
+	UpdatedAt   int64
+	Tenant      string
+	ClientJobID string
 }
 
 func initDatabase(path string) (*sql.DB, error) {
@@ -268,8 +1138,11 @@ func createSchema(db *sql.DB) error {
 			name TEXT NOT NULL,
 			status TEXT NOT NULL,
 			data TEXT NOT NULL,
+			stats TEXT NOT NULL DEFAULT '{}',
 			created_at INT NOT NULL,
-			updated_at INT NOT NULL
+			updated_at INT NOT NULL,
+			tenant TEXT NOT NULL DEFAULT '',
+			client_job_id TEXT NOT NULL DEFAULT ''
 		)
 	`)
 	if err != nil {
@@ -284,6 +1157,155 @@ func createSchema(db *sql.DB) error {
 			email INTEGER NOT NULL DEFAULT 0,
 			max_time TEXT NOT NULL DEFAULT '10m',
 			proxies TEXT NOT NULL DEFAULT '[]',
+			email_proxies TEXT NOT NULL DEFAULT '{}',
+			email_host_interval TEXT NOT NULL DEFAULT '',
+			proxy_provider TEXT NOT NULL DEFAULT '',
+			monthly_max_places INTEGER NOT NULL DEFAULT 0,
+			monthly_max_email_fetches INTEGER NOT NULL DEFAULT 0,
+			fast_lane_threshold INTEGER NOT NULL DEFAULT 0,
+			usage_webhook TEXT NOT NULL DEFAULT '',
+			usage_thresholds TEXT NOT NULL DEFAULT '{}',
+			branding TEXT NOT NULL DEFAULT '{}',
+			export TEXT NOT NULL DEFAULT '{}',
+			export_mappings TEXT NOT NULL DEFAULT '{}',
+			territories TEXT NOT NULL DEFAULT 'null',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// columns added after the first release, missing in older databases
+	if err := addColumnIfMissing(db, "settings", "email_proxies", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "proxy_provider", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "monthly_max_places", `INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "monthly_max_email_fetches", `INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "fast_lane_threshold", `INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "usage_webhook", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "usage_thresholds", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "branding", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "export", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "export_mappings", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "territories", `TEXT NOT NULL DEFAULT 'null'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "settings", "email_host_interval", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "jobs", "stats", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "jobs", "tenant", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	if err := addColumnIfMissing(db, "jobs", "client_job_id", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	// an idempotency key names one job of its tenant: the duplicates that
+	// raced in before the key was unique keep the oldest job only
+	_, err = db.Exec(`
+		DROP INDEX IF EXISTS jobs_client_job_id;
+		UPDATE jobs SET client_job_id = '' WHERE client_job_id != '' AND rowid NOT IN (
+			SELECT MIN(rowid) FROM jobs WHERE client_job_id != '' GROUP BY tenant, client_job_id
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS jobs_tenant_client_job_id ON jobs (tenant, client_job_id) WHERE client_job_id != ''
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS settings_profiles (
+			name TEXT PRIMARY KEY,
+			settings TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS tenants (
+			name TEXT PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			settings TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS pipelines (
+			id TEXT PRIMARY KEY,
+			tenant TEXT NOT NULL DEFAULT '',
+			data TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS suppressions (
+			list TEXT NOT NULL,
+			value TEXT NOT NULL,
+			added_at INTEGER NOT NULL,
+			PRIMARY KEY (list, value)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS watchlists (
+			id TEXT PRIMARY KEY,
+			tenant TEXT NOT NULL DEFAULT '',
+			data TEXT NOT NULL,
 			created_at INTEGER NOT NULL,
 			updated_at INTEGER NOT NULL
 		)
@@ -292,6 +1314,52 @@ func createSchema(db *sql.DB) error {
 		return err
 	}
 
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS place_history (
+			tenant TEXT NOT NULL,
+			cid TEXT NOT NULL,
+			job_id TEXT NOT NULL,
+			taken_at INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			review_rating REAL NOT NULL,
+			review_count INTEGER NOT NULL,
+			open_hours TEXT NOT NULL DEFAULT 'null',
+			status TEXT NOT NULL,
+			PRIMARY KEY (tenant, cid, job_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS search_places USING fts5(
+			title,
+			category,
+			address,
+			emails,
+			tenant UNINDEXED,
+			job_id UNINDEXED,
+			record UNINDEXED,
+			phone UNINDEXED,
+			web_site UNINDEXED,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS search_jobs (
+			job_id TEXT PRIMARY KEY,
+			indexed_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC().Unix()
 
 	_, err = db.Exec(
@@ -301,3 +1369,31 @@ func createSchema(db *sql.DB) error {
 
 	return err
 }
+
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+
+		if name == column {
+			return nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition)
+
+	return err
+}
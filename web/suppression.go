@@ -0,0 +1,361 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// A suppression list ("contacted-2025", "unsubscribed") holds the emails and
+// domains already contacted. The exports can flag or exclude the places
+// matching one, so that leads are not contacted twice by campaigns run
+// months apart.
+
+// Suppression actions of the exports, see ExportFilter.
+const (
+	SuppressExclude = "exclude"
+	SuppressFlag    = "flag"
+)
+
+// SuppressionListInfo describes a suppression list.
+type SuppressionListInfo struct {
+	Name      string    `json:"name"`
+	Emails    int       `json:"emails"`
+	Domains   int       `json:"domains"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SuppressionRepository is implemented by the repositories storing the
+// suppression lists.
+type SuppressionRepository interface {
+	ListSuppressionLists(context.Context) ([]SuppressionListInfo, error)
+	// AddSuppressions adds the normalized values to list name, creating it,
+	// after clearing it when replace is set.
+	AddSuppressions(ctx context.Context, name string, values []string, replace bool) error
+	// GetSuppressions returns the values of list name, ErrNotFound when
+	// there is none.
+	GetSuppressions(ctx context.Context, name string) ([]string, error)
+	DeleteSuppressionList(ctx context.Context, name string) error
+}
+
+// SuppressionUpload tells what Service.AddSuppressions did.
+type SuppressionUpload struct {
+	SuppressionListInfo
+	// Added counts the valid values read, Skipped the ones that are
+	// neither an email nor a domain.
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// ValidateSuppressionListName checks that name can name a suppression list,
+// with the rules of the profile names.
+func ValidateSuppressionListName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid suppression list name %q: use up to 40 lowercase letters, digits, '-' and '_'", name)
+	}
+
+	return nil
+}
+
+// ParseSuppressions reads the emails and domains of r, one per line or
+// separated by commas, semicolons or tabs, as normalized by
+// gmaps.NormalizeSuppression. It returns the valid ones and the count of the
+// others, such as a CSV header.
+func ParseSuppressions(r io.Reader) (values []string, skipped int, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for sc.Scan() {
+		fields := strings.FieldsFunc(sc.Text(), func(r rune) bool {
+			return r == ',' || r == ';' || r == '\t'
+		})
+
+		for _, f := range fields {
+			if strings.TrimSpace(f) == "" {
+				continue
+			}
+
+			v, ok := gmaps.NormalizeSuppression(f)
+			if !ok {
+				skipped++
+
+				continue
+			}
+
+			values = append(values, v)
+		}
+	}
+
+	return values, skipped, sc.Err()
+}
+
+func (s *Service) suppressionRepo() (SuppressionRepository, error) {
+	repo, ok := s.repo.(SuppressionRepository)
+	if !ok {
+		return nil, errors.New("suppression lists not supported by repository")
+	}
+
+	return repo, nil
+}
+
+// SuppressionLists returns the suppression lists, sorted by name.
+func (s *Service) SuppressionLists(ctx context.Context) ([]SuppressionListInfo, error) {
+	repo, ok := s.repo.(SuppressionRepository)
+	if !ok {
+		return []SuppressionListInfo{}, nil
+	}
+
+	return repo.ListSuppressionLists(ctx)
+}
+
+// AddSuppressions adds the emails and domains read from r to list name, see
+// ParseSuppressions. replace clears the list first.
+func (s *Service) AddSuppressions(ctx context.Context, name string, r io.Reader, replace bool) (SuppressionUpload, error) {
+	values, skipped, err := ParseSuppressions(r)
+	if err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	return s.addSuppressions(ctx, name, values, skipped, replace)
+}
+
+// AddJobSuppressions adds the emails found by job jobID to list name, to
+// suppress the leads of a campaign once it is run.
+func (s *Service) AddJobSuppressions(ctx context.Context, name, jobID string, replace bool) (SuppressionUpload, error) {
+	if _, err := s.Get(ctx, jobID); err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	entries, err := s.loadEntries(ctx, jobID)
+	if err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	var (
+		values  []string
+		skipped int
+	)
+
+	for i := range entries {
+		for _, email := range entries[i].Emails {
+			if v, ok := gmaps.NormalizeSuppression(email); ok && strings.Contains(v, "@") {
+				values = append(values, v)
+			} else {
+				skipped++
+			}
+		}
+	}
+
+	return s.addSuppressions(ctx, name, values, skipped, replace)
+}
+
+func (s *Service) addSuppressions(ctx context.Context, name string, values []string, skipped int, replace bool) (SuppressionUpload, error) {
+	repo, err := s.suppressionRepo()
+	if err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	if err := ValidateSuppressionListName(name); err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	if err := repo.AddSuppressions(ctx, name, values, replace); err != nil {
+		return SuppressionUpload{}, err
+	}
+
+	ans := SuppressionUpload{
+		SuppressionListInfo: SuppressionListInfo{Name: name},
+		Added:               len(values),
+		Skipped:             skipped,
+	}
+
+	lists, err := repo.ListSuppressionLists(ctx)
+	if err != nil {
+		return ans, err
+	}
+
+	for _, l := range lists {
+		if l.Name == name {
+			ans.SuppressionListInfo = l
+		}
+	}
+
+	return ans, nil
+}
+
+// DeleteSuppressionList deletes suppression list name.
+func (s *Service) DeleteSuppressionList(ctx context.Context, name string) error {
+	repo, ok := s.repo.(SuppressionRepository)
+	if !ok {
+		return ErrNotFound
+	}
+
+	return repo.DeleteSuppressionList(ctx, name)
+}
+
+// Suppressions returns the union of the suppression lists names, an error
+// naming the first unknown one.
+func (s *Service) Suppressions(ctx context.Context, names []string) (*gmaps.SuppressionList, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	if TenantFrom(ctx) != "" {
+		return nil, fmt.Errorf("suppression lists: %w", ErrTenantForbidden)
+	}
+
+	repo, err := s.suppressionRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+
+	for _, name := range names {
+		list, err := repo.GetSuppressions(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("unknown suppression list %q", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, list...)
+	}
+
+	return gmaps.NewSuppressionList(values), nil
+}
+
+// uploadSuppressions adds the file or the text posted from the settings
+// page to a suppression list.
+func (s *Server) uploadSuppressions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var body io.Reader = strings.NewReader(r.FormValue("values"))
+
+	file, _, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+
+		body = io.MultiReader(file, strings.NewReader("\n"), body)
+	}
+
+	_, err = s.svc.AddSuppressions(r.Context(), r.FormValue("name"), body, r.FormValue("replace") == "on")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	redirectAfter(w, r, "/settings")
+}
+
+// deleteSuppressions deletes the suppression list posted from the settings
+// page.
+func (s *Server) deleteSuppressions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	err := s.svc.DeleteSuppressionList(r.Context(), r.FormValue("name"))
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	redirectAfter(w, r, "/settings")
+}
+
+type apiSuppressionListsResponse struct {
+	Lists []SuppressionListInfo `json:"lists"`
+}
+
+func (s *Server) apiGetSuppressionLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := s.svc.SuppressionLists(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiSuppressionListsResponse{Lists: lists})
+}
+
+// apiAddSuppressions adds the emails and domains of the request body to a
+// suppression list, or with job=ID the emails found by that job.
+// replace=true clears the list first.
+func (s *Server) apiAddSuppressions(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    = r.PathValue("name")
+		replace = r.URL.Query().Get("replace") == "true"
+		upload  SuppressionUpload
+		err     error
+	)
+
+	if jobID := r.URL.Query().Get("job"); jobID != "" {
+		upload, err = s.svc.AddJobSuppressions(r.Context(), name, jobID, replace)
+	} else {
+		upload, err = s.svc.AddSuppressions(r.Context(), name, r.Body, replace)
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: "Job not found",
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, upload)
+}
+
+func (s *Server) apiDeleteSuppressionList(w http.ResponseWriter, r *http.Request) {
+	err := s.svc.DeleteSuppressionList(r.Context(), r.PathValue("name"))
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: "Suppression list not found",
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,378 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A tenant is a client account of a shared deployment. It calls the REST API
+// with its own token and only ever sees its own jobs, whose result files are
+// kept in a folder of their own, and its jobs run with its own settings and
+// monthly budgets, set by the owner of the deployment. The jobs of the owner,
+// those created with the API token or from the Web UI, belong to the default
+// tenant "".
+
+// tenantTokenPrefix starts the API tokens of the tenants.
+const tenantTokenPrefix = "gmt_"
+
+var (
+	// ErrTenantForbidden is returned when a tenant asks for something shared
+	// by the whole deployment, like the profiles or the suppression lists.
+	ErrTenantForbidden = errors.New("not available to tenants")
+	// ErrTenantsDisabled is returned when managing the tenants of a
+	// deployment without API token, whose API is open to anyone.
+	ErrTenantsDisabled = errors.New("tenants need an API token")
+)
+
+// Tenant is a client account, see WithTenant.
+type Tenant struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewTenant is a tenant just created, with the only copy of its token.
+type NewTenant struct {
+	Tenant
+	Token string `json:"token"`
+}
+
+// TenantRepository is implemented by the repositories storing the tenants.
+type TenantRepository interface {
+	// CreateTenant returns ErrAlreadyExists when tenant name exists.
+	CreateTenant(ctx context.Context, name, tokenHash string) (Tenant, error)
+	// TenantByToken returns ErrNotFound when no tenant has tokenHash.
+	TenantByToken(ctx context.Context, tokenHash string) (Tenant, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	// GetTenantSettings returns ErrNotFound when there is no tenant name.
+	GetTenantSettings(ctx context.Context, name string) (Settings, error)
+	UpsertTenantSettings(ctx context.Context, name string, settings *Settings) error
+	DeleteTenant(ctx context.Context, name string) error
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx acting for tenant name, "" for the
+// default tenant.
+func WithTenant(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, name)
+}
+
+// TenantFrom returns the tenant ctx acts for, "" for the default tenant.
+func TenantFrom(ctx context.Context) string {
+	name, _ := ctx.Value(tenantKey{}).(string)
+
+	return name
+}
+
+// TenantFolder returns the folder of the result files of the jobs of tenant
+// name under dataFolder: dataFolder itself for the default tenant.
+func TenantFolder(dataFolder, name string) string {
+	if name == "" {
+		return dataFolder
+	}
+
+	return filepath.Join(dataFolder, "tenants", name)
+}
+
+// folder returns the folder of the result files of the tenant of ctx.
+func (s *Service) folder(ctx context.Context) string {
+	return TenantFolder(s.dataFolder, TenantFrom(ctx))
+}
+
+// hashTenantToken returns what is stored of a tenant token.
+func hashTenantToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) tenantRepo() (TenantRepository, error) {
+	repo, ok := s.repo.(TenantRepository)
+	if !ok {
+		return nil, errors.New("tenants not supported by repository")
+	}
+
+	return repo, nil
+}
+
+// CreateTenant creates tenant name, with the settings of the default tenant
+// as a start, and returns its API token.
+func (s *Service) CreateTenant(ctx context.Context, name string) (NewTenant, error) {
+	repo, err := s.tenantRepo()
+	if err != nil {
+		return NewTenant{}, err
+	}
+
+	if err := ValidateTenantName(name); err != nil {
+		return NewTenant{}, err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return NewTenant{}, err
+	}
+
+	token := tenantTokenPrefix + hex.EncodeToString(buf)
+
+	tenant, err := repo.CreateTenant(ctx, name, hashTenantToken(token))
+	if err != nil {
+		return NewTenant{}, err
+	}
+
+	settings, err := s.GetSettings(WithTenant(ctx, ""))
+	if err != nil {
+		return NewTenant{}, err
+	}
+
+	// the budgets of the deployment are not those of a client
+	settings.MonthlyMaxPlaces = 0
+	settings.MonthlyMaxEmailFetches = 0
+
+	if err := repo.UpsertTenantSettings(ctx, name, &settings); err != nil {
+		return NewTenant{}, err
+	}
+
+	return NewTenant{Tenant: tenant, Token: token}, nil
+}
+
+// ValidateTenantName checks that name can name a tenant, like a profile.
+func ValidateTenantName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid tenant name %q: use up to 40 lowercase letters, digits, '-' and '_'", name)
+	}
+
+	return nil
+}
+
+// Tenants returns the tenants, sorted by name.
+func (s *Service) Tenants(ctx context.Context) ([]Tenant, error) {
+	repo, ok := s.repo.(TenantRepository)
+	if !ok {
+		return []Tenant{}, nil
+	}
+
+	return repo.ListTenants(ctx)
+}
+
+// TenantByToken returns the tenant of API token, ErrNotFound when there is
+// none.
+func (s *Service) TenantByToken(ctx context.Context, token string) (Tenant, error) {
+	repo, ok := s.repo.(TenantRepository)
+	if !ok || !strings.HasPrefix(token, tenantTokenPrefix) {
+		return Tenant{}, ErrNotFound
+	}
+
+	return repo.TenantByToken(ctx, hashTenantToken(token))
+}
+
+// TenantSettings returns the settings of tenant name.
+func (s *Service) TenantSettings(ctx context.Context, name string) (Settings, error) {
+	repo, err := s.tenantRepo()
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings, err := repo.GetTenantSettings(ctx, name)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings.ApplyDefaults()
+
+	return settings, nil
+}
+
+// SaveTenantSettings replaces the settings of tenant name, its monthly
+// budgets included.
+func (s *Service) SaveTenantSettings(ctx context.Context, name string, settings *Settings) error {
+	repo, err := s.tenantRepo()
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.GetTenantSettings(ctx, name); err != nil {
+		return err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	settings.ApplyDefaults()
+
+	return repo.UpsertTenantSettings(ctx, name, settings)
+}
+
+// DeleteTenant deletes tenant name with its jobs and their results. Its
+// running jobs are not stopped, but their results are dropped.
+func (s *Service) DeleteTenant(ctx context.Context, name string) error {
+	repo, err := s.tenantRepo()
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.GetTenantSettings(ctx, name); err != nil {
+		return err
+	}
+
+	tctx := WithTenant(ctx, name)
+
+	pipelines, err := s.Pipelines(tctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range pipelines {
+		if err := s.DeletePipeline(tctx, pipelines[i].ID); err != nil {
+			return err
+		}
+	}
+
+	jobs, err := s.All(tctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if err := s.Delete(tctx, jobs[i].ID); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.DeleteTenant(ctx, name); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(s.folder(tctx))
+}
+
+// tenantAllowed reports whether a tenant may call the API at path: the
+// profiles, the suppression lists, the archives, the backups and the tenants
+// are shared by the deployment.
+func tenantAllowed(path string) bool {
+	for _, prefix := range []string{"/api/v1/profiles", "/api/v1/suppression-lists", "/api/v1/export", "/api/v1/import", "/api/v1/backup", "/api/v1/tenants"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tenantStatus returns the HTTP status of a tenant error.
+func tenantStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrTenantsDisabled):
+		return http.StatusForbidden
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+func renderTenantError(w http.ResponseWriter, err error) {
+	code := tenantStatus(err)
+
+	renderJSON(w, code, apiError{
+		Code:    code,
+		Message: err.Error(),
+	})
+}
+
+type apiTenantsResponse struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+type apiCreateTenantRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) apiGetTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := s.svc.Tenants(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiTenantsResponse{Tenants: tenants})
+}
+
+func (s *Server) apiCreateTenant(w http.ResponseWriter, r *http.Request) {
+	if s.apiToken == "" {
+		renderTenantError(w, ErrTenantsDisabled)
+
+		return
+	}
+
+	var req apiCreateTenantRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderTenantError(w, err)
+
+		return
+	}
+
+	tenant, err := s.svc.CreateTenant(r.Context(), req.Name)
+	if err != nil {
+		renderTenantError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusCreated, tenant)
+}
+
+func (s *Server) apiDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	if err := s.svc.DeleteTenant(r.Context(), r.PathValue("name")); err != nil {
+		renderTenantError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) apiGetTenantSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.svc.TenantSettings(r.Context(), r.PathValue("name"))
+	if err != nil {
+		renderTenantError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) apiSaveTenantSettings(w http.ResponseWriter, r *http.Request) {
+	var settings Settings
+
+	err := json.NewDecoder(r.Body).Decode(&settings)
+	if err == nil {
+		err = s.svc.SaveTenantSettings(r.Context(), r.PathValue("name"), &settings)
+	}
+
+	if err != nil {
+		renderTenantError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, settings)
+}
@@ -0,0 +1,121 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+func newTestService(t *testing.T, opts ...web.ServiceOption) (*web.Service, string) {
+	t.Helper()
+
+	folder := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(folder, "jobs.db"))
+	require.NoError(t, err)
+
+	return web.NewService(repo, folder, opts...), folder
+}
+
+// endJob creates a job of data for the tenant of ctx, writes its results
+// with the plain copies a running job leaves around, and ends it ok.
+func endJob(ctx context.Context, t *testing.T, svc *web.Service, folder string, data web.JobData) web.Job {
+	t.Helper()
+
+	folder = web.TenantFolder(folder, web.TenantFrom(ctx))
+	require.NoError(t, os.MkdirAll(folder, 0o755))
+
+	job := web.Job{ID: uuid.New().String(), Name: "cafes", Date: time.Now().UTC(), Status: web.StatusPending, Data: data}
+	require.NoError(t, svc.Create(ctx, &job))
+
+	entries := []*gmaps.Entry{{Cid: "1234567890", Title: "Caffe Sport", ReviewRating: 4.5, ReviewCount: 120}}
+	require.NoError(t, web.WriteResults(folder, job.ID, entries, web.CSVDefault))
+
+	base := filepath.Join(folder, job.ID)
+	for _, p := range []string{base + ".json.gz", base + ".csv.gz", base + ".json.idx", base + ".ndjson"} {
+		require.NoError(t, os.WriteFile(p, []byte("Caffe Sport"), 0o600))
+	}
+
+	job.Status = web.StatusOK
+	require.NoError(t, svc.Update(ctx, &job))
+
+	return job
+}
+
+func TestTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	svc, folder := newTestService(t)
+
+	srv, err := web.New(svc, "127.0.0.1:0", "owner-token")
+	require.NoError(t, err)
+
+	acme, err := svc.CreateTenant(ctx, "acme")
+	require.NoError(t, err)
+
+	globex, err := svc.CreateTenant(ctx, "globex")
+	require.NoError(t, err)
+
+	job := endJob(web.WithTenant(ctx, "acme"), t, svc, folder, web.JobData{Keywords: []string{"cafe"}})
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  int
+	}{
+		{"own job", acme.Token, "/api/v1/jobs/" + job.ID, http.StatusOK},
+		{"own file", acme.Token, "/api/v1/jobs/" + job.ID + "/download/json", http.StatusOK},
+		{"own records", acme.Token, "/api/v1/jobs/" + job.ID + "/records", http.StatusOK},
+		{"own history", acme.Token, "/api/v1/places/1234567890/history", http.StatusOK},
+		{"job of another tenant", globex.Token, "/api/v1/jobs/" + job.ID, http.StatusNotFound},
+		{"file of another tenant", globex.Token, "/api/v1/jobs/" + job.ID + "/download/json", http.StatusNotFound},
+		{"csv of another tenant", globex.Token, "/api/v1/jobs/" + job.ID + "/download/csv", http.StatusNotFound},
+		{"records of another tenant", globex.Token, "/api/v1/jobs/" + job.ID + "/records", http.StatusNotFound},
+		{"history of another tenant", globex.Token, "/api/v1/places/1234567890/history", http.StatusNotFound},
+		{"profiles", globex.Token, "/api/v1/profiles", http.StatusForbidden},
+		{"profile", globex.Token, "/api/v1/profiles/polite-eu", http.StatusForbidden},
+		{"suppression lists", globex.Token, "/api/v1/suppression-lists", http.StatusForbidden},
+		{"export", globex.Token, "/api/v1/export", http.StatusForbidden},
+		{"import", globex.Token, "/api/v1/import", http.StatusForbidden},
+		{"backup", globex.Token, "/api/v1/backup", http.StatusForbidden},
+		{"tenants", globex.Token, "/api/v1/tenants", http.StatusForbidden},
+		{"settings of a tenant", globex.Token, "/api/v1/tenants/acme/settings", http.StatusForbidden},
+		{"unknown tenant token", "gmt_0123456789abcdef", "/api/v1/jobs/" + job.ID, http.StatusUnauthorized},
+		{"no token", "", "/api/v1/jobs", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+
+	// the jobs listed are those of the tenant only
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+globex.Token)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), job.ID)
+}
@@ -0,0 +1,214 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Territories are named polygons, like the regions of the sales reps, kept
+// in the settings of a tenant or in the data of a job. The exports assign
+// each place to the territory holding it, so that the results come split
+// across the reps; those of a job replace those of its tenant.
+
+// maxTerritoriesSize caps the GeoJSON uploaded as territories.
+const maxTerritoriesSize = 10 << 20
+
+// Territories returns the territories of the tenant of ctx.
+func (s *Service) Territories(ctx context.Context) (gmaps.Territories, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Territories, nil
+}
+
+// SaveTerritories replaces the territories of the tenant of ctx, nil
+// deleting them.
+func (s *Service) SaveTerritories(ctx context.Context, territories gmaps.Territories) error {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings.Territories = territories
+
+	return s.SaveSettings(ctx, &settings)
+}
+
+// JobTerritories returns the territories the results of job id are
+// assigned to: those of the job, or else those of its tenant.
+func (s *Service) JobTerritories(ctx context.Context, id string) (gmaps.Territories, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(job.Data.Territories) > 0 {
+		return job.Data.Territories, nil
+	}
+
+	return s.Territories(ctx)
+}
+
+// SaveJobTerritories replaces the territories of job id, nil falling back
+// to those of its tenant. The results are assigned at export time, so
+// those of a job already over can be split again.
+func (s *Service) SaveJobTerritories(ctx context.Context, id string, territories gmaps.Territories) error {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	job.Data.Territories = territories
+
+	return s.repo.Update(ctx, &job)
+}
+
+// readTerritories reads the GeoJSON of the body of r.
+func readTerritories(r *http.Request) (gmaps.Territories, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxTerritoriesSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxTerritoriesSize {
+		return nil, errors.New("territories GeoJSON too large")
+	}
+
+	return gmaps.ParseTerritories(data)
+}
+
+type apiTerritoriesResponse struct {
+	Names       []string          `json:"names"`
+	Territories gmaps.Territories `json:"territories"`
+}
+
+func newAPITerritoriesResponse(territories gmaps.Territories) apiTerritoriesResponse {
+	ans := apiTerritoriesResponse{Names: territories.Names(), Territories: territories}
+	if ans.Names == nil {
+		ans.Names = []string{}
+	}
+
+	if ans.Territories == nil {
+		ans.Territories = gmaps.Territories{}
+	}
+
+	return ans
+}
+
+func (s *Server) apiGetTerritories(w http.ResponseWriter, r *http.Request) {
+	territories, err := s.svc.Territories(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, newAPITerritoriesResponse(territories))
+}
+
+// apiSaveTerritories replaces the territories of the tenant with the
+// GeoJSON FeatureCollection of the request body.
+func (s *Server) apiSaveTerritories(w http.ResponseWriter, r *http.Request) {
+	territories, err := readTerritories(r)
+	if err == nil {
+		err = s.svc.SaveTerritories(r.Context(), territories)
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, newAPITerritoriesResponse(territories))
+}
+
+func (s *Server) apiDeleteTerritories(w http.ResponseWriter, r *http.Request) {
+	if err := s.svc.SaveTerritories(r.Context(), nil); err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// apiJobTerritories returns, replaces or deletes the territories of a job,
+// see Service.SaveJobTerritories. GET returns those the exports use, the
+// tenant ones when the job has none.
+func (s *Server) apiJobTerritories(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	// like apiGetJob, a job that cannot be read is not found
+	if _, err := s.svc.Get(r.Context(), id.String()); err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: "Job not found",
+		})
+
+		return
+	}
+
+	var (
+		territories gmaps.Territories
+		err         error
+	)
+
+	switch r.Method {
+	case http.MethodGet:
+		territories, err = s.svc.JobTerritories(r.Context(), id.String())
+	case http.MethodPut:
+		territories, err = readTerritories(r)
+		if err != nil {
+			renderJSON(w, http.StatusUnprocessableEntity, apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: err.Error(),
+			})
+
+			return
+		}
+
+		err = s.svc.SaveJobTerritories(r.Context(), id.String(), territories)
+	case http.MethodDelete:
+		err = s.svc.SaveJobTerritories(r.Context(), id.String(), nil)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, newAPITerritoriesResponse(territories))
+}
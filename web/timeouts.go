@@ -0,0 +1,103 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Default timeouts of the server, see WithTimeouts.
+const (
+	DefaultReadTimeout  = 60 * time.Second
+	DefaultWriteTimeout = 60 * time.Second
+	DefaultIdleTimeout  = 120 * time.Second
+)
+
+// ServerTimeouts are the timeouts of the HTTP server. Write bounds a whole
+// response, except the streamed ones, see streamingDeadlines.
+type ServerTimeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
+}
+
+// WithTimeouts sets the timeouts of the server; the zero ones keep their
+// default.
+func WithTimeouts(t ServerTimeouts) ServerOption {
+	return func(s *Server) {
+		if t.Read > 0 {
+			s.srv.ReadTimeout = t.Read
+		}
+
+		if t.Write > 0 {
+			s.srv.WriteTimeout = t.Write
+		}
+
+		if t.Idle > 0 {
+			s.srv.IdleTimeout = t.Idle
+		}
+	}
+}
+
+// streamingPath reports whether the responses to the requests for path are
+// streamed: the downloads, the exports and the backups, which a slow link
+// takes longer than the write timeout to receive.
+func streamingPath(path string) bool {
+	if strings.HasPrefix(path, "/download/") || strings.HasPrefix(path, sharedAPIPrefix) ||
+		path == "/api/v1/export" || path == "/api/v1/backup" {
+		return true
+	}
+
+	rest, ok := strings.CutPrefix(path, "/api/v1/jobs/")
+
+	return ok && strings.Contains(rest, "/download/")
+}
+
+// streamingDeadlines lifts the write deadline of the streamed responses
+// and sets it again before each write, timeout ahead: a download goes on as
+// long as the client keeps reading it, and a client that stopped is still
+// cut off after timeout.
+func streamingDeadlines(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if timeout <= 0 || !streamingPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		dw := &deadlineWriter{ResponseWriter: w, rc: http.NewResponseController(w), timeout: timeout}
+
+		// the response may take long to start, a backup snapshotting the
+		// database
+		_ = dw.rc.SetWriteDeadline(time.Time{})
+
+		next.ServeHTTP(dw, r)
+	})
+}
+
+// deadlineWriter pushes the write deadline back before the writes.
+type deadlineWriter struct {
+	http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	// set is when the deadline was last pushed back, at most once a second.
+	set time.Time
+}
+
+func (dw *deadlineWriter) Write(p []byte) (int, error) {
+	if now := time.Now(); now.Sub(dw.set) >= time.Second {
+		_ = dw.rc.SetWriteDeadline(now.Add(dw.timeout))
+		dw.set = now
+	}
+
+	return dw.ResponseWriter.Write(p)
+}
+
+func (dw *deadlineWriter) Flush() {
+	_ = dw.rc.Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (dw *deadlineWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
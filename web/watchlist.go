@@ -0,0 +1,610 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A watchlist holds places to keep an eye on, without a keyword job for
+// each: the scheduler refreshes them with a job of their own every
+// interval and reports how they changed since the refresh before, from
+// their history.
+
+// Bounds of a watchlist, its interval in hours.
+const (
+	WatchlistDefaultInterval = 24
+	WatchlistMaxPlaces       = 500
+	// WatchlistCheckInterval is how often the scheduler looks for the
+	// watchlists due for a refresh.
+	WatchlistCheckInterval = time.Minute
+)
+
+// WatchlistEventChanges is the event of the alerts posted to the webhook of
+// a watchlist.
+const WatchlistEventChanges = "watchlist.changes"
+
+// WatchlistRepository is implemented by the repositories storing the
+// watchlists.
+type WatchlistRepository interface {
+	// ListWatchlists returns the watchlists of all the tenants.
+	ListWatchlists(context.Context) ([]Watchlist, error)
+	// GetWatchlist returns ErrNotFound when there is no watchlist id.
+	GetWatchlist(ctx context.Context, id string) (Watchlist, error)
+	UpsertWatchlist(ctx context.Context, wl *Watchlist) error
+	DeleteWatchlist(ctx context.Context, id string) error
+}
+
+// Watchlist is a list of places refreshed on an interval.
+type Watchlist struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+	// Tenant is the client account owning the watchlist, "" for the
+	// default tenant.
+	Tenant string `json:"tenant,omitempty"`
+	// Places are the links of the place pages; a CID stands for its link.
+	Places []string `json:"places"`
+	// IntervalHours is the time between two refreshes,
+	// WatchlistDefaultInterval when 0.
+	IntervalHours int `json:"interval_hours"`
+	// Lang is the language of the settings when empty.
+	Lang    string `json:"lang,omitempty"`
+	Email   bool   `json:"email,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	// Webhook receives the changes found by each refresh, see
+	// WatchlistAlert.
+	Webhook string `json:"webhook,omitempty"`
+	// LastRun is when the last refresh started and JobID its job.
+	LastRun time.Time `json:"last_run,omitzero"`
+	JobID   string    `json:"job_id,omitempty"`
+	// Changes are those found by the last refresh that ended ok.
+	Changes []PlaceChange `json:"changes,omitempty"`
+}
+
+// PlaceChange is a field of a place that changed between two refreshes.
+type PlaceChange struct {
+	Cid    string `json:"cid"`
+	Title  string `json:"title"`
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// WatchlistAlert is posted to the webhook of a watchlist when a refresh
+// found changes.
+type WatchlistAlert struct {
+	Event     string        `json:"event"`
+	Watchlist string        `json:"watchlist"`
+	Name      string        `json:"name"`
+	Tenant    string        `json:"tenant,omitempty"`
+	JobID     string        `json:"job_id"`
+	Time      time.Time     `json:"time"`
+	Changes   []PlaceChange `json:"changes"`
+}
+
+func (s *Service) watchlistRepo() (WatchlistRepository, error) {
+	repo, ok := s.repo.(WatchlistRepository)
+	if !ok {
+		return nil, errors.New("watchlists not supported by repository")
+	}
+
+	return repo, nil
+}
+
+// interval returns the time between two refreshes of wl.
+func (wl *Watchlist) interval() time.Duration {
+	hours := wl.IntervalHours
+	if hours == 0 {
+		hours = WatchlistDefaultInterval
+	}
+
+	return time.Duration(hours) * time.Hour
+}
+
+// normalize checks the fields of wl set by the user and turns its CIDs into
+// links.
+func (wl *Watchlist) normalize() error {
+	wl.Name = strings.TrimSpace(wl.Name)
+	if wl.Name == "" {
+		return errors.New("missing name")
+	}
+
+	if wl.IntervalHours < 0 {
+		return errors.New("interval cannot be negative")
+	}
+
+	var places []string
+
+	for _, p := range wl.Places {
+		p = strings.TrimSpace(p)
+
+		switch {
+		case p == "":
+			continue
+		case validCID(p):
+			p = "https://maps.google.com/?cid=" + p
+		case !isPlaceLink(p):
+			return fmt.Errorf("invalid place %q: use a CID or the link of a Google Maps place", p)
+		}
+
+		if !slices.Contains(places, p) {
+			places = append(places, p)
+		}
+	}
+
+	if len(places) == 0 || len(places) > WatchlistMaxPlaces {
+		return fmt.Errorf("a watchlist has 1 to %d places", WatchlistMaxPlaces)
+	}
+
+	wl.Places = places
+
+	return validateWebhookURL("webhook", wl.Webhook)
+}
+
+// refreshJob returns the job refreshing the places of wl, with the defaults
+// of its profile and of the settings. The job is not stored.
+func (s *Service) refreshJob(ctx context.Context, wl *Watchlist) (*Job, error) {
+	job := Job{
+		ID:     uuid.New().String(),
+		Name:   "watchlist: " + wl.Name,
+		Date:   time.Now().UTC(),
+		Status: StatusPending,
+		Data: JobData{
+			Places:    wl.Places,
+			Lang:      wl.Lang,
+			Email:     wl.Email,
+			Profile:   wl.Profile,
+			Watchlist: wl.ID,
+		},
+	}
+
+	if err := s.ApplyProfile(ctx, &job.Data); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Data.Lang == "" {
+		job.Data.Lang = settings.Language
+	}
+
+	if job.Data.MaxTime == 0 {
+		job.Data.MaxTime, err = time.ParseDuration(settings.MaxTime)
+		if err != nil {
+			return nil, fmt.Errorf("max time of the settings: %w", err)
+		}
+	}
+
+	// the places are visited, there is no search to scroll
+	job.Data.Depth = 1
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CreateWatchlist stores wl for the tenant of ctx. Its first refresh starts
+// with the next check of the scheduler.
+func (s *Service) CreateWatchlist(ctx context.Context, wl *Watchlist) error {
+	repo, err := s.watchlistRepo()
+	if err != nil {
+		return err
+	}
+
+	if err := wl.normalize(); err != nil {
+		return err
+	}
+
+	wl.ID = uuid.New().String()
+	wl.Date = time.Now().UTC()
+	wl.Tenant = TenantFrom(ctx)
+	wl.LastRun = time.Time{}
+	wl.JobID = ""
+	wl.Changes = nil
+
+	if _, err := s.refreshJob(ctx, wl); err != nil {
+		return err
+	}
+
+	s.watchlistMu.Lock()
+	defer s.watchlistMu.Unlock()
+
+	return repo.UpsertWatchlist(ctx, wl)
+}
+
+// Watchlists returns the watchlists of the tenant of ctx, sorted by name.
+func (s *Service) Watchlists(ctx context.Context) ([]Watchlist, error) {
+	repo, ok := s.repo.(WatchlistRepository)
+	if !ok {
+		return []Watchlist{}, nil
+	}
+
+	watchlists, err := repo.ListWatchlists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ans := []Watchlist{}
+
+	for i := range watchlists {
+		if watchlists[i].Tenant == TenantFrom(ctx) {
+			ans = append(ans, watchlists[i])
+		}
+	}
+
+	slices.SortFunc(ans, func(a, b Watchlist) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return ans, nil
+}
+
+// GetWatchlist returns watchlist id, ErrNotFound when it belongs to another
+// tenant than the one of ctx.
+func (s *Service) GetWatchlist(ctx context.Context, id string) (Watchlist, error) {
+	repo, err := s.watchlistRepo()
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	wl, err := repo.GetWatchlist(ctx, id)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	if wl.Tenant != TenantFrom(ctx) {
+		return Watchlist{}, fmt.Errorf("%w: watchlist %s", ErrNotFound, id)
+	}
+
+	return wl, nil
+}
+
+// UpdateWatchlist replaces the places and the options of watchlist id with
+// those of wl, keeping its refreshes.
+func (s *Service) UpdateWatchlist(ctx context.Context, id string, wl *Watchlist) error {
+	repo, err := s.watchlistRepo()
+	if err != nil {
+		return err
+	}
+
+	if err := wl.normalize(); err != nil {
+		return err
+	}
+
+	s.watchlistMu.Lock()
+	defer s.watchlistMu.Unlock()
+
+	prev, err := s.GetWatchlist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	wl.ID = prev.ID
+	wl.Date = prev.Date
+	wl.Tenant = prev.Tenant
+	wl.LastRun = prev.LastRun
+	wl.JobID = prev.JobID
+	wl.Changes = prev.Changes
+
+	if _, err := s.refreshJob(ctx, wl); err != nil {
+		return err
+	}
+
+	return repo.UpsertWatchlist(ctx, wl)
+}
+
+// DeleteWatchlist deletes watchlist id. The jobs of its refreshes and the
+// history of its places are kept.
+func (s *Service) DeleteWatchlist(ctx context.Context, id string) error {
+	repo, err := s.watchlistRepo()
+	if err != nil {
+		return err
+	}
+
+	s.watchlistMu.Lock()
+	defer s.watchlistMu.Unlock()
+
+	if _, err := s.GetWatchlist(ctx, id); err != nil {
+		return err
+	}
+
+	return repo.DeleteWatchlist(ctx, id)
+}
+
+// RefreshWatchlists creates the refresh jobs of the watchlists of all the
+// tenants due for one, and returns how many it created. A watchlist whose
+// last refresh is still running is not refreshed again.
+func (s *Service) RefreshWatchlists(ctx context.Context) (int, error) {
+	repo, ok := s.repo.(WatchlistRepository)
+	if !ok {
+		return 0, nil
+	}
+
+	s.watchlistMu.Lock()
+	defer s.watchlistMu.Unlock()
+
+	watchlists, err := repo.ListWatchlists(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	created := 0
+
+	for i := range watchlists {
+		wl := &watchlists[i]
+
+		if !wl.LastRun.IsZero() && now.Sub(wl.LastRun) < wl.interval() {
+			continue
+		}
+
+		if wl.JobID != "" {
+			if job, err := s.repo.Get(ctx, wl.JobID); err == nil && !isFinished(job.Status) {
+				continue
+			}
+		}
+
+		tctx := WithTenant(ctx, wl.Tenant)
+
+		job, err := s.refreshJob(tctx, wl)
+		if err == nil {
+			err = s.Create(tctx, job)
+		}
+
+		if err != nil {
+			log.Printf("watchlist %s: creating the refresh job: %v", wl.ID, err)
+
+			continue
+		}
+
+		wl.LastRun = now
+		wl.JobID = job.ID
+
+		if err := repo.UpsertWatchlist(ctx, wl); err != nil {
+			return created, err
+		}
+
+		created++
+	}
+
+	return created, nil
+}
+
+// watchlistEnded records the changes of the places of job, the refresh of
+// a watchlist which just ended ok, since their last snapshot, and posts
+// them to the webhook of the watchlist. It runs before the snapshots of job
+// are stored. An encrypted job is left out, like its snapshots.
+func (s *Service) watchlistEnded(ctx context.Context, job *Job) {
+	if job.Data.Watchlist == "" || job.Status != StatusOK || job.Data.Encrypt {
+		return
+	}
+
+	repo, ok := s.repo.(WatchlistRepository)
+	if !ok {
+		return
+	}
+
+	history, ok := s.repo.(HistoryRepository)
+	if !ok {
+		return
+	}
+
+	s.watchlistMu.Lock()
+	defer s.watchlistMu.Unlock()
+
+	wl, err := repo.GetWatchlist(ctx, job.Data.Watchlist)
+	if err != nil || wl.Tenant != job.Tenant {
+		log.Printf("watchlist of job %s: %v", job.ID, err)
+
+		return
+	}
+
+	entries, err := s.loadEntries(WithTenant(ctx, job.Tenant), job.ID)
+	if err != nil {
+		log.Printf("watchlist %s: %v", wl.ID, err)
+
+		return
+	}
+
+	var changes []PlaceChange
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Cid == "" {
+			continue
+		}
+
+		prev, err := history.PlaceHistory(ctx, job.Tenant, e.Cid, time.Time{}, time.Time{})
+		if err != nil {
+			log.Printf("watchlist %s: %v", wl.ID, err)
+
+			return
+		}
+
+		if len(prev) == 0 {
+			continue
+		}
+
+		after := snapshotOf(job, time.Time{}, e)
+
+		changes = append(changes, placeChanges(e.Cid, &prev[len(prev)-1], &after)...)
+	}
+
+	wl.Changes = changes
+
+	if err := repo.UpsertWatchlist(ctx, &wl); err != nil {
+		log.Printf("watchlist %s: %v", wl.ID, err)
+	}
+
+	if len(changes) == 0 || wl.Webhook == "" {
+		return
+	}
+
+	alert := WatchlistAlert{
+		Event:     WatchlistEventChanges,
+		Watchlist: wl.ID,
+		Name:      wl.Name,
+		Tenant:    wl.Tenant,
+		JobID:     job.ID,
+		Time:      time.Now().UTC(),
+		Changes:   changes,
+	}
+
+	go postWatchlistAlert(wl.Webhook, &alert)
+}
+
+// placeChanges returns the fields of place cid that changed from before to
+// after.
+func placeChanges(cid string, before, after *PlaceSnapshot) []PlaceChange {
+	var ans []PlaceChange
+
+	add := func(field string, from, to any) {
+		ans = append(ans, PlaceChange{Cid: cid, Title: after.Title, Field: field, Before: from, After: to})
+	}
+
+	if before.Title != after.Title {
+		add("title", before.Title, after.Title)
+	}
+
+	if before.ReviewRating != after.ReviewRating {
+		add("review_rating", before.ReviewRating, after.ReviewRating)
+	}
+
+	if before.ReviewCount != after.ReviewCount {
+		add("review_count", before.ReviewCount, after.ReviewCount)
+	}
+
+	if !maps.EqualFunc(before.OpenHours, after.OpenHours, slices.Equal) {
+		add("open_hours", before.OpenHours, after.OpenHours)
+	}
+
+	if before.Status != after.Status {
+		add("status", before.Status, after.Status)
+	}
+
+	return ans
+}
+
+// postWatchlistAlert posts alert to webhook, retrying with a growing pause.
+func postWatchlistAlert(webhook string, alert *WatchlistAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("watchlist webhook: %v", err)
+
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = sendWebhook(webhook, body)
+		if err == nil {
+			return
+		}
+
+		if attempt == usageWebhookAttempts {
+			log.Printf("watchlist webhook of %s: giving up after %d attempts: %v", alert.Watchlist, attempt, err)
+
+			return
+		}
+
+		time.Sleep(time.Duration(attempt) * 5 * time.Second)
+	}
+}
+
+type apiWatchlistsResponse struct {
+	Watchlists []Watchlist `json:"watchlists"`
+}
+
+func renderWatchlistError(w http.ResponseWriter, err error) {
+	code := pipelineStatus(err)
+
+	renderJSON(w, code, apiError{
+		Code:    code,
+		Message: err.Error(),
+	})
+}
+
+func (s *Server) apiGetWatchlists(w http.ResponseWriter, r *http.Request) {
+	watchlists, err := s.svc.Watchlists(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, apiWatchlistsResponse{Watchlists: watchlists})
+}
+
+func (s *Server) apiCreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	var wl Watchlist
+
+	if err := json.NewDecoder(r.Body).Decode(&wl); err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	if err := s.svc.CreateWatchlist(r.Context(), &wl); err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusCreated, wl)
+}
+
+func (s *Server) apiGetWatchlist(w http.ResponseWriter, r *http.Request) {
+	wl, err := s.svc.GetWatchlist(r.Context(), r.PathValue("id"))
+	if err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, wl)
+}
+
+func (s *Server) apiUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	var wl Watchlist
+
+	if err := json.NewDecoder(r.Body).Decode(&wl); err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	if err := s.svc.UpdateWatchlist(r.Context(), r.PathValue("id"), &wl); err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, wl)
+}
+
+func (s *Server) apiDeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	if err := s.svc.DeleteWatchlist(r.Context(), r.PathValue("id")); err != nil {
+		renderWatchlistError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
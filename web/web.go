@@ -4,14 +4,16 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -28,9 +30,29 @@ type Server struct {
 	srv      *http.Server
 	svc      *Service
 	apiToken string
+	// workerToken enables the worker API when set.
+	workerToken string
+	// uiDir overrides the templates and serves the assets of the Web UI,
+	// see WithUIDir.
+	uiDir string
+	// catalogs are the messages of the Web UI by language.
+	catalogs catalogs
+	// pdf prints the market reports, see WithPDFPrinter.
+	pdf PDFPrinter
 }
 
-func New(svc *Service, addr string, apiToken string) (*Server, error) {
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithWorkerToken serves the API the remote workers pull their jobs from,
+// authenticated with token.
+func WithWorkerToken(token string) ServerOption {
+	return func(s *Server) {
+		s.workerToken = token
+	}
+}
+
+func New(svc *Service, addr string, apiToken string, opts ...ServerOption) (*Server, error) {
 	ans := Server{
 		svc:      svc,
 		apiToken: apiToken,
@@ -38,13 +60,17 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 		srv: &http.Server{
 			Addr:              addr,
 			ReadHeaderTimeout: 10 * time.Second,
-			ReadTimeout:       60 * time.Second,
-			WriteTimeout:      60 * time.Second,
-			IdleTimeout:       120 * time.Second,
+			ReadTimeout:       DefaultReadTimeout,
+			WriteTimeout:      DefaultWriteTimeout,
+			IdleTimeout:       DefaultIdleTimeout,
 			MaxHeaderBytes:    1 << 20,
 		},
 	}
 
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
 	staticFS, err := fs.Sub(static, "static")
 	if err != nil {
 		return nil, err
@@ -54,6 +80,7 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 	mux := http.NewServeMux()
 
 	mux.Handle("/static/", http.StripPrefix("/static/", fileServer))
+	mux.Handle("/branding/", ans.brandingHandler())
 	mux.HandleFunc("/scrape", ans.scrape)
 	mux.HandleFunc("/download/csv", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
@@ -63,60 +90,535 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 		r = requestWithID(r)
 		ans.downloadJSON(w, r)
 	})
+	mux.HandleFunc("/download/manifest", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+		ans.downloadManifest(w, r)
+	})
 	mux.HandleFunc("/view/json", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 		ans.viewJSON(w, r)
 	})
+	mux.HandleFunc("/diagnosis", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+		ans.downloadDiagnosis(w, r)
+	})
+	mux.HandleFunc("/screenshot", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+		ans.screenshot(w, r)
+	})
 	mux.HandleFunc("/delete", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 		ans.delete(w, r)
 	})
+	mux.HandleFunc("/retry", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+		ans.retryFailures(w, r)
+	})
+	mux.HandleFunc("/email/abort", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+		ans.abortEmails(w, r)
+	})
 	mux.HandleFunc("/jobs", ans.getJobs)
 	mux.HandleFunc("/preview", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 		ans.preview(w, r)
 	})
+	mux.HandleFunc("/keywords/expand", ans.keywordsPreview)
+	mux.HandleFunc("/sandbox", ans.sandboxPage)
+	mux.HandleFunc("/sandbox/run", ans.runSandbox)
 	mux.HandleFunc("/settings", ans.settingsPage)
 	mux.HandleFunc("/settings/save", ans.saveSettings)
+	mux.HandleFunc("/settings/profiles/delete", ans.deleteProfile)
+	mux.HandleFunc("/settings/suppressions/upload", ans.uploadSuppressions)
+	mux.HandleFunc("/settings/suppressions/delete", ans.deleteSuppressions)
 	mux.HandleFunc("/", ans.index)
 
-	// api routes
-	mux.HandleFunc("/api/docs", ans.redocHandler)
-	mux.HandleFunc("/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			ans.apiScrape(w, r)
-		case http.MethodGet:
-			ans.apiGetJobs(w, r)
-		default:
+	// api routes
+	mux.HandleFunc("/api/docs", ans.redocHandler)
+	mux.HandleFunc("/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			ans.apiScrape(w, r)
+		case http.MethodGet:
+			ans.apiGetJobs(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/keywords/expand", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiExpandKeywords(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/sandbox", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiSandbox(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/lookup", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiLookup(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/places/{cid}/history", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiPlaceHistory(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiSearch(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiExport(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/import", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiImport(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/backup", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiBackup(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/profiles", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetProfiles(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/profiles/{name}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetProfile(w, r)
+		case http.MethodPut:
+			ans.apiSaveProfile(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteProfile(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/suppression-lists", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetSuppressionLists(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/suppression-lists/{name}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			ans.apiAddSuppressions(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteSuppressionList(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/territories", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetTerritories(w, r)
+		case http.MethodPut:
+			ans.apiSaveTerritories(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteTerritories(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetPipelines(w, r)
+		case http.MethodPost:
+			ans.apiCreatePipeline(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/pipelines/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetPipeline(w, r)
+		case http.MethodDelete:
+			ans.apiDeletePipeline(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/watchlists", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetWatchlists(w, r)
+		case http.MethodPost:
+			ans.apiCreateWatchlist(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/watchlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetWatchlist(w, r)
+		case http.MethodPut:
+			ans.apiUpdateWatchlist(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteWatchlist(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/tenants", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetTenants(w, r)
+		case http.MethodPost:
+			ans.apiCreateTenant(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/tenants/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiDeleteTenant(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/tenants/{name}/settings", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetTenantSettings(w, r)
+		case http.MethodPut:
+			ans.apiSaveTenantSettings(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/usage", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetUsage(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetJob(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteJob(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/download/csv", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.downloadCSV(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/download/json", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.downloadJSON(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/share", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodPost {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiCreateShare(w, r)
+	})
+
+	mux.HandleFunc(sharedAPIPrefix+"{token}/{format}", ans.sharedDownload)
+
+	mux.HandleFunc("/api/v1/jobs/{id}/manifest", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.downloadManifest(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/manifest-key", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiManifestKey(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/diagnosis/{file}", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
 			ans := apiError{
 				Code:    http.StatusMethodNotAllowed,
 				Message: "Method not allowed",
 			}
 
 			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
 		}
+
+		ans.downloadDiagnosis(w, r)
 	})
 
-	mux.HandleFunc("/api/v1/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/jobs/{id}/screenshot", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 
-		switch r.Method {
-		case http.MethodGet:
-			ans.apiGetJob(w, r)
-		case http.MethodDelete:
-			ans.apiDeleteJob(w, r)
-		default:
+		if r.Method != http.MethodGet {
 			ans := apiError{
 				Code:    http.StatusMethodNotAllowed,
 				Message: "Method not allowed",
 			}
 
 			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
 		}
+
+		ans.screenshot(w, r)
 	})
 
-	mux.HandleFunc("/api/v1/jobs/{id}/download/csv", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/jobs/{id}/email", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 
 		if r.Method != http.MethodGet {
@@ -130,13 +632,13 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 			return
 		}
 
-		ans.downloadCSV(w, r)
+		ans.apiEmailEstimate(w, r)
 	})
 
-	mux.HandleFunc("/api/v1/jobs/{id}/download/json", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/jobs/{id}/email/abort", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodPost {
 			ans := apiError{
 				Code:    http.StatusMethodNotAllowed,
 				Message: "Method not allowed",
@@ -147,7 +649,7 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 			return
 		}
 
-		ans.downloadJSON(w, r)
+		ans.apiAbortEmails(w, r)
 	})
 
 	mux.HandleFunc("/api/v1/jobs/{id}/view/json", func(w http.ResponseWriter, r *http.Request) {
@@ -184,6 +686,124 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 		ans.apiGetRecords(w, r)
 	})
 
+	mux.HandleFunc("/api/v1/jobs/{id}/heatmap", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiHeatmap(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/report", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiReport(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/conflicts", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiConflicts(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/territories", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		switch r.Method {
+		case http.MethodGet, http.MethodPut, http.MethodDelete:
+			ans.apiJobTerritories(w, r)
+		default:
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/route", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiRoute(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/failures", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiFailures(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/failures/retry", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodPost {
+			resp := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, resp)
+
+			return
+		}
+
+		ans.apiRetryFailures(w, r)
+	})
+
 	mux.HandleFunc("/api/v1/jobs/{id}/records/{recordId}", func(w http.ResponseWriter, r *http.Request) {
 		r = requestWithID(r)
 
@@ -202,7 +822,12 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 		}
 	})
 
-	handler := apiAuthMiddleware(apiToken, securityHeaders(mux))
+	if ans.workerToken != "" {
+		ans.registerWorkerAPI(mux)
+	}
+
+	handler := apiAuthMiddleware(apiToken, svc.TenantByToken, securityHeaders(compressResponses(mux)))
+	handler = streamingDeadlines(ans.srv.WriteTimeout, handler)
 	ans.srv.Handler = handler
 
 	tmplsKeys := []string{
@@ -213,10 +838,23 @@ func New(svc *Service, addr string, apiToken string) (*Server, error) {
 		"static/templates/settings.html",
 		"static/templates/settings_success.html",
 		"static/templates/preview.html",
+		"static/templates/keywords_preview.html",
+		"static/templates/sandbox.html",
+		"static/templates/sandbox_result.html",
+		"static/templates/report.html",
+	}
+
+	if ans.uiDir != "" {
+		log.Printf("web ui: customized from %s", ans.uiDir)
+	}
+
+	ans.catalogs, err = loadCatalogs(ans.uiDir)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, key := range tmplsKeys {
-		tmp, err := template.ParseFS(static, key)
+		tmp, err := ans.parseTemplate(key)
 		if err != nil {
 			return nil, err
 		}
@@ -265,6 +903,41 @@ type formData struct {
 	Email    bool
 	Proxies  []string
 	APIToken string
+
+	ExcludeServiceArea bool
+	VerifyEmails       bool
+	SkipReviews        bool
+	SkipImages         bool
+	SkipAbout          bool
+	AnonymizeReviewers string
+	ReviewLangs        string
+	ExcludeDomains     string
+	ExcludePlaces      string
+	IncludeCategories  string
+	ExcludeCategories  string
+	MinRating          float64
+	MinReviews         int
+	MaxReviews         int
+	RequireWebsite     bool
+	RequirePhone       bool
+	ProxyCountry       string
+	ProxyCity          string
+	CustomFields       string
+	MaxPlaces          int
+	MaxEmailFetches    int
+	Profile            string
+	Profiles           []string
+	Brand              Branding
+
+	// Categories, Locations and Variables are those of the keyword builder.
+	Categories string
+	Locations  string
+	Variables  string
+
+	// Jobs, Preview and Error are rendered by the index in place of htmx.
+	Jobs    []Job
+	Preview *previewData
+	Error   string
 }
 
 type ctxKey string
@@ -308,30 +981,8 @@ func (s *Server) index(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tmpl, ok := s.tmpl["static/templates/index.html"]
-	if !ok {
-		http.Error(w, "missing tpl", http.StatusInternalServerError)
-
-		return
-	}
-
-	settings, _ := s.svc.GetSettings(r.Context())
-
-	data := formData{
-		Name:     "",
-		MaxTime:  settings.MaxTime,
-		Keywords: []string{},
-		Language: settings.Language,
-		Zoom:     15,
-		FastMode: false,
-		Radius:   10000,
-		Lat:      "0",
-		Lon:      "0",
-		Depth:    settings.Depth,
-		Email:    settings.Email,
-		Proxies:  settings.Proxies,
-		APIToken: s.apiToken,
-	}
+	// ?profile= prefills the form with the defaults of a settings profile
+	data := s.indexData(r.Context(), r.URL.Query().Get("profile"))
 
 	if cloneID := r.URL.Query().Get("clone"); cloneID != "" {
 		job, err := s.svc.Get(r.Context(), cloneID)
@@ -348,6 +999,28 @@ func (s *Server) index(w http.ResponseWriter, r *http.Request) {
 			data.Lon = job.Data.Lon
 			data.Depth = job.Data.Depth
 			data.Email = job.Data.Email
+			data.ExcludeServiceArea = job.Data.ExcludeServiceArea
+			data.VerifyEmails = job.Data.VerifyEmails
+			data.SkipReviews = job.Data.SkipReviews
+			data.SkipImages = job.Data.SkipImages
+			data.SkipAbout = job.Data.SkipAbout
+			data.AnonymizeReviewers = job.Data.AnonymizeReviewers
+			data.ReviewLangs = strings.Join(job.Data.ReviewLangs, ",")
+			data.ExcludeDomains = strings.Join(job.Data.ExcludeDomains, "\n")
+			data.ExcludePlaces = strings.Join(job.Data.ExcludePlaces, "\n")
+			data.IncludeCategories = strings.Join(job.Data.IncludeCategories, "\n")
+			data.ExcludeCategories = strings.Join(job.Data.ExcludeCategories, "\n")
+			data.MinRating = job.Data.MinRating
+			data.MinReviews = job.Data.MinReviews
+			data.MaxReviews = job.Data.MaxReviews
+			data.RequireWebsite = job.Data.RequireWebsite
+			data.RequirePhone = job.Data.RequirePhone
+			data.ProxyCountry = job.Data.ProxyCountry
+			data.ProxyCity = job.Data.ProxyCity
+			data.CustomFields = gmaps.FormatCustomExtractors(job.Data.CustomExtractors)
+			data.MaxPlaces = job.Data.MaxPlaces
+			data.MaxEmailFetches = job.Data.MaxEmailFetches
+			data.Profile = job.Data.Profile
 
 			if job.Data.MaxTime > 0 {
 				data.MaxTime = job.Data.MaxTime.String()
@@ -359,7 +1032,123 @@ func (s *Server) index(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	_ = tmpl.Execute(w, data)
+	// ?preview= shows the results of a job, like the preview button does
+	// with htmx
+	if id, err := uuid.Parse(r.URL.Query().Get("preview")); err == nil {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		preview, err := s.loadPreview(r.Context(), id.String(), page)
+		if err != nil {
+			data.Error = "Results not found"
+		} else {
+			data.Preview = &preview
+		}
+	}
+
+	s.renderIndex(w, r, http.StatusOK, &data)
+}
+
+// indexData returns the index page with the defaults of settings profile.
+func (s *Server) indexData(ctx context.Context, profile string) formData {
+	settings, err := s.svc.ProfileSettings(ctx, profile)
+	if err != nil {
+		log.Printf("profile %s: %v", profile, err)
+	}
+
+	profiles, err := s.svc.Profiles(ctx)
+	if err != nil {
+		log.Printf("listing profiles: %v", err)
+	}
+
+	jobs, err := s.svc.All(ctx)
+	if err != nil {
+		log.Printf("listing jobs: %v", err)
+	}
+
+	s.svc.withEmailEstimates(ctx, jobs)
+
+	return formData{
+		Name:     "",
+		MaxTime:  settings.MaxTime,
+		Keywords: []string{},
+		Language: settings.Language,
+		Zoom:     15,
+		FastMode: false,
+		Radius:   10000,
+		Lat:      "0",
+		Lon:      "0",
+		Depth:    settings.Depth,
+		Email:    settings.Email,
+		Proxies:  settings.Proxies,
+		APIToken: s.apiToken,
+		Profile:  profile,
+		Profiles: profiles,
+		Brand:    s.brand(ctx),
+		Jobs:     jobs,
+	}
+}
+
+func (s *Server) renderIndex(w http.ResponseWriter, r *http.Request, code int, data *formData) {
+	tmpl, ok := s.tmpl["static/templates/index.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	s.renderStatus(w, r, code, tmpl, data)
+}
+
+// scrapeFailed answers a scrape form that did not create its job: htmx shows
+// msg above the form, a plain post gets the form back with msg.
+func (s *Server) scrapeFailed(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	if isHTMX(r) {
+		http.Error(w, msg, code)
+
+		return
+	}
+
+	data := s.indexData(r.Context(), r.Form.Get("profile"))
+
+	data.Error = msg
+	data.Name = r.Form.Get("name")
+	data.Keywords = formLines(r.Form, "keywords")
+	data.Categories = r.Form.Get("categories")
+	data.Locations = r.Form.Get("locations")
+	data.Variables = r.Form.Get("variables")
+	data.Language = r.Form.Get("lang")
+	data.MaxTime = r.Form.Get("maxtime")
+	data.Zoom, _ = strconv.Atoi(r.Form.Get("zoom"))
+	data.Radius, _ = strconv.Atoi(r.Form.Get("radius"))
+	data.Depth, _ = strconv.Atoi(r.Form.Get("depth"))
+	data.Lat = r.Form.Get("latitude")
+	data.Lon = r.Form.Get("longitude")
+	data.FastMode = r.Form.Get("fastmode") == "on"
+	data.Email = r.Form.Get("email") == "on"
+	data.ExcludeServiceArea = r.Form.Get("exclude_service_area") == "on"
+	data.VerifyEmails = r.Form.Get("verify_emails") == "on"
+	data.SkipReviews = r.Form.Get("skip_reviews") == "on"
+	data.SkipImages = r.Form.Get("skip_images") == "on"
+	data.SkipAbout = r.Form.Get("skip_about") == "on"
+	data.AnonymizeReviewers = r.Form.Get("anonymize_reviewers")
+	data.ReviewLangs = r.Form.Get("review_langs")
+	data.ExcludeDomains = r.Form.Get("exclude_domains")
+	data.ExcludePlaces = r.Form.Get("exclude_places")
+	data.IncludeCategories = r.Form.Get("include_categories")
+	data.ExcludeCategories = r.Form.Get("exclude_categories")
+	data.MinRating, _ = formRating(r.Form, "min_rating")
+	data.MinReviews, _ = formLimit(r.Form, "min_reviews")
+	data.MaxReviews, _ = formLimit(r.Form, "max_reviews")
+	data.RequireWebsite = r.Form.Get("require_website") == "on"
+	data.RequirePhone = r.Form.Get("require_phone") == "on"
+	data.ProxyCountry = r.Form.Get("proxy_country")
+	data.ProxyCity = r.Form.Get("proxy_city")
+	data.CustomFields = r.Form.Get("custom_fields")
+	data.MaxPlaces, _ = formLimit(r.Form, "max_places")
+	data.MaxEmailFetches, _ = formLimit(r.Form, "max_email_fetches")
+	data.Proxies = formLines(r.Form, "proxies")
+
+	s.renderIndex(w, r, code, &data)
 }
 
 func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
@@ -371,7 +1160,7 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 
 	err := r.ParseForm()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.scrapeFailed(w, r, http.StatusInternalServerError, err.Error())
 
 		return
 	}
@@ -388,34 +1177,49 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 
 	maxTime, err := time.ParseDuration(maxTimeStr)
 	if err != nil {
-		http.Error(w, "invalid max time", http.StatusUnprocessableEntity)
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid max time")
 
 		return
 	}
 
 	if maxTime < time.Minute*1 {
-		http.Error(w, "max time must be more than 1m", http.StatusUnprocessableEntity)
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "max time must be more than 1m")
 
 		return
 	}
 
 	newJob.Data.MaxTime = maxTime
 
-	keywordsStr, ok := r.Form["keywords"]
-	if !ok {
-		http.Error(w, "missing keywords", http.StatusUnprocessableEntity)
+	vars, err := ParseTemplateVariables(r.Form.Get("variables"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
 
 		return
 	}
 
-	keywords := strings.Split(keywordsStr[0], "\n")
-	for _, k := range keywords {
-		k = strings.TrimSpace(k)
-		if k == "" {
-			continue
-		}
+	// {{variable}} placeholders are resolved here, the job stores the result
+	newJob.Data.Keywords, err = ExpandTemplates(strings.Split(r.Form.Get("keywords"), "\n"), vars)
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
+
+		return
+	}
+
+	// keyword builder: categories × locations are appended to the typed keywords
+	newJob.Data.Keywords = append(newJob.Data.Keywords,
+		ExpandKeywords(splitLines(r.Form.Get("categories")), splitLines(r.Form.Get("locations")))...,
+	)
+
+	if len(newJob.Data.Keywords) == 0 {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "missing keywords")
+
+		return
+	}
 
-		newJob.Data.Keywords = append(newJob.Data.Keywords, k)
+	if len(newJob.Data.Keywords) > MaxExpandedKeywords {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%s: %d (max %d)", ErrTooManyKeywords, len(newJob.Data.Keywords), MaxExpandedKeywords))
+
+		return
 	}
 
 	if newJob.Name == "" {
@@ -428,35 +1232,93 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 
 	newJob.Data.Lang = r.Form.Get("lang")
 
-	newJob.Data.Zoom, err = strconv.Atoi(r.Form.Get("zoom"))
+	newJob.Data.Zoom, err = strconv.Atoi(r.Form.Get("zoom"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid zoom")
+
+		return
+	}
+
+	if r.Form.Get("fastmode") == "on" {
+		newJob.Data.FastMode = true
+	}
+
+	newJob.Data.Radius, err = strconv.Atoi(r.Form.Get("radius"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid radius")
+
+		return
+	}
+
+	newJob.Data.Lat = r.Form.Get("latitude")
+	newJob.Data.Lon = r.Form.Get("longitude")
+
+	newJob.Data.Depth, err = strconv.Atoi(r.Form.Get("depth"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid depth")
+
+		return
+	}
+
+	newJob.Data.Email = r.Form.Get("email") == "on"
+	newJob.Data.ExcludeServiceArea = r.Form.Get("exclude_service_area") == "on"
+	newJob.Data.VerifyEmails = r.Form.Get("verify_emails") == "on"
+	newJob.Data.SkipReviews = r.Form.Get("skip_reviews") == "on"
+	newJob.Data.SkipImages = r.Form.Get("skip_images") == "on"
+	newJob.Data.SkipAbout = r.Form.Get("skip_about") == "on"
+	newJob.Data.AnonymizeReviewers = r.Form.Get("anonymize_reviewers")
+
+	newJob.Data.ReviewLangs, err = gmaps.ParseLanguages(r.Form.Get("review_langs"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
+
+		return
+	}
+
+	newJob.Data.ProxyCountry = strings.ToLower(strings.TrimSpace(r.Form.Get("proxy_country")))
+	newJob.Data.ProxyCity = strings.TrimSpace(r.Form.Get("proxy_city"))
+
+	newJob.Data.CustomExtractors, err = gmaps.ParseCustomExtractors(r.Form.Get("custom_fields"))
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
+
+		return
+	}
+
+	newJob.Data.MaxPlaces, err = formLimit(r.Form, "max_places")
 	if err != nil {
-		http.Error(w, "invalid zoom", http.StatusUnprocessableEntity)
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid max places")
 
 		return
 	}
 
-	if r.Form.Get("fastmode") == "on" {
-		newJob.Data.FastMode = true
+	newJob.Data.MaxEmailFetches, err = formLimit(r.Form, "max_email_fetches")
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid max email fetches")
+
+		return
 	}
 
-	newJob.Data.Radius, err = strconv.Atoi(r.Form.Get("radius"))
+	newJob.Data.MinRating, err = formRating(r.Form, "min_rating")
 	if err != nil {
-		http.Error(w, "invalid radius", http.StatusUnprocessableEntity)
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid min rating")
 
 		return
 	}
 
-	newJob.Data.Lat = r.Form.Get("latitude")
-	newJob.Data.Lon = r.Form.Get("longitude")
-
-	newJob.Data.Depth, err = strconv.Atoi(r.Form.Get("depth"))
+	newJob.Data.MinReviews, err = formLimit(r.Form, "min_reviews")
 	if err != nil {
-		http.Error(w, "invalid depth", http.StatusUnprocessableEntity)
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid min reviews")
 
 		return
 	}
 
-	newJob.Data.Email = r.Form.Get("email") == "on"
+	newJob.Data.MaxReviews, err = formLimit(r.Form, "max_reviews")
+	if err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, "invalid max reviews")
+
+		return
+	}
 
 	proxies := strings.Split(r.Form.Get("proxies"), "\n")
 	if len(proxies) > 0 {
@@ -470,6 +1332,21 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	newJob.Data.ExcludeDomains = formLines(r.Form, "exclude_domains")
+	newJob.Data.ExcludePlaces = formLines(r.Form, "exclude_places")
+	newJob.Data.IncludeCategories = formLines(r.Form, "include_categories")
+	newJob.Data.ExcludeCategories = formLines(r.Form, "exclude_categories")
+	newJob.Data.RequireWebsite = r.Form.Get("require_website") == "on"
+	newJob.Data.RequirePhone = r.Form.Get("require_phone") == "on"
+
+	newJob.Data.Profile = r.Form.Get("profile")
+
+	if err := s.svc.ApplyProfile(r.Context(), &newJob.Data); err != nil {
+		s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
+
+		return
+	}
+
 	splitKeywords := r.Form.Get("split-keywords") == "on"
 
 	tmpl, ok := s.tmpl["static/templates/job_row.html"]
@@ -479,6 +1356,8 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var created []Job
+
 	if splitKeywords && len(newJob.Data.Keywords) > 1 {
 		baseData := newJob.Data
 
@@ -494,35 +1373,46 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 			j.Data.Keywords = []string{kw}
 
 			if err := j.Validate(); err != nil {
-				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
 
 				return
 			}
 
 			if err := s.svc.Create(r.Context(), &j); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				s.scrapeFailed(w, r, http.StatusInternalServerError, err.Error())
 
 				return
 			}
 
-			_ = tmpl.Execute(w, j)
+			created = append(created, j)
 		}
 	} else {
 		err = newJob.Validate()
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			s.scrapeFailed(w, r, http.StatusUnprocessableEntity, err.Error())
 
 			return
 		}
 
 		err = s.svc.Create(r.Context(), &newJob)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			s.scrapeFailed(w, r, http.StatusInternalServerError, err.Error())
 
 			return
 		}
 
-		_ = tmpl.Execute(w, newJob)
+		created = append(created, newJob)
+	}
+
+	// without JavaScript the form was posted by the browser itself
+	if !isHTMX(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
+	for i := range created {
+		s.render(w, r, tmpl, created[i])
 	}
 }
 
@@ -546,11 +1436,13 @@ func (s *Server) getJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = tmpl.Execute(w, jobs)
+	s.svc.withEmailEstimates(r.Context(), jobs)
+
+	s.render(w, r, tmpl, jobs)
 }
 
 func (s *Server) downloadCSV(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 
 		return
@@ -565,32 +1457,83 @@ func (s *Server) downloadCSV(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, errMsg := s.exportFilter(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	format, mapping, err := s.exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	name := s.exportFilename(ctx, id.String(), &format, "csv")
+
+	if r.URL.Query().Get("phase") == phaseMaps {
+		s.downloadMaps(w, r, id.String(), "csv", name, "text/csv")
+
+		return
+	}
+
+	// another dialect or layout than those of the file is written on the fly
+	if !filter.IsZero() || !format.plainCSV() {
+		s.downloadFilteredCSV(w, r, id.String(), filter, format, mapping, name)
+
+		return
+	}
+
 	filePath, err := s.svc.GetCSV(ctx, id.String())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	file, err := os.Open(filePath)
+	s.serveResult(w, r, filePath, name, "text/csv")
+}
+
+// exportFormat returns the export format of the settings, overridden by the
+// query of r, see ExportFormat.Override, with its mapping if any.
+func (s *Server) exportFormat(r *http.Request) (ExportFormat, *ExportMapping, error) {
+	settings, err := s.svc.GetSettings(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
-		return
+		return ExportFormat{}, nil, err
 	}
-	defer file.Close()
 
-	fileName := filepath.Base(filePath)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
-	w.Header().Set("Content-Type", "text/csv")
+	format := settings.Export.Override(r.URL.Query())
+
+	if err := format.Validate(); err != nil {
+		return ExportFormat{}, nil, err
+	}
+
+	if format.Mapping == "" {
+		return format, nil, nil
+	}
+
+	mapping, ok := settings.ExportMappings[format.Mapping]
+	if !ok {
+		return ExportFormat{}, nil, fmt.Errorf("unknown export mapping %q", format.Mapping)
+	}
 
-	_, err = io.Copy(w, file)
+	return format, &mapping, nil
+}
+
+// exportFilename returns the name of the download of job id with extension
+// ext, named after the ID when the job is unknown.
+func (s *Server) exportFilename(ctx context.Context, id string, format *ExportFormat, ext string) string {
+	job, err := s.svc.Get(ctx, id)
 	if err != nil {
-		http.Error(w, "Failed to send file", http.StatusInternalServerError)
-		return
+		job = Job{ID: id}
 	}
+
+	return format.Filename(&job, ext)
 }
 
 func (s *Server) downloadJSON(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 
 		return
@@ -605,28 +1548,260 @@ func (s *Server) downloadJSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, errMsg := s.exportFilter(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	format, _, err := s.exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	name := s.exportFilename(ctx, id.String(), &format, "json")
+
+	if r.URL.Query().Get("phase") == phaseMaps {
+		s.downloadMaps(w, r, id.String(), "json", name, "application/json")
+
+		return
+	}
+
+	if r.URL.Query().Get("format") == formatPlacesAPI {
+		s.downloadPlacesAPI(w, r, id.String(), filter, strings.TrimSuffix(name, ".json")+"_places_api.json")
+
+		return
+	}
+
+	if !filter.IsZero() {
+		s.downloadFilteredJSON(w, r, id.String(), filter, name)
+
+		return
+	}
+
 	filePath, err := s.svc.GetJSON(ctx, id.String())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	file, err := os.Open(filePath)
+	if info, err := os.Stat(filePath); err == nil {
+		if etag, modified := fileValidator(info); notModified(w, r, etag, modified) {
+			return
+		}
+	}
+
+	s.serveResult(w, r, filePath, name, "application/json")
+}
+
+// formatPlacesAPI selects the Places API "Place Details" compatible JSON
+// export in the download endpoints.
+const formatPlacesAPI = "places_api"
+
+func (s *Server) downloadPlacesAPI(w http.ResponseWriter, r *http.Request, id string, filter ExportFilter, name string) {
+	places, err := s.svc.GetPlacesAPI(r.Context(), id, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	_ = enc.Encode(places)
+}
+
+// getMinEmailConfidence parses the optional min_email_confidence query
+// parameter of the export endpoints.
+func getMinEmailConfidence(r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("min_email_confidence")
+	if raw == "" {
+		return 0, true
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 || v > gmaps.MaxEmailConfidence {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// exportFilter parses the optional filters of the export endpoints,
+// min_email_confidence, review_langs, suppress, suppress_action,
+// new_since_job, new_since, territory and validation, returning the error
+// message of the invalid one. The territories of the job are always applied.
+func (s *Server) exportFilter(r *http.Request) (ExportFilter, string) {
+	minConfidence, ok := getMinEmailConfidence(r)
+	if !ok {
+		return ExportFilter{}, "Invalid min_email_confidence"
+	}
+
+	langs, err := gmaps.ParseLanguages(r.URL.Query().Get("review_langs"))
+	if err != nil {
+		return ExportFilter{}, "Invalid review_langs: " + err.Error()
+	}
+
+	filter := ExportFilter{
+		MinEmailConfidence: minConfidence,
+		ReviewLanguages:    langs,
+		SuppressAction:     r.URL.Query().Get("suppress_action"),
+		Validation:         r.URL.Query().Get("validation"),
+	}
+
+	switch filter.Validation {
+	case "", ValidationValid, ValidationQuarantine:
+	default:
+		return ExportFilter{}, "Invalid validation: use valid or quarantine"
+	}
+
+	for _, name := range strings.Split(r.URL.Query().Get("suppress"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter.Suppress = append(filter.Suppress, name)
+		}
+	}
+
+	switch filter.SuppressAction {
+	case "", SuppressExclude, SuppressFlag:
+	default:
+		return ExportFilter{}, "Invalid suppress_action: use exclude or flag"
+	}
+
+	if v := r.URL.Query().Get("new_since"); v != "" {
+		since, err := parseSince(v)
+		if err != nil {
+			return ExportFilter{}, "Invalid new_since: use RFC 3339 or YYYY-MM-DD"
+		}
+
+		filter.NewSince = since
+	}
+
+	if v := r.URL.Query().Get("new_since_job"); v != "" {
+		ref, err := uuid.Parse(v)
+		if err != nil {
+			return ExportFilter{}, "Invalid new_since_job"
+		}
+
+		// like the lists below, an unknown job is an error of the client
+		if _, err := s.svc.Get(r.Context(), ref.String()); err != nil {
+			return ExportFilter{}, fmt.Sprintf("Unknown job %q in new_since_job", v)
+		}
+
+		filter.NewSinceJob = ref.String()
+	}
+
+	if id, ok := getIDFromRequest(r); ok {
+		// a job that cannot be read is reported by the download itself
+		filter.Territories, _ = s.svc.JobTerritories(r.Context(), id.String())
+	}
+
+	if v := strings.TrimSpace(r.URL.Query().Get("territory")); v != "" {
+		if !slices.Contains(filter.Territories.Names(), v) {
+			return ExportFilter{}, fmt.Sprintf("Unknown territory %q", v)
+		}
+
+		filter.Territory = v
+	}
+
+	if len(filter.Suppress) == 0 {
+		return filter, ""
+	}
+
+	// le liste sconosciute sono un errore del client, non un 404 del job
+	lists, err := s.svc.SuppressionLists(r.Context())
+	if err != nil {
+		return ExportFilter{}, err.Error()
+	}
+
+	for _, name := range filter.Suppress {
+		if !slices.ContainsFunc(lists, func(l SuppressionListInfo) bool { return l.Name == name }) {
+			return ExportFilter{}, fmt.Sprintf("Unknown suppression list %q", name)
+		}
+	}
+
+	return filter, ""
+}
+
+// parseSince parses the new_since of the exports, a time in RFC 3339 or a
+// day (UTC).
+func parseSince(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
+
+func (s *Server) downloadFilteredJSON(w http.ResponseWriter, r *http.Request, id string, filter ExportFilter, name string) {
+	entries, err := s.svc.GetEntries(r.Context(), id, filter)
 	if err != nil {
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-	defer file.Close()
 
-	fileName := filepath.Base(filePath)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	w.Header().Set("Content-Disposition", contentDisposition(name))
 	w.Header().Set("Content-Type", "application/json")
 
-	_, err = io.Copy(w, file)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	_ = enc.Encode(entries)
+}
+
+func (s *Server) downloadFilteredCSV(w http.ResponseWriter, r *http.Request, id string, filter ExportFilter, format ExportFormat, mapping *ExportMapping, name string) {
+	entries, err := s.svc.GetEntries(r.Context(), id, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", contentDisposition(name))
+	w.Header().Set("Content-Type", "text/csv")
+
+	header := &gmaps.Entry{}
+	if len(entries) > 0 {
+		header = &entries[0]
+	}
+
+	// the flagged places get the matched email or domain in a last column
+	flag := len(filter.Suppress) > 0 && filter.SuppressAction == SuppressFlag
+
+	cw, err := newCSVExporter(w, format, mapping)
 	if err != nil {
-		http.Error(w, "Failed to send file", http.StatusInternalServerError)
 		return
 	}
+
+	headers := csvHeaders(header, s.svc.csvLayout)
+	if len(filter.Territories) > 0 {
+		headers = append(headers, "territory")
+	}
+
+	if flag {
+		headers = append(headers, "suppressed")
+	}
+
+	_ = cw.WriteHeader(headers)
+
+	for i := range entries {
+		row := csvRow(&entries[i], s.svc.csvLayout)
+		if len(filter.Territories) > 0 {
+			row = append(row, entries[i].Territory)
+		}
+
+		if flag {
+			row = append(row, entries[i].Suppressed)
+		}
+
+		_ = cw.Write(row)
+	}
+
+	_ = cw.Flush()
 }
 
 func (s *Server) viewJSON(w http.ResponseWriter, r *http.Request) {
@@ -650,7 +1825,7 @@ func (s *Server) viewJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Leggi il file JSON
-	data, err := os.ReadFile(filePath)
+	data, err := s.svc.readResultFile(filePath)
 	if err != nil {
 		http.Error(w, "Failed to read file", http.StatusInternalServerError)
 		return
@@ -677,7 +1852,8 @@ func (s *Server) viewJSON(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
+	// the form of the delete button posts without JavaScript
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 
 		return
@@ -697,6 +1873,12 @@ func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !isHTMX(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -707,9 +1889,18 @@ type apiError struct {
 
 type apiScrapeRequest struct {
 	Name string
+	// Variables resolve the {{variable}} placeholders of the keywords, see
+	// ExpandTemplates.
+	Variables map[string][]string `json:"variables"`
+	// ClientJobID is the idempotency key of the request, as the
+	// Idempotency-Key header.
+	ClientJobID string `json:"client_job_id"`
 	JobData
 }
 
+// maxClientJobID bounds the length of an idempotency key.
+const maxClientJobID = 255
+
 type apiScrapeResponse struct {
 	ID string `json:"id"`
 }
@@ -735,24 +1926,96 @@ func (s *Server) apiScrape(w http.ResponseWriter, r *http.Request) {
 			Message: err.Error(),
 		}
 
-		renderJSON(w, http.StatusUnprocessableEntity, ans)
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	// a retried request replays the job of the first one
+	clientJobID := r.Header.Get("Idempotency-Key")
+
+	switch {
+	case clientJobID != "" && req.ClientJobID != "" && clientJobID != req.ClientJobID:
+		err = errors.New("the Idempotency-Key header and client_job_id differ")
+	case clientJobID == "":
+		clientJobID = req.ClientJobID
+	}
+
+	if err == nil && len(clientJobID) > maxClientJobID {
+		err = fmt.Errorf("idempotency key longer than %d characters", maxClientJobID)
+	}
+
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	newJob := Job{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Date:        time.Now().UTC(),
+		Status:      StatusPending,
+		Data:        req.JobData,
+		ClientJobID: clientJobID,
+	}
+
+	newJob.Data.Keywords, err = ExpandTemplates(req.Keywords, req.Variables)
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	// convert to seconds
+	newJob.Data.MaxTime *= time.Second
+
+	// the fields left out take the defaults of the profile, if any
+	err = s.svc.ApplyProfile(r.Context(), &newJob.Data)
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	err = newJob.Validate()
+	if err != nil {
+		ans := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, ans)
+
+		return
+	}
+
+	err = s.svc.Create(r.Context(), &newJob)
+	if errors.Is(err, ErrAlreadyExists) {
+		w.Header().Set("Idempotent-Replayed", "true")
+
+		renderJSON(w, http.StatusOK, apiScrapeResponse{ID: newJob.ID})
 
 		return
 	}
 
-	newJob := Job{
-		ID:     uuid.New().String(),
-		Name:   req.Name,
-		Date:   time.Now().UTC(),
-		Status: StatusPending,
-		Data:   req.JobData,
-	}
-
-	// convert to seconds
-	newJob.Data.MaxTime *= time.Second
-
-	err = newJob.Validate()
-	if err != nil {
+	// a dependency of another tenant or missing, or no key to encrypt
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNoResultsKey) {
 		ans := apiError{
 			Code:    http.StatusUnprocessableEntity,
 			Message: err.Error(),
@@ -763,7 +2026,6 @@ func (s *Server) apiScrape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.svc.Create(r.Context(), &newJob)
 	if err != nil {
 		ans := apiError{
 			Code:    http.StatusInternalServerError,
@@ -795,7 +2057,8 @@ func (s *Server) apiGetJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	renderJSON(w, http.StatusOK, jobs)
+	// polled by the integrations
+	renderJSONConditional(w, r, jobs)
 }
 
 func (s *Server) apiGetJob(w http.ResponseWriter, r *http.Request) {
@@ -823,7 +2086,10 @@ func (s *Server) apiGetJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	renderJSON(w, http.StatusOK, job)
+	jobs := []Job{job}
+	s.svc.withEmailEstimates(r.Context(), jobs)
+
+	renderJSON(w, http.StatusOK, jobs[0])
 }
 
 func (s *Server) apiDeleteJob(w http.ResponseWriter, r *http.Request) {
@@ -840,6 +2106,17 @@ func (s *Server) apiDeleteJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := s.svc.Delete(r.Context(), id.String())
+	if errors.Is(err, ErrNotFound) {
+		apiError := apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		}
+
+		renderJSON(w, http.StatusNotFound, apiError)
+
+		return
+	}
+
 	if err != nil {
 		apiError := apiError{
 			Code:    http.StatusInternalServerError,
@@ -877,8 +2154,14 @@ func (s *Server) apiViewJSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if info, err := os.Stat(filePath); err == nil {
+		if etag, modified := fileValidator(info); notModified(w, r, etag, modified) {
+			return
+		}
+	}
+
 	// Leggi il file JSON
-	data, err := os.ReadFile(filePath)
+	data, err := s.svc.readResultFile(filePath)
 	if err != nil {
 		apiError := apiError{
 			Code:    http.StatusInternalServerError,
@@ -905,20 +2188,33 @@ func (s *Server) apiViewJSON(w http.ResponseWriter, r *http.Request) {
 }
 
 type apiRecord struct {
-	ID           int     `json:"id"`
-	JobID        string  `json:"job_id"`
-	Title        string  `json:"title"`
-	Address      string  `json:"address"`
-	Phone        string  `json:"phone"`
-	Website      string  `json:"website"`
-	Email        string  `json:"email"`
-	Category     string  `json:"category"`
-	Rating       float64 `json:"rating"`
-	ReviewsCount int     `json:"reviews_count"`
-	Latitude     float64 `json:"latitude"`
-	Longitude    float64 `json:"longitude"`
-	PlaceID      string  `json:"place_id"`
-	GoogleURL    string  `json:"google_url"`
+	ID                  int     `json:"id"`
+	JobID               string  `json:"job_id"`
+	Title               string  `json:"title"`
+	Address             string  `json:"address"`
+	Phone               string  `json:"phone"`
+	Website             string  `json:"website"`
+	Email               string  `json:"email"`
+	EmailConfidence     int     `json:"email_confidence"`
+	EmailClassification string  `json:"email_classification"`
+	EmailError          string  `json:"email_error"`
+	Category            string  `json:"category"`
+	Rating              float64 `json:"rating"`
+	ReviewsCount        int     `json:"reviews_count"`
+	Latitude            float64 `json:"latitude"`
+	Longitude           float64 `json:"longitude"`
+	PlaceID             string  `json:"place_id"`
+	CID                 string  `json:"cid"`
+	DataID              string  `json:"data_id"`
+	GoogleURL           string  `json:"google_url"`
+	Query               string  `json:"query"`
+	IsServiceArea       bool    `json:"is_service_area"`
+	ServiceArea         string  `json:"service_area"`
+	ChainID             string  `json:"chain_id"`
+	// WebsiteStatus is how the website answered when searched for emails.
+	WebsiteStatus *gmaps.WebsiteStatus `json:"website_status,omitempty"`
+	// CustomFields are the fields read by the custom extractors of the job.
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
 }
 
 type apiRecordsResponse struct {
@@ -926,24 +2222,41 @@ type apiRecordsResponse struct {
 	Total    int         `json:"total"`
 	Page     int         `json:"page"`
 	PageSize int         `json:"pageSize"`
+	// Partial is set while the job runs: the records are the ones written
+	// so far and Total grows until the job is over.
+	Partial bool `json:"partial"`
+	// NextCursor, when paging by cursor, asks for the next records; empty
+	// after the last one.
+	NextCursor *string `json:"next_cursor,omitempty"`
 }
 
 func entryToRecord(e *gmaps.Entry, idx int, jobID string) apiRecord {
 	return apiRecord{
-		ID:           idx + 1,
-		JobID:        jobID,
-		Title:        e.Title,
-		Address:      e.Address,
-		Phone:        e.Phone,
-		Website:      e.WebSite,
-		Email:        strings.Join(e.Emails, ", "),
-		Category:     e.Category,
-		Rating:       e.ReviewRating,
-		ReviewsCount: e.ReviewCount,
-		Latitude:     e.Latitude,
-		Longitude:    e.Longtitude,
-		PlaceID:      e.PlaceID,
-		GoogleURL:    e.Link,
+		ID:                  idx + 1,
+		JobID:               jobID,
+		Title:               e.Title,
+		Address:             e.Address,
+		Phone:               e.Phone,
+		Website:             e.WebSite,
+		Email:               strings.Join(e.Emails, ", "),
+		EmailConfidence:     e.EmailConfidence,
+		EmailClassification: strings.Join(e.EmailClassifications, ", "),
+		EmailError:          e.EmailError,
+		Category:            e.Category,
+		Rating:              e.ReviewRating,
+		ReviewsCount:        e.ReviewCount,
+		Latitude:            e.Latitude,
+		Longitude:           e.Longtitude,
+		PlaceID:             e.PlaceID,
+		CID:                 e.Cid,
+		DataID:              e.DataID,
+		GoogleURL:           e.Link,
+		Query:               e.Query,
+		IsServiceArea:       e.IsServiceArea,
+		ServiceArea:         e.ServiceArea,
+		ChainID:             e.ChainID,
+		WebsiteStatus:       e.WebsiteStatus,
+		CustomFields:        e.CustomFields,
 	}
 }
 
@@ -968,10 +2281,61 @@ func (s *Server) apiGetRecords(w http.ResponseWriter, r *http.Request) {
 		pageSize = 25
 	}
 
-	search := r.URL.Query().Get("search")
+	// the records change only with the results
+	if etag, modified, ok := s.svc.resultsValidator(r.Context(), id.String()); ok && notModified(w, r, etag, modified) {
+		return
+	}
 
-	indexed, total, err := s.svc.GetRecords(r.Context(), id.String(), page, pageSize, search)
-	if err != nil {
+	if r.URL.Query().Get("group") == "chain" {
+		s.apiGetChains(w, r, id.String())
+
+		return
+	}
+
+	minConfidence, ok := getMinEmailConfidence(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid min_email_confidence",
+		})
+
+		return
+	}
+
+	filter := RecordFilter{
+		Search:             r.URL.Query().Get("search"),
+		ExcludeChains:      r.URL.Query().Get("exclude_chains") == "true",
+		MinEmailConfidence: minConfidence,
+	}
+
+	var (
+		indexed []IndexedEntry
+		total   int
+		partial bool
+		next    *string
+		err     error
+	)
+
+	// cursor= asks for the first records by cursor
+	if r.URL.Query().Has("cursor") {
+		var cursor string
+
+		indexed, cursor, total, partial, err = s.svc.GetRecordsAfter(r.Context(), id.String(), r.URL.Query().Get("cursor"), pageSize, filter)
+		next = &cursor
+		page = 0
+	} else {
+		indexed, total, partial, err = s.svc.GetRecords(r.Context(), id.String(), page, pageSize, filter)
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidCursor):
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	case err != nil:
 		renderJSON(w, http.StatusNotFound, apiError{
 			Code:    http.StatusNotFound,
 			Message: err.Error(),
@@ -987,13 +2351,67 @@ func (s *Server) apiGetRecords(w http.ResponseWriter, r *http.Request) {
 	}
 
 	renderJSON(w, http.StatusOK, apiRecordsResponse{
-		Records:  records,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
+		Records:    records,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		Partial:    partial,
+		NextCursor: next,
 	})
 }
 
+type apiChain struct {
+	ChainID string      `json:"chain_id"`
+	Name    string      `json:"name"`
+	Domain  string      `json:"domain"`
+	Count   int         `json:"count"`
+	Records []apiRecord `json:"records"`
+}
+
+type apiChainsResponse struct {
+	Chains      []apiChain `json:"chains"`
+	Independent int        `json:"independent"`
+	Partial     bool       `json:"partial"`
+}
+
+// apiGetChains is the grouped view of the records API: one item per brand
+// with its locations, plus the number of independent businesses.
+func (s *Server) apiGetChains(w http.ResponseWriter, r *http.Request, jobID string) {
+	groups, independent, partial, err := s.svc.GetChains(r.Context(), jobID)
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	ans := apiChainsResponse{
+		Chains:      make([]apiChain, 0, len(groups)),
+		Independent: independent,
+		Partial:     partial,
+	}
+
+	for _, g := range groups {
+		chain := apiChain{
+			ChainID: g.Chain.ID,
+			Name:    g.Chain.Name,
+			Domain:  g.Chain.Domain,
+			Count:   len(g.Entries),
+			Records: make([]apiRecord, 0, len(g.Entries)),
+		}
+
+		for i := range g.Entries {
+			chain.Records = append(chain.Records, entryToRecord(&g.Entries[i].Entry, g.Entries[i].Index, jobID))
+		}
+
+		ans.Chains = append(ans.Chains, chain)
+	}
+
+	renderJSON(w, http.StatusOK, ans)
+}
+
 func (s *Server) apiUpdateRecord(w http.ResponseWriter, r *http.Request) {
 	id, ok := getIDFromRequest(r)
 	if !ok {
@@ -1120,6 +2538,116 @@ type previewData struct {
 	HasNext    bool
 	PrevPage   int
 	NextPage   int
+	// Partial is set while the job runs, the preview then refreshes itself
+	Partial bool
+	// Stats summarizes all the results, not only those of the page.
+	Stats previewStats
+}
+
+// previewTopCategories is the number of categories listed by previewStats.
+const previewTopCategories = 5
+
+// previewStats is the sanity report shown above the preview table.
+type previewStats struct {
+	// Columns counts the results with a value in each column, in the order
+	// of the table.
+	Columns []previewColumn
+	// Rated counts the results with a rating, MinRating and MaxRating are
+	// taken among them.
+	Rated     int
+	MinRating float64
+	MaxRating float64
+	// TopCategories are the most frequent categories, the most frequent
+	// first.
+	TopCategories []previewCategory
+}
+
+type previewColumn struct {
+	// Name is the column of the table, as in the preview.<name> messages.
+	Name     string
+	NonEmpty int
+	// Percent is the share of the results with a value, rounded down.
+	Percent int
+}
+
+type previewCategory struct {
+	Name  string
+	Count int
+}
+
+// previewColumns tells whether a result has a value in each column of the
+// preview table.
+var previewColumns = []struct {
+	name string
+	has  func(e *previewEntry) bool
+}{
+	{"title", func(e *previewEntry) bool { return strings.TrimSpace(e.Title) != "" }},
+	{"category", func(e *previewEntry) bool { return strings.TrimSpace(e.Category) != "" }},
+	{"address", func(e *previewEntry) bool { return strings.TrimSpace(e.Address) != "" }},
+	{"phone", func(e *previewEntry) bool { return strings.TrimSpace(e.Phone) != "" }},
+	{"website", func(e *previewEntry) bool { return strings.TrimSpace(e.WebSite) != "" }},
+	{"rating", func(e *previewEntry) bool { return e.Rating > 0 }},
+	{"reviews", func(e *previewEntry) bool { return e.ReviewCount > 0 }},
+	{"emails", func(e *previewEntry) bool { return len(e.Emails) > 0 }},
+}
+
+// computePreviewStats returns the stats of entries.
+func computePreviewStats(entries []previewEntry) previewStats {
+	ans := previewStats{Columns: make([]previewColumn, len(previewColumns))}
+
+	for i, c := range previewColumns {
+		ans.Columns[i].Name = c.name
+	}
+
+	counts := map[string]int{}
+
+	for i := range entries {
+		e := &entries[i]
+
+		for j, c := range previewColumns {
+			if c.has(e) {
+				ans.Columns[j].NonEmpty++
+			}
+		}
+
+		if e.Rating > 0 {
+			if ans.Rated == 0 || e.Rating < ans.MinRating {
+				ans.MinRating = e.Rating
+			}
+
+			ans.MaxRating = max(ans.MaxRating, e.Rating)
+			ans.Rated++
+		}
+
+		if c := strings.TrimSpace(e.Category); c != "" {
+			counts[c]++
+		}
+	}
+
+	if len(entries) > 0 {
+		for i := range ans.Columns {
+			ans.Columns[i].Percent = ans.Columns[i].NonEmpty * 100 / len(entries)
+		}
+	}
+
+	for name, n := range counts {
+		ans.TopCategories = append(ans.TopCategories, previewCategory{Name: name, Count: n})
+	}
+
+	sort.Slice(ans.TopCategories, func(i, j int) bool {
+		a, b := ans.TopCategories[i], ans.TopCategories[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+
+		return a.Name < b.Name
+	})
+
+	if len(ans.TopCategories) > previewTopCategories {
+		ans.TopCategories = ans.TopCategories[:previewTopCategories]
+	}
+
+	return ans
 }
 
 func (s *Server) preview(w http.ResponseWriter, r *http.Request) {
@@ -1136,28 +2664,57 @@ func (s *Server) preview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filePath, err := s.svc.GetJSON(r.Context(), id.String())
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	pdata, err := s.loadPreview(r.Context(), id.String(), page)
 	if err != nil {
 		http.Error(w, "Results not found", http.StatusNotFound)
 
 		return
 	}
 
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		http.Error(w, "Failed to read results", http.StatusInternalServerError)
+	tmpl, ok := s.tmpl["static/templates/preview.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
 
 		return
 	}
 
-	var entries []previewEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		http.Error(w, "Failed to parse results", http.StatusInternalServerError)
+	s.render(w, r, tmpl, pdata)
+}
 
-		return
+// loadPreview returns page of the results of job id, ErrNotFound when it has
+// none.
+func (s *Server) loadPreview(ctx context.Context, id string, page int) (previewData, error) {
+	results, partial, err := s.svc.summaryResults(ctx, id)
+	if err != nil {
+		// a job that just started, or runs on a remote worker, has no
+		// results here yet
+		job, jerr := s.svc.Get(ctx, id)
+		if jerr != nil || job.Status != StatusWorking {
+			return previewData{}, ErrNotFound
+		}
+
+		partial = true
+	}
+
+	entries := make([]previewEntry, 0, len(results))
+
+	for i := range results {
+		e := &results[i]
+
+		entries = append(entries, previewEntry{
+			Title:       e.Title,
+			Category:    e.Category,
+			Address:     e.Address,
+			Phone:       e.Phone,
+			WebSite:     e.WebSite,
+			ReviewCount: e.ReviewCount,
+			Rating:      e.ReviewRating,
+			Emails:      e.Emails,
+		})
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
 		page = 1
 	}
@@ -1183,9 +2740,10 @@ func (s *Server) preview(w http.ResponseWriter, r *http.Request) {
 		pageEntries = entries[start:end]
 	}
 
-	pdata := previewData{
+	return previewData{
 		Entries:    pageEntries,
-		JobID:      id.String(),
+		JobID:      id,
+		Partial:    partial,
 		Page:       page,
 		TotalPages: totalPages,
 		Total:      total,
@@ -1193,16 +2751,8 @@ func (s *Server) preview(w http.ResponseWriter, r *http.Request) {
 		HasNext:    page < totalPages,
 		PrevPage:   page - 1,
 		NextPage:   page + 1,
-	}
-
-	tmpl, ok := s.tmpl["static/templates/preview.html"]
-	if !ok {
-		http.Error(w, "missing tpl", http.StatusInternalServerError)
-
-		return
-	}
-
-	_ = tmpl.Execute(w, pdata)
+		Stats:      computePreviewStats(entries),
+	}, nil
 }
 
 func (s *Server) settingsPage(w http.ResponseWriter, r *http.Request) {
@@ -1219,17 +2769,76 @@ func (s *Server) settingsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ?profile= edits a settings profile, a new one starting from the
+	// global settings
+	profile := r.URL.Query().Get("profile")
+	newProfile := false
+
 	settings, _ := s.svc.GetSettings(r.Context())
+	brand := settings.Branding
+
+	if profile != "" {
+		if err := ValidateProfileName(profile); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		saved, err := s.svc.GetProfile(r.Context(), profile)
+
+		switch {
+		case errors.Is(err, ErrNotFound):
+			newProfile = true
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		default:
+			settings = saved
+		}
+	}
+
+	profiles, err := s.svc.Profiles(r.Context())
+	if err != nil {
+		log.Printf("listing profiles: %v", err)
+	}
+
+	suppressions, err := s.svc.SuppressionLists(r.Context())
+	if err != nil {
+		log.Printf("listing suppression lists: %v", err)
+	}
 
 	data := struct {
 		Settings
-		APIToken string
+		APIToken         string
+		EmailProxiesText string
+		// UsageThresholdsText is only edited with the default settings
+		UsageThresholdsText string
+		Profile             string
+		Profiles            []string
+		NewProfile          bool
+		SuppressionLists    []SuppressionListInfo
+		Brand               Branding
+		ExportDelimiters    []string
+		ExportMappingsText  string
+		// Saved shows that the settings were just saved, see saveSettings
+		Saved bool
 	}{
-		Settings: settings,
-		APIToken: s.apiToken,
-	}
-
-	_ = tmpl.Execute(w, data)
+		Settings:            settings,
+		APIToken:            s.apiToken,
+		EmailProxiesText:    gmaps.FormatEmailProxyRoutes(settings.EmailProxies),
+		UsageThresholdsText: FormatUsageThresholds(settings.UsageThresholds),
+		Profile:             profile,
+		Profiles:            profiles,
+		NewProfile:          newProfile,
+		Brand:               brand,
+		SuppressionLists:    suppressions,
+		ExportDelimiters:    exportDelimiters,
+		ExportMappingsText:  FormatExportMappings(settings.ExportMappings),
+		Saved:               r.URL.Query().Get("saved") != "",
+	}
+
+	s.render(w, r, tmpl, data)
 }
 
 func (s *Server) saveSettings(w http.ResponseWriter, r *http.Request) {
@@ -1270,12 +2879,107 @@ func (s *Server) saveSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := s.svc.SaveSettings(r.Context(), &settings); err != nil {
+	settings.ProxyProvider = strings.TrimSpace(r.Form.Get("proxy_provider"))
+
+	settings.MonthlyMaxPlaces, err = formLimit(r.Form, "monthly_max_places")
+	if err != nil {
+		http.Error(w, "invalid monthly max places", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	settings.MonthlyMaxEmailFetches, err = formLimit(r.Form, "monthly_max_email_fetches")
+	if err != nil {
+		http.Error(w, "invalid monthly max email fetches", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	settings.FastLaneThreshold, err = formLimit(r.Form, "fast_lane_threshold")
+	if err != nil {
+		http.Error(w, "invalid fast lane threshold", http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	settings.EmailProxies, err = gmaps.ParseEmailProxyRoutes(r.Form.Get("email_proxies"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	settings.EmailHostInterval = strings.TrimSpace(r.Form.Get("email_host_interval"))
+
+	settings.UsageWebhook = strings.TrimSpace(r.Form.Get("usage_webhook"))
+
+	settings.Branding = Branding{
+		ProductName:     strings.TrimSpace(r.Form.Get("brand_product_name")),
+		LogoURL:         strings.TrimSpace(r.Form.Get("brand_logo_url")),
+		PrimaryColor:    strings.TrimSpace(r.Form.Get("brand_primary_color")),
+		BackgroundColor: strings.TrimSpace(r.Form.Get("brand_background_color")),
+	}
+
+	settings.Export = ExportFormat{
+		FilenamePattern:  strings.TrimSpace(r.Form.Get("export_filename")),
+		Delimiter:        r.Form.Get("export_delimiter"),
+		DecimalSeparator: r.Form.Get("export_decimal"),
+		Encoding:         r.Form.Get("export_encoding"),
+		DateFormat:       strings.TrimSpace(r.Form.Get("export_date_format")),
+		Mapping:          strings.TrimSpace(r.Form.Get("export_mapping")),
+	}
+
+	settings.ExportMappings, err = ParseExportMappings(r.Form.Get("export_mappings"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	settings.UsageThresholds, err = ParseUsageThresholds(r.Form.Get("usage_thresholds"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	profile := r.Form.Get("profile")
+
+	if profile == "" {
+		// the territories are uploaded over the REST API, not from the form
+		if current, err := s.svc.GetSettings(r.Context()); err == nil {
+			settings.Territories = current.Territories
+		}
+
+		err = s.svc.SaveSettings(r.Context(), &settings)
+	} else {
+		err = s.svc.SaveProfile(r.Context(), profile, &settings)
+	}
+
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 
 		return
 	}
 
+	// a new profile joins the list of the page
+	if r.Form.Get("new_profile") == "true" {
+		redirectAfter(w, r, "/settings?profile="+url.QueryEscape(profile))
+
+		return
+	}
+
+	if !isHTMX(r) {
+		target := "/settings?saved=1"
+		if profile != "" {
+			target += "&profile=" + url.QueryEscape(profile)
+		}
+
+		http.Redirect(w, r, target, http.StatusSeeOther)
+
+		return
+	}
+
 	tmpl, ok := s.tmpl["static/templates/settings_success.html"]
 	if !ok {
 		http.Error(w, "missing tpl", http.StatusInternalServerError)
@@ -1283,7 +2987,50 @@ func (s *Server) saveSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_ = tmpl.Execute(w, nil)
+	s.render(w, r, tmpl, profile)
+}
+
+// formLimit reads a non-negative limit from form, 0 when the field is empty.
+func formLimit(form url.Values, key string) (int, error) {
+	v := strings.TrimSpace(form.Get(key))
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+
+	return n, nil
+}
+
+// formRating parses the rating of the form field key, 0 when it is empty.
+func formRating(form url.Values, key string) (float64, error) {
+	v := strings.TrimSpace(form.Get(key))
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", "."), 64)
+	if err != nil || n < 0 || n > gmaps.MaxRating {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+
+	return n, nil
+}
+
+// formLines returns the non-empty lines of the textarea key of form.
+func formLines(form url.Values, key string) []string {
+	var ans []string
+
+	for _, line := range strings.Split(form.Get(key), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ans = append(ans, line)
+		}
+	}
+
+	return ans
 }
 
 func renderJSON(w http.ResponseWriter, code int, data any) {
@@ -1297,21 +3044,46 @@ func formatDate(t time.Time) string {
 	return t.Format("Jan 02, 2006 15:04:05")
 }
 
-func apiAuthMiddleware(token string, next http.Handler) http.Handler {
+// apiAuthMiddleware checks the token of the API calls: token for the
+// default tenant, or the token of a tenant found by tenantByToken, whose
+// calls then act for it.
+func apiAuthMiddleware(token string, tenantByToken func(context.Context, string) (Tenant, error), next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if token != "" && strings.HasPrefix(r.URL.Path, "/api/v1/") {
-			auth := r.Header.Get("Authorization")
-			if auth != "Bearer "+token {
-				renderJSON(w, http.StatusUnauthorized, apiError{
-					Code:    http.StatusUnauthorized,
-					Message: "Unauthorized",
-				})
+		// the worker API checks the worker token itself, and the shared
+		// results their link
+		if !strings.HasPrefix(r.URL.Path, "/api/v1/") || strings.HasPrefix(r.URL.Path, workerAPIPrefix) ||
+			strings.HasPrefix(r.URL.Path, sharedAPIPrefix) {
+			next.ServeHTTP(w, r)
 
-				return
-			}
+			return
 		}
 
-		next.ServeHTTP(w, r)
+		bearer, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token != "" && bearer == token {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		tenant, err := tenantByToken(r.Context(), bearer)
+
+		switch {
+		case err == nil && !tenantAllowed(r.URL.Path):
+			renderJSON(w, http.StatusForbidden, apiError{
+				Code:    http.StatusForbidden,
+				Message: "Forbidden: " + ErrTenantForbidden.Error(),
+			})
+		case err == nil:
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant.Name)))
+		case token == "":
+			// without token the API is open
+			next.ServeHTTP(w, r)
+		default:
+			renderJSON(w, http.StatusUnauthorized, apiError{
+				Code:    http.StatusUnauthorized,
+				Message: "Unauthorized",
+			})
+		}
 	})
 }
 
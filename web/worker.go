@@ -0,0 +1,315 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultLeaseTimeout is how long a remote worker may go without a heartbeat
+// before its job is given to another worker.
+const DefaultLeaseTimeout = 2 * time.Minute
+
+// WorkerLease records the remote worker running a job and its progress.
+type WorkerLease struct {
+	ID string `json:"id"`
+	// Results is the number of places written so far.
+	Results     int       `json:"results"`
+	ClaimedAt   time.Time `json:"claimed_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// ResultFormats are the result files a worker uploads for a job.
+var ResultFormats = []string{"csv", "json"}
+
+// Claim hands the next pending job to workerID and marks it working. It
+// returns nil when no job is pending. An empty workerID claims for the local
+// runner, which holds no lease.
+//
+// The jobs claimed once a monthly budget of the settings is exhausted fail
+// instead, with the budget in their Stats.Usage.Exceeded.
+func (s *Service) Claim(ctx context.Context, workerID string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.claim(ctx, workerID, s.nextPending)
+}
+
+// claim marks working the job taken by next for workerID, failing the ones
+// over a monthly budget. A job that cannot be updated is handed back to the
+// queue. s.mu must be held.
+func (s *Service) claim(ctx context.Context, workerID string, next func(context.Context, string) (*Job, error)) (*Job, error) {
+	for {
+		job, err := next(ctx, workerID)
+		if err != nil || job == nil {
+			return nil, err
+		}
+
+		exceeded, err := s.applyBudgets(ctx, job)
+		if err != nil {
+			return nil, s.nack(ctx, job.ID, workerID, err)
+		}
+
+		if exceeded == "" {
+			started, err := s.start(ctx, job, workerID)
+			if err != nil {
+				return nil, s.nack(ctx, job.ID, workerID, err)
+			}
+
+			return started, nil
+		}
+
+		job.Status = StatusFailed
+		job.Stats.Usage.Exceeded = exceeded
+
+		// a retry of the failures keeps the results it started from
+		if job.Stats.Retry != nil {
+			if _, err := s.finishRetry(job); err != nil {
+				return nil, s.nack(ctx, job.ID, workerID, err)
+			}
+		}
+
+		if err := s.repo.Update(ctx, job); err != nil {
+			return nil, s.nack(ctx, job.ID, workerID, err)
+		}
+
+		if s.queue != nil {
+			if err := s.queue.Ack(ctx, job.ID, workerID); err != nil {
+				return nil, err
+			}
+		}
+
+		s.dependencyEnded(ctx, job)
+	}
+}
+
+// start marks job working for workerID.
+func (s *Service) start(ctx context.Context, job *Job, workerID string) (*Job, error) {
+	job.Status = StatusWorking
+
+	if workerID != "" {
+		now := time.Now().UTC()
+
+		job.Stats.Worker = &WorkerLease{ID: workerID, ClaimedAt: now, HeartbeatAt: now}
+	}
+
+	if err := s.repo.Update(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *Service) nextPending(ctx context.Context, workerID string) (*Job, error) {
+	if s.queue != nil {
+		return s.claimQueued(ctx, workerID)
+	}
+
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusPending, Limit: 1})
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+// Heartbeat renews the lease of workerID on job id and records how many
+// results it wrote so far.
+func (s *Service) Heartbeat(ctx context.Context, id, workerID string, results int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.leased(ctx, id, workerID)
+	if err != nil {
+		return err
+	}
+
+	if s.queue != nil {
+		if err := s.queue.Extend(ctx, id, workerID, DefaultLeaseTimeout); err != nil {
+			return err
+		}
+	}
+
+	job.Stats.Worker.Results = results
+	job.Stats.Worker.HeartbeatAt = time.Now().UTC()
+
+	return s.repo.Update(ctx, &job)
+}
+
+// SaveResults stores a result file uploaded by workerID for job id. The file
+// replaces the previous one atomically, so a failed upload never leaves a
+// truncated file behind.
+func (s *Service) SaveResults(ctx context.Context, id, workerID, format string, r io.Reader) error {
+	if !slices.Contains(ResultFormats, format) {
+		return fmt.Errorf("invalid result format %q", format)
+	}
+
+	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid file name")
+	}
+
+	s.mu.Lock()
+	job, err := s.leased(ctx, id, workerID)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	folder := TenantFolder(s.dataFolder, job.Tenant)
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(filepath.Join(folder, id+"."+format), func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+
+		return err
+	})
+}
+
+// Complete ends the lease of workerID on job id with status, StatusOK or
+// StatusFailed, and the stats the worker recorded.
+func (s *Service) Complete(ctx context.Context, id, workerID, status string, stats JobStats) error {
+	if !isFinished(status) {
+		return fmt.Errorf("invalid status %q", status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.leased(ctx, id, workerID)
+	if err != nil {
+		return err
+	}
+
+	if s.queue != nil {
+		if err := s.queue.Ack(ctx, id, workerID); err != nil {
+			return err
+		}
+	}
+
+	lease := job.Stats.Worker
+	lease.HeartbeatAt = time.Now().UTC()
+
+	job.Status = status
+	job.Stats = stats
+	job.Stats.Worker = lease
+
+	metered := &job
+
+	if job.Stats.Retry != nil {
+		retried, err := s.finishRetry(&job)
+		if err != nil {
+			return err
+		}
+
+		metered = &retried
+	}
+
+	s.sealEnded(&job)
+
+	if err := s.repo.Update(ctx, &job); err != nil {
+		return err
+	}
+
+	s.meterUsage(ctx, metered)
+	s.watchlistEnded(ctx, &job)
+	s.recordHistory(ctx, &job)
+	s.searchEnded(ctx, &job)
+	s.manifestEnded(ctx, &job)
+	s.dependencyEnded(ctx, &job)
+
+	return nil
+}
+
+// RequeueExpired puts back in the queue the jobs whose worker sent no
+// heartbeat for DefaultLeaseTimeout, and returns how many it requeued.
+func (s *Service) RequeueExpired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.expiredJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+
+	for i := range jobs {
+		jobs[i].Status = StatusPending
+		jobs[i].Stats = JobStats{}
+
+		if err := s.repo.Update(ctx, &jobs[i]); err != nil {
+			return requeued, err
+		}
+
+		if s.queue != nil {
+			if err := s.queue.Push(ctx, jobs[i].ID); err != nil {
+				return requeued, err
+			}
+		}
+
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// expiredJobs returns the working jobs whose lease ran out. With a queue,
+// the queue tracks the leases.
+func (s *Service) expiredJobs(ctx context.Context) ([]Job, error) {
+	if s.queue != nil {
+		ids, err := s.queue.Expired(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var jobs []Job
+
+		for _, id := range ids {
+			job, err := s.repo.Get(ctx, id)
+			if err == nil && job.Status == StatusWorking {
+				jobs = append(jobs, job)
+			}
+		}
+
+		return jobs, nil
+	}
+
+	jobs, err := s.repo.Select(ctx, SelectParams{Status: StatusWorking})
+	if err != nil {
+		return nil, err
+	}
+
+	expired := jobs[:0]
+
+	for i := range jobs {
+		lease := jobs[i].Stats.Worker
+		if lease != nil && time.Since(lease.HeartbeatAt) >= DefaultLeaseTimeout {
+			expired = append(expired, jobs[i])
+		}
+	}
+
+	return expired, nil
+}
+
+// leased returns job id if workerID holds it. The caller holds s.mu.
+func (s *Service) leased(ctx context.Context, id, workerID string) (Job, error) {
+	// like apiGetJob, a job that cannot be read is reported as missing
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Job{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	if job.Status != StatusWorking || job.Stats.Worker == nil || job.Stats.Worker.ID != workerID {
+		return Job{}, ErrLeaseLost
+	}
+
+	return job, nil
+}
@@ -0,0 +1,260 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// workerAPIPrefix is the root of the API used by the remote workers, guarded
+// by the worker token instead of the API token.
+const workerAPIPrefix = "/api/v1/worker/"
+
+// uploadTimeout bounds the upload of a result file, which may take longer
+// than the read timeout of the server.
+const uploadTimeout = 10 * time.Minute
+
+type workerClaimRequest struct {
+	WorkerID string `json:"worker_id"`
+}
+
+type workerHeartbeatRequest struct {
+	WorkerID string `json:"worker_id"`
+	Results  int    `json:"results"`
+}
+
+type workerCompleteRequest struct {
+	WorkerID string   `json:"worker_id"`
+	Status   string   `json:"status"`
+	Stats    JobStats `json:"stats"`
+}
+
+func (s *Server) registerWorkerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/worker/claim", s.workerAuth(s.workerClaim))
+	mux.HandleFunc("GET /api/v1/worker/settings", s.workerAuth(s.workerSettings))
+	mux.HandleFunc("POST /api/v1/worker/jobs/{id}/heartbeat", s.workerAuth(s.workerHeartbeat))
+	mux.HandleFunc("PUT /api/v1/worker/jobs/{id}/results/{format}", s.workerAuth(s.workerUpload))
+	mux.HandleFunc("PUT /api/v1/worker/jobs/{id}/diagnosis/{file}", s.workerAuth(s.workerUploadDiagnosis))
+	mux.HandleFunc("POST /api/v1/worker/jobs/{id}/complete", s.workerAuth(s.workerComplete))
+}
+
+func (s *Server) workerAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.workerToken)) != 1 {
+			renderJSON(w, http.StatusUnauthorized, apiError{
+				Code:    http.StatusUnauthorized,
+				Message: "Unauthorized",
+			})
+
+			return
+		}
+
+		next(w, requestWithID(r))
+	}
+}
+
+func (s *Server) workerClaim(w http.ResponseWriter, r *http.Request) {
+	var req workerClaimRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.WorkerID == "" {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "missing worker_id",
+		})
+
+		return
+	}
+
+	job, err := s.svc.Claim(r.Context(), req.WorkerID)
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if job == nil {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, job)
+}
+
+// workerSettings returns the settings of the profile query parameter, the
+// global ones when it is empty, or those of the tenant query parameter.
+func (s *Server) workerSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := WithTenant(r.Context(), r.URL.Query().Get("tenant"))
+
+	settings, err := s.svc.ProfileSettings(ctx, r.URL.Query().Get("profile"))
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) workerHeartbeat(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	var req workerHeartbeatRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := s.svc.Heartbeat(r.Context(), id.String(), req.WorkerID, req.Results); err != nil {
+		renderWorkerError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) workerUpload(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	format := r.PathValue("format")
+	if !slices.Contains(ResultFormats, format) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid format",
+		})
+
+		return
+	}
+
+	_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadTimeout))
+
+	err := s.svc.SaveResults(r.Context(), id.String(), r.URL.Query().Get("worker_id"), format, r.Body)
+	if err != nil {
+		renderWorkerError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) workerUploadDiagnosis(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	name := r.PathValue("file")
+	if !validDiagnosisFile(name) {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid file name",
+		})
+
+		return
+	}
+
+	_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadTimeout))
+
+	err := s.svc.SaveDiagnosis(r.Context(), id.String(), r.URL.Query().Get("worker_id"), name, r.Body)
+	if err != nil {
+		renderWorkerError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) workerComplete(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	var req workerCompleteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if req.Status != StatusOK && req.Status != StatusFailed {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid status",
+		})
+
+		return
+	}
+
+	if err := s.svc.Complete(r.Context(), id.String(), req.WorkerID, req.Status, req.Stats); err != nil {
+		renderWorkerError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// renderWorkerError reports a lost lease as a conflict, so that the worker
+// stops working on the job.
+func renderWorkerError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, ErrLeaseLost):
+		code = http.StatusConflict
+	}
+
+	renderJSON(w, code, apiError{Code: code, Message: err.Error()})
+}
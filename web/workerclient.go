@@ -0,0 +1,182 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WorkerClient is the side of the worker API used by a remote worker.
+type WorkerClient struct {
+	baseURL  string
+	token    string
+	workerID string
+	http     *http.Client
+}
+
+// NewWorkerClient creates a client for the coordinator at baseURL, which
+// authenticates with token and claims jobs as workerID.
+func NewWorkerClient(baseURL, token, workerID string) (*WorkerClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid coordinator url %q", baseURL)
+	}
+
+	if workerID == "" {
+		return nil, fmt.Errorf("missing worker id")
+	}
+
+	return &WorkerClient{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		workerID: workerID,
+		// no overall timeout: the uploads can be large, the requests are
+		// bounded by their context
+		http: &http.Client{Transport: http.DefaultTransport},
+	}, nil
+}
+
+// WorkerID returns the id the client claims jobs as.
+func (c *WorkerClient) WorkerID() string {
+	return c.workerID
+}
+
+// Claim takes the next pending job, or returns nil when there is none.
+func (c *WorkerClient) Claim(ctx context.Context) (*Job, error) {
+	var job Job
+
+	found, err := c.do(ctx, http.MethodPost, "claim", workerClaimRequest{WorkerID: c.workerID}, &job)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Heartbeat renews the lease on job id. It returns ErrLeaseLost or
+// ErrNotFound when the job should be abandoned.
+func (c *WorkerClient) Heartbeat(ctx context.Context, id string, results int) error {
+	_, err := c.do(ctx, http.MethodPost, "jobs/"+id+"/heartbeat",
+		workerHeartbeatRequest{WorkerID: c.workerID, Results: results}, nil)
+
+	return err
+}
+
+// UploadResults sends the result file of job id in format, one of
+// ResultFormats.
+func (c *WorkerClient) UploadResults(ctx context.Context, id, format string, body io.Reader) error {
+	endpoint := fmt.Sprintf("jobs/%s/results/%s?worker_id=%s", id, format, url.QueryEscape(c.workerID))
+
+	_, err := c.send(ctx, http.MethodPut, endpoint, "application/octet-stream", body, nil)
+
+	return err
+}
+
+// UploadDiagnosis sends the diagnosis file name of job id, see
+// DiagnosisDir.
+func (c *WorkerClient) UploadDiagnosis(ctx context.Context, id, name string, body io.Reader) error {
+	endpoint := fmt.Sprintf("jobs/%s/diagnosis/%s?worker_id=%s", id, url.PathEscape(name), url.QueryEscape(c.workerID))
+
+	_, err := c.send(ctx, http.MethodPut, endpoint, "application/octet-stream", body, nil)
+
+	return err
+}
+
+// Complete reports the end of job id.
+func (c *WorkerClient) Complete(ctx context.Context, id, status string, stats JobStats) error {
+	_, err := c.do(ctx, http.MethodPost, "jobs/"+id+"/complete",
+		workerCompleteRequest{WorkerID: c.workerID, Status: status, Stats: stats}, nil)
+
+	return err
+}
+
+// ProfileSettings returns the settings saved on the coordinator a job of
+// profile name, and of the tenant of ctx, runs with, see
+// Service.ProfileSettings.
+func (c *WorkerClient) ProfileSettings(ctx context.Context, name string) (Settings, error) {
+	var settings Settings
+
+	query := url.Values{"profile": {name}}
+	if tenant := TenantFrom(ctx); tenant != "" {
+		query.Set("tenant", tenant)
+	}
+
+	_, err := c.do(ctx, http.MethodGet, "settings?"+query.Encode(), nil, &settings)
+
+	return settings, err
+}
+
+func (c *WorkerClient) do(ctx context.Context, method, endpoint string, in, out any) (bool, error) {
+	var body io.Reader
+
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return false, err
+		}
+
+		body = bytes.NewReader(raw)
+	}
+
+	return c.send(ctx, method, endpoint, "application/json", body, out)
+}
+
+// send calls endpoint and decodes the answer into out. It returns false on
+// 204 No Content.
+func (c *WorkerClient) send(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(method))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+workerAPIPrefix+endpoint, body)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
+		return false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, ErrNotFound
+	case resp.StatusCode == http.StatusConflict:
+		return false, ErrLeaseLost
+	case resp.StatusCode >= http.StatusBadRequest:
+		var apiErr apiError
+
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+
+		return false, fmt.Errorf("coordinator: %s %s: %d %s", method, endpoint, resp.StatusCode, apiErr.Message)
+	}
+
+	if out == nil {
+		return true, nil
+	}
+
+	return true, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func requestTimeout(method string) time.Duration {
+	if method == http.MethodPut {
+		return uploadTimeout
+	}
+
+	return 30 * time.Second
+}